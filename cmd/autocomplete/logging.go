@@ -0,0 +1,42 @@
+package main
+
+import (
+    "fmt"
+    "log/slog"
+    "os"
+    "strings"
+
+    "autocomplete/internal/config"
+)
+
+// logLevel a naplózási szint (LOG_LEVEL: debug, info, warn, error); újratöltéskor a
+// handler cseréje nélkül módosítható.
+var logLevel slog.LevelVar
+
+// setupLogging beállítja az alapértelmezett slog naplózót; a log csomag kimenete is ezen keresztül megy.
+// A format (LOG_FORMAT) json vagy a helyi fejlesztéshez olvashatóbb text lehet.
+func setupLogging(level, format string) error {
+    lvl, err := config.ParseLogLevel(level)
+    if err != nil {
+        return err
+    }
+    logLevel.Set(lvl)
+    opts := &slog.HandlerOptions{Level: &logLevel}
+    var handler slog.Handler
+    switch strings.ToLower(format) {
+    case "json":
+        handler = slog.NewJSONHandler(os.Stderr, opts)
+    case "text":
+        handler = slog.NewTextHandler(os.Stderr, opts)
+    default:
+        return fmt.Errorf("ismeretlen naplózási formátum: %q", format)
+    }
+    slog.SetDefault(slog.New(handler))
+    return nil
+}
+
+// fatal hibaszinten naplóz, majd kilép a folyamatból.
+func fatal(msg string, args ...any) {
+    slog.Error(msg, args...)
+    os.Exit(1)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "os"
+    "os/signal"
+    "syscall"
+
+    "autocomplete/internal/index"
+)
+
+const usage = `Használat: autocomplete [-config fájl.yaml] <parancs> [kapcsolók]
+
+Parancsok:
+  serve                          HTTP szerver indítása (alapértelmezett)
+  index create                   az index létrehozása a beállításokkal és a mappinggel
+  index delete -yes              az index törlése az összes dokumentummal
+  index check [-debug]           az index mapping ellenőrzése; hiba vagy eltérés esetén 1-es kód
+  index ensure                   hiányzó index vagy mezők pótlása (mint AUTO_CREATE_INDEX)
+  index seed                     az index létrehozása és feltöltése a beépített mintaadattal
+  import csv [kapcsolók] <fájl>  CSV fájl importálása az indexbe ("-" esetén a standard bemenetről)
+
+A konfigurációt minden parancs a -config (vagy CONFIG_FILE) YAML fájlból olvassa;
+a környezeti változók felülírják a fájlbeli értékeket.
+`
+
+// main az első argumentum alapján választja ki az alparancsot. Argumentum nélkül a
+// korábbi viselkedésnek megfelelően a szervert indítja.
+func main() {
+    global := flag.NewFlagSet("autocomplete", flag.ExitOnError)
+    global.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+    configPath := global.String("config", os.Getenv("CONFIG_FILE"), "YAML konfigurációs fájl")
+    global.Parse(os.Args[1:])
+
+    args := global.Args()
+    if len(args) == 0 {
+        args = []string{"serve"}
+    }
+    switch args[0] {
+    case "serve":
+        runServe(loadConfig(*configPath), *configPath, args[1:])
+    case "index":
+        os.Exit(runIndex(newServices(loadConfig(*configPath)), args[1:]))
+    case "import":
+        os.Exit(runImport(newServices(loadConfig(*configPath)), args[1:]))
+    case "help", "-h", "-help", "--help":
+        fmt.Print(usage)
+    default:
+        fmt.Fprintf(os.Stderr, "Ismeretlen parancs: %q\n\n%s", args[0], usage)
+        os.Exit(2)
+    }
+}
+
+// commandContext a CLI parancsokhoz ad SIGINT/SIGTERM jelzésre megszakított kontextust.
+func commandContext() (context.Context, context.CancelFunc) {
+    return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+// runIndex az "index" alparancsokat hajtja végre, és a kilépési kódot adja vissza.
+func runIndex(svc *services, args []string) int {
+    if len(args) == 0 {
+        fmt.Fprint(os.Stderr, usage)
+        return 2
+    }
+    if svc.indexes == nil {
+        fmt.Fprintln(os.Stderr, "Az index parancsokhoz OpenSearch háttérrendszer szükséges (BACKEND=opensearch)")
+        return 2
+    }
+    ctx, stop := commandContext()
+    defer stop()
+
+    switch args[0] {
+    case "create":
+        fs := flag.NewFlagSet("index create", flag.ExitOnError)
+        fs.Parse(args[1:])
+        if err := svc.indexes.Create(ctx); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            return 1
+        }
+    case "delete":
+        fs := flag.NewFlagSet("index delete", flag.ExitOnError)
+        yes := fs.Bool("yes", false, "a törlés megerősítése")
+        fs.Parse(args[1:])
+        if !*yes {
+            fmt.Fprintf(os.Stderr, "Az index (%s) törléséhez add meg a -yes kapcsolót\n", svc.indexes.Name())
+            return 2
+        }
+        if err := svc.indexes.Delete(ctx); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            return 1
+        }
+    case "check":
+        fs := flag.NewFlagSet("index check", flag.ExitOnError)
+        debug := fs.Bool("debug", false, "a nyers OpenSearch válaszok kiírása")
+        fs.Parse(args[1:])
+        res, err := svc.indexes.Check(ctx)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            return 1
+        }
+        if !*debug {
+            res.Debug = ""
+        }
+        printJSON(os.Stdout, res)
+        if !res.FieldMappingExists || res.Drift {
+            return 1
+        }
+    case "ensure":
+        fs := flag.NewFlagSet("index ensure", flag.ExitOnError)
+        fs.Parse(args[1:])
+        if err := svc.indexes.Ensure(ctx); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            return 1
+        }
+    case "seed":
+        fs := flag.NewFlagSet("index seed", flag.ExitOnError)
+        fs.Parse(args[1:])
+        summary, err := svc.indexes.Seed(ctx)
+        printJSON(os.Stdout, summary)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            return 1
+        }
+    default:
+        fmt.Fprintf(os.Stderr, "Ismeretlen index parancs: %q\n\n%s", args[0], usage)
+        return 2
+    }
+    return 0
+}
+
+// runImport az "import csv" alparancs: a fájlt ugyanazzal a BulkIndexer-rel tölti be, mint a
+// POST /api/admin/import/csv végpont, és az összesítőt JSON-ként írja ki. Ha volt hibás rekord
+// vagy az import megszakadt, 1-es kóddal lép ki.
+func runImport(svc *services, args []string) int {
+    if len(args) == 0 || args[0] != "csv" {
+        fmt.Fprint(os.Stderr, usage)
+        return 2
+    }
+    if svc.indexes == nil {
+        fmt.Fprintln(os.Stderr, "Az importhoz OpenSearch háttérrendszer szükséges (BACKEND=opensearch)")
+        return 2
+    }
+    fs := flag.NewFlagSet("import csv", flag.ExitOnError)
+    delimiter := fs.String("delimiter", ",", "mezőelválasztó karakter")
+    mappingSpec := fs.String("mapping", "", "fejléc-leképezés a CSV_HEADER_MAPPING formátumában")
+    dryRun := fs.Bool("dry-run", false, "csak ellenőrzés, az indexbe nem ír")
+    fs.Parse(args[1:])
+    if fs.NArg() != 1 {
+        fmt.Fprintln(os.Stderr, "Használat: autocomplete import csv [-delimiter ,] [-mapping spec] [-dry-run] <fájl>")
+        return 2
+    }
+
+    runes := []rune(*delimiter)
+    if len(runes) != 1 {
+        fmt.Fprintln(os.Stderr, "A -delimiter egyetlen karakter lehet")
+        return 2
+    }
+    mapping := svc.cfg.Import.CSVHeaderMapping
+    if *mappingSpec != "" {
+        var err error
+        if mapping, err = index.ParseHeaderMapping(*mappingSpec); err != nil {
+            fmt.Fprintln(os.Stderr, "Hibás -mapping:", err)
+            return 2
+        }
+    }
+
+    var input io.Reader = os.Stdin
+    if path := fs.Arg(0); path != "-" {
+        f, err := os.Open(path)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            return 1
+        }
+        defer f.Close()
+        input = f
+    }
+
+    ctx, stop := commandContext()
+    defer stop()
+    indexer := svc.indexes.NewBulkIndexer(ctx)
+    indexer.DryRun = *dryRun
+    indexer.OnFlush = func(s index.BulkSummary) {
+        fmt.Fprintf(os.Stderr, "feldolgozva: %d, indexelve: %d, hibás: %d\n", s.Total, s.Indexed, s.Failed)
+    }
+    err := index.ImportCSV(input, runes[0], mapping, indexer)
+    if err == nil {
+        err = indexer.Flush()
+    }
+    summary := indexer.Summary()
+    if err != nil {
+        summary.Error = err.Error()
+    }
+    printJSON(os.Stdout, summary)
+    if err != nil || summary.Failed > 0 {
+        return 1
+    }
+    return 0
+}
+
+// printJSON behúzott JSON-ként írja ki az értéket.
+func printJSON(w io.Writer, v interface{}) {
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+    encoder.Encode(v)
+}
@@ -0,0 +1,296 @@
+package main
+
+import (
+    "context"
+    "expvar"
+    "flag"
+    "io"
+    "log/slog"
+    "os"
+    "os/signal"
+    "reflect"
+    "syscall"
+    "time"
+
+    "autocomplete/internal/changefeed"
+    "autocomplete/internal/config"
+    "autocomplete/internal/httpapi"
+    "autocomplete/internal/index"
+    "autocomplete/internal/mappingwatch"
+    "autocomplete/internal/resync"
+    "autocomplete/internal/suggest"
+)
+
+// runServe a "serve" alparancs: elindítja a publikus, az admin és a debug HTTP szervert,
+// SIGHUP jelzésre újratölti a konfiguráció módosítható részét, SIGINT/SIGTERM jelzésre
+// pedig szabályosan leállítja a szervereket.
+func runServe(cfg config.Config, configPath string, args []string) {
+    fs := flag.NewFlagSet("serve", flag.ExitOnError)
+    fs.Parse(args)
+
+    svc := newServices(cfg)
+    if cfg.Index.AutoCreate && svc.indexes != nil {
+        if err := svc.indexes.Ensure(context.Background()); err != nil {
+            fatal("Index bootstrap failed", "index", svc.indexes.Name(), "error", err)
+        }
+    }
+    server := httpapi.New(cfg, svc.suggester, svc.indexes)
+    expvar.Publish("concurrency", expvar.Func(server.ConcurrencyStats))
+    expvar.Publish("experiment", expvar.Func(server.ExperimentStats))
+
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+    watchReloadSignal(ctx.Done(), &reloader{path: configPath, fixed: cfg.WithoutReloadable(), search: engineOptions(cfg), svc: svc, server: server})
+    if cfg.Cache.Warmup {
+        startCacheWarmup(ctx, cfg.Cache.WarmupPrefixes, svc.suggester, server)
+    }
+    if cfg.Materialize.Interval > 0 {
+        go scheduleMaterialize(ctx, cfg.Materialize.Interval, svc.suggester)
+    }
+    flushDone := make(chan struct{})
+    go flushSelections(ctx, cfg.Popularity.FlushInterval, svc.suggester, flushDone)
+    consumerDone := make(chan struct{})
+    startChangeConsumer(ctx, cfg.Kafka, svc.indexes, consumerDone)
+    startResync(ctx, cfg, svc.indexes, server)
+    startMappingCheck(ctx, cfg.MappingCheck, svc.indexes)
+    if svc.client != nil {
+        svc.client.StartPinging(ctx, cfg.OpenSearch.PingInterval)
+    }
+
+    if err := server.Serve(ctx); err != nil {
+        fatal("Server error", "error", err)
+    }
+    <-flushDone
+    <-consumerDone
+    // A folyamatban lévő kérések lezárultak, az OpenSearch felé nyitva maradt tétlen
+    // kapcsolatokat is lezárjuk.
+    if svc.client != nil {
+        svc.client.CloseIdleConnections()
+    }
+    if closer, ok := svc.suggester.(io.Closer); ok {
+        if err := closer.Close(); err != nil {
+            slog.Error("Backend close failed", "error", err)
+        }
+    }
+    slog.Info("Server stopped")
+}
+
+// startChangeConsumer KAFKA_BROKERS esetén a háttérben fogyasztja a címváltozás-eseményeket (lásd
+// changefeed), és a számlálóit "changefeed" néven közzéteszi az expvar metrikák között. Ha az
+// olvasó nem hozható létre, a folyamat kilép; ha a fogyasztás hibával áll le, csak naplózza. A
+// done csatornát a fogyasztó leállása után (vagy azonnal, ha nincs beállítva) lezárja.
+func startChangeConsumer(ctx context.Context, cfg config.KafkaConfig, indexes *index.Manager, done chan<- struct{}) {
+    if len(cfg.Brokers) == 0 {
+        close(done)
+        return
+    }
+    reader, err := changefeed.NewKafkaReader(cfg.Brokers, cfg.Topic, cfg.GroupID)
+    if err != nil {
+        fatal("Kafka consumer setup failed", "error", err)
+    }
+    consumer := changefeed.New(reader, indexes, cfg.BatchSize, cfg.FlushInterval)
+    expvar.Publish("changefeed", expvar.Func(func() interface{} { return consumer.Stats() }))
+    slog.Info("Kafka consumer started", "brokers", cfg.Brokers, "topic", cfg.Topic, "group_id", cfg.GroupID)
+    go func() {
+        defer close(done)
+        defer reader.Close()
+        if err := consumer.Run(ctx); err != nil {
+            slog.Error("Kafka consumer stopped", "error", err)
+            return
+        }
+        slog.Info("Kafka consumer stopped")
+    }()
+}
+
+// startResync RESYNC_SOURCE esetén beállítja a teljes újraszinkronizálást az admin végponthoz, és
+// RESYNC_SCHEDULE esetén a háttérben a ctx lezárásáig ütemezi (lásd resync.Syncer.Run).
+func startResync(ctx context.Context, cfg config.Config, indexes *index.Manager, server *httpapi.Server) {
+    if cfg.Resync.Source == "" || indexes == nil {
+        return
+    }
+    syncer := resync.New(indexes, resync.Options{
+        Source:           cfg.Resync.Source,
+        S3:               resync.S3Config{Region: cfg.Resync.S3Region, Endpoint: cfg.Resync.S3Endpoint},
+        CSVHeaderMapping: cfg.Import.CSVHeaderMapping,
+        Timeout:          cfg.Resync.Timeout,
+        DeleteOld:        cfg.Resync.DeleteOld,
+    })
+    server.SetResync(syncer)
+    if cfg.Resync.Schedule == "" {
+        return
+    }
+    // A config.Load már ellenőrizte a kifejezést.
+    schedule, _ := resync.ParseSchedule(cfg.Resync.Schedule)
+    slog.Info("Resync scheduled", "schedule", schedule.String(), "next_run", schedule.Next(time.Now()))
+    go syncer.Run(ctx, schedule)
+}
+
+// startMappingCheck MAPPING_CHECK_INTERVAL > 0 esetén a háttérben a ctx lezárásáig ütemezi az
+// index mappingjének ellenőrzését (lásd mappingwatch), és az eredményt "mapping_ok" és
+// "mapping_check" néven közzéteszi az expvar metrikák között. Indexkezelő nélkül nem csinál semmit.
+func startMappingCheck(ctx context.Context, cfg config.MappingCheckConfig, indexes *index.Manager) {
+    if cfg.Interval <= 0 || indexes == nil {
+        return
+    }
+    watcher := mappingwatch.New(indexes, mappingwatch.Options{
+        Interval:   cfg.Interval,
+        Timeout:    cfg.Timeout,
+        WebhookURL: cfg.WebhookURL,
+    })
+    expvar.Publish("mapping_ok", expvar.Func(watcher.Gauge))
+    expvar.Publish("mapping_check", expvar.Func(func() interface{} { return watcher.Status() }))
+    slog.Info("Mapping check scheduled", "index", indexes.Name(), "interval", cfg.Interval.String(), "webhook", cfg.WebhookURL != "")
+    go watcher.Run(ctx)
+}
+
+// startCacheWarmup a háttérben előmelegíti a javaslat-gyorsítótárat; amíg fut, a /healthz
+// 503-at ad, így a readiness probe csak utána enged forgalmat a példányra. Gyorsítótár nélküli
+// háttérrendszernél nem csinál semmit.
+func startCacheWarmup(ctx context.Context, prefixes []string, suggester suggest.Suggester, server *httpapi.Server) {
+    warmer, ok := suggester.(suggest.CacheWarmer)
+    if !ok {
+        slog.Info("Cache warm-up skipped, the backend has no cache")
+        return
+    }
+    server.SetWarmingUp(true)
+    go func() {
+        defer server.SetWarmingUp(false)
+        summary, err := warmer.WarmCache(ctx, prefixes)
+        if err != nil {
+            slog.Warn("Cache warm-up failed", "error", err, "queries", summary.Queries)
+            return
+        }
+        slog.Info("Cache warm-up finished", "prefixes", summary.Prefixes, "queries", summary.Queries,
+            "failed", summary.Failed, "duration", summary.Duration.String())
+    }()
+}
+
+// scheduleMaterialize interval időközönként elindítja a javaslatok előszámítását (az elsőt
+// azonnal), amíg a ctx le nem zárul.
+func scheduleMaterialize(ctx context.Context, interval time.Duration, suggester suggest.Suggester) {
+    if _, ok := suggester.(suggest.Materializer); !ok {
+        slog.Info("Materialization schedule skipped, the backend does not support it")
+        return
+    }
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        startMaterialize(suggester)
+        select {
+        case <-ticker.C:
+        case <-ctx.Done():
+            return
+        }
+    }
+}
+
+// startMaterialize elindítja az előszámítást, ha a háttérrendszer támogatja; a már futó
+// előszámítást nem indítja újra.
+func startMaterialize(suggester suggest.Suggester) {
+    materializer, ok := suggester.(suggest.Materializer)
+    if !ok {
+        return
+    }
+    if _, err := materializer.StartMaterialize(); err != nil {
+        slog.Warn("Materialization not started", "error", err)
+    }
+}
+
+// selectionFlusher a kiválasztásokat pufferelő háttérrendszerek (suggest.Engine).
+type selectionFlusher interface {
+    FlushSelections(ctx context.Context) error
+}
+
+// flushSelections interval időközönként az indexbe írja a pufferelt kiválasztásokat; a ctx
+// lezárásakor még egyszer üríti a puffert, majd lezárja a done csatornát.
+func flushSelections(ctx context.Context, interval time.Duration, suggester suggest.Suggester, done chan<- struct{}) {
+    defer close(done)
+    flusher, ok := suggester.(selectionFlusher)
+    if !ok {
+        return
+    }
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ticker.C:
+            flusher.FlushSelections(ctx)
+        case <-ctx.Done():
+            flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+            defer cancel()
+            flusher.FlushSelections(flushCtx)
+            return
+        }
+    }
+}
+
+// optionsSetter a futás közben átállítható háttérrendszerek (suggest.Engine, memory.Backend, sqlite.Backend).
+type optionsSetter interface {
+    SetOptions(suggest.Options)
+}
+
+// reloader a futó szolgáltatás módosítható beállításait frissíti a konfigurációs fájlból.
+type reloader struct {
+    // path a -config / CONFIG_FILE fájl, amelyet SIGHUP-ra újraolvasunk.
+    path string
+    // fixed az induláskori konfiguráció rögzített része; újratöltéskor ehhez hasonlítunk,
+    // hogy a csak újraindítással érvényesülő változásokra figyelmeztessünk.
+    fixed config.Config
+    // search az utoljára alkalmazott keresési beállítások; ha az eredményt befolyásoló részük
+    // (lásd suggest.Options.ResultKey) változik, a javaslat-gyorsítótár ürül.
+    search suggest.Options
+    svc    *services
+    server *httpapi.Server
+}
+
+// reload újraolvassa a konfigurációs fájlt és a környezeti változókat, majd alkalmazza a
+// módosítható beállításokat. Érvénytelen konfigurációnál a korábbi marad érvényben. A rögzített
+// beállítások (portok, OpenSearch kapcsolat, index, cache méret stb.) változását csak naplózzuk,
+// ezek újraindítás után lépnek életbe. Ha a keresési beállítások eredményt befolyásoló része
+// változik, a javaslat-gyorsítótárat üríti, hogy a már tárolt előtagokra is az új beállítások
+// érvényesüljenek.
+func (r *reloader) reload() {
+    cfg, err := config.Load(r.path)
+    if err != nil {
+        slog.Error("Config reload failed, keeping previous configuration", "path", r.path, "error", err)
+        return
+    }
+    if !reflect.DeepEqual(cfg.WithoutReloadable(), r.fixed) {
+        slog.Warn("Config reload: non-reloadable settings changed, restart required to apply them", "path", r.path)
+    }
+    lvl, _ := config.ParseLogLevel(cfg.Logging.Level)
+    logLevel.Set(lvl)
+    if r.svc.cache != nil {
+        r.svc.cache.SetTTL(cfg.Cache.TTL)
+    }
+    search, server := engineOptions(cfg), httpapi.OptionsFrom(cfg)
+    if s, ok := r.svc.suggester.(optionsSetter); ok {
+        s.SetOptions(search)
+    }
+    if r.svc.cache != nil && search.ResultKey() != r.search.ResultKey() {
+        slog.Info("Cache flush: search settings changed", "flushed", r.svc.cache.Flush())
+    }
+    r.search = search
+    previousServer := r.server.Options()
+    r.server.SetOptions(server)
+    slog.Info("Config reloaded", "path", r.path,
+        "log_level", cfg.Logging.Level, "cache_ttl", cfg.Cache.TTL.String(), "search", search,
+        "previous_server", previousServer, "server", server)
+}
+
+// watchReloadSignal SIGHUP jelzésre újratölti a konfigurációt, amíg a stop csatorna nyitva van.
+func watchReloadSignal(stop <-chan struct{}, r *reloader) {
+    hup := make(chan os.Signal, 1)
+    signal.Notify(hup, syscall.SIGHUP)
+    go func() {
+        defer signal.Stop(hup)
+        for {
+            select {
+            case <-hup:
+                r.reload()
+            case <-stop:
+                return
+            }
+        }
+    }()
+}
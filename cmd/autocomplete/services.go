@@ -0,0 +1,165 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "expvar"
+    "log/slog"
+
+    "autocomplete/internal/cache"
+    "autocomplete/internal/config"
+    "autocomplete/internal/index"
+    "autocomplete/internal/memory"
+    "autocomplete/internal/opensearch"
+    "autocomplete/internal/sqlite"
+    "autocomplete/internal/suggest"
+)
+
+// services a konfigurációból felépített, a parancsok között megosztott komponensek. Memóriabeli
+// és SQLite háttérrendszernél (BACKEND=memory, BACKEND=sqlite) csak a suggester van kitöltve.
+type services struct {
+    cfg       config.Config
+    client    *opensearch.Client
+    cache     *cache.LRU[suggest.Set]
+    indexes   *index.Manager
+    suggester suggest.Suggester
+}
+
+// loadConfig betölti a konfigurációt (lásd config.Load), és beállítja a naplózást. Ha bármely
+// beállítás hiányzik vagy érvénytelen, mindet naplózza, és a folyamat kilép.
+func loadConfig(path string) config.Config {
+    cfg, err := config.Load(path)
+    if logErr := setupLogging(cfg.Logging.Level, cfg.Logging.Format); logErr != nil {
+        setupLogging("info", "json")
+    }
+    var configErrs config.Errors
+    if errors.As(err, &configErrs) {
+        fatal("Invalid configuration", "errors", []string(configErrs))
+    }
+    if err != nil {
+        fatal("Invalid configuration", "error", err)
+    }
+    return cfg
+}
+
+// clientConfig az OpenSearch kliens beállításait állítja össze a konfigurációból.
+func clientConfig(cfg config.Config) opensearch.Config {
+    osConfig := opensearch.DefaultConfig()
+    osConfig.URLs = cfg.OpenSearch.URLs()
+    osConfig.Username = cfg.OpenSearch.User
+    osConfig.Password = cfg.OpenSearch.Password
+    // A config.Load már betöltötte egyszer a tanúsítványokat, itt nem várunk hibát.
+    osConfig.TLSClientConfig, _ = cfg.OpenSearch.TLSConfig()
+    if cfg.OpenSearch.InsecureSkipVerify {
+        slog.Warn("OpenSearch TLS certificate verification is disabled (OPENSEARCH_INSECURE_SKIP_VERIFY)")
+    }
+    osConfig.Timeout = cfg.OpenSearch.Timeout
+    osConfig.MaxIdleConnsPerHost = cfg.OpenSearch.MaxIdleConnsPerHost
+    osConfig.MaxRetries = cfg.OpenSearch.MaxRetries
+    osConfig.RetryBaseDelay = cfg.OpenSearch.RetryBaseDelay
+    osConfig.RetryMaxDelay = cfg.OpenSearch.RetryMaxDelay
+    osConfig.BreakerThreshold = cfg.OpenSearch.CircuitFailureThreshold
+    osConfig.BreakerOpenTimeout = cfg.OpenSearch.CircuitOpenTimeout
+    osConfig.SlowQueryThreshold = cfg.OpenSearch.SlowQueryThreshold
+    osConfig.Compression = cfg.OpenSearch.Compression
+    osConfig.MaxConcurrent = cfg.Concurrency.OpenSearch
+    osConfig.QueueTimeout = cfg.Concurrency.QueueTimeout
+    osConfig.Mode = cfg.OpenSearch.Mode
+    osConfig.FixtureDir = cfg.OpenSearch.FixtureDir
+    if cfg.OpenSearch.Mode != opensearch.ModeLive {
+        slog.Warn("OpenSearch development mode enabled (OPENSEARCH_MODE)", "mode", cfg.OpenSearch.Mode, "fixture_dir", cfg.OpenSearch.FixtureDir)
+    }
+    return osConfig
+}
+
+// engineOptions kiemeli a konfigurációból a javaslatmotor módosítható beállításait. A
+// rangsorolási folyamatot a config.Load már ellenőrizte.
+func engineOptions(cfg config.Config) suggest.Options {
+    ranking, _ := suggest.ParsePipeline(cfg.Search.RankingPipeline)
+    return suggest.Options{
+        QueryMode:              cfg.Search.QueryMode,
+        SuggestionLimit:        cfg.Search.SuggestionLimit,
+        FuzzyFallback:          cfg.Search.FuzzyFallback,
+        KeyboardTypoMinResults: cfg.Search.KeyboardTypoMinResults,
+        FoldAccents:            cfg.Search.FoldAccents,
+        GeoScale:               cfg.Search.GeoScale,
+        WeightBoost:            cfg.Search.WeightBoost,
+        Ranking:                ranking,
+        CanaryIndex:            cfg.Search.CanaryIndex,
+        CanaryPercent:          cfg.Search.CanaryPercent,
+        StaleWhileRevalidate:   cfg.Cache.StaleWhileRevalidate,
+        StaleTimeout:           cfg.Cache.StaleTimeout,
+
+        MaterializedServe:        cfg.Materialize.Serve,
+        MaterializedPrefixLength: cfg.Materialize.MaxPrefixLength,
+        PopularityRanking:        cfg.Popularity.Ranking,
+    }
+}
+
+// newServices a már ellenőrzött konfigurációból létrehozza az OpenSearch klienst, a
+// gyorsítótárat, az indexkezelőt és a javaslatmotort, és közzéteszi az expvar metrikákat.
+// BACKEND=memory esetén csak betölti az adatfájlt, BACKEND=sqlite esetén megnyitja az adatbázist
+// (üres adatbázisba betöltve az esetleges adatfájlt); ha ez nem sikerül, a folyamat kilép.
+func newServices(cfg config.Config) *services {
+    svc := &services{cfg: cfg}
+    switch cfg.Backend.Type {
+    case config.BackendMemory:
+        backend, err := memory.Load(cfg.Backend.DataFile, cfg.Import.CSVHeaderMapping, engineOptions(cfg))
+        if err != nil {
+            fatal("Memory backend load failed", "error", err)
+        }
+        svc.suggester = backend
+        return svc
+    case config.BackendSQLite:
+        svc.suggester = openSQLite(cfg)
+        return svc
+    }
+    svc.client = opensearch.New(clientConfig(cfg))
+    expvar.Publish("opensearch", expvar.Func(func() interface{} { return svc.client.Stats() }))
+
+    svc.cache = cache.New[suggest.Set](cfg.Cache.Size, cfg.Cache.TTL)
+    expvar.Publish("cache", expvar.Func(func() interface{} { return svc.cache.Stats() }))
+
+    svc.indexes = index.New(svc.client, index.DefaultName)
+    svc.indexes.BulkBatchSize = cfg.Import.BulkBatchSize
+    svc.indexes.DuplicatePolicy = cfg.Import.DuplicatePolicy
+    svc.indexes.FieldStrategy = cfg.Index.FieldStrategy
+    svc.indexes.SnapshotRepository = cfg.Index.SnapshotRepository
+    svc.indexes.CardinalityPrecision = cfg.Index.CardinalityPrecision
+    // Az alias átváltása után a régi indexből származó javaslatok elavultak; ha az előszámítás
+    // használatban van, a lookup indexet is újraépítjük.
+    svc.indexes.OnSwap = func() {
+        slog.Info("Cache flush", "flushed", svc.cache.Flush())
+        if cfg.Materialize.Serve || cfg.Materialize.Interval > 0 {
+            startMaterialize(svc.suggester)
+        }
+    }
+    engine := suggest.New(svc.client, svc.indexes.Name(), svc.cache, engineOptions(cfg))
+    expvar.Publish("index_routing", expvar.Func(func() interface{} { return engine.RouteStats() }))
+    svc.suggester = engine
+    return svc
+}
+
+// openSQLite megnyitja az SQLite adatbázist, és ha üres, betölti a BACKEND_DATA_FILE címlistát.
+func openSQLite(cfg config.Config) *sqlite.Backend {
+    backend, err := sqlite.Open(cfg.Backend.SQLitePath, engineOptions(cfg))
+    if err != nil {
+        fatal("SQLite backend open failed", "error", err)
+    }
+    if cfg.Backend.DataFile == "" {
+        return backend
+    }
+    ctx := context.Background()
+    empty, err := backend.Empty(ctx)
+    if err != nil {
+        fatal("SQLite backend open failed", "error", err)
+    }
+    if !empty {
+        slog.Info("SQLite database is not empty, data file skipped", "path", cfg.Backend.SQLitePath, "dataFile", cfg.Backend.DataFile)
+        return backend
+    }
+    if err := backend.Import(ctx, cfg.Backend.DataFile, cfg.Import.CSVHeaderMapping); err != nil {
+        fatal("SQLite backend load failed", "error", err)
+    }
+    return backend
+}
@@ -0,0 +1,7 @@
+//go:build sqlite
+
+package main
+
+// A BACKEND=sqlite háttérrendszer drivere. Cgo-t igényel, ezért csak a "sqlite" build taggel
+// kerül a binárisba: go build -tags sqlite ./cmd/autocomplete
+import _ "github.com/mattn/go-sqlite3"
@@ -0,0 +1,196 @@
+// Package analytics a javaslatkérések lekérdezéseit gyűjti a memóriában, időablakos
+// összesítésekhez (leggyakoribb és találat nélküli lekérdezések).
+package analytics
+
+import (
+    "sort"
+    "sync"
+    "time"
+)
+
+// BucketSize az összesítés időfelbontása: a lekérdezéseket ekkora időszeletenként számoljuk,
+// így az időablak is ennyire pontos.
+const BucketSize = 10 * time.Minute
+
+// maxQueriesPerBucket egy időszeletben nyilvántartott különböző lekérdezések legnagyobb száma;
+// ezen felül az új lekérdezéseket csak az összesítő számláló tartalmazza.
+const maxQueriesPerBucket = 5000
+
+// key egy lekérdezés a javaslat fajtájával és a kísérleti változattal együtt.
+type key struct {
+    kind    string
+    variant string
+    query   string
+}
+
+// counts egy lekérdezés számlálói egy időszeletben.
+type counts struct {
+    total int
+    zero  int
+    fuzzy int
+}
+
+type bucket struct {
+    start   time.Time
+    total   int
+    queries map[key]*counts
+}
+
+// Store időszeletenként számolja a lekérdezéseket, és a Retention-nél régebbi szeleteket
+// eldobja. Konkurens használatra biztonságos.
+type Store struct {
+    mu        sync.Mutex
+    retention time.Duration
+    buckets   []*bucket
+}
+
+// New létrehoz egy gyűjtőt, amely retention ideig őrzi meg a lekérdezéseket.
+func New(retention time.Duration) *Store {
+    return &Store{retention: retention}
+}
+
+// Retention a megőrzési idő, egyben a lekérdezhető leghosszabb időablak.
+func (s *Store) Retention() time.Duration {
+    return s.retention
+}
+
+// Record egy javaslatkérést rögzít: a kind a javaslat fajtája, a query a normalizált
+// lekérdezés, a results a visszaadott javaslatok száma, a fuzzy pedig jelzi, hogy a javaslatok
+// az elgépelés-tűrő tartalékból származnak. A variant a kérés A/B kísérleti változata (üres, ha a
+// kérés nem tartozik kísérlethez).
+func (s *Store) Record(kind, variant, query string, results int, fuzzy bool) {
+    now := time.Now()
+    start := now.Truncate(BucketSize)
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.expire(now)
+    var b *bucket
+    if n := len(s.buckets); n > 0 && s.buckets[n-1].start.Equal(start) {
+        b = s.buckets[n-1]
+    } else {
+        b = &bucket{start: start, queries: map[key]*counts{}}
+        s.buckets = append(s.buckets, b)
+    }
+    b.total++
+    k := key{kind: kind, variant: variant, query: query}
+    c, ok := b.queries[k]
+    if !ok {
+        if len(b.queries) >= maxQueriesPerBucket {
+            return
+        }
+        c = &counts{}
+        b.queries[k] = c
+    }
+    c.total++
+    if results == 0 {
+        c.zero++
+    }
+    if fuzzy {
+        c.fuzzy++
+    }
+}
+
+// expire eldobja a megőrzési időnél régebbi időszeleteket; a hívó tartja a zárat.
+func (s *Store) expire(now time.Time) {
+    cutoff := now.Add(-s.retention)
+    i := 0
+    for i < len(s.buckets) && !s.buckets[i].start.Add(BucketSize).After(cutoff) {
+        i++
+    }
+    s.buckets = s.buckets[i:]
+}
+
+// QueryStats egy lekérdezés összesített számai az időablakban. A Variant csak kísérleti
+// változatra szűkített összesítésben szerepel.
+type QueryStats struct {
+    Query       string `json:"query"`
+    Kind        string `json:"kind"`
+    Variant     string `json:"variant,omitempty"`
+    Count       int    `json:"count"`
+    ZeroResults int    `json:"zeroResults"`
+    Fuzzy       int    `json:"fuzzy"`
+}
+
+// Summary egy időablak összesítése: a Total az összes rögzített kérés, a Distinct a
+// nyilvántartott különböző lekérdezések száma, a Queries a kért sorrendben a legfeljebb
+// limit darab lekérdezés.
+type Summary struct {
+    From     time.Time    `json:"from"`
+    To       time.Time    `json:"to"`
+    Total    int          `json:"total"`
+    Distinct int          `json:"distinct"`
+    Queries  []QueryStats `json:"queries"`
+}
+
+// Top az időablak leggyakoribb lekérdezései gyakoriság szerint csökkenő sorrendben. Üres kind
+// esetén minden fajtát, üres variant esetén minden kísérleti változatot (és a kísérleten kívüli
+// kéréseket) összevonva figyelembe vesz.
+func (s *Store) Top(window time.Duration, kind, variant string, limit int) Summary {
+    return s.summarize(window, kind, variant, limit, false)
+}
+
+// ZeroResults az időablak találat nélküli lekérdezései a találat nélküli kérések száma szerint
+// csökkenő sorrendben; ezekből derülnek ki a hiányzó települések és a szükséges szinonimák.
+func (s *Store) ZeroResults(window time.Duration, kind, variant string, limit int) Summary {
+    return s.summarize(window, kind, variant, limit, true)
+}
+
+func (s *Store) summarize(window time.Duration, kind, variant string, limit int, zeroOnly bool) Summary {
+    now := time.Now()
+    from := now.Add(-window).Truncate(BucketSize)
+    s.mu.Lock()
+    s.expire(now)
+    summary := Summary{From: from, To: now}
+    merged := map[key]*QueryStats{}
+    for _, b := range s.buckets {
+        if b.start.Before(from) {
+            continue
+        }
+        summary.Total += b.total
+        for k, c := range b.queries {
+            if (kind != "" && k.kind != kind) || (variant != "" && k.variant != variant) {
+                continue
+            }
+            // Változatra szűkítés nélkül a változatok számai összeadódnak.
+            mk := key{kind: k.kind, variant: variant, query: k.query}
+            stats, ok := merged[mk]
+            if !ok {
+                stats = &QueryStats{Query: k.query, Kind: k.kind, Variant: variant}
+                merged[mk] = stats
+            }
+            stats.Count += c.total
+            stats.ZeroResults += c.zero
+            stats.Fuzzy += c.fuzzy
+        }
+    }
+    s.mu.Unlock()
+
+    summary.Distinct = len(merged)
+    summary.Queries = []QueryStats{}
+    for _, stats := range merged {
+        if zeroOnly && stats.ZeroResults == 0 {
+            continue
+        }
+        summary.Queries = append(summary.Queries, *stats)
+    }
+    rank := func(q QueryStats) int {
+        if zeroOnly {
+            return q.ZeroResults
+        }
+        return q.Count
+    }
+    sort.Slice(summary.Queries, func(i, j int) bool {
+        a, b := summary.Queries[i], summary.Queries[j]
+        if rank(a) != rank(b) {
+            return rank(a) > rank(b)
+        }
+        if a.Query != b.Query {
+            return a.Query < b.Query
+        }
+        return a.Kind < b.Kind
+    })
+    if len(summary.Queries) > limit {
+        summary.Queries = summary.Queries[:limit]
+    }
+    return summary
+}
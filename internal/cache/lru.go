@@ -0,0 +1,146 @@
+// Package cache egy egyszerű, konkurens használatra biztonságos LRU gyorsítótárat
+// ad lejárati idővel (TTL), találati metrikákkal.
+package cache
+
+import (
+    "container/list"
+    "sync"
+    "time"
+)
+
+// Stats a gyorsítótár metrikái.
+type Stats struct {
+    Size      int     `json:"size"`
+    Capacity  int     `json:"capacity"`
+    Hits      int64   `json:"hits"`
+    Misses    int64   `json:"misses"`
+    Evictions int64   `json:"evictions"`
+    HitRate   float64 `json:"hitRate"`
+}
+
+type entry[V any] struct {
+    key     string
+    value   V
+    expires time.Time
+}
+
+// LRU egy legfeljebb capacity elemet tartó gyorsítótár; a legrégebben használt elem
+// kerül ki elsőként, a ttl-nél régebbi elemeket pedig lejártnak tekinti.
+// A nulla kapacitású LRU semmit sem tárol.
+type LRU[V any] struct {
+    mu       sync.Mutex
+    capacity int
+    ttl      time.Duration
+    items    map[string]*list.Element
+    order    *list.List
+
+    hits      int64
+    misses    int64
+    evictions int64
+}
+
+// New létrehoz egy LRU gyorsítótárat. ttl <= 0 esetén az elemek nem járnak le.
+func New[V any](capacity int, ttl time.Duration) *LRU[V] {
+    return &LRU[V]{
+        capacity: capacity,
+        ttl:      ttl,
+        items:    make(map[string]*list.Element),
+        order:    list.New(),
+    }
+}
+
+// Get visszaadja a kulcshoz tartozó, még le nem járt értéket. A lejárt elemeket nem törli,
+// hogy GetStale-lel tartalékként még elérhetők maradjanak, amíg az LRU ki nem szorítja őket.
+func (c *LRU[V]) Get(key string) (V, bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    var zero V
+    el, ok := c.items[key]
+    if !ok {
+        c.misses++
+        return zero, false
+    }
+    e := el.Value.(*entry[V])
+    if c.ttl > 0 && time.Now().After(e.expires) {
+        c.misses++
+        return zero, false
+    }
+    c.order.MoveToFront(el)
+    c.hits++
+    return e.value, true
+}
+
+// GetStale a lejárati időtől függetlenül visszaadja a kulcshoz tartozó értéket, ha még
+// a gyorsítótárban van. A találati metrikákat nem módosítja.
+func (c *LRU[V]) GetStale(key string) (V, bool) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    el, ok := c.items[key]
+    if !ok {
+        var zero V
+        return zero, false
+    }
+    return el.Value.(*entry[V]).value, true
+}
+
+// Set eltárolja az értéket; ha a gyorsítótár megtelt, a legrégebben használt elemet kiveszi.
+func (c *LRU[V]) Set(key string, value V) {
+    if c.capacity <= 0 {
+        return
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    expires := time.Now().Add(c.ttl)
+    if el, ok := c.items[key]; ok {
+        e := el.Value.(*entry[V])
+        e.value = value
+        e.expires = expires
+        c.order.MoveToFront(el)
+        return
+    }
+    c.items[key] = c.order.PushFront(&entry[V]{key: key, value: value, expires: expires})
+    for c.order.Len() > c.capacity {
+        c.removeElement(c.order.Back())
+        c.evictions++
+    }
+}
+
+// SetTTL módosítja a lejárati időt. A már tárolt elemek lejárata nem változik, az új
+// érték a következő Set hívásoktól érvényes.
+func (c *LRU[V]) SetTTL(ttl time.Duration) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.ttl = ttl
+}
+
+// Flush kiüríti a gyorsítótárat, és visszaadja a törölt elemek számát.
+func (c *LRU[V]) Flush() int {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    n := c.order.Len()
+    c.items = make(map[string]*list.Element)
+    c.order.Init()
+    return n
+}
+
+// Stats visszaadja a gyorsítótár aktuális metrikáit.
+func (c *LRU[V]) Stats() Stats {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    s := Stats{
+        Size:      c.order.Len(),
+        Capacity:  c.capacity,
+        Hits:      c.hits,
+        Misses:    c.misses,
+        Evictions: c.evictions,
+    }
+    if total := c.hits + c.misses; total > 0 {
+        s.HitRate = float64(c.hits) / float64(total)
+    }
+    return s
+}
+
+func (c *LRU[V]) removeElement(el *list.Element) {
+    c.order.Remove(el)
+    delete(c.items, el.Value.(*entry[V]).key)
+}
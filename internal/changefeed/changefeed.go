@@ -0,0 +1,271 @@
+// Package changefeed a címregiszter változásfolyamát (Kafka topic) fogyasztja, és a változásokat
+// kötegelve, a _bulk API-n keresztül alkalmazza az indexre, így az index teljes újratöltés nélkül
+// naprakész marad.
+//
+// A feldolgozás legalább egyszeri (at-least-once): egy köteg offsetjei csak a sikeres _bulk írás
+// után kerülnek commitra, így leállás vagy hiba után a köteg újra megérkezik. A műveletek
+// idempotensek (lásd index.UpdateOperation), ezért az ismételt alkalmazás nem okoz eltérést.
+package changefeed
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "autocomplete/internal/index"
+)
+
+// Message a változásfolyam egy üzenete: a Value egy index.UpdateOperation JSON alakban. A
+// HighWaterMark a partíció következő, még nem létező offsetje a lekéréskor; ebből számoljuk a
+// lemaradást.
+type Message struct {
+    Topic         string
+    Partition     int
+    Offset        int64
+    HighWaterMark int64
+    Value         []byte
+}
+
+// Reader a változásfolyam olvasója. A FetchMessage a következő üzenetig blokkol; a
+// CommitMessages az üzenetek offsetjeit véglegesíti a consumer groupban.
+type Reader interface {
+    FetchMessage(ctx context.Context) (Message, error)
+    CommitMessages(ctx context.Context, msgs ...Message) error
+    Close() error
+}
+
+// Applier a változásokat az indexre alkalmazó komponens (index.Manager).
+type Applier interface {
+    ApplyUpdates(ctx context.Context, ops []index.UpdateOperation) (index.BulkSummary, error)
+}
+
+// Retry késleltetések: sikertelen _bulk írás vagy commit után retryBaseDelay-től duplázva,
+// legfeljebb retryMaxDelay-ig várunk az újrapróbálással.
+const (
+    retryBaseDelay = time.Second
+    retryMaxDelay  = 30 * time.Second
+)
+
+// Stats a fogyasztó számlálói (expvar "changefeed"). A Lag a partíciók commitolt offsetje
+// mögötti üzenetek száma összesen, az utolsó lekéréskori high water mark alapján. Az Invalid a
+// nem értelmezhető, a Failed az index által elutasított műveletek száma; ezek nem blokkolják a
+// folyamot, a naplóban megjelennek.
+type Stats struct {
+    Consumed   int64     `json:"consumed"`
+    Indexed    int64     `json:"indexed"`
+    Deleted    int64     `json:"deleted"`
+    Invalid    int64     `json:"invalid"`
+    Failed     int64     `json:"failed"`
+    Batches    int64     `json:"batches"`
+    Retries    int64     `json:"retries"`
+    Lag        int64     `json:"lag"`
+    LastCommit time.Time `json:"lastCommit"`
+}
+
+// Consumer a Reader üzeneteit legfeljebb BatchSize elemű kötegekbe gyűjti, és a köteget
+// FlushInterval elteltével akkor is alkalmazza, ha nem telt meg.
+type Consumer struct {
+    reader        Reader
+    applier       Applier
+    batchSize     int
+    flushInterval time.Duration
+
+    consumed atomic.Int64
+    indexed  atomic.Int64
+    deleted  atomic.Int64
+    invalid  atomic.Int64
+    failed   atomic.Int64
+    batches  atomic.Int64
+    retries  atomic.Int64
+
+    mu         sync.Mutex
+    committed  map[int]int64
+    watermarks map[int]int64
+    lastCommit time.Time
+}
+
+// New létrehoz egy fogyasztót; a reader lezárása a hívó feladata.
+func New(reader Reader, applier Applier, batchSize int, flushInterval time.Duration) *Consumer {
+    return &Consumer{
+        reader:        reader,
+        applier:       applier,
+        batchSize:     batchSize,
+        flushInterval: flushInterval,
+        committed:     map[int]int64{},
+        watermarks:    map[int]int64{},
+    }
+}
+
+// Run a ctx lezárásáig fogyasztja az üzeneteket. A lezáráskor még nem commitolt köteget eldobja;
+// azt a következő indításkor a Reader újra kézbesíti. Az olvasás hibája megszakítja a futást.
+func (c *Consumer) Run(ctx context.Context) error {
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    messages := make(chan Message, c.batchSize)
+    fetchErr := make(chan error, 1)
+    go func() {
+        defer close(messages)
+        for {
+            msg, err := c.reader.FetchMessage(ctx)
+            if err != nil {
+                fetchErr <- err
+                return
+            }
+            select {
+            case messages <- msg:
+            case <-ctx.Done():
+                return
+            }
+        }
+    }()
+
+    var batch []Message
+    timer := time.NewTimer(c.flushInterval)
+    defer timer.Stop()
+    for {
+        select {
+        case msg, ok := <-messages:
+            if !ok {
+                if ctx.Err() != nil {
+                    return nil
+                }
+                return <-fetchErr
+            }
+            c.consumed.Add(1)
+            c.observe(msg)
+            batch = append(batch, msg)
+            if len(batch) < c.batchSize {
+                continue
+            }
+        case <-timer.C:
+        case <-ctx.Done():
+            return nil
+        }
+        if len(batch) > 0 {
+            // A process csak a ctx lezárásakor ad hibát.
+            if err := c.process(ctx, batch); err != nil {
+                return nil
+            }
+            batch = batch[:0]
+        }
+        if !timer.Stop() {
+            select {
+            case <-timer.C:
+            default:
+            }
+        }
+        timer.Reset(c.flushInterval)
+    }
+}
+
+// observe a partíció high water markját jegyzi fel a lemaradás számításához.
+func (c *Consumer) observe(msg Message) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if _, ok := c.committed[msg.Partition]; !ok {
+        c.committed[msg.Partition] = msg.Offset
+    }
+    c.watermarks[msg.Partition] = msg.HighWaterMark
+}
+
+// process alkalmazza és commitolja a köteget; a sikertelen _bulk írást és commitot a ctx
+// lezárásáig újrapróbálja, ilyenkor a ctx hibáját adja vissza.
+func (c *Consumer) process(ctx context.Context, batch []Message) error {
+    ops := make([]index.UpdateOperation, 0, len(batch))
+    sources := make([]Message, 0, len(batch))
+    for _, msg := range batch {
+        var op index.UpdateOperation
+        if err := json.Unmarshal(msg.Value, &op); err != nil {
+            c.invalid.Add(1)
+            slog.Warn("Change event skipped, invalid JSON", "partition", msg.Partition, "offset", msg.Offset, "error", err)
+            continue
+        }
+        ops = append(ops, op)
+        sources = append(sources, msg)
+    }
+
+    var summary index.BulkSummary
+    err := c.retry(ctx, "apply", func() error {
+        var err error
+        summary, err = c.applier.ApplyUpdates(ctx, ops)
+        return err
+    })
+    if err != nil {
+        return err
+    }
+    for _, e := range summary.Errors {
+        msg := sources[e.Index]
+        slog.Warn("Change event rejected", "partition", msg.Partition, "offset", msg.Offset, "id", e.ID, "error", e.Error)
+    }
+    c.indexed.Add(int64(summary.Indexed))
+    c.deleted.Add(int64(summary.Deleted))
+    c.failed.Add(int64(summary.Failed))
+    c.batches.Add(1)
+
+    if err := c.retry(ctx, "commit", func() error { return c.reader.CommitMessages(ctx, batch...) }); err != nil {
+        return err
+    }
+    c.mu.Lock()
+    for _, msg := range batch {
+        if msg.Offset+1 > c.committed[msg.Partition] {
+            c.committed[msg.Partition] = msg.Offset + 1
+        }
+    }
+    c.lastCommit = time.Now()
+    c.mu.Unlock()
+    slog.Debug("Change batch committed", "messages", len(batch), "indexed", summary.Indexed, "deleted", summary.Deleted, "failed", summary.Failed)
+    return nil
+}
+
+// retry az fn-t a sikerig, vagy a ctx lezárásáig ismétli exponenciálisan növekvő várakozással.
+func (c *Consumer) retry(ctx context.Context, step string, fn func() error) error {
+    delay := retryBaseDelay
+    for {
+        err := fn()
+        if err == nil {
+            return nil
+        }
+        if ctx.Err() != nil {
+            return ctx.Err()
+        }
+        c.retries.Add(1)
+        slog.Error("Change batch failed, retrying", "step", step, "delay", delay.String(), "error", err)
+        select {
+        case <-time.After(delay):
+        case <-ctx.Done():
+            return ctx.Err()
+        }
+        delay = min(2*delay, retryMaxDelay)
+    }
+}
+
+// Stats a számlálók pillanatképe.
+func (c *Consumer) Stats() Stats {
+    c.mu.Lock()
+    var lag int64
+    for partition, watermark := range c.watermarks {
+        if n := watermark - c.committed[partition]; n > 0 {
+            lag += n
+        }
+    }
+    lastCommit := c.lastCommit
+    c.mu.Unlock()
+    return Stats{
+        Consumed:   c.consumed.Load(),
+        Indexed:    c.indexed.Load(),
+        Deleted:    c.deleted.Load(),
+        Invalid:    c.invalid.Load(),
+        Failed:     c.failed.Load(),
+        Batches:    c.batches.Load(),
+        Retries:    c.retries.Load(),
+        Lag:        lag,
+        LastCommit: lastCommit,
+    }
+}
+
+// ErrKafkaUnsupported jelzi, hogy a bináris a "kafka" build tag nélkül készült.
+var ErrKafkaUnsupported = errors.New("a bináris Kafka támogatás nélkül készült (go build -tags kafka ./cmd/autocomplete)")
@@ -0,0 +1,49 @@
+//go:build kafka
+
+package changefeed
+
+// A Kafka olvasó a github.com/segmentio/kafka-go klienst használja, ezért csak a "kafka" build
+// taggel kerül a binárisba: go build -tags kafka ./cmd/autocomplete
+
+import (
+    "context"
+
+    "github.com/segmentio/kafka-go"
+)
+
+// kafkaReader a Reader megvalósítása egy kafka-go consumer group olvasóval.
+type kafkaReader struct {
+    r *kafka.Reader
+}
+
+// NewKafkaReader a brokers Kafka fürt topic topicját a groupID consumer groupban olvassa. Az
+// offsetek csak a CommitMessages hívásra kerülnek commitra (szinkron commit); új consumer group
+// a topic elejéről indul.
+func NewKafkaReader(brokers []string, topic, groupID string) (Reader, error) {
+    return &kafkaReader{r: kafka.NewReader(kafka.ReaderConfig{
+        Brokers:     brokers,
+        Topic:       topic,
+        GroupID:     groupID,
+        StartOffset: kafka.FirstOffset,
+    })}, nil
+}
+
+func (k *kafkaReader) FetchMessage(ctx context.Context) (Message, error) {
+    m, err := k.r.FetchMessage(ctx)
+    if err != nil {
+        return Message{}, err
+    }
+    return Message{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset, HighWaterMark: m.HighWaterMark, Value: m.Value}, nil
+}
+
+func (k *kafkaReader) CommitMessages(ctx context.Context, msgs ...Message) error {
+    commits := make([]kafka.Message, len(msgs))
+    for i, m := range msgs {
+        commits[i] = kafka.Message{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset}
+    }
+    return k.r.CommitMessages(ctx, commits...)
+}
+
+func (k *kafkaReader) Close() error {
+    return k.r.Close()
+}
@@ -0,0 +1,8 @@
+//go:build !kafka
+
+package changefeed
+
+// NewKafkaReader a "kafka" build tag nélkül készült binárisban mindig ErrKafkaUnsupported hibát ad.
+func NewKafkaReader(brokers []string, topic, groupID string) (Reader, error) {
+    return nil, ErrKafkaUnsupported
+}
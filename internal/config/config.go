@@ -0,0 +1,1141 @@
+// Package config a szolgáltatás konfigurációját tölti be a beépített alapértékekből, a YAML
+// konfigurációs fájlból és a környezeti változókból, és ellenőrzi az értékeket.
+package config
+
+import (
+    "bytes"
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "fmt"
+    "io"
+    "log/slog"
+    "net"
+    "net/url"
+    "os"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "gopkg.in/yaml.v3"
+
+    "autocomplete/internal/analytics"
+    "autocomplete/internal/experiment"
+    "autocomplete/internal/i18n"
+    "autocomplete/internal/index"
+    "autocomplete/internal/opensearch"
+    "autocomplete/internal/resync"
+    "autocomplete/internal/suggest"
+)
+
+// geoScalePattern az OpenSearch távolságmegadásának itt elfogadott alakja (pl. "25km", "1.5km", "500m").
+var geoScalePattern = regexp.MustCompile(`^\d+(\.\d+)?(km|m)$`)
+
+// Config a szolgáltatás teljes konfigurációja. Forrásai növekvő elsőbbséggel: a beépített
+// alapértékek, a YAML konfigurációs fájl (-config kapcsoló vagy CONFIG_FILE), végül a
+// korábbról ismert környezeti változók, amelyek minden fájlbeli értéket felülírnak.
+type Config struct {
+    Logging      LoggingConfig      `yaml:"logging"`
+    Backend      BackendConfig      `yaml:"backend"`
+    OpenSearch   OpenSearchConfig   `yaml:"opensearch"`
+    Server       ServerConfig       `yaml:"server"`
+    Search       SearchConfig       `yaml:"search"`
+    Cache        CacheConfig        `yaml:"cache"`
+    RateLimit    RateLimitConfig    `yaml:"rateLimit"`
+    Concurrency  ConcurrencyConfig  `yaml:"concurrency"`
+    Import       ImportConfig       `yaml:"import"`
+    Index        IndexConfig        `yaml:"index"`
+    Compression  CompressionConfig  `yaml:"compression"`
+    HTTPCache    HTTPCacheConfig    `yaml:"httpCache"`
+    Materialize  MaterializeConfig  `yaml:"materialize"`
+    Popularity   PopularityConfig   `yaml:"popularity"`
+    Analytics    AnalyticsConfig    `yaml:"analytics"`
+    Experiment   ExperimentConfig   `yaml:"experiment"`
+    Kafka        KafkaConfig        `yaml:"kafka"`
+    Resync       ResyncConfig       `yaml:"resync"`
+    MappingCheck MappingCheckConfig `yaml:"mappingCheck"`
+    Demo         DemoConfig         `yaml:"demo"`
+    Widget       WidgetConfig       `yaml:"widget"`
+}
+
+// LoggingConfig: LOG_LEVEL, LOG_FORMAT.
+type LoggingConfig struct {
+    Level  string `yaml:"level"`
+    Format string `yaml:"format"`
+}
+
+// A javaslatokat kiszolgáló háttérrendszerek (BACKEND).
+const (
+    // BackendOpenSearch az OpenSearch indexben keres (alapértelmezés).
+    BackendOpenSearch = "opensearch"
+    // BackendMemory a DataFile címlistáját memóriabeli prefix fába tölti; fejlesztéshez és
+    // CI-hoz, OpenSearch fürt nélkül. Az index- és importkezelő végpontok ilyenkor nem érhetők el.
+    BackendMemory = "memory"
+    // BackendSQLite a címlistát a SQLitePath beágyazott SQLite adatbázisból, FTS5 prefix
+    // lekérdezésekkel szolgálja ki; kis, helyben telepített példányokhoz. A binárist a
+    // "sqlite" build taggel kell fordítani.
+    BackendSQLite = "sqlite"
+)
+
+// BackendConfig: BACKEND, BACKEND_DATA_FILE, BACKEND_SQLITE_PATH. A DataFile .csv
+// kiterjesztésnél CSV (az import.csvHeaderMapping leképezéssel), egyébként NDJSON vagy JSON
+// tömb. SQLite háttérrendszernél a DataFile opcionális, és csak üres adatbázisba töltődik be.
+type BackendConfig struct {
+    Type       string `yaml:"type"`
+    DataFile   string `yaml:"dataFile"`
+    SQLitePath string `yaml:"sqlitePath"`
+}
+
+// OpenSearchConfig: OPENSEARCH_*, CIRCUIT_*, SLOW_QUERY_THRESHOLD. A Host a fürt egy vagy több
+// csomópontja vesszővel elválasztva (pl. "os1,os2,os3:9201"); a port nélküli csomópontok a Port
+// értékét kapják. Több csomópont között a kérések körbeforgó sorrendben oszlanak el, a
+// PingInterval (OPENSEARCH_PING_INTERVAL; 0: ki) időközönként ellenőrzött vagy hálózati hibával
+// válaszoló csomópont pedig a helyreállásáig kimarad. A Scheme "https" (alapértelmezés) vagy "http";
+// https esetén a CACert saját CA tanúsítványcsomagot, a ClientCert/ClientKey pár mTLS
+// kliens tanúsítványt ad meg, az InsecureSkipVerify pedig kikapcsolja a tanúsítvány-ellenőrzést.
+// A Mode (OPENSEARCH_MODE) fejlesztéshez: "record" a valódi fürt kérés-válasz párjait a
+// FixtureDir (OPENSEARCH_FIXTURE_DIR) könyvtárba írja, "replay" fürt nélkül ezekből válaszol,
+// "stub" a beépített csonkkal (üres találatok) fut; alapértelmezés a "live". A Compression
+// (OPENSEARCH_COMPRESSION) gzip tömörített válaszokat kér, és a nagy kéréstörzseket (pl. a bulk
+// importot) tömörítve küldi; a megtakarított bájtokat az "opensearch" expvar metrika mutatja.
+type OpenSearchConfig struct {
+    Scheme                  string        `yaml:"scheme"`
+    Host                    string        `yaml:"host"`
+    Port                    string        `yaml:"port"`
+    User                    string        `yaml:"user"`
+    Password                string        `yaml:"password"`
+    CACert                  string        `yaml:"caCert"`
+    ClientCert              string        `yaml:"clientCert"`
+    ClientKey               string        `yaml:"clientKey"`
+    InsecureSkipVerify      bool          `yaml:"insecureSkipVerify"`
+    Timeout                 time.Duration `yaml:"timeout"`
+    MaxIdleConnsPerHost     int           `yaml:"maxIdleConnsPerHost"`
+    MaxRetries              int           `yaml:"maxRetries"`
+    RetryBaseDelay          time.Duration `yaml:"retryBaseDelay"`
+    RetryMaxDelay           time.Duration `yaml:"retryMaxDelay"`
+    CircuitFailureThreshold int           `yaml:"circuitFailureThreshold"`
+    CircuitOpenTimeout      time.Duration `yaml:"circuitOpenTimeout"`
+    SlowQueryThreshold      time.Duration `yaml:"slowQueryThreshold"`
+    Compression             bool          `yaml:"compression"`
+    PingInterval            time.Duration `yaml:"pingInterval"`
+    Mode                    string        `yaml:"mode"`
+    FixtureDir              string        `yaml:"fixtureDir"`
+}
+
+// ServerConfig: PORT, ADMIN_PORT, ADMIN_TOKEN, DEBUG_SERVER_ADDR, SHUTDOWN_TIMEOUT, DEBUG_ENABLED,
+// DEFAULT_LANGUAGE, RESPONSE_META, MAX_RESPONSE_BYTES, valamint a HTTPS listener beállításai: TLS_CERT_FILE, TLS_KEY_FILE, AUTOCERT_HOSTS
+// (vesszővel elválasztva), AUTOCERT_CACHE_DIR, AUTOCERT_EMAIL, HTTP_REDIRECT_PORT. A DefaultLanguage
+// a hibaüzenetek és a debug információ nyelve (hu vagy en), ha a kérés sem a lang paraméterrel,
+// sem az Accept-Language fejléccel nem választ támogatott nyelvet. A ResponseMeta a
+// javaslatválaszokba időzítési és gyorsítótár adatokat tartalmazó "meta" blokkot tesz, a
+// MaxResponseBytes a javaslatválaszok legnagyobb mérete bájtban (0: nincs korlát); a hosszabb
+// válaszok javaslatlistája csonkolódik.
+type ServerConfig struct {
+    Port             string        `yaml:"port"`
+    AdminPort        string        `yaml:"adminPort"`
+    AdminToken       string        `yaml:"adminToken"`
+    DebugServerAddr  string        `yaml:"debugServerAddr"`
+    ShutdownTimeout  time.Duration `yaml:"shutdownTimeout"`
+    DebugEnabled     bool          `yaml:"debugEnabled"`
+    DefaultLanguage  string        `yaml:"defaultLanguage"`
+    ResponseMeta     bool          `yaml:"responseMeta"`
+    MaxResponseBytes int           `yaml:"maxResponseBytes"`
+    TLSCertFile      string        `yaml:"tlsCertFile"`
+    TLSKeyFile       string        `yaml:"tlsKeyFile"`
+    AutocertHosts    []string      `yaml:"autocertHosts"`
+    AutocertCacheDir string        `yaml:"autocertCacheDir"`
+    AutocertEmail    string        `yaml:"autocertEmail"`
+    HTTPRedirectPort string        `yaml:"httpRedirectPort"`
+}
+
+// SearchConfig: QUERY_MODE, SUGGESTION_LIMIT, FUZZY_FALLBACK, VALIDATE_BATCH_MAX, GEO_SCALE,
+// DEFAULT_SORT, FOLD_ACCENTS, MIN_QUERY_LEN, MAX_QUERY_LEN, SEARCH_SUBQUERY_TIMEOUT, SUGGEST_FIELDS,
+// WEIGHT_BOOST, RANKING_PIPELINE, CANARY_INDEX, CANARY_PERCENT, KEYBOARD_TYPO_MIN_RESULTS. A GeoScale a ?lat=&lon= szerinti
+// rangsorolás távolsága (pl. "25km", "500m"), a DefaultSort a ?sort= nélküli kérések rendezése
+// (relevance, alphabetical vagy popularity). A FoldAccents a lekérdezéseket ékezetek nélkül
+// futtatja; csak ngram módban. A MinQueryLength-nél rövidebb lekérdezésekre a javaslatvégpontok
+// háttérkérés nélkül üres listát adnak, a MaxQueryLength-nél hosszabbakra 400-as hibát (karakterben;
+// 0: nincs felső korlát).
+// A SubQueryTimeout az /api/search részlekérdezéseinek egyenkénti időkorlátja (0: nincs); a
+// lejárt részlekérdezés nélkül a válasz részleges. A SuggestFields a többmezős javaslatkérés
+// (/api/autocomplete?fields=) mezőtáblája: a kliens által használt névhez az index szöveges
+// mezőjét rendeli (név=mező párok vesszővel elválasztva, pl. "telepules=telepules,utca=kozter_nev").
+// A konfigurációs fájl párjai az alapértelmezett táblához adódnak, a SUGGEST_FIELDS lecseréli azt.
+// A WeightBoost a dokumentumsúly (lakosság vagy címszám) szerinti előresorolás erőssége (0: ki;
+// lásd suggest.Options.WeightBoost). A RankingPipeline a relevancia szerinti rendezést felváltó
+// rangsorolási lépések lépés:szorzó listája (pl. "prefix:3,length:1,weight:0.5,geo:2,recency:1";
+// lásd suggest.ParsePipeline); üresen a beépített rendezés marad. A CanaryIndex (ha meg van adva)
+// egy új indexverzió (pl. "orszagos_cimlista_v2"), amely a javaslatkérések CanaryPercent
+// százalékát kapja a stabil alias helyett; az indexenkénti számok az "index_routing" expvar
+// metrikában hasonlíthatók össze. Csak OpenSearch háttérrendszerrel. A KeyboardTypoMinResults
+// (ha > 0) esetén az ennél kevesebb javaslatot adó lekérdezések az utolsó karakter magyar
+// QWERTZ billentyűzeten szomszédos betűivel is lefutnak ("budapesr" -> "budapest"; 0: ki).
+type SearchConfig struct {
+    QueryMode        string  `yaml:"queryMode"`
+    SuggestionLimit  int     `yaml:"suggestionLimit"`
+    FuzzyFallback    bool    `yaml:"fuzzyFallback"`
+    ValidateBatchMax int     `yaml:"validateBatchMax"`
+    GeoScale         string  `yaml:"geoScale"`
+    DefaultSort      string  `yaml:"defaultSort"`
+    FoldAccents      bool    `yaml:"foldAccents"`
+    MinQueryLength   int     `yaml:"minQueryLength"`
+    MaxQueryLength   int     `yaml:"maxQueryLength"`
+    WeightBoost      float64 `yaml:"weightBoost"`
+    RankingPipeline  string  `yaml:"rankingPipeline"`
+    CanaryIndex      string  `yaml:"canaryIndex"`
+    CanaryPercent    float64 `yaml:"canaryPercent"`
+
+    KeyboardTypoMinResults int `yaml:"keyboardTypoMinResults"`
+
+    SubQueryTimeout time.Duration     `yaml:"subQueryTimeout"`
+    SuggestFields   map[string]string `yaml:"suggestFields"`
+}
+
+// CacheConfig: CACHE_SIZE, CACHE_TTL, STALE_WHILE_REVALIDATE, STALE_TIMEOUT, CACHE_WARMUP,
+// CACHE_WARMUP_PREFIXES (vesszővel elválasztva). Warmup esetén induláskor a WarmupPrefixes
+// (ha üres, az index településneveinek 1–2 karakteres előtagjai) javaslatai a gyorsítótárba
+// kerülnek, és a /healthz addig 503-at ad.
+type CacheConfig struct {
+    Size                 int           `yaml:"size"`
+    TTL                  time.Duration `yaml:"ttl"`
+    StaleWhileRevalidate bool          `yaml:"staleWhileRevalidate"`
+    StaleTimeout         time.Duration `yaml:"staleTimeout"`
+    Warmup               bool          `yaml:"warmup"`
+    WarmupPrefixes       []string      `yaml:"warmupPrefixes"`
+}
+
+// RateLimitConfig: RATE_LIMIT_RPS, RATE_LIMIT_BURST, TRUSTED_PROXIES (vesszővel elválasztva).
+type RateLimitConfig struct {
+    RPS            float64  `yaml:"rps"`
+    Burst          int      `yaml:"burst"`
+    TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+// ConcurrencyConfig: CONCURRENCY_LIMITS (útvonal=korlát párok vesszővel elválasztva, pl.
+// "/api/autocomplete=64,/api/geocode=16"), CONCURRENCY_DEFAULT_LIMIT, CONCURRENCY_QUEUE_TIMEOUT,
+// OPENSEARCH_MAX_CONCURRENCY. Az Endpoints a nyilvános végpontok útvonalanként egyszerre
+// kiszolgált kéréseinek felső korlátja; a listában nem szereplő útvonalakra a Default érvényes
+// (0: korlátlan). Az OpenSearch az OpenSearch felé egyszerre futó kérések korlátja. A korlát
+// felett érkező kérések legfeljebb QueueTimeout ideig várnak, utána 503-at kapnak.
+type ConcurrencyConfig struct {
+    Endpoints    map[string]int `yaml:"endpoints"`
+    Default      int            `yaml:"default"`
+    OpenSearch   int            `yaml:"opensearch"`
+    QueueTimeout time.Duration  `yaml:"queueTimeout"`
+}
+
+// ImportConfig: BULK_BATCH_SIZE, CSV_HEADER_MAPPING, UPDATES_SECRET, IMPORT_DUPLICATE_POLICY. Az
+// UpdatesSecret a POST /api/admin/updates kötegeinek HMAC-SHA256 aláíró kulcsa; üresen a végpont
+// ki van kapcsolva. A DuplicatePolicy a tömeges betöltésben a kis- és nagybetűben, szóközökben
+// vagy ékezetekben eltérő ismétlődő címek kezelése: off, flag (jelzés az összesítőben) vagy
+// merge (csak az első előfordulás kerül az indexbe).
+type ImportConfig struct {
+    BulkBatchSize    int               `yaml:"bulkBatchSize"`
+    CSVHeaderMapping map[string]string `yaml:"csvHeaderMapping"`
+    UpdatesSecret    string            `yaml:"updatesSecret"`
+    DuplicatePolicy  string            `yaml:"duplicatePolicy"`
+}
+
+// IndexConfig: AUTO_CREATE_INDEX, INDEX_FIELD_STRATEGY, SNAPSHOT_REPOSITORY,
+// INDEX_CARDINALITY_PRECISION. A FieldStrategy
+// "ngram" vagy "search_as_you_type"; az utóbbi szükséges a search_as_you_type lekérdezési
+// módhoz, és meglévő indexnél a POST /api/admin/mapping/upgrade veszi fel az új almezőket. A
+// SnapshotRepository egy az OpenSearch-ben már regisztrált snapshot tároló neve; megadása
+// esetén az /api/admin/snapshots végpontok pillanatképet készítenek és állítanak vissza. A
+// CardinalityPrecision a /api/checkMapping egyedi értékszámlálásának pontossági küszöbe
+// (legfeljebb 40000): az ennél kevesebb egyedi érték száma közel pontos.
+type IndexConfig struct {
+    AutoCreate           bool   `yaml:"autoCreate"`
+    FieldStrategy        string `yaml:"fieldStrategy"`
+    SnapshotRepository   string `yaml:"snapshotRepository"`
+    CardinalityPrecision int    `yaml:"cardinalityPrecision"`
+}
+
+// CompressionConfig: COMPRESSION_ENABLED, COMPRESSION_MIN_SIZE, COMPRESSION_TYPES (vesszővel elválasztva).
+type CompressionConfig struct {
+    Enabled      bool     `yaml:"enabled"`
+    MinSize      int      `yaml:"minSize"`
+    ContentTypes []string `yaml:"contentTypes"`
+}
+
+// MaterializeConfig: MATERIALIZE_SERVE, MATERIALIZE_MAX_PREFIX_LENGTH, MATERIALIZE_INTERVAL. Az
+// előszámítás a legfeljebb MaxPrefixLength karakteres előtagok javaslatait egy lookup indexbe
+// írja; Interval > 0 esetén ilyen időközönként, egyébként csak a POST /api/admin/materialize
+// indítja. Serve esetén a rövid, szűrő nélküli lekérdezések ebből az indexből jönnek.
+type MaterializeConfig struct {
+    Serve           bool          `yaml:"serve"`
+    MaxPrefixLength int           `yaml:"maxPrefixLength"`
+    Interval        time.Duration `yaml:"interval"`
+}
+
+// PopularityConfig: POPULARITY_RANKING, SELECTION_FLUSH_INTERVAL. A POST /api/select
+// kiválasztásai a memóriában gyűlnek, és FlushInterval időközönként kerülnek az indexbe; Ranking
+// esetén a javaslatok elsősorban a kiválasztások száma szerint rendeződnek.
+type PopularityConfig struct {
+    Ranking       bool          `yaml:"ranking"`
+    FlushInterval time.Duration `yaml:"flushInterval"`
+}
+
+// AnalyticsConfig: ANALYTICS_ENABLED, ANALYTICS_RETENTION. A javaslatkérések lekérdezései a
+// memóriában gyűlnek Retention ideig; az /api/admin/analytics/top és /zero-results végpontok
+// ezeket összesítik. A folyamat újraindításakor elvesznek.
+type AnalyticsConfig struct {
+    Enabled   bool          `yaml:"enabled"`
+    Retention time.Duration `yaml:"retention"`
+}
+
+// ExperimentConfig: EXPERIMENT_NAME, EXPERIMENT_CLIENT_HEADER, EXPERIMENT_VARIANTS (lásd
+// experiment.ParseVariants). Ha a Name nem üres, a ClientHeader fejlécet küldő klienseket az
+// azonosítójuk hash-e a Variants egyikéhez rendeli, amely a QUERY_MODE és a RANKING_PIPELINE
+// értékét felülírhatja; a változatok számai a GET /api/admin/experiments jelentésében és a
+// lekérdezés-statisztikában hasonlíthatók össze. Az első változat a kontroll. Újraindításkor a
+// számlálók elvesznek.
+type ExperimentConfig struct {
+    Name         string               `yaml:"name"`
+    ClientHeader string               `yaml:"clientHeader"`
+    Variants     []experiment.Variant `yaml:"variants"`
+}
+
+// KafkaConfig: KAFKA_BROKERS (vesszővel elválasztva), KAFKA_TOPIC, KAFKA_GROUP_ID,
+// KAFKA_BATCH_SIZE, KAFKA_FLUSH_INTERVAL. Ha a Brokers nem üres, a serve parancs a Topic
+// címváltozás-eseményeit (index.UpdateOperation JSON üzenetenként) fogyasztja, és legfeljebb
+// BatchSize elemű, FlushInterval időnként alkalmazott kötegekben írja az indexbe. Csak OpenSearch
+// háttérrendszerrel, és csak a "kafka" build taggel fordított binárisban használható.
+type KafkaConfig struct {
+    Brokers       []string      `yaml:"brokers"`
+    Topic         string        `yaml:"topic"`
+    GroupID       string        `yaml:"groupId"`
+    BatchSize     int           `yaml:"batchSize"`
+    FlushInterval time.Duration `yaml:"flushInterval"`
+}
+
+// DemoConfig: DEMO_TITLE, DEMO_API_BASE_PATH. A demo felület címe, és a nyilvános API
+// útvonal-előtagja, ha a szolgáltatás fordított proxy mögött alútvonalon érhető el (pl.
+// "/autocomplete"); az előtagot a beágyazható widget is használja.
+type DemoConfig struct {
+    Title       string `yaml:"title"`
+    APIBasePath string `yaml:"apiBasePath"`
+}
+
+// WidgetConfig: CORS_ALLOWED_ORIGINS (vesszővel elválasztva), WIDGET_DEBOUNCE, JSONP_ENABLED. Az AllowedOrigins
+// azok az oldalak (pl. "https://example.hu", vagy "*" bármelyikre), amelyekről a beágyazott
+// widget böngészőből hívhatja a nyilvános API-t; üresen nincs CORS. A Debounce a widget és a
+// demo felület alapértelmezett késleltetése a gépelés és a lekérdezés között. A JSONP a CORS-t
+// nem támogató régi beágyazóknak engedélyezi a javaslatvégpontok "callback" paraméterét.
+type WidgetConfig struct {
+    AllowedOrigins []string      `yaml:"allowedOrigins"`
+    Debounce       time.Duration `yaml:"debounce"`
+    JSONP          bool          `yaml:"jsonp"`
+}
+
+// ResyncConfig: RESYNC_SOURCE, RESYNC_SCHEDULE, RESYNC_S3_REGION, RESYNC_S3_ENDPOINT,
+// RESYNC_DELETE_OLD, RESYNC_TIMEOUT. A Source a kanonikus címlista http(s):// URL-je vagy
+// s3://bucket/kulcs címe; a teljes újraszinkronizálás letölti, új indexbe tölti, majd átváltja rá
+// az aliast. A Schedule ötmezős cron kifejezés (pl. "0 3 * * *"); üresen csak az
+// /api/admin/resync végpont indítja. Csak OpenSearch háttérrendszerrel használható.
+type ResyncConfig struct {
+    Source     string        `yaml:"source"`
+    Schedule   string        `yaml:"schedule"`
+    S3Region   string        `yaml:"s3Region"`
+    S3Endpoint string        `yaml:"s3Endpoint"`
+    DeleteOld  bool          `yaml:"deleteOld"`
+    Timeout    time.Duration `yaml:"timeout"`
+}
+
+// MappingCheckConfig: MAPPING_CHECK_INTERVAL, MAPPING_CHECK_TIMEOUT, MAPPING_CHECK_WEBHOOK_URL.
+// A serve parancs Interval időközönként (0: kikapcsolva) ellenőrzi az index mappingjét (lásd
+// mappingwatch), az eredményt "mapping_ok" néven közzéteszi az expvar metrikák között, és ha
+// WebhookURL meg van adva, eltéréskor és helyreálláskor oda küld értesítést (a törzs "text" mezője
+// miatt Slack incoming webhook is lehet). Csak OpenSearch háttérrendszerrel működik.
+type MappingCheckConfig struct {
+    Interval   time.Duration `yaml:"interval"`
+    Timeout    time.Duration `yaml:"timeout"`
+    WebhookURL string        `yaml:"webhookUrl"`
+}
+
+// HTTPCacheConfig: HTTP_CACHE_ENABLED, HTTP_CACHE_MAX_AGE. A javaslat végpontok Cache-Control
+// és ETag fejlécei, hogy a böngészők és CDN-ek újrahasznosíthassák az azonos lekérdezéseket.
+type HTTPCacheConfig struct {
+    Enabled bool          `yaml:"enabled"`
+    MaxAge  time.Duration `yaml:"maxAge"`
+}
+
+// Default a beépített alapértékeket adja vissza.
+func Default() Config {
+    osDefaults := opensearch.DefaultConfig()
+    return Config{
+        Logging: LoggingConfig{Level: "info", Format: "json"},
+        Backend: BackendConfig{Type: BackendOpenSearch},
+        OpenSearch: OpenSearchConfig{
+            Scheme:                  "https",
+            Timeout:                 osDefaults.Timeout,
+            MaxIdleConnsPerHost:     osDefaults.MaxIdleConnsPerHost,
+            MaxRetries:              osDefaults.MaxRetries,
+            RetryBaseDelay:          osDefaults.RetryBaseDelay,
+            RetryMaxDelay:           osDefaults.RetryMaxDelay,
+            CircuitFailureThreshold: osDefaults.BreakerThreshold,
+            CircuitOpenTimeout:      osDefaults.BreakerOpenTimeout,
+            SlowQueryThreshold:      time.Second,
+            Compression:             osDefaults.Compression,
+            PingInterval:            10 * time.Second,
+            Mode:                    opensearch.ModeLive,
+        },
+        Server: ServerConfig{
+            Port:             "80",
+            AdminPort:        "8081",
+            DebugServerAddr:  "localhost:6060",
+            ShutdownTimeout:  30 * time.Second,
+            DebugEnabled:     true,
+            DefaultLanguage:  i18n.Hungarian,
+            MaxResponseBytes: 64 << 10,
+            AutocertCacheDir: "autocert-cache",
+        },
+        Search: SearchConfig{QueryMode: suggest.QueryModeNgram, SuggestionLimit: 10, FuzzyFallback: true, ValidateBatchMax: 100, GeoScale: "25km", DefaultSort: suggest.SortRelevance,
+            MinQueryLength: 2, MaxQueryLength: 100, WeightBoost: 1, SubQueryTimeout: time.Second,
+            SuggestFields: map[string]string{"telepules": "telepules", "kozter_nev": "kozter_nev", "teljes_cim": "teljes_cim"}},
+        Cache:       CacheConfig{Size: 10000, TTL: 5 * time.Minute, StaleTimeout: 300 * time.Millisecond},
+        RateLimit:   RateLimitConfig{RPS: 20, Burst: 40},
+        Concurrency: ConcurrencyConfig{Endpoints: map[string]int{}, QueueTimeout: 250 * time.Millisecond},
+        Import:      ImportConfig{BulkBatchSize: 500, CSVHeaderMapping: map[string]string{}, DuplicatePolicy: index.DuplicatePolicyFlag},
+        Index:       IndexConfig{FieldStrategy: index.FieldStrategyNgram, CardinalityPrecision: 10000},
+        Compression: CompressionConfig{
+            Enabled:      true,
+            MinSize:      1024,
+            ContentTypes: []string{"application/json", "application/x-ndjson", "text/html", "text/plain", "text/css", "text/javascript"},
+        },
+        HTTPCache:    HTTPCacheConfig{Enabled: true, MaxAge: 60 * time.Second},
+        Materialize:  MaterializeConfig{MaxPrefixLength: 3},
+        Popularity:   PopularityConfig{Ranking: true, FlushInterval: 30 * time.Second},
+        Analytics:    AnalyticsConfig{Enabled: true, Retention: 24 * time.Hour},
+        Experiment:   ExperimentConfig{ClientHeader: "X-Client-ID"},
+        Kafka:        KafkaConfig{GroupID: "autocomplete", BatchSize: 500, FlushInterval: time.Second},
+        Resync:       ResyncConfig{S3Region: "us-east-1", DeleteOld: true, Timeout: time.Hour},
+        MappingCheck: MappingCheckConfig{Interval: 5 * time.Minute, Timeout: 30 * time.Second},
+        Demo:         DemoConfig{Title: "Buddha's Autocomplete Demo"},
+        Widget:       WidgetConfig{Debounce: 150 * time.Millisecond},
+    }
+}
+
+// Errors az összes hiányzó vagy érvénytelen beállítást tartalmazza, hogy egyszerre
+// lehessen mindet javítani.
+type Errors []string
+
+func (e Errors) Error() string {
+    return "érvénytelen konfiguráció:\n  - " + strings.Join(e, "\n  - ")
+}
+
+func (e *Errors) addf(format string, args ...any) {
+    *e = append(*e, fmt.Sprintf(format, args...))
+}
+
+// Load betölti a konfigurációt: alapértékek, majd a path fájl (ha nem üres), majd a
+// környezeti változók. A hibákat nem az elsőnél állva, hanem összegyűjtve, Errors-ként adja vissza.
+func Load(path string) (Config, error) {
+    cfg := Default()
+    var errs Errors
+    if path != "" {
+        data, err := os.ReadFile(path)
+        if err != nil {
+            return cfg, err
+        }
+        decoder := yaml.NewDecoder(bytes.NewReader(data))
+        decoder.KnownFields(true)
+        if err := decoder.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+            return cfg, fmt.Errorf("a konfigurációs fájl (%s) nem értelmezhető: %w", path, err)
+        }
+    }
+    cfg.applyEnv(&errs)
+    cfg.validate(&errs)
+    if len(errs) > 0 {
+        return cfg, errs
+    }
+    return cfg, nil
+}
+
+// envReader a környezeti változókat olvassa be, a formátumhibákat pedig gyűjti.
+type envReader struct {
+    errs *Errors
+}
+
+func (e envReader) string(key string, dst *string) {
+    if v, ok := os.LookupEnv(key); ok && v != "" {
+        *dst = v
+    }
+}
+
+func (e envReader) int(key string, dst *int) {
+    if v := os.Getenv(key); v != "" {
+        n, err := strconv.Atoi(v)
+        if err != nil {
+            e.errs.addf("%s: nem egész szám: %q", key, v)
+            return
+        }
+        *dst = n
+    }
+}
+
+func (e envReader) float(key string, dst *float64) {
+    if v := os.Getenv(key); v != "" {
+        f, err := strconv.ParseFloat(v, 64)
+        if err != nil {
+            e.errs.addf("%s: nem szám: %q", key, v)
+            return
+        }
+        *dst = f
+    }
+}
+
+func (e envReader) bool(key string, dst *bool) {
+    if v := os.Getenv(key); v != "" {
+        b, err := strconv.ParseBool(v)
+        if err != nil {
+            e.errs.addf("%s: nem logikai érték: %q", key, v)
+            return
+        }
+        *dst = b
+    }
+}
+
+func (e envReader) duration(key string, dst *time.Duration) {
+    if v := os.Getenv(key); v != "" {
+        d, err := time.ParseDuration(v)
+        if err != nil {
+            e.errs.addf("%s: nem időtartam: %q", key, v)
+            return
+        }
+        *dst = d
+    }
+}
+
+// applyEnv a beállított környezeti változókkal felülírja a konfigurációt.
+func (c *Config) applyEnv(errs *Errors) {
+    env := envReader{errs: errs}
+    env.string("LOG_LEVEL", &c.Logging.Level)
+    env.string("LOG_FORMAT", &c.Logging.Format)
+    env.string("BACKEND", &c.Backend.Type)
+    env.string("BACKEND_DATA_FILE", &c.Backend.DataFile)
+    env.string("BACKEND_SQLITE_PATH", &c.Backend.SQLitePath)
+
+    env.string("OPENSEARCH_SCHEME", &c.OpenSearch.Scheme)
+    env.string("OPENSEARCH_HOST", &c.OpenSearch.Host)
+    env.string("OPENSEARCH_PORT", &c.OpenSearch.Port)
+    env.string("OPENSEARCH_USER", &c.OpenSearch.User)
+    env.string("OPENSEARCH_PASSWORD", &c.OpenSearch.Password)
+    env.string("OPENSEARCH_CA_CERT", &c.OpenSearch.CACert)
+    env.string("OPENSEARCH_CLIENT_CERT", &c.OpenSearch.ClientCert)
+    env.string("OPENSEARCH_CLIENT_KEY", &c.OpenSearch.ClientKey)
+    env.bool("OPENSEARCH_INSECURE_SKIP_VERIFY", &c.OpenSearch.InsecureSkipVerify)
+    env.duration("OPENSEARCH_TIMEOUT", &c.OpenSearch.Timeout)
+    env.int("OPENSEARCH_MAX_IDLE_CONNS_PER_HOST", &c.OpenSearch.MaxIdleConnsPerHost)
+    env.int("OPENSEARCH_MAX_RETRIES", &c.OpenSearch.MaxRetries)
+    env.duration("OPENSEARCH_RETRY_BASE_DELAY", &c.OpenSearch.RetryBaseDelay)
+    env.duration("OPENSEARCH_RETRY_MAX_DELAY", &c.OpenSearch.RetryMaxDelay)
+    env.int("CIRCUIT_FAILURE_THRESHOLD", &c.OpenSearch.CircuitFailureThreshold)
+    env.duration("CIRCUIT_OPEN_TIMEOUT", &c.OpenSearch.CircuitOpenTimeout)
+    env.duration("SLOW_QUERY_THRESHOLD", &c.OpenSearch.SlowQueryThreshold)
+    env.bool("OPENSEARCH_COMPRESSION", &c.OpenSearch.Compression)
+    env.duration("OPENSEARCH_PING_INTERVAL", &c.OpenSearch.PingInterval)
+    env.string("OPENSEARCH_MODE", &c.OpenSearch.Mode)
+    env.string("OPENSEARCH_FIXTURE_DIR", &c.OpenSearch.FixtureDir)
+
+    env.string("PORT", &c.Server.Port)
+    env.string("ADMIN_PORT", &c.Server.AdminPort)
+    env.string("ADMIN_TOKEN", &c.Server.AdminToken)
+    env.string("DEBUG_SERVER_ADDR", &c.Server.DebugServerAddr)
+    env.duration("SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+    env.bool("DEBUG_ENABLED", &c.Server.DebugEnabled)
+    env.string("DEFAULT_LANGUAGE", &c.Server.DefaultLanguage)
+    env.bool("RESPONSE_META", &c.Server.ResponseMeta)
+    env.int("MAX_RESPONSE_BYTES", &c.Server.MaxResponseBytes)
+    env.string("TLS_CERT_FILE", &c.Server.TLSCertFile)
+    env.string("TLS_KEY_FILE", &c.Server.TLSKeyFile)
+    if hosts := os.Getenv("AUTOCERT_HOSTS"); hosts != "" {
+        c.Server.AutocertHosts = nil
+        for _, host := range strings.Split(hosts, ",") {
+            if host = strings.TrimSpace(host); host != "" {
+                c.Server.AutocertHosts = append(c.Server.AutocertHosts, host)
+            }
+        }
+    }
+    env.string("AUTOCERT_CACHE_DIR", &c.Server.AutocertCacheDir)
+    env.string("AUTOCERT_EMAIL", &c.Server.AutocertEmail)
+    env.string("HTTP_REDIRECT_PORT", &c.Server.HTTPRedirectPort)
+
+    env.string("QUERY_MODE", &c.Search.QueryMode)
+    env.int("SUGGESTION_LIMIT", &c.Search.SuggestionLimit)
+    env.bool("FUZZY_FALLBACK", &c.Search.FuzzyFallback)
+    env.bool("FOLD_ACCENTS", &c.Search.FoldAccents)
+    env.int("VALIDATE_BATCH_MAX", &c.Search.ValidateBatchMax)
+    env.string("GEO_SCALE", &c.Search.GeoScale)
+    env.string("DEFAULT_SORT", &c.Search.DefaultSort)
+    env.int("MIN_QUERY_LEN", &c.Search.MinQueryLength)
+    env.int("MAX_QUERY_LEN", &c.Search.MaxQueryLength)
+    env.duration("SEARCH_SUBQUERY_TIMEOUT", &c.Search.SubQueryTimeout)
+    env.float("WEIGHT_BOOST", &c.Search.WeightBoost)
+    env.string("RANKING_PIPELINE", &c.Search.RankingPipeline)
+    env.string("CANARY_INDEX", &c.Search.CanaryIndex)
+    env.float("CANARY_PERCENT", &c.Search.CanaryPercent)
+    env.int("KEYBOARD_TYPO_MIN_RESULTS", &c.Search.KeyboardTypoMinResults)
+    if spec := os.Getenv("SUGGEST_FIELDS"); spec != "" {
+        fields, err := ParseSuggestFields(spec)
+        if err != nil {
+            errs.addf("SUGGEST_FIELDS: %v", err)
+        } else {
+            c.Search.SuggestFields = fields
+        }
+    }
+
+    env.int("CACHE_SIZE", &c.Cache.Size)
+    env.duration("CACHE_TTL", &c.Cache.TTL)
+    env.bool("STALE_WHILE_REVALIDATE", &c.Cache.StaleWhileRevalidate)
+    env.duration("STALE_TIMEOUT", &c.Cache.StaleTimeout)
+    env.bool("CACHE_WARMUP", &c.Cache.Warmup)
+    if spec := os.Getenv("CACHE_WARMUP_PREFIXES"); spec != "" {
+        c.Cache.WarmupPrefixes = strings.Split(spec, ",")
+    }
+
+    env.float("RATE_LIMIT_RPS", &c.RateLimit.RPS)
+    env.int("RATE_LIMIT_BURST", &c.RateLimit.Burst)
+    if spec := os.Getenv("TRUSTED_PROXIES"); spec != "" {
+        c.RateLimit.TrustedProxies = strings.Split(spec, ",")
+    }
+
+    if spec := os.Getenv("CONCURRENCY_LIMITS"); spec != "" {
+        limits, err := ParseConcurrencyLimits(spec)
+        if err != nil {
+            errs.addf("CONCURRENCY_LIMITS: %v", err)
+        } else {
+            c.Concurrency.Endpoints = limits
+        }
+    }
+    env.int("CONCURRENCY_DEFAULT_LIMIT", &c.Concurrency.Default)
+    env.int("OPENSEARCH_MAX_CONCURRENCY", &c.Concurrency.OpenSearch)
+    env.duration("CONCURRENCY_QUEUE_TIMEOUT", &c.Concurrency.QueueTimeout)
+
+    env.int("BULK_BATCH_SIZE", &c.Import.BulkBatchSize)
+    env.string("UPDATES_SECRET", &c.Import.UpdatesSecret)
+    env.string("IMPORT_DUPLICATE_POLICY", &c.Import.DuplicatePolicy)
+    if spec := os.Getenv("CSV_HEADER_MAPPING"); spec != "" {
+        mapping, err := index.ParseHeaderMapping(spec)
+        if err != nil {
+            errs.addf("CSV_HEADER_MAPPING: %v", err)
+        } else {
+            c.Import.CSVHeaderMapping = mapping
+        }
+    }
+
+    env.bool("AUTO_CREATE_INDEX", &c.Index.AutoCreate)
+    env.string("INDEX_FIELD_STRATEGY", &c.Index.FieldStrategy)
+    env.string("SNAPSHOT_REPOSITORY", &c.Index.SnapshotRepository)
+    env.int("INDEX_CARDINALITY_PRECISION", &c.Index.CardinalityPrecision)
+
+    env.bool("HTTP_CACHE_ENABLED", &c.HTTPCache.Enabled)
+    env.duration("HTTP_CACHE_MAX_AGE", &c.HTTPCache.MaxAge)
+
+    env.bool("MATERIALIZE_SERVE", &c.Materialize.Serve)
+    env.int("MATERIALIZE_MAX_PREFIX_LENGTH", &c.Materialize.MaxPrefixLength)
+    env.duration("MATERIALIZE_INTERVAL", &c.Materialize.Interval)
+
+    env.bool("POPULARITY_RANKING", &c.Popularity.Ranking)
+    env.duration("SELECTION_FLUSH_INTERVAL", &c.Popularity.FlushInterval)
+
+    env.bool("ANALYTICS_ENABLED", &c.Analytics.Enabled)
+    env.duration("ANALYTICS_RETENTION", &c.Analytics.Retention)
+
+    env.string("EXPERIMENT_NAME", &c.Experiment.Name)
+    env.string("EXPERIMENT_CLIENT_HEADER", &c.Experiment.ClientHeader)
+    if spec := os.Getenv("EXPERIMENT_VARIANTS"); spec != "" {
+        variants, err := experiment.ParseVariants(spec)
+        if err != nil {
+            errs.addf("EXPERIMENT_VARIANTS: %v", err)
+        } else {
+            c.Experiment.Variants = variants
+        }
+    }
+
+    if brokers := os.Getenv("KAFKA_BROKERS"); brokers != "" {
+        c.Kafka.Brokers = nil
+        for _, broker := range strings.Split(brokers, ",") {
+            if broker = strings.TrimSpace(broker); broker != "" {
+                c.Kafka.Brokers = append(c.Kafka.Brokers, broker)
+            }
+        }
+    }
+    env.string("KAFKA_TOPIC", &c.Kafka.Topic)
+    env.string("KAFKA_GROUP_ID", &c.Kafka.GroupID)
+    env.int("KAFKA_BATCH_SIZE", &c.Kafka.BatchSize)
+    env.duration("KAFKA_FLUSH_INTERVAL", &c.Kafka.FlushInterval)
+
+    env.string("RESYNC_SOURCE", &c.Resync.Source)
+    env.string("RESYNC_SCHEDULE", &c.Resync.Schedule)
+    env.string("RESYNC_S3_REGION", &c.Resync.S3Region)
+    env.string("RESYNC_S3_ENDPOINT", &c.Resync.S3Endpoint)
+    env.bool("RESYNC_DELETE_OLD", &c.Resync.DeleteOld)
+    env.duration("RESYNC_TIMEOUT", &c.Resync.Timeout)
+
+    env.duration("MAPPING_CHECK_INTERVAL", &c.MappingCheck.Interval)
+    env.duration("MAPPING_CHECK_TIMEOUT", &c.MappingCheck.Timeout)
+    env.string("MAPPING_CHECK_WEBHOOK_URL", &c.MappingCheck.WebhookURL)
+
+    env.string("DEMO_TITLE", &c.Demo.Title)
+    env.string("DEMO_API_BASE_PATH", &c.Demo.APIBasePath)
+    if spec := os.Getenv("CORS_ALLOWED_ORIGINS"); spec != "" {
+        c.Widget.AllowedOrigins = nil
+        for _, origin := range strings.Split(spec, ",") {
+            if origin = strings.TrimSpace(origin); origin != "" {
+                c.Widget.AllowedOrigins = append(c.Widget.AllowedOrigins, origin)
+            }
+        }
+    }
+    env.duration("WIDGET_DEBOUNCE", &c.Widget.Debounce)
+    env.bool("JSONP_ENABLED", &c.Widget.JSONP)
+
+    env.bool("COMPRESSION_ENABLED", &c.Compression.Enabled)
+    env.int("COMPRESSION_MIN_SIZE", &c.Compression.MinSize)
+    if types := os.Getenv("COMPRESSION_TYPES"); types != "" {
+        c.Compression.ContentTypes = nil
+        for _, t := range strings.Split(types, ",") {
+            if t = strings.TrimSpace(t); t != "" {
+                c.Compression.ContentTypes = append(c.Compression.ContentTypes, t)
+            }
+        }
+    }
+}
+
+// validate ellenőrzi a kötelező mezőket és az értéktartományokat. A hibaüzenetek a
+// fájlbeli kulcsot és a felülíró környezeti változót is megnevezik.
+func (c *Config) validate(errs *Errors) {
+    if _, err := ParseLogLevel(c.Logging.Level); err != nil {
+        errs.addf("logging.level (LOG_LEVEL): %v", err)
+    }
+    if f := strings.ToLower(c.Logging.Format); f != "json" && f != "text" {
+        errs.addf("logging.format (LOG_FORMAT): ismeretlen formátum: %q", c.Logging.Format)
+    }
+
+    var required []struct{ key, env, value string }
+    // Replay és stub módban nincs fürt, így kapcsolódási adatok sem kellenek.
+    offline := c.OpenSearch.Mode == opensearch.ModeReplay || c.OpenSearch.Mode == opensearch.ModeStub
+    switch {
+    case c.Backend.Type == BackendOpenSearch && offline:
+    case c.Backend.Type == BackendOpenSearch:
+        required = append(required,
+            struct{ key, env, value string }{"opensearch.host", "OPENSEARCH_HOST", c.OpenSearch.Host},
+            struct{ key, env, value string }{"opensearch.port", "OPENSEARCH_PORT", c.OpenSearch.Port})
+    case c.Backend.Type == BackendMemory:
+        required = append(required, struct{ key, env, value string }{"backend.dataFile", "BACKEND_DATA_FILE", c.Backend.DataFile})
+    case c.Backend.Type == BackendSQLite:
+        required = append(required, struct{ key, env, value string }{"backend.sqlitePath", "BACKEND_SQLITE_PATH", c.Backend.SQLitePath})
+    default:
+        errs.addf("backend.type (BACKEND): %q, elvárt: %s, %s vagy %s", c.Backend.Type, BackendOpenSearch, BackendMemory, BackendSQLite)
+    }
+    // Titkosítatlan (fejlesztői) fürtnél jellemzően nincs hitelesítés sem.
+    if c.Backend.Type == BackendOpenSearch && c.OpenSearch.Scheme != "http" && !offline {
+        required = append(required,
+            struct{ key, env, value string }{"opensearch.user", "OPENSEARCH_USER", c.OpenSearch.User},
+            struct{ key, env, value string }{"opensearch.password", "OPENSEARCH_PASSWORD", c.OpenSearch.Password})
+    }
+    for _, r := range required {
+        if r.value == "" {
+            errs.addf("%s (%s): kötelező", r.key, r.env)
+        }
+    }
+
+    switch {
+    case !opensearch.IsMode(c.OpenSearch.Mode):
+        errs.addf("opensearch.mode (OPENSEARCH_MODE): %q, elvárt: %s, %s, %s vagy %s", c.OpenSearch.Mode,
+            opensearch.ModeLive, opensearch.ModeRecord, opensearch.ModeReplay, opensearch.ModeStub)
+    case c.OpenSearch.Mode == opensearch.ModeRecord && c.OpenSearch.FixtureDir == "":
+        errs.addf("opensearch.fixtureDir (OPENSEARCH_FIXTURE_DIR): kötelező %s módban", opensearch.ModeRecord)
+    case c.OpenSearch.Mode == opensearch.ModeReplay:
+        if info, err := os.Stat(c.OpenSearch.FixtureDir); err != nil || !info.IsDir() {
+            errs.addf("opensearch.fixtureDir (OPENSEARCH_FIXTURE_DIR): %q, elvárt: létező könyvtár %s módban", c.OpenSearch.FixtureDir, opensearch.ModeReplay)
+        }
+    }
+
+    switch c.OpenSearch.Scheme {
+    case "https":
+        if _, err := c.OpenSearch.TLSConfig(); err != nil {
+            errs.addf("opensearch TLS (OPENSEARCH_CA_CERT, OPENSEARCH_CLIENT_CERT, OPENSEARCH_CLIENT_KEY): %v", err)
+        }
+    case "http":
+        if c.OpenSearch.CACert != "" || c.OpenSearch.ClientCert != "" || c.OpenSearch.ClientKey != "" || c.OpenSearch.InsecureSkipVerify {
+            errs.addf("opensearch.scheme (OPENSEARCH_SCHEME): a TLS beállítások csak https sémával használhatók")
+        }
+    default:
+        errs.addf("opensearch.scheme (OPENSEARCH_SCHEME): %q, elvárt: https vagy http", c.OpenSearch.Scheme)
+    }
+
+    if err := c.Server.validateTLS(); err != nil {
+        errs.addf("server TLS (TLS_CERT_FILE, TLS_KEY_FILE, AUTOCERT_HOSTS, HTTP_REDIRECT_PORT): %v", err)
+    }
+    if !i18n.IsSupported(c.Server.DefaultLanguage) {
+        errs.addf("server.defaultLanguage (DEFAULT_LANGUAGE): %q, elvárt: %s", c.Server.DefaultLanguage, strings.Join(i18n.Supported, " vagy "))
+    }
+
+    positive := func(key, env string, ok bool) {
+        if !ok {
+            errs.addf("%s (%s): érvénytelen érték", key, env)
+        }
+    }
+    positive("opensearch.timeout", "OPENSEARCH_TIMEOUT", c.OpenSearch.Timeout > 0)
+    positive("opensearch.maxIdleConnsPerHost", "OPENSEARCH_MAX_IDLE_CONNS_PER_HOST", c.OpenSearch.MaxIdleConnsPerHost > 0)
+    positive("opensearch.maxRetries", "OPENSEARCH_MAX_RETRIES", c.OpenSearch.MaxRetries >= 0)
+    positive("opensearch.retryBaseDelay", "OPENSEARCH_RETRY_BASE_DELAY", c.OpenSearch.RetryBaseDelay > 0)
+    positive("opensearch.retryMaxDelay", "OPENSEARCH_RETRY_MAX_DELAY", c.OpenSearch.RetryMaxDelay > 0)
+    positive("opensearch.circuitFailureThreshold", "CIRCUIT_FAILURE_THRESHOLD", c.OpenSearch.CircuitFailureThreshold >= 0)
+    positive("opensearch.circuitOpenTimeout", "CIRCUIT_OPEN_TIMEOUT", c.OpenSearch.CircuitOpenTimeout > 0)
+    positive("opensearch.slowQueryThreshold", "SLOW_QUERY_THRESHOLD", c.OpenSearch.SlowQueryThreshold >= 0)
+    positive("opensearch.pingInterval", "OPENSEARCH_PING_INTERVAL", c.OpenSearch.PingInterval >= 0)
+    if c.OpenSearch.Host != "" {
+        for _, host := range strings.Split(c.OpenSearch.Host, ",") {
+            if strings.TrimSpace(host) == "" {
+                errs.addf("opensearch.host (OPENSEARCH_HOST): üres csomópont a listában: %q", c.OpenSearch.Host)
+                break
+            }
+        }
+    }
+    positive("server.shutdownTimeout", "SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout >= 0)
+    positive("server.maxResponseBytes", "MAX_RESPONSE_BYTES", c.Server.MaxResponseBytes >= 0)
+    positive("search.suggestionLimit", "SUGGESTION_LIMIT", c.Search.SuggestionLimit > 0)
+    positive("search.validateBatchMax", "VALIDATE_BATCH_MAX", c.Search.ValidateBatchMax > 0)
+    positive("search.minQueryLength", "MIN_QUERY_LEN", c.Search.MinQueryLength > 0)
+    positive("search.maxQueryLength", "MAX_QUERY_LEN", c.Search.MaxQueryLength >= 0)
+    if c.Search.MaxQueryLength > 0 && c.Search.MaxQueryLength < c.Search.MinQueryLength {
+        errs.addf("search.maxQueryLength (MAX_QUERY_LEN): %d, nem lehet kisebb a MIN_QUERY_LEN-nél (%d)", c.Search.MaxQueryLength, c.Search.MinQueryLength)
+    }
+    positive("search.subQueryTimeout", "SEARCH_SUBQUERY_TIMEOUT", c.Search.SubQueryTimeout >= 0)
+    positive("search.weightBoost", "WEIGHT_BOOST", c.Search.WeightBoost >= 0)
+    positive("search.keyboardTypoMinResults", "KEYBOARD_TYPO_MIN_RESULTS", c.Search.KeyboardTypoMinResults >= 0)
+    if _, err := suggest.ParsePipeline(c.Search.RankingPipeline); err != nil {
+        errs.addf("search.rankingPipeline (RANKING_PIPELINE): %v", err)
+    }
+    if c.Search.CanaryPercent < 0 || c.Search.CanaryPercent > 100 {
+        errs.addf("search.canaryPercent (CANARY_PERCENT): %g, elvárt: 0 és 100 közötti szám", c.Search.CanaryPercent)
+    }
+    if c.Search.CanaryIndex == index.DefaultName {
+        errs.addf("search.canaryIndex (CANARY_INDEX): nem lehet a stabil index (%s)", index.DefaultName)
+    }
+    for name, field := range c.Search.SuggestFields {
+        if !index.IsSuggestField(field) {
+            errs.addf("search.suggestFields (SUGGEST_FIELDS): a(z) %q névhez nem javaslatmező tartozik: %q", name, field)
+        }
+    }
+    positive("cache.size", "CACHE_SIZE", c.Cache.Size >= 0)
+    positive("cache.ttl", "CACHE_TTL", c.Cache.TTL >= 0)
+    positive("cache.staleTimeout", "STALE_TIMEOUT", c.Cache.StaleTimeout >= 0)
+    positive("rateLimit.rps", "RATE_LIMIT_RPS", c.RateLimit.RPS >= 0)
+    positive("rateLimit.burst", "RATE_LIMIT_BURST", c.RateLimit.Burst > 0)
+    positive("concurrency.default", "CONCURRENCY_DEFAULT_LIMIT", c.Concurrency.Default >= 0)
+    positive("concurrency.opensearch", "OPENSEARCH_MAX_CONCURRENCY", c.Concurrency.OpenSearch >= 0)
+    positive("concurrency.queueTimeout", "CONCURRENCY_QUEUE_TIMEOUT", c.Concurrency.QueueTimeout >= 0)
+    for path, limit := range c.Concurrency.Endpoints {
+        if !strings.HasPrefix(path, "/") || limit < 0 {
+            errs.addf("concurrency.endpoints (CONCURRENCY_LIMITS): érvénytelen bejegyzés: %q=%d", path, limit)
+        }
+    }
+    positive("import.bulkBatchSize", "BULK_BATCH_SIZE", c.Import.BulkBatchSize > 0)
+    positive("httpCache.maxAge", "HTTP_CACHE_MAX_AGE", c.HTTPCache.MaxAge >= 0)
+    positive("materialize.maxPrefixLength", "MATERIALIZE_MAX_PREFIX_LENGTH", c.Materialize.MaxPrefixLength > 0)
+    if c.Demo.APIBasePath != "" && !strings.HasPrefix(c.Demo.APIBasePath, "/") {
+        errs.addf("demo.apiBasePath (DEMO_API_BASE_PATH): %q, \"/\" jellel kell kezdődnie", c.Demo.APIBasePath)
+    }
+    for _, origin := range c.Widget.AllowedOrigins {
+        if origin == "*" {
+            continue
+        }
+        if u, err := url.Parse(origin); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || (u.Path != "" && u.Path != "/") {
+            errs.addf("widget.allowedOrigins (CORS_ALLOWED_ORIGINS): %q, elvárt: séma://host[:port] vagy *", origin)
+        }
+    }
+    positive("widget.debounce", "WIDGET_DEBOUNCE", c.Widget.Debounce >= 0)
+    positive("materialize.interval", "MATERIALIZE_INTERVAL", c.Materialize.Interval >= 0)
+    positive("popularity.flushInterval", "SELECTION_FLUSH_INTERVAL", c.Popularity.FlushInterval > 0)
+    positive("analytics.retention", "ANALYTICS_RETENTION", c.Analytics.Retention >= analytics.BucketSize)
+    positive("compression.minSize", "COMPRESSION_MIN_SIZE", c.Compression.MinSize >= 0)
+    if len(c.Kafka.Brokers) > 0 {
+        if c.Backend.Type != BackendOpenSearch {
+            errs.addf("kafka.brokers (KAFKA_BROKERS): csak %s háttérrendszerrel használható", BackendOpenSearch)
+        }
+        if c.Kafka.Topic == "" {
+            errs.addf("kafka.topic (KAFKA_TOPIC): kötelező, ha a KAFKA_BROKERS meg van adva")
+        }
+        if c.Kafka.GroupID == "" {
+            errs.addf("kafka.groupId (KAFKA_GROUP_ID): kötelező, ha a KAFKA_BROKERS meg van adva")
+        }
+        positive("kafka.batchSize", "KAFKA_BATCH_SIZE", c.Kafka.BatchSize > 0)
+        positive("kafka.flushInterval", "KAFKA_FLUSH_INTERVAL", c.Kafka.FlushInterval > 0)
+    }
+    if c.Resync.Source != "" {
+        if c.Backend.Type != BackendOpenSearch {
+            errs.addf("resync.source (RESYNC_SOURCE): csak %s háttérrendszerrel használható", BackendOpenSearch)
+        }
+        if u, err := url.Parse(c.Resync.Source); err != nil || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "s3") || u.Host == "" {
+            errs.addf("resync.source (RESYNC_SOURCE): %q, elvárt: http(s):// URL vagy s3://bucket/kulcs", c.Resync.Source)
+        } else if u.Scheme == "s3" && c.Resync.S3Region == "" {
+            errs.addf("resync.s3Region (RESYNC_S3_REGION): kötelező s3:// forrás esetén")
+        }
+        positive("resync.timeout", "RESYNC_TIMEOUT", c.Resync.Timeout > 0)
+    }
+    if c.Resync.Schedule != "" {
+        if _, err := resync.ParseSchedule(c.Resync.Schedule); err != nil {
+            errs.addf("resync.schedule (RESYNC_SCHEDULE): %v", err)
+        }
+        if c.Resync.Source == "" {
+            errs.addf("resync.source (RESYNC_SOURCE): kötelező, ha a RESYNC_SCHEDULE meg van adva")
+        }
+    }
+    positive("mappingCheck.interval", "MAPPING_CHECK_INTERVAL", c.MappingCheck.Interval >= 0)
+    positive("mappingCheck.timeout", "MAPPING_CHECK_TIMEOUT", c.MappingCheck.Timeout > 0)
+    if c.MappingCheck.WebhookURL != "" {
+        if u, err := url.Parse(c.MappingCheck.WebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+            errs.addf("mappingCheck.webhookUrl (MAPPING_CHECK_WEBHOOK_URL): %q, elvárt: http(s):// URL", c.MappingCheck.WebhookURL)
+        }
+    }
+
+    c.validateQueryMode(errs, "search.queryMode (QUERY_MODE)", c.Search.QueryMode)
+    if c.Search.FoldAccents && c.Search.QueryMode != suggest.QueryModeNgram {
+        errs.addf("search.foldAccents (FOLD_ACCENTS): csak %s lekérdezési módban használható", suggest.QueryModeNgram)
+    }
+    if c.Experiment.Name != "" {
+        c.validateExperiment(errs)
+    }
+    switch c.Index.FieldStrategy {
+    case index.FieldStrategyNgram, index.FieldStrategySearchAsYouType:
+    default:
+        errs.addf("index.fieldStrategy (INDEX_FIELD_STRATEGY): %q, elvárt: %s vagy %s", c.Index.FieldStrategy,
+            index.FieldStrategyNgram, index.FieldStrategySearchAsYouType)
+    }
+    if c.Index.CardinalityPrecision < 1 || c.Index.CardinalityPrecision > 40000 {
+        errs.addf("index.cardinalityPrecision (INDEX_CARDINALITY_PRECISION): %d, elvárt: 1 és 40000 közötti egész", c.Index.CardinalityPrecision)
+    }
+    if !suggest.IsSortMode(c.Search.DefaultSort) {
+        errs.addf("search.defaultSort (DEFAULT_SORT): %q, elvárt: %s, %s vagy %s", c.Search.DefaultSort, suggest.SortRelevance, suggest.SortAlphabetical, suggest.SortPopularity)
+    }
+    if !geoScalePattern.MatchString(c.Search.GeoScale) {
+        errs.addf("search.geoScale (GEO_SCALE): %q, elvárt: távolság km vagy m egységgel (pl. 25km)", c.Search.GeoScale)
+    }
+    if _, err := ParseTrustedProxies(strings.Join(c.RateLimit.TrustedProxies, ",")); err != nil {
+        errs.addf("rateLimit.trustedProxies (TRUSTED_PROXIES): %v", err)
+    }
+    if !index.IsDuplicatePolicy(c.Import.DuplicatePolicy) {
+        errs.addf("import.duplicatePolicy (IMPORT_DUPLICATE_POLICY): %q, elvárt: %s, %s vagy %s", c.Import.DuplicatePolicy,
+            index.DuplicatePolicyOff, index.DuplicatePolicyFlag, index.DuplicatePolicyMerge)
+    }
+    for header, field := range c.Import.CSVHeaderMapping {
+        if !index.IsAddressField(field) {
+            errs.addf("import.csvHeaderMapping (CSV_HEADER_MAPPING): ismeretlen mező a(z) %q oszlophoz: %q", header, field)
+        }
+    }
+}
+
+// URLs a Host csomópontjainak alap URL-jei a Scheme-mel; a port nélküli csomópontok a Port
+// értékét kapják (IPv6 címnél szögletes zárójelben, pl. "[::1]" vagy "[::1]:9201").
+func (c OpenSearchConfig) URLs() []string {
+    var urls []string
+    for _, host := range strings.Split(c.Host, ",") {
+        host = strings.TrimSpace(host)
+        if host == "" {
+            continue
+        }
+        if _, _, err := net.SplitHostPort(host); err != nil {
+            host = net.JoinHostPort(strings.Trim(host, "[]"), c.Port)
+        }
+        urls = append(urls, c.Scheme+"://"+host)
+    }
+    return urls
+}
+
+// TLSConfig az OpenSearch kapcsolat TLS beállításait állítja össze. Nil-t ad vissza, ha
+// nincs eltérés az alapértelmezéstől (rendszer CA-k, ellenőrzés bekapcsolva, nincs kliens tanúsítvány).
+func (c OpenSearchConfig) TLSConfig() (*tls.Config, error) {
+    if c.CACert == "" && c.ClientCert == "" && c.ClientKey == "" && !c.InsecureSkipVerify {
+        return nil, nil
+    }
+    tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: c.InsecureSkipVerify}
+    if c.CACert != "" {
+        pem, err := os.ReadFile(c.CACert)
+        if err != nil {
+            return nil, fmt.Errorf("a CA tanúsítvány nem olvasható: %w", err)
+        }
+        pool := x509.NewCertPool()
+        if !pool.AppendCertsFromPEM(pem) {
+            return nil, fmt.Errorf("a CA fájl (%s) nem tartalmaz PEM tanúsítványt", c.CACert)
+        }
+        tlsConfig.RootCAs = pool
+    }
+    if (c.ClientCert == "") != (c.ClientKey == "") {
+        return nil, errors.New("a kliens tanúsítványhoz és kulcshoz mindkét fájl megadása szükséges")
+    }
+    if c.ClientCert != "" {
+        cert, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+        if err != nil {
+            return nil, fmt.Errorf("a kliens tanúsítvány nem tölthető be: %w", err)
+        }
+        tlsConfig.Certificates = []tls.Certificate{cert}
+    }
+    return tlsConfig, nil
+}
+
+// ParseLogLevel a LOG_LEVEL értékét slog szintté alakítja.
+func ParseLogLevel(level string) (slog.Level, error) {
+    var lvl slog.Level
+    if err := lvl.UnmarshalText([]byte(level)); err != nil {
+        return lvl, fmt.Errorf("ismeretlen naplózási szint: %q", level)
+    }
+    return lvl, nil
+}
+
+// ParseTrustedProxies feldolgozza a vesszővel elválasztott CIDR (vagy egyedi IP) listát (TRUSTED_PROXIES).
+func ParseTrustedProxies(spec string) ([]*net.IPNet, error) {
+    var nets []*net.IPNet
+    for _, item := range strings.Split(spec, ",") {
+        item = strings.TrimSpace(item)
+        if item == "" {
+            continue
+        }
+        if !strings.Contains(item, "/") {
+            if ip := net.ParseIP(item); ip != nil && ip.To4() != nil {
+                item += "/32"
+            } else {
+                item += "/128"
+            }
+        }
+        _, ipNet, err := net.ParseCIDR(item)
+        if err != nil {
+            return nil, err
+        }
+        nets = append(nets, ipNet)
+    }
+    return nets, nil
+}
+
+// ParseConcurrencyLimits feldolgozza a vesszővel elválasztott útvonal=korlát párokat (CONCURRENCY_LIMITS).
+func ParseConcurrencyLimits(spec string) (map[string]int, error) {
+    limits := map[string]int{}
+    for _, item := range strings.Split(spec, ",") {
+        item = strings.TrimSpace(item)
+        if item == "" {
+            continue
+        }
+        path, value, ok := strings.Cut(item, "=")
+        if !ok {
+            return nil, fmt.Errorf("hiányzó '=' a(z) %q bejegyzésben", item)
+        }
+        limit, err := strconv.Atoi(strings.TrimSpace(value))
+        if err != nil {
+            return nil, fmt.Errorf("érvénytelen korlát a(z) %q bejegyzésben", item)
+        }
+        limits[strings.TrimSpace(path)] = limit
+    }
+    return limits, nil
+}
+
+// validateQueryMode a key beállítás mode lekérdezési módját ellenőrzi: ismert mód-e, és a
+// search_as_you_type módhoz megfelelő-e az index mezőstratégiája.
+func (c *Config) validateQueryMode(errs *Errors, key, mode string) {
+    switch mode {
+    case suggest.QueryModeNgram, suggest.QueryModeRegex, suggest.QueryModeCompletion, suggest.QueryModeSearchAsYouType:
+    default:
+        errs.addf("%s: %q, elvárt: %s, %s, %s vagy %s", key, mode,
+            suggest.QueryModeNgram, suggest.QueryModeRegex, suggest.QueryModeCompletion, suggest.QueryModeSearchAsYouType)
+    }
+    if mode == suggest.QueryModeSearchAsYouType && c.Index.FieldStrategy != index.FieldStrategySearchAsYouType {
+        errs.addf("%s: a %s módhoz index.fieldStrategy (INDEX_FIELD_STRATEGY) = %s szükséges",
+            key, suggest.QueryModeSearchAsYouType, index.FieldStrategySearchAsYouType)
+    }
+}
+
+// validateExperiment a bekapcsolt kísérlet változatait ellenőrzi: legalább kettő kell, egyedi
+// nevekkel és pozitív súlyokkal, a felülírt lekérdezési mód és rangsorolás pedig a QUERY_MODE és a
+// RANKING_PIPELINE szabályai szerint érvényes.
+func (c *Config) validateExperiment(errs *Errors) {
+    if c.Experiment.ClientHeader == "" {
+        errs.addf("experiment.clientHeader (EXPERIMENT_CLIENT_HEADER): nem lehet üres")
+    }
+    if len(c.Experiment.Variants) < 2 {
+        errs.addf("experiment.variants (EXPERIMENT_VARIANTS): legalább két változat szükséges, kapott: %d", len(c.Experiment.Variants))
+    }
+    seen := map[string]bool{}
+    for _, v := range c.Experiment.Variants {
+        if v.Name == "" || seen[v.Name] {
+            errs.addf("experiment.variants (EXPERIMENT_VARIANTS): a változatnév nem lehet üres vagy ismétlődő: %q", v.Name)
+        }
+        seen[v.Name] = true
+        if v.Weight <= 0 {
+            errs.addf("experiment.variants (EXPERIMENT_VARIANTS): a(z) %q változat súlya pozitív egész lehet, kapott: %d", v.Name, v.Weight)
+        }
+        if v.QueryMode != "" {
+            c.validateQueryMode(errs, fmt.Sprintf("experiment.variants (EXPERIMENT_VARIANTS): a(z) %q változat queryMode értéke", v.Name), v.QueryMode)
+            if c.Search.FoldAccents && v.QueryMode != suggest.QueryModeNgram {
+                errs.addf("experiment.variants (EXPERIMENT_VARIANTS): a(z) %q változat: a FOLD_ACCENTS csak %s lekérdezési módban használható", v.Name, suggest.QueryModeNgram)
+            }
+        }
+        if _, err := suggest.ParsePipeline(v.RankingPipeline); err != nil {
+            errs.addf("experiment.variants (EXPERIMENT_VARIANTS): a(z) %q változat: %v", v.Name, err)
+        }
+    }
+}
+
+// ParseSuggestFields feldolgozza a vesszővel elválasztott név=mező párokat (SUGGEST_FIELDS); a
+// mezőket a validate ellenőrzi (lásd index.IsSuggestField).
+func ParseSuggestFields(spec string) (map[string]string, error) {
+    fields := map[string]string{}
+    for _, item := range strings.Split(spec, ",") {
+        item = strings.TrimSpace(item)
+        if item == "" {
+            continue
+        }
+        name, field, ok := strings.Cut(item, "=")
+        if !ok {
+            return nil, fmt.Errorf("hiányzó '=' a(z) %q bejegyzésben", item)
+        }
+        name, field = strings.TrimSpace(name), strings.TrimSpace(field)
+        if name == "" {
+            return nil, fmt.Errorf("hiányzó név a(z) %q bejegyzésben", item)
+        }
+        fields[name] = field
+    }
+    return fields, nil
+}
+
+// validateTLS ellenőrzi a HTTPS listener beállításainak összefüggéseit.
+func (c ServerConfig) validateTLS() error {
+    if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+        return errors.New("a tanúsítvány és a kulcs fájlt együtt kell megadni")
+    }
+    if c.TLSCertFile != "" && len(c.AutocertHosts) > 0 {
+        return errors.New("a tanúsítványfájlok és az autocert nem használható együtt")
+    }
+    if c.TLSCertFile != "" {
+        if _, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile); err != nil {
+            return err
+        }
+    }
+    if c.HTTPRedirectPort != "" && c.TLSCertFile == "" && len(c.AutocertHosts) == 0 {
+        return errors.New("az átirányító listenerhez TLS beállítás szükséges")
+    }
+    return nil
+}
+
+// WithoutReloadable a konfiguráció másolatát adja vissza a futás közben módosítható mezők
+// alapértékével, így két konfiguráció összevetésekor csak a rögzített beállítások eltérése számít.
+func (c Config) WithoutReloadable() Config {
+    defaults := Default()
+    c.Logging.Level = defaults.Logging.Level
+    c.Server.DebugEnabled = defaults.Server.DebugEnabled
+    c.Server.DefaultLanguage = defaults.Server.DefaultLanguage
+    c.Server.ResponseMeta = defaults.Server.ResponseMeta
+    c.Server.MaxResponseBytes = defaults.Server.MaxResponseBytes
+    c.Search = defaults.Search
+    c.Cache.TTL = defaults.Cache.TTL
+    c.Cache.StaleWhileRevalidate = defaults.Cache.StaleWhileRevalidate
+    c.Cache.StaleTimeout = defaults.Cache.StaleTimeout
+    c.RateLimit.RPS = defaults.RateLimit.RPS
+    c.RateLimit.Burst = defaults.RateLimit.Burst
+    c.HTTPCache = defaults.HTTPCache
+    c.Materialize.Serve = defaults.Materialize.Serve
+    c.Materialize.MaxPrefixLength = defaults.Materialize.MaxPrefixLength
+    c.Popularity.Ranking = defaults.Popularity.Ranking
+    return c
+}
@@ -0,0 +1,66 @@
+package dsl
+
+// Agg egy aggregáció JSON alakja.
+type Agg map[string]interface{}
+
+// TermsAgg a terms aggregáció paraméterei; az Include reguláris kifejezés a kulcsokra. Az Order
+// egyetlen rendezési feltétel (map[string]string) vagy azok sorrendben alkalmazott listája.
+type TermsAgg struct {
+    Field   string      `json:"field"`
+    Size    int         `json:"size,omitempty"`
+    Include string      `json:"include,omitempty"`
+    Order   interface{} `json:"order,omitempty"`
+}
+
+// Terms a mező egyedi értékeit gyűjti vödrökbe.
+func Terms(t TermsAgg) Agg {
+    return Agg{"terms": t}
+}
+
+// CardinalityAgg a cardinality aggregáció paraméterei; a PrecisionThreshold alatti darabszámok
+// közel pontosak (0: az OpenSearch alapértéke, legfeljebb 40000).
+type CardinalityAgg struct {
+    Field              string `json:"field"`
+    PrecisionThreshold int    `json:"precision_threshold,omitempty"`
+}
+
+// Cardinality a mező egyedi értékeinek becsült száma.
+func Cardinality(field string) Agg {
+    return CardinalityOf(CardinalityAgg{Field: field})
+}
+
+// CardinalityOf a Cardinality a c paramétereivel.
+func CardinalityOf(c CardinalityAgg) Agg {
+    return Agg{"cardinality": c}
+}
+
+// GeoCentroid a geo_point mező értékeinek súlypontja (az AggResult.Location-be kerül).
+func GeoCentroid(field string) Agg {
+    return Agg{"geo_centroid": map[string]interface{}{"field": field}}
+}
+
+// FilterAgg a lekérdezésnek megfelelő dokumentumokat számolja meg.
+func FilterAgg(q Query) Agg {
+    return Agg{"filter": q}
+}
+
+// Global a lekérdezéstől független, az index összes dokumentumát tartalmazó vödör; az
+// al-aggregációival a lekérdezés szűkítése nélkül lehet számolni.
+func Global() Agg {
+    return Agg{"global": map[string]interface{}{}}
+}
+
+// TopHits a vödör első size találatát adja vissza, a source mezőkre szűkítve.
+func TopHits(size int, source ...string) Agg {
+    params := map[string]interface{}{"size": size}
+    if len(source) > 0 {
+        params["_source"] = source
+    }
+    return Agg{"top_hits": params}
+}
+
+// With al-aggregációkat ad az aggregációhoz.
+func (a Agg) With(sub map[string]Agg) Agg {
+    a["aggs"] = sub
+    return a
+}
@@ -0,0 +1,30 @@
+package dsl
+
+// Property egy mező mappingje. Csak a szolgáltatás által használt beállításokat tartalmazza,
+// így az élő mappingből beolvasva a többi beállítás elvész; összevetésre és új mezők
+// felvételére alkalmas, a teljes mapping visszaírására nem.
+type Property struct {
+    Type           string              `json:"type,omitempty"`
+    Analyzer       string              `json:"analyzer,omitempty"`
+    SearchAnalyzer string              `json:"search_analyzer,omitempty"`
+    Normalizer     string              `json:"normalizer,omitempty"`
+    Fields         map[string]Property `json:"fields,omitempty"`
+    Properties     map[string]Property `json:"properties,omitempty"`
+    Contexts       []CompletionContext `json:"contexts,omitempty"`
+}
+
+// CompletionContext egy completion mező kontextusa (pl. category típusú szűrőmező).
+type CompletionContext struct {
+    Name string `json:"name"`
+    Type string `json:"type"`
+}
+
+// MappingResponse a GET /<index>/_mapping válasza, konkrét index nevek szerint.
+type MappingResponse map[string]struct {
+    Mappings struct {
+        Meta struct {
+            MappingVersion int `json:"mapping_version"`
+        } `json:"_meta"`
+        Properties map[string]Property `json:"properties"`
+    } `json:"mappings"`
+}
@@ -0,0 +1,101 @@
+// Package dsl típusos építőelemeket ad az OpenSearch keresési kéréseihez és válaszaihoz, így a
+// lekérdezések összeállítása és a válaszok feldolgozása nem egymásba ágyazott map-ekkel és
+// típusellenőrzésekkel történik.
+package dsl
+
+// Query egy lekérdezési záradék (term, match, bool, ...) JSON alakja.
+type Query map[string]interface{}
+
+// Term pontos egyezést követel meg a mezőn.
+func Term(field string, value interface{}) Query {
+    return Query{"term": map[string]interface{}{field: value}}
+}
+
+// TermCaseInsensitive kis-nagybetű független pontos egyezés keyword mezőn.
+func TermCaseInsensitive(field, value string) Query {
+    return Query{"term": map[string]interface{}{
+        field: map[string]interface{}{"value": value, "case_insensitive": true},
+    }}
+}
+
+// Exists azokra a dokumentumokra illeszkedik, amelyekben a mezőnek van értéke.
+func Exists(field string) Query {
+    return Query{"exists": map[string]interface{}{"field": field}}
+}
+
+// GeoDistance a geo_point mező (lat, lon) körüli distance sugarú körbe eső dokumentumaira
+// illeszkedik; a distance mértékegységgel adandó meg ("800m", "5km").
+func GeoDistance(field string, lat, lon float64, distance string) Query {
+    return Query{"geo_distance": map[string]interface{}{
+        "distance": distance,
+        field:      map[string]float64{"lat": lat, "lon": lon},
+    }}
+}
+
+// MatchAll minden dokumentumra illeszkedik.
+func MatchAll() Query {
+    return Query{"match_all": map[string]interface{}{}}
+}
+
+// WildcardCaseInsensitive kis-nagybetű független wildcard illesztés keyword mezőn ("*" és "?").
+func WildcardCaseInsensitive(field, value string) Query {
+    return Query{"wildcard": map[string]interface{}{
+        field: map[string]interface{}{"value": value, "case_insensitive": true},
+    }}
+}
+
+// Prefix a megadott előtaggal kezdődő értékekre illeszkedik.
+func Prefix(field, value string) Query {
+    return Query{"prefix": map[string]interface{}{field: value}}
+}
+
+// PrefixCaseInsensitive kis-nagybetű független előtag-illesztés keyword mezőn.
+func PrefixCaseInsensitive(field, value string) Query {
+    return Query{"prefix": map[string]interface{}{
+        field: map[string]interface{}{"value": value, "case_insensitive": true},
+    }}
+}
+
+// MatchQuery egy match lekérdezés paraméterei; az üres mezők kimaradnak a kérésből.
+type MatchQuery struct {
+    Query        string `json:"query"`
+    Operator     string `json:"operator,omitempty"`
+    Fuzziness    string `json:"fuzziness,omitempty"`
+    PrefixLength int    `json:"prefix_length,omitempty"`
+}
+
+// Match teljes szöveges keresés a mezőn.
+func Match(field string, m MatchQuery) Query {
+    return Query{"match": map[string]interface{}{field: m}}
+}
+
+// MultiMatchQuery egy multi_match lekérdezés paraméterei; az üres mezők kimaradnak a kérésből.
+type MultiMatchQuery struct {
+    Query    string   `json:"query"`
+    Type     string   `json:"type,omitempty"`
+    Fields   []string `json:"fields"`
+    Operator string   `json:"operator,omitempty"`
+}
+
+// MultiMatch a szöveget egyszerre több mezőn keresi.
+func MultiMatch(m MultiMatchQuery) Query {
+    return Query{"multi_match": m}
+}
+
+// BoolQuery a bool lekérdezés ágai; az üres ágak kimaradnak a kérésből.
+type BoolQuery struct {
+    Must    []Query `json:"must,omitempty"`
+    Filter  []Query `json:"filter,omitempty"`
+    Should  []Query `json:"should,omitempty"`
+    MustNot []Query `json:"must_not,omitempty"`
+}
+
+// Bool a záradékokat bool lekérdezésbe foglalja.
+func Bool(b BoolQuery) Query {
+    return Query{"bool": b}
+}
+
+// Filter a záradékokat pontozás nélküli bool filter-be foglalja.
+func Filter(filters ...Query) Query {
+    return Bool(BoolQuery{Filter: filters})
+}
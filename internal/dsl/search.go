@@ -0,0 +1,178 @@
+package dsl
+
+import (
+    "encoding/json"
+    "strings"
+)
+
+// Search egy _search kérés törzse. A Size nulla értéke is kikerül a kérésbe, mivel a
+// csak aggregációt futtató lekérdezéseknél így kérjük, hogy ne jöjjenek találatok.
+type Search struct {
+    Size           int                    `json:"size"`
+    Source         []string               `json:"_source,omitempty"`
+    TrackTotalHits interface{}            `json:"track_total_hits,omitempty"`
+    Query          Query                  `json:"query,omitempty"`
+    Sort           []interface{}          `json:"sort,omitempty"`
+    Aggs           map[string]Agg         `json:"aggs,omitempty"`
+    Suggest        map[string]interface{} `json:"suggest,omitempty"`
+    // StoredFields ["_none_"] értékkel a találatok _source és metaadat mezők nélkül érkeznek
+    // (pl. ha csak a Hit.Sort értékei kellenek).
+    StoredFields []string `json:"stored_fields,omitempty"`
+    // PIT és SearchAfter a point-in-time lapozáshoz: a kérés a PIT pillanatképében fut (ilyenkor
+    // az útvonalban nem szerepelhet index), és az előző lap utolsó találatának Sort értékei után
+    // folytatódik.
+    PIT         *PIT          `json:"pit,omitempty"`
+    SearchAfter []interface{} `json:"search_after,omitempty"`
+}
+
+// PIT egy point-in-time pillanatkép azonosítója és a lejárata, amelyet minden kérés meghosszabbít.
+type PIT struct {
+    ID        string `json:"id"`
+    KeepAlive string `json:"keep_alive,omitempty"`
+}
+
+// GeoDistanceSort a találatokat a geo_point mező (lat, lon) ponttól mért távolsága szerint
+// növekvő sorrendbe rendezi; a találat Sort[0] értéke a távolság méterben.
+func GeoDistanceSort(field string, lat, lon float64) map[string]interface{} {
+    return map[string]interface{}{"_geo_distance": map[string]interface{}{
+        field:   map[string]float64{"lat": lat, "lon": lon},
+        "order": "asc",
+        "unit":  "m",
+    }}
+}
+
+// SearchResponse a _search válaszának a szolgáltatás által használt része.
+type SearchResponse struct {
+    Hits         HitList              `json:"hits"`
+    Aggregations map[string]AggResult `json:"aggregations"`
+    // PITID point-in-time keresésnél a pillanatkép (esetleg megváltozott) azonosítója, amellyel a
+    // következő lapot kérni kell.
+    PITID string `json:"pit_id,omitempty"`
+}
+
+// HitList a találatok listája a becsült összes találatszámmal.
+type HitList struct {
+    Total struct {
+        Value int `json:"value"`
+    } `json:"total"`
+    Hits []Hit `json:"hits"`
+}
+
+// Hit egy találat; a Source a dokumentum nyers JSON alakja.
+type Hit struct {
+    Index  string          `json:"_index"`
+    ID     string          `json:"_id"`
+    Score  float64         `json:"_score"`
+    Source json.RawMessage `json:"_source"`
+    // Sort a rendezési kulcsok értékei (csak rendezett keresésnél, pl. GeoDistanceSort).
+    Sort []interface{} `json:"sort,omitempty"`
+}
+
+// Decode a találat dokumentumát v-be olvassa.
+func (h Hit) Decode(v interface{}) error {
+    return json.Unmarshal(h.Source, v)
+}
+
+// AggResult egy aggregáció eredménye. A típustól függően a Buckets (terms), a Value
+// (metrikák), a DocCount (filter), a Hits (top_hits) vagy a Location (geo_centroid; nil, ha
+// egyik dokumentumnak sincs helyadata) kerül kitöltésre; a Sub az al-aggregációk eredménye
+// név szerint.
+type AggResult struct {
+    DocCount int
+    Value    float64
+    Buckets  []Bucket
+    Hits     HitList
+    Location *Point
+    Sub      map[string]AggResult
+}
+
+// Point egy geo_point érték vagy geo_centroid eredmény.
+type Point struct {
+    Lat float64 `json:"lat"`
+    Lon float64 `json:"lon"`
+}
+
+// Bucket egy terms vödör.
+type Bucket struct {
+    Key      string
+    DocCount int
+    Sub      map[string]AggResult
+}
+
+// Keys a vödrök kulcsai sorrendben.
+func (a AggResult) Keys() []string {
+    keys := make([]string, 0, len(a.Buckets))
+    for _, b := range a.Buckets {
+        keys = append(keys, b.Key)
+    }
+    return keys
+}
+
+// UnmarshalJSON az ismert mezőket közvetlenül, a többi objektum értékű kulcsot
+// al-aggregációként olvassa be.
+func (a *AggResult) UnmarshalJSON(data []byte) error {
+    var known struct {
+        DocCount int      `json:"doc_count"`
+        Value    *float64 `json:"value"`
+        Buckets  []Bucket `json:"buckets"`
+        Hits     HitList  `json:"hits"`
+        Location *Point   `json:"location"`
+    }
+    if err := json.Unmarshal(data, &known); err != nil {
+        return err
+    }
+    *a = AggResult{DocCount: known.DocCount, Buckets: known.Buckets, Hits: known.Hits, Location: known.Location}
+    if known.Value != nil {
+        a.Value = *known.Value
+    }
+    sub, err := subAggregations(data, "doc_count", "value", "buckets", "hits", "location")
+    a.Sub = sub
+    return err
+}
+
+// UnmarshalJSON a kulcsot szövegként olvassa be akkor is, ha a mező numerikus.
+func (b *Bucket) UnmarshalJSON(data []byte) error {
+    var known struct {
+        Key         json.RawMessage `json:"key"`
+        KeyAsString string          `json:"key_as_string"`
+        DocCount    int             `json:"doc_count"`
+    }
+    if err := json.Unmarshal(data, &known); err != nil {
+        return err
+    }
+    *b = Bucket{DocCount: known.DocCount, Key: known.KeyAsString}
+    if b.Key == "" {
+        if err := json.Unmarshal(known.Key, &b.Key); err != nil {
+            b.Key = strings.TrimSpace(string(known.Key))
+        }
+    }
+    sub, err := subAggregations(data, "key", "key_as_string", "doc_count")
+    b.Sub = sub
+    return err
+}
+
+// subAggregations a skip-en kívüli, objektum értékű kulcsokat AggResult-ként olvassa be.
+func subAggregations(data []byte, skip ...string) (map[string]AggResult, error) {
+    var raw map[string]json.RawMessage
+    if err := json.Unmarshal(data, &raw); err != nil {
+        return nil, err
+    }
+    for _, name := range skip {
+        delete(raw, name)
+    }
+    var sub map[string]AggResult
+    for name, value := range raw {
+        if len(value) == 0 || value[0] != '{' {
+            continue
+        }
+        var res AggResult
+        if err := json.Unmarshal(value, &res); err != nil {
+            return nil, err
+        }
+        if sub == nil {
+            sub = map[string]AggResult{}
+        }
+        sub[name] = res
+    }
+    return sub, nil
+}
@@ -0,0 +1,224 @@
+// Package experiment a javaslatkérések A/B kísérleteit kezeli: a klienseket az azonosítójuk
+// hash-e alapján a kísérlet változataihoz rendeli, és változatonként gyűjti a kérések és
+// kiválasztások számait az összehasonlító jelentéshez.
+package experiment
+
+import (
+    "fmt"
+    "hash/fnv"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// latencySamples a változatonként megőrzött legutóbbi késleltetésminták száma a percentilisekhez.
+const latencySamples = 1000
+
+// Variant a kísérlet egy változata: a Weight a hozzárendelt kliensek aránya a többi változat
+// súlyához képest, a QueryMode és a RankingPipeline (ha meg van adva) a QUERY_MODE és a
+// RANKING_PIPELINE értékét írja felül a változat kéréseinél; üresen a beállított érték marad.
+type Variant struct {
+    Name            string `yaml:"name"`
+    Weight          int    `yaml:"weight"`
+    QueryMode       string `yaml:"queryMode"`
+    RankingPipeline string `yaml:"rankingPipeline"`
+}
+
+// ParseVariants feldolgozza a pontosvesszővel elválasztott változatlistát (EXPERIMENT_VARIANTS),
+// pl. "control:50; regex:25 queryMode=regex; ranked:25 rankingPipeline=prefix:3,weight:1". A
+// változat neve után kettősponttal a súlya áll (alapértelmezés: 1), utána szóközzel elválasztva a
+// felülírt beállítások kulcs=érték alakban.
+func ParseVariants(spec string) ([]Variant, error) {
+    var variants []Variant
+    for _, item := range strings.Split(spec, ";") {
+        fields := strings.Fields(item)
+        if len(fields) == 0 {
+            continue
+        }
+        name, weightText, hasWeight := strings.Cut(fields[0], ":")
+        v := Variant{Name: name, Weight: 1}
+        if hasWeight {
+            w, err := strconv.Atoi(weightText)
+            if err != nil {
+                return nil, fmt.Errorf("érvénytelen súly a(z) %q változatnál: %q", name, weightText)
+            }
+            v.Weight = w
+        }
+        for _, field := range fields[1:] {
+            key, value, ok := strings.Cut(field, "=")
+            switch {
+            case ok && key == "queryMode":
+                v.QueryMode = value
+            case ok && key == "rankingPipeline":
+                v.RankingPipeline = value
+            default:
+                return nil, fmt.Errorf("ismeretlen beállítás a(z) %q változatnál: %q, elvárt: queryMode= vagy rankingPipeline=", name, field)
+            }
+        }
+        variants = append(variants, v)
+    }
+    return variants, nil
+}
+
+// stats egy változat számlálói.
+type stats struct {
+    requests   int64
+    zero       int64
+    fuzzy      int64
+    results    int64
+    selections int64
+    // latencies a legutóbbi latencySamples késleltetés (ms) körkörös pufferben.
+    latencies []float64
+    next      int
+}
+
+// Experiment egy futó kísérlet a változataival és a számlálóival. Konkurens használatra biztonságos.
+type Experiment struct {
+    name     string
+    variants []Variant
+    total    int
+    started  time.Time
+
+    mu    sync.Mutex
+    stats map[string]*stats
+}
+
+// New a name kísérletet hozza létre a variants változatokkal; az első változat a kontroll, az
+// összehasonlító jelentés ehhez méri a többit. A változatok ellenőrzése a hívó feladata (lásd
+// config.Load): a nevek egyediek, a súlyok pozitívak.
+func New(name string, variants []Variant) *Experiment {
+    e := &Experiment{name: name, variants: variants, started: time.Now(), stats: map[string]*stats{}}
+    for _, v := range variants {
+        e.total += v.Weight
+        e.stats[v.Name] = &stats{}
+    }
+    return e
+}
+
+// Assign a clientID klienst egy változathoz rendeli: a kísérlet nevének és az azonosítónak az
+// FNV-1a hash-e a súlyok arányában választ, így ugyanaz a kliens mindig ugyanazt a változatot
+// kapja, egy új kísérletben viszont a többitől függetlenül sorsolódik.
+func (e *Experiment) Assign(clientID string) Variant {
+    h := fnv.New64a()
+    h.Write([]byte(e.name))
+    h.Write([]byte{0})
+    h.Write([]byte(clientID))
+    n := int(h.Sum64() % uint64(e.total))
+    for _, v := range e.variants {
+        if n < v.Weight {
+            return v
+        }
+        n -= v.Weight
+    }
+    return e.variants[len(e.variants)-1]
+}
+
+// RecordRequest a variant változat egy javaslatkérését rögzíti: a results a visszaadott
+// javaslatok száma, a fuzzy az elgépelés-tűrő tartalék jelzése, a took a kérés késleltetése.
+func (e *Experiment) RecordRequest(variant string, results int, fuzzy bool, took time.Duration) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    st, ok := e.stats[variant]
+    if !ok {
+        return
+    }
+    st.requests++
+    st.results += int64(results)
+    if results == 0 {
+        st.zero++
+    }
+    if fuzzy {
+        st.fuzzy++
+    }
+    ms := float64(took.Microseconds()) / 1000
+    if len(st.latencies) < latencySamples {
+        st.latencies = append(st.latencies, ms)
+    } else {
+        st.latencies[st.next] = ms
+        st.next = (st.next + 1) % latencySamples
+    }
+}
+
+// RecordSelection a variant változat egy kiválasztását (POST /api/select) rögzíti.
+func (e *Experiment) RecordSelection(variant string) {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    if st, ok := e.stats[variant]; ok {
+        st.selections++
+    }
+}
+
+// VariantReport egy változat összesítése. A Requests a változat javaslatlekérdezései (a
+// csoportos és az /api/search kérések szakaszonként számítanak), a SelectionRate a kiválasztások
+// és a kérések aránya (a javaslatok elfogadottsága), a SelectionLift ennek relatív eltérése a
+// kontrolltól (a kontrollnál és kiválasztás nélküli kontroll mellett hiányzik). A késleltetési
+// percentilisek a legutóbbi 1000 kérésből számolódnak.
+type VariantReport struct {
+    Name            string   `json:"name"`
+    Weight          int      `json:"weight"`
+    QueryMode       string   `json:"queryMode,omitempty"`
+    RankingPipeline string   `json:"rankingPipeline,omitempty"`
+    Requests        int64    `json:"requests"`
+    ZeroResults     int64    `json:"zeroResults"`
+    Fuzzy           int64    `json:"fuzzy"`
+    Selections      int64    `json:"selections"`
+    ZeroResultRate  float64  `json:"zeroResultRate"`
+    FuzzyRate       float64  `json:"fuzzyRate"`
+    SelectionRate   float64  `json:"selectionRate"`
+    SelectionLift   *float64 `json:"selectionLift,omitempty"`
+    AvgResults      float64  `json:"avgResults"`
+    LatencyP50Ms    float64  `json:"latencyP50Ms"`
+    LatencyP95Ms    float64  `json:"latencyP95Ms"`
+}
+
+// Report a kísérlet összehasonlító jelentése (GET /api/admin/experiments, expvar "experiment"):
+// a változatok a beállítás sorrendjében, a Control az első változat neve.
+type Report struct {
+    Experiment string          `json:"experiment"`
+    Control    string          `json:"control"`
+    Since      time.Time       `json:"since"`
+    Variants   []VariantReport `json:"variants"`
+}
+
+// Report a számlálók pillanatképéből összeállítja a jelentést.
+func (e *Experiment) Report() Report {
+    e.mu.Lock()
+    defer e.mu.Unlock()
+    report := Report{Experiment: e.name, Control: e.variants[0].Name, Since: e.started, Variants: make([]VariantReport, len(e.variants))}
+    for i, v := range e.variants {
+        st := e.stats[v.Name]
+        vr := VariantReport{Name: v.Name, Weight: v.Weight, QueryMode: v.QueryMode, RankingPipeline: v.RankingPipeline,
+            Requests: st.requests, ZeroResults: st.zero, Fuzzy: st.fuzzy, Selections: st.selections}
+        if st.requests > 0 {
+            n := float64(st.requests)
+            vr.ZeroResultRate = float64(st.zero) / n
+            vr.FuzzyRate = float64(st.fuzzy) / n
+            vr.SelectionRate = float64(st.selections) / n
+            vr.AvgResults = float64(st.results) / n
+        }
+        vr.LatencyP50Ms, vr.LatencyP95Ms = percentiles(st.latencies)
+        report.Variants[i] = vr
+    }
+    if control := report.Variants[0].SelectionRate; control > 0 {
+        for i := 1; i < len(report.Variants); i++ {
+            lift := report.Variants[i].SelectionRate/control - 1
+            report.Variants[i].SelectionLift = &lift
+        }
+    }
+    return report
+}
+
+// percentiles a minták mediánja és 95. percentilise; minták nélkül 0.
+func percentiles(samples []float64) (p50, p95 float64) {
+    if len(samples) == 0 {
+        return 0, 0
+    }
+    sorted := append([]float64(nil), samples...)
+    sort.Float64s(sorted)
+    at := func(p float64) float64 {
+        return sorted[int(p*float64(len(sorted)-1))]
+    }
+    return at(0.5), at(0.95)
+}
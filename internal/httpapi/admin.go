@@ -0,0 +1,25 @@
+package httpapi
+
+import (
+    "crypto/subtle"
+    "net/http"
+    "strings"
+)
+
+// requireAdminToken csak az "Authorization: Bearer <ADMIN_TOKEN>" fejlécet tartalmazó kéréseket
+// engedi tovább a next kezelőhöz. Az adminisztrációs végpontok (betöltés, import, cache ürítés,
+// újraindexelés) így saját porton, a nyilvános forgalomtól független tokennel érhetők el.
+// Üres token esetén minden kérést elutasít.
+func (s *Server) requireAdminToken(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        auth := r.Header.Get("Authorization")
+        token := strings.TrimPrefix(auth, "Bearer ")
+        adminToken := s.cfg.AdminToken
+        if token == auth || adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+            w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+            writeError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Érvénytelen vagy hiányzó admin token")
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
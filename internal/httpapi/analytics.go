@@ -0,0 +1,93 @@
+package httpapi
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "time"
+
+    "autocomplete/internal/analytics"
+    "autocomplete/internal/suggest"
+)
+
+// defaultAnalyticsLimit és maxAnalyticsLimit az összesítésben visszaadott lekérdezések
+// alapértelmezett és legnagyobb száma.
+const (
+    defaultAnalyticsLimit = 50
+    maxAnalyticsLimit     = 1000
+)
+
+// recordQuery a javaslatkérést a lekérdezés-statisztikába (ha az engedélyezett) és a kérés
+// kísérleti változatának számlálóiba (ha van) rögzíti; a statisztika a változattal címkézve tárol.
+func (s *Server) recordQuery(ctx context.Context, kind, query string, set suggest.Set) {
+    s.recordExperiment(ctx, set)
+    if s.analytics == nil {
+        return
+    }
+    if kind == "" {
+        kind = suggest.KindSettlement
+    }
+    s.analytics.Record(kind, experimentVariant(ctx), suggest.NormalizeQuery(query, false), len(set.Suggestions), set.Fuzzy)
+}
+
+// analyticsHandler kezeli a GET /api/admin/analytics/top és /zero-results végpontot: a
+// "window" időablak (pl. 1h, alapértelmezés: ANALYTICS_RETENTION) lekérdezéseit összesíti,
+// a zeroResults esetén csak a találat nélkülieket. A "kind" a javaslat fajtájára, a "variant" a
+// futó kísérlet egy változatára szűkít, a "limit" a visszaadott lekérdezések száma (legfeljebb 1000). Kikapcsolt statisztikánál 501.
+func (s *Server) analyticsHandler(zeroResults bool) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodGet {
+            writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+            return
+        }
+        if s.analytics == nil {
+            writeError(w, r, http.StatusNotImplemented, ErrCodeNotImplemented, "A lekérdezés-statisztika ki van kapcsolva (ANALYTICS_ENABLED)")
+            return
+        }
+        window := s.analytics.Retention()
+        if v := r.URL.Query().Get("window"); v != "" {
+            d, err := time.ParseDuration(v)
+            if err != nil || d <= 0 || d > s.analytics.Retention() {
+                writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter,
+                    fmt.Sprintf("A 'window' pozitív időtartam lehet (pl. 1h), legfeljebb %s", s.analytics.Retention()))
+                return
+            }
+            window = d
+        }
+        limit := defaultAnalyticsLimit
+        if v := r.URL.Query().Get("limit"); v != "" {
+            n, err := strconv.Atoi(v)
+            if err != nil || n < 1 || n > maxAnalyticsLimit {
+                writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, fmt.Sprintf("A 'limit' 1 és %d közötti egész szám lehet", maxAnalyticsLimit))
+                return
+            }
+            limit = n
+        }
+        kind := r.URL.Query().Get("kind")
+        switch kind {
+        case "", suggest.KindSettlement, suggest.KindStreet, suggest.KindAddress, suggest.KindZip:
+        default:
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "A 'kind' értéke settlement, street, address vagy zip lehet")
+            return
+        }
+        variant := r.URL.Query().Get("variant")
+        if variant != "" && !s.isVariant(variant) {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, fmt.Sprintf("Ismeretlen kísérleti változat: %s", variant))
+            return
+        }
+
+        var summary analytics.Summary
+        if zeroResults {
+            summary = s.analytics.ZeroResults(window, kind, variant, limit)
+        } else {
+            summary = s.analytics.Top(window, kind, variant, limit)
+        }
+        w.Header().Set("Content-Type", "application/json")
+        if err := json.NewEncoder(w).Encode(summary); err != nil {
+            slog.Error("Hiba az analitika válasz kódolásakor", "error", err)
+        }
+    }
+}
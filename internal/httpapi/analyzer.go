@@ -0,0 +1,99 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+)
+
+// AnalyzerUpdate a PUT /api/admin/index/settings válasza: az új analyzer beállítások és az
+// ezeket betöltő újraindexelés állapota.
+type AnalyzerUpdate struct {
+    Analyzer index.AnalyzerSettings `json:"analyzer"`
+    Reindex  *index.ReindexJob      `json:"reindex"`
+}
+
+// AnalyzeResult a POST /api/admin/analyze válasza: a szöveg tokenjei.
+type AnalyzeResult struct {
+    Tokens []index.AnalyzeToken `json:"tokens"`
+}
+
+// indexSettingsHandler kezeli az /api/admin/index/settings végpontot.
+// GET: az "autocomplete" analyzer jelenlegi beállításai (minGram, maxGram, tokenizer, filters).
+// PUT: a beállítások cseréje; az index alias-alapú újraindexeléssel kapja meg az új
+// analyzert, az előrehaladás a GET /api/admin/reindex végponton követhető. A deleteOld=1 query
+// paraméterrel a váltás után a régi index törlődik.
+func (s *Server) indexSettingsHandler(w http.ResponseWriter, r *http.Request) {
+    switch r.Method {
+    case http.MethodGet:
+        analyzer, err := s.indexes.Analyzer(r.Context())
+        if err != nil {
+            writeUpstreamError(w, r, err, "Hiba az analyzer beállítások lekérésekor")
+            slog.Error("Analyzer settings fetch error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        if err := json.NewEncoder(w).Encode(analyzer); err != nil {
+            slog.Error("Hiba az analyzer válasz kódolásakor", "error", err)
+        }
+    case http.MethodPut:
+        var analyzer index.AnalyzerSettings
+        if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&analyzer); err != nil {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "A törzsnek {\"minGram\", \"maxGram\", \"tokenizer\", \"filters\"} mezőket tartalmazó JSON objektumnak kell lennie")
+            return
+        }
+        job, err := s.indexes.SetAnalyzer(r.Context(), analyzer, r.URL.Query().Get("deleteOld") == "1")
+        if errors.Is(err, index.ErrInvalidAnalyzer) {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, err.Error())
+            return
+        }
+        if err != nil {
+            writeReindexStartError(w, r, err)
+            return
+        }
+        reqlog.Add(r.Context(), "min_gram", analyzer.MinGram, "max_gram", analyzer.MaxGram, "tokenizer", analyzer.Tokenizer, "target", job.Target)
+        slog.Info("Analyzer update started", "min_gram", analyzer.MinGram, "max_gram", analyzer.MaxGram,
+            "tokenizer", analyzer.Tokenizer, "filters", analyzer.Filters, "target", job.Target)
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusAccepted)
+        if err := json.NewEncoder(w).Encode(AnalyzerUpdate{Analyzer: analyzer, Reindex: job}); err != nil {
+            slog.Error("Hiba az analyzer válasz kódolásakor", "error", err)
+        }
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET és PUT kérés engedélyezett")
+    }
+}
+
+// analyzeHandler kezeli a POST /api/admin/analyze végpontot: az index _analyze API-jával
+// megmutatja, milyen tokenekre bontja a "text" szöveget az "analyzer" (alapértelmezésben
+// "autocomplete") vagy a "field" mező analyzere.
+func (s *Server) analyzeHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+        return
+    }
+    var req index.AnalyzeRequest
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "A törzsnek {\"text\": ...} alakú JSON objektumnak kell lennie")
+        return
+    }
+    tokens, err := s.indexes.Analyze(r.Context(), req)
+    if errors.Is(err, index.ErrInvalidAnalyzer) {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, err.Error())
+        return
+    }
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a szöveg elemzésekor")
+        slog.Error("Analyze error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "analyzer", req.Analyzer, "field", req.Field, "tokens", len(tokens))
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(AnalyzeResult{Tokens: tokens}); err != nil {
+        slog.Error("Hiba az elemzési válasz kódolásakor", "error", err)
+    }
+}
@@ -0,0 +1,114 @@
+package httpapi
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "math"
+    "net/http"
+    "strconv"
+    "time"
+
+    "autocomplete/internal/opensearch"
+    "autocomplete/internal/reqlog"
+)
+
+// A hibaválaszok gépi feldolgozásra szánt kódjai.
+const (
+    ErrCodeMissingParameter = "missing_parameter"
+    ErrCodeInvalidParameter = "invalid_parameter"
+    ErrCodeInvalidBody      = "invalid_body"
+    ErrCodeMethodNotAllowed = "method_not_allowed"
+    ErrCodeNotFound         = "not_found"
+    ErrCodeConflict         = "conflict"
+    ErrCodeUnauthorized     = "unauthorized"
+    ErrCodeRateLimited      = "rate_limited"
+    ErrCodeUpstream         = "upstream_error"
+    ErrCodeUnavailable      = "backend_unavailable"
+    ErrCodeNotImplemented   = "not_implemented"
+)
+
+// APIError a hibaválasz törzse; minden végpont {"error": {...}} alakban küldi.
+type APIError struct {
+    Code      string `json:"code"`
+    Message   string `json:"message"`
+    RequestID string `json:"requestId,omitempty"`
+}
+
+// ErrorResponse a hibaválaszok egységes borítéka.
+type ErrorResponse struct {
+    Error APIError `json:"error"`
+}
+
+// Error lehetővé teszi, hogy az APIError hibaként is továbbadható legyen.
+func (e *APIError) Error() string {
+    return e.Message
+}
+
+// withRequestID minden kérésnek azonosítót ad: a bejövő X-Request-ID fejlécet használja,
+// ha van, különben generál egyet. Az azonosítót a válasz X-Request-ID fejlécébe is beírja.
+func withRequestID(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        id := r.Header.Get("X-Request-ID")
+        if id == "" || len(id) > 128 {
+            id = newRequestID()
+        }
+        w.Header().Set("X-Request-ID", id)
+        next.ServeHTTP(w, r.WithContext(reqlog.WithRequestID(r.Context(), id)))
+    })
+}
+
+// newRequestID egy véletlen, 16 hexadecimális karakteres azonosítót ad vissza.
+func newRequestID() string {
+    var b [8]byte
+    if _, err := rand.Read(b[:]); err != nil {
+        return "unknown"
+    }
+    return hex.EncodeToString(b[:])
+}
+
+// writeError egységes JSON hibaválaszt küld a megadott státusszal, kóddal és üzenettel; az
+// üzenetet a kérés nyelvére fordítja (lásd withLanguage).
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("X-Content-Type-Options", "nosniff")
+    w.WriteHeader(status)
+    response := ErrorResponse{Error: APIError{Code: code, Message: localize(r.Context(), message), RequestID: reqlog.RequestID(r.Context())}}
+    if err := json.NewEncoder(w).Encode(response); err != nil {
+        slog.Error("Hiba a hibaválasz kódolásakor", "error", err)
+    }
+}
+
+// upstreamAPIError az OpenSearch hívás hibáját a kliensnek szánt APIError-ra képezi: nyitott
+// circuit breaker vagy túlterhelés (OverloadedError) esetén backend_unavailable kódra (a retryAfter
+// ilyenkor pozitív), egyébként upstream_error kódra a message üzenettel. Belső részleteket nem ad tovább.
+func upstreamAPIError(err error, message string) (apiErr APIError, retryAfter time.Duration) {
+    var openErr *opensearch.CircuitOpenError
+    if errors.As(err, &openErr) {
+        return APIError{Code: ErrCodeUnavailable, Message: "Az adatbázis átmenetileg nem elérhető"}, openErr.RetryAfter
+    }
+    var overloaded *opensearch.OverloadedError
+    if errors.As(err, &overloaded) {
+        return APIError{Code: ErrCodeUnavailable, Message: "Az adatbázis túlterhelt, próbálja újra később"}, overloaded.RetryAfter
+    }
+    return APIError{Code: ErrCodeUpstream, Message: message}, 0
+}
+
+// writeUpstreamError az OpenSearch hívás hibáját küldi vissza: nyitott circuit breaker vagy a
+// párhuzamossági korlát miatti elutasítás esetén azonnali 503-at Retry-After fejléccel, egyébként 500-at upstream_error kóddal.
+func writeUpstreamError(w http.ResponseWriter, r *http.Request, err error, message string) {
+    apiErr, retryAfter := upstreamAPIError(err, message)
+    if apiErr.Code == ErrCodeUnavailable {
+        w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+        writeError(w, r, http.StatusServiceUnavailable, apiErr.Code, apiErr.Message)
+        return
+    }
+    writeError(w, r, http.StatusInternalServerError, apiErr.Code, apiErr.Message)
+}
+
+// writeNotImplemented 501-es választ küld, ha a beállított háttérrendszer nem támogatja a végpontot.
+func writeNotImplemented(w http.ResponseWriter, r *http.Request) {
+    writeError(w, r, http.StatusNotImplemented, ErrCodeNotImplemented, "A keresési háttérrendszer nem támogatja ezt a végpontot")
+}
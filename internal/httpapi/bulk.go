@@ -0,0 +1,49 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+)
+
+// bulkHandler kezeli a POST /api/admin/bulk végpontot, amely NDJSON vagy JSON tömb
+// formátumú címrekordokat tölt be kötegelve, és összesítőt ad vissza. Az index paraméterrel
+// a folyamatban lévő újraindexelés célindexébe is tölthető.
+func (s *Server) bulkHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+        return
+    }
+    indexer := s.indexes.NewBulkIndexer(r.Context())
+    if target := r.URL.Query().Get("index"); target != "" {
+        if !s.indexes.IsImportTarget(target) {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "Az 'index' paraméter csak a folyamatban lévő újraindexelés célindexe lehet")
+            return
+        }
+        indexer.Index = target
+    }
+    err := index.ReadBulkDocuments(r.Body, indexer)
+    if err == nil {
+        err = indexer.Flush()
+    }
+    summary := indexer.Summary()
+    status := http.StatusOK
+    if err != nil {
+        slog.Error("Bulk error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        summary.Error = err.Error()
+        status = http.StatusBadGateway
+        var syntaxErr *json.SyntaxError
+        if errors.As(err, &syntaxErr) {
+            status = http.StatusBadRequest
+        }
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    if err := json.NewEncoder(w).Encode(summary); err != nil {
+        slog.Error("Hiba a bulk válasz kódolásakor", "error", err)
+    }
+}
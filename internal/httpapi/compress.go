@@ -0,0 +1,187 @@
+package httpapi
+
+import (
+    "compress/gzip"
+    "io"
+    "mime"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+
+    "github.com/andybalholm/brotli"
+)
+
+var gzipWriters = sync.Pool{New: func() interface{} {
+    w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+    return w
+}}
+
+// negotiateEncoding az Accept-Encoding fejléc alapján választ kódolást ("br", "gzip" vagy "").
+// Azonos súlynál a brotlit részesíti előnyben; q=0 kizárja a kódolást.
+func negotiateEncoding(header string) string {
+    weights := map[string]float64{}
+    for _, part := range strings.Split(header, ",") {
+        name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+        name = strings.ToLower(strings.TrimSpace(name))
+        q := 1.0
+        if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+            if f, err := strconv.ParseFloat(v, 64); err == nil {
+                q = f
+            }
+        }
+        weights[name] = q
+    }
+    best, bestQ := "", 0.0
+    for _, encoding := range []string{"br", "gzip"} {
+        q, ok := weights[encoding]
+        if !ok {
+            q, ok = weights["*"]
+        }
+        if ok && q > bestQ {
+            best, bestQ = encoding, q
+        }
+    }
+    return best
+}
+
+// compressibleType jelzi, hogy a Content-Type szerepel-e a types listán.
+func compressibleType(contentType string, types []string) bool {
+    mediaType, _, err := mime.ParseMediaType(contentType)
+    if err != nil {
+        return false
+    }
+    for _, t := range types {
+        if mediaType == t {
+            return true
+        }
+    }
+    return false
+}
+
+// compressResponses a válaszokat tömörítő middleware (COMPRESSION_ENABLED, COMPRESSION_MIN_SIZE,
+// COMPRESSION_TYPES): az Accept-Encoding alapján brotli vagy gzip kódolással, de csak a
+// beállított tartalomtípusoknál és legalább a minimális méretű válaszoknál.
+func (s *Server) compressResponses(next http.Handler) http.Handler {
+    if !s.compression.Enabled {
+        return next
+    }
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Add("Vary", "Accept-Encoding")
+        encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+        if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+            next.ServeHTTP(w, r)
+            return
+        }
+        cw := &compressWriter{ResponseWriter: w, encoding: encoding, status: http.StatusOK, minSize: s.compression.MinSize, types: s.compression.ContentTypes}
+        defer cw.Close()
+        next.ServeHTTP(cw, r)
+    })
+}
+
+// compressWriter a válasz elejét minSize bájtig puffereli, és csak ezután (vagy
+// Flush/Close hívásnál) dönt a tömörítésről, amikor a fejlécek és a méret már ismertek.
+type compressWriter struct {
+    http.ResponseWriter
+    encoding string
+    status   int
+    minSize  int
+    types    []string
+    buf      []byte
+    decided  bool
+    encoder  io.WriteCloser
+}
+
+func (c *compressWriter) WriteHeader(status int) {
+    if c.decided || status < 200 {
+        c.ResponseWriter.WriteHeader(status)
+        return
+    }
+    c.status = status
+}
+
+func (c *compressWriter) Write(p []byte) (int, error) {
+    if !c.decided {
+        c.buf = append(c.buf, p...)
+        if len(c.buf) < c.minSize {
+            return len(p), nil
+        }
+        if err := c.decide(true); err != nil {
+            return 0, err
+        }
+        return len(p), nil
+    }
+    if c.encoder != nil {
+        return c.encoder.Write(p)
+    }
+    return c.ResponseWriter.Write(p)
+}
+
+// decide elküldi a fejléceket és a pufferelt adatot; a tömörítés akkor indul, ha
+// sizeOK igaz, a tartalomtípus engedélyezett, és a kezelő maga nem kódolta a választ.
+func (c *compressWriter) decide(sizeOK bool) error {
+    c.decided = true
+    h := c.Header()
+    if h.Get("Content-Type") == "" && len(c.buf) > 0 {
+        h.Set("Content-Type", http.DetectContentType(c.buf))
+    }
+    compress := sizeOK && h.Get("Content-Encoding") == "" && compressibleType(h.Get("Content-Type"), c.types) &&
+        c.status != http.StatusNoContent && c.status != http.StatusNotModified
+    if compress {
+        h.Del("Content-Length")
+        h.Set("Content-Encoding", c.encoding)
+        switch c.encoding {
+        case "br":
+            c.encoder = brotli.NewWriterLevel(c.ResponseWriter, brotli.DefaultCompression)
+        default:
+            gz := gzipWriters.Get().(*gzip.Writer)
+            gz.Reset(c.ResponseWriter)
+            c.encoder = gz
+        }
+    }
+    c.ResponseWriter.WriteHeader(c.status)
+    buf := c.buf
+    c.buf = nil
+    if len(buf) == 0 {
+        return nil
+    }
+    var err error
+    if c.encoder != nil {
+        _, err = c.encoder.Write(buf)
+    } else {
+        _, err = c.ResponseWriter.Write(buf)
+    }
+    return err
+}
+
+// Flush folyamatos (pl. NDJSON előrehaladási) válaszoknál a méretküszöbtől függetlenül
+// dönt, majd a kódolóban és az alatta lévő kapcsolaton is kiüríti a puffert.
+func (c *compressWriter) Flush() {
+    if !c.decided {
+        c.decide(true)
+    }
+    if f, ok := c.encoder.(interface{ Flush() error }); ok {
+        f.Flush()
+    }
+    http.NewResponseController(c.ResponseWriter).Flush()
+}
+
+// Close lezárja a kódolót; a küszöb alatti, még pufferelt válaszokat tömörítés nélkül küldi el.
+func (c *compressWriter) Close() error {
+    if !c.decided {
+        c.decide(false)
+    }
+    if c.encoder == nil {
+        return nil
+    }
+    err := c.encoder.Close()
+    if gz, ok := c.encoder.(*gzip.Writer); ok {
+        gzipWriters.Put(gz)
+    }
+    return err
+}
+
+// Unwrap lehetővé teszi, hogy a http.ResponseController elérje az eredeti ResponseWriter-t.
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+    return c.ResponseWriter
+}
@@ -0,0 +1,94 @@
+package httpapi
+
+import (
+    "errors"
+    "log/slog"
+    "math"
+    "net/http"
+    "net/url"
+    "strconv"
+    "sync"
+
+    "autocomplete/internal/config"
+    "autocomplete/internal/ratelimit"
+    "autocomplete/internal/reqlog"
+)
+
+// longLivedRoutes a tartós kapcsolatot (WebSocket, SSE) tartó, valamint a health check
+// útvonalak; ezekre a CONCURRENCY_DEFAULT_LIMIT nem vonatkozik, csak a kifejezett útvonal-korlát.
+var longLivedRoutes = map[string]bool{
+    "/api/autocomplete/ws":     true,
+    "/api/autocomplete/stream": true,
+    "/healthz":                 true,
+}
+
+// endpointLimits a nyilvános végpontok útvonalanként (a ServeMux mintája szerint) számolt
+// párhuzamossági korlátai. A Semaphore-ok az első kéréskor jönnek létre.
+type endpointLimits struct {
+    cfg config.ConcurrencyConfig
+
+    mu         sync.Mutex
+    semaphores map[string]*ratelimit.Semaphore
+}
+
+// semaphore a minta korlátja; nil, ha az útvonal nincs korlátozva.
+func (l *endpointLimits) semaphore(pattern string) *ratelimit.Semaphore {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if sem, ok := l.semaphores[pattern]; ok {
+        return sem
+    }
+    limit, ok := l.cfg.Endpoints[pattern]
+    if !ok && !longLivedRoutes[pattern] {
+        limit = l.cfg.Default
+    }
+    sem := ratelimit.NewSemaphore(limit, l.cfg.QueueTimeout)
+    l.semaphores[pattern] = sem
+    return sem
+}
+
+// stats a létrejött korlátok állapota mintánként.
+func (l *endpointLimits) stats() map[string]ratelimit.SemaphoreStats {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    stats := make(map[string]ratelimit.SemaphoreStats, len(l.semaphores))
+    for pattern, sem := range l.semaphores {
+        if sem != nil {
+            stats[pattern] = sem.Stats()
+        }
+    }
+    return stats
+}
+
+// ConcurrencyStats a nyilvános végpontok párhuzamossági korlátainak állapota útvonalanként
+// (expvar "concurrency").
+func (s *Server) ConcurrencyStats() interface{} {
+    return s.concurrency.stats()
+}
+
+// limitConcurrency útvonalanként korlátozza az egyszerre kiszolgált kéréseket
+// (CONCURRENCY_LIMITS, CONCURRENCY_DEFAULT_LIMIT). A korlát felett érkező kérés legfeljebb
+// CONCURRENCY_QUEUE_TIMEOUT ideig vár szabad helyre, utána 503-at kap Retry-After fejléccel,
+// így a forgalmi csúcsot a szolgáltatás eldobja, mielőtt az OpenSearch-öt túlterhelné.
+func (s *Server) limitConcurrency(mux *http.ServeMux) http.Handler {
+    for path := range s.concurrency.cfg.Endpoints {
+        if _, pattern := mux.Handler(&http.Request{Method: http.MethodGet, URL: &url.URL{Path: path}}); pattern != path {
+            slog.Warn("Concurrency limit configured for unknown route", "path", path, "matched", pattern)
+        }
+    }
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        _, pattern := mux.Handler(r)
+        sem := s.concurrency.semaphore(pattern)
+        if err := sem.Acquire(r.Context()); err != nil {
+            if errors.Is(err, ratelimit.ErrQueueFull) {
+                reqlog.Add(r.Context(), "shed", true)
+                retryAfter := math.Max(1, math.Ceil(sem.QueueTimeout().Seconds()))
+                w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter)))
+                writeError(w, r, http.StatusServiceUnavailable, ErrCodeUnavailable, "A szolgáltatás túlterhelt, próbálja újra később")
+            }
+            return
+        }
+        defer sem.Release()
+        mux.ServeHTTP(w, r)
+    })
+}
@@ -0,0 +1,53 @@
+package httpapi
+
+import (
+    "net/http"
+    "strconv"
+)
+
+// corsMaxAge a preflight válaszok gyorsítótárazási ideje másodpercben.
+const corsMaxAge = 600
+
+// allowedOrigin a kérés Origin fejlécére adandó Access-Control-Allow-Origin érték; üres, ha a
+// kérés nem cross-origin, vagy az origin nincs a CORS_ALLOWED_ORIGINS listában.
+func (s *Server) allowedOrigin(origin string) string {
+    if origin == "" {
+        return ""
+    }
+    for _, allowed := range s.widget.AllowedOrigins {
+        if allowed == "*" || allowed == origin {
+            return allowed
+        }
+    }
+    return ""
+}
+
+// allowCORS a CORS_ALLOWED_ORIGINS listában szereplő oldalakról (pl. a beágyazott widgetből)
+// érkező böngészős kéréseknek engedélyezi a nyilvános API elérését, és a preflight (OPTIONS)
+// kéréseket közvetlenül megválaszolja. Üres lista esetén nem küld CORS fejlécet. Futó kísérletnél
+// a kliensazonosító fejléc küldését és az X-Experiment-Variant olvasását is engedélyezi.
+func (s *Server) allowCORS(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        origin := s.allowedOrigin(r.Header.Get("Origin"))
+        if origin == "" {
+            next.ServeHTTP(w, r)
+            return
+        }
+        w.Header().Set("Access-Control-Allow-Origin", origin)
+        w.Header().Add("Vary", "Origin")
+        exposed, allowed := "X-Request-Id, Retry-After, ETag", "Content-Type, Accept, X-Request-Id"
+        if s.experiment != nil {
+            exposed += ", " + experimentVariantHeader
+            allowed += ", " + s.clientHeader
+        }
+        w.Header().Set("Access-Control-Expose-Headers", exposed)
+        if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+            w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+            w.Header().Set("Access-Control-Allow-Headers", allowed)
+            w.Header().Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+            w.WriteHeader(http.StatusNoContent)
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
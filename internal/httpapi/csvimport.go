@@ -0,0 +1,117 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "log/slog"
+    "net/http"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+)
+
+// csvImportHandler kezeli a POST /api/admin/import/csv végpontot. A CSV fájlt a multipart
+// kérés "file" mezőjében várja, és a feltöltést a memóriába töltés nélkül, folyamatosan dolgozza fel.
+// Query paraméterek:
+//   - delimiter: mezőelválasztó karakter (alapértelmezés ",")
+//   - mapping: a CSV_HEADER_MAPPING felülírása ugyanolyan formátumban
+//   - dryRun=1: csak ellenőrzés, az indexbe nem ír
+//   - progress=1: kötegenként NDJSON előrehaladási sorokat küld, az utolsó sor az összesítő
+//   - index: a folyamatban lévő újraindexelés célindexe (alapértelmezés az alias)
+func (s *Server) csvImportHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+        return
+    }
+    params := r.URL.Query()
+
+    delimiter := ','
+    if d := params.Get("delimiter"); d != "" {
+        runes := []rune(d)
+        if len(runes) != 1 {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "A 'delimiter' paraméter egyetlen karakter lehet")
+            return
+        }
+        delimiter = runes[0]
+    }
+    mapping := s.csvHeaderMapping
+    if spec := params.Get("mapping"); spec != "" {
+        var err error
+        if mapping, err = index.ParseHeaderMapping(spec); err != nil {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "Hibás 'mapping' paraméter: "+err.Error())
+            return
+        }
+    }
+    target := params.Get("index")
+    if target != "" && !s.indexes.IsImportTarget(target) {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "Az 'index' paraméter csak a folyamatban lévő újraindexelés célindexe lehet")
+        return
+    }
+
+    mr, err := r.MultipartReader()
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "Multipart kérés szükséges")
+        return
+    }
+    var file io.Reader
+    for {
+        part, err := mr.NextPart()
+        if err != nil {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "Hiányzó 'file' mező")
+            return
+        }
+        if part.FormName() == "file" {
+            file = part
+            break
+        }
+    }
+
+    indexer := s.indexes.NewBulkIndexer(r.Context())
+    if target != "" {
+        indexer.Index = target
+    }
+    indexer.DryRun = params.Get("dryRun") == "1"
+    progress := params.Get("progress") == "1"
+    flusher, _ := w.(http.Flusher)
+    encoder := json.NewEncoder(w)
+    if progress {
+        w.Header().Set("Content-Type", "application/x-ndjson")
+    } else {
+        w.Header().Set("Content-Type", "application/json")
+    }
+    indexer.OnFlush = func(sum index.BulkSummary) {
+        slog.Info("CSV import progress", "request_id", reqlog.RequestID(r.Context()), "processed", sum.Total, "indexed", sum.Indexed, "failed", sum.Failed)
+        if progress {
+            encoder.Encode(map[string]interface{}{
+                "processed": sum.Total,
+                "indexed":   sum.Indexed,
+                "failed":    sum.Failed,
+                "batches":   sum.Batches,
+            })
+            if flusher != nil {
+                flusher.Flush()
+            }
+        }
+    }
+
+    err = index.ImportCSV(file, delimiter, mapping, indexer)
+    if err == nil {
+        err = indexer.Flush()
+    }
+    summary := indexer.Summary()
+    if err != nil {
+        slog.Error("CSV import error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        summary.Error = err.Error()
+        if !progress {
+            status := http.StatusBadGateway
+            if errors.Is(err, index.ErrInvalidCSV) {
+                status = http.StatusBadRequest
+            }
+            w.WriteHeader(status)
+        }
+    }
+    if err := encoder.Encode(summary); err != nil {
+        slog.Error("Hiba a CSV import válasz kódolásakor", "error", err)
+    }
+}
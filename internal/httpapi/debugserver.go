@@ -0,0 +1,20 @@
+package httpapi
+
+import (
+    "expvar"
+    "net/http"
+    "net/http/pprof"
+)
+
+// newDebugMux a net/http/pprof profilozó és a runtime/expvar metrika végpontokat adja vissza.
+// A nyilvános forgalomtól elkülönített debug listeneren (DEBUG_SERVER_ADDR) szolgáljuk ki.
+func newDebugMux() *http.ServeMux {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/debug/pprof/", pprof.Index)
+    mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+    mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+    mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+    mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+    mux.Handle("/debug/vars", expvar.Handler())
+    return mux
+}
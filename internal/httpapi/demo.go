@@ -0,0 +1,97 @@
+package httpapi
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "embed"
+    "encoding/hex"
+    "html/template"
+    "io/fs"
+    "log/slog"
+    "net/http"
+    "strings"
+)
+
+// demoFiles a demo felület beágyazott fájljai: az index.html sablon és a statikus JS/CSS.
+//
+//go:embed demo
+var demoFiles embed.FS
+
+// demoStaticPrefix a demo statikus fájljainak útvonala.
+const demoStaticPrefix = "/demo/static/"
+
+var (
+    demoAssets   = mustSub(demoFiles, "demo")
+    demoTemplate = template.Must(template.ParseFS(demoAssets, "index.html"))
+    // demoAssetVersion a statikus fájlok tartalmából képzett verzió; a hivatkozásokba kerül, így
+    // a fájlok hosszan gyorsítótárazhatók, mégis új kiadáskor azonnal frissülnek.
+    demoAssetVersion = assetVersion(demoAssets, "demo.css", "demo.js")
+)
+
+// demoPage a demo sablon paraméterei. Az APIBasePath a nyilvános API útvonal-előtagja
+// (DEMO_API_BASE_PATH, pl. fordított proxy mögött), a MinQueryLength alatt a felület nem kérdez,
+// a DebounceMillis a gépelés utáni késleltetés (WIDGET_DEBOUNCE), így a demo ugyanazokkal a
+// beállításokkal működik, mint a beágyazott widget.
+type demoPage struct {
+    Title          string
+    APIBasePath    string
+    MinQueryLength int
+    DebounceMillis int64
+    AssetVersion   string
+}
+
+// demoHandler szolgáltatja a demo HTML felületet.
+func (s *Server) demoHandler(w http.ResponseWriter, r *http.Request) {
+    page := demoPage{
+        Title:          s.demo.Title,
+        APIBasePath:    strings.TrimRight(s.demo.APIBasePath, "/"),
+        MinQueryLength: s.Options().MinQueryLength,
+        DebounceMillis: s.widget.Debounce.Milliseconds(),
+        AssetVersion:   demoAssetVersion,
+    }
+    var body bytes.Buffer
+    if err := demoTemplate.Execute(&body, page); err != nil {
+        slog.Error("Hiba a demo oldal előállításakor", "error", err)
+        http.Error(w, "Hiba a demo oldal előállításakor", http.StatusInternalServerError)
+        return
+    }
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    w.Header().Set("Cache-Control", "no-cache")
+    w.Write(body.Bytes())
+}
+
+// demoStaticHandler a demo statikus fájljait (JS, CSS) szolgálja ki. A hivatkozások a
+// demoAssetVersion verziót tartalmazzák, ezért a válasz egy évig gyorsítótárazható.
+func demoStaticHandler() http.Handler {
+    files := http.StripPrefix(demoStaticPrefix, http.FileServer(http.FS(demoAssets)))
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if name := strings.TrimPrefix(r.URL.Path, demoStaticPrefix); name == "" || name == "index.html" {
+            http.NotFound(w, r)
+            return
+        }
+        w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+        files.ServeHTTP(w, r)
+    })
+}
+
+// assetVersion a megadott fájlok tartalmának rövid SHA-256 lenyomata.
+func assetVersion(fsys fs.FS, names ...string) string {
+    h := sha256.New()
+    for _, name := range names {
+        data, err := fs.ReadFile(fsys, name)
+        if err != nil {
+            panic(err)
+        }
+        h.Write(data)
+    }
+    return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+// mustSub a beágyazott fájlrendszer dir alkönyvtárát adja vissza.
+func mustSub(fsys fs.FS, dir string) fs.FS {
+    sub, err := fs.Sub(fsys, dir)
+    if err != nil {
+        panic(err)
+    }
+    return sub
+}
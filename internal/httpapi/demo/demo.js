@@ -0,0 +1,125 @@
+// A szerver a <body> data- attribútumaiban adja át az API útvonalát, a minimális lekérdezéshosszt
+// és a gépelés utáni késleltetést (ms), így a demo az éles beállításokkal működik.
+const apiBase = document.body.dataset.apiBase || '';
+const minQueryLength = parseInt(document.body.dataset.minQueryLength, 10) || 1;
+const debounceMillis = parseInt(document.body.dataset.debounce, 10) || 0;
+
+let currentSuggestions = [];
+let activeIndex = -1;
+let debounceTimer = null;
+let controller = null;
+const input = document.getElementById('autocomplete');
+const suggestionsList = document.getElementById('suggestions');
+const errorDiv = document.getElementById('error');
+const debugDiv = document.getElementById('debug');
+const validateBtn = document.getElementById('validateBtn');
+const validationResult = document.getElementById('validationResult');
+
+function clearSuggestions() {
+    suggestionsList.innerHTML = '';
+    activeIndex = -1;
+    input.setAttribute('aria-expanded', 'false');
+    input.removeAttribute('aria-activedescendant');
+}
+
+function setActive(index) {
+    const items = suggestionsList.children;
+    if (activeIndex >= 0 && items[activeIndex]) items[activeIndex].classList.remove('active');
+    activeIndex = index;
+    if (activeIndex >= 0 && items[activeIndex]) {
+        items[activeIndex].classList.add('active');
+        items[activeIndex].scrollIntoView({block: 'nearest'});
+        input.setAttribute('aria-activedescendant', items[activeIndex].id);
+    } else {
+        input.removeAttribute('aria-activedescendant');
+    }
+}
+
+function choose(item) {
+    input.value = item;
+    clearSuggestions();
+    validationResult.textContent = "";
+}
+
+function renderSuggestions(suggestions) {
+    clearSuggestions();
+    suggestions.forEach((item, i) => {
+        const li = document.createElement('li');
+        li.id = 'suggestion-' + i;
+        li.setAttribute('role', 'option');
+        li.textContent = item;
+        li.addEventListener('click', () => choose(item));
+        suggestionsList.appendChild(li);
+    });
+    input.setAttribute('aria-expanded', suggestions.length > 0 ? 'true' : 'false');
+}
+
+function fetchSuggestions() {
+    const query = input.value;
+    // Az elavult, még folyamatban lévő kérés válasza nem írhatja felül az újabbét.
+    if (controller) controller.abort();
+    if (query.trim().length < minQueryLength) {
+        clearSuggestions();
+        currentSuggestions = [];
+        return;
+    }
+    controller = new AbortController();
+    fetch(apiBase + '/api/autocomplete?debug=1&q=' + encodeURIComponent(query), {signal: controller.signal})
+        .then(response => {
+            if(!response.ok) throw new Error("HTTP hiba: " + response.status);
+            return response.json();
+        })
+        .then(data => {
+            currentSuggestions = data.suggestions;
+            renderSuggestions(data.suggestions);
+            debugDiv.textContent = data.debug || "";
+        })
+        .catch(err => {
+            if (err.name === 'AbortError') return;
+            errorDiv.textContent = "Hiba történt: " + err.message;
+        });
+}
+
+input.addEventListener('input', () => {
+    errorDiv.textContent = "";
+    debugDiv.textContent = "";
+    validationResult.textContent = "";
+    clearTimeout(debounceTimer);
+    debounceTimer = setTimeout(fetchSuggestions, debounceMillis);
+});
+
+input.addEventListener('keydown', event => {
+    const count = suggestionsList.children.length;
+    if (count === 0) return;
+    switch (event.key) {
+    case 'ArrowDown':
+        event.preventDefault();
+        setActive((activeIndex + 1) % count);
+        break;
+    case 'ArrowUp':
+        event.preventDefault();
+        setActive(activeIndex <= 0 ? count - 1 : activeIndex - 1);
+        break;
+    case 'Enter':
+        if (activeIndex >= 0) {
+            event.preventDefault();
+            choose(currentSuggestions[activeIndex]);
+        }
+        break;
+    case 'Escape':
+        clearSuggestions();
+        break;
+    }
+});
+
+validateBtn.addEventListener('click', () => {
+    const inputVal = input.value.trim();
+    if(inputVal === "") {
+        validationResult.textContent = "Az input üres!";
+        validationResult.style.color = "red";
+        return;
+    }
+    const isValid = currentSuggestions.includes(inputVal);
+    validationResult.textContent = isValid ? "Az input érvényes." : "Az input nem egyezik az adatbázissal.";
+    validationResult.style.color = isValid ? "green" : "red";
+});
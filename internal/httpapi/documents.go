@@ -0,0 +1,78 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+    "net/url"
+    "strings"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+)
+
+// documentsHandler kezeli az /api/admin/documents/{id} végpontot, amellyel egy-egy címrekord
+// az OpenSearch közvetlen elérése nélkül javítható.
+// POST: új rekord létrehozása (409, ha az id már létezik).
+// PUT: rekord létrehozása vagy felülírása.
+// DELETE: rekord törlése (404, ha nincs ilyen).
+// A refresh=1 query paraméterrel a válasz csak akkor érkezik meg, amikor a változás a
+// keresésekben is látszik. A javaslat-gyorsítótárat nem üríti (lásd /api/admin/cache/flush).
+func (s *Server) documentsHandler(w http.ResponseWriter, r *http.Request) {
+    // Az azonosító kódolt "/" jelet (%2F) is tartalmazhat, ezért a kódolt útvonalból olvassuk ki.
+    rawID := strings.TrimPrefix(r.URL.EscapedPath(), "/api/admin/documents/")
+    id, err := url.PathUnescape(rawID)
+    if err != nil || id == "" || strings.Contains(rawID, "/") {
+        writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, "Hiányzó vagy érvénytelen dokumentum azonosító")
+        return
+    }
+    refresh := r.URL.Query().Get("refresh") == "1"
+
+    var result index.DocumentResult
+    status := http.StatusOK
+    switch r.Method {
+    case http.MethodPost, http.MethodPut:
+        var doc index.AddressDocument
+        if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&doc); err != nil {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "A törzsnek címrekord JSON objektumnak kell lennie")
+            return
+        }
+        if doc.ID != "" && doc.ID != id {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "A törzs id mezője eltér az útvonalban megadott azonosítótól")
+            return
+        }
+        if err := doc.Validate(); err != nil {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, err.Error())
+            return
+        }
+        result, err = s.indexes.PutDocument(r.Context(), id, doc, r.Method == http.MethodPost, refresh)
+        if result.Result == "created" {
+            status = http.StatusCreated
+        }
+    case http.MethodDelete:
+        result, err = s.indexes.DeleteDocument(r.Context(), id, refresh)
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST, PUT és DELETE kérés engedélyezett")
+        return
+    }
+    switch {
+    case errors.Is(err, index.ErrDocumentExists):
+        writeError(w, r, http.StatusConflict, ErrCodeConflict, err.Error())
+        return
+    case errors.Is(err, index.ErrDocumentNotFound):
+        writeError(w, r, http.StatusNotFound, ErrCodeNotFound, err.Error())
+        return
+    case err != nil:
+        writeError(w, r, http.StatusBadGateway, ErrCodeUpstream, "Hiba a dokumentum módosításakor")
+        slog.Error("Document update error", "request_id", reqlog.RequestID(r.Context()), "method", r.Method, "id", id, "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "document_id", id, "result", result.Result)
+    slog.Info("Document updated", "id", id, "result", result.Result, "refresh", refresh)
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    if err := json.NewEncoder(w).Encode(result); err != nil {
+        slog.Error("Hiba a dokumentum válasz kódolásakor", "error", err)
+    }
+}
@@ -0,0 +1,136 @@
+package httpapi
+
+import (
+    "context"
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "strings"
+    "time"
+
+    "autocomplete/internal/experiment"
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// maxClientIDLength a kísérleti hozzárendeléshez használt kliensazonosító legnagyobb hossza bájtban.
+const maxClientIDLength = 200
+
+// experimentVariantHeader a válasz fejléce, amely a kérés kísérleti változatát jelzi.
+const experimentVariantHeader = "X-Experiment-Variant"
+
+type experimentKey struct{}
+
+// experimentState a assignVariant által a kérés kontextusába tett adatok: a változat neve és a
+// késleltetés mérésének kezdete.
+type experimentState struct {
+    variant string
+    start   time.Time
+}
+
+// newExperiment a konfiguráció kísérletét és a változatok motorbeállítás-felülírásait hozza
+// létre; kikapcsolt kísérletnél nil. A változatokat a config.Load már ellenőrizte.
+func newExperiment(name string, variants []experiment.Variant) (*experiment.Experiment, map[string]suggest.Override) {
+    if name == "" {
+        return nil, nil
+    }
+    overrides := make(map[string]suggest.Override, len(variants))
+    for _, v := range variants {
+        ranking, _ := suggest.ParsePipeline(v.RankingPipeline)
+        overrides[v.Name] = suggest.Override{QueryMode: v.QueryMode, Ranking: ranking}
+    }
+    return experiment.New(name, variants), overrides
+}
+
+// assignVariant bekapcsolt kísérletnél (EXPERIMENT_NAME) a kliensazonosító fejlécet
+// (EXPERIMENT_CLIENT_HEADER) küldő kéréseket a kísérlet egy változatához rendeli: a változat
+// beállításait a javaslatmotor kérésszintű felülírásaként adja tovább, a nevét az
+// X-Experiment-Variant fejlécben és a kérés naplósorában jelzi. Az azonosító nélküli kérések a
+// kísérleten kívül, a beállított értékekkel futnak. A válaszok a fejléc szerint változnak, ezért
+// a Vary fejlécbe is bekerül.
+func (s *Server) assignVariant(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if s.experiment == nil {
+            next.ServeHTTP(w, r)
+            return
+        }
+        w.Header().Add("Vary", s.clientHeader)
+        clientID := strings.TrimSpace(r.Header.Get(s.clientHeader))
+        if clientID == "" || len(clientID) > maxClientIDLength {
+            next.ServeHTTP(w, r)
+            return
+        }
+        variant := s.experiment.Assign(clientID).Name
+        w.Header().Set(experimentVariantHeader, variant)
+        reqlog.Add(r.Context(), "variant", variant)
+        ctx := suggest.WithOverride(r.Context(), s.overrides[variant])
+        ctx = context.WithValue(ctx, experimentKey{}, experimentState{variant: variant, start: time.Now()})
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
+
+// experimentVariant a kérés kísérleti változatának neve; üres, ha a kérés nem tartozik kísérlethez.
+func experimentVariant(ctx context.Context) string {
+    state, _ := ctx.Value(experimentKey{}).(experimentState)
+    return state.variant
+}
+
+// restartExperimentClock a kontextus kísérleti késleltetésmérését most kezdi újra; a WebSocket
+// kapcsolat üzenetei így a kapcsolat élettartama helyett a saját késleltetésükkel számítanak.
+func restartExperimentClock(ctx context.Context) context.Context {
+    state, ok := ctx.Value(experimentKey{}).(experimentState)
+    if !ok {
+        return ctx
+    }
+    state.start = time.Now()
+    return context.WithValue(ctx, experimentKey{}, state)
+}
+
+// recordExperiment a javaslatkérést a kérés kísérleti változatának számlálóiba rögzíti.
+func (s *Server) recordExperiment(ctx context.Context, set suggest.Set) {
+    state, ok := ctx.Value(experimentKey{}).(experimentState)
+    if !ok {
+        return
+    }
+    s.experiment.RecordRequest(state.variant, len(set.Suggestions), set.Fuzzy, time.Since(state.start))
+}
+
+// recordExperimentSelection a kiválasztást (POST /api/select) a kérés kísérleti változatához rögzíti.
+func (s *Server) recordExperimentSelection(ctx context.Context) {
+    if variant := experimentVariant(ctx); variant != "" {
+        s.experiment.RecordSelection(variant)
+    }
+}
+
+// isVariant jelzi, hogy a futó kísérletnek van-e name nevű változata.
+func (s *Server) isVariant(name string) bool {
+    _, ok := s.overrides[name]
+    return ok
+}
+
+// ExperimentStats a futó kísérlet összehasonlító jelentése (expvar "experiment"); kikapcsolt
+// kísérletnél nil.
+func (s *Server) ExperimentStats() interface{} {
+    if s.experiment == nil {
+        return nil
+    }
+    return s.experiment.Report()
+}
+
+// experimentsHandler kezeli a GET /api/admin/experiments végpontot: a futó kísérlet változatainak
+// kérés-, találat nélküli, elgépelés-tűrő és kiválasztásszámait, arányait és késleltetését adja
+// vissza a kontrollhoz mért kiválasztási aránnyal (lásd experiment.Report). Kikapcsolt kísérletnél 501.
+func (s *Server) experimentsHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+        return
+    }
+    if s.experiment == nil {
+        writeError(w, r, http.StatusNotImplemented, ErrCodeNotImplemented, "Nincs futó kísérlet (EXPERIMENT_NAME)")
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(s.experiment.Report()); err != nil {
+        slog.Error("Hiba a kísérleti jelentés kódolásakor", "error", err)
+    }
+}
@@ -0,0 +1,102 @@
+package httpapi
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "strconv"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// exportFlushRows ennyi soronként küldjük ki a pufferelt választ, hogy a nagy exportok
+// folyamatosan érkezzenek.
+const exportFlushRows = 1000
+
+// exportHandler kezeli a GET /api/admin/export végpontot, amely az index teljes egyedi
+// településlistáját (streets=1 esetén település–közterület párjait) streameli offline
+// ellenőrzéshez és a forrásregiszterrel való összevetéshez. Query paraméterek:
+//   - format: "csv" (alapértelmezés, fejléccel) vagy "ndjson" (soronként egy suggest.ExportRow)
+//   - streets=1: a közterületek is szerepeljenek
+//
+// Ha az OpenSearch hiba a válasz megkezdése után lép fel, a kapcsolatot megszakítjuk, így a
+// csonka export nem tűnik teljesnek.
+func (s *Server) exportHandler(w http.ResponseWriter, r *http.Request) {
+    exporter, ok := s.suggester.(suggest.Exporter)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+        return
+    }
+    params := r.URL.Query()
+    format := params.Get("format")
+    if format == "" {
+        format = "csv"
+    }
+    if format != "csv" && format != "ndjson" {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "A 'format' paraméter értéke 'csv' vagy 'ndjson' lehet")
+        return
+    }
+    streets := params.Get("streets") == "1"
+
+    rows := 0
+    csvWriter := csv.NewWriter(w)
+    encoder := json.NewEncoder(w)
+    flush := func() {
+        csvWriter.Flush()
+        http.NewResponseController(w).Flush()
+    }
+    // start az első sor előtt (üres exportnál a végén) írja ki a fejléceket, így az első lap
+    // lekérdezésének hibája még rendes hibaválaszként mehet ki.
+    start := func() {
+        w.Header().Set("Content-Disposition", `attachment; filename="cimlista-export.`+format+`"`)
+        if format == "ndjson" {
+            w.Header().Set("Content-Type", "application/x-ndjson")
+            return
+        }
+        w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+        header := []string{"telepules", "count"}
+        if streets {
+            header = []string{"telepules", "kozter_nev", "count"}
+        }
+        csvWriter.Write(header)
+    }
+    err := exporter.Export(r.Context(), streets, func(row suggest.ExportRow) error {
+        if rows == 0 {
+            start()
+        }
+        rows++
+        var err error
+        if format == "ndjson" {
+            err = encoder.Encode(row)
+        } else {
+            record := []string{row.Telepules, strconv.FormatInt(row.Count, 10)}
+            if streets {
+                record = []string{row.Telepules, row.KozterNev, strconv.FormatInt(row.Count, 10)}
+            }
+            err = csvWriter.Write(record)
+        }
+        if rows%exportFlushRows == 0 {
+            flush()
+        }
+        return err
+    })
+    reqlog.Add(r.Context(), "format", format, "streets", streets, "rows", rows)
+    if err != nil {
+        slog.Error("Export error", "request_id", reqlog.RequestID(r.Context()), "rows", rows, "error", err)
+        if rows == 0 {
+            writeUpstreamError(w, r, err, "Hiba az export lekérdezésekor")
+            return
+        }
+        panic(http.ErrAbortHandler)
+    }
+    if rows == 0 {
+        start()
+    }
+    flush()
+}
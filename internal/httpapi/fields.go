@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+    "fmt"
+    "log/slog"
+    "net/http"
+    "sort"
+    "strings"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// kindFields a többmezős javaslatkérés fajtája a lekérdezés-statisztikában és a GeoJSON
+// válasz "kind" tulajdonságában.
+const kindFields = "fields"
+
+// suggestFields a "fields" paraméter vesszővel elválasztott neveit a SUGGEST_FIELDS mezőtábla
+// szerint index mezőkre oldja fel, a megadás sorrendjében és ismétlődés nélkül. Üres vagy
+// ismeretlen név esetén hibát ad, amely a választható neveket is felsorolja.
+func (s *Server) suggestFields(param string) ([]suggest.Field, error) {
+    table := s.Options().SuggestFields
+    var fields []suggest.Field
+    seen := map[string]bool{}
+    for _, name := range strings.Split(param, ",") {
+        name = strings.TrimSpace(name)
+        if seen[name] {
+            continue
+        }
+        field, ok := table[name]
+        if !ok {
+            names := make([]string, 0, len(table))
+            for name := range table {
+                names = append(names, name)
+            }
+            sort.Strings(names)
+            return nil, fmt.Errorf("ismeretlen mező a 'fields' paraméterben: %q (választható: %s)", name, strings.Join(names, ", "))
+        }
+        seen[name] = true
+        fields = append(fields, suggest.Field{Name: name, IndexField: field})
+    }
+    return fields, nil
+}
+
+// fieldAutocomplete az /api/autocomplete "fields" paraméteres, többmezős változata: a
+// lekérdezést a megadott mezők mindegyikén futtatja (lásd suggest.FieldSuggester), a
+// javaslatobjektumokat (items) pedig a forrásmezőjük nevével együtt mindig kiírja. A "megye"
+// és a "telepules" paraméter minden mezőre szűkít. Ha a háttérrendszer nem támogatja, 501.
+func (s *Server) fieldAutocomplete(w http.ResponseWriter, r *http.Request, query string, near *index.GeoPoint, sortBy, highlight, format string) {
+    suggester, ok := s.suggester.(suggest.FieldSuggester)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    fields, err := s.suggestFields(r.URL.Query().Get("fields"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    req := suggest.Request{Query: query, Megye: r.URL.Query().Get("megye"), Telepules: r.URL.Query().Get("telepules"), Near: near, Sort: sortBy}
+    set, debugInfo, err := suggester.SuggestFields(r.Context(), req, fields)
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a javaslatok lekérésekor")
+        slog.Error("Field autocomplete error", "request_id", reqlog.RequestID(r.Context()), "query", query, "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "fields", len(fields))
+    s.writeSuggestions(w, r, kindFields, query, set, debugInfo, highlight, format)
+}
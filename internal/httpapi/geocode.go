@@ -0,0 +1,104 @@
+package httpapi
+
+import (
+    "log/slog"
+    "net/http"
+    "strings"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// geocodeFormats a geokódoló végpontok válaszformátumai (lásd responseFormat).
+var geocodeFormats = []string{formatJSON, formatGeoJSON, formatMsgPack}
+
+// geocodeHandler kezeli a GET /api/geocode végpontot, amely a "telepules" (és opcionálisan
+// "kozter_nev", "irsz") paraméterekkel megadott címet ellenőrzi, és érvényes cím esetén a
+// koordinátáit adja vissza (lásd suggest.Geocode); "format=geojson" esetén GeoJSON-ként.
+func (s *Server) geocodeHandler(w http.ResponseWriter, r *http.Request) {
+    geocoder, ok := s.suggester.(suggest.Geocoder)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+        return
+    }
+    params := r.URL.Query()
+    format, err := responseFormat(r, geocodeFormats...)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    in := suggest.AddressInput{Telepules: params.Get("telepules"), KozterNev: params.Get("kozter_nev"), Irsz: params.Get("irsz")}
+    if strings.TrimSpace(in.Telepules) == "" {
+        writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, "Hiányzó 'telepules' paraméter")
+        return
+    }
+    result, err := geocoder.Geocode(r.Context(), in)
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a cím geokódolásakor")
+        slog.Error("Geocode error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "valid", result.Valid, "precision", result.Precision)
+    var response interface{} = result
+    if format == formatGeoJSON {
+        response = geocodeFeatures(result)
+    }
+    writeGeocodeResponse(w, response, format)
+}
+
+// writeGeocodeResponse a geokódoló végpontok válaszát írja ki a format szerint kódolva.
+func writeGeocodeResponse(w http.ResponseWriter, response interface{}, format string) {
+    body, contentType, err := encodeResponse(response, format)
+    if err != nil {
+        slog.Error("Hiba a geokódolás válaszának kódolásakor", "format", format, "error", err)
+        return
+    }
+    w.Header().Set("Content-Type", contentType)
+    w.Header().Add("Vary", "Accept")
+    w.Write(body)
+}
+
+// reverseGeocodeHandler kezeli a GET /api/reverse-geocode végpontot, amely a "lat" és "lon"
+// ponthoz legközelebbi települést és közterületet adja vissza a távolsággal együtt; "format=geojson"
+// esetén GeoJSON-ként.
+func (s *Server) reverseGeocodeHandler(w http.ResponseWriter, r *http.Request) {
+    geocoder, ok := s.suggester.(suggest.Geocoder)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+        return
+    }
+    format, err := responseFormat(r, geocodeFormats...)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    point, err := parseNear(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    if point == nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, "Hiányzó 'lat' és 'lon' paraméter")
+        return
+    }
+    result, err := geocoder.ReverseGeocode(r.Context(), *point)
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a fordított geokódoláskor")
+        slog.Error("Reverse geocode error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "found", result.Found, "distance_m", int(result.DistanceMeters))
+    var response interface{} = result
+    if format == formatGeoJSON {
+        response = reverseGeocodeFeatures(result)
+    }
+    writeGeocodeResponse(w, response, format)
+}
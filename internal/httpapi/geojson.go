@@ -0,0 +1,103 @@
+package httpapi
+
+import (
+    "autocomplete/internal/index"
+    "autocomplete/internal/suggest"
+)
+
+// geoJSONContentType a GeoJSON válaszok típusa.
+const geoJSONContentType = "application/geo+json"
+
+// FeatureCollection egy GeoJSON pontgyűjtemény. A Hint a túl rövid lekérdezésre adott üres
+// válasz magyarázata (idegen tag, a GeoJSON feldolgozók figyelmen kívül hagyják).
+type FeatureCollection struct {
+    Type     string    `json:"type"`
+    Features []Feature `json:"features"`
+    Hint     string    `json:"hint,omitempty"`
+}
+
+// Feature egy GeoJSON pont a tulajdonságaival.
+type Feature struct {
+    Type       string                 `json:"type"`
+    Geometry   PointGeometry          `json:"geometry"`
+    Properties map[string]interface{} `json:"properties"`
+}
+
+// PointGeometry egy GeoJSON Point; a koordináták sorrendje [lon, lat].
+type PointGeometry struct {
+    Type        string     `json:"type"`
+    Coordinates [2]float64 `json:"coordinates"`
+}
+
+// newFeatureCollection üres FeatureCollection-t ad vissza (a Features nem nil, így "[]"-ként kerül ki).
+func newFeatureCollection() FeatureCollection {
+    return FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+}
+
+// add a p ponton tulajdonságokkal ellátott Feature-t ad a gyűjteményhez.
+func (fc *FeatureCollection) add(p index.GeoPoint, properties map[string]interface{}) {
+    fc.Features = append(fc.Features, Feature{
+        Type:       "Feature",
+        Geometry:   PointGeometry{Type: "Point", Coordinates: [2]float64{p.Lon, p.Lat}},
+        Properties: properties,
+    })
+}
+
+// suggestionFeatures a helyadattal rendelkező javaslatokat sorrendjükben Feature-ként adja
+// vissza; a helyadat nélküliek kimaradnak, a "rank" tulajdonság az eredeti helyük.
+func suggestionFeatures(kind string, set suggest.Set) FeatureCollection {
+    fc := newFeatureCollection()
+    for i, item := range set.Items() {
+        if item.Location == nil {
+            continue
+        }
+        properties := map[string]interface{}{"value": item.Value, "kind": kind, "rank": i + 1}
+        if item.KSHKod != "" {
+            properties["kshKod"] = item.KSHKod
+        }
+        if item.Field != "" {
+            properties["field"] = item.Field
+        }
+        fc.add(*item.Location, properties)
+    }
+    return fc
+}
+
+// documentProperties a rekord címrészei GeoJSON tulajdonságként (az üresek nélkül).
+func documentProperties(doc *index.AddressDocument) map[string]interface{} {
+    properties := map[string]interface{}{}
+    if doc == nil {
+        return properties
+    }
+    for key, value := range map[string]string{"telepules": doc.Telepules, "kozter_nev": doc.KozterNev, "irsz": doc.Irsz, "megye": doc.Megye, "ksh_kod": doc.KSHKod} {
+        if value != "" {
+            properties[key] = value
+        }
+    }
+    return properties
+}
+
+// geocodeFeatures a geokódolás eredménye GeoJSON-ként: egy pont, ha van koordináta, egyébként üres.
+func geocodeFeatures(result suggest.Geocode) FeatureCollection {
+    fc := newFeatureCollection()
+    if result.Location == nil {
+        return fc
+    }
+    properties := documentProperties(result.Canonical)
+    properties["precision"] = result.Precision
+    fc.add(*result.Location, properties)
+    return fc
+}
+
+// reverseGeocodeFeatures a fordított geokódolás eredménye GeoJSON-ként: a legközelebbi rekord
+// pontja a távolsággal, vagy üres, ha a sugáron belül nincs rekord.
+func reverseGeocodeFeatures(result suggest.ReverseGeocode) FeatureCollection {
+    fc := newFeatureCollection()
+    if !result.Found || result.Nearest.GeoPoint() == nil {
+        return fc
+    }
+    properties := documentProperties(result.Nearest)
+    properties["distanceMeters"] = result.DistanceMeters
+    fc.add(*result.Nearest.GeoPoint(), properties)
+    return fc
+}
@@ -0,0 +1,217 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+
+    "github.com/graphql-go/graphql"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+var suggestionItemType = graphql.NewObject(graphql.ObjectConfig{
+    Name: "SuggestionItem",
+    Fields: graphql.Fields{
+        "value":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+        "kshKod": &graphql.Field{Type: graphql.String},
+        "weight": &graphql.Field{Type: graphql.Int},
+    },
+})
+
+var suggestionsType = graphql.NewObject(graphql.ObjectConfig{
+    Name: "Suggestions",
+    Fields: graphql.Fields{
+        "suggestions": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+        "items":       &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(suggestionItemType)))},
+        "fuzzy":       &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+        "stale":       &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+    },
+})
+
+var validationType = graphql.NewObject(graphql.ObjectConfig{
+    Name: "AddressValidation",
+    Fields: graphql.Fields{
+        "valid":           &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+        "settlementFound": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+        "streetFound":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+        "zipMatches":      &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+        "kshKod":          &graphql.Field{Type: graphql.String},
+    },
+})
+
+// suggestionsResult a javaslatmotor eredményét a Suggestions GraphQL típus mezőneveire képezi.
+func suggestionsResult(set suggest.Set) map[string]interface{} {
+    suggestions := set.Suggestions
+    if suggestions == nil {
+        suggestions = []string{}
+    }
+    items := make([]interface{}, 0, len(suggestions))
+    for _, item := range set.Items() {
+        entry := map[string]interface{}{"value": item.Value}
+        if item.KSHKod != "" {
+            entry["kshKod"] = item.KSHKod
+        }
+        if item.Weight > 0 {
+            entry["weight"] = item.Weight
+        }
+        items = append(items, entry)
+    }
+    return map[string]interface{}{"suggestions": suggestions, "items": items, "fuzzy": set.Fuzzy, "stale": set.Stale}
+}
+
+// resolverError naplózza a háttérrendszer hibáját, a kliensnek pedig a REST végpontokéval
+// egyező, belső részleteket nem tartalmazó üzenetet ad vissza.
+func resolverError(p graphql.ResolveParams, err error) error {
+    if err == nil {
+        return nil
+    }
+    slog.Error("GraphQL resolver error", "request_id", reqlog.RequestID(p.Context), "field", p.Info.FieldName, "error", err)
+    apiErr, _ := upstreamAPIError(err, "Hiba a javaslatok lekérésekor")
+    return errors.New(localize(p.Context, apiErr.Message))
+}
+
+// graphQLSuggest a REST végpontokkal azonos hosszkorláttal kér javaslatot: a MAX_QUERY_LEN-nél
+// hosszabb prefix hiba, a MIN_QUERY_LEN-nél rövidebbre háttérkérés nélkül üres eredményt ad.
+func (s *Server) graphQLSuggest(p graphql.ResolveParams, req suggest.Request) (interface{}, error) {
+    hint, err := s.queryLength(p.Context, req.Query)
+    if err != nil {
+        return nil, err
+    }
+    if hint != "" {
+        return suggestionsResult(suggest.Set{}), nil
+    }
+    set, _, err := s.suggester.Suggest(p.Context, req)
+    if err == nil {
+        s.recordQuery(p.Context, req.Kind, req.Query, set)
+    }
+    return suggestionsResult(set), resolverError(p, err)
+}
+
+func stringArg(p graphql.ResolveParams, name string) string {
+    s, _ := p.Args[name].(string)
+    return s
+}
+
+// newGraphQLSchema összeállítja a /graphql végpont sémáját. Egy kérésben lekérhetők a település-
+// és (településre szűkített) közterület-javaslatok és egy cím ellenőrzése is, ugyanazzal a
+// háttérrendszerrel, mint a REST végpontok, így a gyorsítótár és a tartalék lekérdezések is érvényesek.
+//
+//	query {
+//	  settlements(prefix: "Buda") { suggestions fuzzy items { value kshKod } }
+//	  streets(prefix: "Fő", telepules: "Budapest") { suggestions }
+//	  validate(telepules: "Budapest", kozterNev: "Fő utca", irsz: "1011") { valid streetFound zipMatches kshKod }
+//	}
+func (s *Server) newGraphQLSchema() graphql.Schema {
+    prefixArg := &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}
+    query := graphql.NewObject(graphql.ObjectConfig{
+        Name: "Query",
+        Fields: graphql.Fields{
+            "settlements": &graphql.Field{
+                Type: graphql.NewNonNull(suggestionsType),
+                Args: graphql.FieldConfigArgument{"prefix": prefixArg, "megye": &graphql.ArgumentConfig{Type: graphql.String}},
+                Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+                    return s.graphQLSuggest(p, suggest.Request{Kind: suggest.KindSettlement, Query: stringArg(p, "prefix"), Megye: stringArg(p, "megye"), Sort: s.Options().DefaultSort})
+                },
+            },
+            "streets": &graphql.Field{
+                Type: graphql.NewNonNull(suggestionsType),
+                Args: graphql.FieldConfigArgument{"prefix": prefixArg, "telepules": &graphql.ArgumentConfig{Type: graphql.String}},
+                Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+                    return s.graphQLSuggest(p, suggest.Request{Kind: suggest.KindStreet, Query: stringArg(p, "prefix"), Telepules: stringArg(p, "telepules"), Sort: s.Options().DefaultSort})
+                },
+            },
+            "addresses": &graphql.Field{
+                Type: graphql.NewNonNull(suggestionsType),
+                Args: graphql.FieldConfigArgument{"prefix": prefixArg},
+                Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+                    return s.graphQLSuggest(p, suggest.Request{Kind: suggest.KindAddress, Query: stringArg(p, "prefix"), Sort: s.Options().DefaultSort})
+                },
+            },
+            "validate": &graphql.Field{
+                Type: graphql.NewNonNull(validationType),
+                Args: graphql.FieldConfigArgument{
+                    "telepules": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+                    "kozterNev": &graphql.ArgumentConfig{Type: graphql.String},
+                    "irsz":      &graphql.ArgumentConfig{Type: graphql.String},
+                },
+                Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+                    v, err := s.suggester.Validate(p.Context, suggest.AddressInput{Telepules: stringArg(p, "telepules"), KozterNev: stringArg(p, "kozterNev"), Irsz: stringArg(p, "irsz")})
+                    if err != nil {
+                        return nil, resolverError(p, err)
+                    }
+                    result := map[string]interface{}{
+                        "valid":           v.Valid,
+                        "settlementFound": v.SettlementFound,
+                        "streetFound":     v.StreetFound,
+                        "zipMatches":      v.ZipMatches,
+                    }
+                    if v.Canonical != nil && v.Canonical.KSHKod != "" {
+                        result["kshKod"] = v.Canonical.KSHKod
+                    }
+                    return result, nil
+                },
+            },
+        },
+    })
+    schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query})
+    if err != nil {
+        panic(err)
+    }
+    return schema
+}
+
+// graphQLRequest a GraphQL over HTTP kérés törzse.
+type graphQLRequest struct {
+    Query         string                 `json:"query"`
+    OperationName string                 `json:"operationName"`
+    Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphQLHandler kezeli a /graphql végpontot: POST esetén JSON törzset, GET esetén a
+// query, operationName és variables (JSON) query paramétereket vár. A mezőszintű hibák a
+// GraphQL szokás szerint 200-as válasz "errors" tömbjébe kerülnek.
+func (s *Server) graphQLHandler(w http.ResponseWriter, r *http.Request) {
+    var req graphQLRequest
+    switch r.Method {
+    case http.MethodGet:
+        params := r.URL.Query()
+        req.Query = params.Get("query")
+        req.OperationName = params.Get("operationName")
+        if v := params.Get("variables"); v != "" {
+            if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+                writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "Hibás 'variables' paraméter")
+                return
+            }
+        }
+    case http.MethodPost:
+        if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "Hibás JSON törzs")
+            return
+        }
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET és POST kérés engedélyezett")
+        return
+    }
+    if req.Query == "" {
+        writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, "Hiányzó 'query'")
+        return
+    }
+
+    result := graphql.Do(graphql.Params{
+        Schema:         s.graphQLSchema,
+        RequestString:  req.Query,
+        VariableValues: req.Variables,
+        OperationName:  req.OperationName,
+        Context:        r.Context(),
+    })
+    if len(result.Errors) > 0 {
+        reqlog.Add(r.Context(), "graphql_errors", len(result.Errors))
+    }
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(result); err != nil {
+        slog.Error("Hiba a GraphQL válasz kódolásakor", "error", err)
+    }
+}
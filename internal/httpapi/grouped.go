@@ -0,0 +1,174 @@
+package httpapi
+
+import (
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// defaultGroupLimit a csoportonként visszaadott javaslatok alapértelmezett legnagyobb száma,
+// maxGroupLimit a kérhető legnagyobb érték.
+const (
+    defaultGroupLimit = 5
+    maxGroupLimit     = 50
+)
+
+// GroupedResult az /api/autocomplete/grouped végpont válasza: a település-, közterület- és
+// irányítószám-javaslatok fajtánként, a csoportos legördülő listák szakaszainak megfelelően.
+// A Matched és a KSHCodes (településnév → KSH kód) a településcsoportra vonatkozik (lásd
+// SearchResult); a Fuzzy és a Stale akkor
+// igaz, ha bármelyik csoportra igaz; a Hint a túl rövid lekérdezés magyarázata (lásd SearchResult).
+type GroupedResult struct {
+    Settlements []string          `json:"settlements"`
+    Streets     []string          `json:"streets"`
+    Zips        []string          `json:"zips"`
+    Matched     map[string]string `json:"matched,omitempty"`
+    KSHCodes    map[string]string `json:"kshCodes,omitempty"`
+    Fuzzy       bool              `json:"fuzzy,omitempty"`
+    Stale       bool              `json:"stale,omitempty"`
+    Hint        string            `json:"hint,omitempty"`
+}
+
+// groupedFormats a csoportos végpont válaszformátumai (lásd responseFormat).
+var groupedFormats = []string{formatJSON, formatCSV, formatMsgPack}
+
+// csvRecords a csoportos javaslatok CSV sorai: csoport (settlement, street, zip), érték és a
+// település KSH kódja.
+func (g GroupedResult) csvRecords() [][]string {
+    records := [][]string{{"kind", "value", "ksh_kod"}}
+    for _, group := range []struct {
+        kind   string
+        values []string
+    }{{suggest.KindSettlement, g.Settlements}, {suggest.KindStreet, g.Streets}, {suggest.KindZip, g.Zips}} {
+        for _, value := range group.values {
+            code := ""
+            if group.kind == suggest.KindSettlement {
+                code = g.KSHCodes[value]
+            }
+            records = append(records, []string{group.kind, value, code})
+        }
+    }
+    return records
+}
+
+// suggestionGroup a csoportos keresés egy csoportja: a javaslatkérés és a csoport limitje.
+type suggestionGroup struct {
+    param string
+    req   suggest.Request
+    limit int
+    set   suggest.Set
+    err   error
+}
+
+// groupLimit a "<name>" limit paraméter értéke 0 és maxGroupLimit között; üresen
+// defaultGroupLimit. A 0 kihagyja a csoportot.
+func groupLimit(r *http.Request, name string) (int, error) {
+    v := r.URL.Query().Get(name)
+    if v == "" {
+        return defaultGroupLimit, nil
+    }
+    n, err := strconv.Atoi(v)
+    if err != nil || n < 0 || n > maxGroupLimit {
+        return 0, fmt.Errorf("a '%s' paraméter 0 és %d közötti egész szám lehet", name, maxGroupLimit)
+    }
+    return n, nil
+}
+
+// groupedAutocompleteHandler kezeli az /api/autocomplete/grouped végpontot: a település-,
+// közterület- és irányítószám-javaslatokat párhuzamosan kérdezi le, és fajtánként csoportosítva
+// adja vissza. A csoportok mérete a "settlementLimit", "streetLimit" és "zipLimit" paraméterrel
+// állítható (0 kihagyja a csoportot); irányítószámot csak számjegyekből álló lekérdezésre keres.
+// A "megye", "telepules" és "sort" paraméterek az egyes végpontokhoz hasonlóan működnek. A
+// MIN_QUERY_LEN-nél rövidebb lekérdezés csak irányítószám-előtagként (számjegyekkel) keres.
+// Bármelyik csoport hibája a teljes kérés hibája. A válasz JSON, CSV vagy MessagePack lehet
+// (lásd responseFormat).
+func (s *Server) groupedAutocompleteHandler(w http.ResponseWriter, r *http.Request) {
+    query := strings.TrimSpace(r.URL.Query().Get("q"))
+    if query == "" {
+        writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, "Hiányzó 'q' paraméter")
+        return
+    }
+    format, err := s.suggestionResponseFormat(r, groupedFormats...)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    hint, err := s.queryLength(r.Context(), query)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    if hint != "" && !suggest.IsZipPrefix(query) {
+        s.writeSuggestionResponse(w, r, GroupedResult{Settlements: []string{}, Streets: []string{}, Zips: []string{}, Hint: hint}, format, true)
+        return
+    }
+    sortBy, err := s.sortMode(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    groups := []*suggestionGroup{
+        {param: "settlementLimit", req: suggest.Request{Kind: suggest.KindSettlement, Query: query, Megye: r.URL.Query().Get("megye"), Sort: sortBy}},
+        {param: "streetLimit", req: suggest.Request{Kind: suggest.KindStreet, Query: query, Telepules: r.URL.Query().Get("telepules"), Sort: sortBy}},
+        {param: "zipLimit", req: suggest.Request{Kind: suggest.KindZip, Query: query, Sort: sortBy}},
+    }
+    for _, g := range groups {
+        if g.limit, err = groupLimit(r, g.param); err != nil {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+            return
+        }
+    }
+    if !suggest.IsZipPrefix(query) {
+        groups[2].limit = 0
+    }
+    if hint != "" {
+        groups[0].limit, groups[1].limit = 0, 0
+    }
+
+    var wg sync.WaitGroup
+    for _, g := range groups {
+        if g.limit == 0 {
+            continue
+        }
+        wg.Add(1)
+        go func(g *suggestionGroup) {
+            defer wg.Done()
+            g.set, _, g.err = s.suggester.Suggest(r.Context(), g.req)
+        }(g)
+    }
+    wg.Wait()
+
+    response := GroupedResult{Hint: hint}
+    results := []*[]string{&response.Settlements, &response.Streets, &response.Zips}
+    for i, g := range groups {
+        if g.err != nil {
+            writeUpstreamError(w, r, g.err, "Hiba a javaslatok lekérésekor")
+            slog.Error("Grouped autocomplete error", "request_id", reqlog.RequestID(r.Context()), "kind", g.req.Kind, "query", query, "error", g.err)
+            return
+        }
+        suggestions := g.set.Suggestions
+        if len(suggestions) > g.limit {
+            suggestions = suggestions[:g.limit]
+        }
+        if suggestions == nil {
+            suggestions = []string{}
+        }
+        *results[i] = suggestions
+        if g.limit > 0 {
+            s.recordQuery(r.Context(), g.req.Kind, query, g.set)
+        }
+        response.Fuzzy = response.Fuzzy || g.set.Fuzzy
+        response.Stale = response.Stale || g.set.Stale
+    }
+    response.Matched = groups[0].set.Matched
+    response.KSHCodes = groups[0].set.KSHCodes
+    reqlog.Add(r.Context(), "query", query, "settlement_count", len(response.Settlements),
+        "street_count", len(response.Streets), "zip_count", len(response.Zips))
+    s.writeSuggestionResponse(w, r, response, format, !response.Stale)
+}
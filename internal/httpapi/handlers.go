@@ -0,0 +1,430 @@
+package httpapi
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "unicode/utf8"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// SearchResult tartalmazza az autocomplete javaslatokat és a debug információkat.
+// A Fuzzy jelzi, hogy a javaslatok az elgépelés-tűrő tartalék lekérdezésből származnak,
+// a Stale pedig azt, hogy a háttérrendszer hibája miatt korábbi, elavult javaslatokat adunk vissza.
+// A Matched a jelenlegi településnévhez azt a korábbi vagy alternatív nevet adja, amelyre a
+// lekérdezés illeszkedett (pl. {"Kaposvár": "Toponár"}). A Hint a MIN_QUERY_LEN-nél rövidebb
+// lekérdezésre kapott üres lista magyarázata. A Highlights a "highlight" paraméter
+// megadásakor a javaslatokkal azonos sorrendben a lekérdezésre illeszkedő szakaszokat adja.
+// Az Items a "details=1" paraméter megadásakor a javaslatokat objektumként, a hozzájuk
+// tartozó azonosítókkal (településeknél a KSH kóddal) adja vissza, szintén azonos sorrendben.
+// A Meta a RESPONSE_META bekapcsolásakor a kérés időzítési adatai (lásd ResponseMeta).
+type SearchResult struct {
+    Suggestions []string            `json:"suggestions"`
+    Fuzzy       bool                `json:"fuzzy,omitempty"`
+    Stale       bool                `json:"stale,omitempty"`
+    Matched     map[string]string   `json:"matched,omitempty"`
+    Highlights  []suggest.Highlight `json:"highlights,omitempty"`
+    Items       []suggest.Item      `json:"items,omitempty"`
+    Hint        string              `json:"hint,omitempty"`
+    Debug       string              `json:"debug,omitempty"`
+    Meta        *ResponseMeta       `json:"meta,omitempty"`
+}
+
+// csvRecords a javaslatok CSV sorai: érték, a település KSH kódja és a helyadat (ha van). A
+// javaslatobjektumokból (Items) dolgozik, ha ki vannak töltve, egyébként a javaslatokból.
+// Többmezős kérésnél (lásd fieldAutocomplete) a forrásmező neve a "field" oszlopba kerül.
+func (res SearchResult) csvRecords() [][]string {
+    header := []string{"value", "ksh_kod", "lat", "lon"}
+    withField := len(res.Items) > 0 && res.Items[0].Field != ""
+    if withField {
+        header = append(header, "field")
+    }
+    records := [][]string{header}
+    items := res.Items
+    if items == nil {
+        for _, suggestion := range res.Suggestions {
+            items = append(items, suggest.Item{Value: suggestion})
+        }
+    }
+    for _, item := range items {
+        lat, lon := "", ""
+        if item.Location != nil {
+            lat, lon = strconv.FormatFloat(item.Location.Lat, 'f', -1, 64), strconv.FormatFloat(item.Location.Lon, 'f', -1, 64)
+        }
+        record := []string{item.Value, item.KSHKod, lat, lon}
+        if withField {
+            record = append(record, item.Field)
+        }
+        records = append(records, record)
+    }
+    return records
+}
+
+// A "highlight" paraméter értékei: offsets esetén a kiemelt szakaszok rune indexei, html
+// esetén ezek mellett a javaslat <em> elemekkel kiemelt, HTML-biztos alakja is.
+const (
+    highlightOffsets = "offsets"
+    highlightHTML    = "html"
+)
+
+// debugRequested jelzi, hogy a kérés debug információt kér-e (?debug=1 vagy X-Debug: 1 fejléc).
+// Ha a DebugEnabled szerveroldali kapcsoló ki van kapcsolva, mindig hamis.
+func (s *Server) debugRequested(r *http.Request) bool {
+    if !s.Options().DebugEnabled {
+        return false
+    }
+    for _, v := range []string{r.URL.Query().Get("debug"), r.Header.Get("X-Debug")} {
+        if on, err := strconv.ParseBool(v); err == nil && on {
+            return true
+        }
+    }
+    return false
+}
+
+// queryLength a lekérdezés normalizált alakjának karakterszámát veti össze a MinQueryLength és
+// MaxQueryLength korláttal. A túl rövid lekérdezésre hint-et ad (a kezelők ilyenkor háttérkérés
+// nélkül üres listát adnak vissza), a túl hosszúra hibát; mindkettőt a kérés nyelvén.
+func (s *Server) queryLength(ctx context.Context, query string) (hint string, err error) {
+    opts := s.Options()
+    n := utf8.RuneCountInString(suggest.NormalizeQuery(query, false))
+    switch {
+    case opts.MaxQueryLength > 0 && n > opts.MaxQueryLength:
+        return "", errors.New(localize(ctx, fmt.Sprintf("a 'q' paraméter legfeljebb %d karakter lehet", opts.MaxQueryLength)))
+    case n < opts.MinQueryLength:
+        return localize(ctx, fmt.Sprintf("Legalább %d karakter szükséges a javaslatokhoz", opts.MinQueryLength)), nil
+    }
+    return "", nil
+}
+
+// rejectQueryLength a queryLength szerint túl hosszú lekérdezésre 400-as hibát, túl rövidre
+// hint-tel ellátott üres SearchResult-ot (GeoJSON formátumnál FeatureCollection-t) ír ki a
+// format szerint, és ilyenkor true-t ad.
+func (s *Server) rejectQueryLength(w http.ResponseWriter, r *http.Request, query, format string) bool {
+    hint, err := s.queryLength(r.Context(), query)
+    switch {
+    case err != nil:
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return true
+    case hint != "":
+        reqlog.Add(r.Context(), "query", query, "result_count", 0, "too_short", true)
+        if format == formatGeoJSON {
+            fc := newFeatureCollection()
+            fc.Hint = hint
+            s.writeSuggestionResponse(w, r, fc, format, true)
+            return true
+        }
+        meta := responseMeta(r, query)
+        s.writeSuggestionResponse(w, r, SearchResult{Suggestions: []string{}, Hint: hint, Meta: meta}, format, meta == nil)
+        return true
+    }
+    return false
+}
+
+// highlightMode az opcionális "highlight" paraméter értéke (üres, ha nincs megadva);
+// ismeretlen érték esetén hibát ad.
+func highlightMode(mode string) (string, error) {
+    switch mode {
+    case "", highlightOffsets, highlightHTML:
+        return mode, nil
+    }
+    return "", fmt.Errorf("a 'highlight' értéke %s vagy %s lehet", highlightOffsets, highlightHTML)
+}
+
+// highlights a javaslatok kiemelései a highlight mód szerint; üres módnál nil.
+func highlights(suggestions []string, query, mode string) []suggest.Highlight {
+    if mode == "" {
+        return nil
+    }
+    return suggest.Highlights(suggestions, query, mode == highlightHTML)
+}
+
+// writeSuggestions a háttérrendszer kind fajtájú eredményét SearchResult válaszként írja ki,
+// és rögzíti a lekérdezés-statisztikában; a highlight mód megadásakor a kiemeléseket, a
+// "details=1" paraméterrel a javaslatobjektumokat is kiírja. A format szerint kódol (lásd
+// responseFormat); GeoJSON formátumnál SearchResult helyett a helyadattal rendelkező javaslatok
+// FeatureCollection-jét adja, CSV-nél a javaslatobjektumokat soronként.
+func (s *Server) writeSuggestions(w http.ResponseWriter, r *http.Request, kind, query string, set suggest.Set, debugInfo, highlight, format string) {
+    reqlog.Add(r.Context(), "query", query, "result_count", len(set.Suggestions), "fuzzy", set.Fuzzy)
+    s.recordQuery(r.Context(), kind, query, set)
+    if format == formatGeoJSON {
+        s.writeSuggestionResponse(w, r, suggestionFeatures(kind, set), format, !set.Stale)
+        return
+    }
+    response := SearchResult{Suggestions: set.Suggestions, Fuzzy: set.Fuzzy, Stale: set.Stale, Matched: set.Matched,
+        Highlights: highlights(set.Suggestions, query, highlight)}
+    if r.URL.Query().Get("details") == "1" || format == formatCSV || set.Sources != nil {
+        response.Items = set.Items()
+    }
+    if s.debugRequested(r) {
+        response.Debug = localize(r.Context(), debugInfo)
+    }
+    response.Meta = responseMeta(r, query)
+    s.capResponse(r, query, &response)
+    s.writeSuggestionResponse(w, r, response, format, !response.Stale && response.Debug == "" && response.Meta == nil)
+}
+
+// parseNear az opcionális "lat" és "lon" paraméterekből a felhasználó helyzetét olvassa ki.
+// Ha egyik sincs megadva, nil-t ad; ha csak az egyik, vagy valamelyik érvénytelen, hibát.
+func parseNear(r *http.Request) (*index.GeoPoint, error) {
+    latParam, lonParam := r.URL.Query().Get("lat"), r.URL.Query().Get("lon")
+    if latParam == "" && lonParam == "" {
+        return nil, nil
+    }
+    if latParam == "" || lonParam == "" {
+        return nil, errors.New("a 'lat' és 'lon' paramétert együtt kell megadni")
+    }
+    lat, latErr := strconv.ParseFloat(latParam, 64)
+    lon, lonErr := strconv.ParseFloat(lonParam, 64)
+    if latErr != nil || lonErr != nil {
+        return nil, errors.New("a 'lat' és 'lon' paraméternek számnak kell lennie")
+    }
+    near := &index.GeoPoint{Lat: lat, Lon: lon}
+    if err := near.Validate(); err != nil {
+        return nil, err
+    }
+    return near, nil
+}
+
+// sortMode az opcionális "sort" paraméter értéke, vagy ha nincs megadva, a beállított
+// alapértelmezett rendezés. Ismeretlen érték esetén hibát ad.
+func (s *Server) sortMode(r *http.Request) (string, error) {
+    mode := r.URL.Query().Get("sort")
+    if mode == "" {
+        return s.Options().DefaultSort, nil
+    }
+    if !suggest.IsSortMode(mode) {
+        return "", fmt.Errorf("a 'sort' értéke %s, %s vagy %s lehet", suggest.SortRelevance, suggest.SortAlphabetical, suggest.SortPopularity)
+    }
+    return mode, nil
+}
+
+// autocompleteHandler kezeli az /api/autocomplete végpontot.
+// Az opcionális "megye" paraméterrel a javaslatok egy megyére szűkíthetők, a "lat" és "lon"
+// paraméterekkel a megadott ponthoz közeli települések kerülnek előre. A "sort" paraméter a
+// rendezést választja ki (relevance, alphabetical, popularity), a "highlight" (offsets, html)
+// a javaslatok lekérdezésre illeszkedő szakaszait is visszaadja, a "details=1" pedig a
+// javaslatokat a települések KSH kódjával együtt objektumként is. A "format=geojson" GeoJSON
+// FeatureCollection-t ad a helyadattal rendelkező javaslatokkal. A "fields" paraméterrel a
+// keresés a SUGGEST_FIELDS mezőtábla több mezőjén fut (lásd fieldAutocomplete).
+func (s *Server) autocompleteHandler(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query().Get("q")
+    if query == "" {
+        writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, "Hiányzó 'q' paraméter")
+        return
+    }
+    format, err := s.suggestionResponseFormat(r, suggestionFormats...)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    if s.rejectQueryLength(w, r, query, format) {
+        return
+    }
+    near, err := parseNear(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    sortBy, err := s.sortMode(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    highlight, err := highlightMode(r.URL.Query().Get("highlight"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    if r.URL.Query().Has("fields") {
+        s.fieldAutocomplete(w, r, query, near, sortBy, highlight, format)
+        return
+    }
+    megye := r.URL.Query().Get("megye")
+    set, debugInfo, err := s.suggester.Suggest(r.Context(), suggest.Request{Kind: suggest.KindSettlement, Query: query, Megye: megye, Near: near, Sort: sortBy})
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a javaslatok lekérésekor")
+        slog.Error("Autocomplete error", "request_id", reqlog.RequestID(r.Context()), "query", query, "error", err)
+        return
+    }
+    s.writeSuggestions(w, r, suggest.KindSettlement, query, set, debugInfo, highlight, format)
+}
+
+// streetAutocompleteHandler kezeli az /api/autocomplete/street végpontot.
+// Az opcionális "telepules" paraméterrel a javaslatok egy településre szűkíthetők; a "lat",
+// "lon", "sort", "highlight", "details" és "format" paraméterek az /api/autocomplete végponthoz
+// hasonlóan működnek.
+func (s *Server) streetAutocompleteHandler(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query().Get("q")
+    if query == "" {
+        writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, "Hiányzó 'q' paraméter")
+        return
+    }
+    format, err := s.suggestionResponseFormat(r, suggestionFormats...)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    if s.rejectQueryLength(w, r, query, format) {
+        return
+    }
+    near, err := parseNear(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    sortBy, err := s.sortMode(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    highlight, err := highlightMode(r.URL.Query().Get("highlight"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    telepules := r.URL.Query().Get("telepules")
+    set, debugInfo, err := s.suggester.Suggest(r.Context(), suggest.Request{Kind: suggest.KindStreet, Query: query, Telepules: telepules, Near: near, Sort: sortBy})
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a javaslatok lekérésekor")
+        slog.Error("Street autocomplete error", "request_id", reqlog.RequestID(r.Context()), "query", query, "error", err)
+        return
+    }
+    s.writeSuggestions(w, r, suggest.KindStreet, query, set, debugInfo, highlight, format)
+}
+
+// addressAutocompleteHandler kezeli az /api/autocomplete/address végpontot; a "lat" és "lon"
+// paraméterekkel a megadott ponthoz közeli települések címei kerülnek előre, a "sort", a
+// "highlight", a "details" és a "format" az /api/autocomplete végponthoz hasonlóan működik.
+func (s *Server) addressAutocompleteHandler(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query().Get("q")
+    if query == "" {
+        writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, "Hiányzó 'q' paraméter")
+        return
+    }
+    format, err := s.suggestionResponseFormat(r, suggestionFormats...)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    if s.rejectQueryLength(w, r, query, format) {
+        return
+    }
+    near, err := parseNear(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    sortBy, err := s.sortMode(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    highlight, err := highlightMode(r.URL.Query().Get("highlight"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    set, debugInfo, err := s.suggester.Suggest(r.Context(), suggest.Request{Kind: suggest.KindAddress, Query: query, Near: near, Sort: sortBy})
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a javaslatok lekérésekor")
+        slog.Error("Address autocomplete error", "request_id", reqlog.RequestID(r.Context()), "query", query, "error", err)
+        return
+    }
+    s.writeSuggestions(w, r, suggest.KindAddress, query, set, debugInfo, highlight, format)
+}
+
+// cacheFlushHandler kezeli a POST /api/admin/cache/flush végpontot, amely kiüríti a javaslat-gyorsítótárat.
+func (s *Server) cacheFlushHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+        return
+    }
+    flusher, ok := s.suggester.(suggest.CacheFlusher)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    flushed := flusher.FlushCache()
+    slog.Info("Cache flush", "flushed", flushed)
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(map[string]int{"flushed": flushed}); err != nil {
+        slog.Error("Hiba a cache flush válasz kódolásakor", "error", err)
+    }
+}
+
+// materializeHandler kezeli az /api/admin/materialize végpontot.
+// GET: az utolsó előszámítás állapota. POST: új előszámítás indítása (lásd suggest.Engine.StartMaterialize).
+func (s *Server) materializeHandler(w http.ResponseWriter, r *http.Request) {
+    materializer, ok := s.suggester.(suggest.Materializer)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    var job *suggest.MaterializeJob
+    status := http.StatusOK
+    switch r.Method {
+    case http.MethodGet:
+        if job = materializer.CurrentMaterialize(); job == nil {
+            writeError(w, r, http.StatusNotFound, ErrCodeNotFound, "Még nem indult előszámítás")
+            return
+        }
+    case http.MethodPost:
+        var err error
+        if job, err = materializer.StartMaterialize(); errors.Is(err, suggest.ErrMaterializeInProgress) {
+            writeError(w, r, http.StatusConflict, ErrCodeConflict, err.Error())
+            return
+        }
+        status = http.StatusAccepted
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET és POST kérés engedélyezett")
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    if err := json.NewEncoder(w).Encode(job); err != nil {
+        slog.Error("Hiba a materialize válasz kódolásakor", "error", err)
+    }
+}
+
+// mappingCheckHandler kezeli az /api/checkMapping végpontot.
+func (s *Server) mappingCheckHandler(w http.ResponseWriter, r *http.Request) {
+    res, err := s.indexes.Check(r.Context())
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a mapping ellenőrzésekor")
+        slog.Error("Mapping check error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        return
+    }
+    if !s.debugRequested(r) {
+        res.Debug = ""
+    }
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(res); err != nil {
+        slog.Error("Hiba a mapping check válasz kódolásakor", "error", err)
+    }
+}
+
+// healthHandler kezeli a /healthz végpontot: 200-at ad, ha a háttérrendszer kérések
+// fogadására kész, különben (és amíg a cache előmelegítése fut) 503-at.
+func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
+    if s.warmingUp.Load() {
+        writeError(w, r, http.StatusServiceUnavailable, ErrCodeUnavailable, "A gyorsítótár előmelegítése folyamatban")
+        return
+    }
+    if err := s.suggester.Health(r.Context()); err != nil {
+        slog.Warn("Health check failed", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        writeError(w, r, http.StatusServiceUnavailable, ErrCodeUnavailable, "A keresési háttérrendszer nem elérhető")
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Cache-Control", "no-store")
+    if err := json.NewEncoder(w).Encode(map[string]string{"status": "ok"}); err != nil {
+        slog.Error("Hiba a health válasz kódolásakor", "error", err)
+    }
+}
@@ -0,0 +1,59 @@
+package httpapi
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strings"
+
+    "autocomplete/internal/reqlog"
+)
+
+// etagMatches jelzi, hogy az If-None-Match fejléc tartalmazza-e az etaget (gyenge összevetéssel).
+func etagMatches(ifNoneMatch, etag string) bool {
+    for _, candidate := range strings.Split(ifNoneMatch, ",") {
+        candidate = strings.TrimSpace(candidate)
+        if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+            return true
+        }
+    }
+    return false
+}
+
+// writeSuggestionResponse a format szerint kódolva küldi a javaslatokat (lásd encodeResponse);
+// a válasz az Accept fejléctől függ, ezért Vary: Accept fejlécet is küld. Ha a HTTPCacheEnabled
+// be van kapcsolva és a hívó szerint a válasz gyorsítótárazható (nem elavult és nincs benne debug szöveg),
+// Cache-Control max-age-et és a törzsből képzett ETaget is küld, egyező If-None-Match esetén
+// pedig 304-gyel, törzs nélkül válaszol. Egyébként Cache-Control: no-store. Engedélyezett JSONP
+// esetén a JSON törzs a "callback" hívásába csomagolva, JavaScriptként kerül ki (lásd wrapJSONP).
+func (s *Server) writeSuggestionResponse(w http.ResponseWriter, r *http.Request, response interface{}, format string, cacheable bool) {
+    body, contentType, err := encodeResponse(response, format)
+    if err != nil {
+        slog.Error("Hiba a válasz kódolásakor", "format", format, "error", err)
+        return
+    }
+    if wrapped, ok := s.wrapJSONP(r, body, format); ok {
+        body, contentType = wrapped, jsonpContentType
+    }
+    w.Header().Set("Content-Type", contentType)
+    w.Header().Add("Vary", "Accept")
+    opts := s.Options()
+    if !opts.HTTPCacheEnabled || !cacheable {
+        w.Header().Set("Cache-Control", "no-store")
+        w.Write(body)
+        return
+    }
+    sum := sha256.Sum256(body)
+    // Gyenge ETag, mert a tömörítő middleware a reprezentációt bájtszinten megváltoztathatja.
+    etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+    w.Header().Set("ETag", etag)
+    w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(opts.HTTPCacheMaxAge.Seconds())))
+    if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+        reqlog.Add(r.Context(), "not_modified", true)
+        w.WriteHeader(http.StatusNotModified)
+        return
+    }
+    w.Write(body)
+}
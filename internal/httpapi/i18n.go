@@ -0,0 +1,40 @@
+package httpapi
+
+import (
+    "context"
+    "net/http"
+
+    "autocomplete/internal/i18n"
+)
+
+type languageKey struct{}
+
+// withLanguage kiválasztja a válasz szövegeinek (hibaüzenetek, hint, debug információ) nyelvét:
+// a "lang" query paramétert (hu vagy en), ha támogatott, egyébként az Accept-Language fejléc
+// legjobb támogatott nyelvét, végül a DEFAULT_LANGUAGE beállítást. A nyelvet a kérés
+// kontextusába teszi (lásd localize), és Content-Language fejlécben jelzi.
+func (s *Server) withLanguage(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        lang := r.URL.Query().Get("lang")
+        if !i18n.IsSupported(lang) {
+            lang = i18n.Negotiate(r.Header.Get("Accept-Language"), s.Options().DefaultLanguage)
+        }
+        w.Header().Set("Content-Language", lang)
+        w.Header().Add("Vary", "Accept-Language")
+        next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), languageKey{}, lang)))
+    })
+}
+
+// requestLanguage a kérés withLanguage által választott nyelve; a middleware nélkül (pl. a
+// debug szerveren) magyar.
+func requestLanguage(ctx context.Context) string {
+    if lang, ok := ctx.Value(languageKey{}).(string); ok {
+        return lang
+    }
+    return i18n.Hungarian
+}
+
+// localize a kliensnek szánt magyar üzenetet a kérés nyelvére fordítja.
+func localize(ctx context.Context, message string) string {
+    return i18n.Translate(requestLanguage(ctx), message)
+}
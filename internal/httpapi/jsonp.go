@@ -0,0 +1,58 @@
+package httpapi
+
+import (
+    "bytes"
+    "errors"
+    "net/http"
+    "regexp"
+)
+
+// jsonpContentType a JSONP válaszok típusa.
+const jsonpContentType = "text/javascript; charset=utf-8"
+
+// jsonpCallbackPattern az elfogadott callback nevek: pontokkal elválasztott JS azonosítók
+// (pl. "cb", "app.onSuggest"), legfeljebb 64 karakter; más nem kerülhet a válaszba.
+var jsonpCallbackPattern = regexp.MustCompile(`^[A-Za-z_$][0-9A-Za-z_$]*(\.[A-Za-z_$][0-9A-Za-z_$]*)*$`)
+
+// validJSONPCallback jelzi, hogy a callback biztonságosan a válaszba írható-e.
+func validJSONPCallback(callback string) bool {
+    return len(callback) <= 64 && jsonpCallbackPattern.MatchString(callback)
+}
+
+// suggestionResponseFormat a javaslatvégpontok válaszformátuma (lásd responseFormat), amely a
+// "callback" (JSONP) paramétert is ellenőrzi: csak JSONP_ENABLED mellett, JSON formátummal és
+// érvényes névvel adható meg.
+func (s *Server) suggestionResponseFormat(r *http.Request, supported ...string) (string, error) {
+    format, err := responseFormat(r, supported...)
+    if err != nil {
+        return "", err
+    }
+    callback := r.URL.Query().Get("callback")
+    switch {
+    case callback == "":
+    case !s.widget.JSONP:
+        return "", errors.New("a 'callback' paraméter (JSONP) nincs engedélyezve")
+    case format != formatJSON:
+        return "", errors.New("a 'callback' paraméter csak JSON formátummal használható")
+    case !validJSONPCallback(callback):
+        return "", errors.New("a 'callback' értéke pontokkal elválasztott JavaScript azonosító lehet (legfeljebb 64 karakter)")
+    }
+    return format, nil
+}
+
+// wrapJSONP a JSON törzset a kérés "callback" függvényének hívásába csomagolja, ha a JSONP
+// engedélyezett és a callback érvényes; a "/**/" előtag a Content-Type szimatolás (Rosetta
+// Flash) elleni szokásos védelem. A második visszatérési érték jelzi, hogy történt-e csomagolás.
+func (s *Server) wrapJSONP(r *http.Request, body []byte, format string) ([]byte, bool) {
+    callback := r.URL.Query().Get("callback")
+    if callback == "" || !s.widget.JSONP || format != formatJSON || !validJSONPCallback(callback) {
+        return body, false
+    }
+    wrapped := make([]byte, 0, len(body)+len(callback)+8)
+    wrapped = append(wrapped, "/**/"...)
+    wrapped = append(wrapped, callback...)
+    wrapped = append(wrapped, '(')
+    wrapped = append(wrapped, bytes.TrimRight(body, "\n")...)
+    wrapped = append(wrapped, ");\n"...)
+    return wrapped, true
+}
@@ -0,0 +1,82 @@
+package httpapi
+
+import (
+    "bufio"
+    "log/slog"
+    "net"
+    "net/http"
+    "time"
+
+    "autocomplete/internal/reqlog"
+)
+
+// statusRecorder megjegyzi a válasz státuszkódját és méretét a naplózáshoz.
+type statusRecorder struct {
+    http.ResponseWriter
+    status int
+    bytes  int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+    if s.status == 0 {
+        s.status = status
+    }
+    s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+    if s.status == 0 {
+        s.status = http.StatusOK
+    }
+    n, err := s.ResponseWriter.Write(b)
+    s.bytes += n
+    return n, err
+}
+
+// Flush továbbítja a Flush hívást, hogy a folyamatos (NDJSON) válaszok működjenek.
+func (s *statusRecorder) Flush() {
+    if f, ok := s.ResponseWriter.(http.Flusher); ok {
+        f.Flush()
+    }
+}
+
+// Hijack a WebSocket kapcsolatokhoz adja át a nyers kapcsolatot; a naplóban 101-es státusz szerepel.
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+    s.status = http.StatusSwitchingProtocols
+    return http.NewResponseController(s.ResponseWriter).Hijack()
+}
+
+// Unwrap lehetővé teszi, hogy a http.ResponseController elérje az eredeti ResponseWriter-t.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+    return s.ResponseWriter
+}
+
+// logRequests kérésenként egy strukturált naplósort ír a request ID-val, státusszal,
+// futásidővel és a kezelők, illetve a javaslatmotor által reqlog.Add-dal hozzáadott mezőkkel.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        start := time.Now()
+        ctx, fields := reqlog.WithFields(r.Context())
+        rec := &statusRecorder{ResponseWriter: w}
+        next.ServeHTTP(rec, r.WithContext(ctx))
+
+        if rec.status == 0 {
+            rec.status = http.StatusOK
+        }
+        args := []any{
+            "request_id", reqlog.RequestID(r.Context()),
+            "method", r.Method,
+            "path", r.URL.Path,
+            "status", rec.status,
+            "bytes", rec.bytes,
+            "latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+            "client_ip", s.clientIP(r),
+        }
+        args = append(args, fields.Args()...)
+        level := slog.LevelInfo
+        if rec.status >= 500 {
+            level = slog.LevelError
+        }
+        slog.Log(r.Context(), level, "request", args...)
+    })
+}
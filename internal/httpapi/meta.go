@@ -0,0 +1,66 @@
+package httpapi
+
+import (
+    "context"
+    "net/http"
+    "time"
+
+    "autocomplete/internal/suggest"
+)
+
+// ResponseMeta a javaslatválaszok "meta" blokkja (RESPONSE_META), amelyből a kliensek a
+// szervernapló nélkül is láthatják a kérés késleltetését: a lekérdezés és normalizált alakja, a
+// kezelő teljes futásideje (TookMs) és ebből az OpenSearch által mért idő (UpstreamTookMs), a
+// gyorsítótár állapota (hit, miss, stale vagy revalidated) és az utolsó lekérdezésre illeszkedő
+// dokumentumok száma (TotalCandidates). A Truncated jelzi, hogy a válasz a MAX_RESPONSE_BYTES
+// korlát miatt csonkolva van (lásd capResponse); ilyenkor a blokk kikapcsolt RESPONSE_META mellett
+// is megjelenik, a Query és a Truncated mezővel. A háttérkérés nélkül megválaszolt kérésnél (cache
+// találat, túl rövid lekérdezés, memóriabeli háttérrendszer) a nem mért mezők hiányoznak.
+type ResponseMeta struct {
+    Query           string  `json:"query"`
+    NormalizedQuery string  `json:"normalizedQuery,omitempty"`
+    TookMs          float64 `json:"tookMs,omitempty"`
+    UpstreamTookMs  *int64  `json:"upstreamTookMs,omitempty"`
+    Cache           string  `json:"cache,omitempty"`
+    TotalCandidates *int64  `json:"totalCandidates,omitempty"`
+    Truncated       bool    `json:"truncated,omitempty"`
+}
+
+type metaStateKey struct{}
+
+// metaState a withResponseMeta által a kérés kontextusába tett adatok.
+type metaState struct {
+    start    time.Time
+    recorder *suggest.MetaRecorder
+}
+
+// withResponseMeta a RESPONSE_META bekapcsolásakor megjegyzi a kérés kezdetét, és a
+// javaslatmotor Meta gyűjtőjét a kérés kontextusához rendeli (lásd responseMeta).
+func (s *Server) withResponseMeta(next http.HandlerFunc) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if !s.Options().ResponseMeta {
+            next(w, r)
+            return
+        }
+        ctx, recorder := suggest.WithMeta(r.Context())
+        ctx = context.WithValue(ctx, metaStateKey{}, metaState{start: time.Now(), recorder: recorder})
+        next(w, r.WithContext(ctx))
+    }
+}
+
+// responseMeta a kérés meta blokkja; nil, ha a RESPONSE_META ki van kapcsolva.
+func responseMeta(r *http.Request, query string) *ResponseMeta {
+    state, ok := r.Context().Value(metaStateKey{}).(metaState)
+    if !ok {
+        return nil
+    }
+    m := state.recorder.Meta()
+    return &ResponseMeta{
+        Query:           query,
+        NormalizedQuery: m.NormalizedQuery,
+        TookMs:          float64(time.Since(state.start).Microseconds()) / 1000,
+        UpstreamTookMs:  m.UpstreamTookMs,
+        Cache:           m.Cache,
+        TotalCandidates: m.TotalCandidates,
+    }
+}
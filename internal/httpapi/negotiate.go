@@ -0,0 +1,128 @@
+package httpapi
+
+import (
+    "bytes"
+    "encoding/csv"
+    "encoding/json"
+    "fmt"
+    "mime"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+
+    "autocomplete/internal/msgpack"
+)
+
+// A válaszformátumok. A "format" paraméterrel vagy az Accept fejléccel választhatók (a paraméter
+// az erősebb); alapértelmezés a JSON. A geojson a helyadattal rendelkező találatok GeoJSON
+// FeatureCollection-je (RFC 7946), amely közvetlenül térképre (Leaflet, MapLibre) tehető, a
+// msgpack a JSON válasz tömör bináris (MessagePack) alakja a beágyazott klienseknek.
+const (
+    formatJSON    = "json"
+    formatGeoJSON = "geojson"
+    formatCSV     = "csv"
+    formatMsgPack = "msgpack"
+)
+
+// suggestionFormats a javaslatvégpontok (/api/autocomplete, .../street, .../address) formátumai.
+var suggestionFormats = []string{formatJSON, formatGeoJSON, formatCSV, formatMsgPack}
+
+// formatContentTypes a formátumok tartalomtípusa a válaszban.
+var formatContentTypes = map[string]string{
+    formatJSON:    "application/json",
+    formatGeoJSON: geoJSONContentType,
+    formatCSV:     "text/csv; charset=utf-8",
+    formatMsgPack: msgpack.ContentType,
+}
+
+// mediaTypeFormats az Accept fejlécben elfogadott médiatípusok formátuma.
+var mediaTypeFormats = map[string]string{
+    "application/json":        formatJSON,
+    "application/geo+json":    formatGeoJSON,
+    "text/csv":                formatCSV,
+    "application/msgpack":     formatMsgPack,
+    "application/x-msgpack":   formatMsgPack,
+    "application/vnd.msgpack": formatMsgPack,
+}
+
+// csvResponse a CSV-ként is kiírható válaszok: a CSV sorai, az első a fejléc.
+type csvResponse interface {
+    csvRecords() [][]string
+}
+
+// responseFormat a válasz formátuma a supported formátumok közül: a "format" paraméter, ha meg
+// van adva (ismeretlen vagy nem támogatott értékre hibát ad), egyébként az Accept fejléc
+// legnagyobb q értékű támogatott médiatípusa; ha egyik sem az, formatJSON.
+func responseFormat(r *http.Request, supported ...string) (string, error) {
+    if format := r.URL.Query().Get("format"); format != "" {
+        for _, f := range supported {
+            if f == format {
+                return format, nil
+            }
+        }
+        return "", fmt.Errorf("a 'format' értéke %s lehet", strings.Join(supported, ", "))
+    }
+    return negotiateFormat(r.Header.Get("Accept"), supported), nil
+}
+
+// negotiateFormat az Accept fejléc médiatípusait q érték szerint (azonos q-nál a fejléc
+// sorrendjében) veszi sorra, és az első támogatott formátumot adja; a "*/*" és a nem támogatott
+// típusok a JSON-t választják.
+func negotiateFormat(accept string, supported []string) string {
+    type mediaRange struct {
+        format string
+        q      float64
+    }
+    var ranges []mediaRange
+    for _, part := range strings.Split(accept, ",") {
+        mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+        if err != nil {
+            continue
+        }
+        q := 1.0
+        if v, ok := params["q"]; ok {
+            if q, err = strconv.ParseFloat(v, 64); err != nil || q <= 0 {
+                continue
+            }
+        }
+        format, ok := mediaTypeFormats[mediaType]
+        if !ok {
+            continue
+        }
+        ranges = append(ranges, mediaRange{format: format, q: q})
+    }
+    sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+    for _, m := range ranges {
+        for _, f := range supported {
+            if f == m.format {
+                return f
+            }
+        }
+    }
+    return formatJSON
+}
+
+// encodeResponse a választ a formátum szerint kódolja, és a tartalomtípusát is visszaadja.
+// CSV-t csak a csvResponse típusok adnak; a többi válasz JSON-ként kerül ki.
+func encodeResponse(response interface{}, format string) ([]byte, string, error) {
+    switch format {
+    case formatMsgPack:
+        body, err := msgpack.Marshal(response)
+        return body, formatContentTypes[formatMsgPack], err
+    case formatCSV:
+        if records, ok := response.(csvResponse); ok {
+            var body bytes.Buffer
+            writer := csv.NewWriter(&body)
+            writer.WriteAll(records.csvRecords())
+            return body.Bytes(), formatContentTypes[formatCSV], writer.Error()
+        }
+    case formatGeoJSON:
+        if _, ok := response.(FeatureCollection); ok {
+            body, err := json.Marshal(response)
+            return append(body, '\n'), formatContentTypes[formatGeoJSON], err
+        }
+    }
+    body, err := json.Marshal(response)
+    return append(body, '\n'), formatContentTypes[formatJSON], err
+}
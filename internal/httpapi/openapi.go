@@ -0,0 +1,482 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "reflect"
+    "strconv"
+    "strings"
+    "time"
+
+    "autocomplete/internal/analytics"
+    "autocomplete/internal/experiment"
+    "autocomplete/internal/index"
+    "autocomplete/internal/resync"
+    "autocomplete/internal/suggest"
+)
+
+// apiParam egy query vagy path paraméter leírása az OpenAPI dokumentumhoz.
+type apiParam struct {
+    Name        string
+    In          string // "query", "path" vagy "header"
+    Required    bool
+    Type        string // "string", "integer", "number" vagy "boolean"
+    Description string
+}
+
+// apiOperation egy végpont egy metódusának leírása. A Response a 2xx válasz Go típusa, amelyből
+// a séma reflectionnel készül, így a dokumentum a struktúrákkal együtt változik; nil esetén a
+// válasznak nincs törzse.
+type apiOperation struct {
+    Method      string
+    Path        string
+    Summary     string
+    Tags        []string
+    Params      []apiParam
+    RequestBody map[string]interface{}
+    Status      int
+    Response    interface{}
+    Admin       bool
+    Errors      []int
+}
+
+var (
+    qParam             = apiParam{Name: "q", In: "query", Required: true, Type: "string", Description: "A keresett prefix; MIN_QUERY_LEN-nél rövidebbre üres lista hint-tel, MAX_QUERY_LEN-nél hosszabbra 400"}
+    debugParam         = apiParam{Name: "debug", In: "query", Type: "string", Description: "1 esetén debug szöveg a válaszban (ha DEBUG_ENABLED)"}
+    latParam           = apiParam{Name: "lat", In: "query", Type: "number", Description: "Szélesség; a lon-nal együtt a közeli települések előre kerülnek"}
+    lonParam           = apiParam{Name: "lon", In: "query", Type: "number", Description: "Hosszúság; a lat-tal együtt adandó meg"}
+    sortParam          = apiParam{Name: "sort", In: "query", Type: "string", Description: "Rendezés: relevance, alphabetical vagy popularity (alapértelmezés: DEFAULT_SORT)"}
+    highlightParam     = apiParam{Name: "highlight", In: "query", Type: "string", Description: "Kiemelés: offsets (illeszkedő szakaszok rune indexei) vagy html (<em> elemekkel kiemelt javaslat is)"}
+    formatParam        = apiParam{Name: "format", In: "query", Type: "string", Description: "json (alapértelmezés), geojson (FeatureCollection a helyadattal rendelkező találatokkal), csv vagy msgpack; megadás nélkül az Accept fejléc dönt"}
+    callbackParam      = apiParam{Name: "callback", In: "query", Type: "string", Description: "JSONP callback neve (csak JSONP_ENABLED mellett, JSON formátummal)"}
+    listFormatParam    = apiParam{Name: "format", In: "query", Type: "string", Description: "json (alapértelmezés), csv vagy msgpack; megadás nélkül az Accept fejléc dönt"}
+    geocodeFormatParam = apiParam{Name: "format", In: "query", Type: "string", Description: "json (alapértelmezés), geojson vagy msgpack; megadás nélkül az Accept fejléc dönt"}
+    detailsParam       = apiParam{Name: "details", In: "query", Type: "string", Description: "1 esetén a javaslatok objektumként is (items), településeknél a KSH kóddal, súlyozott indexnél a súllyal (weight)"}
+)
+
+// documentParams az /api/admin/documents/{id} végpont paraméterei.
+var documentParams = []apiParam{
+    {Name: "id", In: "path", Required: true, Type: "string", Description: "A címrekord azonosítója (_id)"},
+    {Name: "refresh", In: "query", Type: "string", Description: "1 esetén a válasz megvárja, hogy a változás a keresésekben is látsszon"},
+}
+
+// languageParams minden végponton választhatják a hibaüzenetek, a hint és a debug szöveg nyelvét.
+var languageParams = []apiParam{
+    {Name: "lang", In: "query", Type: "string", Description: "A hibaüzenetek és a debug szöveg nyelve: hu vagy en (erősebb az Accept-Language fejlécnél)"},
+    {Name: "Accept-Language", In: "header", Type: "string", Description: "A válasz szövegeinek nyelve (hu vagy en); megadás nélkül DEFAULT_LANGUAGE"},
+}
+
+// snapshotNameParam a /api/admin/snapshots/{name} végpontok útvonal paramétere.
+var snapshotNameParam = apiParam{Name: "name", In: "path", Required: true, Type: "string", Description: "A pillanatkép neve"}
+
+// analyticsParams az /api/admin/analytics végpontok közös paraméterei.
+var analyticsParams = []apiParam{
+    {Name: "window", In: "query", Type: "string", Description: "Időablak (pl. 1h; alapértelmezés és legnagyobb érték: ANALYTICS_RETENTION)"},
+    {Name: "kind", In: "query", Type: "string", Description: "Szűkítés javaslatfajtára: settlement, street, address vagy zip"},
+    {Name: "variant", In: "query", Type: "string", Description: "Szűkítés a futó A/B kísérlet egy változatára (EXPERIMENT_VARIANTS)"},
+    {Name: "limit", In: "query", Type: "integer", Description: "Visszaadott lekérdezések száma (1–1000, alapértelmezés: 50)"},
+}
+
+// apiOperations a nyilvános és az admin végpontok listája; új végpontnál ezt is bővíteni kell.
+func apiOperations() []apiOperation {
+    suggestErrors := []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable}
+    adminErrors := []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusBadGateway}
+    documentRequestBody := map[string]interface{}{"required": true, "content": map[string]interface{}{
+        "application/json": map[string]interface{}{"schema": schemaRef(index.AddressDocument{})},
+    }}
+    return []apiOperation{
+        {Method: "get", Path: "/api/autocomplete", Summary: "Településnév javaslatok", Tags: []string{"suggest"},
+            Params: []apiParam{qParam, {Name: "megye", In: "query", Type: "string", Description: "Szűrés megyére"}, latParam, lonParam, sortParam, highlightParam, detailsParam, formatParam, callbackParam, debugParam,
+                {Name: "fields", In: "query", Type: "string", Description: "Vesszővel elválasztott mezőnevek (SUGGEST_FIELDS, pl. telepules,kozter_nev): a keresés mindegyiken fut, az items a javaslatok forrásmezőjét (field) is tartalmazza; a \"telepules\" paraméter ilyenkor településre szűkít"},
+                {Name: "telepules", In: "query", Type: "string", Description: "Szűrés településre (csak a fields paraméterrel)"}},
+            Response: SearchResult{}, Errors: append([]int{http.StatusNotImplemented}, suggestErrors...)},
+        {Method: "get", Path: "/api/autocomplete/street", Summary: "Közterületnév javaslatok", Tags: []string{"suggest"},
+            Params:   []apiParam{qParam, {Name: "telepules", In: "query", Type: "string", Description: "Szűrés településre"}, latParam, lonParam, sortParam, highlightParam, detailsParam, formatParam, callbackParam, debugParam},
+            Response: SearchResult{}, Errors: suggestErrors},
+        {Method: "get", Path: "/api/autocomplete/zip", Summary: "Irányítószám javaslatok", Tags: []string{"suggest"},
+            Params:   []apiParam{{Name: "q", In: "query", Required: true, Type: "string", Description: "Legfeljebb 4 számjegy"}, listFormatParam, callbackParam, debugParam},
+            Response: SearchResult{}, Errors: suggestErrors},
+        {Method: "get", Path: "/api/autocomplete/address", Summary: "Teljes cím javaslatok", Tags: []string{"suggest"},
+            Params: []apiParam{qParam, latParam, lonParam, sortParam, highlightParam, detailsParam, formatParam, callbackParam, debugParam}, Response: SearchResult{}, Errors: suggestErrors},
+        {Method: "get", Path: "/api/autocomplete/ws", Summary: "WebSocket javaslatfolyam: a kliens wsRequest üzeneteket küld, a szerver wsResponse üzenetekkel válaszol", Tags: []string{"suggest"},
+            Status: http.StatusSwitchingProtocols, Response: wsResponse{}, Errors: []int{http.StatusBadRequest, http.StatusTooManyRequests}},
+        {Method: "get", Path: "/api/autocomplete/stream", Summary: "Település- és közterület-javaslatok Server-Sent Events folyamként (settlement, street, error, done események; az adat SearchResult)", Tags: []string{"suggest"},
+            Params: []apiParam{qParam, {Name: "megye", In: "query", Type: "string", Description: "Településjavaslatok szűrése megyére"},
+                {Name: "telepules", In: "query", Type: "string", Description: "Közterület-javaslatok szűrése településre"}, sortParam, highlightParam},
+            Response: SearchResult{}, Errors: []int{http.StatusBadRequest, http.StatusTooManyRequests}},
+        {Method: "get", Path: "/api/autocomplete/grouped", Summary: "Település-, közterület- és irányítószám-javaslatok fajtánként csoportosítva", Tags: []string{"suggest"},
+            Params: []apiParam{qParam, {Name: "megye", In: "query", Type: "string", Description: "Településjavaslatok szűrése megyére"},
+                {Name: "telepules", In: "query", Type: "string", Description: "Közterület-javaslatok szűrése településre"},
+                {Name: "settlementLimit", In: "query", Type: "integer", Description: "Településjavaslatok legnagyobb száma (0–50, alapértelmezés: 5; 0 kihagyja)"},
+                {Name: "streetLimit", In: "query", Type: "integer", Description: "Közterület-javaslatok legnagyobb száma (0–50, alapértelmezés: 5; 0 kihagyja)"},
+                {Name: "zipLimit", In: "query", Type: "integer", Description: "Irányítószám-javaslatok legnagyobb száma (0–50, alapértelmezés: 5; 0 kihagyja)"}, sortParam, listFormatParam, callbackParam},
+            Response: GroupedResult{}, Errors: suggestErrors},
+        {Method: "get", Path: "/api/search", Summary: "Keresés a teljes címjegyzékben: település-, közterület- és irányítószám-javaslatok egy rangsorolt listában, forrásukkal", Tags: []string{"suggest"},
+            Params: []apiParam{qParam, {Name: "megye", In: "query", Type: "string", Description: "Településjavaslatok szűrése megyére"},
+                {Name: "telepules", In: "query", Type: "string", Description: "Közterület-javaslatok szűrése településre"},
+                {Name: "limit", In: "query", Type: "integer", Description: "Találatok legnagyobb száma (1–50, alapértelmezés: 10)"}, listFormatParam, callbackParam},
+            Response: SectionedResult{}, Errors: append([]int{http.StatusMethodNotAllowed}, suggestErrors...)},
+        {Method: "get", Path: "/api/zip/{code}", Summary: "Irányítószámhoz tartozó települések", Tags: []string{"suggest"},
+            Params:   []apiParam{{Name: "code", In: "path", Required: true, Type: "string", Description: "4 jegyű irányítószám"}, debugParam},
+            Response: ZipLookupResult{}, Errors: append([]int{http.StatusNotFound, http.StatusNotImplemented}, suggestErrors...)},
+        {Method: "get", Path: "/api/settlements", Summary: "A települések teljes listája lapozva, opcionálisan egy megyére szűkítve", Tags: []string{"suggest"},
+            Params: []apiParam{{Name: "megye", In: "query", Type: "string", Description: "Szűrés megyére"},
+                {Name: "limit", In: "query", Type: "integer", Description: "A lap mérete (1–1000, alapértelmezés: 100)"},
+                {Name: "after", In: "query", Type: "string", Description: "Az előző válasz next kurzora (átlátszatlan)"}, debugParam},
+            Response: SettlementListResult{}, Errors: append([]int{http.StatusBadRequest, http.StatusNotImplemented}, suggestErrors...)},
+        {Method: "get", Path: "/api/settlements/{name}/stats", Summary: "Település közterületeinek és irányítószámainak száma", Tags: []string{"suggest"},
+            Params:   []apiParam{{Name: "name", In: "path", Required: true, Type: "string", Description: "Településnév (kis-nagybetű független)"}, debugParam},
+            Response: SettlementStatsResult{}, Errors: append([]int{http.StatusNotFound, http.StatusNotImplemented}, suggestErrors...)},
+        {Method: "get", Path: "/api/settlements/{name}/streets", Summary: "Egy település közterületei lapozva, opcionálisan előtagra szűrve", Tags: []string{"suggest"},
+            Params: []apiParam{{Name: "name", In: "path", Required: true, Type: "string", Description: "Településnév (kis-nagybetű független)"},
+                {Name: "prefix", In: "query", Type: "string", Description: "Csak az ezzel kezdődő közterületek (kis-nagybetű független)"},
+                {Name: "limit", In: "query", Type: "integer", Description: "A lap mérete (1–1000, alapértelmezés: 100)"},
+                {Name: "after", In: "query", Type: "string", Description: "Az előző válasz next kurzora (átlátszatlan)"}, debugParam},
+            Response: StreetListResult{}, Errors: append([]int{http.StatusBadRequest, http.StatusNotFound, http.StatusNotImplemented}, suggestErrors...)},
+        {Method: "get", Path: "/api/suggest/spelling", Summary: "Helyesírási javaslatok településnévre", Tags: []string{"suggest"},
+            Params: []apiParam{qParam, debugParam}, Response: SpellingResult{}, Errors: append([]int{http.StatusNotImplemented}, suggestErrors...)},
+        {Method: "get", Path: "/api/checkMapping", Summary: "Index mapping ellenőrzése", Tags: []string{"ops"},
+            Params: []apiParam{debugParam}, Response: index.MappingCheckResult{}, Errors: []int{http.StatusInternalServerError, http.StatusServiceUnavailable}},
+        {Method: "post", Path: "/api/select", Summary: "Kiválasztott javaslat rögzítése a népszerűség szerinti rangsoroláshoz", Tags: []string{"suggest"},
+            RequestBody: map[string]interface{}{"required": true, "content": map[string]interface{}{
+                "application/json": map[string]interface{}{"schema": schemaRef(suggest.Selection{})},
+            }},
+            Status: http.StatusNoContent, Errors: []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusNotImplemented, http.StatusServiceUnavailable}},
+        {Method: "get", Path: "/healthz", Summary: "A keresési háttérrendszer állapota", Tags: []string{"ops"},
+            Response: map[string]string{}, Errors: []int{http.StatusServiceUnavailable}},
+        {Method: "post", Path: "/api/validate/batch", Summary: "Címek kötegelt ellenőrzése (kis-nagybetű független, kanonikus alakkal)", Tags: []string{"validate"},
+            RequestBody: map[string]interface{}{"required": true, "content": map[string]interface{}{
+                "application/json": map[string]interface{}{"schema": map[string]interface{}{"type": "array", "items": schemaRef(suggest.AddressInput{})}},
+            }},
+            Response: BatchValidationResult{}, Errors: append([]int{http.StatusNotImplemented}, suggestErrors...)},
+        {Method: "get", Path: "/api/validate/address", Summary: "Házszámos cím ellenőrzése a közterület-szakaszok házszámtartományai alapján (páros/páratlan oldallal), az irányítószámmal", Tags: []string{"validate"},
+            Params: []apiParam{
+                {Name: "telepules", In: "query", Type: "string", Required: true, Description: "Település (pontos egyezés)"},
+                {Name: "kozter_nev", In: "query", Type: "string", Required: true, Description: "Közterület (pontos egyezés)"},
+                {Name: "hazszam", In: "query", Type: "string", Required: true, Description: "Házszám, betűjellel vagy albetéttel is (pl. 12/A)"},
+            },
+            Response: suggest.HouseNumberValidation{}, Errors: append([]int{http.StatusNotImplemented}, suggestErrors...)},
+        {Method: "get", Path: "/api/geocode", Summary: "Ellenőrzött cím koordinátái (közterület vagy település pontossággal)", Tags: []string{"validate"},
+            Params: []apiParam{
+                {Name: "telepules", In: "query", Type: "string", Required: true, Description: "Település (pontos egyezés)"},
+                {Name: "kozter_nev", In: "query", Type: "string", Description: "Közterület (pontos egyezés)"},
+                {Name: "irsz", In: "query", Type: "string", Description: "Irányítószám"},
+                geocodeFormatParam,
+            },
+            Response: suggest.Geocode{}, Errors: append([]int{http.StatusNotImplemented}, suggestErrors...)},
+        {Method: "get", Path: "/api/reverse-geocode", Summary: "A ponthoz legközelebbi település és közterület", Tags: []string{"validate"},
+            Params: []apiParam{
+                {Name: "lat", In: "query", Type: "number", Required: true, Description: "Szélesség (WGS84)"},
+                {Name: "lon", In: "query", Type: "number", Required: true, Description: "Hosszúság (WGS84)"},
+                geocodeFormatParam,
+            },
+            Response: suggest.ReverseGeocode{}, Errors: append([]int{http.StatusNotImplemented}, suggestErrors...)},
+        {Method: "post", Path: "/api/parse", Summary: "Szabad szöveges címsor felbontása irányítószámra, településre, közterületre, jellegre és házszámra, részenkénti megbízhatósággal", Tags: []string{"validate"},
+            RequestBody: map[string]interface{}{"required": true, "content": map[string]interface{}{
+                "application/json": map[string]interface{}{"schema": schemaRef(ParseRequest{})},
+            }},
+            Response: suggest.ParsedAddress{}, Errors: suggestErrors},
+        {Method: "post", Path: "/graphql", Summary: "GraphQL lekérdezés (települések, közterületek, címellenőrzés egy kérésben)", Tags: []string{"graphql"},
+            RequestBody: map[string]interface{}{"required": true, "content": map[string]interface{}{
+                "application/json": map[string]interface{}{"schema": schemaRef(graphQLRequest{})},
+            }},
+            Response: map[string]interface{}{}, Errors: []int{http.StatusBadRequest, http.StatusTooManyRequests}},
+
+        {Method: "post", Path: "/api/admin/bulk", Summary: "Címrekordok tömeges betöltése (NDJSON vagy JSON tömb)", Tags: []string{"admin"}, Admin: true,
+            Params: []apiParam{{Name: "index", In: "query", Type: "string", Description: "Újraindexelés célindexe"}},
+            RequestBody: map[string]interface{}{"required": true, "content": map[string]interface{}{
+                "application/x-ndjson": map[string]interface{}{"schema": schemaRef(index.AddressDocument{})},
+                "application/json":     map[string]interface{}{"schema": map[string]interface{}{"type": "array", "items": schemaRef(index.AddressDocument{})}},
+            }},
+            Response: index.BulkSummary{}, Errors: adminErrors},
+        {Method: "post", Path: "/api/admin/import/csv", Summary: "CSV import", Tags: []string{"admin"}, Admin: true,
+            Params: []apiParam{
+                {Name: "delimiter", In: "query", Type: "string", Description: "Mezőelválasztó (alapértelmezés: ,)"},
+                {Name: "mapping", In: "query", Type: "string", Description: "fejléc=mező,... leképezés"},
+                {Name: "dryRun", In: "query", Type: "string", Description: "1 esetén csak ellenőrzés"},
+                {Name: "progress", In: "query", Type: "string", Description: "1 esetén NDJSON előrehaladási sorok"},
+                {Name: "index", In: "query", Type: "string", Description: "Újraindexelés célindexe"},
+            },
+            RequestBody: map[string]interface{}{"required": true, "content": map[string]interface{}{
+                "multipart/form-data": map[string]interface{}{"schema": map[string]interface{}{
+                    "type":       "object",
+                    "properties": map[string]interface{}{"file": map[string]interface{}{"type": "string", "format": "binary"}},
+                }},
+            }},
+            Response: index.BulkSummary{}, Errors: adminErrors},
+        {Method: "post", Path: "/api/admin/seed", Summary: "Index létrehozása és feltöltése a beépített mintaadattal", Tags: []string{"admin"}, Admin: true,
+            Response: index.SeedSummary{}, Errors: []int{http.StatusUnauthorized, http.StatusBadGateway, http.StatusNotImplemented}},
+        {Method: "post", Path: "/api/admin/weights/address-count", Summary: "A települések címszámának beírása a dokumentumok súlyába (WEIGHT_BOOST)", Tags: []string{"admin"}, Admin: true,
+            Response: index.WeightSummary{}, Errors: []int{http.StatusUnauthorized, http.StatusBadGateway, http.StatusNotImplemented}},
+        {Method: "post", Path: "/api/admin/documents/{id}", Summary: "Címrekord létrehozása", Tags: []string{"admin"}, Admin: true,
+            Params: documentParams, RequestBody: documentRequestBody,
+            Status: http.StatusCreated, Response: index.DocumentResult{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusBadGateway}},
+        {Method: "put", Path: "/api/admin/documents/{id}", Summary: "Címrekord létrehozása vagy felülírása", Tags: []string{"admin"}, Admin: true,
+            Params: documentParams, RequestBody: documentRequestBody,
+            Response: index.DocumentResult{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusBadGateway}},
+        {Method: "delete", Path: "/api/admin/documents/{id}", Summary: "Címrekord törlése", Tags: []string{"admin"}, Admin: true,
+            Params:   documentParams,
+            Response: index.DocumentResult{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusBadGateway}},
+        {Method: "post", Path: "/api/admin/updates", Summary: "A címregiszter változásfolyamának aláírt kötege (add/update/delete)", Tags: []string{"admin"}, Admin: true,
+            Params: []apiParam{{Name: SignatureHeader, In: "header", Required: true, Type: "string", Description: "sha256=<a törzs HMAC-SHA256 értéke hexában, UPDATES_SECRET kulccsal>"}},
+            RequestBody: map[string]interface{}{"required": true, "content": map[string]interface{}{
+                "application/json": map[string]interface{}{"schema": schemaRef(index.UpdateBatch{})},
+            }},
+            Response: index.BulkSummary{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusBadGateway, http.StatusNotImplemented}},
+        {Method: "post", Path: "/api/admin/cache/flush", Summary: "Javaslat-gyorsítótár ürítése", Tags: []string{"admin"}, Admin: true,
+            Response: map[string]int{}, Errors: []int{http.StatusUnauthorized}},
+        {Method: "get", Path: "/api/admin/materialize", Summary: "Az utolsó javaslat-előszámítás állapota", Tags: []string{"admin"}, Admin: true,
+            Response: suggest.MaterializeJob{}, Errors: []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusNotImplemented}},
+        {Method: "post", Path: "/api/admin/materialize", Summary: "Rövid prefixek javaslatainak előszámítása a lookup indexbe", Tags: []string{"admin"}, Admin: true,
+            Status: http.StatusAccepted, Response: suggest.MaterializeJob{}, Errors: []int{http.StatusUnauthorized, http.StatusConflict, http.StatusNotImplemented}},
+        {Method: "get", Path: "/api/admin/export", Summary: "Az index egyedi település- (és közterület-) listájának exportja", Tags: []string{"admin"}, Admin: true,
+            Params: []apiParam{
+                {Name: "format", In: "query", Type: "string", Description: "csv (alapértelmezés) vagy ndjson"},
+                {Name: "streets", In: "query", Type: "string", Description: "1 esetén település–közterület párok"},
+            },
+            Response: suggest.ExportRow{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError, http.StatusNotImplemented}},
+        {Method: "get", Path: "/api/admin/quality", Summary: "Adatminőségi jelentés (duplikált települések, hiányzó irányítószámok, gyanús karakterek, üres közterületnevek)", Tags: []string{"admin"}, Admin: true,
+            Params:   []apiParam{{Name: "samples", In: "query", Type: "integer", Description: "Minta rekordok száma hibatípusonként (alapértelmezés 10, legfeljebb 100)"}},
+            Response: index.QualityReport{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError, http.StatusNotImplemented}},
+        {Method: "get", Path: "/api/admin/reindex", Summary: "Az utolsó újraindexelés állapota", Tags: []string{"admin"}, Admin: true,
+            Response: index.ReindexJob{}, Errors: []int{http.StatusUnauthorized, http.StatusNotFound}},
+        {Method: "post", Path: "/api/admin/reindex", Summary: "Alias-alapú újraindexelés indítása", Tags: []string{"admin"}, Admin: true,
+            Params: []apiParam{
+                {Name: "mode", In: "query", Type: "string", Description: "reindex (alapértelmezés) vagy import"},
+                {Name: "deleteOld", In: "query", Type: "string", Description: "1 esetén a régi index törlése a váltás után"},
+            },
+            Status: http.StatusAccepted, Response: index.ReindexJob{}, Errors: []int{http.StatusUnauthorized, http.StatusConflict, http.StatusBadGateway}},
+        {Method: "post", Path: "/api/admin/reindex/swap", Summary: "Import módú újraindexelés befejezése (alias váltás)", Tags: []string{"admin"}, Admin: true,
+            Response: index.ReindexJob{}, Errors: []int{http.StatusUnauthorized, http.StatusConflict, http.StatusInternalServerError}},
+        {Method: "get", Path: "/api/admin/resync", Summary: "Az utolsó teljes újraszinkronizálás állapota és a következő ütemezett futás", Tags: []string{"admin"}, Admin: true,
+            Response: resync.Status{}, Errors: []int{http.StatusUnauthorized, http.StatusNotImplemented}},
+        {Method: "post", Path: "/api/admin/resync", Summary: "Teljes újraszinkronizálás indítása a RESYNC_SOURCE forrásból", Tags: []string{"admin"}, Admin: true,
+            Status: http.StatusAccepted, Response: resync.Job{}, Errors: []int{http.StatusUnauthorized, http.StatusConflict, http.StatusNotImplemented}},
+        {Method: "get", Path: "/api/admin/snapshots", Summary: "A SNAPSHOT_REPOSITORY tároló pillanatképei", Tags: []string{"admin"}, Admin: true,
+            Response: []index.Snapshot{}, Errors: []int{http.StatusUnauthorized, http.StatusBadGateway, http.StatusNotImplemented}},
+        {Method: "post", Path: "/api/admin/snapshots", Summary: "Pillanatkép készítése az alias mögötti indexről", Tags: []string{"admin"}, Admin: true,
+            Params:   []apiParam{{Name: "name", In: "query", Type: "string", Description: "A pillanatkép neve (alapértelmezés: <alias>-<UTC időbélyeg>)"}},
+            Status:   http.StatusAccepted,
+            Response: index.Snapshot{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusBadGateway, http.StatusNotImplemented}},
+        {Method: "get", Path: "/api/admin/snapshots/{name}", Summary: "Egy pillanatkép állapota", Tags: []string{"admin"}, Admin: true,
+            Params:   []apiParam{snapshotNameParam},
+            Response: index.Snapshot{}, Errors: []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusBadGateway, http.StatusNotImplemented}},
+        {Method: "post", Path: "/api/admin/snapshots/{name}/restore", Summary: "Pillanatkép visszaállítása új indexbe, majd aliasváltás", Tags: []string{"admin"}, Admin: true,
+            Params: []apiParam{snapshotNameParam,
+                {Name: "deleteOld", In: "query", Type: "string", Description: "1 esetén a régi index törlése a váltás után"},
+            },
+            Status: http.StatusAccepted, Response: index.ReindexJob{}, Errors: []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusConflict, http.StatusBadGateway, http.StatusNotImplemented}},
+        {Method: "post", Path: "/api/admin/mapping/upgrade", Summary: "Elavult mapping frissítése újraindexeléssel", Tags: []string{"admin"}, Admin: true,
+            Params: []apiParam{
+                {Name: "force", In: "query", Type: "string", Description: "1 esetén eltérés nélkül is újraindexel"},
+                {Name: "deleteOld", In: "query", Type: "string", Description: "1 esetén a régi index törlése a váltás után"},
+            },
+            Status: http.StatusAccepted, Response: map[string]interface{}{}, Errors: []int{http.StatusUnauthorized, http.StatusConflict, http.StatusBadGateway}},
+        {Method: "get", Path: "/api/admin/synonyms", Summary: "Az index szinonimalistája", Tags: []string{"admin"}, Admin: true,
+            Response: SynonymList{}, Errors: []int{http.StatusUnauthorized, http.StatusBadGateway}},
+        {Method: "put", Path: "/api/admin/synonyms", Summary: "Szinonimalista cseréje újraindexeléssel", Tags: []string{"admin"}, Admin: true,
+            Params: []apiParam{{Name: "deleteOld", In: "query", Type: "string", Description: "1 esetén a régi index törlése a váltás után"}},
+            RequestBody: map[string]interface{}{"required": true, "content": map[string]interface{}{
+                "application/json": map[string]interface{}{"schema": schemaRef(SynonymList{})},
+            }},
+            Status: http.StatusAccepted, Response: SynonymUpdate{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusBadGateway}},
+        {Method: "get", Path: "/api/admin/index/settings", Summary: "Az autocomplete analyzer beállításai", Tags: []string{"admin"}, Admin: true,
+            Response: index.AnalyzerSettings{}, Errors: []int{http.StatusUnauthorized, http.StatusBadGateway}},
+        {Method: "put", Path: "/api/admin/index/settings", Summary: "Analyzer beállítások cseréje újraindexeléssel", Tags: []string{"admin"}, Admin: true,
+            Params: []apiParam{{Name: "deleteOld", In: "query", Type: "string", Description: "1 esetén a régi index törlése a váltás után"}},
+            RequestBody: map[string]interface{}{"required": true, "content": map[string]interface{}{
+                "application/json": map[string]interface{}{"schema": schemaRef(index.AnalyzerSettings{})},
+            }},
+            Status: http.StatusAccepted, Response: AnalyzerUpdate{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusConflict, http.StatusBadGateway}},
+        {Method: "post", Path: "/api/admin/analyze", Summary: "Szöveg tokenizálása az index egy analyzerével", Tags: []string{"admin"}, Admin: true,
+            RequestBody: map[string]interface{}{"required": true, "content": map[string]interface{}{
+                "application/json": map[string]interface{}{"schema": schemaRef(index.AnalyzeRequest{})},
+            }},
+            Response: AnalyzeResult{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusBadGateway}},
+        {Method: "get", Path: "/api/admin/analytics/top", Summary: "Leggyakoribb lekérdezések az időablakban", Tags: []string{"admin"}, Admin: true,
+            Params: analyticsParams, Response: analytics.Summary{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotImplemented}},
+        {Method: "get", Path: "/api/admin/analytics/zero-results", Summary: "Találat nélküli lekérdezések az időablakban", Tags: []string{"admin"}, Admin: true,
+            Params: analyticsParams, Response: analytics.Summary{}, Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotImplemented}},
+        {Method: "get", Path: "/api/admin/experiments", Summary: "A futó A/B kísérlet változatainak összehasonlítása", Tags: []string{"admin"}, Admin: true,
+            Response: experiment.Report{}, Errors: []int{http.StatusUnauthorized, http.StatusNotImplemented}},
+    }
+}
+
+// schemaRef a névvel ellátott struktúrákra components/schemas hivatkozást ad, minden másra beágyazott sémát.
+func schemaRef(v interface{}) map[string]interface{} {
+    t := reflect.TypeOf(v)
+    if t.Kind() == reflect.Struct && t.Name() != "" {
+        return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+    }
+    return schemaFor(t)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor a Go típusból JSON sémát készít a json tagek alapján.
+func schemaFor(t reflect.Type) map[string]interface{} {
+    if t.Kind() == reflect.Pointer {
+        t = t.Elem()
+    }
+    if t == timeType {
+        return map[string]interface{}{"type": "string", "format": "date-time"}
+    }
+    switch t.Kind() {
+    case reflect.String:
+        return map[string]interface{}{"type": "string"}
+    case reflect.Bool:
+        return map[string]interface{}{"type": "boolean"}
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+        reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+        return map[string]interface{}{"type": "integer"}
+    case reflect.Float32, reflect.Float64:
+        return map[string]interface{}{"type": "number"}
+    case reflect.Slice, reflect.Array:
+        return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem())}
+    case reflect.Map:
+        return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+    case reflect.Struct:
+        properties := map[string]interface{}{}
+        for i := 0; i < t.NumField(); i++ {
+            field := t.Field(i)
+            if !field.IsExported() {
+                continue
+            }
+            name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+            if name == "-" {
+                continue
+            }
+            if name == "" && field.Anonymous {
+                // A beágyazott struct mezői az encoding/json-hoz hasonlóan a külső objektumba kerülnek.
+                if embedded, ok := schemaFor(field.Type)["properties"].(map[string]interface{}); ok {
+                    for k, v := range embedded {
+                        properties[k] = v
+                    }
+                    continue
+                }
+            }
+            if name == "" {
+                name = field.Name
+            }
+            properties[name] = schemaFor(field.Type)
+        }
+        return map[string]interface{}{"type": "object", "properties": properties}
+    }
+    return map[string]interface{}{}
+}
+
+// buildOpenAPISpec összeállítja az OpenAPI 3 dokumentumot az apiOperations alapján.
+func buildOpenAPISpec() map[string]interface{} {
+    paths := map[string]interface{}{}
+    schemas := map[string]interface{}{
+        "ErrorResponse": schemaFor(reflect.TypeOf(ErrorResponse{})),
+    }
+    register := func(v interface{}) {
+        t := reflect.TypeOf(v)
+        if t.Kind() == reflect.Struct && t.Name() != "" {
+            schemas[t.Name()] = schemaFor(t)
+        }
+    }
+    register(index.AddressDocument{})
+    register(graphQLRequest{})
+    register(suggest.AddressInput{})
+    register(suggest.Selection{})
+
+    for _, op := range apiOperations() {
+        params := []interface{}{}
+        for _, p := range append(op.Params, languageParams...) {
+            params = append(params, map[string]interface{}{
+                "name":        p.Name,
+                "in":          p.In,
+                "required":    p.Required,
+                "description": p.Description,
+                "schema":      map[string]interface{}{"type": p.Type},
+            })
+        }
+        status := op.Status
+        if status == 0 {
+            status = http.StatusOK
+        }
+        success := map[string]interface{}{"description": http.StatusText(status)}
+        if op.Response != nil {
+            register(op.Response)
+            success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": schemaRef(op.Response)}}
+        }
+        responses := map[string]interface{}{strconv.Itoa(status): success}
+        for _, code := range op.Errors {
+            responses[strconv.Itoa(code)] = map[string]interface{}{
+                "description": http.StatusText(code),
+                "content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/ErrorResponse"}}},
+            }
+        }
+        operation := map[string]interface{}{
+            "summary":    op.Summary,
+            "tags":       op.Tags,
+            "parameters": params,
+            "responses":  responses,
+        }
+        if op.RequestBody != nil {
+            operation["requestBody"] = op.RequestBody
+        }
+        if op.Admin {
+            operation["security"] = []interface{}{map[string]interface{}{"adminToken": []string{}}}
+        }
+        item, _ := paths[op.Path].(map[string]interface{})
+        if item == nil {
+            item = map[string]interface{}{}
+            paths[op.Path] = item
+        }
+        item[op.Method] = operation
+    }
+
+    return map[string]interface{}{
+        "openapi": "3.0.3",
+        "info": map[string]interface{}{
+            "title":       "Országos címlista autocomplete API",
+            "version":     "1.0.0",
+            "description": "Település, közterület, irányítószám és teljes cím javaslatok. Az admin végpontok külön porton (ADMIN_PORT) érhetők el, bearer tokennel.",
+        },
+        "servers": []interface{}{map[string]interface{}{"url": "/"}},
+        "paths":   paths,
+        "components": map[string]interface{}{
+            "schemas": schemas,
+            "securitySchemes": map[string]interface{}{
+                "adminToken": map[string]interface{}{"type": "http", "scheme": "bearer"},
+            },
+        },
+    }
+}
+
+// openAPIHandler kiszolgálja az /api/openapi.json dokumentumot.
+func (s *Server) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+    s.openAPIOnce.Do(func() {
+        var err error
+        if s.openAPISpec, err = json.MarshalIndent(buildOpenAPISpec(), "", "  "); err != nil {
+            slog.Error("Hiba az OpenAPI dokumentum kódolásakor", "error", err)
+        }
+    })
+    w.Header().Set("Content-Type", "application/json")
+    w.Write(s.openAPISpec)
+}
+
+// swaggerUIHandler az /api/docs oldalon Swagger UI-t jelenít meg az /api/openapi.json dokumentumhoz.
+func swaggerUIHandler(w http.ResponseWriter, r *http.Request) {
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    w.Write([]byte(`<!DOCTYPE html>
+<html lang="hu">
+<head>
+  <meta charset="UTF-8">
+  <title>Autocomplete API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`))
+}
@@ -0,0 +1,56 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strings"
+    "unicode/utf8"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// maxParseInputLength a felbontandó címsor legnagyobb hossza karakterben.
+const maxParseInputLength = 300
+
+// ParseRequest a POST /api/parse kérés törzse.
+type ParseRequest struct {
+    Address string `json:"address"`
+}
+
+// parseHandler kezeli a POST /api/parse végpontot, amely egy szabad szöveges címsort
+// ("1117 Budapest, Irinyi József u. 42.") irányítószámra, településre, közterületre,
+// közterület-jellegre és házszámra bont, a részeket az index alapján egyezteti, és mindegyikhez
+// megbízhatóságot ad (lásd suggest.ParseAddress).
+func (s *Server) parseHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+        return
+    }
+    var req ParseRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "A törzsnek {\"address\": \"...\"} alakú JSON objektumnak kell lennie")
+        return
+    }
+    if strings.TrimSpace(req.Address) == "" {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "Hiányzó 'address' mező")
+        return
+    }
+    if utf8.RuneCountInString(req.Address) > maxParseInputLength {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, fmt.Sprintf("Az 'address' legfeljebb %d karakter lehet", maxParseInputLength))
+        return
+    }
+    result, err := suggest.ParseAddress(r.Context(), s.suggester, req.Address)
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a cím felbontásakor")
+        slog.Error("Address parse error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "confidence", result.Confidence)
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(result); err != nil {
+        slog.Error("Hiba a címfelbontás válaszának kódolásakor", "error", err)
+    }
+}
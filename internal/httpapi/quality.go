@@ -0,0 +1,47 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "strconv"
+
+    "autocomplete/internal/reqlog"
+)
+
+const (
+    defaultQualitySamples = 10
+    maxQualitySamples     = 100
+)
+
+// qualityHandler kezeli a GET /api/admin/quality végpontot, amely az index adatminőségi
+// jelentését adja vissza (lásd index.Manager.QualityReport). A samples query paraméter a
+// hibatípusonkénti minta rekordok száma (alapértelmezés 10, legfeljebb 100). A jelentés a
+// teljes indexet bejárja, ezért nagy indexen másodpercekig is tarthat.
+func (s *Server) qualityHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+        return
+    }
+    samples := defaultQualitySamples
+    if raw := r.URL.Query().Get("samples"); raw != "" {
+        n, err := strconv.Atoi(raw)
+        if err != nil || n < 0 || n > maxQualitySamples {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "A 'samples' paraméter 0 és 100 közötti egész szám lehet")
+            return
+        }
+        samples = n
+    }
+    report, err := s.indexes.QualityReport(r.Context(), samples)
+    if err != nil {
+        slog.Error("Quality report error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        writeUpstreamError(w, r, err, "Hiba az adatminőségi jelentés készítésekor")
+        return
+    }
+    reqlog.Add(r.Context(), "documents", report.Documents, "duplicates", report.DuplicateSettlementsTotal,
+        "missing_zip", report.MissingZip.Count, "empty_street", report.EmptyStreet.Count, "suspicious", report.SuspiciousValuesTotal)
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(report); err != nil {
+        slog.Error("Hiba a quality válasz kódolásakor", "error", err)
+    }
+}
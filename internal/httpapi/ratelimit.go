@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+    "fmt"
+    "log/slog"
+    "math"
+    "net"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// isTrustedProxy jelzi, hogy az IP a megbízható proxyk hálózatainak valamelyikébe esik-e.
+func (s *Server) isTrustedProxy(ip net.IP) bool {
+    for _, ipNet := range s.trustedProxies {
+        if ipNet.Contains(ip) {
+            return true
+        }
+    }
+    return false
+}
+
+// clientIP meghatározza a kliens IP címét. Megbízható proxytól (TRUSTED_PROXIES) érkező kérésnél az
+// X-Forwarded-For láncot jobbról balra bejárva az első nem megbízható címet adja vissza.
+func (s *Server) clientIP(r *http.Request) string {
+    host, _, err := net.SplitHostPort(r.RemoteAddr)
+    if err != nil {
+        host = r.RemoteAddr
+    }
+    remote := net.ParseIP(host)
+    if remote == nil || !s.isTrustedProxy(remote) {
+        return host
+    }
+    hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+    for i := len(hops) - 1; i >= 0; i-- {
+        hop := strings.TrimSpace(hops[i])
+        ip := net.ParseIP(hop)
+        if ip == nil {
+            break
+        }
+        if !s.isTrustedProxy(ip) {
+            return hop
+        }
+        host = hop
+    }
+    return host
+}
+
+// rateLimit 429 Too Many Requests válasszal (és Retry-After fejléccel) utasítja el
+// a kliens IP-jére vonatkozó korlátot túllépő kéréseket (RATE_LIMIT_RPS, RATE_LIMIT_BURST).
+// Nulla RateLimitRPS esetén a korlátozás ki van kapcsolva.
+func (s *Server) rateLimit(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        if s.Options().RateLimitRPS <= 0 {
+            next.ServeHTTP(w, r)
+            return
+        }
+        ok, wait := s.limiter.Allow(s.clientIP(r))
+        if !ok {
+            w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(wait.Seconds()))))
+            writeError(w, r, http.StatusTooManyRequests, ErrCodeRateLimited, "Túl sok kérés, próbáld újra később")
+            return
+        }
+        next.ServeHTTP(w, r)
+    })
+}
+
+// startRateLimitCleanup időnként eldobja a régóta inaktív kliensek bucketjeit, hogy a
+// nyilvántartás ne nőjön korlátlanul.
+func (s *Server) startRateLimitCleanup(interval time.Duration) {
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for range ticker.C {
+            if removed := s.limiter.Cleanup(interval); removed > 0 {
+                slog.Debug("Rate limit cleanup", "removed", removed)
+            }
+        }
+    }()
+}
@@ -0,0 +1,129 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+)
+
+// writeReindexStartError az újraindexelés indításának hibáját a megfelelő státuszkóddal írja ki.
+func writeReindexStartError(w http.ResponseWriter, r *http.Request, err error) {
+    switch {
+    case errors.Is(err, index.ErrReindexInProgress):
+        writeError(w, r, http.StatusConflict, ErrCodeConflict, err.Error())
+    case errors.Is(err, index.ErrNoReindexSource):
+        writeError(w, r, http.StatusNotFound, ErrCodeNotFound, err.Error())
+    default:
+        writeError(w, r, http.StatusBadGateway, ErrCodeUpstream, err.Error())
+    }
+}
+
+// reindexHandler kezeli az /api/admin/reindex végpontot.
+// GET: az utolsó újraindexelés állapota (előrehaladás lekérdezéshez).
+// POST: új újraindexelés indítása. Query paraméterek:
+//   - mode: "reindex" (alapértelmezés, a jelenlegi index átmásolása) vagy "import"
+//     (üres célindex, amelyet a bulk/CSV import tölt fel az index paraméterrel, majd
+//     a POST /api/admin/reindex/swap vált át rá)
+//   - deleteOld=1: a váltás után a régi index törlése
+func (s *Server) reindexHandler(w http.ResponseWriter, r *http.Request) {
+    switch r.Method {
+    case http.MethodGet:
+        job := s.indexes.CurrentReindex()
+        if job == nil {
+            writeError(w, r, http.StatusNotFound, ErrCodeNotFound, "Még nem indult újraindexelés")
+            return
+        }
+        writeReindexJob(w, http.StatusOK, job)
+    case http.MethodPost:
+        params := r.URL.Query()
+        mode := params.Get("mode")
+        if mode == "" {
+            mode = index.ReindexModeReindex
+        }
+        if mode != index.ReindexModeReindex && mode != index.ReindexModeImport {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "A 'mode' paraméter értéke 'reindex' vagy 'import' lehet")
+            return
+        }
+        job, err := s.indexes.StartReindex(r.Context(), mode, params.Get("deleteOld") == "1")
+        if err != nil {
+            writeReindexStartError(w, r, err)
+            return
+        }
+        reqlog.Add(r.Context(), "target", job.Target, "mode", mode)
+        writeReindexJob(w, http.StatusAccepted, job)
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET és POST kérés engedélyezett")
+    }
+}
+
+// reindexSwapHandler kezeli a POST /api/admin/reindex/swap végpontot, amely import módban
+// a célindex feltöltése után átváltja az aliast.
+func (s *Server) reindexSwapHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+        return
+    }
+    job, err := s.indexes.ImportSwap(r.Context())
+    if errors.Is(err, index.ErrNoAwaitingImport) {
+        writeError(w, r, http.StatusConflict, ErrCodeConflict, "Nincs importra váró újraindexelés")
+        return
+    }
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba az alias váltásakor")
+        return
+    }
+    writeReindexJob(w, http.StatusOK, job)
+}
+
+func writeReindexJob(w http.ResponseWriter, status int, job *index.ReindexJob) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    if err := json.NewEncoder(w).Encode(job); err != nil {
+        slog.Error("Hiba a reindex válasz kódolásakor", "error", err)
+    }
+}
+
+// mappingUpgradeHandler kezeli a POST /api/admin/mapping/upgrade végpontot. Ha az élő index
+// mappingje eltér az elvárttól (régebbi verzió vagy hiányzó mező), az alias-alapú
+// újraindexeléssel új, aktuális sémájú indexbe másolja a dokumentumokat; az előrehaladás a
+// GET /api/admin/reindex végponton követhető. Query paraméterek:
+//   - force=1: újraindexelés akkor is, ha nincs eltérés
+//   - deleteOld=1: a váltás után a régi index törlése
+func (s *Server) mappingUpgradeHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+        return
+    }
+    params := r.URL.Query()
+    res, err := s.indexes.Check(r.Context())
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a mapping ellenőrzésekor")
+        slog.Error("Mapping check error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        return
+    }
+    res.Debug = ""
+    reqlog.Add(r.Context(), "mapping_version", res.MappingVersion, "drift", res.Drift)
+    if !res.Drift && params.Get("force") != "1" {
+        w.Header().Set("Content-Type", "application/json")
+        if err := json.NewEncoder(w).Encode(map[string]interface{}{"upgraded": false, "mapping": res}); err != nil {
+            slog.Error("Hiba a mapping upgrade válasz kódolásakor", "error", err)
+        }
+        return
+    }
+
+    slog.Info("Mapping upgrade started", "from_version", res.MappingVersion, "to_version", index.MappingVersion, "missing_fields", res.MissingFields)
+    job, err := s.indexes.StartReindex(r.Context(), index.ReindexModeReindex, params.Get("deleteOld") == "1")
+    if err != nil {
+        writeReindexStartError(w, r, err)
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusAccepted)
+    if err := json.NewEncoder(w).Encode(map[string]interface{}{"upgraded": true, "mapping": res, "reindex": job}); err != nil {
+        slog.Error("Hiba a mapping upgrade válasz kódolásakor", "error", err)
+    }
+}
@@ -0,0 +1,44 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/resync"
+)
+
+// resyncHandler kezeli az /api/admin/resync végpontot (RESYNC_SOURCE nélkül 501).
+// GET: az utolsó (vagy folyamatban lévő) teljes újraszinkronizálás állapota és a következő
+// ütemezett futás.
+// POST: teljes újraszinkronizálás indítása az ütemezéstől függetlenül; ha már fut egy, 409.
+func (s *Server) resyncHandler(w http.ResponseWriter, r *http.Request) {
+    if s.resync == nil {
+        writeError(w, r, http.StatusNotImplemented, ErrCodeNotImplemented, "A teljes újraszinkronizálás nincs beállítva (RESYNC_SOURCE)")
+        return
+    }
+    var body interface{}
+    status := http.StatusOK
+    switch r.Method {
+    case http.MethodGet:
+        body = s.resync.Status()
+    case http.MethodPost:
+        job, err := s.resync.Start(resync.TriggerManual)
+        if errors.Is(err, resync.ErrInProgress) {
+            writeError(w, r, http.StatusConflict, ErrCodeConflict, err.Error())
+            return
+        }
+        reqlog.Add(r.Context(), "source", job.Source)
+        body, status = job, http.StatusAccepted
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET és POST kérés engedélyezett")
+        return
+    }
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    if err := json.NewEncoder(w).Encode(body); err != nil {
+        slog.Error("Hiba a resync válasz kódolásakor", "error", err)
+    }
+}
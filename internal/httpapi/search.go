@@ -0,0 +1,188 @@
+package httpapi
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// defaultSearchLimit a szekcionált keresés találatainak alapértelmezett legnagyobb száma,
+// maxSearchLimit a kérhető legnagyobb érték.
+const (
+    defaultSearchLimit = 10
+    maxSearchLimit     = 50
+)
+
+// SectionedResult az /api/search végpont válasza: a település-, közterület- és
+// irányítószám-javaslatok egyetlen rangsorolt listában, mindegyik a forrásával (lásd
+// suggest.MergeSections). A Fuzzy és a Stale akkor igaz, ha bármelyik részlekérdezésre igaz; a
+// Partial jelzi, hogy a Missing forrásokból (pl. "street") a részlekérdezés időkorlátja miatt
+// nincs találat; a Hint a túl rövid lekérdezés magyarázata (lásd SearchResult).
+type SectionedResult struct {
+    Results []suggest.SectionHit `json:"results"`
+    Fuzzy   bool                 `json:"fuzzy,omitempty"`
+    Stale   bool                 `json:"stale,omitempty"`
+    Partial bool                 `json:"partial,omitempty"`
+    Missing []string             `json:"missing,omitempty"`
+    Hint    string               `json:"hint,omitempty"`
+}
+
+// searchFormats a szekcionált keresés válaszformátumai (lásd responseFormat).
+var searchFormats = []string{formatJSON, formatCSV, formatMsgPack}
+
+// csvRecords a szekcionált keresés CSV sorai: forrás, érték és a település KSH kódja.
+func (res SectionedResult) csvRecords() [][]string {
+    records := [][]string{{"source", "value", "ksh_kod"}}
+    for _, hit := range res.Results {
+        records = append(records, []string{hit.Source, hit.Value, hit.KSHKod})
+    }
+    return records
+}
+
+// searchLimit a "limit" paraméter értéke 1 és maxSearchLimit között; üresen defaultSearchLimit.
+func searchLimit(r *http.Request) (int, error) {
+    v := r.URL.Query().Get("limit")
+    if v == "" {
+        return defaultSearchLimit, nil
+    }
+    n, err := strconv.Atoi(v)
+    if err != nil || n < 1 || n > maxSearchLimit {
+        return 0, fmt.Errorf("a 'limit' paraméter 1 és %d közötti egész szám lehet", maxSearchLimit)
+    }
+    return n, nil
+}
+
+// searchHandler kezeli a GET /api/search végpontot: a település-, közterület- és (számjegyekből
+// álló lekérdezésre) irányítószám-javaslatokat párhuzamosan kérdezi le, és egyetlen rangsorolt
+// listában, forrásukkal együtt adja vissza, így egy keresőmezővel a teljes címjegyzék
+// kereshető. A "megye" és "telepules" paraméterek az egyes végpontokhoz hasonlóan szűkítenek,
+// a "limit" a találatok legnagyobb száma. A részlekérdezések egyenként legfeljebb
+// SEARCH_SUBQUERY_TIMEOUT ideig futnak; a lejárt részlekérdezés nélküli válasz részleges
+// ("partial": true), és nem gyorsítótárazható. Más hiba esetén az első hibás részlekérdezés a
+// többit megszakítja, és a teljes kérés hibája lesz. A MIN_QUERY_LEN-nél rövidebb lekérdezés csak irányítószám-előtagként
+// keres. A válasz JSON, CSV vagy MessagePack lehet (lásd responseFormat).
+func (s *Server) searchHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+        return
+    }
+    query := strings.TrimSpace(r.URL.Query().Get("q"))
+    if query == "" {
+        writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, "Hiányzó 'q' paraméter")
+        return
+    }
+    format, err := s.suggestionResponseFormat(r, searchFormats...)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    limit, err := searchLimit(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    hint, err := s.queryLength(r.Context(), query)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    zip := suggest.IsZipPrefix(query)
+    if hint != "" && !zip {
+        s.writeSuggestionResponse(w, r, SectionedResult{Results: []suggest.SectionHit{}, Hint: hint}, format, true)
+        return
+    }
+
+    var requests []suggest.Request
+    if hint == "" {
+        requests = append(requests,
+            suggest.Request{Kind: suggest.KindSettlement, Query: query, Megye: r.URL.Query().Get("megye")},
+            suggest.Request{Kind: suggest.KindStreet, Query: query, Telepules: r.URL.Query().Get("telepules")})
+    }
+    if zip {
+        requests = append(requests, suggest.Request{Kind: suggest.KindZip, Query: query})
+    }
+    sections, timedOut, err := s.suggestSections(r.Context(), requests, s.Options().SubQueryTimeout)
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a javaslatok lekérésekor")
+        slog.Error("Search error", "request_id", reqlog.RequestID(r.Context()), "query", query, "error", err)
+        return
+    }
+
+    response := SectionedResult{Results: suggest.MergeSections(query, sections, limit), Hint: hint,
+        Partial: len(timedOut) > 0, Missing: timedOut}
+    for _, section := range sections {
+        s.recordQuery(r.Context(), section.Kind, query, section.Set)
+        response.Fuzzy = response.Fuzzy || section.Set.Fuzzy
+        response.Stale = response.Stale || section.Set.Stale
+    }
+    reqlog.Add(r.Context(), "query", query, "result_count", len(response.Results), "sections", len(sections))
+    if response.Partial {
+        reqlog.Add(r.Context(), "partial", true, "timed_out", strings.Join(timedOut, ","))
+    }
+    s.writeSuggestionResponse(w, r, response, format, !response.Stale && !response.Partial)
+}
+
+// suggestSections a javaslatkéréseket párhuzamosan, egyenként legfeljebb timeout ideig (0:
+// korlátlanul) futtatja. A sikeres eredményeket a kérések sorrendjében, az időkorlát miatt
+// elmaradt kérések fajtáját a timedOut-ban adja vissza. Egyéb hiba esetén a többi kérést
+// megszakítja, és az első hibát adja vissza.
+func (s *Server) suggestSections(ctx context.Context, requests []suggest.Request, timeout time.Duration) (sections []suggest.Section, timedOut []string, err error) {
+    ctx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    results := make([]*suggest.Section, len(requests))
+    expired := make([]bool, len(requests))
+    var (
+        wg      sync.WaitGroup
+        errOnce sync.Once
+    )
+    for i, req := range requests {
+        wg.Add(1)
+        go func(i int, req suggest.Request) {
+            defer wg.Done()
+            subCtx, cancelSub := ctx, context.CancelFunc(func() {})
+            if timeout > 0 {
+                subCtx, cancelSub = context.WithTimeout(ctx, timeout)
+            }
+            defer cancelSub()
+            set, _, subErr := s.suggester.Suggest(subCtx, req)
+            switch {
+            case subErr == nil:
+                results[i] = &suggest.Section{Kind: req.Kind, Set: set}
+            case subQueryTimedOut(ctx, subCtx, subErr):
+                expired[i] = true
+            default:
+                errOnce.Do(func() {
+                    err = subErr
+                    cancel()
+                })
+            }
+        }(i, req)
+    }
+    wg.Wait()
+    if err != nil {
+        return nil, nil, err
+    }
+    for i, req := range requests {
+        if expired[i] {
+            timedOut = append(timedOut, req.Kind)
+        } else if results[i] != nil {
+            sections = append(sections, *results[i])
+        }
+    }
+    return sections, timedOut, nil
+}
+
+// subQueryTimedOut jelzi, hogy a részlekérdezés a saját időkorlátja miatt szakadt meg (és nem a
+// kérés vagy egy másik részlekérdezés hibája miatt).
+func subQueryTimedOut(parent, sub context.Context, err error) bool {
+    return parent.Err() == nil && errors.Is(sub.Err(), context.DeadlineExceeded) && errors.Is(err, context.DeadlineExceeded)
+}
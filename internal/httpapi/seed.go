@@ -0,0 +1,32 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "log/slog"
+    "net/http"
+
+    "autocomplete/internal/reqlog"
+)
+
+// seedHandler kezeli a POST /api/admin/seed végpontot: ha az index nem létezik, létrehozza, és
+// feltölti a binárisba ágyazott mintaadattal (lásd index.Manager.Seed), így egy friss fejlesztői
+// telepítés egy hívással használható. Az ismételt hívás ugyanazokat a dokumentumokat írja felül.
+func (s *Server) seedHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+        return
+    }
+    summary, err := s.indexes.Seed(r.Context())
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a mintaadat betöltésekor")
+        slog.Error("Seed error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "created", summary.Created, "indexed", summary.Load.Indexed, "failed", summary.Load.Failed)
+    slog.Info("Sample dataset loaded", "index", summary.Index, "created", summary.Created,
+        "indexed", summary.Load.Indexed, "failed", summary.Load.Failed)
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(summary); err != nil {
+        slog.Error("Hiba a seed válasz kódolásakor", "error", err)
+    }
+}
@@ -0,0 +1,56 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+    "strings"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// maxSelectionValueLength a kiválasztott érték legnagyobb hossza bájtban.
+const maxSelectionValueLength = 200
+
+// selectHandler kezeli a POST /api/select végpontot: a kliens ezzel jelzi, hogy a felhasználó
+// melyik javaslatot választotta ki. A törzs {"kind": "settlement|street|address", "value": "...",
+// "telepules": "..."}; a kiválasztás 204-es válasszal, késleltetve kerül az indexbe.
+func (s *Server) selectHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+        return
+    }
+    var sel suggest.Selection
+    if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&sel); err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "A törzsnek egy kiválasztás JSON objektumának kell lennie")
+        return
+    }
+    sel.Value, sel.Telepules = strings.TrimSpace(sel.Value), strings.TrimSpace(sel.Telepules)
+    if sel.Value == "" || len(sel.Value) > maxSelectionValueLength {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "A 'value' mező kötelező, legfeljebb 200 bájt")
+        return
+    }
+    recorder, ok := s.suggester.(suggest.SelectionRecorder)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    err := recorder.RecordSelection(r.Context(), sel)
+    switch {
+    case errors.Is(err, suggest.ErrUnknownKind):
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "A 'kind' értéke settlement, street vagy address lehet")
+        return
+    case errors.Is(err, suggest.ErrSelectionBufferFull):
+        writeError(w, r, http.StatusServiceUnavailable, ErrCodeUnavailable, "A kiválasztás most nem rögzíthető")
+        return
+    case err != nil:
+        slog.Error("Selection record error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        writeError(w, r, http.StatusInternalServerError, ErrCodeUpstream, "Hiba a kiválasztás rögzítésekor")
+        return
+    }
+    s.recordExperimentSelection(r.Context())
+    reqlog.Add(r.Context(), "kind", sel.Kind, "value", sel.Value)
+    w.WriteHeader(http.StatusNoContent)
+}
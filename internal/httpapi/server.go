@@ -0,0 +1,297 @@
+// Package httpapi a szolgáltatás HTTP felülete: a nyilvános javaslat, ellenőrző, GraphQL,
+// WebSocket és SSE végpontok, az admin végpontok (betöltés, import, újraindexelés, cache
+// ürítés), valamint a köztük megosztott middleware-ek (request ID, naplózás, tömörítés,
+// CORS, sebességkorlátozás, párhuzamossági korlát).
+package httpapi
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net"
+    "net/http"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/graphql-go/graphql"
+    "golang.org/x/net/websocket"
+
+    "autocomplete/internal/analytics"
+    "autocomplete/internal/config"
+    "autocomplete/internal/experiment"
+    "autocomplete/internal/index"
+    "autocomplete/internal/ratelimit"
+    "autocomplete/internal/resync"
+    "autocomplete/internal/suggest"
+)
+
+// Options a HTTP réteg futás közben, újraindítás nélkül módosítható beállításai.
+type Options struct {
+    DebugEnabled     bool
+    DefaultLanguage  string
+    ResponseMeta     bool
+    MaxResponseBytes int
+    RateLimitRPS     float64
+    RateLimitBurst   int
+    HTTPCacheEnabled bool
+    HTTPCacheMaxAge  time.Duration
+    ValidateBatchMax int
+    DefaultSort      string
+    MinQueryLength   int
+    MaxQueryLength   int
+    SubQueryTimeout  time.Duration
+    // SuggestFields a többmezős javaslatkérés mezőtáblája (lásd suggestFields).
+    SuggestFields map[string]string
+}
+
+// OptionsFrom kiemeli a konfigurációból a HTTP réteg módosítható beállításait.
+func OptionsFrom(cfg config.Config) Options {
+    return Options{
+        DebugEnabled:     cfg.Server.DebugEnabled,
+        DefaultLanguage:  cfg.Server.DefaultLanguage,
+        ResponseMeta:     cfg.Server.ResponseMeta,
+        MaxResponseBytes: cfg.Server.MaxResponseBytes,
+        RateLimitRPS:     cfg.RateLimit.RPS,
+        RateLimitBurst:   cfg.RateLimit.Burst,
+        HTTPCacheEnabled: cfg.HTTPCache.Enabled,
+        HTTPCacheMaxAge:  cfg.HTTPCache.MaxAge,
+        ValidateBatchMax: cfg.Search.ValidateBatchMax,
+        DefaultSort:      cfg.Search.DefaultSort,
+        MinQueryLength:   cfg.Search.MinQueryLength,
+        MaxQueryLength:   cfg.Search.MaxQueryLength,
+        SubQueryTimeout:  cfg.Search.SubQueryTimeout,
+        SuggestFields:    cfg.Search.SuggestFields,
+    }
+}
+
+// Server a nyilvános, az admin és a debug HTTP szervert fogja össze a javaslatokat kiszolgáló
+// háttérrendszerrel és az indexkezelővel. A kezelők az Options()-on keresztül olvassák a módosítható beállításokat,
+// így az újratöltés egyetlen atomi cserével érvényesül.
+type Server struct {
+    suggester suggest.Suggester
+    indexes   *index.Manager
+    limiter   *ratelimit.Limiter
+    // concurrency a nyilvános végpontok útvonalankénti párhuzamossági korlátai.
+    concurrency *endpointLimits
+    options     atomic.Pointer[Options]
+    // analytics a javaslatkérések lekérdezés-statisztikája; nil, ha ANALYTICS_ENABLED ki van kapcsolva.
+    analytics *analytics.Store
+    // experiment a futó A/B kísérlet; nil, ha az EXPERIMENT_NAME üres. Az overrides a változatok
+    // motorbeállítás-felülírásai, a clientHeader a kliensazonosító fejléce (lásd assignVariant).
+    experiment   *experiment.Experiment
+    overrides    map[string]suggest.Override
+    clientHeader string
+    // resync a teljes újraszinkronizálás; nil, ha a RESYNC_SOURCE nincs megadva.
+    resync *resync.Syncer
+    // warmingUp igaz, amíg az induláskori cache előmelegítés fut; addig a /healthz 503-at ad.
+    warmingUp atomic.Bool
+
+    cfg              config.ServerConfig
+    compression      config.CompressionConfig
+    demo             config.DemoConfig
+    widget           config.WidgetConfig
+    trustedProxies   []*net.IPNet
+    csvHeaderMapping map[string]string
+    updatesSecret    string
+
+    graphQLSchema graphql.Schema
+    openAPIOnce   sync.Once
+    openAPISpec   []byte
+
+    // wsConns a nyitott WebSocket kapcsolatok; a szerver leállásakor closeWebSockets zárja le
+    // őket, mivel a Shutdown az átvett (hijacked) kapcsolatokat nem kezeli.
+    wsMu    sync.Mutex
+    wsConns map[*websocket.Conn]struct{}
+}
+
+// New a már ellenőrzött konfigurációból hozza létre a szervert. Az indexes nil lehet, ha a
+// háttérrendszernek nincs OpenSearch indexe; ilyenkor az indexkezelő végpontok 501-et adnak.
+func New(cfg config.Config, suggester suggest.Suggester, indexes *index.Manager) *Server {
+    opts := OptionsFrom(cfg)
+    s := &Server{
+        suggester:        suggester,
+        indexes:          indexes,
+        limiter:          ratelimit.New(opts.RateLimitRPS, opts.RateLimitBurst),
+        concurrency:      &endpointLimits{cfg: cfg.Concurrency, semaphores: map[string]*ratelimit.Semaphore{}},
+        cfg:              cfg.Server,
+        compression:      cfg.Compression,
+        demo:             cfg.Demo,
+        widget:           cfg.Widget,
+        csvHeaderMapping: cfg.Import.CSVHeaderMapping,
+        updatesSecret:    cfg.Import.UpdatesSecret,
+        wsConns:          map[*websocket.Conn]struct{}{},
+    }
+    if cfg.Analytics.Enabled {
+        s.analytics = analytics.New(cfg.Analytics.Retention)
+    }
+    s.experiment, s.overrides = newExperiment(cfg.Experiment.Name, cfg.Experiment.Variants)
+    s.clientHeader = cfg.Experiment.ClientHeader
+    s.options.Store(&opts)
+    // A config.Load már ellenőrizte a formátumot.
+    s.trustedProxies, _ = config.ParseTrustedProxies(strings.Join(cfg.RateLimit.TrustedProxies, ","))
+    s.graphQLSchema = s.newGraphQLSchema()
+    return s
+}
+
+// Options az érvényes beállítások pillanatképét adja vissza.
+func (s *Server) Options() Options {
+    return *s.options.Load()
+}
+
+// SetOptions lecseréli a módosítható beállításokat, és a rate limitert is átállítja.
+func (s *Server) SetOptions(opts Options) {
+    s.limiter.SetRate(opts.RateLimitRPS, opts.RateLimitBurst)
+    s.options.Store(&opts)
+}
+
+// SetWarmingUp jelzi, hogy fut-e az induláskori cache előmelegítés.
+func (s *Server) SetWarmingUp(warming bool) {
+    s.warmingUp.Store(warming)
+}
+
+// SetResync beállítja a teljes újraszinkronizálást, amelyet az /api/admin/resync végpont kezel.
+func (s *Server) SetResync(syncer *resync.Syncer) {
+    s.resync = syncer
+}
+
+// PublicHandler a nyilvános végpontok kezelője a middleware-ekkel együtt.
+func (s *Server) PublicHandler() http.Handler {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/api/autocomplete", s.withResponseMeta(s.autocompleteHandler))
+    mux.HandleFunc("/api/autocomplete/street", s.withResponseMeta(s.streetAutocompleteHandler))
+    mux.HandleFunc("/api/autocomplete/zip", s.withResponseMeta(s.zipAutocompleteHandler))
+    mux.HandleFunc("/api/autocomplete/address", s.withResponseMeta(s.addressAutocompleteHandler))
+    mux.HandleFunc("/api/autocomplete/ws", s.wsAutocompleteHandler)
+    mux.HandleFunc("/api/autocomplete/stream", s.streamAutocompleteHandler)
+    mux.HandleFunc("/api/autocomplete/grouped", s.groupedAutocompleteHandler)
+    mux.HandleFunc("/api/search", s.searchHandler)
+    mux.HandleFunc("/api/zip/", s.zipLookupHandler)
+    mux.HandleFunc("/api/settlements", s.settlementListHandler)
+    mux.HandleFunc(settlementsPrefix, s.settlementsHandler)
+    mux.HandleFunc("/api/suggest/spelling", s.spellingSuggestHandler)
+    mux.HandleFunc("/api/checkMapping", s.requireIndexes(s.mappingCheckHandler))
+    mux.HandleFunc("/healthz", s.healthHandler)
+    mux.HandleFunc("/api/validate/batch", s.validateBatchHandler)
+    mux.HandleFunc("/api/validate/address", s.validateAddressHandler)
+    mux.HandleFunc("/api/parse", s.parseHandler)
+    mux.HandleFunc("/api/geocode", s.geocodeHandler)
+    mux.HandleFunc("/api/reverse-geocode", s.reverseGeocodeHandler)
+    mux.HandleFunc("/api/select", s.selectHandler)
+    mux.HandleFunc("/graphql", s.graphQLHandler)
+    mux.HandleFunc("/api/openapi.json", s.openAPIHandler)
+    mux.HandleFunc("/api/docs", swaggerUIHandler)
+    mux.HandleFunc("/widget.js", s.widgetHandler("widget.js"))
+    mux.HandleFunc("/widget-element.js", s.widgetHandler("element.js"))
+    mux.Handle(demoStaticPrefix, demoStaticHandler())
+    mux.HandleFunc("/", s.demoHandler)
+    return withRequestID(s.logRequests(s.withLanguage(s.allowCORS(s.assignVariant(s.compressResponses(s.rateLimit(s.limitConcurrency(mux))))))))
+}
+
+// AdminHandler az admin végpontok kezelője; minden kéréshez az ADMIN_TOKEN szükséges.
+func (s *Server) AdminHandler() http.Handler {
+    mux := http.NewServeMux()
+    mux.HandleFunc("/api/admin/bulk", s.requireIndexes(s.bulkHandler))
+    mux.HandleFunc("/api/admin/import/csv", s.requireIndexes(s.csvImportHandler))
+    mux.HandleFunc("/api/admin/seed", s.requireIndexes(s.seedHandler))
+    mux.HandleFunc("/api/admin/weights/address-count", s.requireIndexes(s.addressCountWeightsHandler))
+    mux.HandleFunc("/api/admin/documents/", s.requireIndexes(s.documentsHandler))
+    mux.HandleFunc("/api/admin/updates", s.requireIndexes(s.updatesHandler))
+    mux.HandleFunc("/api/admin/cache/flush", s.cacheFlushHandler)
+    mux.HandleFunc("/api/admin/materialize", s.materializeHandler)
+    mux.HandleFunc("/api/admin/export", s.exportHandler)
+    mux.HandleFunc("/api/admin/quality", s.requireIndexes(s.qualityHandler))
+    mux.HandleFunc("/api/admin/reindex", s.requireIndexes(s.reindexHandler))
+    mux.HandleFunc("/api/admin/reindex/swap", s.requireIndexes(s.reindexSwapHandler))
+    mux.HandleFunc("/api/admin/resync", s.resyncHandler)
+    mux.HandleFunc("/api/admin/snapshots", s.requireIndexes(s.snapshotsHandler))
+    mux.HandleFunc("/api/admin/snapshots/", s.requireIndexes(s.snapshotsHandler))
+    mux.HandleFunc("/api/admin/mapping/upgrade", s.requireIndexes(s.mappingUpgradeHandler))
+    mux.HandleFunc("/api/admin/synonyms", s.requireIndexes(s.synonymsHandler))
+    mux.HandleFunc("/api/admin/index/settings", s.requireIndexes(s.indexSettingsHandler))
+    mux.HandleFunc("/api/admin/analyze", s.requireIndexes(s.analyzeHandler))
+    mux.HandleFunc("/api/admin/analytics/top", s.analyticsHandler(false))
+    mux.HandleFunc("/api/admin/analytics/zero-results", s.analyticsHandler(true))
+    mux.HandleFunc("/api/admin/experiments", s.experimentsHandler)
+    return withRequestID(s.logRequests(s.withLanguage(s.compressResponses(s.requireAdminToken(mux)))))
+}
+
+// requireIndexes 501-es hibát ad az indexkezelő végpontokon, ha nincs indexkezelő
+// (memóriabeli és SQLite háttérrendszernél).
+func (s *Server) requireIndexes(next http.HandlerFunc) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if s.indexes == nil {
+            writeNotImplemented(w, r)
+            return
+        }
+        next(w, r)
+    }
+}
+
+// Serve elindítja a publikus, az opcionális átirányító, az admin és a debug HTTP szervert,
+// és a ctx lezárásáig fut. Utána a folyamatban lévő kérések befejezésére legfeljebb
+// ShutdownTimeout ideig vár. Bármelyik listener hibája esetén azzal tér vissza.
+func (s *Server) Serve(ctx context.Context) error {
+    publicServer := &http.Server{Addr: fmt.Sprintf(":%s", s.cfg.Port), Handler: s.PublicHandler()}
+    tlsConfig, certManager, err := publicTLSConfig(s.cfg)
+    if err != nil {
+        return fmt.Errorf("érvénytelen TLS beállítás: %w", err)
+    }
+    publicServer.TLSConfig = tlsConfig
+    publicServer.RegisterOnShutdown(s.closeWebSockets)
+    servers := []*http.Server{publicServer}
+    if s.cfg.HTTPRedirectPort != "" {
+        var redirect http.Handler = httpsRedirectHandler(s.cfg.Port)
+        if certManager != nil {
+            redirect = certManager.HTTPHandler(redirect)
+        }
+        servers = append(servers, &http.Server{Addr: fmt.Sprintf(":%s", s.cfg.HTTPRedirectPort), Handler: redirect})
+    }
+    if s.cfg.AdminToken != "" {
+        servers = append(servers, &http.Server{Addr: fmt.Sprintf(":%s", s.cfg.AdminPort), Handler: s.AdminHandler()})
+    } else {
+        slog.Warn("ADMIN_TOKEN is not set, admin endpoints are disabled")
+    }
+    if s.cfg.DebugServerAddr != "off" {
+        servers = append(servers, &http.Server{Addr: s.cfg.DebugServerAddr, Handler: newDebugMux()})
+    }
+    s.startRateLimitCleanup(10 * time.Minute)
+
+    serverErr := make(chan error, len(servers))
+    for _, server := range servers {
+        server := server
+        go func() {
+            if server.TLSConfig != nil {
+                slog.Info("Server listening", "addr", server.Addr, "tls", true)
+                serverErr <- server.ListenAndServeTLS("", "")
+                return
+            }
+            slog.Info("Server listening", "addr", server.Addr)
+            serverErr <- server.ListenAndServe()
+        }()
+    }
+
+    var result error
+    select {
+    case result = <-serverErr:
+    case <-ctx.Done():
+    }
+
+    slog.Info("Leállítás: folyamatban lévő kérések kivárása", "timeout", s.cfg.ShutdownTimeout.String())
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+    defer cancel()
+    var wg sync.WaitGroup
+    for _, server := range servers {
+        wg.Add(1)
+        go func(server *http.Server) {
+            defer wg.Done()
+            if err := server.Shutdown(shutdownCtx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+                slog.Error("Hiba a szerver leállításakor", "addr", server.Addr, "error", err)
+            }
+        }(server)
+    }
+    wg.Wait()
+    return result
+}
@@ -0,0 +1,182 @@
+package httpapi
+
+import (
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// settlementsPrefix a településenkénti végpontok útvonalának eleje.
+const settlementsPrefix = "/api/settlements/"
+
+// defaultSettlementPage a település- és a közterületlista egy oldalának alapértelmezett mérete.
+const defaultSettlementPage = 100
+
+// SettlementListResult a GET /api/settlements válasza: a lap települései és a következő lap
+// kurzora (next), amelyet az "after" paraméterben kell visszaküldeni.
+type SettlementListResult struct {
+    suggest.SettlementPage
+    Megye string `json:"megye,omitempty"`
+    Debug string `json:"debug,omitempty"`
+}
+
+// StreetListResult a GET /api/settlements/{name}/streets válasza: a település közterületei és a
+// következő lap kurzora (next), amelyet az "after" paraméterben kell visszaküldeni.
+type StreetListResult struct {
+    suggest.StreetPage
+    Debug string `json:"debug,omitempty"`
+}
+
+// SettlementStatsResult a GET /api/settlements/{name}/stats válasza.
+type SettlementStatsResult struct {
+    suggest.SettlementStats
+    Debug string `json:"debug,omitempty"`
+}
+
+// settlementsHandler az /api/settlements/{name}/... végpontokat a név utáni útvonalrész szerint
+// a megfelelő kezelőhöz irányítja; ismeretlen útvonalra 404-et ad.
+func (s *Server) settlementsHandler(w http.ResponseWriter, r *http.Request) {
+    rest := strings.TrimPrefix(r.URL.Path, settlementsPrefix)
+    if name, ok := strings.CutSuffix(rest, "/stats"); ok && name != "" {
+        s.settlementStatsHandler(w, r, name)
+        return
+    }
+    if name, ok := strings.CutSuffix(rest, "/streets"); ok && name != "" {
+        s.settlementStreetsHandler(w, r, name)
+        return
+    }
+    writeError(w, r, http.StatusNotFound, ErrCodeNotFound, "Ismeretlen végpont")
+}
+
+// settlementStatsHandler kezeli a GET /api/settlements/{name}/stats végpontot: a település
+// különböző közterületeinek és irányítószámainak számát adja vissza (pl. a felület "3214
+// közterület" feliratához és a találati panel méretezéséhez). A név kis-nagybetű független;
+// ismeretlen településre 404. A válasz HTTP_CACHE_ENABLED mellett gyorsítótárazható.
+func (s *Server) settlementStatsHandler(w http.ResponseWriter, r *http.Request, name string) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+        return
+    }
+    provider, ok := s.suggester.(suggest.SettlementStatsProvider)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    stats, debugInfo, err := provider.SettlementStats(r.Context(), name)
+    switch {
+    case errors.Is(err, suggest.ErrUnknownSettlement):
+        writeError(w, r, http.StatusNotFound, ErrCodeNotFound, "Ismeretlen település")
+        return
+    case errors.Is(err, suggest.ErrInvalidCursor):
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "Az 'after' értéke egy korábbi válasz 'next' kurzora lehet")
+        return
+    case err != nil:
+        writeUpstreamError(w, r, err, "Hiba a település összesítésekor")
+        slog.Error("Settlement stats error", "request_id", reqlog.RequestID(r.Context()), "settlement", name, "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "settlement", stats.Settlement, "streets", stats.Streets, "zip_codes", stats.ZipCodes)
+    response := SettlementStatsResult{SettlementStats: stats}
+    if s.debugRequested(r) {
+        response.Debug = localize(r.Context(), debugInfo)
+    }
+    s.writeSuggestionResponse(w, r, response, formatJSON, response.Debug == "")
+}
+
+// settlementListHandler kezeli a GET /api/settlements végpontot: a települések teljes listáját
+// adja lapozva (pl. offline településválasztóhoz vagy egy kis megye előtöltéséhez). A "megye"
+// paraméter egy megyére szűkít, a "limit" a lap mérete (alapértelmezés 100, legfeljebb 1000), az
+// "after" az előző válasz "next" kurzora (átlátszatlan; érvénytelen kurzorra 400). A válasz HTTP_CACHE_ENABLED mellett gyorsítótárazható.
+func (s *Server) settlementListHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+        return
+    }
+    lister, ok := s.suggester.(suggest.SettlementLister)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    query := r.URL.Query()
+    limit, ok := pageLimit(w, r, suggest.MaxSettlementPage)
+    if !ok {
+        return
+    }
+    megye := strings.TrimSpace(query.Get("megye"))
+    page, debugInfo, err := lister.ListSettlements(r.Context(), megye, query.Get("after"), limit)
+    switch {
+    case errors.Is(err, suggest.ErrInvalidCursor):
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "Az 'after' értéke egy korábbi válasz 'next' kurzora lehet")
+        return
+    case err != nil:
+        writeUpstreamError(w, r, err, "Hiba a településlista lekérdezésekor")
+        slog.Error("Settlement list error", "request_id", reqlog.RequestID(r.Context()), "megye", megye, "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "settlements", len(page.Settlements))
+    response := SettlementListResult{SettlementPage: page, Megye: megye}
+    if s.debugRequested(r) {
+        response.Debug = localize(r.Context(), debugInfo)
+    }
+    s.writeSuggestionResponse(w, r, response, formatJSON, response.Debug == "")
+}
+
+// settlementStreetsHandler kezeli a GET /api/settlements/{name}/streets végpontot: a település
+// közterületeit adja lapozva, így a település kiválasztása után a közterület-legördülő teljes
+// egészében ebből a szolgáltatásból tölthető. A név kis-nagybetű független; a "prefix" paraméter
+// az azzal kezdődő közterületekre szűkít, a "limit" és az "after" a /api/settlements-hez
+// hasonlóan lapoz. Ismeretlen településre 404. A válasz HTTP_CACHE_ENABLED mellett
+// gyorsítótárazható.
+func (s *Server) settlementStreetsHandler(w http.ResponseWriter, r *http.Request, name string) {
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+        return
+    }
+    lister, ok := s.suggester.(suggest.StreetLister)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    query := r.URL.Query()
+    limit, ok := pageLimit(w, r, suggest.MaxStreetPage)
+    if !ok {
+        return
+    }
+    page, debugInfo, err := lister.ListStreets(r.Context(), name, query.Get("prefix"), query.Get("after"), limit)
+    switch {
+    case errors.Is(err, suggest.ErrUnknownSettlement):
+        writeError(w, r, http.StatusNotFound, ErrCodeNotFound, "Ismeretlen település")
+        return
+    case err != nil:
+        writeUpstreamError(w, r, err, "Hiba a közterületlista lekérdezésekor")
+        slog.Error("Street list error", "request_id", reqlog.RequestID(r.Context()), "settlement", name, "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "settlement", page.Settlement, "streets", len(page.Streets))
+    response := StreetListResult{StreetPage: page}
+    if s.debugRequested(r) {
+        response.Debug = localize(r.Context(), debugInfo)
+    }
+    s.writeSuggestionResponse(w, r, response, formatJSON, response.Debug == "")
+}
+
+// pageLimit a lapozó végpontok "limit" paramétere (alapértelmezés: defaultSettlementPage); 1 és
+// max közötti egész számot fogad el, egyébként 400-at ír, és ok=false-t ad.
+func pageLimit(w http.ResponseWriter, r *http.Request, max int) (limit int, ok bool) {
+    v := r.URL.Query().Get("limit")
+    if v == "" {
+        return defaultSettlementPage, true
+    }
+    n, err := strconv.Atoi(v)
+    if err != nil || n < 1 || n > max {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, fmt.Sprintf("A 'limit' 1 és %d közötti egész szám lehet", max))
+        return 0, false
+    }
+    return n, true
+}
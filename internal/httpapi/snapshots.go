@@ -0,0 +1,91 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+    "strings"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+)
+
+// snapshotsHandler kezeli az /api/admin/snapshots végpontokat (SNAPSHOT_REPOSITORY nélkül 501),
+// amelyekkel kockázatos újraindexelés előtt az index menthető, és szükség esetén visszaállítható.
+//   - GET /api/admin/snapshots: a tároló pillanatképei
+//   - POST /api/admin/snapshots?name=: pillanatkép indítása az alias mögötti indexről (a név
+//     elhagyható); az állapot a GET /api/admin/snapshots/{name} végponton követhető
+//   - GET /api/admin/snapshots/{name}: a pillanatkép állapota
+//   - POST /api/admin/snapshots/{name}/restore?deleteOld=1: visszaállítás új indexbe, majd
+//     aliasváltás; az előrehaladás a GET /api/admin/reindex végponton követhető
+func (s *Server) snapshotsHandler(w http.ResponseWriter, r *http.Request) {
+    if s.indexes.SnapshotRepository == "" {
+        writeError(w, r, http.StatusNotImplemented, ErrCodeNotImplemented, "A pillanatkép tároló nincs beállítva (SNAPSHOT_REPOSITORY)")
+        return
+    }
+    name, action, _ := strings.Cut(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/snapshots"), "/"), "/")
+    switch {
+    case name == "" && r.Method == http.MethodGet:
+        snapshots, err := s.indexes.Snapshots(r.Context())
+        if err != nil {
+            writeSnapshotError(w, r, err)
+            return
+        }
+        writeSnapshotResponse(w, http.StatusOK, snapshots)
+    case name == "" && r.Method == http.MethodPost:
+        snapshot, err := s.indexes.CreateSnapshot(r.Context(), r.URL.Query().Get("name"))
+        if err != nil {
+            writeSnapshotError(w, r, err)
+            return
+        }
+        reqlog.Add(r.Context(), "snapshot", snapshot.Name)
+        writeSnapshotResponse(w, http.StatusAccepted, snapshot)
+    case name != "" && action == "" && r.Method == http.MethodGet:
+        snapshot, err := s.indexes.Snapshot(r.Context(), name)
+        if err != nil {
+            writeSnapshotError(w, r, err)
+            return
+        }
+        writeSnapshotResponse(w, http.StatusOK, snapshot)
+    case name != "" && action == "restore" && r.Method == http.MethodPost:
+        job, err := s.indexes.StartRestore(r.Context(), name, r.URL.Query().Get("deleteOld") == "1")
+        if err != nil {
+            writeSnapshotError(w, r, err)
+            return
+        }
+        reqlog.Add(r.Context(), "snapshot", name, "target", job.Target)
+        writeReindexJob(w, http.StatusAccepted, job)
+    case name != "" && action != "" && action != "restore":
+        writeError(w, r, http.StatusNotFound, ErrCodeNotFound, "Ismeretlen pillanatkép művelet")
+    case action == "restore":
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+    case name != "":
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET és POST kérés engedélyezett")
+    }
+}
+
+// writeSnapshotError a pillanatkép műveletek hibáját a megfelelő státuszkóddal írja ki.
+func writeSnapshotError(w http.ResponseWriter, r *http.Request, err error) {
+    switch {
+    case errors.Is(err, index.ErrInvalidSnapshotName):
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+    case errors.Is(err, index.ErrSnapshotNotFound), errors.Is(err, index.ErrNoReindexSource):
+        writeError(w, r, http.StatusNotFound, ErrCodeNotFound, err.Error())
+    case errors.Is(err, index.ErrSnapshotExists), errors.Is(err, index.ErrSnapshotInProgress), errors.Is(err, index.ErrReindexInProgress):
+        writeError(w, r, http.StatusConflict, ErrCodeConflict, err.Error())
+    default:
+        slog.Error("Snapshot error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        writeError(w, r, http.StatusBadGateway, ErrCodeUpstream, err.Error())
+    }
+}
+
+func writeSnapshotResponse(w http.ResponseWriter, status int, body interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    if err := json.NewEncoder(w).Encode(body); err != nil {
+        slog.Error("Hiba a snapshot válasz kódolásakor", "error", err)
+    }
+}
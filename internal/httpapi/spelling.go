@@ -0,0 +1,47 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "strings"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// SpellingResult az /api/suggest/spelling végpont válasza.
+type SpellingResult struct {
+    Query       string                       `json:"query"`
+    Suggestions []suggest.SpellingSuggestion `json:"suggestions"`
+    Debug       string                       `json:"debug,omitempty"`
+}
+
+// spellingSuggestHandler kezeli az /api/suggest/spelling végpontot.
+func (s *Server) spellingSuggestHandler(w http.ResponseWriter, r *http.Request) {
+    query := strings.TrimSpace(r.URL.Query().Get("q"))
+    if query == "" {
+        writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, "Hiányzó 'q' paraméter")
+        return
+    }
+    checker, ok := s.suggester.(suggest.SpellChecker)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    suggestions, debugInfo, err := checker.Spelling(r.Context(), query)
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a javaslatok lekérésekor")
+        slog.Error("Spelling suggest error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "query", query, "result_count", len(suggestions))
+    response := SpellingResult{Query: query, Suggestions: suggestions}
+    if s.debugRequested(r) {
+        response.Debug = localize(r.Context(), debugInfo)
+    }
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(response); err != nil {
+        slog.Error("Hiba a válasz kódolásakor", "error", err)
+    }
+}
@@ -0,0 +1,125 @@
+package httpapi
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "net/http"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// streamEvent egy részeredmény az SSE folyamban: a Source jelzi, melyik részlekérdezésből származik.
+type streamEvent struct {
+    Source string
+    Set    suggest.Set
+    Err    error
+}
+
+// writeSSE egy Server-Sent Events eseményt ír ki JSON adattal, és azonnal üríti a puffert.
+func writeSSE(w http.ResponseWriter, event string, data interface{}) error {
+    payload, err := json.Marshal(data)
+    if err != nil {
+        return err
+    }
+    if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+        return err
+    }
+    return http.NewResponseController(w).Flush()
+}
+
+// streamAutocompleteHandler kezeli az /api/autocomplete/stream végpontot. A település- és
+// közterület-javaslatokat párhuzamosan kérdezi le, és mindkettőt külön "settlement" ill.
+// "street" eseményként küldi el, amint megérkezett, így a felület nem vár a lassabb
+// részlekérdezésre. Egy részlekérdezés hibája "error" eseményt ad, a folyamot "done" zárja.
+// A MIN_QUERY_LEN-nél rövidebb lekérdezésre háttérkérés nélkül üres, hint-tel ellátott eseményeket küld.
+func (s *Server) streamAutocompleteHandler(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query().Get("q")
+    if query == "" {
+        writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, "Hiányzó 'q' paraméter")
+        return
+    }
+    megye := r.URL.Query().Get("megye")
+    telepules := r.URL.Query().Get("telepules")
+    sortBy, err := s.sortMode(r)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    highlight, err := highlightMode(r.URL.Query().Get("highlight"))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    hint, err := s.queryLength(r.Context(), query)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+
+    ctx, cancel := context.WithCancel(r.Context())
+    defer cancel()
+    events := make(chan streamEvent, 2)
+    if hint != "" {
+        // Túl rövid lekérdezés: háttérkérés nélkül üres eseményeket küldünk.
+        events <- streamEvent{Source: "settlement"}
+        events <- streamEvent{Source: "street"}
+    } else {
+        s.streamSuggestions(ctx, events, query, megye, telepules, sortBy)
+    }
+
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-store")
+    w.Header().Set("X-Accel-Buffering", "no")
+    w.WriteHeader(http.StatusOK)
+
+    counts := map[string]int{}
+    for i := 0; i < 2; i++ {
+        var ev streamEvent
+        select {
+        case ev = <-events:
+        case <-ctx.Done():
+            return
+        }
+        var err error
+        if ev.Err != nil {
+            slog.Error("Stream autocomplete error", "request_id", reqlog.RequestID(ctx), "source", ev.Source, "query", query, "error", ev.Err)
+            apiErr, _ := upstreamAPIError(ev.Err, "Hiba a javaslatok lekérésekor")
+            apiErr.Message = localize(ctx, apiErr.Message)
+            apiErr.RequestID = reqlog.RequestID(ctx)
+            err = writeSSE(w, "error", map[string]interface{}{"source": ev.Source, "error": apiErr})
+        } else {
+            counts[ev.Source] = len(ev.Set.Suggestions)
+            if hint == "" {
+                s.recordQuery(ctx, ev.Source, query, ev.Set)
+            }
+            suggestions := ev.Set.Suggestions
+            if suggestions == nil {
+                suggestions = []string{}
+            }
+            err = writeSSE(w, ev.Source, SearchResult{Suggestions: suggestions, Fuzzy: ev.Set.Fuzzy, Stale: ev.Set.Stale, Matched: ev.Set.Matched,
+                Highlights: highlights(suggestions, query, highlight), Hint: hint})
+        }
+        if err != nil {
+            slog.Debug("SSE write failed", "request_id", reqlog.RequestID(ctx), "error", err)
+            return
+        }
+    }
+    reqlog.Add(ctx, "query", query, "settlement_count", counts["settlement"], "street_count", counts["street"])
+    writeSSE(w, "done", map[string]interface{}{})
+}
+
+// streamSuggestions párhuzamosan lekéri a település- és közterület-javaslatokat, és az
+// eredményeket az events csatornára küldi, amint megérkeztek.
+func (s *Server) streamSuggestions(ctx context.Context, events chan<- streamEvent, query, megye, telepules, sortBy string) {
+    go func() {
+        set, _, err := s.suggester.Suggest(ctx, suggest.Request{Kind: suggest.KindSettlement, Query: query, Megye: megye, Sort: sortBy})
+        events <- streamEvent{Source: "settlement", Set: set, Err: err}
+    }()
+    go func() {
+        set, _, err := s.suggester.Suggest(ctx, suggest.Request{Kind: suggest.KindStreet, Query: query, Telepules: telepules, Sort: sortBy})
+        events <- streamEvent{Source: "street", Set: set, Err: err}
+    }()
+}
@@ -0,0 +1,70 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+)
+
+// SynonymList a GET és PUT /api/admin/synonyms törzse; a szabályok Solr formátumúak
+// ("bp, budapest" vagy "krt => körút").
+type SynonymList struct {
+    Synonyms []string `json:"synonyms"`
+}
+
+// SynonymUpdate a PUT /api/admin/synonyms válasza: az érvényesítés utáni lista és az új
+// szinonimákat betöltő újraindexelés állapota.
+type SynonymUpdate struct {
+    Synonyms []string          `json:"synonyms"`
+    Reindex  *index.ReindexJob `json:"reindex"`
+}
+
+// synonymsHandler kezeli az /api/admin/synonyms végpontot.
+// GET: az index jelenlegi szinonimalistája.
+// PUT: a lista cseréje; az index alias-alapú újraindexeléssel kapja meg az új szinonimákat,
+// az előrehaladás a GET /api/admin/reindex végponton követhető. A deleteOld=1 query
+// paraméterrel a váltás után a régi index törlődik.
+func (s *Server) synonymsHandler(w http.ResponseWriter, r *http.Request) {
+    switch r.Method {
+    case http.MethodGet:
+        synonyms, err := s.indexes.Synonyms(r.Context())
+        if err != nil {
+            writeUpstreamError(w, r, err, "Hiba a szinonimák lekérésekor")
+            slog.Error("Synonym fetch error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        if err := json.NewEncoder(w).Encode(SynonymList{Synonyms: synonyms}); err != nil {
+            slog.Error("Hiba a szinonima válasz kódolásakor", "error", err)
+        }
+    case http.MethodPut:
+        var list SynonymList
+        if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&list); err != nil || list.Synonyms == nil {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "A törzsnek {\"synonyms\": [...]} alakú JSON objektumnak kell lennie")
+            return
+        }
+        job, err := s.indexes.SetSynonyms(r.Context(), list.Synonyms, r.URL.Query().Get("deleteOld") == "1")
+        if errors.Is(err, index.ErrInvalidSynonyms) {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, err.Error())
+            return
+        }
+        if err != nil {
+            writeReindexStartError(w, r, err)
+            return
+        }
+        synonyms, _ := index.NormalizeSynonyms(list.Synonyms)
+        reqlog.Add(r.Context(), "synonyms", len(synonyms), "target", job.Target)
+        slog.Info("Synonym update started", "synonyms", len(synonyms), "target", job.Target)
+        w.Header().Set("Content-Type", "application/json")
+        w.WriteHeader(http.StatusAccepted)
+        if err := json.NewEncoder(w).Encode(SynonymUpdate{Synonyms: synonyms, Reindex: job}); err != nil {
+            slog.Error("Hiba a szinonima válasz kódolásakor", "error", err)
+        }
+    default:
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET és PUT kérés engedélyezett")
+    }
+}
@@ -0,0 +1,52 @@
+package httpapi
+
+import (
+    "crypto/tls"
+    "net"
+    "net/http"
+
+    "golang.org/x/crypto/acme/autocert"
+
+    "autocomplete/internal/config"
+)
+
+// publicTLSConfig a publikus szerver saját TLS lezárásának beállításait adja vissza: vagy
+// tanúsítványfájlokkal (TLS_CERT_FILE, TLS_KEY_FILE), vagy Let's Encrypt autocerttel az
+// AUTOCERT_HOSTS gépnevekre. Autocert esetén a managert is visszaadja, mert a redirect
+// listener (HTTP_REDIRECT_PORT) az ACME http-01 ellenőrzéseket is kiszolgálja. TLS nélkül
+// mindkettő nil.
+func publicTLSConfig(c config.ServerConfig) (*tls.Config, *autocert.Manager, error) {
+    if len(c.AutocertHosts) > 0 {
+        manager := &autocert.Manager{
+            Prompt:     autocert.AcceptTOS,
+            HostPolicy: autocert.HostWhitelist(c.AutocertHosts...),
+            Cache:      autocert.DirCache(c.AutocertCacheDir),
+            Email:      c.AutocertEmail,
+        }
+        tlsConfig := manager.TLSConfig()
+        tlsConfig.MinVersion = tls.VersionTLS12
+        return tlsConfig, manager, nil
+    }
+    if c.TLSCertFile != "" {
+        cert, err := tls.LoadX509KeyPair(c.TLSCertFile, c.TLSKeyFile)
+        if err != nil {
+            return nil, nil, err
+        }
+        return &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}, nil, nil
+    }
+    return nil, nil, nil
+}
+
+// httpsRedirectHandler minden kérést a port HTTPS listener azonos útvonalára irányít át.
+func httpsRedirectHandler(port string) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        host := r.Host
+        if h, _, err := net.SplitHostPort(host); err == nil {
+            host = h
+        }
+        if port != "443" {
+            host = net.JoinHostPort(host, port)
+        }
+        http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+    })
+}
@@ -0,0 +1,65 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "net/http"
+    "sort"
+
+    "autocomplete/internal/reqlog"
+)
+
+// capResponse a MaxResponseBytes szerint korlátozza a javaslatválasz méretét: ha a válasz JSON
+// alakja (a debug szöveg nélkül) nagyobb a korlátnál, a javaslatok listájának végéről annyit
+// hagy el, hogy beleférjen, a kiemeléseket, a javaslatobjektumokat és a Matched párokat is
+// ehhez igazítva. A csonkolást a meta blokk truncated mezője jelzi (kikapcsolt RESPONSE_META
+// mellett is). Az első javaslatot akkor is megtartja, ha egymagában túllépi a korlátot.
+func (s *Server) capResponse(r *http.Request, query string, response *SearchResult) {
+    limit := s.Options().MaxResponseBytes
+    if limit <= 0 || len(response.Suggestions) <= 1 {
+        return
+    }
+    size := func(n int) int {
+        capped := truncated(*response, n)
+        capped.Debug = ""
+        body, _ := json.Marshal(capped)
+        return len(body)
+    }
+    total := len(response.Suggestions)
+    if size(total) <= limit {
+        return
+    }
+    // A legnagyobb még beférő elemszám; a méret az elemszámmal monoton nő.
+    n := sort.Search(total, func(i int) bool { return size(i+1) > limit })
+    if n == 0 {
+        n = 1
+    }
+    *response = truncated(*response, n)
+    if response.Meta == nil {
+        response.Meta = &ResponseMeta{Query: query}
+    }
+    response.Meta.Truncated = true
+    reqlog.Add(r.Context(), "truncated", total-n)
+}
+
+// truncated a válasz másolata az első n javaslattal.
+func truncated(response SearchResult, n int) SearchResult {
+    dropped := response.Suggestions[n:]
+    response.Suggestions = response.Suggestions[:n]
+    if len(response.Highlights) > n {
+        response.Highlights = response.Highlights[:n]
+    }
+    if len(response.Items) > n {
+        response.Items = response.Items[:n]
+    }
+    if len(response.Matched) > 0 && len(dropped) > 0 {
+        matched := make(map[string]string, len(response.Matched))
+        for name, alias := range response.Matched {
+            matched[name] = alias
+        }
+        for _, name := range dropped {
+            delete(matched, name)
+        }
+        response.Matched = matched
+    }
+    return response
+}
@@ -0,0 +1,83 @@
+package httpapi
+
+import (
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "io"
+    "log/slog"
+    "net/http"
+    "strings"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+)
+
+// SignatureHeader a /api/admin/updates kötegének aláírása "sha256=<hex>" alakban: a nyers
+// törzs HMAC-SHA256 értéke az UPDATES_SECRET kulccsal.
+const SignatureHeader = "X-Signature-256"
+
+// maxUpdateBatchBytes egy változásköteg legnagyobb mérete.
+const maxUpdateBatchBytes = 32 << 20
+
+// validSignature ellenőrzi, hogy a header a body secret kulcsú HMAC-SHA256 aláírása-e.
+func validSignature(body []byte, header, secret string) bool {
+    sig, ok := strings.CutPrefix(header, "sha256=")
+    if !ok {
+        return false
+    }
+    got, err := hex.DecodeString(sig)
+    if err != nil {
+        return false
+    }
+    mac := hmac.New(sha256.New, []byte(secret))
+    mac.Write(body)
+    return hmac.Equal(got, mac.Sum(nil))
+}
+
+// updatesHandler kezeli a POST /api/admin/updates végpontot, amely a címregiszter
+// változásfolyamának aláírt kötegeit (add/update/delete műveletek, lásd index.UpdateBatch) a
+// _bulk API-val alkalmazza, így az index teljes újratöltés nélkül naprakész marad. Az aláírást
+// a SignatureHeader fejléc hordozza; UPDATES_SECRET nélkül a végpont 501-et ad. A válasz a
+// bulk betöltéshez hasonló összesítő.
+func (s *Server) updatesHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+        return
+    }
+    if s.updatesSecret == "" {
+        writeError(w, r, http.StatusNotImplemented, ErrCodeNotImplemented, "Az inkrementális frissítés nincs beállítva (UPDATES_SECRET)")
+        return
+    }
+    body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxUpdateBatchBytes))
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "A törzs nem olvasható, vagy túl nagy")
+        return
+    }
+    if !validSignature(body, r.Header.Get(SignatureHeader), s.updatesSecret) {
+        slog.Warn("Update batch rejected, invalid signature", "request_id", reqlog.RequestID(r.Context()))
+        writeError(w, r, http.StatusUnauthorized, ErrCodeUnauthorized, "Érvénytelen vagy hiányzó aláírás ("+SignatureHeader+")")
+        return
+    }
+    var batch index.UpdateBatch
+    if err := json.Unmarshal(body, &batch); err != nil || batch.Operations == nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "A törzsnek {\"operations\": [...]} alakú JSON objektumnak kell lennie")
+        return
+    }
+
+    summary, err := s.indexes.ApplyUpdates(r.Context(), batch.Operations)
+    status := http.StatusOK
+    if err != nil {
+        slog.Error("Update batch error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        summary.Error = err.Error()
+        status = http.StatusBadGateway
+    }
+    reqlog.Add(r.Context(), "operations", summary.Total, "indexed", summary.Indexed, "deleted", summary.Deleted, "failed", summary.Failed)
+    slog.Info("Update batch applied", "operations", summary.Total, "indexed", summary.Indexed, "deleted", summary.Deleted, "failed", summary.Failed)
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    if err := json.NewEncoder(w).Encode(summary); err != nil {
+        slog.Error("Hiba a frissítés válasz kódolásakor", "error", err)
+    }
+}
@@ -0,0 +1,133 @@
+package httpapi
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strings"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// BatchValidationRow a kötegelt ellenőrzés egy sora; hiba esetén a Result helyett az Error van kitöltve.
+type BatchValidationRow struct {
+    Input  suggest.AddressInput       `json:"input"`
+    Result *suggest.AddressValidation `json:"result,omitempty"`
+    Error  *APIError                  `json:"error,omitempty"`
+}
+
+// BatchValidationResult a POST /api/validate/batch válasza, a sorok a kérés sorrendjében.
+type BatchValidationResult struct {
+    Results []BatchValidationRow `json:"results"`
+    Valid   int                  `json:"valid"`
+    Invalid int                  `json:"invalid"`
+    Failed  int                  `json:"failed"`
+}
+
+// batchValidationResult a háttérrendszer soronkénti eredményeiből összesíti a választ.
+func batchValidationResult(ctx context.Context, rows []suggest.BatchValidation) BatchValidationResult {
+    result := BatchValidationResult{Results: make([]BatchValidationRow, len(rows))}
+    for i, row := range rows {
+        out := BatchValidationRow{Input: row.Input}
+        if row.Err != nil {
+            out.Error = &APIError{Code: ErrCodeUpstream, Message: localize(ctx, "A sor ellenőrzése sikertelen")}
+            result.Failed++
+        } else {
+            v := row.Result
+            out.Result = &v
+            if v.Valid {
+                result.Valid++
+            } else {
+                result.Invalid++
+            }
+        }
+        result.Results[i] = out
+    }
+    return result
+}
+
+// validateBatchHandler kezeli a POST /api/validate/batch végpontot. A törzs AddressInput
+// elemek JSON tömbje, legfeljebb ValidateBatchMax elemmel (VALIDATE_BATCH_MAX).
+func (s *Server) validateBatchHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+        return
+    }
+    var inputs []suggest.AddressInput
+    if err := json.NewDecoder(r.Body).Decode(&inputs); err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, "A törzsnek címek JSON tömbjének kell lennie")
+        return
+    }
+    max := s.Options().ValidateBatchMax
+    if len(inputs) == 0 || len(inputs) > max {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, fmt.Sprintf("1 és %d közötti számú cím adható meg", max))
+        return
+    }
+    for i, in := range inputs {
+        if strings.TrimSpace(in.Telepules) == "" {
+            writeError(w, r, http.StatusBadRequest, ErrCodeInvalidBody, fmt.Sprintf("Hiányzó 'telepules' a(z) %d. sorban", i+1))
+            return
+        }
+    }
+    validator, ok := s.suggester.(suggest.BatchValidator)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    rows, err := validator.ValidateBatch(r.Context(), inputs)
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a címek ellenőrzésekor")
+        slog.Error("Batch validation error", "request_id", reqlog.RequestID(r.Context()), "rows", len(inputs), "error", err)
+        return
+    }
+    result := batchValidationResult(r.Context(), rows)
+    reqlog.Add(r.Context(), "rows", len(inputs), "valid", result.Valid, "invalid", result.Invalid, "failed", result.Failed)
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(result); err != nil {
+        slog.Error("Hiba a kötegelt ellenőrzés válaszának kódolásakor", "error", err)
+    }
+}
+
+// validateAddressHandler kezeli a GET /api/validate/address végpontot, amely a "telepules",
+// "kozter_nev" és "hazszam" paraméterekkel megadott házszámos címet a közterület-szakaszok
+// házszámtartományai alapján (páros/páratlan oldal szerint is) ellenőrzi, és érvényes cím
+// esetén a szakasz irányítószámát adja vissza. A házszám betűjele és albetétje ("12/A") nem számít.
+func (s *Server) validateAddressHandler(w http.ResponseWriter, r *http.Request) {
+    validator, ok := s.suggester.(suggest.HouseNumberValidator)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    if r.Method != http.MethodGet {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+        return
+    }
+    params := r.URL.Query()
+    in := suggest.HouseNumberInput{Telepules: params.Get("telepules"), KozterNev: params.Get("kozter_nev"), Hazszam: params.Get("hazszam")}
+    for _, p := range []struct{ name, value string }{{"telepules", in.Telepules}, {"kozter_nev", in.KozterNev}, {"hazszam", in.Hazszam}} {
+        if strings.TrimSpace(p.value) == "" {
+            writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, fmt.Sprintf("Hiányzó '%s' paraméter", p.name))
+            return
+        }
+    }
+    result, err := validator.ValidateHouseNumber(r.Context(), in)
+    if errors.Is(err, index.ErrInvalidHouseNumber) {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a cím ellenőrzésekor")
+        slog.Error("House number validation error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "valid", result.Valid, "irsz", result.Irsz)
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(result); err != nil {
+        slog.Error("Hiba a címellenőrzés válaszának kódolásakor", "error", err)
+    }
+}
@@ -0,0 +1,32 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "log/slog"
+    "net/http"
+
+    "autocomplete/internal/reqlog"
+)
+
+// addressCountWeightsHandler kezeli a POST /api/admin/weights/address-count végpontot: a
+// lakosságszám nélkül betöltött indexben a települések címszámát írja a dokumentumok súlyába
+// (lásd index.Manager.AddressCountWeights), hogy a WEIGHT_BOOST a nagyobb településeket
+// előresorolja. A javaslat-gyorsítótár régi sorrendjei a CACHE_TTL lejártáig megmaradhatnak.
+func (s *Server) addressCountWeightsHandler(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak POST kérés engedélyezett")
+        return
+    }
+    summary, err := s.indexes.AddressCountWeights(r.Context())
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a súlyok kiszámításakor")
+        slog.Error("Address count weights error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "settlements", summary.Settlements, "updated", summary.Updated)
+    slog.Info("Address count weights written", "settlements", summary.Settlements, "updated", summary.Updated)
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(summary); err != nil {
+        slog.Error("Hiba a súlyozási válasz kódolásakor", "error", err)
+    }
+}
@@ -0,0 +1,84 @@
+package httpapi
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "embed"
+    "encoding/hex"
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "strings"
+    "text/template"
+)
+
+// widgetFiles a beágyazható widget forrásai: a közös mag (core.js) és a két kiadás, a
+// szelektorral csatolható widget.js és a <address-autocomplete> web component (element.js).
+//
+//go:embed widget
+var widgetFiles embed.FS
+
+// widgetMaxAge a /widget.js válaszok gyorsítótárazási ideje másodpercben; a verzióváltást az
+// ETag jelzi a lejárat után.
+const widgetMaxAge = 3600
+
+var (
+    widgetTemplates = template.Must(template.ParseFS(widgetFiles, "widget/*.js"))
+    // widgetVersion a widget forrásainak lenyomata; a szkriptbe (AddressAutocomplete.version) és
+    // az X-Widget-Version fejlécbe kerül.
+    widgetVersion = assetVersion(mustSub(widgetFiles, "widget"), "core.js", "widget.js", "element.js")
+)
+
+// widgetScript a widget sablon paraméterei; a JS-be kerülő értékek JSON literálok.
+type widgetScript struct {
+    Defaults    string
+    BasePath    string
+    Version     string
+    VersionText string
+}
+
+// widgetHandler a name sablonból előállított widget szkriptet szolgálja ki (GET /widget.js,
+// /widget-element.js). Az alapértékek (minimális lekérdezéshossz, debounce) a futó
+// konfigurációból kerülnek bele; az ETag a kimenet lenyomata, így a böngészők feltételes
+// kéréssel ellenőrzik a frissességet.
+func (s *Server) widgetHandler(name string) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodGet && r.Method != http.MethodHead {
+            writeError(w, r, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Csak GET kérés engedélyezett")
+            return
+        }
+        defaults, _ := json.Marshal(map[string]interface{}{
+            "kind":     "settlement",
+            "minChars": s.Options().MinQueryLength,
+            "debounce": s.widget.Debounce.Milliseconds(),
+        })
+        basePath, _ := json.Marshal(strings.TrimRight(s.demo.APIBasePath, "/"))
+        version, _ := json.Marshal(widgetVersion)
+        var body bytes.Buffer
+        err := widgetTemplates.ExecuteTemplate(&body, name, widgetScript{
+            Defaults:    string(defaults),
+            BasePath:    string(basePath),
+            Version:     string(version),
+            VersionText: widgetVersion,
+        })
+        if err != nil {
+            slog.Error("Hiba a widget előállításakor", "template", name, "error", err)
+            http.Error(w, "Hiba a widget előállításakor", http.StatusInternalServerError)
+            return
+        }
+        sum := sha256.Sum256(body.Bytes())
+        etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+        w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+        w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(widgetMaxAge))
+        w.Header().Set("ETag", etag)
+        w.Header().Set("X-Widget-Version", widgetVersion)
+        // A szkriptet bármely oldal betöltheti.
+        w.Header().Set("Access-Control-Allow-Origin", "*")
+        if etagMatches(r.Header.Get("If-None-Match"), etag) {
+            w.WriteHeader(http.StatusNotModified)
+            return
+        }
+        w.Write(body.Bytes())
+    }
+}
@@ -0,0 +1,183 @@
+{{define "core"}}
+    // A szerver által beállított alapértékek (lásd widgetHandler) és a widget verziója.
+    var DEFAULTS = {{.Defaults}};
+    var VERSION = {{.Version}};
+    var ENDPOINTS = {
+        settlement: '/api/autocomplete',
+        street: '/api/autocomplete/street',
+        address: '/api/autocomplete/address'
+    };
+
+    // Az API címe a betöltő <script> forrásából adódik, így a widget bármely oldalba beilleszthető.
+    var script = document.currentScript;
+    var apiBase = (script ? new URL(script.src, location.href).origin : '') + {{.BasePath}};
+
+    var styleInjected = false;
+    function injectStyle() {
+        if (styleInjected) return;
+        styleInjected = true;
+        var style = document.createElement('style');
+        style.textContent =
+            '.aac-list{position:absolute;z-index:1000;list-style:none;margin:0;padding:0;background:#fff;border:1px solid #ccc;border-radius:4px;box-shadow:0 2px 6px rgba(0,0,0,.15);max-height:280px;overflow-y:auto}' +
+            '.aac-list[hidden]{display:none}' +
+            '.aac-item{padding:6px 10px;cursor:pointer}' +
+            '.aac-item.aac-active,.aac-item:hover{background:#e0e0e0}';
+        document.head.appendChild(style);
+    }
+
+    var counter = 0;
+
+    // Autocomplete egy <input> mezőt köt az API-hoz: késleltetett (debounce) lekérdezés, a
+    // folyamatban lévő kérés megszakítása, nyíl/Enter/Escape billentyűkezelés és ARIA combobox.
+    function Autocomplete(input, options) {
+        var opts = {};
+        for (var key in DEFAULTS) opts[key] = DEFAULTS[key];
+        for (var key2 in options || {}) if (options[key2] !== undefined && options[key2] !== null) opts[key2] = options[key2];
+        if (!ENDPOINTS[opts.kind]) throw new Error('Ismeretlen javaslattípus: ' + opts.kind);
+
+        injectStyle();
+        var list = document.createElement('ul');
+        var listId = 'aac-list-' + (++counter);
+        list.className = 'aac-list';
+        list.id = listId;
+        list.setAttribute('role', 'listbox');
+        list.hidden = true;
+        document.body.appendChild(list);
+        input.setAttribute('role', 'combobox');
+        input.setAttribute('aria-autocomplete', 'list');
+        input.setAttribute('aria-controls', listId);
+        input.setAttribute('aria-expanded', 'false');
+        input.setAttribute('autocomplete', 'off');
+
+        var items = [];
+        var active = -1;
+        var timer = null;
+        var controller = null;
+
+        function position() {
+            var rect = input.getBoundingClientRect();
+            list.style.left = (rect.left + window.scrollX) + 'px';
+            list.style.top = (rect.bottom + window.scrollY) + 'px';
+            list.style.minWidth = rect.width + 'px';
+        }
+
+        function close() {
+            list.hidden = true;
+            list.innerHTML = '';
+            items = [];
+            active = -1;
+            input.setAttribute('aria-expanded', 'false');
+            input.removeAttribute('aria-activedescendant');
+        }
+
+        function highlight(index) {
+            var children = list.children;
+            if (active >= 0 && children[active]) children[active].classList.remove('aac-active');
+            active = index;
+            if (active >= 0 && children[active]) {
+                children[active].classList.add('aac-active');
+                children[active].scrollIntoView({block: 'nearest'});
+                input.setAttribute('aria-activedescendant', children[active].id);
+            } else {
+                input.removeAttribute('aria-activedescendant');
+            }
+        }
+
+        function select(index) {
+            if (index < 0 || index >= items.length) return;
+            input.value = items[index];
+            close();
+            input.dispatchEvent(new CustomEvent('autocomplete:select', {bubbles: true, detail: {value: input.value, kind: opts.kind}}));
+        }
+
+        function render(suggestions) {
+            list.innerHTML = '';
+            items = suggestions;
+            active = -1;
+            if (!suggestions.length) {
+                close();
+                return;
+            }
+            suggestions.forEach(function (value, i) {
+                var li = document.createElement('li');
+                li.className = 'aac-item';
+                li.id = listId + '-' + i;
+                li.setAttribute('role', 'option');
+                li.textContent = value;
+                li.addEventListener('mousedown', function (e) {
+                    e.preventDefault();
+                    select(i);
+                });
+                list.appendChild(li);
+            });
+            position();
+            list.hidden = false;
+            input.setAttribute('aria-expanded', 'true');
+        }
+
+        function query() {
+            var q = input.value.trim();
+            if (controller) controller.abort();
+            if (q.length < opts.minChars) {
+                close();
+                return;
+            }
+            var params = new URLSearchParams({q: q});
+            if (opts.kind === 'street' && opts.settlementInput) {
+                var settlement = document.querySelector(opts.settlementInput);
+                if (settlement && settlement.value.trim()) params.set('telepules', settlement.value.trim());
+            }
+            controller = new AbortController();
+            fetch(apiBase + ENDPOINTS[opts.kind] + '?' + params, {signal: controller.signal})
+                .then(function (response) {
+                    if (!response.ok) throw new Error('HTTP ' + response.status);
+                    return response.json();
+                })
+                .then(function (data) {
+                    if (document.activeElement === input) render(data.suggestions || []);
+                })
+                .catch(function (err) {
+                    if (err.name !== 'AbortError') close();
+                });
+        }
+
+        input.addEventListener('input', function () {
+            clearTimeout(timer);
+            timer = setTimeout(query, opts.debounce);
+        });
+        input.addEventListener('keydown', function (e) {
+            if (list.hidden) return;
+            switch (e.key) {
+            case 'ArrowDown':
+                e.preventDefault();
+                highlight((active + 1) % items.length);
+                break;
+            case 'ArrowUp':
+                e.preventDefault();
+                highlight(active <= 0 ? items.length - 1 : active - 1);
+                break;
+            case 'Enter':
+                if (active >= 0) {
+                    e.preventDefault();
+                    select(active);
+                }
+                break;
+            case 'Escape':
+                close();
+                break;
+            }
+        });
+        input.addEventListener('blur', close);
+        window.addEventListener('resize', function () {
+            if (!list.hidden) position();
+        });
+
+        this.input = input;
+        this.close = close;
+        this.destroy = function () {
+            clearTimeout(timer);
+            if (controller) controller.abort();
+            list.remove();
+        };
+    }
+{{end}}
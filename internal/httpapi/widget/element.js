@@ -0,0 +1,54 @@
+/*! Cím autocomplete web component {{.VersionText}}
+ *
+ * Használat:
+ *   <script src="https://AUTOCOMPLETE_HOST/widget-element.js"></script>
+ *   <address-autocomplete kind="street" name="utca" placeholder="Utca" settlement-input="#telepules"></address-autocomplete>
+ *
+ * Attribútumok: kind (settlement, street, address), min-chars, debounce (ms), settlement-input,
+ * valamint a belső <input>-ra átkerülő name, placeholder és required. Az elem value tulajdonsága
+ * a mező értéke; a kiválasztásról "autocomplete:select" esemény érkezik.
+ */
+(function () {
+    'use strict';
+{{template "core" .}}
+    if (!window.customElements || customElements.get('address-autocomplete')) return;
+
+    class AddressAutocompleteElement extends HTMLElement {
+        connectedCallback() {
+            if (this._widget) return;
+            var input = document.createElement('input');
+            input.type = 'text';
+            ['name', 'placeholder', 'required'].forEach(function (attr) {
+                if (this.hasAttribute(attr)) input.setAttribute(attr, this.getAttribute(attr));
+            }, this);
+            this.appendChild(input);
+            var num = function (value) {
+                return value ? parseInt(value, 10) : undefined;
+            };
+            this._widget = new Autocomplete(input, {
+                kind: this.getAttribute('kind') || undefined,
+                minChars: num(this.getAttribute('min-chars')),
+                debounce: num(this.getAttribute('debounce')),
+                settlementInput: this.getAttribute('settlement-input') || undefined
+            });
+        }
+
+        disconnectedCallback() {
+            if (this._widget) {
+                this._widget.destroy();
+                this._widget.input.remove();
+                this._widget = null;
+            }
+        }
+
+        get value() {
+            return this._widget ? this._widget.input.value : '';
+        }
+
+        set value(v) {
+            if (this._widget) this._widget.input.value = v;
+        }
+    }
+
+    customElements.define('address-autocomplete', AddressAutocompleteElement);
+})();
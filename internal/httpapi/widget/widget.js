@@ -0,0 +1,41 @@
+/*! Cím autocomplete widget {{.VersionText}}
+ *
+ * Használat:
+ *   <input id="cim">
+ *   <script src="https://AUTOCOMPLETE_HOST/widget.js" data-target="#cim" data-kind="settlement"></script>
+ *
+ * vagy programból: AddressAutocomplete.attach('#cim', {kind: 'street', settlementInput: '#telepules'}).
+ * Beállítások (data-* attribútumként is): kind (settlement, street, address), minChars, debounce (ms),
+ * settlementInput (street esetén a települést tartalmazó mező szelektora). A kiválasztásról az
+ * input "autocomplete:select" eseményt küld.
+ */
+(function () {
+    'use strict';
+{{template "core" .}}
+    function attach(target, options) {
+        var inputs = typeof target === 'string' ? document.querySelectorAll(target) : [target];
+        return Array.prototype.map.call(inputs, function (input) {
+            return new Autocomplete(input, options);
+        });
+    }
+
+    window.AddressAutocomplete = {attach: attach, version: VERSION};
+
+    if (script && script.dataset.target) {
+        var data = script.dataset;
+        var options = {
+            kind: data.kind,
+            minChars: data.minChars ? parseInt(data.minChars, 10) : undefined,
+            debounce: data.debounce ? parseInt(data.debounce, 10) : undefined,
+            settlementInput: data.settlementInput
+        };
+        var run = function () {
+            attach(data.target, options);
+        };
+        if (document.readyState === 'loading') {
+            document.addEventListener('DOMContentLoaded', run);
+        } else {
+            run();
+        }
+    }
+})();
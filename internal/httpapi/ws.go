@@ -0,0 +1,192 @@
+package httpapi
+
+import (
+    "context"
+    "log/slog"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "golang.org/x/net/websocket"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// WebSocketIdleTimeout után zárjuk a kapcsolatot, ha a kliens nem küldött üzenetet.
+var WebSocketIdleTimeout = 2 * time.Minute
+
+// wsRequest a kliens egy leütésének megfelelő üzenet. A Type a javaslat fajtája:
+// "settlement" (alapértelmezés), "street", "address" vagy "zip"; az ID-t a válasz visszaküldi.
+// A Highlight a HTTP végpontok "highlight" paraméterének megfelelője (offsets vagy html).
+type wsRequest struct {
+    ID        int64  `json:"id"`
+    Type      string `json:"type"`
+    Q         string `json:"q"`
+    Megye     string `json:"megye,omitempty"`
+    Telepules string `json:"telepules,omitempty"`
+    Highlight string `json:"highlight,omitempty"`
+}
+
+// wsResponse a szerver válasza egy wsRequest-re; hiba esetén csak az Error mező van kitöltve.
+type wsResponse struct {
+    ID          int64               `json:"id"`
+    Q           string              `json:"q"`
+    Suggestions []string            `json:"suggestions"`
+    Fuzzy       bool                `json:"fuzzy,omitempty"`
+    Stale       bool                `json:"stale,omitempty"`
+    Matched     map[string]string   `json:"matched,omitempty"`
+    Highlights  []suggest.Highlight `json:"highlights,omitempty"`
+    Hint        string              `json:"hint,omitempty"`
+    Error       *APIError           `json:"error,omitempty"`
+}
+
+// closeWebSockets lezárja az összes nyitott WebSocket kapcsolatot.
+func (s *Server) closeWebSockets() {
+    s.wsMu.Lock()
+    defer s.wsMu.Unlock()
+    for conn := range s.wsConns {
+        conn.Close()
+    }
+}
+
+// wsSuggest a kérés típusa szerinti javaslatot kéri le a háttérrendszertől. A MIN_QUERY_LEN-nél
+// rövidebb lekérdezésre (az irányítószám kivételével) háttérkérés nélkül üres eredményt és hint-et ad.
+func (s *Server) wsSuggest(ctx context.Context, req wsRequest) (suggest.Set, string, error) {
+    switch req.Type {
+    case "", suggest.KindSettlement, suggest.KindStreet, suggest.KindAddress, suggest.KindZip:
+    default:
+        return suggest.Set{}, "", errUnknownSuggestType
+    }
+    if _, err := highlightMode(req.Highlight); err != nil {
+        return suggest.Set{}, "", &APIError{Code: ErrCodeInvalidParameter, Message: err.Error()}
+    }
+    hint, err := s.queryLength(ctx, req.Q)
+    if err != nil {
+        return suggest.Set{}, "", &APIError{Code: ErrCodeInvalidParameter, Message: err.Error()}
+    }
+    if hint != "" && req.Type != suggest.KindZip {
+        return suggest.Set{}, hint, nil
+    }
+    set, _, err := s.suggester.Suggest(ctx, suggest.Request{Kind: req.Type, Query: req.Q, Megye: req.Megye, Telepules: req.Telepules, Sort: s.Options().DefaultSort})
+    return set, "", err
+}
+
+var errUnknownSuggestType = &APIError{Code: ErrCodeInvalidParameter, Message: "Ismeretlen 'type': settlement, street, address vagy zip lehet"}
+
+// wsAutocompleteHandler kezeli az /api/autocomplete/ws végpontot. A kliens minden leütésnél
+// egy wsRequest JSON üzenetet küld; az új üzenet megszakítja az előző, még futó OpenSearch
+// lekérdezést, és csak a legutolsó kérésre érkezik javaslat, így a régebbi válaszok nem
+// írhatják felül a frissebbeket. Az üzenetek a HTTP kérésekkel közös IP alapú korlátba számítanak.
+func (s *Server) wsAutocompleteHandler(w http.ResponseWriter, r *http.Request) {
+    server := websocket.Server{
+        // Nyilvános, hitelesítés nélküli API: az Origin fejlécet nem korlátozzuk.
+        Handshake: func(*websocket.Config, *http.Request) error { return nil },
+        Handler:   func(conn *websocket.Conn) { s.serveSuggestionStream(r, conn) },
+    }
+    server.ServeHTTP(w, r)
+}
+
+func (s *Server) serveSuggestionStream(r *http.Request, conn *websocket.Conn) {
+    conn.MaxPayloadBytes = 4096
+    s.wsMu.Lock()
+    s.wsConns[conn] = struct{}{}
+    s.wsMu.Unlock()
+    defer func() {
+        s.wsMu.Lock()
+        delete(s.wsConns, conn)
+        s.wsMu.Unlock()
+        conn.Close()
+    }()
+
+    ip := s.clientIP(r)
+    // A kérés kontextusa a kapcsolat átvétele után is él, és hordozza a request ID-t.
+    ctx, cancelAll := context.WithCancel(context.WithoutCancel(r.Context()))
+    defer cancelAll()
+
+    var (
+        mu       sync.Mutex
+        latest   int64
+        cancel   context.CancelFunc = func() {}
+        inflight sync.WaitGroup
+        messages int
+    )
+    send := func(resp wsResponse) {
+        mu.Lock()
+        defer mu.Unlock()
+        if resp.ID != latest {
+            return
+        }
+        if resp.Error != nil {
+            apiErr := *resp.Error
+            apiErr.Message = localize(ctx, apiErr.Message)
+            resp.Error = &apiErr
+        }
+        conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+        if err := websocket.JSON.Send(conn, resp); err != nil {
+            slog.Debug("WebSocket send failed", "request_id", reqlog.RequestID(ctx), "error", err)
+        }
+    }
+    defer func() {
+        inflight.Wait()
+        reqlog.Add(r.Context(), "ws_messages", messages)
+    }()
+
+    for {
+        conn.SetReadDeadline(time.Now().Add(WebSocketIdleTimeout))
+        var req wsRequest
+        if err := websocket.JSON.Receive(conn, &req); err != nil {
+            return
+        }
+        messages++
+        mu.Lock()
+        cancel()
+        latest = req.ID
+        queryCtx, cancelQuery := context.WithCancel(ctx)
+        cancel = cancelQuery
+        mu.Unlock()
+
+        req.Q = strings.TrimSpace(req.Q)
+        if req.Q == "" {
+            send(wsResponse{ID: req.ID, Suggestions: []string{}})
+            continue
+        }
+        if s.Options().RateLimitRPS > 0 {
+            if ok, _ := s.limiter.Allow(ip); !ok {
+                send(wsResponse{ID: req.ID, Q: req.Q, Error: &APIError{Code: ErrCodeRateLimited, Message: "Túl sok kérés, próbáld újra később"}})
+                continue
+            }
+        }
+
+        inflight.Add(1)
+        go func(req wsRequest) {
+            defer inflight.Done()
+            started := restartExperimentClock(queryCtx)
+            set, hint, err := s.wsSuggest(started, req)
+            if queryCtx.Err() != nil {
+                // Egy újabb leütés már felváltotta ezt a kérést.
+                return
+            }
+            if err != nil {
+                apiErr, ok := err.(*APIError)
+                if !ok {
+                    slog.Error("WebSocket autocomplete error", "request_id", reqlog.RequestID(ctx), "query", req.Q, "error", err)
+                    e, _ := upstreamAPIError(err, "Hiba a javaslatok lekérésekor")
+                    apiErr = &e
+                }
+                send(wsResponse{ID: req.ID, Q: req.Q, Error: apiErr})
+                return
+            }
+            if hint == "" {
+                s.recordQuery(started, req.Type, req.Q, set)
+            }
+            suggestions := set.Suggestions
+            if suggestions == nil {
+                suggestions = []string{}
+            }
+            send(wsResponse{ID: req.ID, Q: req.Q, Suggestions: suggestions, Fuzzy: set.Fuzzy, Stale: set.Stale, Matched: set.Matched,
+                Highlights: highlights(suggestions, req.Q, req.Highlight), Hint: hint})
+        }(req)
+    }
+}
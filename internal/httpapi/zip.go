@@ -0,0 +1,88 @@
+package httpapi
+
+import (
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "strings"
+
+    "autocomplete/internal/reqlog"
+    "autocomplete/internal/suggest"
+)
+
+// ZipLookupResult az /api/zip/{code} végpont válasza: az irányítószámhoz tartozó települések.
+type ZipLookupResult struct {
+    Zip         string   `json:"zip"`
+    Settlements []string `json:"settlements"`
+    Debug       string   `json:"debug,omitempty"`
+}
+
+// zipFormats az irányítószám-javaslatok válaszformátumai (lásd responseFormat).
+var zipFormats = []string{formatJSON, formatCSV, formatMsgPack}
+
+// zipAutocompleteHandler kezeli az /api/autocomplete/zip végpontot; a válasz JSON, CSV vagy
+// MessagePack lehet (lásd responseFormat).
+func (s *Server) zipAutocompleteHandler(w http.ResponseWriter, r *http.Request) {
+    query := strings.TrimSpace(r.URL.Query().Get("q"))
+    if query == "" {
+        writeError(w, r, http.StatusBadRequest, ErrCodeMissingParameter, "Hiányzó 'q' paraméter")
+        return
+    }
+    if !suggest.IsZipPrefix(query) {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "A 'q' paraméter legfeljebb 4 számjegy lehet")
+        return
+    }
+    format, err := s.suggestionResponseFormat(r, zipFormats...)
+    if err != nil {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, err.Error())
+        return
+    }
+    set, debugInfo, err := s.suggester.Suggest(r.Context(), suggest.Request{Kind: suggest.KindZip, Query: query, Sort: s.Options().DefaultSort})
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba a javaslatok lekérésekor")
+        slog.Error("Zip autocomplete error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "query", query, "result_count", len(set.Suggestions))
+    s.recordQuery(r.Context(), suggest.KindZip, query, set)
+    response := SearchResult{Suggestions: set.Suggestions}
+    if s.debugRequested(r) {
+        response.Debug = localize(r.Context(), debugInfo)
+    }
+    response.Meta = responseMeta(r, query)
+    s.capResponse(r, query, &response)
+    s.writeSuggestionResponse(w, r, response, format, response.Debug == "" && response.Meta == nil)
+}
+
+// zipLookupHandler kezeli az /api/zip/{code} végpontot.
+func (s *Server) zipLookupHandler(w http.ResponseWriter, r *http.Request) {
+    zip := strings.TrimPrefix(r.URL.Path, "/api/zip/")
+    if len(zip) != 4 || !suggest.IsZipPrefix(zip) {
+        writeError(w, r, http.StatusBadRequest, ErrCodeInvalidParameter, "Érvénytelen irányítószám")
+        return
+    }
+    resolver, ok := s.suggester.(suggest.ZipResolver)
+    if !ok {
+        writeNotImplemented(w, r)
+        return
+    }
+    settlements, debugInfo, err := resolver.ZipSettlements(r.Context(), zip)
+    if err != nil {
+        writeUpstreamError(w, r, err, "Hiba az irányítószám feloldásakor")
+        slog.Error("Zip lookup error", "request_id", reqlog.RequestID(r.Context()), "error", err)
+        return
+    }
+    reqlog.Add(r.Context(), "query", zip, "result_count", len(settlements))
+    if len(settlements) == 0 {
+        writeError(w, r, http.StatusNotFound, ErrCodeNotFound, "Ismeretlen irányítószám")
+        return
+    }
+    response := ZipLookupResult{Zip: zip, Settlements: settlements}
+    if s.debugRequested(r) {
+        response.Debug = localize(r.Context(), debugInfo)
+    }
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(response); err != nil {
+        slog.Error("Hiba a válasz kódolásakor", "error", err)
+    }
+}
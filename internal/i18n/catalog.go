@@ -0,0 +1,212 @@
+package i18n
+
+// english a katalógus: a magyar üzenetek angol fordítása. A magyar szövegben a %s helyére
+// tetszőleges (változatlanul átvett) szöveg illeszkedik, a %v helyére egy másik, szintén
+// fordítandó üzenet (pl. a becsomagolt hiba szövege); az angol szöveg a helyőrzőket ugyanabban a
+// sorrendben tartalmazza. A pontos egyezésű bejegyzések elsőbbséget élveznek, a paraméteresek
+// közül az első illeszkedő érvényes.
+var english = []struct{ hu, en string }{
+    // Metódusok, hitelesítés, terhelés.
+    {"Csak GET kérés engedélyezett", "Only GET requests are allowed"},
+    {"Csak POST kérés engedélyezett", "Only POST requests are allowed"},
+    {"Csak GET és POST kérés engedélyezett", "Only GET and POST requests are allowed"},
+    {"Csak GET és PUT kérés engedélyezett", "Only GET and PUT requests are allowed"},
+    {"Csak POST, PUT és DELETE kérés engedélyezett", "Only POST, PUT and DELETE requests are allowed"},
+    {"Érvénytelen vagy hiányzó admin token", "Invalid or missing admin token"},
+    {"Érvénytelen vagy hiányzó aláírás (%s)", "Invalid or missing signature (%s)"},
+    {"Túl sok kérés, próbáld újra később", "Too many requests, please try again later"},
+    {"A szolgáltatás túlterhelt, próbálja újra később", "The service is overloaded, please try again later"},
+    {"Az adatbázis túlterhelt, próbálja újra később", "The database is overloaded, please try again later"},
+    {"Az adatbázis átmenetileg nem elérhető", "The database is temporarily unavailable"},
+    {"A keresési háttérrendszer nem elérhető", "The search backend is unavailable"},
+    {"A keresési háttérrendszer nem támogatja ezt a végpontot", "The search backend does not support this endpoint"},
+    {"A gyorsítótár előmelegítése folyamatban", "Cache warm-up in progress"},
+
+    // Paraméterek.
+    {"Hiányzó 'q' paraméter", "Missing 'q' parameter"},
+    {"Hiányzó 'telepules' paraméter", "Missing 'telepules' parameter"},
+    {"Hiányzó 'lat' és 'lon' paraméter", "Missing 'lat' and 'lon' parameters"},
+    {"Hiányzó '%s' paraméter", "Missing '%s' parameter"},
+    {"Hiányzó 'query'", "Missing 'query'"},
+    {"Hiányzó 'address' mező", "Missing 'address' field"},
+    {"Hiányzó 'file' mező", "Missing 'file' field"},
+    {"Hiányzó vagy érvénytelen dokumentum azonosító", "Missing or invalid document ID"},
+    {"Hiányzó 'telepules' a(z) %s. sorban", "Missing 'telepules' in row %s"},
+    {"Hibás 'variables' paraméter", "Invalid 'variables' parameter"},
+    {"Hibás 'mapping' paraméter: %v", "Invalid 'mapping' parameter: %v"},
+    {"Hibás JSON törzs", "Invalid JSON body"},
+    {"Multipart kérés szükséges", "A multipart request is required"},
+    {"A 'q' paraméter legfeljebb 4 számjegy lehet", "The 'q' parameter can be at most 4 digits"},
+    {"a 'q' paraméter legfeljebb %s karakter lehet", "the 'q' parameter can be at most %s characters"},
+    {"Legalább %s karakter szükséges a javaslatokhoz", "At least %s characters are required for suggestions"},
+    {"a 'limit' paraméter 1 és %s közötti egész szám lehet", "the 'limit' parameter must be an integer between 1 and %s"},
+    {"a '%s' paraméter 0 és %s közötti egész szám lehet", "the '%s' parameter must be an integer between 0 and %s"},
+    {"A 'limit' 1 és %s közötti egész szám lehet", "'limit' must be an integer between 1 and %s"},
+    {"Az 'after' értéke egy korábbi válasz 'next' kurzora lehet", "'after' must be the 'next' cursor of a previous response"},
+    {"A 'window' pozitív időtartam lehet (pl. 1h), legfeljebb %s", "'window' must be a positive duration (e.g. 1h), at most %s"},
+    {"A 'samples' paraméter 0 és 100 közötti egész szám lehet", "The 'samples' parameter must be an integer between 0 and 100"},
+    {"a 'format' értéke %s lehet", "'format' must be one of %s"},
+    {"A 'format' paraméter értéke 'csv' vagy 'ndjson' lehet", "The 'format' parameter must be 'csv' or 'ndjson'"},
+    {"a 'sort' értéke %s, %s vagy %s lehet", "'sort' must be %s, %s or %s"},
+    {"ismeretlen mező a 'fields' paraméterben: %s (választható: %s)", "unknown field in 'fields': %s (available: %s)"},
+    {"a 'highlight' értéke %s vagy %s lehet", "'highlight' must be %s or %s"},
+    {"A 'kind' értéke settlement, street vagy address lehet", "'kind' must be settlement, street or address"},
+    {"A 'kind' értéke settlement, street, address vagy zip lehet", "'kind' must be settlement, street, address or zip"},
+    {"Ismeretlen 'type': settlement, street, address vagy zip lehet", "Unknown 'type': must be settlement, street, address or zip"},
+    {"A 'mode' paraméter értéke 'reindex' vagy 'import' lehet", "The 'mode' parameter must be 'reindex' or 'import'"},
+    {"A 'delimiter' paraméter egyetlen karakter lehet", "The 'delimiter' parameter must be a single character"},
+    {"Az 'index' paraméter csak a folyamatban lévő újraindexelés célindexe lehet", "The 'index' parameter can only be the target index of the reindex in progress"},
+    {"Az 'address' legfeljebb %s karakter lehet", "'address' can be at most %s characters"},
+    {"A 'value' mező kötelező, legfeljebb 200 bájt", "The 'value' field is required, at most 200 bytes"},
+    {"a 'lat' és 'lon' paramétert együtt kell megadni", "'lat' and 'lon' must be given together"},
+    {"a 'lat' és 'lon' paraméternek számnak kell lennie", "'lat' and 'lon' must be numbers"},
+    {"a 'callback' paraméter (JSONP) nincs engedélyezve", "the 'callback' parameter (JSONP) is not enabled"},
+    {"a 'callback' paraméter csak JSON formátummal használható", "the 'callback' parameter can only be used with the JSON format"},
+    {"a 'callback' értéke pontokkal elválasztott JavaScript azonosító lehet (legfeljebb 64 karakter)", "'callback' must be a dot-separated JavaScript identifier (at most 64 characters)"},
+    {"1 és %s közötti számú cím adható meg", "Between 1 and %s addresses can be given"},
+    {"Érvénytelen irányítószám", "Invalid postal code"},
+    {"Ismeretlen irányítószám", "Unknown postal code"},
+    {"Ismeretlen település", "Unknown settlement"},
+    {"Ismeretlen végpont", "Unknown endpoint"},
+
+    // Törzsek.
+    {"A törzs nem olvasható, vagy túl nagy", "The body cannot be read or is too large"},
+    {"A törzsnek címek JSON tömbjének kell lennie", "The body must be a JSON array of addresses"},
+    {"A törzsnek címrekord JSON objektumnak kell lennie", "The body must be an address record JSON object"},
+    {"A törzsnek egy kiválasztás JSON objektumának kell lennie", "The body must be a selection JSON object"},
+    {`A törzsnek {"address": "..."} alakú JSON objektumnak kell lennie`, `The body must be a JSON object of the form {"address": "..."}`},
+    {`A törzsnek {"operations": [...]} alakú JSON objektumnak kell lennie`, `The body must be a JSON object of the form {"operations": [...]}`},
+    {`A törzsnek {"synonyms": [...]} alakú JSON objektumnak kell lennie`, `The body must be a JSON object of the form {"synonyms": [...]}`},
+    {`A törzsnek {"text": ...} alakú JSON objektumnak kell lennie`, `The body must be a JSON object of the form {"text": ...}`},
+    {`A törzsnek {"minGram", "maxGram", "tokenizer", "filters"} mezőket tartalmazó JSON objektumnak kell lennie`, `The body must be a JSON object with the fields {"minGram", "maxGram", "tokenizer", "filters"}`},
+    {"A törzs id mezője eltér az útvonalban megadott azonosítótól", "The id field of the body differs from the ID in the path"},
+
+    // Dokumentumok és rekordok.
+    {"a dokumentum már létezik: %s", "the document already exists: %s"},
+    {"a dokumentum nem található: %s", "the document was not found: %s"},
+    {"hiányzó id mező", "missing id field"},
+    {"hiányzó document mező", "missing document field"},
+    {"hiányzó telepules mező", "missing telepules field"},
+    {"a document id mezője eltér a művelet id mezőjétől", "the id field of the document differs from the id field of the operation"},
+    {"a lat és lon mezőt együtt kell megadni", "the lat and lon fields must be given together"},
+    {"házszámtartomány csak közterülettel adható meg", "a house number range can only be given with a street"},
+    {"érvénytelen %s érték: %s", "invalid %s value: %s"},
+    {"érvénytelen hazszam_paritas: %s (%s vagy %s)", "invalid hazszam_paritas: %s (%s or %s)"},
+    {"érvénytelen házszámtartomány: %s (1 ≤ hazszam_tol ≤ hazszam_ig ≤ %s)", "invalid house number range: %s (1 ≤ hazszam_tol ≤ hazszam_ig ≤ %s)"},
+    {"érvénytelen koordináta: %s", "invalid coordinate: %s"},
+    {"érvénytelen ksh_kod: %s (5 számjegy)", "invalid ksh_kod: %s (5 digits)"},
+    {"a weight 0 és %s közé kell essen", "the weight must be between 0 and %s"},
+    {"ismeretlen művelet: %s", "unknown operation: %s"},
+    {"érvénytelen házszám: %s", "invalid house number: %s"},
+    {"ismeretlen javaslatfajta: %s", "unknown suggestion kind: %s"},
+    {"a kiválasztási puffer megtelt", "the selection buffer is full"},
+    {"A kiválasztás most nem rögzíthető", "The selection cannot be recorded right now"},
+    {"A sor ellenőrzése sikertelen", "Validating the row failed"},
+
+    // CSV import, szinonimák, analyzer.
+    {"érvénytelen CSV: a fejléc nem olvasható: %s", "invalid CSV: the header cannot be read: %s"},
+    {"érvénytelen CSV: a fejlécben nincs telepules mezőre leképezett oszlop", "invalid CSV: no column in the header is mapped to the telepules field"},
+    {"hibás leképezés: %s", "invalid mapping: %s"},
+    {"ismeretlen mező a leképezésben: %s", "unknown field in the mapping: %s"},
+    {"érvénytelen szinonimalista: legfeljebb %s szabály adható meg", "invalid synonym list: at most %s rules can be given"},
+    {"érvénytelen szinonimalista: %s. szabály: legalább két kifejezés kell (%s)", "invalid synonym list: rule %s: at least two terms are required (%s)"},
+    {`érvénytelen szinonimalista: %s. szabály: legfeljebb egy "=>" lehet`, `invalid synonym list: rule %s: at most one "=>" is allowed`},
+    {"érvénytelen szinonimalista: %s. szabály: üres kifejezés (%s)", "invalid synonym list: rule %s: empty term (%s)"},
+    {"érvénytelen analyzer beállítás: a minGram legalább 1, a maxGram legalább minGram és legfeljebb %s lehet", "invalid analyzer settings: minGram must be at least 1, maxGram at least minGram and at most %s"},
+    {"érvénytelen analyzer beállítás: ismeretlen tokenizáló: %s", "invalid analyzer settings: unknown tokenizer: %s"},
+    {"érvénytelen analyzer beállítás: nem engedélyezett szűrő: %s", "invalid analyzer settings: filter not allowed: %s"},
+    {"érvénytelen analyzer beállítás: a(z) %s szűrő többször szerepel", "invalid analyzer settings: the %s filter is listed more than once"},
+    {"érvénytelen analyzer beállítás: a(z) %s szűrő nem hagyható el", "invalid analyzer settings: the %s filter cannot be omitted"},
+    {"érvénytelen analyzer beállítás: a 'text' nem lehet üres", "invalid analyzer settings: 'text' cannot be empty"},
+    {"érvénytelen analyzer beállítás: a 'text' legfeljebb %s karakter lehet", "invalid analyzer settings: 'text' can be at most %s characters"},
+    {"érvénytelen analyzer beállítás: az 'analyzer' és a 'field' közül csak az egyik adható meg", "invalid analyzer settings: only one of 'analyzer' and 'field' can be given"},
+    {"érvénytelen analyzer beállítás: %s", "invalid analyzer settings: %s"},
+
+    // Újraindexelés, pillanatképek, háttérfeladatok.
+    {"már folyamatban van egy újraindexelés (%s)", "a reindex is already in progress (%s)"},
+    {"nincs átmásolható forrás index (%s)", "there is no source index to copy (%s)"},
+    {"nincs importra váró újraindexelés", "there is no reindex awaiting import"},
+    {"Nincs importra váró újraindexelés", "There is no reindex awaiting import"},
+    {"Még nem indult újraindexelés", "No reindex has been started yet"},
+    {"Még nem indult előszámítás", "No precomputation has been started yet"},
+    {"már folyamatban van a javaslatok előszámítása", "precomputing suggestions is already in progress"},
+    {"érvénytelen pillanatkép név (kisbetűk, számjegyek, '.', '_' és '-')", "invalid snapshot name (lowercase letters, digits, '.', '_' and '-')"},
+    {"már létezik ilyen nevű pillanatkép (%s)", "a snapshot with this name already exists (%s)"},
+    {"a pillanatkép nem található (%s)", "the snapshot was not found (%s)"},
+    {"már folyamatban van egy pillanatkép készítése", "a snapshot is already in progress"},
+    {"a(z) %s pillanatkép %s indexet tartalmaz, egyet kellene", "snapshot %s contains %s indices, it should contain one"},
+    {"a(z) %s pillanatkép %s állapotú, csak SUCCESS állapotú állítható vissza", "snapshot %s is in state %s, only SUCCESS snapshots can be restored"},
+    {"Ismeretlen pillanatkép művelet", "Unknown snapshot operation"},
+    {"A pillanatkép tároló nincs beállítva (SNAPSHOT_REPOSITORY)", "The snapshot repository is not configured (SNAPSHOT_REPOSITORY)"},
+    {"A teljes újraszinkronizálás nincs beállítva (RESYNC_SOURCE)", "Full resync is not configured (RESYNC_SOURCE)"},
+    {"Az inkrementális frissítés nincs beállítva (UPDATES_SECRET)", "Incremental updates are not configured (UPDATES_SECRET)"},
+    {"A lekérdezés-statisztika ki van kapcsolva (ANALYTICS_ENABLED)", "Query analytics is disabled (ANALYTICS_ENABLED)"},
+    {"Nincs futó kísérlet (EXPERIMENT_NAME)", "No experiment is running (EXPERIMENT_NAME)"},
+    {"Ismeretlen kísérleti változat: %s", "Unknown experiment variant: %s"},
+
+    // Háttérhibák.
+    {"Hiba a javaslatok lekérésekor", "Error fetching suggestions"},
+    {"Hiba a cím ellenőrzésekor", "Error validating the address"},
+    {"Hiba a címek ellenőrzésekor", "Error validating the addresses"},
+    {"Hiba a cím felbontásakor", "Error parsing the address"},
+    {"Hiba a cím geokódolásakor", "Error geocoding the address"},
+    {"Hiba a fordított geokódoláskor", "Error during reverse geocoding"},
+    {"Hiba az irányítószám feloldásakor", "Error resolving the postal code"},
+    {"Hiba a település összesítésekor", "Error summarizing the settlement"},
+    {"Hiba a településlista lekérdezésekor", "Error querying the settlement list"},
+    {"Hiba a közterületlista lekérdezésekor", "Error querying the street list"},
+    {"Hiba a kiválasztás rögzítésekor", "Error recording the selection"},
+    {"Hiba a dokumentum módosításakor", "Error modifying the document"},
+    {"Hiba a mapping ellenőrzésekor", "Error checking the mapping"},
+    {"Hiba a mintaadat betöltésekor", "Error loading the sample data"},
+    {"Hiba a súlyok kiszámításakor", "Error computing the weights"},
+    {"Hiba a szinonimák lekérésekor", "Error fetching the synonyms"},
+    {"Hiba a szöveg elemzésekor", "Error analyzing the text"},
+    {"Hiba az adatminőségi jelentés készítésekor", "Error creating the data quality report"},
+    {"Hiba az alias váltásakor", "Error switching the alias"},
+    {"Hiba az analyzer beállítások lekérésekor", "Error fetching the analyzer settings"},
+    {"Hiba az export lekérdezésekor", "Error querying the export"},
+
+    // Debug információ (?debug=1).
+    {"Normalizált lekérdezés: %s -> %s", "Normalized query: %s -> %s"},
+    {"Cache találat: %s, mező: %s", "Cache hit: %s, field: %s"},
+    {"Mező: %s (%s)", "Field: %s (%s)"},
+    {"Rangsorolás (%s): %s", "Ranking (%s): %s"},
+    {"Kanári index: %s", "Canary index: %s"},
+    {"Elavult cache találat: %s, mező: %s", "Stale cache hit: %s, field: %s"},
+    {"Előre kiszámolt találat: %s, mező: %s", "Precomputed hit: %s, field: %s"},
+    {"A friss lekérdezés nem érkezett meg %s alatt, háttérben frissül", "The fresh query did not arrive within %s, refreshing in the background"},
+    {"Memóriabeli keresés (%s): %s", "In-memory search (%s): %s"},
+    {"Memóriabeli irányítószám feloldás: %s", "In-memory postal code lookup: %s"},
+    {"Memóriabeli település összesítés: %s", "In-memory settlement summary: %s"},
+    {"Memóriabeli településlista: megye=%s, after=%s, size=%s", "In-memory settlement list: county=%s, after=%s, size=%s"},
+    {"Memóriabeli közterületlista: %s, prefix=%s, after=%s, size=%s", "In-memory street list: %s, prefix=%s, after=%s, size=%s"},
+    {"SQLite keresés (%s): %s", "SQLite search (%s): %s"},
+    {"SQLite irányítószám feloldás: %s", "SQLite postal code lookup: %s"},
+    {"Keresési lekérdezés (%s): %s, mező: %s", "Search query (%s): %s, field: %s"},
+    {"Billentyűszomszéd-változatok: %s, mező: %s", "Keyboard neighbor variants: %s, field: %s"},
+    {"Fuzzy lekérdezés: %s, mező: %s", "Fuzzy query: %s, field: %s"},
+    {"Generált regexp: %s", "Generated regexp: %s"},
+    {"Szavankénti illesztés: %s", "Per-word matching: %s"},
+    {"Közelség szerinti rangsorolás: %s (scale: %s)", "Ranking by distance: %s (scale: %s)"},
+    {"Helyesírási javaslatkérés: %s", "Spelling suggestion request: %s"},
+    {"Irányítószám keresés: %s", "Postal code search: %s"},
+    {"Irányítószám feloldás: %s", "Postal code lookup: %s"},
+    {"Település összesítés: %s", "Settlement summary: %s"},
+    {"Közterületek: %s, irányítószámok: %s, rekordok: %s", "Streets: %s, postal codes: %s, records: %s"},
+    {"Településlista: megye=%s, after=%s, size=%s", "Settlement list: county=%s, after=%s, size=%s"},
+    {"Települések: %s, következő oldal: %s", "Settlements: %s, next page: %s"},
+    {"Közterületlista: %s, prefix=%s, after=%s, size=%s", "Street list: %s, prefix=%s, after=%s, size=%s"},
+    {"Közterületek: %s, következő oldal: %s", "Streets: %s, next page: %s"},
+    {"A kurzor pillanatképe lejárt, új pillanatkép", "The cursor's point-in-time snapshot expired, opened a new one"},
+    {"OpenSearch válasz státusza: %s", "OpenSearch response status: %s"},
+    {"Válasz body: %s", "Response body: %s"},
+    {"Aggregáció válasz body: %s", "Aggregation response body: %s"},
+    {"Mapping lekérdezés válasz body: %s", "Mapping query response body: %s"},
+    {"Visszaadott javaslatok: %s", "Returned suggestions: %s"},
+    {"Korábbi/alternatív névre illeszkedett: %s", "Matched a former/alternative name: %s"},
+    {"Egyedi találatok becsült száma: %s", "Estimated number of unique hits: %s"},
+    {"Hiba a payload marshalolásakor: %s", "Error marshaling the payload: %s"},
+    {"Hiba a válasz JSON dekódolásakor: %s", "Error decoding the response JSON: %s"},
+    {"Hiba az OpenSearch lekérdezés végrehajtásakor: %s", "Error executing the OpenSearch query: %s"},
+}
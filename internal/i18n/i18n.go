@@ -0,0 +1,131 @@
+// Package i18n az API kliensnek szánt szövegeinek (hibaüzenetek, hint-ek, debug információ)
+// fordítása. A forrásnyelv a magyar: az üzenetek a kódban magyarul állnak, a katalógus
+// (lásd catalog.go) ezek angol megfelelőjét adja; a katalógusban nem szereplő üzenet magyarul marad.
+package i18n
+
+import (
+    "fmt"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// A támogatott nyelvek (BCP 47 elsődleges nyelvi címke).
+const (
+    Hungarian = "hu"
+    English   = "en"
+)
+
+// Supported a támogatott nyelvek listája.
+var Supported = []string{Hungarian, English}
+
+// IsSupported jelzi, hogy a lang támogatott nyelv-e.
+func IsSupported(lang string) bool {
+    return lang == Hungarian || lang == English
+}
+
+// Negotiate az Accept-Language fejléc nyelvi tartományait q érték szerint (azonos q-nál a fejléc
+// sorrendjében) veszi sorra, és az első támogatott nyelvet adja; a régiót figyelmen kívül hagyja
+// ("en-GB" angol). Ha egyik sem támogatott (vagy a fejléc üres, vagy "*"), a fallback-et adja.
+func Negotiate(acceptLanguage, fallback string) string {
+    type languageRange struct {
+        lang string
+        q    float64
+    }
+    var ranges []languageRange
+    for _, part := range strings.Split(acceptLanguage, ",") {
+        tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+        q := 1.0
+        if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+            var err error
+            if q, err = strconv.ParseFloat(v, 64); err != nil || q <= 0 {
+                continue
+            }
+        }
+        primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+        if IsSupported(primary) {
+            ranges = append(ranges, languageRange{lang: primary, q: q})
+        }
+    }
+    sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+    if len(ranges) > 0 {
+        return ranges[0].lang
+    }
+    return fallback
+}
+
+// Translate a magyar message lang nyelvű alakját adja. A többsoros szöveget (pl. a debug
+// információt) soronként fordítja; a katalógusban nem szereplő üzenet (és sor) változatlan.
+func Translate(lang, message string) string {
+    if lang != English || message == "" {
+        return message
+    }
+    if strings.Contains(message, "\n") {
+        lines := strings.Split(message, "\n")
+        for i, line := range lines {
+            lines[i] = translate(line)
+        }
+        return strings.Join(lines, "\n")
+    }
+    return translate(message)
+}
+
+// translate egy sor angol fordítása: előbb a pontos egyezés, majd a minták sorrendjében az első
+// illeszkedő minta; a %v helyére kerülő részüzenetet is lefordítja.
+func translate(message string) string {
+    if en, ok := exact[message]; ok {
+        return en
+    }
+    for _, p := range patterns {
+        match := p.re.FindStringSubmatch(message)
+        if match == nil {
+            continue
+        }
+        args := make([]interface{}, len(match)-1)
+        for i, arg := range match[1:] {
+            if p.nested[i] {
+                arg = translate(arg)
+            }
+            args[i] = arg
+        }
+        return fmt.Sprintf(p.en, args...)
+    }
+    return message
+}
+
+// pattern egy paraméteres katalógusbejegyzés.
+type pattern struct {
+    re *regexp.Regexp
+    en string
+    // nested jelzi paraméterenként, hogy a helyére kerülő szöveg maga is fordítandó üzenet (%v).
+    nested []bool
+}
+
+var (
+    exact    = map[string]string{}
+    patterns []pattern
+)
+
+// placeholder a katalógus helyőrzői: a %s változatlanul átvett, a %v lefordított paraméter.
+var placeholder = regexp.MustCompile(`%[sv]`)
+
+func init() {
+    for _, e := range english {
+        if !placeholder.MatchString(e.hu) {
+            exact[e.hu] = e.en
+            continue
+        }
+        var nested []bool
+        for _, m := range placeholder.FindAllString(e.hu, -1) {
+            nested = append(nested, m == "%v")
+        }
+        parts := placeholder.Split(e.hu, -1)
+        for i := range parts {
+            parts[i] = regexp.QuoteMeta(parts[i])
+        }
+        re := regexp.MustCompile("^" + strings.Join(parts, "(.+?)") + "$")
+        en := placeholder.ReplaceAllString(e.en, "%s")
+        patterns = append(patterns, pattern{re: re, en: en, nested: nested})
+    }
+}
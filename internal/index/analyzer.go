@@ -0,0 +1,233 @@
+package index
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "unicode/utf8"
+)
+
+// AutocompleteFilter az "autocomplete" analyzer edge_ngram szűrőjének neve; a MinGram és a
+// MaxGram ennek a paraméterei.
+const AutocompleteFilter = "autocomplete_filter"
+
+// MaxGramLimit a MaxGram legnagyobb megengedett értéke; a hosszabb prefixek csak az index
+// méretét növelnék.
+const MaxGramLimit = 50
+
+// MaxAnalyzeTextLength az Analyze-nak átadható szöveg legnagyobb hossza karakterben.
+const MaxAnalyzeTextLength = 1000
+
+// AnalyzerSettings az "autocomplete" analyzer hangolható paraméterei: az edge_ngram szűrő
+// (AutocompleteFilter) prefixhosszai, a tokenizáló (amelyet az "autocomplete_search" analyzer
+// is használ) és a szűrők sorrendje.
+type AnalyzerSettings struct {
+    MinGram   int      `json:"minGram"`
+    MaxGram   int      `json:"maxGram"`
+    Tokenizer string   `json:"tokenizer"`
+    Filters   []string `json:"filters"`
+}
+
+// DefaultAnalyzer az új indexek kezdeti analyzer beállításai.
+var DefaultAnalyzer = AnalyzerSettings{
+    MinGram:   1,
+    MaxGram:   20,
+    Tokenizer: "standard",
+    Filters:   []string{"lowercase", SynonymFilter, "autocomplete_folding", AutocompleteFilter},
+}
+
+// allowedTokenizers az analyzerben használható beépített tokenizálók.
+var allowedTokenizers = map[string]bool{"standard": true, "whitespace": true, "letter": true, "classic": true}
+
+// allowedFilters az analyzerben használható szűrők: néhány beépített szűrő és az index saját
+// szűrői (lásd settings).
+var allowedFilters = map[string]bool{
+    "lowercase": true, "asciifolding": true, "trim": true, "unique": true, "remove_duplicates": true, "apostrophe": true,
+    SynonymFilter: true, "autocomplete_folding": true, AutocompleteFilter: true,
+}
+
+// ErrInvalidAnalyzer jelzi, hogy az analyzer beállításai vagy az elemzési kérés érvénytelen.
+var ErrInvalidAnalyzer = errors.New("érvénytelen analyzer beállítás")
+
+// Validate ellenőrzi a beállításokat: 1 <= MinGram <= MaxGram <= MaxGramLimit, ismert
+// tokenizáló, csak engedélyezett, nem ismétlődő szűrők, köztük az AutocompleteFilter.
+func (a AnalyzerSettings) Validate() error {
+    if a.MinGram < 1 || a.MaxGram < a.MinGram || a.MaxGram > MaxGramLimit {
+        return fmt.Errorf("%w: a minGram legalább 1, a maxGram legalább minGram és legfeljebb %d lehet", ErrInvalidAnalyzer, MaxGramLimit)
+    }
+    if !allowedTokenizers[a.Tokenizer] {
+        return fmt.Errorf("%w: ismeretlen tokenizáló: %q", ErrInvalidAnalyzer, a.Tokenizer)
+    }
+    seen := make(map[string]bool, len(a.Filters))
+    for _, filter := range a.Filters {
+        if !allowedFilters[filter] {
+            return fmt.Errorf("%w: nem engedélyezett szűrő: %q", ErrInvalidAnalyzer, filter)
+        }
+        if seen[filter] {
+            return fmt.Errorf("%w: a(z) %q szűrő többször szerepel", ErrInvalidAnalyzer, filter)
+        }
+        seen[filter] = true
+    }
+    if !seen[AutocompleteFilter] {
+        return fmt.Errorf("%w: a(z) %q szűrő nem hagyható el", ErrInvalidAnalyzer, AutocompleteFilter)
+    }
+    return nil
+}
+
+// analysis az index létrehozásakor használt, futás közben módosítható elemzési beállítások.
+type analysis struct {
+    Synonyms []string
+    Analyzer AnalyzerSettings
+}
+
+// defaultAnalysis a még nem létező (vagy régi, ilyen beállítások nélkül készült) index elemzési
+// beállításai.
+func defaultAnalysis() analysis {
+    return analysis{Synonyms: DefaultSynonyms, Analyzer: DefaultAnalyzer}
+}
+
+// analysis az index (vagy az alias mögötti index) jelenlegi szinonimalistáját és analyzer
+// beállításait olvassa ki a _settings válaszból; a hiányzó részeknél az alapértékeket adja.
+func (m *Manager) analysis(ctx context.Context) (analysis, error) {
+    current := defaultAnalysis()
+    resp, err := m.client.Do(ctx, "GET", "/"+m.name+"/_settings", nil, "")
+    if err != nil {
+        return current, fmt.Errorf("a beállítások lekérése sikertelen: %w", err)
+    }
+    if resp.StatusCode == http.StatusNotFound {
+        return current, nil
+    }
+    if resp.StatusCode != http.StatusOK {
+        return current, fmt.Errorf("a beállítások lekérése sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    // Az OpenSearch a számértékű beállításokat szövegként adja vissza ("min_gram": "1").
+    var indexes map[string]struct {
+        Settings struct {
+            Index struct {
+                Analysis struct {
+                    Filter map[string]struct {
+                        Synonyms []string    `json:"synonyms"`
+                        MinGram  json.Number `json:"min_gram"`
+                        MaxGram  json.Number `json:"max_gram"`
+                    } `json:"filter"`
+                    Analyzer map[string]struct {
+                        Tokenizer string   `json:"tokenizer"`
+                        Filter    []string `json:"filter"`
+                    } `json:"analyzer"`
+                } `json:"analysis"`
+            } `json:"index"`
+        } `json:"settings"`
+    }
+    if err := json.Unmarshal(resp.Body, &indexes); err != nil {
+        return current, err
+    }
+    for _, index := range indexes {
+        live := index.Settings.Index.Analysis
+        if filter, ok := live.Filter[SynonymFilter]; ok {
+            current.Synonyms = append([]string{}, filter.Synonyms...)
+        }
+        if filter, ok := live.Filter[AutocompleteFilter]; ok {
+            if n, err := strconv.Atoi(filter.MinGram.String()); err == nil {
+                current.Analyzer.MinGram = n
+            }
+            if n, err := strconv.Atoi(filter.MaxGram.String()); err == nil {
+                current.Analyzer.MaxGram = n
+            }
+        }
+        if analyzer, ok := live.Analyzer["autocomplete"]; ok {
+            if analyzer.Tokenizer != "" {
+                current.Analyzer.Tokenizer = analyzer.Tokenizer
+            }
+            if len(analyzer.Filter) > 0 {
+                current.Analyzer.Filters = append([]string{}, analyzer.Filter...)
+            }
+        }
+        break
+    }
+    return current, nil
+}
+
+// Analyzer az index (vagy az alias mögötti index) "autocomplete" analyzerének beállításait adja
+// vissza. Ha az index nem létezik, a DefaultAnalyzer-t.
+func (m *Manager) Analyzer(ctx context.Context) (AnalyzerSettings, error) {
+    current, err := m.analysis(ctx)
+    return current.Analyzer, err
+}
+
+// SetAnalyzer az "autocomplete" analyzer beállításait cseréli: mivel ezek az indexelt prefixeket
+// határozzák meg, a jelenlegi index tartalmát alias-alapú újraindexeléssel új, az a beállításait
+// használó indexbe másolja (lásd StartReindex). A beállításokat a Validate szerint ellenőrzi.
+func (m *Manager) SetAnalyzer(ctx context.Context, a AnalyzerSettings, deleteOld bool) (*ReindexJob, error) {
+    if err := a.Validate(); err != nil {
+        return nil, err
+    }
+    return m.startReindex(ctx, ReindexModeReindex, deleteOld, func(current *analysis) {
+        current.Analyzer = a
+    })
+}
+
+// AnalyzeRequest az Analyze paraméterei: a Text az elemzendő szöveg, az Analyzer az index egy
+// analyzere (üresen "autocomplete"), a Field helyette egy mező, amelynek az index analyzerével
+// elemez. Az Analyzer és a Field közül legfeljebb az egyik adható meg.
+type AnalyzeRequest struct {
+    Text     string `json:"text"`
+    Analyzer string `json:"analyzer,omitempty"`
+    Field    string `json:"field,omitempty"`
+}
+
+// AnalyzeToken az elemzés egy tokenje az OpenSearch _analyze válaszából.
+type AnalyzeToken struct {
+    Token       string `json:"token"`
+    StartOffset int    `json:"start_offset"`
+    EndOffset   int    `json:"end_offset"`
+    Type        string `json:"type"`
+    Position    int    `json:"position"`
+}
+
+// Analyze az index _analyze API-jával megmutatja, milyen tokenekre bontja a szöveget az index
+// egy analyzere vagy mezője. Érvénytelen kérésnél (az OpenSearch 400-as válaszánál is)
+// ErrInvalidAnalyzer hibát ad.
+func (m *Manager) Analyze(ctx context.Context, req AnalyzeRequest) ([]AnalyzeToken, error) {
+    if strings.TrimSpace(req.Text) == "" {
+        return nil, fmt.Errorf("%w: a 'text' nem lehet üres", ErrInvalidAnalyzer)
+    }
+    if utf8.RuneCountInString(req.Text) > MaxAnalyzeTextLength {
+        return nil, fmt.Errorf("%w: a 'text' legfeljebb %d karakter lehet", ErrInvalidAnalyzer, MaxAnalyzeTextLength)
+    }
+    payload := map[string]interface{}{"text": req.Text}
+    switch {
+    case req.Analyzer != "" && req.Field != "":
+        return nil, fmt.Errorf("%w: az 'analyzer' és a 'field' közül csak az egyik adható meg", ErrInvalidAnalyzer)
+    case req.Field != "":
+        payload["field"] = req.Field
+    case req.Analyzer != "":
+        payload["analyzer"] = req.Analyzer
+    default:
+        payload["analyzer"] = "autocomplete"
+    }
+    body, _ := json.Marshal(payload)
+    resp, err := m.client.Do(ctx, "POST", "/"+m.name+"/_analyze", body, "application/json")
+    if err != nil {
+        return nil, fmt.Errorf("az elemzés sikertelen: %w", err)
+    }
+    if resp.StatusCode == http.StatusBadRequest {
+        return nil, fmt.Errorf("%w: %s", ErrInvalidAnalyzer, string(resp.Body))
+    }
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("az elemzés sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    var result struct {
+        Tokens []AnalyzeToken `json:"tokens"`
+    }
+    if err := json.Unmarshal(resp.Body, &result); err != nil {
+        return nil, err
+    }
+    if result.Tokens == nil {
+        result.Tokens = []AnalyzeToken{}
+    }
+    return result.Tokens, nil
+}
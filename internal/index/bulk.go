@@ -0,0 +1,418 @@
+package index
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "net/http"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+)
+
+// AddressDocument egy címrekord az indexben. Az ID opcionális; ha meg van adva,
+// a dokumentum ezzel az _id-vel kerül az indexbe, egyébként az OpenSearch generál egyet.
+// A Lat/Lon a rekord koordinátái (WGS84): közterületes rekordnál a közterületé, egyébként a
+// településé; a közelség szerinti rangsoroláshoz és a geokódoláshoz. Csak együtt adhatók meg,
+// és az indexbe a location geo_point mezőként kerülnek. Az Aliases a település korábbi vagy
+// alternatív nevei (pl. összevonás előtti községnevek); a településjavaslat ezekre
+// is illeszkedik, de a Telepules szerinti jelenlegi nevet adja vissza. A Weight a rangsorolási
+// súly, jellemzően a település lakossága (a CSV "nepesseg" oszlopa) vagy címszáma (lásd
+// AddressCountWeights); a completion lekérdezési módban 1 az alapértelmezés. A KSHKod a település KSH
+// statisztikai azonosítója (5 jegyű településkód), amellyel a downstream rendszerek a név
+// helyett a hivatalos azonosítóra kapcsolhatnak. A HazszamTol–HazszamIg a közterület-szakasz
+// házszámtartománya (zárt intervallum), a HazszamParitas pedig az oldala (ParityEven,
+// ParityOdd, vagy üresen mindkettő); ugyanazon közterület szakaszai külön rekordok, a saját
+// irányítószámukkal (lásd CoversHouseNumber).
+type AddressDocument struct {
+    ID        string `json:"id,omitempty"`
+    Telepules string `json:"telepules"`
+    KozterNev string `json:"kozter_nev,omitempty"`
+    Irsz      string `json:"irsz,omitempty"`
+    Megye     string `json:"megye,omitempty"`
+    KSHKod    string `json:"ksh_kod,omitempty"`
+    // Házszámtartomány (lásd CoversHouseNumber).
+    HazszamTol     int      `json:"hazszam_tol,omitempty"`
+    HazszamIg      int      `json:"hazszam_ig,omitempty"`
+    HazszamParitas string   `json:"hazszam_paritas,omitempty"`
+    Lat            *float64 `json:"lat,omitempty"`
+    Lon            *float64 `json:"lon,omitempty"`
+    Aliases        []string `json:"aliases,omitempty"`
+    Weight         int      `json:"weight,omitempty"`
+    // TeljesCim a betöltéskor képzett "Település, Közterület" szöveg; a bemenetben nem kell megadni.
+    TeljesCim string `json:"teljes_cim,omitempty"`
+    // Location a Lat/Lon-ból képzett geo_point; a bemenetben nem kell megadni.
+    Location *GeoPoint `json:"location,omitempty"`
+    // Suggest a completion mezők bemenetei (lásd CompletionInputs); a bemenetben nem kell megadni.
+    Suggest map[string]CompletionInput `json:"suggest,omitempty"`
+}
+
+// GeoPoint egy földrajzi pont (WGS84) az OpenSearch geo_point objektum alakjában.
+type GeoPoint struct {
+    Lat float64 `json:"lat"`
+    Lon float64 `json:"lon"`
+}
+
+// Validate ellenőrzi, hogy a koordináták érvényes tartományba esnek-e.
+func (p GeoPoint) Validate() error {
+    if !(p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180) {
+        return fmt.Errorf("érvénytelen koordináta: %g, %g", p.Lat, p.Lon)
+    }
+    return nil
+}
+
+// earthRadiusMeters a Föld átlagos sugara a haversine távolsághoz.
+const earthRadiusMeters = 6371008.8
+
+// DistanceMeters a két pont gömbi (haversine) távolsága méterben; kis eltéréssel egyezik az
+// OpenSearch geo_distance számításával.
+func (p GeoPoint) DistanceMeters(q GeoPoint) float64 {
+    rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+    dLat, dLon := rad(q.Lat-p.Lat), rad(q.Lon-p.Lon)
+    h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(rad(p.Lat))*math.Cos(rad(q.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+    return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// GeoPoint a dokumentum koordinátái, vagy nil, ha nincsenek megadva.
+func (d AddressDocument) GeoPoint() *GeoPoint {
+    if d.Lat == nil || d.Lon == nil {
+        return nil
+    }
+    return &GeoPoint{Lat: *d.Lat, Lon: *d.Lon}
+}
+
+// kshCodePattern a KSH településkód alakja.
+var kshCodePattern = regexp.MustCompile(`^[0-9]{5}$`)
+
+// FullAddress a dokumentum "Település, Közterület" alakú teljes címe (közterület nélkül csak a település).
+func (d AddressDocument) FullAddress() string {
+    telepules := strings.TrimSpace(d.Telepules)
+    if kozter := strings.TrimSpace(d.KozterNev); kozter != "" {
+        return telepules + ", " + kozter
+    }
+    return telepules
+}
+
+// Validate ellenőrzi, hogy a rekord indexelhető-e.
+func (d AddressDocument) Validate() error {
+    if strings.TrimSpace(d.Telepules) == "" {
+        return errors.New("hiányzó telepules mező")
+    }
+    if d.Weight < 0 || d.Weight > maxCompletionWeight {
+        return fmt.Errorf("a weight 0 és %d közé kell essen", maxCompletionWeight)
+    }
+    if d.KSHKod != "" && !kshCodePattern.MatchString(d.KSHKod) {
+        return fmt.Errorf("érvénytelen ksh_kod: %q (5 számjegy)", d.KSHKod)
+    }
+    if err := d.validateHouseNumberRange(); err != nil {
+        return err
+    }
+    if (d.Lat == nil) != (d.Lon == nil) {
+        return errors.New("a lat és lon mezőt együtt kell megadni")
+    }
+    if p := d.GeoPoint(); p != nil {
+        return p.Validate()
+    }
+    return nil
+}
+
+// BulkRecordError egy sikertelenül feldolgozott rekordot ír le; az Index a rekord
+// (0-tól számolt) pozíciója a bemenetben.
+type BulkRecordError struct {
+    Index int    `json:"index"`
+    ID    string `json:"id,omitempty"`
+    Error string `json:"error"`
+}
+
+// BulkSummary a tömeges betöltés összesítője; a Deleted csak az inkrementális frissítésnél
+// (lásd ApplyUpdates) nem nulla. A Duplicates az ismétlődő címek listája (lásd
+// DuplicatePolicyFlag), a Merged az összevont rekordok száma; ezek sem az Indexed, sem a
+// Failed számba nem tartoznak bele.
+type BulkSummary struct {
+    Total      int               `json:"total"`
+    Indexed    int               `json:"indexed"`
+    Deleted    int               `json:"deleted,omitempty"`
+    Failed     int               `json:"failed"`
+    Merged     int               `json:"merged,omitempty"`
+    Batches    int               `json:"batches"`
+    DryRun     bool              `json:"dryRun,omitempty"`
+    Errors     []BulkRecordError `json:"errors"`
+    Duplicates []BulkDuplicate   `json:"duplicates,omitempty"`
+    Error      string            `json:"error,omitempty"`
+}
+
+// addError rögzít egy rekordszintű hibát az összesítőben.
+func (s *BulkSummary) addError(index int, id, msg string) {
+    s.Failed++
+    s.Errors = append(s.Errors, BulkRecordError{Index: index, ID: id, Error: msg})
+}
+
+// DocumentSink a beolvasott rekordokat fogadja (lásd ReadBulkDocuments, ImportCSV). Az Add
+// hibája megszakítja a beolvasást; a Fail egy értelmezhetetlen rekordot rögzít.
+type DocumentSink interface {
+    Add(pos int, doc AddressDocument) error
+    Fail(pos int, msg string)
+}
+
+// BulkIndexer kötegekbe gyűjti a dokumentumokat, és a Manager BulkBatchSize-a szerinti
+// elemenként elküldi őket az OpenSearch _bulk API-nak. DryRun esetén csak ellenőriz, az
+// érvényes rekordokat sikeresként számolja, de nem küld semmit. Az OnFlush (ha meg van adva)
+// minden köteg után megkapja az aktuális összesítőt. Az Index alapértelmezés szerint a
+// Manager indexe, újraindexeléskor a célindex is lehet (lásd IsImportTarget). A
+// DuplicatePolicy az ismétlődő címek kezelése (alapértelmezés a Manager DuplicatePolicy-ja).
+type BulkIndexer struct {
+    Index           string
+    DryRun          bool
+    DuplicatePolicy string
+    OnFlush         func(BulkSummary)
+
+    ctx       context.Context
+    manager   *Manager
+    batchSize int
+    docs      []AddressDocument
+    positions []int
+    seen      map[string]duplicateOccurrence
+    summary   BulkSummary
+}
+
+// NewBulkIndexer a Manager indexébe töltő BulkIndexer-t ad vissza.
+func (m *Manager) NewBulkIndexer(ctx context.Context) *BulkIndexer {
+    return &BulkIndexer{
+        Index:           m.name,
+        DuplicatePolicy: m.DuplicatePolicy,
+        ctx:             ctx,
+        manager:         m,
+        batchSize:       m.BulkBatchSize,
+        seen:            map[string]duplicateOccurrence{},
+        summary:         BulkSummary{Errors: []BulkRecordError{}},
+    }
+}
+
+// Add felvesz egy rekordot a kötegbe; a pos a rekord pozíciója a bemenetben.
+// Érvénytelen rekordot nem küld el, hanem hibaként rögzít; az ismétlődő címet a
+// DuplicatePolicy szerint jelzi vagy összevonja. Ha a köteg megtelt, elküldi.
+func (b *BulkIndexer) Add(pos int, doc AddressDocument) error {
+    b.summary.Total++
+    if err := doc.Validate(); err != nil {
+        b.summary.addError(pos, doc.ID, err.Error())
+        return nil
+    }
+    if b.DuplicatePolicy == DuplicatePolicyFlag || b.DuplicatePolicy == DuplicatePolicyMerge {
+        key := addressKey(doc)
+        if first, ok := b.seen[key]; ok {
+            merged := b.DuplicatePolicy == DuplicatePolicyMerge
+            b.summary.Duplicates = append(b.summary.Duplicates, BulkDuplicate{Index: pos, ID: doc.ID, FirstIndex: first.pos, FirstID: first.id, Merged: merged})
+            if merged {
+                b.summary.Merged++
+                return nil
+            }
+        } else {
+            b.seen[key] = duplicateOccurrence{pos: pos, id: doc.ID}
+        }
+    }
+    b.docs = append(b.docs, doc)
+    b.positions = append(b.positions, pos)
+    if len(b.docs) >= b.batchSize {
+        return b.Flush()
+    }
+    return nil
+}
+
+// Fail rögzít egy olyan rekordot, amelyet már a beolvasáskor sem sikerült értelmezni.
+func (b *BulkIndexer) Fail(pos int, msg string) {
+    b.summary.Total++
+    b.summary.addError(pos, "", msg)
+}
+
+// Flush elküldi a függőben lévő köteget. Hibát csak akkor ad vissza, ha maga a
+// _bulk kérés sikertelen; a rekordszintű hibák az összesítőbe kerülnek.
+func (b *BulkIndexer) Flush() error {
+    if len(b.docs) == 0 {
+        return nil
+    }
+    docs, positions := b.docs, b.positions
+    b.docs, b.positions = nil, nil
+    b.summary.Batches++
+    if b.OnFlush != nil {
+        defer func() { b.OnFlush(b.summary) }()
+    }
+
+    if b.DryRun {
+        b.summary.Indexed += len(docs)
+        return nil
+    }
+    itemErrors, err := b.manager.bulkIndex(b.ctx, b.Index, docs)
+    if err != nil {
+        for i, doc := range docs {
+            b.summary.addError(positions[i], doc.ID, err.Error())
+        }
+        return err
+    }
+    for i, doc := range docs {
+        if itemErrors[i] != "" {
+            b.summary.addError(positions[i], doc.ID, itemErrors[i])
+        } else {
+            b.summary.Indexed++
+        }
+    }
+    return nil
+}
+
+// Summary visszaadja az eddigi feldolgozás összesítőjét.
+func (b *BulkIndexer) Summary() BulkSummary {
+    summary := b.summary
+    summary.DryRun = b.DryRun
+    return summary
+}
+
+// bulkIndex egyetlen _bulk kérésben indexeli a dokumentumokat a megadott indexbe. A visszaadott szelet
+// a docs-szal azonos hosszú, és dokumentumonként tartalmazza a hibaüzenetet (üres, ha sikeres).
+func (m *Manager) bulkIndex(ctx context.Context, index string, docs []AddressDocument) ([]string, error) {
+    var payload bytes.Buffer
+    for _, doc := range docs {
+        action := map[string]interface{}{}
+        if doc.ID != "" {
+            action["_id"] = doc.ID
+        }
+        actionBytes, err := json.Marshal(map[string]interface{}{"index": action})
+        if err != nil {
+            return nil, err
+        }
+        sourceBytes, err := json.Marshal(doc.source())
+        if err != nil {
+            return nil, err
+        }
+        payload.Write(actionBytes)
+        payload.WriteByte('\n')
+        payload.Write(sourceBytes)
+        payload.WriteByte('\n')
+    }
+
+    return m.sendBulk(ctx, index, payload.Bytes(), len(docs))
+}
+
+// sendBulk elküldi az n műveletet tartalmazó NDJSON payloadot a _bulk API-nak, és műveletenként
+// visszaadja a hibaüzenetet (üres, ha sikeres). A nem létező dokumentum törlése sikeresnek számít,
+// így a törlés ismételt kézbesítése sem hiba.
+func (m *Manager) sendBulk(ctx context.Context, index string, payload []byte, n int) ([]string, error) {
+    resp, err := m.client.Do(ctx, "POST", "/"+index+"/_bulk", payload, "application/x-ndjson")
+    if err != nil {
+        return nil, err
+    }
+    body := resp.Body
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("_bulk kérés sikertelen (%d): %s", resp.StatusCode, string(body))
+    }
+
+    var result struct {
+        Items []map[string]struct {
+            Status int             `json:"status"`
+            Error  json.RawMessage `json:"error"`
+        } `json:"items"`
+    }
+    if err := json.Unmarshal(body, &result); err != nil {
+        return nil, err
+    }
+    if len(result.Items) != n {
+        return nil, fmt.Errorf("_bulk válasz elemszáma (%d) eltér a küldött műveletekétől (%d)", len(result.Items), n)
+    }
+    itemErrors := make([]string, n)
+    for i, item := range result.Items {
+        for action, res := range item {
+            if res.Status >= 300 && !(action == "delete" && res.Status == http.StatusNotFound) {
+                itemErrors[i] = fmt.Sprintf("státusz %d: %s", res.Status, string(res.Error))
+            }
+        }
+    }
+    return itemErrors, nil
+}
+
+// ReadBulkDocuments beolvassa a bemenetet JSON tömbként (ha '[' karakterrel kezdődik)
+// vagy NDJSON-ként, és minden rekordot átad a sink-nek.
+func ReadBulkDocuments(body io.Reader, sink DocumentSink) error {
+    reader := bufio.NewReader(body)
+    for {
+        b, err := reader.Peek(1)
+        if err == io.EOF {
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        if b[0] == ' ' || b[0] == '\t' || b[0] == '\r' || b[0] == '\n' {
+            reader.ReadByte()
+            continue
+        }
+        if b[0] == '[' {
+            return readJSONArrayDocuments(reader, sink)
+        }
+        return readNDJSONDocuments(reader, sink)
+    }
+}
+
+// readJSONArrayDocuments JSON tömb elemeit dolgozza fel sorban. Szintaktikai hiba esetén
+// a feldolgozás megáll, mivel a tömb többi része már nem értelmezhető megbízhatóan.
+func readJSONArrayDocuments(body io.Reader, sink DocumentSink) error {
+    decoder := json.NewDecoder(body)
+    if _, err := decoder.Token(); err != nil {
+        return err
+    }
+    for pos := 0; decoder.More(); pos++ {
+        var raw json.RawMessage
+        if err := decoder.Decode(&raw); err != nil {
+            return fmt.Errorf("hibás JSON a(z) %d. elemnél: %w", pos, err)
+        }
+        var doc AddressDocument
+        if err := json.Unmarshal(raw, &doc); err != nil {
+            sink.Fail(pos, err.Error())
+            continue
+        }
+        if err := sink.Add(pos, doc); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// readNDJSONDocuments soronként dolgozza fel a bemenetet; a hibás sorok rekordszintű
+// hibaként kerülnek az összesítőbe, az üres sorokat kihagyja.
+func readNDJSONDocuments(body io.Reader, sink DocumentSink) error {
+    scanner := bufio.NewScanner(body)
+    scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+    pos := 0
+    for scanner.Scan() {
+        line := bytes.TrimSpace(scanner.Bytes())
+        if len(line) == 0 {
+            continue
+        }
+        var doc AddressDocument
+        if err := json.Unmarshal(line, &doc); err != nil {
+            sink.Fail(pos, err.Error())
+        } else if err := sink.Add(pos, doc); err != nil {
+            return err
+        }
+        pos++
+    }
+    return scanner.Err()
+}
+
+// ReadDocumentFile a path fájl rekordjait adja át a sink-nek: .csv kiterjesztésnél vesszővel
+// elválasztott CSV-ként a mapping fejléc-leképezéssel (lásd ImportCSV), egyébként NDJSON-ként
+// vagy JSON tömbként (lásd ReadBulkDocuments).
+func ReadDocumentFile(path string, mapping map[string]string, sink DocumentSink) error {
+    f, err := os.Open(path)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+    if strings.EqualFold(filepath.Ext(path), ".csv") {
+        return ImportCSV(f, ',', mapping, sink)
+    }
+    return ReadBulkDocuments(f, sink)
+}
@@ -0,0 +1,74 @@
+package index
+
+import (
+    "strings"
+
+    "autocomplete/internal/dsl"
+)
+
+// maxCompletionWeight a completion súly legnagyobb értéke (az OpenSearch egész korlátja).
+const maxCompletionWeight = 1<<31 - 1
+
+// CompletionInput egy completion mező bemenete: a kiegészítendő szövegek, a súly és a
+// kategória kontextusok (pl. a település megyéje).
+type CompletionInput struct {
+    Input    []string            `json:"input"`
+    Weight   int                 `json:"weight,omitempty"`
+    Contexts map[string][]string `json:"contexts,omitempty"`
+}
+
+// ContextValue a completion kategória kontextusok egységes alakja (kisbetűs, szóközök nélkül a
+// szélein): indexeléskor és lekérdezéskor is ezt kell használni, így a megye- és
+// településszűrés kis-nagybetű független.
+func ContextValue(value string) string {
+    return strings.ToLower(strings.TrimSpace(value))
+}
+
+// completionFields a "suggest" objektum completion mezői a kontextusaikkal: a településnév a
+// megyére, a közterületnév a településre szűkíthető, ahogy az aggregációs lekérdezésekben.
+func completionFields() map[string]dsl.Property {
+    return map[string]dsl.Property{
+        "telepules":  {Type: "completion", Analyzer: "standard", Contexts: []dsl.CompletionContext{{Name: "megye", Type: "category"}}},
+        "kozter_nev": {Type: "completion", Analyzer: "standard", Contexts: []dsl.CompletionContext{{Name: "telepules", Type: "category"}}},
+        "teljes_cim": {Type: "completion", Analyzer: "standard"},
+    }
+}
+
+// CompletionInputs a dokumentum completion mezőinek bemenetei. A településnév mellett az
+// alternatív nevek is bemenetek; a súly a Weight, ha meg van adva, különben 1. A közterületnév
+// kontextusa a település, így a közterület-javaslatok külön bool szűrő nélkül, a completion
+// suggesteren belül szűkíthetők a kiválasztott településre. A kontextusok a ContextValue alakúak.
+func (d AddressDocument) CompletionInputs() map[string]CompletionInput {
+    weight := d.Weight
+    if weight <= 0 {
+        weight = 1
+    }
+    settlement := CompletionInput{Input: append([]string{d.Telepules}, d.Aliases...), Weight: weight}
+    if megye := ContextValue(d.Megye); megye != "" {
+        settlement.Contexts = map[string][]string{"megye": {megye}}
+    }
+    inputs := map[string]CompletionInput{
+        "telepules":  settlement,
+        "teljes_cim": {Input: []string{d.FullAddress()}, Weight: weight},
+    }
+    if kozter := strings.TrimSpace(d.KozterNev); kozter != "" {
+        inputs["kozter_nev"] = CompletionInput{Input: []string{kozter}, Weight: weight,
+            Contexts: map[string][]string{"telepules": {ContextValue(d.Telepules)}}}
+    }
+    return inputs
+}
+
+// completionReindexScript a _reindex során a forrás dokumentumokból a CompletionInputs-szal
+// egyező "suggest" mezőt képez, így a mapping frissítése a korábban, completion mezők nélkül
+// betöltött dokumentumokat is kiszolgálhatóvá teszi a completion lekérdezési módban.
+const completionReindexScript = `def s = ctx._source;
+int w = s.weight != null && s.weight > 0 ? s.weight : 1;
+def names = new ArrayList(); names.add(s.telepules);
+if (s.aliases != null) { names.addAll(s.aliases); }
+def settlement = ['input': names, 'weight': w];
+if (s.megye != null && s.megye.trim() != '') { settlement.contexts = ['megye': [s.megye.trim().toLowerCase()]]; }
+def full = s.teljes_cim != null && s.teljes_cim != '' ? s.teljes_cim : s.telepules;
+s.suggest = ['telepules': settlement, 'teljes_cim': ['input': [full], 'weight': w]];
+if (s.kozter_nev != null && s.kozter_nev.trim() != '') {
+    s.suggest.kozter_nev = ['input': [s.kozter_nev.trim()], 'weight': w, 'contexts': ['telepules': [s.telepules.trim().toLowerCase()]]];
+}`
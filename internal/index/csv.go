@@ -0,0 +1,177 @@
+package index
+
+import (
+    "encoding/csv"
+    "errors"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+)
+
+// ErrInvalidCSV jelzi, hogy a CSV szerkezete nem dolgozható fel.
+var ErrInvalidCSV = errors.New("érvénytelen CSV")
+
+// ParseHeaderMapping feldolgozza a "fejléc=mező,fejléc=mező" alakú leképezést
+// (CSV_HEADER_MAPPING, pl. "Település=telepules,Közterület=kozter_nev,IRSZ=irsz,Megye=megye").
+func ParseHeaderMapping(spec string) (map[string]string, error) {
+    mapping := map[string]string{}
+    for _, pair := range strings.Split(spec, ",") {
+        if strings.TrimSpace(pair) == "" {
+            continue
+        }
+        parts := strings.SplitN(pair, "=", 2)
+        if len(parts) != 2 {
+            return nil, fmt.Errorf("hibás leképezés: %q", pair)
+        }
+        header, field := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+        if !IsAddressField(field) {
+            return nil, fmt.Errorf("ismeretlen mező a leképezésben: %q", field)
+        }
+        mapping[header] = field
+    }
+    return mapping, nil
+}
+
+// aliasSeparator a CSV "aliases" oszlopában a nevek elválasztója.
+const aliasSeparator = "|"
+
+// IsAddressField jelzi, hogy a név az AddressDocument egy CSV-ből tölthető mezője-e.
+func IsAddressField(field string) bool {
+    switch field {
+    case "id", "telepules", "kozter_nev", "irsz", "megye", "ksh_kod", "lat", "lon", "aliases", "weight", "nepesseg",
+        "hazszam_tol", "hazszam_ig", "hazszam_paritas":
+        return true
+    }
+    return false
+}
+
+// setAddressField beállítja a dokumentum adott nevű mezőjét. A koordinátáknál tizedesvesszőt
+// is elfogad; üres koordinátát kihagy, értelmezhetetlen koordinátánál hibát ad. Az "aliases"
+// oszlop "|" jellel elválasztott neveket tartalmaz; a "nepesseg" (lakosságszám) oszlop a Weight-et tölti.
+func setAddressField(doc *AddressDocument, field, value string) error {
+    value = strings.TrimSpace(value)
+    switch field {
+    case "lat", "lon":
+        if value == "" {
+            return nil
+        }
+        f, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+        if err != nil {
+            return fmt.Errorf("érvénytelen %s érték: %q", field, value)
+        }
+        if field == "lat" {
+            doc.Lat = &f
+        } else {
+            doc.Lon = &f
+        }
+    case "weight", "nepesseg", "hazszam_tol", "hazszam_ig":
+        if value == "" {
+            return nil
+        }
+        n, err := strconv.Atoi(value)
+        if err != nil {
+            return fmt.Errorf("érvénytelen %s érték: %q", field, value)
+        }
+        switch field {
+        case "weight", "nepesseg":
+            doc.Weight = n
+        case "hazszam_tol":
+            doc.HazszamTol = n
+        default:
+            doc.HazszamIg = n
+        }
+    case "hazszam_paritas":
+        doc.HazszamParitas = strings.ToLower(value)
+    case "id":
+        doc.ID = value
+    case "telepules":
+        doc.Telepules = value
+    case "kozter_nev":
+        doc.KozterNev = value
+    case "irsz":
+        doc.Irsz = value
+    case "megye":
+        doc.Megye = value
+    case "ksh_kod":
+        doc.KSHKod = value
+    case "aliases":
+        for _, alias := range strings.Split(value, aliasSeparator) {
+            if alias = strings.TrimSpace(alias); alias != "" {
+                doc.Aliases = append(doc.Aliases, alias)
+            }
+        }
+    }
+    return nil
+}
+
+// csvColumns a fejlécsor alapján oszlopindexenként megadja a célmezőt ("" ha az oszlopot kihagyjuk).
+func csvColumns(header []string, mapping map[string]string) ([]string, error) {
+    columns := make([]string, len(header))
+    hasTelepules := false
+    for i, name := range header {
+        name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+        field, ok := mapping[name]
+        if !ok && IsAddressField(name) {
+            field = name
+        }
+        columns[i] = field
+        if field == "telepules" {
+            hasTelepules = true
+        }
+    }
+    if !hasTelepules {
+        return nil, fmt.Errorf("%w: a fejlécben nincs telepules mezőre leképezett oszlop", ErrInvalidCSV)
+    }
+    return columns, nil
+}
+
+// ImportCSV soronként beolvassa a CSV-t, és a rekordokat a sink-nek adja át. A mapping
+// a fejlécoszlopokat képezi le az AddressDocument mezőire; a leképezésben nem szereplő, de
+// mezőnévvel egyező oszlopokat változatlanul használja. A pozíció a fejléc utáni adatsor
+// (0-tól számolt) sorszáma.
+func ImportCSV(body io.Reader, delimiter rune, mapping map[string]string, sink DocumentSink) error {
+    reader := csv.NewReader(body)
+    reader.Comma = delimiter
+    reader.FieldsPerRecord = -1
+    reader.LazyQuotes = true
+    reader.ReuseRecord = true
+
+    header, err := reader.Read()
+    if err != nil {
+        return fmt.Errorf("%w: a fejléc nem olvasható: %v", ErrInvalidCSV, err)
+    }
+    columns, err := csvColumns(header, mapping)
+    if err != nil {
+        return err
+    }
+
+    for pos := 0; ; pos++ {
+        record, err := reader.Read()
+        if err == io.EOF {
+            return nil
+        }
+        var parseErr *csv.ParseError
+        if errors.As(err, &parseErr) {
+            sink.Fail(pos, parseErr.Error())
+            continue
+        }
+        if err != nil {
+            return err
+        }
+        var doc AddressDocument
+        var fieldErr error
+        for i, value := range record {
+            if i < len(columns) && columns[i] != "" && fieldErr == nil {
+                fieldErr = setAddressField(&doc, columns[i], value)
+            }
+        }
+        if fieldErr != nil {
+            sink.Fail(pos, fieldErr.Error())
+            continue
+        }
+        if err := sink.Add(pos, doc); err != nil {
+            return err
+        }
+    }
+}
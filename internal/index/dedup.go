@@ -0,0 +1,67 @@
+package index
+
+import (
+    "fmt"
+    "strings"
+    "unicode"
+
+    "golang.org/x/text/runes"
+    "golang.org/x/text/transform"
+    "golang.org/x/text/unicode/norm"
+)
+
+// A betöltéskor talált ismétlődő címek kezelése (IMPORT_DUPLICATE_POLICY, lásd BulkIndexer):
+// off esetén nincs ellenőrzés, flag esetén mindkét rekord az indexbe kerül, de az összesítő
+// jelzi őket, merge esetén a cím első előfordulása marad, a későbbi változatok beleolvadnak
+// (nem kerülnek az indexbe).
+const (
+    DuplicatePolicyOff   = "off"
+    DuplicatePolicyFlag  = "flag"
+    DuplicatePolicyMerge = "merge"
+)
+
+// IsDuplicatePolicy jelzi, hogy a policy ismert ismétlődéskezelési mód-e.
+func IsDuplicatePolicy(policy string) bool {
+    return policy == DuplicatePolicyOff || policy == DuplicatePolicyFlag || policy == DuplicatePolicyMerge
+}
+
+// BulkDuplicate egy ismétlődő rekord: az Index és az ID a későbbi, a FirstIndex és a FirstID
+// az első előfordulás pozíciója és azonosítója a bemenetben. A Merged jelzi, hogy a rekord
+// beleolvadt az elsőbe (merge), és nem került az indexbe.
+type BulkDuplicate struct {
+    Index      int    `json:"index"`
+    ID         string `json:"id,omitempty"`
+    FirstIndex int    `json:"firstIndex"`
+    FirstID    string `json:"firstId,omitempty"`
+    Merged     bool   `json:"merged"`
+}
+
+// duplicateOccurrence egy cím első előfordulása a betöltésben.
+type duplicateOccurrence struct {
+    pos int
+    id  string
+}
+
+// addressFoldTransformer az ékezeteket leválasztja és elhagyja.
+var addressFoldTransformer = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// addressKey a cím összevetési kulcsa: a település és a közterület kisbetűs, ékezet nélküli,
+// összevont szóközös alakja és az irányítószám. Így a csak kis- és nagybetűben, szóközökben
+// vagy ékezetekben eltérő rekordok ugyanazt a kulcsot kapják; az eltérő irányítószámú azonos
+// nevű utcák (pl. több kerületen átnyúló budapesti utcák) és egy közterület eltérő
+// házszámtartományú szakaszai nem számítanak ismétlődésnek.
+func addressKey(doc AddressDocument) string {
+    key := foldAddressPart(doc.Telepules) + "\x00" + foldAddressPart(doc.KozterNev) + "\x00" + strings.TrimSpace(doc.Irsz)
+    if doc.HasHouseNumberRange() {
+        key += fmt.Sprintf("\x00%d-%d%s", doc.HazszamTol, doc.HazszamIg, doc.HazszamParitas)
+    }
+    return key
+}
+
+func foldAddressPart(s string) string {
+    s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+    if folded, _, err := transform.String(addressFoldTransformer, s); err == nil {
+        s = folded
+    }
+    return s
+}
@@ -0,0 +1,104 @@
+package index
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "net/url"
+)
+
+var (
+    // ErrDocumentExists jelzi, hogy létrehozáskor már van dokumentum a megadott _id-vel.
+    ErrDocumentExists = errors.New("a dokumentum már létezik")
+    // ErrDocumentNotFound jelzi, hogy nincs dokumentum a megadott _id-vel.
+    ErrDocumentNotFound = errors.New("a dokumentum nem található")
+)
+
+// DocumentResult egy egyedi dokumentumművelet eredménye; a Result az OpenSearch "result"
+// mezője ("created", "updated" vagy "deleted").
+type DocumentResult struct {
+    ID     string `json:"id"`
+    Result string `json:"result"`
+}
+
+// source a dokumentum indexbe kerülő alakja: az _id és a Lat/Lon helyett a képzett
+// teljes_cim, location és suggest mezőkkel.
+func (d AddressDocument) source() AddressDocument {
+    source := d
+    source.ID, source.Lat, source.Lon = "", nil, nil
+    source.TeljesCim = d.FullAddress()
+    source.Location = d.GeoPoint()
+    source.Suggest = d.CompletionInputs()
+    return source
+}
+
+// documentPath az id-jű dokumentum _doc útvonala; refresh esetén a kérés megvárja, hogy a
+// változás a keresésekben is látsszon.
+func (m *Manager) documentPath(id string, refresh bool, params url.Values) string {
+    if refresh {
+        params.Set("refresh", "true")
+    }
+    path := "/" + m.name + "/_doc/" + url.PathEscape(id)
+    if len(params) > 0 {
+        path += "?" + params.Encode()
+    }
+    return path
+}
+
+// PutDocument az id-jű dokumentumot indexeli: create esetén csak akkor, ha még nem létezik
+// (különben ErrDocumentExists), egyébként létrehozza vagy felülírja. Az érvénytelen
+// dokumentumot nem küldi el, hanem a Validate hibáját adja vissza.
+func (m *Manager) PutDocument(ctx context.Context, id string, doc AddressDocument, create, refresh bool) (DocumentResult, error) {
+    if err := doc.Validate(); err != nil {
+        return DocumentResult{}, err
+    }
+    body, err := json.Marshal(doc.source())
+    if err != nil {
+        return DocumentResult{}, err
+    }
+    params := url.Values{}
+    if create {
+        params.Set("op_type", "create")
+    }
+    resp, err := m.client.Do(ctx, "PUT", m.documentPath(id, refresh, params), body, "application/json")
+    if err != nil {
+        return DocumentResult{}, err
+    }
+    switch resp.StatusCode {
+    case http.StatusOK, http.StatusCreated:
+    case http.StatusConflict:
+        return DocumentResult{}, fmt.Errorf("%w: %q", ErrDocumentExists, id)
+    default:
+        return DocumentResult{}, fmt.Errorf("a dokumentum indexelése sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    return documentResult(id, resp.Body)
+}
+
+// DeleteDocument törli az id-jű dokumentumot; ha nincs ilyen, ErrDocumentNotFound hibát ad.
+func (m *Manager) DeleteDocument(ctx context.Context, id string, refresh bool) (DocumentResult, error) {
+    resp, err := m.client.Do(ctx, "DELETE", m.documentPath(id, refresh, url.Values{}), nil, "")
+    if err != nil {
+        return DocumentResult{}, err
+    }
+    switch resp.StatusCode {
+    case http.StatusOK:
+    case http.StatusNotFound:
+        return DocumentResult{}, fmt.Errorf("%w: %q", ErrDocumentNotFound, id)
+    default:
+        return DocumentResult{}, fmt.Errorf("a dokumentum törlése sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    return documentResult(id, resp.Body)
+}
+
+// documentResult az egyedi dokumentumművelet válaszából kiolvassa az eredményt.
+func documentResult(id string, body []byte) (DocumentResult, error) {
+    var result struct {
+        Result string `json:"result"`
+    }
+    if err := json.Unmarshal(body, &result); err != nil {
+        return DocumentResult{}, err
+    }
+    return DocumentResult{ID: id, Result: result.Result}, nil
+}
@@ -0,0 +1,76 @@
+package index
+
+import (
+    "errors"
+    "fmt"
+    "regexp"
+    "strconv"
+    "strings"
+)
+
+// A házszámtartomány oldalai (AddressDocument.HazszamParitas); üres érték esetén a tartomány
+// mindkét oldal házszámait tartalmazza.
+const (
+    ParityEven = "paros"
+    ParityOdd  = "paratlan"
+)
+
+// maxHouseNumber a legnagyobb elfogadott házszám.
+const maxHouseNumber = 99999
+
+// houseNumberPattern az elfogadott házszám alakok: szám, utána legfeljebb egy betűjel vagy
+// albetét ("12", "12.", "12/A", "12 b", "12-C").
+var houseNumberPattern = regexp.MustCompile(`^([0-9]{1,5})\.?(?:\s*[/-]?\s*\pL{1,2}\.?)?$`)
+
+// ErrInvalidHouseNumber jelzi, hogy a házszám nem értelmezhető.
+var ErrInvalidHouseNumber = errors.New("érvénytelen házszám")
+
+// ParseHouseNumber a házszám szám részét adja vissza; a betűjelet és az albetétet elhagyja.
+func ParseHouseNumber(s string) (int, error) {
+    match := houseNumberPattern.FindStringSubmatch(strings.TrimSpace(s))
+    if match == nil {
+        return 0, fmt.Errorf("%w: %q", ErrInvalidHouseNumber, s)
+    }
+    n, _ := strconv.Atoi(match[1])
+    if n < 1 {
+        return 0, fmt.Errorf("%w: %q", ErrInvalidHouseNumber, s)
+    }
+    return n, nil
+}
+
+// HasHouseNumberRange jelzi, hogy a rekordhoz meg van-e adva házszámtartomány.
+func (d AddressDocument) HasHouseNumberRange() bool {
+    return d.HazszamTol > 0
+}
+
+// CoversHouseNumber jelzi, hogy az n házszám a rekord házszámtartományába esik-e, az oldal
+// (páros vagy páratlan) figyelembevételével. Tartomány nélküli rekordra hamis.
+func (d AddressDocument) CoversHouseNumber(n int) bool {
+    if !d.HasHouseNumberRange() || n < d.HazszamTol || n > d.HazszamIg {
+        return false
+    }
+    switch d.HazszamParitas {
+    case ParityEven:
+        return n%2 == 0
+    case ParityOdd:
+        return n%2 == 1
+    }
+    return true
+}
+
+// validateHouseNumberRange ellenőrzi a rekord házszámtartományát.
+func (d AddressDocument) validateHouseNumberRange() error {
+    if d.HazszamTol == 0 && d.HazszamIg == 0 && d.HazszamParitas == "" {
+        return nil
+    }
+    if strings.TrimSpace(d.KozterNev) == "" {
+        return errors.New("házszámtartomány csak közterülettel adható meg")
+    }
+    if d.HazszamTol < 1 || d.HazszamIg < d.HazszamTol || d.HazszamIg > maxHouseNumber {
+        return fmt.Errorf("érvénytelen házszámtartomány: %d–%d (1 ≤ hazszam_tol ≤ hazszam_ig ≤ %d)", d.HazszamTol, d.HazszamIg, maxHouseNumber)
+    }
+    if d.HazszamParitas != "" && d.HazszamParitas != ParityEven && d.HazszamParitas != ParityOdd {
+        return fmt.Errorf("érvénytelen hazszam_paritas: %q (%s vagy %s)", d.HazszamParitas, ParityEven, ParityOdd)
+    }
+    return nil
+}
@@ -0,0 +1,232 @@
+// Package index az országos címlista index sémáját és életciklusát kezeli: létrehozás és
+// törlés, a mapping ellenőrzése és pótlása, tömeges betöltés, CSV import és alias-alapú
+// újraindexelés.
+package index
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "sync"
+    "time"
+
+    "autocomplete/internal/dsl"
+    "autocomplete/internal/opensearch"
+)
+
+// DefaultName az index (újraindexelés után alias) alapértelmezett neve.
+const DefaultName = "orszagos_cimlista"
+
+// A szöveges mezők indexelési stratégiái (INDEX_FIELD_STRATEGY).
+const (
+    // FieldStrategyNgram csak az edge_ngram analyzerrel indexelt szöveges mezőket hozza létre.
+    FieldStrategyNgram = "ngram"
+    // FieldStrategySearchAsYouType a "telepules" és "kozter_nev" mezőkhöz "sayt" search_as_you_type
+    // almezőt is felvesz, amelynek shingle almezőin a prefix illesztés szóhatárokon át is működik.
+    FieldStrategySearchAsYouType = "search_as_you_type"
+)
+
+// SearchAsYouTypeField a FieldStrategySearchAsYouType által felvett almező neve.
+const SearchAsYouTypeField = "sayt"
+
+// MappingVersion a settings és properties által leírt séma verziója, amelyet az index
+// _meta.mapping_version mezője tárol. Minden mapping- vagy analyzer-változáskor növelni
+// kell; a régebbi verziójú indexeket a POST /api/admin/mapping/upgrade frissíti.
+const MappingVersion = 10
+
+// Manager egy index kezelője. A szolgáltatás mindig a Name nevű indexen vagy aliason
+// keresztül dolgozik; az exportált mezőket a használat előtt kell beállítani.
+type Manager struct {
+    client *opensearch.Client
+    name   string
+
+    // BulkBatchSize az egy _bulk kérésbe kerülő dokumentumok maximális száma (BULK_BATCH_SIZE).
+    BulkBatchSize int
+    // PollInterval az OpenSearch _reindex feladat állapotának lekérdezési gyakorisága.
+    PollInterval time.Duration
+    // OnSwap (ha meg van adva) az alias átváltása után fut, pl. a javaslat-gyorsítótár ürítésére.
+    OnSwap func()
+    // DuplicatePolicy a tömeges betöltéskor talált ismétlődő címek kezelése
+    // (IMPORT_DUPLICATE_POLICY, lásd DuplicatePolicyFlag); üresen nincs ellenőrzés.
+    DuplicatePolicy string
+    // SnapshotRepository a pillanatképek OpenSearch tárolója (SNAPSHOT_REPOSITORY); üresen a
+    // pillanatkép műveletek nem használhatók.
+    SnapshotRepository string
+    // FieldStrategy a szöveges mezők indexelési stratégiája (INDEX_FIELD_STRATEGY); üresen FieldStrategyNgram.
+    FieldStrategy string
+    // CardinalityPrecision a Check egyedi értékszámlálásának precision_threshold értéke
+    // (INDEX_CARDINALITY_PRECISION); az ennél kevesebb egyedi érték száma közel pontos.
+    CardinalityPrecision int
+
+    reindexMu  sync.Mutex
+    reindexJob *ReindexJob
+}
+
+// New a client-en keresztül a name indexet kezelő Managert adja vissza.
+func New(client *opensearch.Client, name string) *Manager {
+    return &Manager{client: client, name: name, BulkBatchSize: 500, PollInterval: 2 * time.Second}
+}
+
+// Name az index (vagy alias) neve.
+func (m *Manager) Name() string {
+    return m.name
+}
+
+// settings az index analyzer és normalizer beállításai: az "autocomplete" analyzer a
+// szinonimák (lásd SynonymFilter) kibontása után edge_ngram szűrővel prefixekre bontja a
+// szavakat, az "autocomplete_search" a lekérdezésben bontja ki ugyanazokat a szinonimákat, a
+// "lowercase_normalizer" a kis-nagybetű független keyword mezőkhöz kell. A prefixhosszakat, a
+// tokenizálót és az "autocomplete" szűrőit az a.Analyzer adja (lásd AnalyzerSettings).
+func settings(a analysis) map[string]interface{} {
+    synonyms := a.Synonyms
+    if synonyms == nil {
+        synonyms = []string{}
+    }
+    return map[string]interface{}{
+        "analysis": map[string]interface{}{
+            "filter": map[string]interface{}{
+                AutocompleteFilter: map[string]interface{}{
+                    "type":     "edge_ngram",
+                    "min_gram": a.Analyzer.MinGram,
+                    "max_gram": a.Analyzer.MaxGram,
+                },
+                SynonymFilter: map[string]interface{}{
+                    "type":     "synonym",
+                    "synonyms": synonyms,
+                },
+                // Az ékezet nélküli alakot az eredeti mellett indexeli, így az ékezetek
+                // nélkül normalizált lekérdezések (FOLD_ACCENTS) is illeszkednek.
+                "autocomplete_folding": map[string]interface{}{
+                    "type":              "asciifolding",
+                    "preserve_original": true,
+                },
+            },
+            "analyzer": map[string]interface{}{
+                "autocomplete": map[string]interface{}{
+                    "type":      "custom",
+                    "tokenizer": a.Analyzer.Tokenizer,
+                    "filter":    a.Analyzer.Filters,
+                },
+                "autocomplete_search": map[string]interface{}{
+                    "type":      "custom",
+                    "tokenizer": a.Analyzer.Tokenizer,
+                    "filter": []string{
+                        "lowercase",
+                        SynonymFilter,
+                    },
+                },
+            },
+            "normalizer": map[string]interface{}{
+                "lowercase_normalizer": map[string]interface{}{
+                    "type":   "custom",
+                    "filter": []string{"lowercase"},
+                },
+            },
+        },
+    }
+}
+
+// properties az index mezőinek mappingje,
+// ahol a "telepules" és "kozter_nev" mezőkhöz hozzáadjuk a "keyword" almezőt,
+// a "megye" mezőt pedig kisbetűsítő normalizerrel indexeljük a kis-nagybetű független szűréshez.
+// Az "irsz" (irányítószám) keyword mező a prefix kereséshez és a pontos feloldáshoz kell.
+// A "teljes_cim" a betöltéskor képzett "Település, Közterület" szöveg az egymezős címkereséshez.
+// A "popularity" mezőnként a dokumentum értékének kiválasztásait számolja (POST /api/select),
+// a "location" a település koordinátája a közelség szerinti rangsoroláshoz, az "aliases" a
+// település korábbi és alternatív nevei, amelyekre a településjavaslat szintén illeszkedik.
+// A "ksh_kod" a település KSH azonosítója, a javaslatok és az ellenőrzés válaszában szerepel.
+// A "hazszam_tol", "hazszam_ig" és "hazszam_paritas" a közterület-szakasz házszámtartománya.
+// A "suggest" completion mezői a completion lekérdezési módot szolgálják ki, a "weight" ezek
+// súlya, és a többi módban is rangsorol (lásd suggest.Options.WeightBoost).
+// FieldStrategySearchAsYouType esetén a "telepules" és "kozter_nev" "sayt" almezőt is kap.
+func properties(strategy string) map[string]dsl.Property {
+    keyword := map[string]dsl.Property{"keyword": {Type: "keyword"}}
+    counter := dsl.Property{Type: "long"}
+    nameFields := keyword
+    if strategy == FieldStrategySearchAsYouType {
+        nameFields = map[string]dsl.Property{
+            "keyword":            {Type: "keyword"},
+            SearchAsYouTypeField: {Type: "search_as_you_type", Analyzer: "autocomplete_search"},
+        }
+    }
+    return map[string]dsl.Property{
+        "telepules":       {Type: "text", Analyzer: "autocomplete", SearchAnalyzer: "autocomplete_search", Fields: nameFields},
+        "kozter_nev":      {Type: "text", Analyzer: "autocomplete", SearchAnalyzer: "autocomplete_search", Fields: nameFields},
+        "megye":           {Type: "keyword", Normalizer: "lowercase_normalizer"},
+        "irsz":            {Type: "keyword"},
+        "ksh_kod":         {Type: "keyword"},
+        "hazszam_tol":     {Type: "integer"},
+        "hazszam_ig":      {Type: "integer"},
+        "hazszam_paritas": {Type: "keyword"},
+        "teljes_cim":      {Type: "text", Analyzer: "autocomplete", SearchAnalyzer: "autocomplete_search", Fields: keyword},
+        "aliases":         {Type: "text", Analyzer: "autocomplete", SearchAnalyzer: "autocomplete_search", Fields: keyword},
+        "location":        {Type: "geo_point"},
+        "weight":          {Type: "integer"},
+        "suggest":         {Properties: completionFields()},
+        "popularity":      {Properties: map[string]dsl.Property{"telepules": counter, "kozter_nev": counter, "teljes_cim": counter}},
+    }
+}
+
+// IsSuggestField jelzi, hogy a field olyan szöveges mező-e, amelyen javaslatot lehet kérni: az
+// autocomplete analyzerrel indexelt, "keyword" almezővel rendelkező mezők (pl. "telepules",
+// "kozter_nev", "teljes_cim"). A többmezős javaslatkérés mezőtáblája (SUGGEST_FIELDS) ilyen
+// mezőkre hivatkozhat.
+func IsSuggestField(field string) bool {
+    p, ok := properties(FieldStrategyNgram)[field]
+    _, keyword := p.Fields["keyword"]
+    return ok && p.Type == "text" && p.Analyzer == "autocomplete" && keyword
+}
+
+// Create létrehozza az indexet a settings és properties szerinti beállításokkal.
+func (m *Manager) Create(ctx context.Context) error {
+    return m.CreateNamed(ctx, m.name)
+}
+
+// CreateNamed a megadott nevű indexet hozza létre a Create beállításaival, a jelenlegi index
+// szinonimalistájával és analyzer beállításaival (lásd Synonyms és Analyzer).
+func (m *Manager) CreateNamed(ctx context.Context, name string) error {
+    current, err := m.analysis(ctx)
+    if err != nil {
+        return err
+    }
+    return m.createNamed(ctx, name, current)
+}
+
+// createNamed a megadott nevű indexet az a elemzési beállításaival hozza létre.
+func (m *Manager) createNamed(ctx context.Context, name string, a analysis) error {
+    slog.Info("Új index létrehozása autocomplete beállításokkal", "index", name, "synonyms", len(a.Synonyms),
+        "min_gram", a.Analyzer.MinGram, "max_gram", a.Analyzer.MaxGram, "tokenizer", a.Analyzer.Tokenizer)
+    payload := map[string]interface{}{
+        "settings": settings(a),
+        "mappings": map[string]interface{}{
+            "_meta":      map[string]interface{}{"mapping_version": MappingVersion},
+            "properties": properties(m.FieldStrategy),
+        },
+    }
+    body, _ := json.Marshal(payload)
+    resp, err := m.client.Do(ctx, "PUT", "/"+name, body, "application/json")
+    if err != nil {
+        return fmt.Errorf("hiba az index létrehozásakor: %w", err)
+    }
+    if resp.StatusCode != 200 && resp.StatusCode != 201 {
+        return fmt.Errorf("hiba az index létrehozása során (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    slog.Info("Az index sikeresen létrejött", "index", name)
+    return nil
+}
+
+// Delete törli az indexet az összes dokumentumával együtt.
+func (m *Manager) Delete(ctx context.Context) error {
+    slog.Info("Index törlése", "index", m.name)
+    resp, err := m.client.Do(ctx, "DELETE", "/"+m.name, nil, "")
+    if err != nil {
+        return fmt.Errorf("hiba az index törlésekor: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("hiba az index törlése során (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    slog.Info("Az index törölve", "index", m.name)
+    return nil
+}
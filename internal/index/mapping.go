@@ -0,0 +1,241 @@
+package index
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "sort"
+
+    "autocomplete/internal/dsl"
+)
+
+// MappingCheckResult ad információt az index mapping ellenőrzéséről. A UniqueCount az egyedi
+// települések, a UniqueCounts mezőnként (lásd uniqueCountFields) az egyedi értékek becsült száma
+// (cardinality aggregáció, UniqueCountPrecision alatt közel pontos). A Drift jelzi, hogy
+// az élő mapping verziója eltér a MappingVersion-től, vagy hiányoznak belőle elvárt mezők,
+// analyzerek vagy normalizerek (lásd expectedAnalyzers). A Diff az élő mapping és elemzési
+// beállítások részletes eltérése az elvárt definíciótól (hiányzó és eltérő mezők, analyzerek,
+// prefixhosszak); az analyzer beállításokat az alapértékekkel (DefaultAnalyzer) veti össze, így a
+// SetAnalyzer-rel szándékosan hangolt értékek is megjelennek benne, de a Drift-et nem állítják be.
+type MappingCheckResult struct {
+    FieldMappingExists     bool                `json:"fieldMappingExists"`
+    UniqueCount            int                 `json:"uniqueCount"`
+    UniqueCounts           map[string]int      `json:"uniqueCounts"`
+    UniqueCountPrecision   int                 `json:"uniqueCountPrecision"`
+    MappingVersion         int                 `json:"mappingVersion"`
+    ExpectedMappingVersion int                 `json:"expectedMappingVersion"`
+    MissingFields          []string            `json:"missingFields,omitempty"`
+    MissingAnalyzers       []string            `json:"missingAnalyzers,omitempty"`
+    Diff                   []MappingDifference `json:"diff"`
+    Drift                  bool                `json:"drift"`
+    Debug                  string              `json:"debug,omitempty"`
+}
+
+// Ensure ellenőrzi, hogy az index létezik-e és tartalmazza-e a properties összes mezőjét
+// (a szöveges mezőknél a "keyword" almezőt is). Hiányzó indexet létrehoz, hiányzó mezőket a
+// _mapping API-val pótol, majd _update_by_query-vel újraindexeli a meglévő dokumentumokat, hogy
+// az új mezők rájuk is érvényesek legyenek. Minden lépést naplóz.
+func (m *Manager) Ensure(ctx context.Context) error {
+    resp, err := m.client.Do(ctx, "HEAD", "/"+m.name, nil, "")
+    if err != nil {
+        return fmt.Errorf("az index ellenőrzése sikertelen: %w", err)
+    }
+    if resp.StatusCode == http.StatusNotFound {
+        slog.Info("Index bootstrap: az index nem létezik, létrehozás", "index", m.name)
+        return m.Create(ctx)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("az index ellenőrzése sikertelen (%d)", resp.StatusCode)
+    }
+
+    live, err := m.fetchMapping(ctx)
+    if err != nil {
+        return err
+    }
+    if live.Version < MappingVersion {
+        slog.Warn("Index bootstrap: az index mapping verziója elavult, az analyzerek frissítéséhez POST /api/admin/mapping/upgrade szükséges",
+            "index", m.name, "version", live.Version, "expected", MappingVersion)
+    }
+    missing := missingProperties(live.Properties, properties(m.FieldStrategy))
+    if len(missing) == 0 {
+        slog.Info("Index bootstrap: a mapping naprakész, nincs teendő", "index", m.name)
+        return nil
+    }
+    names := make([]string, 0, len(missing))
+    for name := range missing {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    slog.Info("Index bootstrap: hiányzó mezők hozzáadása", "index", m.name, "fields", names)
+
+    body, _ := json.Marshal(map[string]interface{}{"properties": missing})
+    resp, err = m.client.Do(ctx, "PUT", "/"+m.name+"/_mapping", body, "application/json")
+    if err != nil {
+        return fmt.Errorf("a mapping frissítése sikertelen: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("a mapping frissítése sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    slog.Info("Index bootstrap: mapping frissítve", "index", m.name, "fields", names)
+
+    resp, err = m.client.Do(ctx, "POST", "/"+m.name+"/_update_by_query?conflicts=proceed&wait_for_completion=false", nil, "")
+    if err != nil {
+        return fmt.Errorf("az újraindexelés indítása sikertelen: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("az újraindexelés indítása sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    var task struct {
+        Task string `json:"task"`
+    }
+    json.Unmarshal(resp.Body, &task)
+    slog.Info("Index bootstrap: meglévő dokumentumok újraindexelése elindítva", "index", m.name, "task", task.Task)
+    return nil
+}
+
+// liveMapping az élő index mappingjének a sémaellenőrzéshez szükséges része.
+type liveMapping struct {
+    Properties map[string]dsl.Property
+    // Version a _meta.mapping_version értéke; 0, ha az index még verziózás előtt készült.
+    Version int
+}
+
+// fetchMapping lekéri az index (vagy az alias mögötti index) élő mappingjét.
+func (m *Manager) fetchMapping(ctx context.Context) (liveMapping, error) {
+    resp, err := m.client.Do(ctx, "GET", "/"+m.name+"/_mapping", nil, "")
+    if err != nil {
+        return liveMapping{}, fmt.Errorf("a mapping lekérése sikertelen: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return liveMapping{}, fmt.Errorf("a mapping lekérése sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    return parseMapping(resp.Body)
+}
+
+// parseMapping feldolgozza a _mapping választ. A válasz kulcsa a konkrét index neve,
+// ami alias esetén eltér a Manager nevétől, ezért az összes bejegyzést összevonja.
+func parseMapping(body []byte) (liveMapping, error) {
+    var mapping dsl.MappingResponse
+    if err := json.Unmarshal(body, &mapping); err != nil {
+        return liveMapping{}, err
+    }
+    result := liveMapping{Properties: map[string]dsl.Property{}}
+    for _, index := range mapping {
+        for name, def := range index.Mappings.Properties {
+            result.Properties[name] = def
+        }
+        result.Version = index.Mappings.Meta.MappingVersion
+    }
+    return result, nil
+}
+
+// missingProperties visszaadja az elvárt mezők közül azokat, amelyek (vagy amelyek elvárt
+// almezői) hiányoznak az élő mappingből. Almező hiányánál a teljes mezőleírást adja vissza,
+// mivel a _mapping API így tud új almezőt felvenni egy meglévő mezőhöz.
+func missingProperties(live, expected map[string]dsl.Property) map[string]dsl.Property {
+    missing := map[string]dsl.Property{}
+    for name, def := range expected {
+        liveDef, ok := live[name]
+        if !ok {
+            missing[name] = def
+            continue
+        }
+        for sub := range def.Fields {
+            if _, ok := liveDef.Fields[sub]; !ok {
+                missing[name] = def
+                break
+            }
+        }
+    }
+    return missing
+}
+
+// uniqueCountFields a Check által megszámolt mezők: a települések, a közterületnevek és az
+// irányítószámok a keyword (al)mezőjükkel.
+var uniqueCountFields = map[string]string{
+    "telepules":  "telepules.keyword",
+    "kozter_nev": "kozter_nev.keyword",
+    "irsz":       "irsz",
+}
+
+// expectedAnalyzers az index elemzési beállításainak (lásd settings) azon analyzerei és
+// normalizerei, amelyekre a mapping mezői hivatkoznak.
+var expectedAnalyzers = []string{"autocomplete", "autocomplete_search", "lowercase_normalizer"}
+
+// Check lekéri az index mappingjét és elemzési beállításait, összeveti őket az elvárt
+// definícióval (lásd MappingCheckResult), és aggregációs lekérdezéssel megbecsüli a
+// uniqueCountFields mezők egyedi értékeinek számát.
+func (m *Manager) Check(ctx context.Context) (MappingCheckResult, error) {
+    var result MappingCheckResult
+    var debugBuffer bytes.Buffer
+
+    // Mapping lekérdezés
+    resp, err := m.client.Do(ctx, "GET", "/"+m.name+"/_mapping", nil, "")
+    if err != nil {
+        return result, err
+    }
+    body := resp.Body
+    debugBuffer.WriteString("Mapping lekérdezés válasz body: " + string(body) + "\n")
+    if resp.StatusCode != http.StatusOK {
+        return result, fmt.Errorf("a mapping lekérése sikertelen (%d)", resp.StatusCode)
+    }
+    live, err := parseMapping(body)
+    if err != nil {
+        return result, err
+    }
+    _, result.FieldMappingExists = live.Properties["telepules"].Fields["keyword"]
+    result.MappingVersion = live.Version
+    result.ExpectedMappingVersion = MappingVersion
+    for name := range missingProperties(live.Properties, properties(m.FieldStrategy)) {
+        result.MissingFields = append(result.MissingFields, name)
+    }
+    sort.Strings(result.MissingFields)
+    analysis, err := m.liveAnalysis(ctx)
+    if err != nil {
+        return result, err
+    }
+    result.MissingAnalyzers = missingAnalyzers(analysis)
+    expected := settings(defaultAnalysis())["analysis"].(map[string]interface{})
+    result.Diff = append(diffMapping(live, properties(m.FieldStrategy)), diffAnalysis(analysis, expected)...)
+    if result.Diff == nil {
+        result.Diff = []MappingDifference{}
+    }
+    result.Drift = live.Version != MappingVersion || len(result.MissingFields) > 0 || len(result.MissingAnalyzers) > 0
+
+    // Cardinality aggregáció a mezők egyedi értékeinek megszámolására; a terms aggregációval
+    // szemben nincs vödörkorlát, így a több ezer település is pontosan látszik.
+    aggs := make(map[string]dsl.Agg, len(uniqueCountFields))
+    for name, field := range uniqueCountFields {
+        aggs["unique_"+name] = dsl.CardinalityOf(dsl.CardinalityAgg{Field: field, PrecisionThreshold: m.CardinalityPrecision})
+    }
+    aggBytes, err := json.Marshal(dsl.Search{Size: 0, Aggs: aggs})
+    if err != nil {
+        return result, err
+    }
+    respAgg, err := m.client.Do(ctx, "POST", "/"+m.name+"/_search", aggBytes, "application/json")
+    if err != nil {
+        return result, err
+    }
+    aggBody := respAgg.Body
+    debugBuffer.WriteString("Aggregáció válasz body: " + string(aggBody) + "\n")
+    // Hibás válasznál (pl. érvénytelen precision_threshold vagy hiányzó .keyword almező) az
+    // aggregációk hiányoznának, és minden egyedi érték száma csendben 0 lenne.
+    if respAgg.StatusCode != http.StatusOK {
+        return result, fmt.Errorf("az egyedi értékek megszámolása sikertelen (%d): %s", respAgg.StatusCode, aggBody)
+    }
+    var aggResult dsl.SearchResponse
+    if err := json.Unmarshal(aggBody, &aggResult); err != nil {
+        return result, err
+    }
+    result.UniqueCounts = make(map[string]int, len(uniqueCountFields))
+    for name := range uniqueCountFields {
+        result.UniqueCounts[name] = int(aggResult.Aggregations["unique_"+name].Value)
+    }
+    result.UniqueCount = result.UniqueCounts["telepules"]
+    result.UniqueCountPrecision = m.CardinalityPrecision
+    result.Debug = debugBuffer.String()
+    return result, nil
+}
@@ -0,0 +1,184 @@
+package index
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "sort"
+    "strings"
+
+    "autocomplete/internal/dsl"
+)
+
+// A MappingDifference fajtái.
+const (
+    // DiffVersion: a mapping _meta.mapping_version értéke eltér a MappingVersion-től.
+    DiffVersion = "version"
+    // DiffMissingField: hiányzik egy elvárt mező vagy almező.
+    DiffMissingField = "missing_field"
+    // DiffFieldMismatch: a mező típusa, analyzere, keresési analyzere vagy normalizere eltér.
+    DiffFieldMismatch = "field_mismatch"
+    // DiffMissingAnalyzer: hiányzik egy elvárt analyzer, normalizer vagy szűrő.
+    DiffMissingAnalyzer = "missing_analyzer"
+    // DiffAnalyzerMismatch: egy analyzer, normalizer vagy szűrő beállítása eltér.
+    DiffAnalyzerMismatch = "analyzer_mismatch"
+    // DiffGramSize: az edge_ngram szűrő (AutocompleteFilter) prefixhossza eltér.
+    DiffGramSize = "gram_size"
+)
+
+// MappingDifference az élő index és a szolgáltatás által elvárt definíció egy eltérése. A Path
+// a mapping mezőinél a mező útvonala (pl. "telepules.fields.keyword", "telepules.analyzer"), az
+// elemzési beállításoknál az "analysis." kezdetű beállítás (pl.
+// "analysis.filter.autocomplete_filter.max_gram"). Hiányzó elemnél az Actual üres.
+type MappingDifference struct {
+    Kind     string      `json:"kind"`
+    Path     string      `json:"path"`
+    Expected interface{} `json:"expected,omitempty"`
+    Actual   interface{} `json:"actual,omitempty"`
+}
+
+// liveAnalysis lekéri az index (vagy az alias mögötti index) elemzési beállításait (a _settings
+// válasz index.analysis része).
+func (m *Manager) liveAnalysis(ctx context.Context) (map[string]interface{}, error) {
+    resp, err := m.client.Do(ctx, "GET", "/"+m.name+"/_settings", nil, "")
+    if err != nil {
+        return nil, fmt.Errorf("a beállítások lekérése sikertelen: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("a beállítások lekérése sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    var indexes map[string]struct {
+        Settings struct {
+            Index struct {
+                Analysis map[string]interface{} `json:"analysis"`
+            } `json:"index"`
+        } `json:"settings"`
+    }
+    if err := json.Unmarshal(resp.Body, &indexes); err != nil {
+        return nil, err
+    }
+    for _, index := range indexes {
+        if index.Settings.Index.Analysis != nil {
+            return index.Settings.Index.Analysis, nil
+        }
+    }
+    return map[string]interface{}{}, nil
+}
+
+// missingAnalyzers az expectedAnalyzers közül azokat adja vissza, amelyek hiányoznak az élő
+// elemzési beállításokból.
+func missingAnalyzers(live map[string]interface{}) []string {
+    var missing []string
+    for _, name := range expectedAnalyzers {
+        if component(live, "analyzer", name) == nil && component(live, "normalizer", name) == nil {
+            missing = append(missing, name)
+        }
+    }
+    return missing
+}
+
+// component az elemzési beállítások group ("analyzer", "normalizer", "filter") csoportjának
+// name nevű elemét adja vissza; nil, ha nincs ilyen.
+func component(analysis map[string]interface{}, group, name string) map[string]interface{} {
+    components, _ := analysis[group].(map[string]interface{})
+    def, _ := components[name].(map[string]interface{})
+    return def
+}
+
+// diffMapping összeveti az élő mappinget az elvárt mezőkkel (expected) és a MappingVersion-nel.
+func diffMapping(live liveMapping, expected map[string]dsl.Property) []MappingDifference {
+    var diff []MappingDifference
+    if live.Version != MappingVersion {
+        diff = append(diff, MappingDifference{Kind: DiffVersion, Path: "_meta.mapping_version", Expected: MappingVersion, Actual: live.Version})
+    }
+    return append(diff, diffProperties("", live.Properties, expected)...)
+}
+
+// diffProperties a mezőket (és az al- és beágyazott mezőket) hasonlítja össze; az élő
+// mappingben az elvártakon felül szereplő mezőket nem jelzi.
+func diffProperties(prefix string, live, expected map[string]dsl.Property) []MappingDifference {
+    var diff []MappingDifference
+    for _, name := range sortedKeys(expected) {
+        want, path := expected[name], prefix+name
+        got, ok := live[name]
+        if !ok {
+            diff = append(diff, MappingDifference{Kind: DiffMissingField, Path: path, Expected: want})
+            continue
+        }
+        for _, attr := range []struct{ name, want, got string }{
+            {"type", want.Type, got.Type},
+            {"analyzer", want.Analyzer, got.Analyzer},
+            {"search_analyzer", want.SearchAnalyzer, got.SearchAnalyzer},
+            {"normalizer", want.Normalizer, got.Normalizer},
+        } {
+            if attr.want != "" && attr.want != attr.got {
+                diff = append(diff, MappingDifference{Kind: DiffFieldMismatch, Path: path + "." + attr.name, Expected: attr.want, Actual: attr.got})
+            }
+        }
+        diff = append(diff, diffProperties(path+".fields.", got.Fields, want.Fields)...)
+        diff = append(diff, diffProperties(path+".", got.Properties, want.Properties)...)
+    }
+    return diff
+}
+
+// diffAnalysis összeveti az élő elemzési beállításokat az expected beállításokkal (lásd
+// settings). A szinonimalista futás közben módosítható (lásd SetSynonyms), ezért nem számít
+// eltérésnek; az élő értékeket szövegesen hasonlítja, mert az OpenSearch a számokat és
+// logikai értékeket szövegként adja vissza.
+func diffAnalysis(live, expected map[string]interface{}) []MappingDifference {
+    var diff []MappingDifference
+    for _, group := range []string{"analyzer", "normalizer", "filter"} {
+        components, _ := expected[group].(map[string]interface{})
+        for _, name := range sortedKeys(components) {
+            want, _ := components[name].(map[string]interface{})
+            path := "analysis." + group + "." + name
+            got := component(live, group, name)
+            if got == nil {
+                diff = append(diff, MappingDifference{Kind: DiffMissingAnalyzer, Path: path, Expected: want})
+                continue
+            }
+            for _, key := range sortedKeys(want) {
+                if name == SynonymFilter && key == "synonyms" {
+                    continue
+                }
+                if settingString(want[key]) == settingString(got[key]) {
+                    continue
+                }
+                kind := DiffAnalyzerMismatch
+                if name == AutocompleteFilter && (key == "min_gram" || key == "max_gram") {
+                    kind = DiffGramSize
+                }
+                diff = append(diff, MappingDifference{Kind: kind, Path: path + "." + key, Expected: want[key], Actual: got[key]})
+            }
+        }
+    }
+    return diff
+}
+
+// settingString egy beállítás összehasonlítható szöveges alakja; a listákat elemenként.
+func settingString(v interface{}) string {
+    switch v := v.(type) {
+    case nil:
+        return ""
+    case []string:
+        return "[" + strings.Join(v, " ") + "]"
+    case []interface{}:
+        items := make([]string, len(v))
+        for i, item := range v {
+            items[i] = settingString(item)
+        }
+        return "[" + strings.Join(items, " ") + "]"
+    }
+    return fmt.Sprint(v)
+}
+
+// sortedKeys a map kulcsai rendezve, hogy az eltérések sorrendje determinisztikus legyen.
+func sortedKeys[V any](m map[string]V) []string {
+    keys := make([]string, 0, len(m))
+    for key := range m {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
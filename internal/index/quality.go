@@ -0,0 +1,261 @@
+package index
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "regexp"
+    "sort"
+    "strings"
+    "time"
+    "unicode"
+    "unicode/utf8"
+
+    "autocomplete/internal/dsl"
+)
+
+const (
+    // qualityPageSize a mezőértékek composite aggregációs lapozásakor egy kérésben lekért értékek száma.
+    qualityPageSize = 5000
+    // maxQualityListItems a jelentés listáinak (duplikátumok, gyanús értékek) felső korlátja; a
+    // teljes darabszám a *Total mezőkben szerepel.
+    maxQualityListItems = 500
+)
+
+// qualityPunctuation a település- és közterületnevekben elfogadott írásjelek.
+const qualityPunctuation = ".-,'/()"
+
+// mojibakePattern az UTF-8 szöveg Latin-1/Windows-1250 kódolásként olvasásakor keletkező
+// jellegzetes karakterpárok (pl. "Ã©" é helyett, "Å‘" ő helyett).
+var mojibakePattern = regexp.MustCompile(`[ÃÅ][\x{0080}-\x{00BF}\x{2010}-\x{203A}]`)
+
+// QualityReport az index adatminőségi jelentése az adatcsapat számára.
+type QualityReport struct {
+    Index       string    `json:"index"`
+    Documents   int       `json:"documents"`
+    GeneratedAt time.Time `json:"generatedAt"`
+    // DuplicateSettlements a csak kis- és nagybetűben vagy szóközökben eltérő településnevek csoportjai.
+    DuplicateSettlements      []DuplicateGroup `json:"duplicateSettlements"`
+    DuplicateSettlementsTotal int              `json:"duplicateSettlementsTotal"`
+    // MissingZip az irányítószám nélküli, EmptyStreet az üres (vagy csak szóközből álló)
+    // közterületnevű rekordok.
+    MissingZip  QualityIssue `json:"missingZip"`
+    EmptyStreet QualityIssue `json:"emptyStreet"`
+    // SuspiciousValues a gyanús karaktereket tartalmazó település- és közterületnevek.
+    SuspiciousValues      []SuspiciousValue `json:"suspiciousValues"`
+    SuspiciousValuesTotal int               `json:"suspiciousValuesTotal"`
+}
+
+// DuplicateGroup az azonos normalizált alakú (kisbetűs, egyszeres szóközös) értékváltozatok.
+type DuplicateGroup struct {
+    Normalized string       `json:"normalized"`
+    Variants   []ValueCount `json:"variants"`
+}
+
+// ValueCount egy mezőérték és a dokumentumok száma, amelyekben szerepel.
+type ValueCount struct {
+    Value string `json:"value"`
+    Count int    `json:"count"`
+}
+
+// QualityIssue egy hibatípus előfordulásainak száma néhány minta rekorddal.
+type QualityIssue struct {
+    Count   int             `json:"count"`
+    Samples []QualitySample `json:"samples"`
+}
+
+// QualitySample egy hibás rekord azonosítója és főbb mezői.
+type QualitySample struct {
+    ID        string `json:"id"`
+    Telepules string `json:"telepules"`
+    KozterNev string `json:"kozter_nev,omitempty"`
+    Irsz      string `json:"irsz,omitempty"`
+}
+
+// SuspiciousValue egy gyanús mezőérték az okkal és a dokumentumok számával.
+type SuspiciousValue struct {
+    Field  string `json:"field"`
+    Value  string `json:"value"`
+    Reason string `json:"reason"`
+    Count  int    `json:"count"`
+}
+
+// QualityReport lefuttatja az adatminőségi ellenőrzéseket: egy aggregációs lekérdezés számolja
+// az irányítószám nélküli és az üres közterületnevű rekordokat (hibatípusonként legfeljebb
+// samples mintával), a település- és közterületnevek egyedi értékein pedig composite
+// aggregációval végiglapozva keresi a duplikátumokat és a gyanús karaktereket.
+func (m *Manager) QualityReport(ctx context.Context, samples int) (QualityReport, error) {
+    report := QualityReport{Index: m.name, GeneratedAt: time.Now().UTC(), DuplicateSettlements: []DuplicateGroup{}, SuspiciousValues: []SuspiciousValue{}}
+    if err := m.qualityCounts(ctx, samples, &report); err != nil {
+        return report, err
+    }
+
+    variants := map[string][]ValueCount{}
+    err := m.compositeValues(ctx, "telepules.keyword", func(value string, count int) {
+        normalized := strings.ToLower(strings.Join(strings.Fields(value), " "))
+        variants[normalized] = append(variants[normalized], ValueCount{Value: value, Count: count})
+        report.addSuspicious("telepules", value, count)
+    })
+    if err != nil {
+        return report, err
+    }
+    for normalized, values := range variants {
+        if len(values) < 2 {
+            continue
+        }
+        report.DuplicateSettlementsTotal++
+        report.DuplicateSettlements = append(report.DuplicateSettlements, DuplicateGroup{Normalized: normalized, Variants: values})
+    }
+    sort.Slice(report.DuplicateSettlements, func(i, j int) bool {
+        return report.DuplicateSettlements[i].Normalized < report.DuplicateSettlements[j].Normalized
+    })
+    if len(report.DuplicateSettlements) > maxQualityListItems {
+        report.DuplicateSettlements = report.DuplicateSettlements[:maxQualityListItems]
+    }
+
+    err = m.compositeValues(ctx, "kozter_nev.keyword", func(value string, count int) {
+        report.addSuspicious("kozter_nev", value, count)
+    })
+    return report, err
+}
+
+// qualityCounts a dokumentumok, az irányítószám nélküli és az üres közterületnevű rekordok számát
+// és mintáit kérdezi le egyetlen kérésben.
+func (m *Manager) qualityCounts(ctx context.Context, samples int, report *QualityReport) error {
+    source := []string{"telepules", "kozter_nev", "irsz"}
+    missingZip := dsl.Bool(dsl.BoolQuery{Should: []dsl.Query{
+        dsl.Bool(dsl.BoolQuery{MustNot: []dsl.Query{dsl.Exists("irsz")}}),
+        dsl.Term("irsz", ""),
+    }})
+    emptyStreet := dsl.Bool(dsl.BoolQuery{Should: []dsl.Query{
+        dsl.Term("kozter_nev.keyword", ""),
+        {"regexp": map[string]string{"kozter_nev.keyword": "[ \t]+"}},
+    }})
+    payload, err := json.Marshal(dsl.Search{
+        Size:           0,
+        TrackTotalHits: true,
+        Aggs: map[string]dsl.Agg{
+            "missing_zip":  dsl.FilterAgg(missingZip).With(map[string]dsl.Agg{"samples": dsl.TopHits(samples, source...)}),
+            "empty_street": dsl.FilterAgg(emptyStreet).With(map[string]dsl.Agg{"samples": dsl.TopHits(samples, source...)}),
+        },
+    })
+    if err != nil {
+        return err
+    }
+    resp, err := m.client.Do(ctx, "POST", "/"+m.name+"/_search", payload, "application/json")
+    if err != nil {
+        return err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("OpenSearch hiba (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    var result dsl.SearchResponse
+    if err := json.Unmarshal(resp.Body, &result); err != nil {
+        return err
+    }
+    report.Documents = result.Hits.Total.Value
+    report.MissingZip = qualityIssue(result.Aggregations["missing_zip"])
+    report.EmptyStreet = qualityIssue(result.Aggregations["empty_street"])
+    return nil
+}
+
+func qualityIssue(agg dsl.AggResult) QualityIssue {
+    issue := QualityIssue{Count: agg.DocCount, Samples: []QualitySample{}}
+    for _, hit := range agg.Sub["samples"].Hits.Hits {
+        var doc AddressDocument
+        if err := hit.Decode(&doc); err != nil {
+            continue
+        }
+        issue.Samples = append(issue.Samples, QualitySample{ID: hit.ID, Telepules: doc.Telepules, KozterNev: doc.KozterNev, Irsz: doc.Irsz})
+    }
+    return issue
+}
+
+// compositeValues a field összes egyedi értékén composite aggregációval lapoz végig, és
+// mindegyikre meghívja az fn-t az érték dokumentumszámával.
+func (m *Manager) compositeValues(ctx context.Context, field string, fn func(value string, count int)) error {
+    var after map[string]interface{}
+    for {
+        composite := map[string]interface{}{
+            "size":    qualityPageSize,
+            "sources": []interface{}{map[string]interface{}{"value": map[string]interface{}{"terms": map[string]string{"field": field}}}},
+        }
+        if after != nil {
+            composite["after"] = after
+        }
+        payload, err := json.Marshal(dsl.Search{Size: 0, Aggs: map[string]dsl.Agg{"values": {"composite": composite}}})
+        if err != nil {
+            return err
+        }
+        resp, err := m.client.Do(ctx, "POST", "/"+m.name+"/_search", payload, "application/json")
+        if err != nil {
+            return err
+        }
+        if resp.StatusCode != http.StatusOK {
+            return fmt.Errorf("OpenSearch hiba (%d): %s", resp.StatusCode, string(resp.Body))
+        }
+        var result struct {
+            Aggregations struct {
+                Values struct {
+                    AfterKey map[string]interface{} `json:"after_key"`
+                    Buckets  []struct {
+                        Key struct {
+                            Value string `json:"value"`
+                        } `json:"key"`
+                        DocCount int `json:"doc_count"`
+                    } `json:"buckets"`
+                } `json:"values"`
+            } `json:"aggregations"`
+        }
+        if err := json.Unmarshal(resp.Body, &result); err != nil {
+            return err
+        }
+        values := result.Aggregations.Values
+        for _, bucket := range values.Buckets {
+            fn(bucket.Key.Value, bucket.DocCount)
+        }
+        if len(values.Buckets) < qualityPageSize || values.AfterKey == nil {
+            return nil
+        }
+        after = values.AfterKey
+    }
+}
+
+// addSuspicious felveszi az értéket a gyanús értékek közé, ha a suspiciousReason talál benne hibát.
+func (r *QualityReport) addSuspicious(field, value string, count int) {
+    reason := suspiciousReason(value)
+    if reason == "" {
+        return
+    }
+    r.SuspiciousValuesTotal++
+    if len(r.SuspiciousValues) < maxQualityListItems {
+        r.SuspiciousValues = append(r.SuspiciousValues, SuspiciousValue{Field: field, Value: value, Reason: reason, Count: count})
+    }
+}
+
+// suspiciousReason megadja, miért gyanús az érték (üres szöveg, ha nem az). Az üres és a csak
+// szóközből álló értékeket nem jelzi, azokat az EmptyStreet számolja.
+func suspiciousReason(value string) string {
+    switch {
+    case strings.TrimSpace(value) == "":
+        return ""
+    case !utf8.ValidString(value) || strings.ContainsRune(value, utf8.RuneError):
+        return "érvénytelen UTF-8 vagy cserekarakter"
+    case mojibakePattern.MatchString(value):
+        return "hibás karakterkódolás"
+    case value != strings.TrimSpace(value):
+        return "szóköz az elején vagy a végén"
+    case strings.Contains(value, "  "):
+        return "többszörös szóköz"
+    }
+    for _, r := range value {
+        switch {
+        case unicode.IsControl(r):
+            return fmt.Sprintf("vezérlőkarakter: %U", r)
+        case !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != ' ' && !strings.ContainsRune(qualityPunctuation, r):
+            return fmt.Sprintf("szokatlan karakter: %q", r)
+        }
+    }
+    return ""
+}
@@ -0,0 +1,396 @@
+package index
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Az újraindexelési feladat állapotai.
+const (
+    ReindexRunning        = "running"
+    ReindexAwaitingImport = "awaiting_import"
+    ReindexSwapping       = "swapping"
+    ReindexCompleted      = "completed"
+    ReindexFailed         = "failed"
+)
+
+// Az újraindexelés módjai: a régi index tartalmának átmásolása (_reindex), üres célindex,
+// amelyet a bulk/CSV import tölt fel a célindex nevével, illetve pillanatkép visszaállítása a
+// célindexbe (lásd StartRestore).
+const (
+    ReindexModeReindex = "reindex"
+    ReindexModeImport  = "import"
+    ReindexModeRestore = "restore"
+)
+
+var (
+    // ErrReindexInProgress jelzi, hogy már fut egy újraindexelés.
+    ErrReindexInProgress = errors.New("már folyamatban van egy újraindexelés")
+    // ErrNoReindexSource jelzi, hogy reindex módban nincs átmásolható forrás index.
+    ErrNoReindexSource = errors.New("nincs átmásolható forrás index")
+    // ErrNoAwaitingImport jelzi, hogy nincs importra váró, átváltható újraindexelés.
+    ErrNoAwaitingImport = errors.New("nincs importra váró újraindexelés")
+)
+
+// ReindexJob egy blue/green újraindexelés állapota. A szolgáltatás mindig a Manager
+// nevével egyező aliason keresztül kérdez; a célindex (<név>_vN) feltöltése után az alias
+// egyetlen atomi _aliases kéréssel vált át rá. Restore módban a Snapshot a visszaállított
+// pillanatkép neve, a Total és a Created a visszaállítandó és a már visszaállított bájtok száma.
+type ReindexJob struct {
+    Mode       string     `json:"mode"`
+    Alias      string     `json:"alias"`
+    Source     string     `json:"source,omitempty"`
+    Target     string     `json:"target"`
+    Task       string     `json:"task,omitempty"`
+    Snapshot   string     `json:"snapshot,omitempty"`
+    Status     string     `json:"status"`
+    Total      int64      `json:"total"`
+    Created    int64      `json:"created"`
+    Updated    int64      `json:"updated"`
+    DeleteOld  bool       `json:"deleteOld"`
+    StartedAt  time.Time  `json:"startedAt"`
+    FinishedAt *time.Time `json:"finishedAt,omitempty"`
+    Error      string     `json:"error,omitempty"`
+
+    // sourceConcrete jelzi, hogy a forrás maga az alias nevű index (még nem alias);
+    // ilyenkor a váltás a régi indexet törli, hogy a név aliasként felszabaduljon.
+    sourceConcrete bool
+}
+
+// CurrentReindex visszaadja az utolsó újraindexelés állapotának másolatát (nil, ha még nem volt).
+func (m *Manager) CurrentReindex() *ReindexJob {
+    m.reindexMu.Lock()
+    defer m.reindexMu.Unlock()
+    if m.reindexJob == nil {
+        return nil
+    }
+    job := *m.reindexJob
+    return &job
+}
+
+// updateReindex a zár alatt módosítja a folyamatban lévő feladatot.
+func (m *Manager) updateReindex(fn func(job *ReindexJob)) {
+    m.reindexMu.Lock()
+    defer m.reindexMu.Unlock()
+    fn(m.reindexJob)
+}
+
+// finishReindex lezárja a feladatot a megadott állapottal.
+func (m *Manager) finishReindex(status string, err error) {
+    m.updateReindex(func(job *ReindexJob) {
+        now := time.Now()
+        job.Status = status
+        job.FinishedAt = &now
+        if err != nil {
+            job.Error = err.Error()
+        }
+    })
+    job := m.CurrentReindex()
+    if err != nil {
+        slog.Error("Reindex failed", "source", job.Source, "target", job.Target, "error", err)
+    } else {
+        slog.Info("Reindex finished", "source", job.Source, "target", job.Target, "status", status)
+    }
+}
+
+// IsImportTarget jelzi, hogy az index név megadható-e import célként: az alias maga,
+// vagy az import módban várakozó újraindexelés célindexe.
+func (m *Manager) IsImportTarget(name string) bool {
+    if name == m.name {
+        return true
+    }
+    job := m.CurrentReindex()
+    return job != nil && job.Status == ReindexAwaitingImport && job.Target == name
+}
+
+// resolveAliasSource megadja, melyik index áll jelenleg az alias mögött. Ha a név még
+// konkrét index, a concrete igaz; ha egyik sem létezik, a név üres.
+func (m *Manager) resolveAliasSource(ctx context.Context) (name string, concrete bool, err error) {
+    resp, err := m.client.Do(ctx, "GET", "/_alias/"+m.name, nil, "")
+    if err != nil {
+        return "", false, err
+    }
+    if resp.StatusCode == http.StatusOK {
+        var aliases map[string]interface{}
+        if err := json.Unmarshal(resp.Body, &aliases); err != nil {
+            return "", false, err
+        }
+        if len(aliases) != 1 {
+            return "", false, fmt.Errorf("a(z) %s alias %d indexre mutat, egyre kellene", m.name, len(aliases))
+        }
+        for index := range aliases {
+            return index, false, nil
+        }
+    }
+    resp, err = m.client.Do(ctx, "HEAD", "/"+m.name, nil, "")
+    if err != nil {
+        return "", false, err
+    }
+    switch resp.StatusCode {
+    case http.StatusOK:
+        return m.name, true, nil
+    case http.StatusNotFound:
+        return "", false, nil
+    }
+    return "", false, fmt.Errorf("az index ellenőrzése sikertelen (%d)", resp.StatusCode)
+}
+
+// nextIndexVersion a forrás index nevéből képzi a célindex nevét: <név>_vN után
+// <név>_v(N+1), minden más esetben <név>_v2.
+func (m *Manager) nextIndexVersion(source string) string {
+    version := 1
+    if suffix := strings.TrimPrefix(source, m.name+"_v"); suffix != source {
+        if n, err := strconv.Atoi(suffix); err == nil && n > 0 {
+            version = n
+        }
+    }
+    return fmt.Sprintf("%s_v%d", m.name, version+1)
+}
+
+// StartReindex létrehozza a célindexet, és reindex módban elindítja a háttérben futó
+// _reindex feladatot (amely a completion mezőket is újraképzi, lásd completionReindexScript),
+// amelynek végén az alias automatikusan átvált. Import módban a
+// célindex az ImportSwap hívásáig várakozik. Ha már fut egy feladat, ErrReindexInProgress,
+// ha reindex módban nincs forrás, ErrNoReindexSource hibát ad.
+func (m *Manager) StartReindex(ctx context.Context, mode string, deleteOld bool) (*ReindexJob, error) {
+    return m.startReindex(ctx, mode, deleteOld, nil)
+}
+
+// startReindex a StartReindex megvalósítása; a célindex a jelenlegi index elemzési beállításaival
+// készül, amelyeket a nem nil update előtte módosít (pl. új szinonimalistára).
+func (m *Manager) startReindex(ctx context.Context, mode string, deleteOld bool, update func(*analysis)) (*ReindexJob, error) {
+    job, err := m.beginReindex(ctx, mode, deleteOld)
+    if err != nil {
+        return nil, err
+    }
+    fail := func(err error) (*ReindexJob, error) {
+        m.finishReindex(ReindexFailed, err)
+        return nil, err
+    }
+    source, target := job.Source, job.Target
+    current, err := m.analysis(ctx)
+    if err != nil {
+        return fail(err)
+    }
+    if update != nil {
+        update(&current)
+    }
+    if err := m.createNamed(ctx, target, current); err != nil {
+        return fail(err)
+    }
+
+    if mode == ReindexModeImport {
+        m.updateReindex(func(job *ReindexJob) { job.Status = ReindexAwaitingImport })
+        slog.Info("Reindex awaiting import", "source", source, "target", target)
+        return m.CurrentReindex(), nil
+    }
+
+    body, _ := json.Marshal(map[string]interface{}{
+        "source": map[string]interface{}{"index": source},
+        "dest":   map[string]interface{}{"index": target},
+        "script": map[string]interface{}{"lang": "painless", "source": completionReindexScript},
+    })
+    resp, err := m.client.Do(ctx, "POST", "/_reindex?wait_for_completion=false", body, "application/json")
+    if err != nil {
+        return fail(err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fail(fmt.Errorf("a _reindex indítása sikertelen (%d): %s", resp.StatusCode, string(resp.Body)))
+    }
+    var task struct {
+        Task string `json:"task"`
+    }
+    json.Unmarshal(resp.Body, &task)
+    m.updateReindex(func(job *ReindexJob) { job.Task = task.Task })
+    slog.Info("Reindex started", "source", source, "target", target, "task", task.Task)
+
+    go m.watchReindexTask(task.Task)
+    return m.CurrentReindex(), nil
+}
+
+// beginReindex lefoglalja az újraindexelési feladatot, és kijelöli a forrás- és célindexet. Ha
+// már fut egy feladat, ErrReindexInProgress, ha reindex módban nincs forrás, ErrNoReindexSource
+// hibát ad; a további hibáknál a feladatot sikertelenként lezárja.
+func (m *Manager) beginReindex(ctx context.Context, mode string, deleteOld bool) (*ReindexJob, error) {
+    m.reindexMu.Lock()
+    if m.reindexJob != nil && m.reindexJob.FinishedAt == nil {
+        target := m.reindexJob.Target
+        m.reindexMu.Unlock()
+        return nil, fmt.Errorf("%w (%s)", ErrReindexInProgress, target)
+    }
+    // A helyfoglalás megakadályozza, hogy két kérés egyszerre induljon el.
+    m.reindexJob = &ReindexJob{Mode: mode, Alias: m.name, Status: ReindexRunning, StartedAt: time.Now()}
+    m.reindexMu.Unlock()
+
+    source, concrete, err := m.resolveAliasSource(ctx)
+    if err == nil && source == "" && mode == ReindexModeReindex {
+        err = fmt.Errorf("%w (%s)", ErrNoReindexSource, m.name)
+    }
+    if err != nil {
+        m.finishReindex(ReindexFailed, err)
+        return nil, err
+    }
+    m.updateReindex(func(job *ReindexJob) {
+        job.Source = source
+        job.Target = m.nextIndexVersion(source)
+        job.DeleteOld = deleteOld
+        job.sourceConcrete = concrete
+    })
+    return m.CurrentReindex(), nil
+}
+
+// watchReindexTask PollInterval időközönként lekérdezi a _reindex feladat állapotát,
+// frissíti az előrehaladást, és hibátlan befejezés után átváltja az aliast.
+func (m *Manager) watchReindexTask(taskID string) {
+    ctx := context.Background()
+    ticker := time.NewTicker(m.PollInterval)
+    defer ticker.Stop()
+    for range ticker.C {
+        resp, err := m.client.Do(ctx, "GET", "/_tasks/"+taskID, nil, "")
+        if err != nil {
+            slog.Warn("Reindex task poll failed", "task", taskID, "error", err)
+            continue
+        }
+        if resp.StatusCode != http.StatusOK {
+            m.finishReindex(ReindexFailed, fmt.Errorf("a _reindex feladat nem kérdezhető le (%d): %s", resp.StatusCode, string(resp.Body)))
+            return
+        }
+        var status struct {
+            Completed bool `json:"completed"`
+            Task      struct {
+                Status struct {
+                    Total   int64 `json:"total"`
+                    Created int64 `json:"created"`
+                    Updated int64 `json:"updated"`
+                } `json:"status"`
+            } `json:"task"`
+            Response struct {
+                Failures []json.RawMessage `json:"failures"`
+            } `json:"response"`
+            Error json.RawMessage `json:"error"`
+        }
+        if err := json.Unmarshal(resp.Body, &status); err != nil {
+            m.finishReindex(ReindexFailed, err)
+            return
+        }
+        m.updateReindex(func(job *ReindexJob) {
+            job.Total = status.Task.Status.Total
+            job.Created = status.Task.Status.Created
+            job.Updated = status.Task.Status.Updated
+        })
+        if !status.Completed {
+            continue
+        }
+        if len(status.Error) > 0 {
+            m.finishReindex(ReindexFailed, fmt.Errorf("a _reindex feladat hibával zárult: %s", string(status.Error)))
+            return
+        }
+        if len(status.Response.Failures) > 0 {
+            m.finishReindex(ReindexFailed, fmt.Errorf("a _reindex %d dokumentumnál hibát jelzett: %s", len(status.Response.Failures), string(status.Response.Failures[0])))
+            return
+        }
+        if err := m.swapAlias(ctx); err != nil {
+            m.finishReindex(ReindexFailed, err)
+            return
+        }
+        m.finishReindex(ReindexCompleted, nil)
+        return
+    }
+}
+
+// ImportSwap import módban a célindex feltöltése után átváltja az aliast, és a lezárt
+// feladatot adja vissza. Ha nincs importra váró újraindexelés, ErrNoAwaitingImport hibát ad.
+func (m *Manager) ImportSwap(ctx context.Context) (*ReindexJob, error) {
+    m.reindexMu.Lock()
+    if m.reindexJob == nil || m.reindexJob.Status != ReindexAwaitingImport {
+        m.reindexMu.Unlock()
+        return nil, ErrNoAwaitingImport
+    }
+    m.reindexJob.Status = ReindexSwapping
+    m.reindexMu.Unlock()
+
+    if err := m.swapAlias(ctx); err != nil {
+        m.finishReindex(ReindexFailed, err)
+        return nil, err
+    }
+    m.finishReindex(ReindexCompleted, nil)
+    return m.CurrentReindex(), nil
+}
+
+// AbortImport import módban sikertelenként lezárja a várakozó újraindexelést a cause hibával, és
+// törli a félig feltöltött célindexet; az alias a régi indexen marad. Ha nincs importra váró
+// újraindexelés, ErrNoAwaitingImport hibát ad.
+func (m *Manager) AbortImport(ctx context.Context, cause error) error {
+    m.reindexMu.Lock()
+    if m.reindexJob == nil || m.reindexJob.Status != ReindexAwaitingImport {
+        m.reindexMu.Unlock()
+        return ErrNoAwaitingImport
+    }
+    // Az állapot azonnali átírása kizárja a közben érkező ImportSwap-ot.
+    m.reindexJob.Status = ReindexFailed
+    target := m.reindexJob.Target
+    m.reindexMu.Unlock()
+
+    m.finishReindex(ReindexFailed, cause)
+    resp, err := m.client.Do(ctx, "DELETE", "/"+target, nil, "")
+    if err == nil && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+        err = fmt.Errorf("a célindex törlése sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    return err
+}
+
+// swapAlias frissíti a célindexet, majd egyetlen _aliases kérésben az aliast a célindexre
+// állítja. Ha a forrás konkrét index volt, ugyanebben a kérésben törlődik, különben
+// deleteOld esetén a váltás után. Végül meghívja az OnSwap-ot.
+func (m *Manager) swapAlias(ctx context.Context) error {
+    job := m.CurrentReindex()
+    m.updateReindex(func(job *ReindexJob) { job.Status = ReindexSwapping })
+
+    resp, err := m.client.Do(ctx, "POST", "/"+job.Target+"/_refresh", nil, "")
+    if err != nil {
+        return fmt.Errorf("a célindex frissítése sikertelen: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("a célindex frissítése sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+
+    actions := []map[string]interface{}{
+        {"add": map[string]interface{}{"index": job.Target, "alias": m.name}},
+    }
+    switch {
+    case job.sourceConcrete:
+        actions = append(actions, map[string]interface{}{"remove_index": map[string]interface{}{"index": job.Source}})
+    case job.Source != "":
+        actions = append(actions, map[string]interface{}{"remove": map[string]interface{}{"index": job.Source, "alias": m.name}})
+    }
+    body, _ := json.Marshal(map[string]interface{}{"actions": actions})
+    resp, err = m.client.Do(ctx, "POST", "/_aliases", body, "application/json")
+    if err != nil {
+        return fmt.Errorf("az alias váltása sikertelen: %w", err)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("az alias váltása sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    slog.Info("Alias swapped", "alias", m.name, "from", job.Source, "to", job.Target)
+    if m.OnSwap != nil {
+        m.OnSwap()
+    }
+
+    if job.DeleteOld && job.Source != "" && !job.sourceConcrete {
+        resp, err := m.client.Do(ctx, "DELETE", "/"+job.Source, nil, "")
+        if err != nil || resp.StatusCode != http.StatusOK {
+            // Az alias már átváltott, a régi index megmaradása nem teszi sikertelenné a feladatot.
+            slog.Warn("Old index could not be deleted", "index", job.Source, "error", err)
+        } else {
+            slog.Info("Old index deleted", "index", job.Source)
+        }
+    }
+    return nil
+}
@@ -0,0 +1,271 @@
+package index
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "net/url"
+    "regexp"
+    "strings"
+    "time"
+)
+
+var (
+    // ErrSnapshotNotFound jelzi, hogy a tárolóban nincs ilyen nevű pillanatkép.
+    ErrSnapshotNotFound = errors.New("a pillanatkép nem található")
+    // ErrSnapshotExists jelzi, hogy a tárolóban már van ilyen nevű pillanatkép.
+    ErrSnapshotExists = errors.New("már létezik ilyen nevű pillanatkép")
+    // ErrSnapshotInProgress jelzi, hogy a tárolóban már fut egy pillanatkép készítése.
+    ErrSnapshotInProgress = errors.New("már folyamatban van egy pillanatkép készítése")
+    // ErrInvalidSnapshotName jelzi, hogy a név nem használható pillanatkép névként.
+    ErrInvalidSnapshotName = errors.New("érvénytelen pillanatkép név (kisbetűk, számjegyek, '.', '_' és '-')")
+)
+
+// snapshotNamePattern az elfogadott pillanatkép nevek; az OpenSearch csak kisbetűs, szóköz és
+// speciális karakterek nélküli nevet enged.
+var snapshotNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,254}$`)
+
+// Snapshot egy pillanatkép állapota a SnapshotRepository tárolóban. A State az OpenSearch
+// állapota: IN_PROGRESS, SUCCESS, PARTIAL vagy FAILED.
+type Snapshot struct {
+    Name             string     `json:"name"`
+    Repository       string     `json:"repository"`
+    State            string     `json:"state"`
+    Indices          []string   `json:"indices"`
+    ShardsTotal      int        `json:"shardsTotal"`
+    ShardsSuccessful int        `json:"shardsSuccessful"`
+    ShardsFailed     int        `json:"shardsFailed"`
+    StartedAt        *time.Time `json:"startedAt,omitempty"`
+    FinishedAt       *time.Time `json:"finishedAt,omitempty"`
+    Failures         []string   `json:"failures,omitempty"`
+}
+
+// snapshotInfo a _snapshot API egy pillanatképének leírása.
+type snapshotInfo struct {
+    Snapshot          string   `json:"snapshot"`
+    State             string   `json:"state"`
+    Indices           []string `json:"indices"`
+    StartTimeInMillis int64    `json:"start_time_in_millis"`
+    EndTimeInMillis   int64    `json:"end_time_in_millis"`
+    Failures          []struct {
+        Index  string `json:"index"`
+        Reason string `json:"reason"`
+    } `json:"failures"`
+    Shards struct {
+        Total      int `json:"total"`
+        Successful int `json:"successful"`
+        Failed     int `json:"failed"`
+    } `json:"shards"`
+}
+
+func (i snapshotInfo) snapshot(repository string) Snapshot {
+    s := Snapshot{
+        Name:             i.Snapshot,
+        Repository:       repository,
+        State:            i.State,
+        Indices:          i.Indices,
+        ShardsTotal:      i.Shards.Total,
+        ShardsSuccessful: i.Shards.Successful,
+        ShardsFailed:     i.Shards.Failed,
+    }
+    if i.StartTimeInMillis > 0 {
+        t := time.UnixMilli(i.StartTimeInMillis).UTC()
+        s.StartedAt = &t
+    }
+    if i.EndTimeInMillis > 0 {
+        t := time.UnixMilli(i.EndTimeInMillis).UTC()
+        s.FinishedAt = &t
+    }
+    for _, f := range i.Failures {
+        s.Failures = append(s.Failures, f.Index+": "+f.Reason)
+    }
+    return s
+}
+
+// snapshotPath a SnapshotRepository tároló (és a name pillanatkép) _snapshot útvonala.
+func (m *Manager) snapshotPath(name string) string {
+    path := "/_snapshot/" + url.PathEscape(m.SnapshotRepository)
+    if name != "" {
+        path += "/" + url.PathEscape(name)
+    }
+    return path
+}
+
+// CreateSnapshot a háttérben pillanatképet készít az alias mögötti indexről a SnapshotRepository
+// tárolóba (a klaszter globális állapota nélkül). Üres name esetén a név "<alias>-<UTC időbélyeg>".
+// Az előrehaladás a Snapshot metódussal kérdezhető le.
+func (m *Manager) CreateSnapshot(ctx context.Context, name string) (Snapshot, error) {
+    if name == "" {
+        name = m.name + "-" + time.Now().UTC().Format("20060102-150405")
+    }
+    if !snapshotNamePattern.MatchString(name) {
+        return Snapshot{}, ErrInvalidSnapshotName
+    }
+    source, _, err := m.resolveAliasSource(ctx)
+    if err != nil {
+        return Snapshot{}, err
+    }
+    if source == "" {
+        return Snapshot{}, fmt.Errorf("%w (%s)", ErrNoReindexSource, m.name)
+    }
+    body, _ := json.Marshal(map[string]interface{}{
+        "indices":              source,
+        "include_global_state": false,
+        "metadata":             map[string]string{"alias": m.name},
+    })
+    resp, err := m.client.Do(ctx, "PUT", m.snapshotPath(name)+"?wait_for_completion=false", body, "application/json")
+    if err != nil {
+        return Snapshot{}, err
+    }
+    switch {
+    case resp.StatusCode == http.StatusOK:
+    case strings.Contains(string(resp.Body), "concurrent_snapshot_execution_exception"):
+        return Snapshot{}, ErrSnapshotInProgress
+    case strings.Contains(string(resp.Body), "already exists"):
+        return Snapshot{}, fmt.Errorf("%w (%s)", ErrSnapshotExists, name)
+    default:
+        return Snapshot{}, fmt.Errorf("a pillanatkép indítása sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    slog.Info("Snapshot started", "repository", m.SnapshotRepository, "snapshot", name, "index", source)
+    return Snapshot{Name: name, Repository: m.SnapshotRepository, State: "IN_PROGRESS", Indices: []string{source}}, nil
+}
+
+// Snapshot a name pillanatkép állapotát adja vissza; ha nincs ilyen, ErrSnapshotNotFound hibát ad.
+func (m *Manager) Snapshot(ctx context.Context, name string) (Snapshot, error) {
+    snapshots, err := m.getSnapshots(ctx, name)
+    if err != nil {
+        return Snapshot{}, err
+    }
+    if len(snapshots) != 1 {
+        return Snapshot{}, fmt.Errorf("%w (%s)", ErrSnapshotNotFound, name)
+    }
+    return snapshots[0], nil
+}
+
+// Snapshots a SnapshotRepository tároló összes pillanatképét adja vissza, a legrégebbivel kezdve.
+func (m *Manager) Snapshots(ctx context.Context) ([]Snapshot, error) {
+    return m.getSnapshots(ctx, "_all")
+}
+
+func (m *Manager) getSnapshots(ctx context.Context, name string) ([]Snapshot, error) {
+    resp, err := m.client.Do(ctx, "GET", m.snapshotPath(name), nil, "")
+    if err != nil {
+        return nil, err
+    }
+    if resp.StatusCode == http.StatusNotFound && strings.Contains(string(resp.Body), "snapshot_missing_exception") {
+        return nil, fmt.Errorf("%w (%s)", ErrSnapshotNotFound, name)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("a pillanatképek lekérdezése sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    var result struct {
+        Snapshots []snapshotInfo `json:"snapshots"`
+    }
+    if err := json.Unmarshal(resp.Body, &result); err != nil {
+        return nil, err
+    }
+    snapshots := make([]Snapshot, 0, len(result.Snapshots))
+    for _, info := range result.Snapshots {
+        snapshots = append(snapshots, info.snapshot(m.SnapshotRepository))
+    }
+    return snapshots, nil
+}
+
+// StartRestore a name pillanatképben tárolt indexet új célindexként (<név>_vN) állítja vissza,
+// majd a visszaállítás végeztével átváltja rá az aliast, ugyanúgy, mint az újraindexelés
+// (restore mód, az állapot a CurrentReindex-szel követhető). Az élő index a váltásig
+// változatlanul kiszolgál. Ha már fut egy újraindexelés, ErrReindexInProgress hibát ad.
+func (m *Manager) StartRestore(ctx context.Context, name string, deleteOld bool) (*ReindexJob, error) {
+    snapshot, err := m.Snapshot(ctx, name)
+    if err != nil {
+        return nil, err
+    }
+    if snapshot.State != "SUCCESS" {
+        return nil, fmt.Errorf("a(z) %s pillanatkép %s állapotú, csak SUCCESS állapotú állítható vissza", name, snapshot.State)
+    }
+    if len(snapshot.Indices) != 1 {
+        return nil, fmt.Errorf("a(z) %s pillanatkép %d indexet tartalmaz, egyet kellene", name, len(snapshot.Indices))
+    }
+    job, err := m.beginReindex(ctx, ReindexModeRestore, deleteOld)
+    if err != nil {
+        return nil, err
+    }
+    m.updateReindex(func(job *ReindexJob) { job.Snapshot = name })
+
+    // Az aliasokat nem állítjuk vissza, az alias a visszaállítás után a swapAlias-szal vált át.
+    body, _ := json.Marshal(map[string]interface{}{
+        "indices":              snapshot.Indices[0],
+        "include_global_state": false,
+        "include_aliases":      false,
+        "rename_pattern":       "^" + regexp.QuoteMeta(snapshot.Indices[0]) + "$",
+        "rename_replacement":   job.Target,
+    })
+    resp, err := m.client.Do(ctx, "POST", m.snapshotPath(name)+"/_restore?wait_for_completion=false", body, "application/json")
+    if err == nil && resp.StatusCode != http.StatusOK {
+        err = fmt.Errorf("a visszaállítás indítása sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    if err != nil {
+        m.finishReindex(ReindexFailed, err)
+        return nil, err
+    }
+    slog.Info("Restore started", "repository", m.SnapshotRepository, "snapshot", name, "index", snapshot.Indices[0], "target", job.Target)
+
+    go m.watchRestore(job.Target)
+    return m.CurrentReindex(), nil
+}
+
+// watchRestore PollInterval időközönként lekérdezi a célindex helyreállítási állapotát (_recovery),
+// a Total és Created mezőkbe a visszaállítandó és a már visszaállított bájtok számát írja, és
+// ha minden shard elkészült, átváltja az aliast.
+func (m *Manager) watchRestore(target string) {
+    ctx := context.Background()
+    ticker := time.NewTicker(m.PollInterval)
+    defer ticker.Stop()
+    for range ticker.C {
+        resp, err := m.client.Do(ctx, "GET", "/"+target+"/_recovery", nil, "")
+        if err != nil {
+            slog.Warn("Restore poll failed", "index", target, "error", err)
+            continue
+        }
+        if resp.StatusCode != http.StatusOK {
+            m.finishReindex(ReindexFailed, fmt.Errorf("a visszaállítás nem kérdezhető le (%d): %s", resp.StatusCode, string(resp.Body)))
+            return
+        }
+        var recovery map[string]struct {
+            Shards []struct {
+                Stage string `json:"stage"`
+                Index struct {
+                    Size struct {
+                        Total     int64 `json:"total_in_bytes"`
+                        Recovered int64 `json:"recovered_in_bytes"`
+                    } `json:"size"`
+                } `json:"index"`
+            } `json:"shards"`
+        }
+        if err := json.Unmarshal(resp.Body, &recovery); err != nil {
+            m.finishReindex(ReindexFailed, err)
+            return
+        }
+        shards := recovery[target].Shards
+        var total, recovered int64
+        done := len(shards) > 0
+        for _, shard := range shards {
+            total += shard.Index.Size.Total
+            recovered += shard.Index.Size.Recovered
+            done = done && shard.Stage == "DONE"
+        }
+        m.updateReindex(func(job *ReindexJob) { job.Total, job.Created = total, recovered })
+        if !done {
+            continue
+        }
+        if err := m.swapAlias(ctx); err != nil {
+            m.finishReindex(ReindexFailed, err)
+            return
+        }
+        m.finishReindex(ReindexCompleted, nil)
+        return
+    }
+}
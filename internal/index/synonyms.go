@@ -0,0 +1,88 @@
+package index
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+)
+
+// SynonymFilter az index szinonima szűrőjének neve; az "autocomplete" és az
+// "autocomplete_search" analyzer is használja, így a szinonimák indexeléskor és
+// lekérdezéskor is kibomlanak.
+const SynonymFilter = "autocomplete_synonyms"
+
+// MaxSynonymRules a szinonimalista szabályainak legnagyobb száma.
+const MaxSynonymRules = 1000
+
+// DefaultSynonyms az új indexek kezdeti szinonimalistája a gyakori címrövidítésekkel. A
+// szabályok Solr formátumúak: "a, b" kölcsönös, "a => b" egyirányú helyettesítés; a
+// tokenizáló az írásjeleket elhagyja, így a "Bp." és az "u." is illeszkedik.
+var DefaultSynonyms = []string{
+    "bp, budapest",
+    "u, utca",
+    "krt, körút",
+    "ker, kerület",
+    "sgt, sugárút",
+    "stny, sétány",
+    "ltp, lakótelep",
+    "rkp, rakpart",
+}
+
+// ErrInvalidSynonyms jelzi, hogy a szinonimalista nem a várt formátumú.
+var ErrInvalidSynonyms = errors.New("érvénytelen szinonimalista")
+
+// NormalizeSynonyms ellenőrzi és egységes alakra hozza a szinonimaszabályokat: kisbetűs
+// szavak, ", " elválasztó, " => " irányjelölő. Hibás szabálynál ErrInvalidSynonyms hibát ad.
+func NormalizeSynonyms(rules []string) ([]string, error) {
+    if len(rules) > MaxSynonymRules {
+        return nil, fmt.Errorf("%w: legfeljebb %d szabály adható meg", ErrInvalidSynonyms, MaxSynonymRules)
+    }
+    normalized := make([]string, 0, len(rules))
+    for i, rule := range rules {
+        sides := strings.Split(rule, "=>")
+        if len(sides) > 2 {
+            return nil, fmt.Errorf("%w: %d. szabály: legfeljebb egy \"=>\" lehet", ErrInvalidSynonyms, i+1)
+        }
+        parts := make([]string, 0, len(sides))
+        for _, side := range sides {
+            var terms []string
+            for _, term := range strings.Split(side, ",") {
+                term = strings.ToLower(strings.Join(strings.Fields(term), " "))
+                if term == "" {
+                    return nil, fmt.Errorf("%w: %d. szabály: üres kifejezés (%q)", ErrInvalidSynonyms, i+1, rule)
+                }
+                terms = append(terms, term)
+            }
+            parts = append(parts, strings.Join(terms, ", "))
+        }
+        if len(sides) == 1 && !strings.Contains(parts[0], ",") {
+            return nil, fmt.Errorf("%w: %d. szabály: legalább két kifejezés kell (%q)", ErrInvalidSynonyms, i+1, rule)
+        }
+        normalized = append(normalized, strings.Join(parts, " => "))
+    }
+    return normalized, nil
+}
+
+// Synonyms az index (vagy az alias mögötti index) szinonimalistáját adja vissza. Ha az index
+// nem létezik, vagy még szinonima szűrő nélkül készült, a DefaultSynonyms-t.
+func (m *Manager) Synonyms(ctx context.Context) ([]string, error) {
+    current, err := m.analysis(ctx)
+    if err != nil {
+        return nil, err
+    }
+    return current.Synonyms, nil
+}
+
+// SetSynonyms a szinonimalistát cseréli: mivel a szinonimák indexeléskor is kibomlanak, a
+// jelenlegi index tartalmát alias-alapú újraindexeléssel új, a rules listát használó indexbe
+// másolja (lásd StartReindex). A szabályokat a NormalizeSynonyms szerint ellenőrzi.
+func (m *Manager) SetSynonyms(ctx context.Context, rules []string, deleteOld bool) (*ReindexJob, error) {
+    normalized, err := NormalizeSynonyms(rules)
+    if err != nil {
+        return nil, err
+    }
+    return m.startReindex(ctx, ReindexModeReindex, deleteOld, func(current *analysis) {
+        current.Synonyms = normalized
+    })
+}
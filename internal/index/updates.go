@@ -0,0 +1,115 @@
+package index
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+)
+
+// Az inkrementális frissítés műveletei.
+const (
+    UpdateAdd    = "add"
+    UpdateUpdate = "update"
+    UpdateDelete = "delete"
+)
+
+// UpdateOperation a címregiszter változásfolyamának egy művelete: az "add" és az "update" a
+// Document-et az ID-vel indexeli (létrehozza vagy felülírja, így az ismételt kézbesítés sem
+// hiba), a "delete" törli az ID-jű rekordot.
+type UpdateOperation struct {
+    Op       string           `json:"op"`
+    ID       string           `json:"id"`
+    Document *AddressDocument `json:"document,omitempty"`
+}
+
+// UpdateBatch a változásfolyam egy kötege.
+type UpdateBatch struct {
+    Operations []UpdateOperation `json:"operations"`
+}
+
+// validate ellenőrzi, hogy a művelet végrehajtható-e.
+func (op UpdateOperation) validate() error {
+    if op.ID == "" {
+        return errors.New("hiányzó id mező")
+    }
+    switch op.Op {
+    case UpdateAdd, UpdateUpdate:
+        if op.Document == nil {
+            return errors.New("hiányzó document mező")
+        }
+        if op.Document.ID != "" && op.Document.ID != op.ID {
+            return errors.New("a document id mezője eltér a művelet id mezőjétől")
+        }
+        return op.Document.Validate()
+    case UpdateDelete:
+        return nil
+    }
+    return fmt.Errorf("ismeretlen művelet: %q", op.Op)
+}
+
+// ApplyUpdates a műveleteket sorrendben, BulkBatchSize méretű _bulk kérésekben hajtja végre a
+// Manager indexén. Az érvénytelen műveleteket nem küldi el, hanem rekordszintű hibaként rögzíti
+// (a pozíció a művelet indexe a kötegben). Hibát csak akkor ad, ha egy _bulk kérés sikertelen;
+// ilyenkor a további kötegeket nem küldi el.
+func (m *Manager) ApplyUpdates(ctx context.Context, ops []UpdateOperation) (BulkSummary, error) {
+    summary := BulkSummary{Errors: []BulkRecordError{}}
+    var payload bytes.Buffer
+    var pending []int
+    flush := func() error {
+        if len(pending) == 0 {
+            return nil
+        }
+        summary.Batches++
+        itemErrors, err := m.sendBulk(ctx, m.name, payload.Bytes(), len(pending))
+        for i, pos := range pending {
+            switch {
+            case err != nil:
+                summary.addError(pos, ops[pos].ID, err.Error())
+            case itemErrors[i] != "":
+                summary.addError(pos, ops[pos].ID, itemErrors[i])
+            case ops[pos].Op == UpdateDelete:
+                summary.Deleted++
+            default:
+                summary.Indexed++
+            }
+        }
+        payload.Reset()
+        pending = pending[:0]
+        return err
+    }
+
+    for pos, op := range ops {
+        summary.Total++
+        if err := op.validate(); err != nil {
+            summary.addError(pos, op.ID, err.Error())
+            continue
+        }
+        action := "index"
+        if op.Op == UpdateDelete {
+            action = "delete"
+        }
+        actionBytes, err := json.Marshal(map[string]interface{}{action: map[string]string{"_id": op.ID}})
+        if err != nil {
+            return summary, err
+        }
+        payload.Write(actionBytes)
+        payload.WriteByte('\n')
+        if op.Op != UpdateDelete {
+            sourceBytes, err := json.Marshal(op.Document.source())
+            if err != nil {
+                return summary, err
+            }
+            payload.Write(sourceBytes)
+            payload.WriteByte('\n')
+        }
+        pending = append(pending, pos)
+        if len(pending) >= m.BulkBatchSize {
+            if err := flush(); err != nil {
+                return summary, err
+            }
+        }
+    }
+    return summary, flush()
+}
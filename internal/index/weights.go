@@ -0,0 +1,78 @@
+package index
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+)
+
+// weightBatchSize az AddressCountWeights egy _update_by_query kérésében frissített települések száma.
+const weightBatchSize = 500
+
+// addressCountScript a dokumentum súlyát a településének címszámára állítja, majd a completion
+// mezők súlyát is ehhez igazítja (lásd completionReindexScript).
+const addressCountScript = "ctx._source.weight = params.counts[ctx._source.telepules];\n" + completionReindexScript
+
+// WeightSummary az AddressCountWeights eredménye: a súlyozott települések és a frissített
+// dokumentumok száma.
+type WeightSummary struct {
+    Settlements int `json:"settlements"`
+    Updated     int `json:"updated"`
+}
+
+// AddressCountWeights a lakosságszám nélküli adatforrásokhoz a települések rekordjainak (címeinek)
+// számát írja a dokumentumok "weight" mezőjébe, így a nagyobb települések a javaslatok között
+// előrébb kerülnek (lásd suggest.Options.WeightBoost). A meglévő súlyokat felülírja; a
+// településeket weightBatchSize-onként egy-egy _update_by_query kéréssel frissíti.
+func (m *Manager) AddressCountWeights(ctx context.Context) (WeightSummary, error) {
+    var summary WeightSummary
+    counts := map[string]int{}
+    if err := m.compositeValues(ctx, "telepules.keyword", func(value string, count int) { counts[value] = count }); err != nil {
+        return summary, fmt.Errorf("a címszámok lekérése sikertelen: %w", err)
+    }
+    batch := map[string]int{}
+    flush := func() error {
+        if len(batch) == 0 {
+            return nil
+        }
+        names := make([]string, 0, len(batch))
+        for name := range batch {
+            names = append(names, name)
+        }
+        body, _ := json.Marshal(map[string]interface{}{
+            "query": map[string]interface{}{"terms": map[string]interface{}{"telepules.keyword": names}},
+            "script": map[string]interface{}{
+                "lang":   "painless",
+                "source": addressCountScript,
+                "params": map[string]interface{}{"counts": batch},
+            },
+        })
+        resp, err := m.client.Do(ctx, "POST", "/"+m.name+"/_update_by_query?conflicts=proceed&refresh=true", body, "application/json")
+        if err != nil {
+            return err
+        }
+        if resp.StatusCode != http.StatusOK {
+            return fmt.Errorf("_update_by_query sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+        }
+        var result struct {
+            Updated int `json:"updated"`
+        }
+        if err := json.Unmarshal(resp.Body, &result); err != nil {
+            return err
+        }
+        summary.Settlements += len(batch)
+        summary.Updated += result.Updated
+        batch = map[string]int{}
+        return nil
+    }
+    for name, count := range counts {
+        batch[name] = count
+        if len(batch) >= weightBatchSize {
+            if err := flush(); err != nil {
+                return summary, err
+            }
+        }
+    }
+    return summary, flush()
+}
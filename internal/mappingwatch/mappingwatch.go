@@ -0,0 +1,231 @@
+// Package mappingwatch az index mappingjének időzített ellenőrzése (lásd index.Manager.Check):
+// az eredményt mérőszámként közzéteszi, és ha a "keyword" almező, egy elvárt mező vagy analyzer
+// eltűnik, webhookon (pl. Slack incoming webhook) értesít, hogy ne kelljen az /api/checkMapping
+// végpontot kézzel figyelni.
+package mappingwatch
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "autocomplete/internal/index"
+)
+
+// Checker az ellenőrzött index (index.Manager).
+type Checker interface {
+    Name() string
+    Check(ctx context.Context) (index.MappingCheckResult, error)
+}
+
+// Options a Watcher beállításai. Az Interval az ellenőrzések gyakorisága, a Timeout egy
+// ellenőrzés (és értesítés) felső korlátja. Üres WebhookURL esetén nincs értesítés; a HTTPClient
+// nil esetén http.DefaultClient.
+type Options struct {
+    Interval   time.Duration
+    Timeout    time.Duration
+    WebhookURL string
+    HTTPClient *http.Client
+}
+
+// Status az ellenőrzés állapota (expvar "mapping_check"). Az OK az utolsó sikeres ellenőrzés
+// eredménye, a Problems az akkor talált eltérések; a LastError az utolsó sikertelen ellenőrzés
+// hibája, amely az OK értékét nem változtatja meg. A Checks és Errors az ellenőrzések, a
+// Notifications és NotifyErrors az elküldött és a sikertelen értesítések száma.
+type Status struct {
+    OK            bool       `json:"ok"`
+    Problems      []string   `json:"problems,omitempty"`
+    LastCheck     *time.Time `json:"lastCheck,omitempty"`
+    LastError     string     `json:"lastError,omitempty"`
+    Checks        int64      `json:"checks"`
+    Errors        int64      `json:"errors"`
+    Notifications int64      `json:"notifications"`
+    NotifyErrors  int64      `json:"notifyErrors"`
+}
+
+// Notification a webhook törzse. A Text a Slack incoming webhookok által megjelenített üzenet,
+// a többi mező a gépi feldolgozást szolgálja.
+type Notification struct {
+    Text      string    `json:"text"`
+    Index     string    `json:"index"`
+    OK        bool      `json:"ok"`
+    Problems  []string  `json:"problems,omitempty"`
+    CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Watcher időközönként ellenőrzi az index mappingjét; állapotváltozáskor értesít.
+type Watcher struct {
+    checker Checker
+    opts    Options
+
+    mu      sync.Mutex
+    status  Status
+    checked bool
+}
+
+// New a checker indexét opts szerint ellenőrző Watchert adja vissza.
+func New(checker Checker, opts Options) *Watcher {
+    if opts.HTTPClient == nil {
+        opts.HTTPClient = http.DefaultClient
+    }
+    return &Watcher{checker: checker, opts: opts}
+}
+
+// Problems a mapping ellenőrzés eredményének eltérései olvasható formában; üres, ha a mapping
+// rendben van.
+func Problems(res index.MappingCheckResult) []string {
+    var problems []string
+    if !res.FieldMappingExists {
+        problems = append(problems, `hiányzik a "telepules.keyword" almező`)
+    }
+    if res.MappingVersion != res.ExpectedMappingVersion {
+        problems = append(problems, fmt.Sprintf("a mapping verziója %d, elvárt: %d", res.MappingVersion, res.ExpectedMappingVersion))
+    }
+    if len(res.MissingFields) > 0 {
+        problems = append(problems, "hiányzó mezők: "+strings.Join(res.MissingFields, ", "))
+    }
+    if len(res.MissingAnalyzers) > 0 {
+        problems = append(problems, "hiányzó analyzerek: "+strings.Join(res.MissingAnalyzers, ", "))
+    }
+    return problems
+}
+
+// Run Interval időközönként ellenőriz (az elsőt azonnal), amíg a ctx le nem zárul.
+func (w *Watcher) Run(ctx context.Context) {
+    ticker := time.NewTicker(w.opts.Interval)
+    defer ticker.Stop()
+    for {
+        w.CheckNow(ctx)
+        select {
+        case <-ticker.C:
+        case <-ctx.Done():
+            return
+        }
+    }
+}
+
+// CheckNow egyszer ellenőrzi a mappinget, és frissíti az állapotot. Értesítést küld, ha az
+// eredmény hibásra vált (az első ellenőrzéskor is), hibás állapotban a talált eltérések
+// megváltoznak, vagy a hibás állapot helyreáll.
+func (w *Watcher) CheckNow(ctx context.Context) Status {
+    ctx, cancel := context.WithTimeout(ctx, w.opts.Timeout)
+    defer cancel()
+    res, err := w.checker.Check(ctx)
+    now := time.Now().UTC()
+
+    w.mu.Lock()
+    w.status.Checks++
+    w.status.LastCheck = &now
+    if err != nil {
+        w.status.Errors++
+        w.status.LastError = err.Error()
+        status := w.snapshot()
+        w.mu.Unlock()
+        slog.Warn("Scheduled mapping check failed", "index", w.checker.Name(), "error", err)
+        return status
+    }
+    problems := Problems(res)
+    ok := len(problems) == 0
+    changed := w.checked && (ok != w.status.OK || !sameProblems(problems, w.status.Problems))
+    notify := changed || (!w.checked && !ok)
+    w.checked = true
+    w.status.OK = ok
+    w.status.Problems = problems
+    w.status.LastError = ""
+    status := w.snapshot()
+    w.mu.Unlock()
+
+    // Csak állapotváltozáskor naplóz, hogy a tartós eltérés ne ismétlődjön minden ellenőrzéskor.
+    if notify && !ok {
+        slog.Warn("Scheduled mapping check found drift", "index", w.checker.Name(), "problems", problems)
+    } else if notify {
+        slog.Info("Scheduled mapping check recovered", "index", w.checker.Name())
+    }
+    if notify && w.opts.WebhookURL != "" {
+        w.notify(ctx, Notification{Index: w.checker.Name(), OK: ok, Problems: problems, CheckedAt: now})
+    }
+    return status
+}
+
+// notify elküldi az értesítést a webhookra; a hibát naplózza és számolja.
+func (w *Watcher) notify(ctx context.Context, n Notification) {
+    if n.OK {
+        n.Text = fmt.Sprintf("Az autocomplete index (%s) mappingje ismét rendben van.", n.Index)
+    } else {
+        n.Text = fmt.Sprintf("Az autocomplete index (%s) mappingje eltér az elvárttól: %s", n.Index, strings.Join(n.Problems, "; "))
+    }
+    err := w.post(ctx, n)
+    w.mu.Lock()
+    if err != nil {
+        w.status.NotifyErrors++
+    } else {
+        w.status.Notifications++
+    }
+    w.mu.Unlock()
+    if err != nil {
+        slog.Error("Mapping check notification failed", "index", n.Index, "error", err)
+    }
+}
+
+// post a webhookra küldi az n-t JSON törzsként; a nem 2xx választ hibaként adja vissza.
+func (w *Watcher) post(ctx context.Context, n Notification) error {
+    body, _ := json.Marshal(n)
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.opts.WebhookURL, bytes.NewReader(body))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Content-Type", "application/json")
+    resp, err := w.opts.HTTPClient.Do(req)
+    if err != nil {
+        return err
+    }
+    resp.Body.Close()
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        return fmt.Errorf("a webhook válasza: %s", resp.Status)
+    }
+    return nil
+}
+
+// Status az ellenőrzés jelenlegi állapota.
+func (w *Watcher) Status() Status {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    return w.snapshot()
+}
+
+// Gauge a "mapping_ok" mérőszám: 1, ha az utolsó sikeres ellenőrzés nem talált eltérést,
+// különben (és az első sikeres ellenőrzésig) 0.
+func (w *Watcher) Gauge() interface{} {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    if w.checked && w.status.OK {
+        return 1
+    }
+    return 0
+}
+
+// snapshot az állapot másolata; a hívó tartja a zárat.
+func (w *Watcher) snapshot() Status {
+    status := w.status
+    status.Problems = append([]string(nil), w.status.Problems...)
+    return status
+}
+
+// sameProblems jelzi, hogy a két eltéréslista azonos-e.
+func sameProblems(a, b []string) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i] != b[i] {
+            return false
+        }
+    }
+    return true
+}
@@ -0,0 +1,445 @@
+// Package memory OpenSearch nélküli javaslat háttérrendszer fejlesztéshez és CI-hoz: a teljes
+// címlistát egy helyi CSV, NDJSON vagy JSON fájlból memóriabeli prefix fákba tölti, és
+// ugyanazt a suggest.Suggester felületet szolgálja ki, mint az OpenSearch motor.
+package memory
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log/slog"
+    "sort"
+    "strings"
+    "sync/atomic"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/suggest"
+)
+
+// Backend a memóriabeli háttérrendszer. A betöltés után csak olvasott, így a lekérdezések
+// zárolás nélkül futhatnak párhuzamosan.
+type Backend struct {
+    settlements trie
+    streets     trie
+    addresses   trie
+    zips        trie
+
+    // records a rekordok település szerint, a címellenőrzéshez.
+    records map[string][]index.AddressDocument
+    // zipSettlements az irányítószámokhoz tartozó települések, betűrendben.
+    zipSettlements map[string][]string
+    // locations a javaslatok helyadata fajtánként: az értékhez tartozó rekordok koordinátáinak
+    // súlypontja, mint az OpenSearch motor geo_centroid aggregációjánál; a betöltés alatt a
+    // centroids gyűjti az összegeket.
+    locations map[string]map[string]index.GeoPoint
+    centroids map[string]map[string]*centroid
+
+    limit atomic.Int64
+}
+
+var (
+    _ suggest.Suggester               = (*Backend)(nil)
+    _ suggest.ZipResolver             = (*Backend)(nil)
+    _ suggest.HouseNumberValidator    = (*Backend)(nil)
+    _ suggest.Geocoder                = (*Backend)(nil)
+    _ suggest.SettlementStatsProvider = (*Backend)(nil)
+    _ suggest.SettlementLister        = (*Backend)(nil)
+    _ suggest.StreetLister            = (*Backend)(nil)
+)
+
+// centroid egy javaslat rekordjainak koordináta-összege a súlyponthoz.
+type centroid struct {
+    lat, lon float64
+    n        int
+}
+
+// newBackend üres háttérrendszert ad vissza; a rekordokat a Load tölti be.
+func newBackend(opts suggest.Options) *Backend {
+    b := &Backend{records: map[string][]index.AddressDocument{}, zipSettlements: map[string][]string{},
+        locations: map[string]map[string]index.GeoPoint{}, centroids: map[string]map[string]*centroid{}}
+    b.SetOptions(opts)
+    return b
+}
+
+// SetOptions a beállítások közül a javaslatok számát (SuggestionLimit) veszi át.
+func (b *Backend) SetOptions(opts suggest.Options) {
+    b.limit.Store(int64(opts.SuggestionLimit))
+}
+
+// Load a path fájlból tölti be a címlistát: .csv kiterjesztésnél vesszővel elválasztott CSV-ként
+// a mapping fejléc-leképezéssel, egyébként NDJSON-ként vagy JSON tömbként. A hibás rekordokat
+// kihagyja és naplózza; a fájl olvasási hibája vagy érvénytelen CSV fejléc esetén hibát ad.
+func Load(path string, mapping map[string]string, opts suggest.Options) (*Backend, error) {
+    b := newBackend(opts)
+    loader := &loader{backend: b}
+    if err := index.ReadDocumentFile(path, mapping, loader); err != nil {
+        return nil, fmt.Errorf("a címlista betöltése sikertelen (%s): %w", path, err)
+    }
+    b.finish()
+    slog.Info("Memory backend loaded", "path", path, "records", loader.loaded, "failed", loader.failed, "settlements", len(b.records))
+    return b, nil
+}
+
+// loader index.DocumentSink, amely a rekordokat a Backend fáiba tölti.
+type loader struct {
+    loaded int
+    failed int
+
+    backend *Backend
+}
+
+// Add felvesz egy rekordot; az érvénytelen (település nélküli) rekordot kihagyja.
+func (l *loader) Add(pos int, doc index.AddressDocument) error {
+    if err := doc.Validate(); err != nil {
+        l.Fail(pos, err.Error())
+        return nil
+    }
+    l.backend.add(doc)
+    l.loaded++
+    return nil
+}
+
+// Fail naplózza az értelmezhetetlen rekordot.
+func (l *loader) Fail(pos int, msg string) {
+    l.failed++
+    slog.Warn("Memory backend record skipped", "position", pos, "error", msg)
+}
+
+func (b *Backend) add(doc index.AddressDocument) {
+    doc.Telepules = strings.TrimSpace(doc.Telepules)
+    doc.KozterNev = strings.TrimSpace(doc.KozterNev)
+    doc.Irsz = strings.TrimSpace(doc.Irsz)
+    doc.Megye = strings.TrimSpace(doc.Megye)
+    doc.KSHKod = strings.TrimSpace(doc.KSHKod)
+    doc.HazszamParitas = strings.TrimSpace(doc.HazszamParitas)
+    doc.TeljesCim = doc.FullAddress()
+
+    b.settlements.insert(doc.Telepules, doc.Megye)
+    if doc.KozterNev != "" {
+        b.streets.insert(doc.KozterNev, doc.Telepules)
+    }
+    b.addresses.insert(doc.TeljesCim, "")
+    if doc.Irsz != "" {
+        b.zips.insert(doc.Irsz, "")
+        b.zipSettlements[doc.Irsz] = append(b.zipSettlements[doc.Irsz], doc.Telepules)
+    }
+    b.records[doc.Telepules] = append(b.records[doc.Telepules], doc)
+
+    if p := doc.GeoPoint(); p != nil {
+        b.addLocation(suggest.KindSettlement, doc.Telepules, *p)
+        if doc.KozterNev != "" {
+            b.addLocation(suggest.KindStreet, doc.KozterNev, *p)
+        }
+        b.addLocation(suggest.KindAddress, doc.TeljesCim, *p)
+    }
+}
+
+// addLocation a kind fajtájú value javaslat súlypontjához hozzáadja a p pontot.
+func (b *Backend) addLocation(kind, value string, p index.GeoPoint) {
+    if b.centroids[kind] == nil {
+        b.centroids[kind] = map[string]*centroid{}
+    }
+    c := b.centroids[kind][value]
+    if c == nil {
+        c = &centroid{}
+        b.centroids[kind][value] = c
+    }
+    c.lat += p.Lat
+    c.lon += p.Lon
+    c.n++
+}
+
+// finish a betöltés végén rendezi és deduplikálja az irányítószámok településlistáit.
+func (b *Backend) finish() {
+    for zip, settlements := range b.zipSettlements {
+        sort.Strings(settlements)
+        unique := settlements[:0]
+        for i, s := range settlements {
+            if i == 0 || s != settlements[i-1] {
+                unique = append(unique, s)
+            }
+        }
+        b.zipSettlements[zip] = unique
+    }
+    for kind, values := range b.centroids {
+        b.locations[kind] = make(map[string]index.GeoPoint, len(values))
+        for value, c := range values {
+            b.locations[kind][value] = index.GeoPoint{Lat: c.lat / float64(c.n), Lon: c.lon / float64(c.n)}
+        }
+    }
+    b.centroids = nil
+}
+
+// suggestionLocations a javaslatokhoz a helyadatukat rendeli; nil, ha egyiknek sincs.
+func (b *Backend) suggestionLocations(kind string, suggestions []string) map[string]index.GeoPoint {
+    var points map[string]index.GeoPoint
+    for _, suggestion := range suggestions {
+        if p, ok := b.locations[kind][suggestion]; ok {
+            if points == nil {
+                points = map[string]index.GeoPoint{}
+            }
+            points[suggestion] = p
+        }
+    }
+    return points
+}
+
+// Suggest a kérés fajtája szerinti prefix fában keres. A Megye és a Telepules szűrő pontos
+// egyezést vár, mint az OpenSearch motor keyword szűrői.
+func (b *Backend) Suggest(ctx context.Context, req suggest.Request) (suggest.Set, string, error) {
+    limit := int(b.limit.Load())
+    var suggestions []string
+    switch req.Kind {
+    case "", suggest.KindSettlement:
+        suggestions = b.settlements.search(req.Query, req.Megye, limit)
+    case suggest.KindStreet:
+        suggestions = b.streets.search(req.Query, req.Telepules, limit)
+    case suggest.KindAddress:
+        suggestions = b.addresses.search(req.Query, "", limit)
+    case suggest.KindZip:
+        suggestions = b.zips.search(req.Query, "", limit)
+    default:
+        return suggest.Set{}, "", fmt.Errorf("%w: %q", suggest.ErrUnknownKind, req.Kind)
+    }
+    suggestions = suggest.Rank(suggestions, req.Query, req.Sort)
+    debugInfo := fmt.Sprintf("Memóriabeli keresés (%s): %q\nVisszaadott javaslatok: %v\n", req.Kind, req.Query, suggestions)
+    set := suggest.Set{Suggestions: suggestions}
+    if req.Kind == "" || req.Kind == suggest.KindSettlement {
+        set.KSHCodes = b.kshCodes(suggestions)
+        set.Locations = b.suggestionLocations(suggest.KindSettlement, suggestions)
+    } else {
+        set.Locations = b.suggestionLocations(req.Kind, suggestions)
+    }
+    return set, debugInfo, nil
+}
+
+// kshCodes a településnevekhez a rekordjaikban elsőként megadott KSH kódot rendeli; nil, ha
+// egyiknek sincs.
+func (b *Backend) kshCodes(settlements []string) map[string]string {
+    var codes map[string]string
+    for _, name := range settlements {
+        if code := b.kshCode(name); code != "" {
+            if codes == nil {
+                codes = map[string]string{}
+            }
+            codes[name] = code
+        }
+    }
+    return codes
+}
+
+// kshCode a település rekordjaiban elsőként megadott KSH kód ("" ha nincs).
+func (b *Backend) kshCode(telepules string) string {
+    for _, doc := range b.records[telepules] {
+        if doc.KSHKod != "" {
+            return doc.KSHKod
+        }
+    }
+    return ""
+}
+
+// ZipSettlements az irányítószámhoz tartozó településneveket adja vissza betűrendben.
+func (b *Backend) ZipSettlements(ctx context.Context, zip string) ([]string, string, error) {
+    settlements := b.zipSettlements[zip]
+    if limit := int(b.limit.Load()); len(settlements) > limit {
+        settlements = settlements[:limit]
+    }
+    return append([]string{}, settlements...), fmt.Sprintf("Memóriabeli irányítószám feloldás: %q\n", zip), nil
+}
+
+// settlementRecords a name település rekordjai, az OpenSearch motorral azonosan
+// kis-nagybetű függetlenül illesztve a nevet (nil, ha nincs ilyen település).
+func (b *Backend) settlementRecords(name string) []index.AddressDocument {
+    if records, ok := b.records[name]; ok {
+        return records
+    }
+    for telepules, records := range b.records {
+        if strings.EqualFold(telepules, name) {
+            return records
+        }
+    }
+    return nil
+}
+
+// SettlementStats a település rekordjaiból számolja a közterületeket és az irányítószámokat, az
+// OpenSearch motorral azonosan kis-nagybetű függetlenül illesztve a nevet.
+func (b *Backend) SettlementStats(ctx context.Context, name string) (suggest.SettlementStats, string, error) {
+    name = strings.TrimSpace(name)
+    records := b.settlementRecords(name)
+    debugInfo := fmt.Sprintf("Memóriabeli település összesítés: %q\n", name)
+    if len(records) == 0 {
+        return suggest.SettlementStats{}, debugInfo, suggest.ErrUnknownSettlement
+    }
+    streets, zips := map[string]bool{}, map[string]bool{}
+    for _, doc := range records {
+        if doc.KozterNev != "" {
+            streets[doc.KozterNev] = true
+        }
+        if doc.Irsz != "" {
+            zips[doc.Irsz] = true
+        }
+    }
+    stats := suggest.SettlementStats{Settlement: records[0].Telepules, Megye: records[0].Megye, Streets: len(streets),
+        ZipCodes: len(zips), Zips: make([]string, 0, len(zips)), Records: len(records)}
+    for zip := range zips {
+        stats.Zips = append(stats.Zips, zip)
+    }
+    sort.Strings(stats.Zips)
+    return stats, debugInfo, nil
+}
+
+// ListSettlements a településeket az OpenSearch motorral azonos (bájt)sorrendben lapozza; a
+// megyét kis-nagybetű függetlenül illeszti. A kurzor itt egyszerűen a lap utolsó települése.
+func (b *Backend) ListSettlements(ctx context.Context, megye, after string, size int) (suggest.SettlementPage, string, error) {
+    megye = strings.TrimSpace(megye)
+    names := make([]string, 0, len(b.records))
+    for telepules, records := range b.records {
+        if telepules > after && (megye == "" || strings.EqualFold(records[0].Megye, megye)) {
+            names = append(names, telepules)
+        }
+    }
+    sort.Strings(names)
+    page := suggest.SettlementPage{Settlements: []suggest.SettlementListItem{}}
+    for _, name := range names {
+        if len(page.Settlements) == size {
+            page.Next = page.Settlements[size-1].Name
+            break
+        }
+        page.Settlements = append(page.Settlements, suggest.SettlementListItem{Name: name, KSHKod: b.kshCode(name), Records: len(b.records[name])})
+    }
+    return page, fmt.Sprintf("Memóriabeli településlista: megye=%q, after=%q, size=%d\n", megye, after, size), nil
+}
+
+// ListStreets a település közterületeit az OpenSearch motorral azonos (bájt)sorrendben lapozza;
+// az előtagot kis-nagybetű függetlenül illeszti. A kurzor itt a lap utolsó közterülete.
+func (b *Backend) ListStreets(ctx context.Context, name, prefix, after string, size int) (suggest.StreetPage, string, error) {
+    name, prefix = strings.TrimSpace(name), strings.TrimSpace(prefix)
+    debugInfo := fmt.Sprintf("Memóriabeli közterületlista: %q, prefix=%q, after=%q, size=%d\n", name, prefix, after, size)
+    records := b.settlementRecords(name)
+    if len(records) == 0 {
+        return suggest.StreetPage{}, debugInfo, suggest.ErrUnknownSettlement
+    }
+    counts := map[string]int{}
+    for _, doc := range records {
+        if doc.KozterNev != "" && doc.KozterNev > after && strings.HasPrefix(strings.ToLower(doc.KozterNev), strings.ToLower(prefix)) {
+            counts[doc.KozterNev]++
+        }
+    }
+    names := make([]string, 0, len(counts))
+    for street := range counts {
+        names = append(names, street)
+    }
+    sort.Strings(names)
+    page := suggest.StreetPage{Settlement: records[0].Telepules, Streets: []suggest.StreetListItem{}}
+    for _, street := range names {
+        if len(page.Streets) == size {
+            page.Next = page.Streets[size-1].Name
+            break
+        }
+        page.Streets = append(page.Streets, suggest.StreetListItem{Name: street, Records: counts[street]})
+    }
+    return page, debugInfo, nil
+}
+
+// Validate pontos egyezéssel ellenőrzi a címet, az OpenSearch motor Validate-jével azonos módon:
+// a megadott közterületnek és irányítószámnak ugyanahhoz a rekordhoz kell tartoznia.
+func (b *Backend) Validate(ctx context.Context, in suggest.AddressInput) (suggest.AddressValidation, error) {
+    telepules, kozterNev, irsz := strings.TrimSpace(in.Telepules), strings.TrimSpace(in.KozterNev), strings.TrimSpace(in.Irsz)
+    records := b.records[telepules]
+    var result suggest.AddressValidation
+    result.SettlementFound = len(records) > 0
+    if !result.SettlementFound {
+        return result, nil
+    }
+    if kozterNev == "" && irsz == "" {
+        result.Valid = true
+        result.Canonical = &index.AddressDocument{Telepules: records[0].Telepules, Megye: records[0].Megye, KSHKod: b.kshCode(telepules)}
+        return result, nil
+    }
+    for _, doc := range records {
+        streetOK := kozterNev == "" || doc.KozterNev == kozterNev
+        zipOK := irsz == "" || doc.Irsz == irsz
+        result.StreetFound = result.StreetFound || (kozterNev != "" && doc.KozterNev == kozterNev)
+        result.ZipMatches = result.ZipMatches || (irsz != "" && doc.Irsz == irsz)
+        if streetOK && zipOK && !result.Valid {
+            canonical := index.AddressDocument{Telepules: doc.Telepules, KozterNev: doc.KozterNev, Irsz: doc.Irsz, Megye: doc.Megye, KSHKod: doc.KSHKod}
+            result.Valid = true
+            result.Canonical = &canonical
+        }
+    }
+    return result, nil
+}
+
+// ValidateHouseNumber a házszámot a közterület szakaszainak házszámtartományaival veti össze,
+// az OpenSearch motor ValidateHouseNumber-jével azonos módon (lásd suggest.MatchHouseNumber).
+func (b *Backend) ValidateHouseNumber(ctx context.Context, in suggest.HouseNumberInput) (suggest.HouseNumberValidation, error) {
+    n, err := index.ParseHouseNumber(in.Hazszam)
+    if err != nil {
+        return suggest.HouseNumberValidation{}, err
+    }
+    records := b.records[strings.TrimSpace(in.Telepules)]
+    if len(records) == 0 {
+        return suggest.HouseNumberValidation{Hazszam: n}, nil
+    }
+    kozterNev := strings.TrimSpace(in.KozterNev)
+    var segments []index.AddressDocument
+    for _, doc := range records {
+        if doc.KozterNev == kozterNev {
+            segments = append(segments, doc)
+        }
+    }
+    return suggest.MatchHouseNumber(segments, n), nil
+}
+
+// Geocode ellenőrzi a címet (lásd Validate), és az első illeszkedő, koordinátával rendelkező
+// rekord pontját adja vissza; ha ilyen nincs, a település bármely koordinátás rekordjáét.
+func (b *Backend) Geocode(ctx context.Context, in suggest.AddressInput) (suggest.Geocode, error) {
+    validation, err := b.Validate(ctx, in)
+    if err != nil || !validation.Valid {
+        return suggest.Geocode{AddressValidation: validation}, err
+    }
+    kozterNev, irsz := strings.TrimSpace(in.KozterNev), strings.TrimSpace(in.Irsz)
+    var located *index.AddressDocument
+    for i, doc := range b.records[validation.Canonical.Telepules] {
+        if doc.GeoPoint() == nil {
+            continue
+        }
+        if (kozterNev == "" || doc.KozterNev == kozterNev) && (irsz == "" || doc.Irsz == irsz) {
+            located = &b.records[validation.Canonical.Telepules][i]
+            break
+        }
+        if located == nil {
+            located = &b.records[validation.Canonical.Telepules][i]
+        }
+    }
+    return suggest.GeocodeResult(validation, located), nil
+}
+
+// ReverseGeocode a ponthoz legközelebbi koordinátás rekordot keresi meg az összes rekord
+// végigjárásával (a fejlesztői adatfájlok méreténél ez elég gyors).
+func (b *Backend) ReverseGeocode(ctx context.Context, p index.GeoPoint) (suggest.ReverseGeocode, error) {
+    var result suggest.ReverseGeocode
+    for _, records := range b.records {
+        for _, doc := range records {
+            location := doc.GeoPoint()
+            if location == nil {
+                continue
+            }
+            distance := p.DistanceMeters(*location)
+            if distance > suggest.ReverseGeocodeRadius || (result.Found && distance >= result.DistanceMeters) {
+                continue
+            }
+            nearest := suggest.LocatedDocument(doc)
+            result = suggest.ReverseGeocode{Found: true, Nearest: &nearest, DistanceMeters: distance}
+        }
+    }
+    return result, nil
+}
+
+// Health hibát ad, ha a címlista üres, így egy rossz adatfájllal induló példány nem kap forgalmat.
+func (b *Backend) Health(ctx context.Context) error {
+    if len(b.records) == 0 {
+        return errors.New("a memóriabeli címlista üres")
+    }
+    return nil
+}
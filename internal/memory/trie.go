@@ -0,0 +1,92 @@
+package memory
+
+import (
+    "sort"
+    "strings"
+
+    "autocomplete/internal/suggest"
+)
+
+// normalize a kulcsok és a lekérdezések közös alakja, az OpenSearch motorral azonos
+// normalizálással (lásd suggest.NormalizeQuery).
+func normalize(s string) string {
+    return suggest.NormalizeQuery(s, false)
+}
+
+// trie a normalizált kulcsok szerinti prefix fa. Minden kulcshoz egy megjelenített érték és
+// a szűréshez használt címkék (pl. a település megyéje vagy a közterület települése) tartoznak.
+type trie struct {
+    root node
+}
+
+type node struct {
+    children map[rune]*node
+    // keys a children kulcsai rendezve; a keresés ebben a sorrendben járja be a fát.
+    keys  []rune
+    entry *entry
+}
+
+type entry struct {
+    value string
+    tags  map[string]struct{}
+}
+
+// insert felveszi az értéket; a kis-nagybetűben eltérő értékek közül az elsőt tartja meg.
+// Üres tag esetén nem rögzít címkét.
+func (t *trie) insert(value, tag string) {
+    key := normalize(value)
+    if key == "" {
+        return
+    }
+    n := &t.root
+    for _, r := range key {
+        child := n.children[r]
+        if child == nil {
+            if n.children == nil {
+                n.children = map[rune]*node{}
+            }
+            child = &node{}
+            n.children[r] = child
+            i := sort.Search(len(n.keys), func(i int) bool { return n.keys[i] >= r })
+            n.keys = append(n.keys, 0)
+            copy(n.keys[i+1:], n.keys[i:])
+            n.keys[i] = r
+        }
+        n = child
+    }
+    if n.entry == nil {
+        n.entry = &entry{value: strings.TrimSpace(value), tags: map[string]struct{}{}}
+    }
+    if tag != "" {
+        n.entry.tags[tag] = struct{}{}
+    }
+}
+
+// search legfeljebb limit darab, a prefix-szel kezdődő értéket ad vissza: a rövidebb
+// kulcsok előbb, az azonos hosszúak betűrendben. Nem üres tag esetén csak az ezzel a
+// címkével rögzített értékeket.
+func (t *trie) search(prefix, tag string, limit int) []string {
+    n := &t.root
+    for _, r := range normalize(prefix) {
+        if n = n.children[r]; n == nil {
+            return []string{}
+        }
+    }
+    results := []string{}
+    level := []*node{n}
+    for len(level) > 0 && len(results) < limit {
+        var next []*node
+        for _, n := range level {
+            if e := n.entry; e != nil && len(results) < limit {
+                if _, ok := e.tags[tag]; tag == "" || ok {
+                    results = append(results, e.value)
+                }
+            }
+            for _, r := range n.keys {
+                next = append(next, n.children[r])
+            }
+        }
+        level = next
+    }
+    return results
+}
@@ -0,0 +1,142 @@
+// Package msgpack egy minimális MessagePack kódolót ad a válaszokhoz. Az értéket előbb JSON-ként
+// kódolja, így a mezőnevek és az elhagyott mezők (omitempty) pontosan megegyeznek a JSON
+// válaszéval; a kimenet a JSON adatmodellnek megfelelő MessagePack típusokat használja.
+package msgpack
+
+import (
+    "bytes"
+    "encoding/binary"
+    "encoding/json"
+    "fmt"
+    "math"
+    "sort"
+    "strconv"
+)
+
+// ContentType a MessagePack válaszok tartalomtípusa.
+const ContentType = "application/msgpack"
+
+// Marshal v MessagePack alakját adja vissza.
+func Marshal(v interface{}) ([]byte, error) {
+    data, err := json.Marshal(v)
+    if err != nil {
+        return nil, err
+    }
+    decoder := json.NewDecoder(bytes.NewReader(data))
+    decoder.UseNumber()
+    var tree interface{}
+    if err := decoder.Decode(&tree); err != nil {
+        return nil, err
+    }
+    var buf bytes.Buffer
+    if err := encode(&buf, tree); err != nil {
+        return nil, err
+    }
+    return buf.Bytes(), nil
+}
+
+// encode a JSON dekódolásából származó értéket írja ki. Az objektumok kulcsai rendezve
+// kerülnek ki, így ugyanarra a válaszra a kimenet (és az ETag) is azonos.
+func encode(buf *bytes.Buffer, v interface{}) error {
+    switch v := v.(type) {
+    case nil:
+        buf.WriteByte(0xc0)
+    case bool:
+        if v {
+            buf.WriteByte(0xc3)
+        } else {
+            buf.WriteByte(0xc2)
+        }
+    case json.Number:
+        if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+            writeInt(buf, n)
+            return nil
+        }
+        f, err := v.Float64()
+        if err != nil {
+            return err
+        }
+        buf.WriteByte(0xcb)
+        binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+    case string:
+        writeString(buf, v)
+    case []interface{}:
+        writeLength(buf, len(v), 0x90, 0xdc, 0xdd)
+        for _, item := range v {
+            if err := encode(buf, item); err != nil {
+                return err
+            }
+        }
+    case map[string]interface{}:
+        keys := make([]string, 0, len(v))
+        for k := range v {
+            keys = append(keys, k)
+        }
+        sort.Strings(keys)
+        writeLength(buf, len(keys), 0x80, 0xde, 0xdf)
+        for _, k := range keys {
+            writeString(buf, k)
+            if err := encode(buf, v[k]); err != nil {
+                return err
+            }
+        }
+    default:
+        return fmt.Errorf("msgpack: nem kódolható típus: %T", v)
+    }
+    return nil
+}
+
+// writeInt a legrövidebb egész alakot választja.
+func writeInt(buf *bytes.Buffer, n int64) {
+    switch {
+    case n >= 0 && n <= 0x7f:
+        buf.WriteByte(byte(n))
+    case n < 0 && n >= -32:
+        buf.WriteByte(byte(int8(n)))
+    case n >= math.MinInt8 && n <= math.MaxInt8:
+        buf.WriteByte(0xd0)
+        buf.WriteByte(byte(int8(n)))
+    case n >= math.MinInt16 && n <= math.MaxInt16:
+        buf.WriteByte(0xd1)
+        binary.Write(buf, binary.BigEndian, int16(n))
+    case n >= math.MinInt32 && n <= math.MaxInt32:
+        buf.WriteByte(0xd2)
+        binary.Write(buf, binary.BigEndian, int32(n))
+    default:
+        buf.WriteByte(0xd3)
+        binary.Write(buf, binary.BigEndian, n)
+    }
+}
+
+// writeString str típusként írja ki s-t (fixstr, str8, str16 vagy str32).
+func writeString(buf *bytes.Buffer, s string) {
+    switch n := len(s); {
+    case n <= 31:
+        buf.WriteByte(0xa0 | byte(n))
+    case n <= math.MaxUint8:
+        buf.WriteByte(0xd9)
+        buf.WriteByte(byte(n))
+    case n <= math.MaxUint16:
+        buf.WriteByte(0xda)
+        binary.Write(buf, binary.BigEndian, uint16(n))
+    default:
+        buf.WriteByte(0xdb)
+        binary.Write(buf, binary.BigEndian, uint32(n))
+    }
+    buf.WriteString(s)
+}
+
+// writeLength a tömb vagy map fejlécét írja ki: 15 elemig a fix alakot (fix|n), utána a 16
+// vagy 32 bites hosszú alakot.
+func writeLength(buf *bytes.Buffer, n int, fix, code16, code32 byte) {
+    switch {
+    case n <= 15:
+        buf.WriteByte(fix | byte(n))
+    case n <= math.MaxUint16:
+        buf.WriteByte(code16)
+        binary.Write(buf, binary.BigEndian, uint16(n))
+    default:
+        buf.WriteByte(code32)
+        binary.Write(buf, binary.BigEndian, uint32(n))
+    }
+}
@@ -0,0 +1,118 @@
+package opensearch
+
+import (
+    "errors"
+    "fmt"
+    "sync"
+    "time"
+)
+
+// ErrCircuitOpen jelzi, hogy a circuit breaker nyitott állapotban van, ezért a kérést
+// el sem küldtük az OpenSearch felé.
+var ErrCircuitOpen = errors.New("opensearch: circuit breaker nyitva")
+
+// CircuitOpenError a nyitott breaker által elutasított kérés hibája; a RetryAfter
+// megadja, mennyi idő múlva próbálkozik újra a breaker (half-open állapot).
+type CircuitOpenError struct {
+    RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+    return fmt.Sprintf("%v (újrapróbálás %s múlva)", ErrCircuitOpen, e.RetryAfter.Round(time.Second))
+}
+
+// Is lehetővé teszi az errors.Is(err, ErrCircuitOpen) ellenőrzést.
+func (e *CircuitOpenError) Is(target error) bool {
+    return target == ErrCircuitOpen
+}
+
+// A breaker állapotai.
+const (
+    StateClosed   = "closed"
+    StateOpen     = "open"
+    StateHalfOpen = "half-open"
+)
+
+// Breaker egy egyszerű circuit breaker: threshold egymást követő hiba után nyit, openTimeout
+// elteltével egyetlen próbakérést enged át (half-open); ha az sikeres, zár, különben újra nyit.
+type Breaker struct {
+    mu          sync.Mutex
+    threshold   int
+    openTimeout time.Duration
+    state       string
+    failures    int
+    openedAt    time.Time
+    probing     bool
+    opens       int64
+}
+
+// NewBreaker létrehoz egy zárt állapotú breakert.
+func NewBreaker(threshold int, openTimeout time.Duration) *Breaker {
+    return &Breaker{threshold: threshold, openTimeout: openTimeout, state: StateClosed}
+}
+
+// Allow eldönti, hogy a kérés elküldhető-e. Nyitott állapotban *CircuitOpenError hibát ad.
+func (b *Breaker) Allow() error {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    switch b.state {
+    case StateOpen:
+        if wait := b.openTimeout - time.Since(b.openedAt); wait > 0 {
+            return &CircuitOpenError{RetryAfter: wait}
+        }
+        b.state = StateHalfOpen
+        b.probing = true
+        return nil
+    case StateHalfOpen:
+        if b.probing {
+            return &CircuitOpenError{RetryAfter: b.openTimeout}
+        }
+        b.probing = true
+    }
+    return nil
+}
+
+// Success egy sikeres kérést rögzít: zárja a breakert és nullázza a hibaszámlálót.
+func (b *Breaker) Success() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.state = StateClosed
+    b.failures = 0
+    b.probing = false
+}
+
+// Failure egy sikertelen kérést rögzít; a küszöb elérésekor (vagy sikertelen próbakérésnél) nyit.
+func (b *Breaker) Failure() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.failures++
+    if b.state == StateHalfOpen || b.failures >= b.threshold {
+        if b.state != StateOpen {
+            b.opens++
+        }
+        b.state = StateOpen
+        b.openedAt = time.Now()
+    }
+    b.probing = false
+}
+
+// Release egy semleges kimenetelű kérést (pl. a hívó megszakította) zár le, állapotváltás nélkül.
+func (b *Breaker) Release() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.probing = false
+}
+
+// State visszaadja a breaker aktuális állapotát.
+func (b *Breaker) State() string {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return b.state
+}
+
+// Opens visszaadja, hányszor nyitott a breaker az indulás óta.
+func (b *Breaker) Opens() int64 {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    return b.opens
+}
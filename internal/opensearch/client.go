@@ -0,0 +1,557 @@
+// Package opensearch egy megosztott, kapcsolat-újrahasznosító HTTP klienst ad az
+// OpenSearch REST API-hoz, amelyet a szolgáltatás összes kezelője közösen használ.
+package opensearch
+
+import (
+    "bytes"
+    "compress/gzip"
+    "context"
+    "crypto/tls"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log/slog"
+    "math/rand"
+    "net"
+    "net/http"
+    "net/http/httptrace"
+    "os"
+    "strconv"
+    "strings"
+    "sync/atomic"
+    "time"
+
+    "autocomplete/internal/ratelimit"
+    "autocomplete/internal/reqlog"
+)
+
+// Config az OpenSearch kliens beállításai. A nulla értékű mezők helyett
+// a DefaultConfig alapértékei érvényesek.
+type Config struct {
+    URL string
+    // URLs a fürt több csomópontjának címe; ha meg van adva, az URL helyett ezek között
+    // körbeforgó sorrendben oszlanak el a kérések, a hálózati hibával válaszoló csomópont pedig
+    // kikerül a körforgásból, amíg az állapotellenőrzés (lásd StartPinging) vissza nem veszi.
+    URLs     []string
+    Username string
+    Password string
+
+    // Timeout egy teljes kérés (kapcsolódás, küldés, válasz beolvasása) felső korlátja.
+    Timeout time.Duration
+    // DialTimeout a TCP kapcsolat felépítésének felső korlátja.
+    DialTimeout time.Duration
+    // TLSHandshakeTimeout a TLS kézfogás felső korlátja.
+    TLSHandshakeTimeout time.Duration
+    // MaxIdleConns a pool-ban tartott tétlen kapcsolatok összesített maximuma.
+    MaxIdleConns int
+    // MaxIdleConnsPerHost a hostonként tartott tétlen kapcsolatok maximuma.
+    MaxIdleConnsPerHost int
+    // IdleConnTimeout után a tétlen kapcsolatokat lezárjuk.
+    IdleConnTimeout time.Duration
+    // TLSClientConfig az OpenSearch felé használt TLS beállítások (nil esetén az alapértelmezett).
+    TLSClientConfig *tls.Config
+
+    // MaxRetries az átmeneti hibák miatti újrapróbálkozások maximális száma (0: nincs újrapróbálás).
+    MaxRetries int
+    // RetryBaseDelay az első újrapróbálás előtti várakozás felső korlátja; kísérletenként duplázódik.
+    RetryBaseDelay time.Duration
+    // RetryMaxDelay az újrapróbálások közötti várakozás felső korlátja.
+    RetryMaxDelay time.Duration
+
+    // BreakerThreshold ennyi egymást követő sikertelen kérés után nyit a circuit breaker
+    // (0: nincs breaker).
+    BreakerThreshold int
+    // BreakerOpenTimeout ennyi ideig utasítja el a nyitott breaker a kéréseket próbakérés előtt.
+    BreakerOpenTimeout time.Duration
+
+    // SlowQueryThreshold az ennél lassabb kéréseket a "slow_query" naplócsatornára írja és
+    // számolja (0: kikapcsolva); lásd logSlowQuery.
+    SlowQueryThreshold time.Duration
+
+    // MaxConcurrent az egyszerre futó kérések felső korlátja (0: korlátlan); a többi legfeljebb
+    // QueueTimeout ideig vár, utána *OverloadedError hibát kap, így egy forgalmi csúcs nem
+    // árasztja el a klasztert (search queue rejection).
+    MaxConcurrent int
+    // QueueTimeout a MaxConcurrent miatti várakozás felső korlátja.
+    QueueTimeout time.Duration
+
+    // Compression bekapcsolásakor (a DefaultConfig-ban bekapcsolva) a kliens gzip tömörített
+    // választ kér, és a legalább compressMinBytes méretű kéréstörzseket (pl. a _bulk import
+    // payloadját) gzip-pel tömörítve küldi; a megtakarított bájtokat a Stats számolja. Kikapcsolva
+    // a forgalom mindkét irányban tömörítetlen. Felvételi és visszajátszási módban (Mode) nincs
+    // tömörítés, mert a felvételek a tömörítetlen törzsekkel azonosítják és tárolják a kéréseket.
+    Compression bool
+
+    // Mode a kliens működési módja (ModeLive, ModeRecord, ModeReplay vagy ModeStub; üresen
+    // ModeLive). A FixtureDir a ModeRecord által írt és a ModeReplay által olvasott felvételek
+    // könyvtára.
+    Mode       string
+    FixtureDir string
+}
+
+// ErrOverloaded a párhuzamossági korlát miatt el sem küldött kérés hibája; errors.Is-szel
+// ellenőrizhető.
+var ErrOverloaded = errors.New("az OpenSearch felé túl sok a párhuzamos kérés")
+
+// OverloadedError a MaxConcurrent korlát miatt elutasított kérés hibája; a RetryAfter a
+// javasolt várakozás az újrapróbálás előtt.
+type OverloadedError struct {
+    RetryAfter time.Duration
+}
+
+func (e *OverloadedError) Error() string {
+    return ErrOverloaded.Error()
+}
+
+// Is lehetővé teszi az errors.Is(err, ErrOverloaded) ellenőrzést.
+func (e *OverloadedError) Is(target error) bool {
+    return target == ErrOverloaded
+}
+
+// DefaultConfig az alapértelmezett időkorlátokat és pool méreteket adja vissza.
+func DefaultConfig() Config {
+    return Config{
+        Timeout:             10 * time.Second,
+        DialTimeout:         5 * time.Second,
+        TLSHandshakeTimeout: 5 * time.Second,
+        MaxIdleConns:        100,
+        MaxIdleConnsPerHost: 32,
+        IdleConnTimeout:     90 * time.Second,
+        MaxRetries:          2,
+        RetryBaseDelay:      100 * time.Millisecond,
+        RetryMaxDelay:       2 * time.Second,
+        BreakerThreshold:    5,
+        BreakerOpenTimeout:  10 * time.Second,
+        Compression:         true,
+    }
+}
+
+// compressMinBytes az ennél rövidebb kéréstörzseket tömörítés nélkül küldjük: a keresések
+// payloadja jellemzően kisebb, és a gzip fejléc ott többet vesz el, mint amennyit nyer.
+const compressMinBytes = 1024
+
+// Stats a kliens kapcsolat-pool és kérés metrikái.
+type Stats struct {
+    Requests     int64  `json:"requests"`
+    Errors       int64  `json:"errors"`
+    Retries      int64  `json:"retries"`
+    Breaker      string `json:"breaker,omitempty"`
+    BreakerOpens int64  `json:"breakerOpens"`
+    InFlight     int64  `json:"inFlight"`
+    ConnsNew     int64  `json:"connsNew"`
+    ConnsReused  int64  `json:"connsReused"`
+    SlowQueries  int64  `json:"slowQueries"`
+    // Nodes a csomópontok állapota (lásd Config.URLs).
+    Nodes []NodeStats `json:"nodes"`
+    // CompressedRequests és RequestBytesSaved a gzip-pel tömörítve küldött kéréstörzsek száma és
+    // az így megtakarított bájtok, CompressedResponses és ResponseBytesSaved ugyanez a válaszokra.
+    CompressedRequests  int64 `json:"compressedRequests"`
+    RequestBytesSaved   int64 `json:"requestBytesSaved"`
+    CompressedResponses int64 `json:"compressedResponses"`
+    ResponseBytesSaved  int64 `json:"responseBytesSaved"`
+    // Concurrency a MaxConcurrent korlát állapota; nil, ha nincs korlát.
+    Concurrency *ratelimit.SemaphoreStats `json:"concurrency,omitempty"`
+}
+
+// Response egy lefutott OpenSearch kérés státusza és teljes válasz body-ja.
+type Response struct {
+    StatusCode int
+    Body       []byte
+
+    retryAfter string
+}
+
+// Client egy megosztott, konkurens használatra biztonságos OpenSearch kliens.
+type Client struct {
+    nodes     []*node
+    nextNode  atomic.Uint64
+    live      bool
+    username  string
+    password  string
+    http      *http.Client
+    transport *http.Transport
+
+    maxRetries     int
+    retryBaseDelay time.Duration
+    retryMaxDelay  time.Duration
+    breaker        *Breaker
+    slowThreshold  time.Duration
+    concurrency    *ratelimit.Semaphore
+    // compression a kéréstörzsek és a válaszok tömörítése (lásd Config.Compression).
+    compression bool
+
+    requests    atomic.Int64
+    retries     atomic.Int64
+    errors      atomic.Int64
+    inFlight    atomic.Int64
+    connsNew    atomic.Int64
+    connsReused atomic.Int64
+    slowQueries atomic.Int64
+
+    compressedRequests  atomic.Int64
+    requestBytesSaved   atomic.Int64
+    compressedResponses atomic.Int64
+    responseBytesSaved  atomic.Int64
+}
+
+// New létrehoz egy klienst a megadott beállításokkal.
+func New(cfg Config) *Client {
+    def := DefaultConfig()
+    if cfg.Timeout == 0 {
+        cfg.Timeout = def.Timeout
+    }
+    if cfg.DialTimeout == 0 {
+        cfg.DialTimeout = def.DialTimeout
+    }
+    if cfg.TLSHandshakeTimeout == 0 {
+        cfg.TLSHandshakeTimeout = def.TLSHandshakeTimeout
+    }
+    if cfg.MaxIdleConns == 0 {
+        cfg.MaxIdleConns = def.MaxIdleConns
+    }
+    if cfg.MaxIdleConnsPerHost == 0 {
+        cfg.MaxIdleConnsPerHost = def.MaxIdleConnsPerHost
+    }
+    if cfg.IdleConnTimeout == 0 {
+        cfg.IdleConnTimeout = def.IdleConnTimeout
+    }
+    if cfg.RetryBaseDelay == 0 {
+        cfg.RetryBaseDelay = def.RetryBaseDelay
+    }
+    if cfg.RetryMaxDelay == 0 {
+        cfg.RetryMaxDelay = def.RetryMaxDelay
+    }
+    if cfg.BreakerOpenTimeout == 0 {
+        cfg.BreakerOpenTimeout = def.BreakerOpenTimeout
+    }
+    var breaker *Breaker
+    if cfg.BreakerThreshold > 0 {
+        breaker = NewBreaker(cfg.BreakerThreshold, cfg.BreakerOpenTimeout)
+    }
+
+    transport := &http.Transport{
+        Proxy: http.ProxyFromEnvironment,
+        DialContext: (&net.Dialer{
+            Timeout:   cfg.DialTimeout,
+            KeepAlive: 30 * time.Second,
+        }).DialContext,
+        ForceAttemptHTTP2:   true,
+        TLSClientConfig:     cfg.TLSClientConfig,
+        TLSHandshakeTimeout: cfg.TLSHandshakeTimeout,
+        MaxIdleConns:        cfg.MaxIdleConns,
+        MaxIdleConnsPerHost: cfg.MaxIdleConnsPerHost,
+        IdleConnTimeout:     cfg.IdleConnTimeout,
+        // A tömörített választ a doOnce kéri és bontja ki, hogy a megtakarítás mérhető legyen.
+        DisableCompression: true,
+    }
+    var roundTripper http.RoundTripper = transport
+    switch cfg.Mode {
+    case ModeRecord:
+        if err := os.MkdirAll(cfg.FixtureDir, 0o755); err != nil {
+            slog.Error("OpenSearch fixture directory not created", "dir", cfg.FixtureDir, "error", err)
+        }
+        roundTripper = &recordingTransport{next: transport, dir: cfg.FixtureDir}
+    case ModeReplay:
+        roundTripper = &replayTransport{dir: cfg.FixtureDir}
+    case ModeStub:
+        roundTripper = stubTransport{}
+    }
+    return &Client{
+        nodes:          newNodes(cfg),
+        live:           cfg.Mode == "" || cfg.Mode == ModeLive,
+        username:       cfg.Username,
+        password:       cfg.Password,
+        http:           &http.Client{Timeout: cfg.Timeout, Transport: roundTripper},
+        transport:      transport,
+        maxRetries:     cfg.MaxRetries,
+        retryBaseDelay: cfg.RetryBaseDelay,
+        retryMaxDelay:  cfg.RetryMaxDelay,
+        breaker:        breaker,
+        slowThreshold:  cfg.SlowQueryThreshold,
+        concurrency:    ratelimit.NewSemaphore(cfg.MaxConcurrent, cfg.QueueTimeout),
+        compression:    cfg.Compression && cfg.Mode != ModeRecord && cfg.Mode != ModeReplay,
+    }
+}
+
+// Do végrehajt egy kérést a path útvonalon (pl. "/index/_search"), és beolvassa a teljes választ.
+// Hibát csak hálózati vagy olvasási problémánál ad; a nem 2xx státuszokat a hívó értelmezi.
+// Az átmeneti hibákat (lásd shouldRetry) legfeljebb MaxRetries alkalommal, jitterrel növelt
+// várakozás után újrapróbálja; a legutolsó kísérlet eredményét adja vissza.
+// Ha a circuit breaker nyitva van, a kérést el sem küldi, hanem *CircuitOpenError hibát ad;
+// ha a MaxConcurrent korlát miatt a QueueTimeout alatt sem jut szabad hely, *OverloadedError-t.
+// A hely az újrapróbálások idejére is foglalt marad. Több csomópontnál (Config.URLs) minden
+// kísérlet a következő egészséges csomóponthoz megy, így a hálózati hiba utáni újrapróbálás
+// egy másik csomóponton történik.
+func (c *Client) Do(ctx context.Context, method, path string, body []byte, contentType string) (*Response, error) {
+    c.requests.Add(1)
+    if c.breaker != nil {
+        if err := c.breaker.Allow(); err != nil {
+            c.errors.Add(1)
+            return nil, err
+        }
+    }
+    if err := c.concurrency.Acquire(ctx); err != nil {
+        c.errors.Add(1)
+        // A kérés el sem indult: ha az Allow épp a félig nyitott állapot próbakérését adta ki, azt
+        // vissza kell adni, különben a breaker félig nyitva ragadna, és minden további kérést elutasítana.
+        if c.breaker != nil {
+            c.breaker.Release()
+        }
+        if errors.Is(err, ratelimit.ErrQueueFull) {
+            return nil, &OverloadedError{RetryAfter: time.Second}
+        }
+        return nil, err
+    }
+    defer c.concurrency.Release()
+    c.inFlight.Add(1)
+    defer c.inFlight.Add(-1)
+
+    start := time.Now()
+    idempotent := isIdempotent(method, path)
+    payload, encoding := c.compress(body)
+    for attempt := 0; ; attempt++ {
+        resp, err := c.doOnce(ctx, method, path, payload, contentType, encoding)
+        if attempt >= c.maxRetries || !shouldRetry(resp, err, idempotent) || ctx.Err() != nil {
+            if err != nil {
+                c.errors.Add(1)
+            }
+            c.recordOutcome(ctx, resp, err)
+            if elapsed := time.Since(start); c.slowThreshold > 0 && elapsed >= c.slowThreshold {
+                c.logSlowQuery(ctx, method, path, body, resp, elapsed, attempt)
+            }
+            return resp, err
+        }
+        delay := c.backoff(attempt, resp)
+        c.retries.Add(1)
+        timer := time.NewTimer(delay)
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+            c.errors.Add(1)
+            c.recordOutcome(ctx, nil, ctx.Err())
+            return nil, ctx.Err()
+        case <-timer.C:
+        }
+    }
+}
+
+// maxSlowQueryPayload a lassú kérés naplózott payloadjának legnagyobb hossza bájtban.
+const maxSlowQueryPayload = 4096
+
+// logSlowQuery a SlowQueryThreshold-nál lassabb kérést a "slow_query" csatornára naplózza a
+// payloaddal (legfeljebb maxSlowQueryPayload bájt), az OpenSearch által mért "took" idővel
+// (ha a válasz tartalmazza), a válasz méretével és az újrapróbálások számával, és számolja.
+func (c *Client) logSlowQuery(ctx context.Context, method, path string, body []byte, resp *Response, elapsed time.Duration, retries int) {
+    c.slowQueries.Add(1)
+    payload := string(body)
+    if len(payload) > maxSlowQueryPayload {
+        payload = payload[:maxSlowQueryPayload] + "..."
+    }
+    attrs := []any{"channel", "slow_query", "request_id", reqlog.RequestID(ctx), "method", method, "path", path,
+        "duration_ms", elapsed.Milliseconds(), "retries", retries, "payload", payload}
+    if resp != nil {
+        attrs = append(attrs, "status", resp.StatusCode, "response_bytes", len(resp.Body))
+        var took struct {
+            Took *int64 `json:"took"`
+        }
+        if json.Unmarshal(resp.Body, &took) == nil && took.Took != nil {
+            attrs = append(attrs, "took_ms", *took.Took)
+        }
+    }
+    slog.Warn("Slow OpenSearch query", attrs...)
+}
+
+// recordOutcome a kérés végeredményét a breakernek jelzi. Hibának a hálózati hibák, az 5xx és
+// a 429 válaszok számítanak; a hívó általi megszakítás semleges.
+func (c *Client) recordOutcome(ctx context.Context, resp *Response, err error) {
+    if c.breaker == nil {
+        return
+    }
+    switch {
+    case ctx.Err() != nil:
+        c.breaker.Release()
+    case err != nil, resp.StatusCode >= 500, resp.StatusCode == http.StatusTooManyRequests:
+        c.breaker.Failure()
+    default:
+        c.breaker.Success()
+    }
+}
+
+// compress a legalább compressMinBytes méretű body-t gzip-pel tömöríti, ha a kéréstörzsek
+// tömörítése be van kapcsolva, és a tömörített alak rövidebb; ilyenkor a Content-Encoding
+// értékét is visszaadja. Egyébként a body-t változatlanul, üres kódolással adja vissza.
+func (c *Client) compress(body []byte) ([]byte, string) {
+    if !c.compression || len(body) < compressMinBytes {
+        return body, ""
+    }
+    var buf bytes.Buffer
+    zw := gzip.NewWriter(&buf)
+    if _, err := zw.Write(body); err != nil {
+        return body, ""
+    }
+    if err := zw.Close(); err != nil || buf.Len() >= len(body) {
+        return body, ""
+    }
+    c.compressedRequests.Add(1)
+    c.requestBytesSaved.Add(int64(len(body) - buf.Len()))
+    return buf.Bytes(), "gzip"
+}
+
+// doOnce egyetlen HTTP kísérletet hajt végre; az encoding (ha nem üres) a body Content-Encoding
+// fejléce (lásd compress).
+func (c *Client) doOnce(ctx context.Context, method, path string, body []byte, contentType, encoding string) (*Response, error) {
+    var reader io.Reader
+    if body != nil {
+        reader = bytes.NewReader(body)
+    }
+    nd := c.pickNode()
+    nd.requests.Add(1)
+    req, err := http.NewRequestWithContext(ctx, method, nd.url+path, reader)
+    if err != nil {
+        return nil, fmt.Errorf("hiba a HTTP kérés létrehozásakor: %w", err)
+    }
+    if contentType != "" {
+        req.Header.Set("Content-Type", contentType)
+    }
+    if encoding != "" {
+        req.Header.Set("Content-Encoding", encoding)
+    }
+    if c.compression {
+        req.Header.Set("Accept-Encoding", "gzip")
+    }
+    if c.username != "" {
+        req.SetBasicAuth(c.username, c.password)
+    }
+    trace := &httptrace.ClientTrace{
+        GotConn: func(info httptrace.GotConnInfo) {
+            if info.Reused {
+                c.connsReused.Add(1)
+            } else {
+                c.connsNew.Add(1)
+            }
+        },
+    }
+    req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+    resp, err := c.http.Do(req)
+    if err != nil {
+        if ctx.Err() == nil && !errors.Is(err, ErrFixtureMissing) {
+            c.markDown(nd, err)
+        }
+        return nil, err
+    }
+    c.markUp(nd)
+    defer resp.Body.Close()
+    respBody, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return nil, fmt.Errorf("hiba a válasz beolvasásakor: %w", err)
+    }
+    if resp.Header.Get("Content-Encoding") == "gzip" && len(respBody) > 0 {
+        compressed := len(respBody)
+        if respBody, err = gunzip(respBody); err != nil {
+            return nil, fmt.Errorf("hiba a tömörített válasz kibontásakor: %w", err)
+        }
+        c.compressedResponses.Add(1)
+        c.responseBytesSaved.Add(int64(len(respBody) - compressed))
+    }
+    return &Response{StatusCode: resp.StatusCode, Body: respBody, retryAfter: resp.Header.Get("Retry-After")}, nil
+}
+
+// gunzip kibontja a gzip tömörített adatot.
+func gunzip(data []byte) ([]byte, error) {
+    zr, err := gzip.NewReader(bytes.NewReader(data))
+    if err != nil {
+        return nil, err
+    }
+    defer zr.Close()
+    return io.ReadAll(zr)
+}
+
+// isIdempotent jelzi, hogy a kérés többszöri végrehajtása biztonságos-e. A GET/HEAD/PUT/DELETE
+// kérések és a csak olvasó POST végpontok (_search, _msearch, _count, _analyze, _mget) ilyenek.
+func isIdempotent(method, path string) bool {
+    switch method {
+    case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+        return true
+    }
+    endpoint := path
+    if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+        endpoint = endpoint[:i]
+    }
+    endpoint = endpoint[strings.LastIndexByte(endpoint, '/')+1:]
+    switch endpoint {
+    case "_search", "_msearch", "_count", "_analyze", "_mget":
+        return true
+    }
+    return false
+}
+
+// shouldRetry eldönti, hogy egy kísérlet eredménye átmeneti hiba-e. A 429 azt jelzi, hogy a
+// fürt el sem kezdte a kérés feldolgozását, ezért minden kérésnél újrapróbálható; az 502/503/504
+// válaszok és a hálózati hibák (pl. connection reset) csak idempotens kéréseknél. A hiányzó
+// felvétel (ErrFixtureMissing) nem átmeneti.
+func shouldRetry(resp *Response, err error, idempotent bool) bool {
+    if err != nil {
+        return idempotent && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+            !errors.Is(err, ErrFixtureMissing)
+    }
+    switch resp.StatusCode {
+    case http.StatusTooManyRequests:
+        return true
+    case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+        return idempotent
+    }
+    return false
+}
+
+// backoff a következő kísérlet előtti várakozás: RetryBaseDelay * 2^attempt, legfeljebb
+// RetryMaxDelay, "full jitter"-rel; ha a válasz Retry-After fejlécet adott, azt tiszteletben tartja.
+func (c *Client) backoff(attempt int, resp *Response) time.Duration {
+    if resp != nil && resp.retryAfter != "" {
+        if secs, err := strconv.Atoi(resp.retryAfter); err == nil && secs >= 0 {
+            if d := time.Duration(secs) * time.Second; d <= c.retryMaxDelay {
+                return d
+            }
+            return c.retryMaxDelay
+        }
+    }
+    d := c.retryBaseDelay << attempt
+    if d <= 0 || d > c.retryMaxDelay {
+        d = c.retryMaxDelay
+    }
+    return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// CloseIdleConnections lezárja a pool-ban lévő tétlen kapcsolatokat.
+func (c *Client) CloseIdleConnections() {
+    c.transport.CloseIdleConnections()
+}
+
+// Stats visszaadja a kliens aktuális metrikáit.
+func (c *Client) Stats() Stats {
+    s := Stats{
+        Requests:    c.requests.Load(),
+        Errors:      c.errors.Load(),
+        Retries:     c.retries.Load(),
+        InFlight:    c.inFlight.Load(),
+        ConnsNew:    c.connsNew.Load(),
+        ConnsReused: c.connsReused.Load(),
+        SlowQueries: c.slowQueries.Load(),
+
+        CompressedRequests:  c.compressedRequests.Load(),
+        RequestBytesSaved:   c.requestBytesSaved.Load(),
+        CompressedResponses: c.compressedResponses.Load(),
+        ResponseBytesSaved:  c.responseBytesSaved.Load(),
+        Nodes:               c.nodeStats(),
+    }
+    if c.concurrency != nil {
+        concurrency := c.concurrency.Stats()
+        s.Concurrency = &concurrency
+    }
+    if c.breaker != nil {
+        s.Breaker = c.breaker.State()
+        s.BreakerOpens = c.breaker.Opens()
+    }
+    return s
+}
@@ -0,0 +1,296 @@
+package opensearch
+
+import (
+    "bytes"
+    "compress/gzip"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log/slog"
+    "net/http"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+)
+
+// A kliens működési módjai (Config.Mode). Fejlesztéshez és integrációs tesztekhez a kliens
+// fürt nélkül, determinisztikus válaszokkal is futtatható.
+const (
+    // ModeLive a valódi fürtnek küldi a kéréseket (alapértelmezés).
+    ModeLive = "live"
+    // ModeRecord a valódi fürtnek küldi a kéréseket, és a kérés-válasz párokat a FixtureDir
+    // könyvtárba írja.
+    ModeRecord = "record"
+    // ModeReplay a FixtureDir felvételeiből válaszol, a fürthöz nem kapcsolódik; a fel nem vett
+    // kérések ErrFixtureMissing hibát kapnak.
+    ModeReplay = "replay"
+    // ModeStub a beépített, memóriabeli csonkkal válaszol: minden kérés sikeres (a _bulk és az
+    // _msearch műveletenként, illetve keresésenként), a keresések üres találati listát adnak.
+    ModeStub = "stub"
+)
+
+// IsMode jelzi, hogy a mode ismert működési mód-e.
+func IsMode(mode string) bool {
+    switch mode {
+    case ModeLive, ModeRecord, ModeReplay, ModeStub:
+        return true
+    }
+    return false
+}
+
+// ErrFixtureMissing jelzi, hogy replay módban a kéréshez nincs felvétel.
+var ErrFixtureMissing = errors.New("a kéréshez nincs felvett OpenSearch válasz")
+
+// Fixture egy felvett kérés-válasz pár; a FixtureDir könyvtárban fájlonként egy JSON objektum,
+// kézzel is szerkeszthető.
+type Fixture struct {
+    Method      string `json:"method"`
+    Path        string `json:"path"`
+    RequestBody string `json:"requestBody,omitempty"`
+    Status      int    `json:"status"`
+    ContentType string `json:"contentType,omitempty"`
+    Body        string `json:"body"`
+}
+
+// fixtureNameUnsafe a fájlnévben nem használt karakterek.
+var fixtureNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// fixtureName a kéréshez tartozó felvétel fájlneve: a metódus és az útvonal olvasható része,
+// valamint a metódus, a teljes útvonal és a (JSON esetén kulcsrendezett) törzs hash-e.
+func fixtureName(method, path string, body []byte) string {
+    sum := sha256.New()
+    fmt.Fprintf(sum, "%s %s\n", method, path)
+    sum.Write(canonicalBody(body))
+    readable := strings.Trim(fixtureNameUnsafe.ReplaceAllString(path, "_"), "_")
+    if len(readable) > 48 {
+        readable = readable[:48]
+    }
+    return fmt.Sprintf("%s_%s_%s.json", method, readable, hex.EncodeToString(sum.Sum(nil))[:16])
+}
+
+// canonicalBody a JSON törzset (NDJSON esetén soronként) kulcsrendezett, tömör alakra hozza,
+// hogy a mezősorrend ne befolyásolja a felvétel azonosítását; más törzset változatlanul hagy.
+func canonicalBody(body []byte) []byte {
+    var out bytes.Buffer
+    for _, line := range bytes.Split(body, []byte("\n")) {
+        if len(bytes.TrimSpace(line)) == 0 {
+            continue
+        }
+        var v interface{}
+        if err := json.Unmarshal(line, &v); err != nil {
+            return body
+        }
+        canonical, _ := json.Marshal(v)
+        out.Write(canonical)
+        out.WriteByte('\n')
+    }
+    return out.Bytes()
+}
+
+// requestBody a kérés törzse a req módosítása nélkül.
+func requestBody(req *http.Request) ([]byte, error) {
+    if req.Body == nil || req.GetBody == nil {
+        return nil, nil
+    }
+    body, err := req.GetBody()
+    if err != nil {
+        return nil, err
+    }
+    defer body.Close()
+    return io.ReadAll(body)
+}
+
+// fixtureResponse a felvételből képzett HTTP válasz.
+func fixtureResponse(req *http.Request, f Fixture) *http.Response {
+    header := http.Header{}
+    if f.ContentType != "" {
+        header.Set("Content-Type", f.ContentType)
+    }
+    return &http.Response{
+        Status:        fmt.Sprintf("%d %s", f.Status, http.StatusText(f.Status)),
+        StatusCode:    f.Status,
+        Proto:         "HTTP/1.1",
+        ProtoMajor:    1,
+        ProtoMinor:    1,
+        Header:        header,
+        Body:          io.NopCloser(strings.NewReader(f.Body)),
+        ContentLength: int64(len(f.Body)),
+        Request:       req,
+    }
+}
+
+// recordingTransport a next válaszait a dir könyvtárba írja (ModeRecord). Azonos kérés újabb
+// felvétele felülírja a korábbit.
+type recordingTransport struct {
+    next http.RoundTripper
+    dir  string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    body, err := requestBody(req)
+    if err != nil {
+        return nil, err
+    }
+    resp, err := t.next.RoundTrip(req)
+    if err != nil {
+        return nil, err
+    }
+    respBody, err := io.ReadAll(resp.Body)
+    resp.Body.Close()
+    if err != nil {
+        return nil, err
+    }
+    resp.Body = io.NopCloser(bytes.NewReader(respBody))
+    path := req.URL.RequestURI()
+    f := Fixture{Method: req.Method, Path: path, RequestBody: string(body), Status: resp.StatusCode,
+        ContentType: resp.Header.Get("Content-Type"), Body: string(respBody)}
+    if err := writeFixture(filepath.Join(t.dir, fixtureName(req.Method, path, body)), f); err != nil {
+        slog.Warn("OpenSearch fixture not recorded", "method", req.Method, "path", path, "error", err)
+    }
+    return resp, nil
+}
+
+// writeFixture a felvételt ideiglenes fájlon keresztül, atomi átnevezéssel írja ki.
+func writeFixture(name string, f Fixture) error {
+    data, _ := json.MarshalIndent(f, "", "  ")
+    tmp, err := os.CreateTemp(filepath.Dir(name), ".fixture-*")
+    if err != nil {
+        return err
+    }
+    if _, err := tmp.Write(append(data, '\n')); err != nil {
+        tmp.Close()
+        os.Remove(tmp.Name())
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        os.Remove(tmp.Name())
+        return err
+    }
+    return os.Rename(tmp.Name(), name)
+}
+
+// replayTransport a dir könyvtár felvételeiből válaszol (ModeReplay). A fájlokat kérésenként
+// olvassa, így a futó szolgáltatás alatt is szerkeszthetők.
+type replayTransport struct {
+    dir string
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    body, err := requestBody(req)
+    if err != nil {
+        return nil, err
+    }
+    path := req.URL.RequestURI()
+    name := fixtureName(req.Method, path, body)
+    data, err := os.ReadFile(filepath.Join(t.dir, name))
+    if errors.Is(err, os.ErrNotExist) {
+        slog.Warn("OpenSearch fixture missing", "method", req.Method, "path", path, "fixture", name)
+        return nil, fmt.Errorf("%w: %s %s (%s)", ErrFixtureMissing, req.Method, path, name)
+    }
+    if err != nil {
+        return nil, err
+    }
+    var f Fixture
+    if err := json.Unmarshal(data, &f); err != nil {
+        return nil, fmt.Errorf("hibás felvétel (%s): %w", name, err)
+    }
+    return fixtureResponse(req, f), nil
+}
+
+// stubTransport a ModeStub beépített csonkja: a HEAD kérésekre 200-zal, a keresésekre üres
+// találati listával, a _count-ra nullával, a _bulk-ra műveletenként egy sikeres elemmel, az
+// _msearch-re keresésenként egy üres találati listával, a point-in-time megnyitására egy
+// rögzített azonosítóval, minden másra {"acknowledged": true} válasszal felel.
+type stubTransport struct{}
+
+// stubSearchResponse az üres keresési válasz.
+const stubSearchResponse = `{"took":0,"timed_out":false,"hits":{"total":{"value":0,"relation":"eq"},"hits":[]},"aggregations":{},"suggest":{}}`
+
+func (stubTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    f := Fixture{Status: http.StatusOK, ContentType: "application/json", Body: `{"acknowledged":true}`}
+    switch endpoint := req.URL.Path[strings.LastIndexByte(req.URL.Path, '/')+1:]; {
+    case req.Method == http.MethodHead:
+        f.Body = ""
+    case endpoint == "_search":
+        f.Body = stubSearchResponse
+    case endpoint == "_msearch", endpoint == "_bulk":
+        body, err := stubRequestBody(req)
+        if err != nil {
+            return nil, err
+        }
+        if endpoint == "_msearch" {
+            f.Body = stubMsearchResponse(body)
+        } else {
+            f.Body = stubBulkResponse(body)
+        }
+    case endpoint == "_count":
+        f.Body = `{"count":0}`
+    case endpoint == "point_in_time" && req.Method == http.MethodPost:
+        f.Body = `{"pit_id":"stub"}`
+    }
+    return fixtureResponse(req, f), nil
+}
+
+// stubRequestBody a kérés törzse, a gzip tömörítésű törzs kicsomagolva (lásd Config.Compression).
+func stubRequestBody(req *http.Request) ([]byte, error) {
+    body, err := requestBody(req)
+    if err != nil || req.Header.Get("Content-Encoding") != "gzip" {
+        return body, err
+    }
+    zr, err := gzip.NewReader(bytes.NewReader(body))
+    if err != nil {
+        return nil, err
+    }
+    defer zr.Close()
+    return io.ReadAll(zr)
+}
+
+// ndjsonLines az NDJSON törzs nem üres sorai.
+func ndjsonLines(body []byte) [][]byte {
+    var lines [][]byte
+    for _, line := range bytes.Split(body, []byte("\n")) {
+        if len(bytes.TrimSpace(line)) > 0 {
+            lines = append(lines, line)
+        }
+    }
+    return lines
+}
+
+// stubMsearchResponse keresésenként (fejléc–törzs soronként) egy üres találati listát ad.
+func stubMsearchResponse(body []byte) string {
+    responses := make([]string, len(ndjsonLines(body))/2)
+    for i := range responses {
+        responses[i] = stubSearchResponse
+    }
+    return `{"took":0,"responses":[` + strings.Join(responses, ",") + `]}`
+}
+
+// stubBulkResponse a _bulk törzs minden műveletére egy sikeres elemet ad: az index és a create
+// 201-es, az update és a delete 200-as státuszt kap. A delete kivételével minden műveletsort egy
+// dokumentumsor követ, ezt átugorja.
+func stubBulkResponse(body []byte) string {
+    lines := ndjsonLines(body)
+    items := make([]map[string]map[string]int, 0, len(lines)/2)
+    for i := 0; i < len(lines); i++ {
+        var action map[string]json.RawMessage
+        if err := json.Unmarshal(lines[i], &action); err != nil {
+            continue
+        }
+        for name := range action {
+            status := http.StatusOK
+            if name == "index" || name == "create" {
+                status = http.StatusCreated
+            }
+            items = append(items, map[string]map[string]int{name: {"status": status}})
+            if name != "delete" {
+                i++
+            }
+        }
+    }
+    result, _ := json.Marshal(map[string]interface{}{"took": 0, "errors": false, "items": items})
+    return string(result)
+}
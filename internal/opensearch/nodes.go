@@ -0,0 +1,139 @@
+package opensearch
+
+import (
+    "context"
+    "errors"
+    "log/slog"
+    "net/http"
+    "strings"
+    "sync/atomic"
+    "time"
+)
+
+// pingTimeout egy csomópont állapotellenőrző kérésének felső korlátja.
+const pingTimeout = 2 * time.Second
+
+// node a fürt egy csomópontja a kliens szemszögéből: az alap URL-je, az állapota és a számlálói.
+type node struct {
+    url      string
+    down     atomic.Bool
+    requests atomic.Int64
+    failures atomic.Int64
+}
+
+// NodeStats egy csomópont állapota és a neki küldött kérések, illetve a hálózati hibával végzett
+// kérések száma.
+type NodeStats struct {
+    URL      string `json:"url"`
+    Healthy  bool   `json:"healthy"`
+    Requests int64  `json:"requests"`
+    Failures int64  `json:"failures"`
+}
+
+// newNodes a Config.URLs (üresen a Config.URL) címeiből képzi a csomópontokat.
+func newNodes(cfg Config) []*node {
+    urls := cfg.URLs
+    if len(urls) == 0 {
+        urls = []string{cfg.URL}
+    }
+    nodes := make([]*node, 0, len(urls))
+    for _, u := range urls {
+        nodes = append(nodes, &node{url: strings.TrimRight(u, "/")})
+    }
+    return nodes
+}
+
+// pickNode körbeforgó sorrendben a következő egészséges csomópontot adja. Ha egyik csomópont
+// sem egészséges, a sorban következőt adja, így a kérések a leállás után is próbálkoznak, és az
+// első sikeres kérés helyreállítja a csomópontot.
+func (c *Client) pickNode() *node {
+    start := c.nextNode.Add(1) - 1
+    n := uint64(len(c.nodes))
+    for i := uint64(0); i < n; i++ {
+        if nd := c.nodes[(start+i)%n]; !nd.down.Load() {
+            return nd
+        }
+    }
+    return c.nodes[start%n]
+}
+
+// markDown a hálózati hibával végződött kérés csomópontját kiveszi a körforgásból, hogy az
+// újrapróbálás (és a többi kérés) egy másik csomóponthoz menjen. Egyetlen csomópontnál nincs
+// hova átállni, ilyenkor nem jelöl.
+func (c *Client) markDown(nd *node, err error) {
+    nd.failures.Add(1)
+    if len(c.nodes) > 1 && !nd.down.Swap(true) {
+        slog.Warn("OpenSearch node marked down", "node", nd.url, "error", err)
+    }
+}
+
+// markUp a sikeresen válaszoló csomópontot visszaveszi a körforgásba.
+func (c *Client) markUp(nd *node) {
+    if nd.down.Swap(false) {
+        slog.Info("OpenSearch node recovered", "node", nd.url)
+    }
+}
+
+// StartPinging több csomópont esetén a háttérben interval időközönként ellenőrzi a csomópontokat
+// (HEAD /), és az állapotuk szerint kiveszi őket a körforgásból, illetve visszaveszi őket. A ctx
+// lezárásakor leáll. Egyetlen csomópontnál, nem pozitív intervallumnál és fejlesztői módban
+// (lásd Config.Mode) nem indul el.
+func (c *Client) StartPinging(ctx context.Context, interval time.Duration) {
+    if len(c.nodes) < 2 || interval <= 0 || !c.live {
+        return
+    }
+    slog.Info("OpenSearch node health checks scheduled", "nodes", len(c.nodes), "interval", interval.String())
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+        for {
+            select {
+            case <-ctx.Done():
+                return
+            case <-ticker.C:
+                for _, nd := range c.nodes {
+                    if err := c.ping(ctx, nd); err != nil {
+                        if ctx.Err() != nil {
+                            return
+                        }
+                        c.markDown(nd, err)
+                    } else {
+                        c.markUp(nd)
+                    }
+                }
+            }
+        }
+    }()
+}
+
+// ping a csomópont gyökerére küld HEAD kérést a breaker, a párhuzamossági korlát és az
+// újrapróbálás megkerülésével; az 5xx válasz is hibának számít.
+func (c *Client) ping(ctx context.Context, nd *node) error {
+    ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+    defer cancel()
+    req, err := http.NewRequestWithContext(ctx, http.MethodHead, nd.url+"/", nil)
+    if err != nil {
+        return err
+    }
+    if c.username != "" {
+        req.SetBasicAuth(c.username, c.password)
+    }
+    resp, err := c.http.Do(req)
+    if err != nil {
+        return err
+    }
+    resp.Body.Close()
+    if resp.StatusCode >= 500 {
+        return errors.New(resp.Status)
+    }
+    return nil
+}
+
+// nodeStats a csomópontok állapotának pillanatképe.
+func (c *Client) nodeStats() []NodeStats {
+    stats := make([]NodeStats, 0, len(c.nodes))
+    for _, nd := range c.nodes {
+        stats = append(stats, NodeStats{URL: nd.url, Healthy: !nd.down.Load(), Requests: nd.requests.Load(), Failures: nd.failures.Load()})
+    }
+    return stats
+}
@@ -0,0 +1,104 @@
+package ratelimit
+
+import (
+    "context"
+    "errors"
+    "sync/atomic"
+    "time"
+)
+
+// ErrQueueFull a párhuzamossági korlát miatt elutasított művelet hibája: a várakozás
+// túllépte a sor időkorlátját.
+var ErrQueueFull = errors.New("túl sok párhuzamos kérés")
+
+// Semaphore legfeljebb limit párhuzamos műveletet enged; a többi legfeljebb queueTimeout
+// ideig várakozik szabad helyre, utána ErrQueueFull hibát kap. A nil Semaphore nem korlátoz.
+// Konkurens használatra biztonságos.
+type Semaphore struct {
+    slots        chan struct{}
+    queueTimeout time.Duration
+
+    waiting  atomic.Int64
+    acquired atomic.Int64
+    rejected atomic.Int64
+}
+
+// SemaphoreStats a Semaphore pillanatnyi állapota és számlálói.
+type SemaphoreStats struct {
+    Limit    int   `json:"limit"`
+    InUse    int   `json:"inUse"`
+    Waiting  int64 `json:"waiting"`
+    Acquired int64 `json:"acquired"`
+    Rejected int64 `json:"rejected"`
+}
+
+// NewSemaphore limit párhuzamos helyet ad; limit < 1 esetén nil-t, azaz korlátlant.
+func NewSemaphore(limit int, queueTimeout time.Duration) *Semaphore {
+    if limit < 1 {
+        return nil
+    }
+    return &Semaphore{slots: make(chan struct{}, limit), queueTimeout: queueTimeout}
+}
+
+// Acquire helyet foglal; ha nincs szabad hely, legfeljebb a sor időkorlátjáig vár. Az időkorlát
+// lejártakor ErrQueueFull, a ctx megszakításakor a ctx hibáját adja. Sikeres foglalás után
+// a hívónak Release-t kell hívnia.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+    if s == nil {
+        return nil
+    }
+    select {
+    case s.slots <- struct{}{}:
+        s.acquired.Add(1)
+        return nil
+    default:
+    }
+    if s.queueTimeout <= 0 {
+        s.rejected.Add(1)
+        return ErrQueueFull
+    }
+    s.waiting.Add(1)
+    defer s.waiting.Add(-1)
+    timer := time.NewTimer(s.queueTimeout)
+    defer timer.Stop()
+    select {
+    case s.slots <- struct{}{}:
+        s.acquired.Add(1)
+        return nil
+    case <-timer.C:
+        s.rejected.Add(1)
+        return ErrQueueFull
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
+
+// Release felszabadít egy Acquire-rel foglalt helyet.
+func (s *Semaphore) Release() {
+    if s == nil {
+        return
+    }
+    <-s.slots
+}
+
+// QueueTimeout a várakozás felső korlátja; a hívók ebből számolhatják a Retry-After értékét.
+func (s *Semaphore) QueueTimeout() time.Duration {
+    if s == nil {
+        return 0
+    }
+    return s.queueTimeout
+}
+
+// Stats visszaadja a Semaphore aktuális állapotát.
+func (s *Semaphore) Stats() SemaphoreStats {
+    if s == nil {
+        return SemaphoreStats{}
+    }
+    return SemaphoreStats{
+        Limit:    cap(s.slots),
+        InUse:    len(s.slots),
+        Waiting:  s.waiting.Load(),
+        Acquired: s.acquired.Load(),
+        Rejected: s.rejected.Load(),
+    }
+}
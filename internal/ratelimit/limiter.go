@@ -0,0 +1,95 @@
+// Package ratelimit kulcsonkénti (pl. kliens IP szerinti) token bucket korlátozót, valamint
+// várakozási időkorlátos párhuzamossági korlátozót (Semaphore) ad.
+package ratelimit
+
+import (
+    "math"
+    "sync"
+    "time"
+)
+
+type bucket struct {
+    tokens float64
+    last   time.Time
+}
+
+// Limiter kulcsonként egy-egy token bucketet tart: másodpercenként rate tokennel töltődik,
+// legfeljebb burst tokenig. Konkurens használatra biztonságos.
+type Limiter struct {
+    mu      sync.Mutex
+    rate    float64
+    burst   float64
+    buckets map[string]*bucket
+}
+
+// New létrehoz egy korlátozót a megadott töltési sebességgel (token/másodperc) és löketmérettel.
+func New(rate float64, burst int) *Limiter {
+    if burst < 1 {
+        burst = 1
+    }
+    return &Limiter{
+        rate:    rate,
+        burst:   float64(burst),
+        buckets: make(map[string]*bucket),
+    }
+}
+
+// SetRate módosítja a töltési sebességet és a löketméretet; a meglévő bucketek
+// tokenjei legfeljebb az új löketméretig maradnak meg.
+func (l *Limiter) SetRate(rate float64, burst int) {
+    if burst < 1 {
+        burst = 1
+    }
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.rate = rate
+    l.burst = float64(burst)
+    for _, b := range l.buckets {
+        b.tokens = math.Min(l.burst, b.tokens)
+    }
+}
+
+// Allow elvesz egy tokent a kulcs bucketjéből. Ha nincs elég token, false-t és azt az időt
+// adja vissza, amennyi múlva a következő token rendelkezésre áll.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    now := time.Now()
+    b, ok := l.buckets[key]
+    if !ok {
+        b = &bucket{tokens: l.burst, last: now}
+        l.buckets[key] = b
+    } else {
+        b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+        b.last = now
+    }
+    if b.tokens >= 1 {
+        b.tokens--
+        return true, 0
+    }
+    wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+    return false, wait
+}
+
+// Cleanup eldobja azokat a bucketeket, amelyeket legalább idle ideje nem használtak;
+// ezek időközben úgyis teljesen feltöltődtek volna.
+func (l *Limiter) Cleanup(idle time.Duration) int {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    now := time.Now()
+    removed := 0
+    for key, b := range l.buckets {
+        if now.Sub(b.last) >= idle {
+            delete(l.buckets, key)
+            removed++
+        }
+    }
+    return removed
+}
+
+// Len a nyilvántartott kulcsok száma.
+func (l *Limiter) Len() int {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return len(l.buckets)
+}
@@ -0,0 +1,53 @@
+// Package reqlog a kérésenkénti naplózási adatokat (request ID, a kezelők és a lekérdező réteg
+// által hozzáadott mezők) hordozza a context.Context-ben, így a beágyazott csomagok is
+// gazdagíthatják a kérés naplósorát HTTP függőség nélkül.
+package reqlog
+
+import (
+    "context"
+    "sync"
+)
+
+type requestIDKey struct{}
+
+type fieldsKey struct{}
+
+// WithRequestID a kérés azonosítóját a kontextushoz rendeli.
+func WithRequestID(ctx context.Context, id string) context.Context {
+    return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID visszaadja a kéréshez rendelt azonosítót (üres, ha nincs).
+func RequestID(ctx context.Context) string {
+    id, _ := ctx.Value(requestIDKey{}).(string)
+    return id
+}
+
+// Fields a kérésenkénti naplósorhoz hozzáadott mezőket gyűjti; konkurens használatra biztonságos.
+type Fields struct {
+    mu    sync.Mutex
+    attrs []any
+}
+
+// WithFields új mezőgyűjtőt rendel a kontextushoz, amelybe az Add ír.
+func WithFields(ctx context.Context) (context.Context, *Fields) {
+    f := &Fields{}
+    return context.WithValue(ctx, fieldsKey{}, f), f
+}
+
+// Args a hozzáadott mezők másolata slog kulcs-érték párokként.
+func (f *Fields) Args() []any {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return append([]any(nil), f.attrs...)
+}
+
+// Add a kérés naplósorához fűz mezőket (pl. query, result_count, upstream_status).
+// Mezőgyűjtő nélküli kontextusban nem csinál semmit.
+func Add(ctx context.Context, args ...any) {
+    if f, ok := ctx.Value(fieldsKey{}).(*Fields); ok {
+        f.mu.Lock()
+        f.attrs = append(f.attrs, args...)
+        f.mu.Unlock()
+    }
+}
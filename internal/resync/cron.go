@@ -0,0 +1,148 @@
+package resync
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// cronMacros a gyakori ütemezések rövid alakjai.
+var cronMacros = map[string]string{
+    "@hourly":   "0 * * * *",
+    "@daily":    "0 0 * * *",
+    "@midnight": "0 0 * * *",
+    "@weekly":   "0 0 * * 0",
+    "@monthly":  "0 0 1 * *",
+    "@yearly":   "0 0 1 1 *",
+}
+
+// maxScheduleSearch ennyi ideig keressük előre a következő időpontot; ha nincs ilyen (pl.
+// "0 0 30 2 *"), a Next nulla időt ad.
+const maxScheduleSearch = 5 * 366 * 24 * time.Hour
+
+// Schedule egy ötmezős cron kifejezés: perc (0–59), óra (0–23), a hónap napja (1–31), hónap
+// (1–12) és a hét napja (0–7, a 0 és a 7 is vasárnap). Mezőnként "*", szám, "a-b" tartomány,
+// "/n" lépésköz és vesszővel elválasztott lista adható meg; a cronMacros rövidítések is
+// használhatók. Ha a hónap napja és a hét napja is korlátozott, bármelyik egyezése elég.
+type Schedule struct {
+    expr                          string
+    minute, hour, dom, month, dow uint64
+    domRestricted, dowRestricted  bool
+}
+
+// ParseSchedule értelmezi a cron kifejezést.
+func ParseSchedule(expr string) (*Schedule, error) {
+    spec := strings.TrimSpace(expr)
+    if macro, ok := cronMacros[spec]; ok {
+        spec = macro
+    }
+    fields := strings.Fields(spec)
+    if len(fields) != 5 {
+        return nil, fmt.Errorf("a cron kifejezésnek 5 mezőből kell állnia: %q", expr)
+    }
+    s := &Schedule{expr: expr}
+    var err error
+    if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+        return nil, fmt.Errorf("perc: %w", err)
+    }
+    if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+        return nil, fmt.Errorf("óra: %w", err)
+    }
+    if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+        return nil, fmt.Errorf("nap: %w", err)
+    }
+    if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+        return nil, fmt.Errorf("hónap: %w", err)
+    }
+    if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+        return nil, fmt.Errorf("a hét napja: %w", err)
+    }
+    if s.dow&(1<<7) != 0 {
+        s.dow |= 1
+    }
+    s.domRestricted = fields[2] != "*"
+    s.dowRestricted = fields[4] != "*"
+    return s, nil
+}
+
+// String az eredeti kifejezés.
+func (s *Schedule) String() string {
+    return s.expr
+}
+
+// parseCronField egy mezőt bitmaszkká alakít, amelyben az i. bit jelzi, hogy az i érték illeszkedik.
+func parseCronField(field string, min, max int) (uint64, error) {
+    var mask uint64
+    for _, part := range strings.Split(field, ",") {
+        rangePart, stepPart, hasStep := strings.Cut(part, "/")
+        step := 1
+        if hasStep {
+            n, err := strconv.Atoi(stepPart)
+            if err != nil || n <= 0 {
+                return 0, fmt.Errorf("érvénytelen lépésköz: %q", part)
+            }
+            step = n
+        }
+        lo, hi := min, max
+        switch {
+        case rangePart == "*":
+        case strings.Contains(rangePart, "-"):
+            a, b, _ := strings.Cut(rangePart, "-")
+            var errA, errB error
+            lo, errA = strconv.Atoi(a)
+            hi, errB = strconv.Atoi(b)
+            if errA != nil || errB != nil || lo > hi {
+                return 0, fmt.Errorf("érvénytelen tartomány: %q", part)
+            }
+        default:
+            n, err := strconv.Atoi(rangePart)
+            if err != nil {
+                return 0, fmt.Errorf("érvénytelen érték: %q", part)
+            }
+            lo, hi = n, n
+            if hasStep {
+                hi = max
+            }
+        }
+        if lo < min || hi > max {
+            return 0, fmt.Errorf("%q kívül esik a %d–%d tartományon", part, min, max)
+        }
+        for v := lo; v <= hi; v += step {
+            mask |= 1 << uint(v)
+        }
+    }
+    return mask, nil
+}
+
+// dayMatches jelzi, hogy a nap illeszkedik-e a hónap napja és a hét napja mezőre.
+func (s *Schedule) dayMatches(t time.Time) bool {
+    dom := s.dom&(1<<uint(t.Day())) != 0
+    dow := s.dow&(1<<uint(t.Weekday())) != 0
+    if s.domRestricted && s.dowRestricted {
+        return dom || dow
+    }
+    return dom && dow
+}
+
+// Next az after utáni első illeszkedő időpont (percre kerekítve, after időzónájában), vagy
+// nulla idő, ha maxScheduleSearch időn belül nincs ilyen.
+func (s *Schedule) Next(after time.Time) time.Time {
+    t := after.Truncate(time.Minute).Add(time.Minute)
+    limit := after.Add(maxScheduleSearch)
+    for t.Before(limit) {
+        switch {
+        case s.month&(1<<uint(t.Month())) == 0:
+            t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+        case !s.dayMatches(t):
+            t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+        case s.hour&(1<<uint(t.Hour())) == 0:
+            t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+        case s.minute&(1<<uint(t.Minute())) == 0:
+            t = t.Add(time.Minute)
+        default:
+            return t
+        }
+    }
+    return time.Time{}
+}
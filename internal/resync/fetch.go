@@ -0,0 +1,152 @@
+package resync
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "os"
+    "sort"
+    "strings"
+    "time"
+)
+
+// S3Config az s3://bucket/kulcs források elérése. Üres Endpoint esetén az AWS S3 régiós,
+// virtual-hosted végpontját használjuk (https://<bucket>.s3.<régió>.amazonaws.com), különben
+// path-style kéréseket küldünk az Endpoint címre (S3-kompatibilis tárolók, pl. MinIO). A
+// hozzáférési kulcsok a szokásos AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY és AWS_SESSION_TOKEN
+// környezeti változókból jönnek; ha nincsenek megadva, a kérés aláírás nélkül megy ki
+// (nyilvános bucket).
+type S3Config struct {
+    Region   string
+    Endpoint string
+}
+
+// fetch a source tartalmát a dst-be másolja, és az átvitt bájtok számát adja vissza. A source
+// http(s):// URL (pl. előre aláírt S3 URL is) vagy s3://bucket/kulcs lehet.
+func fetch(ctx context.Context, client *http.Client, source string, s3 S3Config, dst io.Writer) (int64, error) {
+    u, err := url.Parse(source)
+    if err != nil {
+        return 0, err
+    }
+    var req *http.Request
+    switch u.Scheme {
+    case "http", "https":
+        if req, err = http.NewRequestWithContext(ctx, http.MethodGet, source, nil); err != nil {
+            return 0, err
+        }
+    case "s3":
+        if req, err = s3Request(ctx, u, s3, time.Now()); err != nil {
+            return 0, err
+        }
+    default:
+        return 0, fmt.Errorf("nem támogatott forrás: %q (http, https vagy s3)", u.Scheme)
+    }
+    resp, err := client.Do(req)
+    if err != nil {
+        return 0, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+        return 0, fmt.Errorf("a letöltés sikertelen (%d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+    }
+    return io.Copy(dst, resp.Body)
+}
+
+// s3Request a GET kérést állítja össze az s3://bucket/kulcs objektumhoz, és ha vannak AWS
+// hozzáférési kulcsok, Signature V4-gyel aláírja.
+func s3Request(ctx context.Context, u *url.URL, cfg S3Config, now time.Time) (*http.Request, error) {
+    bucket, key := u.Host, strings.TrimPrefix(u.Path, "/")
+    if bucket == "" || key == "" {
+        return nil, fmt.Errorf("az S3 forrás alakja s3://bucket/kulcs: %q", u.String())
+    }
+    var target string
+    if cfg.Endpoint != "" {
+        target = strings.TrimSuffix(cfg.Endpoint, "/") + "/" + bucket + "/" + s3EscapePath(key)
+    } else {
+        target = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, cfg.Region, s3EscapePath(key))
+    }
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+    if err != nil {
+        return nil, err
+    }
+    accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+    if accessKey == "" || secretKey == "" {
+        return req, nil
+    }
+    signV4(req, accessKey, secretKey, os.Getenv("AWS_SESSION_TOKEN"), cfg.Region, now)
+    return req, nil
+}
+
+// s3EscapePath a kulcsot az S3 kanonikus URI szabályai szerint kódolja: a "/" marad, minden
+// más a nem fenntartott karaktereken kívül %XX alakú lesz.
+func s3EscapePath(key string) string {
+    var sb strings.Builder
+    for _, b := range []byte(key) {
+        switch {
+        case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+            b == '-', b == '_', b == '.', b == '~', b == '/':
+            sb.WriteByte(b)
+        default:
+            fmt.Fprintf(&sb, "%%%02X", b)
+        }
+    }
+    return sb.String()
+}
+
+// signV4 az AWS Signature Version 4 szerint aláírja a törzs nélküli S3 kérést.
+func signV4(req *http.Request, accessKey, secretKey, sessionToken, region string, now time.Time) {
+    amzDate := now.UTC().Format("20060102T150405Z")
+    date := amzDate[:8]
+    req.Header.Set("X-Amz-Date", amzDate)
+    req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+    if sessionToken != "" {
+        req.Header.Set("X-Amz-Security-Token", sessionToken)
+    }
+
+    headers := map[string]string{"host": req.URL.Host}
+    for name, values := range req.Header {
+        headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+    }
+    names := make([]string, 0, len(headers))
+    for name := range headers {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    var canonicalHeaders strings.Builder
+    for _, name := range names {
+        canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+    }
+    signedHeaders := strings.Join(names, ";")
+
+    canonicalRequest := strings.Join([]string{
+        req.Method,
+        req.URL.EscapedPath(),
+        req.URL.Query().Encode(),
+        canonicalHeaders.String(),
+        signedHeaders,
+        "UNSIGNED-PAYLOAD",
+    }, "\n")
+    scope := date + "/" + region + "/s3/aws4_request"
+    hash := sha256.Sum256([]byte(canonicalRequest))
+    stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+    key := hmacSHA256([]byte("AWS4"+secretKey), date)
+    key = hmacSHA256(key, region)
+    key = hmacSHA256(key, "s3")
+    key = hmacSHA256(key, "aws4_request")
+    signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+    req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+        accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+    mac := hmac.New(sha256.New, key)
+    mac.Write([]byte(data))
+    return mac.Sum(nil)
+}
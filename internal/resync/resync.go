@@ -0,0 +1,247 @@
+// Package resync a kanonikus címlista időzített, teljes újraszinkronizálása: a megadott URL-ről
+// vagy S3 bucketből letölti az adatállományt, egy új célindexbe tölti, majd az aliast atomi
+// váltással átállítja rá (lásd index.Manager.StartReindex import módban).
+package resync
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "net/url"
+    "os"
+    "path"
+    "strings"
+    "sync"
+    "time"
+
+    "autocomplete/internal/index"
+)
+
+// A szinkronizálás állapotai.
+const (
+    StatusRunning   = "running"
+    StatusCompleted = "completed"
+    StatusFailed    = "failed"
+)
+
+// A szinkronizálás indítói.
+const (
+    TriggerSchedule = "schedule"
+    TriggerManual   = "manual"
+)
+
+// ErrInProgress jelzi, hogy már fut egy szinkronizálás.
+var ErrInProgress = errors.New("már folyamatban van egy teljes újraszinkronizálás")
+
+// Job egy szinkronizálás állapota. A Target az új index neve, a Bytes a letöltött adatállomány
+// mérete, a Total, Indexed és Failed a betöltés összesítője (lásd index.BulkSummary).
+type Job struct {
+    Trigger    string     `json:"trigger"`
+    Source     string     `json:"source"`
+    Status     string     `json:"status"`
+    Target     string     `json:"target,omitempty"`
+    Bytes      int64      `json:"bytes"`
+    Total      int        `json:"total"`
+    Indexed    int        `json:"indexed"`
+    Failed     int        `json:"failed"`
+    StartedAt  time.Time  `json:"startedAt"`
+    FinishedAt *time.Time `json:"finishedAt,omitempty"`
+    Error      string     `json:"error,omitempty"`
+}
+
+// Status a GET /api/admin/resync válasza: az utolsó (vagy folyamatban lévő) szinkronizálás, és
+// ütemezés esetén a következő időpont.
+type Status struct {
+    Schedule string     `json:"schedule,omitempty"`
+    NextRun  *time.Time `json:"nextRun,omitempty"`
+    Last     *Job       `json:"last"`
+}
+
+// Options a szinkronizálás beállításai. A Source formátumát a kiterjesztése dönti el: .csv
+// esetén vesszővel elválasztott CSV a CSVHeaderMapping leképezéssel, egyébként NDJSON vagy JSON
+// tömb (lásd index.ReadDocumentFile). A Timeout egy teljes futás (letöltés és betöltés) felső
+// korlátja; a DeleteOld a váltás után törli a régi indexet.
+type Options struct {
+    Source           string
+    S3               S3Config
+    CSVHeaderMapping map[string]string
+    Timeout          time.Duration
+    DeleteOld        bool
+}
+
+// Syncer a teljes újraszinkronizálást futtatja; egyszerre legfeljebb egy futás lehet.
+type Syncer struct {
+    indexes *index.Manager
+    opts    Options
+    client  *http.Client
+
+    mu       sync.Mutex
+    job      *Job
+    schedule *Schedule
+    nextRun  time.Time
+}
+
+// New létrehozza a szinkronizálót az indexes indexkezelőhöz.
+func New(indexes *index.Manager, opts Options) *Syncer {
+    return &Syncer{indexes: indexes, opts: opts, client: &http.Client{}}
+}
+
+// Status az állapot pillanatképe.
+func (s *Syncer) Status() Status {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    var status Status
+    if s.job != nil {
+        job := *s.job
+        status.Last = &job
+    }
+    if s.schedule != nil {
+        status.Schedule = s.schedule.String()
+        if !s.nextRun.IsZero() {
+            next := s.nextRun
+            status.NextRun = &next
+        }
+    }
+    return status
+}
+
+// Start a háttérben elindít egy szinkronizálást; ha már fut egy, ErrInProgress hibát ad.
+func (s *Syncer) Start(trigger string) (*Job, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if s.job != nil && s.job.Status == StatusRunning {
+        return nil, ErrInProgress
+    }
+    s.job = &Job{Trigger: trigger, Source: redactSource(s.opts.Source), Status: StatusRunning, StartedAt: time.Now()}
+    job := *s.job
+    slog.Info("Resync started", "trigger", trigger, "source", job.Source)
+    go s.run()
+    return &job, nil
+}
+
+func (s *Syncer) update(fn func(job *Job)) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    fn(s.job)
+}
+
+// run lefuttatja a szinkronizálást, és lezárja a feladatot.
+func (s *Syncer) run() {
+    ctx, cancel := context.WithTimeout(context.Background(), s.opts.Timeout)
+    defer cancel()
+    err := s.sync(ctx)
+    s.update(func(job *Job) {
+        now := time.Now()
+        job.Status = StatusCompleted
+        job.FinishedAt = &now
+        if err != nil {
+            job.Status = StatusFailed
+            job.Error = err.Error()
+        }
+    })
+    job := s.Status().Last
+    if err != nil {
+        slog.Error("Resync failed", "trigger", job.Trigger, "source", job.Source, "error", err)
+        return
+    }
+    slog.Info("Resync finished", "trigger", job.Trigger, "target", job.Target, "indexed", job.Indexed,
+        "failed", job.Failed, "duration", job.FinishedAt.Sub(job.StartedAt).String())
+}
+
+// sync letölti az adatállományt egy ideiglenes fájlba, import módú újraindexelést indít, a
+// célindexbe tölti a rekordokat, és sikeres betöltés után átváltja az aliast. Ha a betöltés
+// hibával áll le, vagy egyetlen rekord sem került az indexbe, a célindexet eldobja, és az alias a
+// régi indexen marad.
+func (s *Syncer) sync(ctx context.Context) error {
+    u, err := url.Parse(s.opts.Source)
+    if err != nil {
+        return err
+    }
+    // A kiterjesztés megtartása dönti el a ReadDocumentFile-ban a formátumot.
+    f, err := os.CreateTemp("", "resync-*"+path.Ext(u.Path))
+    if err != nil {
+        return err
+    }
+    defer os.Remove(f.Name())
+    n, err := fetch(ctx, s.client, s.opts.Source, s.opts.S3, f)
+    if closeErr := f.Close(); err == nil {
+        err = closeErr
+    }
+    if err != nil {
+        return fmt.Errorf("letöltés: %w", err)
+    }
+    s.update(func(job *Job) { job.Bytes = n })
+
+    reindex, err := s.indexes.StartReindex(ctx, index.ReindexModeImport, s.opts.DeleteOld)
+    if err != nil {
+        return err
+    }
+    s.update(func(job *Job) { job.Target = reindex.Target })
+    indexer := s.indexes.NewBulkIndexer(ctx)
+    indexer.Index = reindex.Target
+    indexer.OnFlush = func(summary index.BulkSummary) {
+        s.update(func(job *Job) { job.Total, job.Indexed, job.Failed = summary.Total, summary.Indexed, summary.Failed })
+    }
+    err = index.ReadDocumentFile(f.Name(), s.opts.CSVHeaderMapping, indexer)
+    if err == nil {
+        err = indexer.Flush()
+    }
+    summary := indexer.Summary()
+    s.update(func(job *Job) { job.Total, job.Indexed, job.Failed = summary.Total, summary.Indexed, summary.Failed })
+    if err == nil && summary.Indexed == 0 {
+        err = errors.New("az adatállomány egyetlen érvényes rekordot sem tartalmaz")
+    }
+    if err != nil {
+        err = fmt.Errorf("betöltés: %w", err)
+        if abortErr := s.indexes.AbortImport(context.Background(), err); abortErr != nil {
+            slog.Warn("Resync target index could not be removed", "index", reindex.Target, "error", abortErr)
+        }
+        return err
+    }
+    _, err = s.indexes.ImportSwap(ctx)
+    return err
+}
+
+// Run a schedule szerint a ctx lezárásáig indítja a szinkronizálásokat. Ha az előző futás még
+// tart, az esedékes futást kihagyja.
+func (s *Syncer) Run(ctx context.Context, schedule *Schedule) {
+    s.mu.Lock()
+    s.schedule = schedule
+    s.mu.Unlock()
+    for {
+        next := schedule.Next(time.Now())
+        if next.IsZero() {
+            slog.Warn("Resync schedule has no upcoming run", "schedule", schedule.String())
+            return
+        }
+        s.mu.Lock()
+        s.nextRun = next
+        s.mu.Unlock()
+        timer := time.NewTimer(time.Until(next))
+        select {
+        case <-timer.C:
+        case <-ctx.Done():
+            timer.Stop()
+            return
+        }
+        if _, err := s.Start(TriggerSchedule); err != nil {
+            slog.Warn("Scheduled resync skipped", "error", err)
+        }
+    }
+}
+
+// redactSource a forrás URL-ből elhagyja a lekérdezési paramétereket és a felhasználói adatokat
+// (pl. előre aláírt S3 URL aláírását), hogy az állapotban és a naplóban ne jelenjenek meg.
+func redactSource(source string) string {
+    u, err := url.Parse(source)
+    if err != nil {
+        return ""
+    }
+    u.User = nil
+    if u.RawQuery != "" {
+        u.RawQuery = "..."
+    }
+    return strings.TrimSuffix(u.String(), "?")
+}
@@ -0,0 +1,381 @@
+// Package sqlite beágyazott SQLite adatbázisra épülő javaslat háttérrendszer kis, helyben
+// telepített példányokhoz, ahol egy OpenSearch fürt üzemeltetése túlzás. A címlista egy
+// addresses táblában van, a prefix keresést az addresses_fts FTS5 index szolgálja ki.
+//
+// A csomag csak a database/sql felületet használja; a DriverName nevű SQLite drivert a
+// binárisnak kell regisztrálnia (lásd cmd/autocomplete, "sqlite" build tag). A driver SQLite-jának
+// FTS5 támogatással kell fordulnia.
+package sqlite
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "log/slog"
+    "strings"
+    "sync/atomic"
+    "unicode"
+
+    "autocomplete/internal/index"
+    "autocomplete/internal/suggest"
+)
+
+// DriverName a database/sql driver neve, amellyel az adatbázist megnyitjuk.
+const DriverName = "sqlite3"
+
+// schema létrehozza a táblákat, ha még nem léteznek. Az FTS5 index külső tartalmú (az
+// addresses táblára mutat), a betöltés végén egyetlen 'rebuild' paranccsal épül fel. A
+// remove_diacritics 2 miatt a "gyor" előtag is megtalálja a "Győr"-t. A korábbi sémával
+// létrehozott adatbázisokat a migrate egészíti ki.
+const schema = `
+CREATE TABLE IF NOT EXISTS addresses (
+    id         INTEGER PRIMARY KEY,
+    telepules  TEXT NOT NULL,
+    kozter_nev TEXT NOT NULL DEFAULT '',
+    irsz       TEXT NOT NULL DEFAULT '',
+    megye      TEXT NOT NULL DEFAULT '',
+    ksh_kod    TEXT NOT NULL DEFAULT '',
+    teljes_cim TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS addresses_telepules ON addresses (telepules, kozter_nev);
+CREATE INDEX IF NOT EXISTS addresses_irsz ON addresses (irsz);
+CREATE VIRTUAL TABLE IF NOT EXISTS addresses_fts USING fts5 (
+    telepules, kozter_nev, teljes_cim,
+    content = 'addresses', content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+`
+
+// Backend az SQLite háttérrendszer. Az adatbázist csak olvassa (a betöltést kivéve), így a
+// lekérdezések párhuzamosan futhatnak.
+type Backend struct {
+    db    *sql.DB
+    limit atomic.Int64
+}
+
+var (
+    _ suggest.Suggester   = (*Backend)(nil)
+    _ suggest.ZipResolver = (*Backend)(nil)
+)
+
+// Open megnyitja (szükség esetén létrehozza) a path adatbázist, és felveszi a sémát. Ha a
+// DriverName driver nincs a binárisba fordítva, erre utaló hibát ad.
+func Open(path string, opts suggest.Options) (*Backend, error) {
+    if !driverRegistered() {
+        return nil, fmt.Errorf("a(z) %q SQLite driver nincs a binárisba fordítva (go build -tags sqlite)", DriverName)
+    }
+    db, err := sql.Open(DriverName, path)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := db.Exec(schema); err != nil {
+        db.Close()
+        return nil, fmt.Errorf("az SQLite séma létrehozása sikertelen (%s): %w", path, err)
+    }
+    if err := migrate(db); err != nil {
+        db.Close()
+        return nil, fmt.Errorf("az SQLite séma frissítése sikertelen (%s): %w", path, err)
+    }
+    b := &Backend{db: db}
+    b.SetOptions(opts)
+    return b, nil
+}
+
+// migrate felveszi a korábbi sémából hiányzó oszlopokat (ksh_kod).
+func migrate(db *sql.DB) error {
+    rows, err := db.Query("SELECT name FROM pragma_table_info('addresses')")
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+    for rows.Next() {
+        var name string
+        if err := rows.Scan(&name); err != nil {
+            return err
+        }
+        if name == "ksh_kod" {
+            return nil
+        }
+    }
+    if err := rows.Err(); err != nil {
+        return err
+    }
+    _, err = db.Exec("ALTER TABLE addresses ADD COLUMN ksh_kod TEXT NOT NULL DEFAULT ''")
+    return err
+}
+
+func driverRegistered() bool {
+    for _, name := range sql.Drivers() {
+        if name == DriverName {
+            return true
+        }
+    }
+    return false
+}
+
+// Close lezárja az adatbázist.
+func (b *Backend) Close() error {
+    return b.db.Close()
+}
+
+// SetOptions a beállítások közül a javaslatok számát (SuggestionLimit) veszi át.
+func (b *Backend) SetOptions(opts suggest.Options) {
+    b.limit.Store(int64(opts.SuggestionLimit))
+}
+
+// Empty jelzi, hogy a címlista üres-e.
+func (b *Backend) Empty(ctx context.Context) (bool, error) {
+    var one int
+    err := b.db.QueryRowContext(ctx, "SELECT 1 FROM addresses LIMIT 1").Scan(&one)
+    if errors.Is(err, sql.ErrNoRows) {
+        return true, nil
+    }
+    return false, err
+}
+
+// Import a path fájl címlistáját (lásd index.ReadDocumentFile) egyetlen tranzakcióban a
+// táblába tölti, majd újraépíti az FTS5 indexet. A hibás rekordokat kihagyja és naplózza.
+func (b *Backend) Import(ctx context.Context, path string, mapping map[string]string) error {
+    tx, err := b.db.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+    stmt, err := tx.PrepareContext(ctx, "INSERT INTO addresses (telepules, kozter_nev, irsz, megye, ksh_kod, teljes_cim) VALUES (?, ?, ?, ?, ?, ?)")
+    if err != nil {
+        return err
+    }
+    defer stmt.Close()
+
+    loader := &loader{ctx: ctx, stmt: stmt}
+    if err := index.ReadDocumentFile(path, mapping, loader); err != nil {
+        return fmt.Errorf("a címlista betöltése sikertelen (%s): %w", path, err)
+    }
+    if _, err := tx.ExecContext(ctx, "INSERT INTO addresses_fts (addresses_fts) VALUES ('rebuild')"); err != nil {
+        return fmt.Errorf("az FTS5 index felépítése sikertelen: %w", err)
+    }
+    if err := tx.Commit(); err != nil {
+        return err
+    }
+    slog.Info("SQLite backend loaded", "path", path, "records", loader.loaded, "failed", loader.failed)
+    return nil
+}
+
+// loader index.DocumentSink, amely a rekordokat az Import tranzakciójában szúrja be.
+type loader struct {
+    loaded int
+    failed int
+
+    ctx  context.Context
+    stmt *sql.Stmt
+}
+
+// Add beszúr egy rekordot; az érvénytelen (település nélküli) rekordot kihagyja.
+func (l *loader) Add(pos int, doc index.AddressDocument) error {
+    if err := doc.Validate(); err != nil {
+        l.Fail(pos, err.Error())
+        return nil
+    }
+    _, err := l.stmt.ExecContext(l.ctx,
+        strings.TrimSpace(doc.Telepules), strings.TrimSpace(doc.KozterNev),
+        strings.TrimSpace(doc.Irsz), strings.TrimSpace(doc.Megye), strings.TrimSpace(doc.KSHKod), doc.FullAddress())
+    if err != nil {
+        return err
+    }
+    l.loaded++
+    return nil
+}
+
+// Fail naplózza az értelmezhetetlen rekordot.
+func (l *loader) Fail(pos int, msg string) {
+    l.failed++
+    slog.Warn("SQLite backend record skipped", "position", pos, "error", msg)
+}
+
+// matchExpression FTS5 lekérdezést képez a column oszlopra: az első szónak az oszlop elején kell
+// állnia, minden szó előtagként illeszkedik. Üres sztringet ad, ha a lekérdezésben nincs szó.
+func matchExpression(column, query string) string {
+    words := strings.FieldsFunc(query, func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+    terms := make([]string, len(words))
+    for i, word := range words {
+        caret := ""
+        if i == 0 {
+            caret = "^ "
+        }
+        terms[i] = fmt.Sprintf(`%s : %s"%s" *`, column, caret, strings.ReplaceAll(word, `"`, `""`))
+    }
+    return strings.Join(terms, " AND ")
+}
+
+// likePrefix LIKE mintát képez, amely a prefix-szel kezdődő értékekre illeszkedik.
+func likePrefix(prefix string) string {
+    return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+// Suggest a kérés fajtája szerinti oszlopban keres az FTS5 indexben; az irányítószámokat
+// előtag szerint a táblából. A rövidebb találatok kerülnek előre, azonos hossznál betűrendben.
+// A Megye és a Telepules szűrő pontos egyezést vár, mint az OpenSearch motor keyword szűrői.
+func (b *Backend) Suggest(ctx context.Context, req suggest.Request) (suggest.Set, string, error) {
+    limit := b.limit.Load()
+    var query string
+    var args []interface{}
+    switch req.Kind {
+    case "", suggest.KindSettlement:
+        query = `SELECT DISTINCT a.telepules FROM addresses_fts JOIN addresses a ON a.id = addresses_fts.rowid
+            WHERE addresses_fts MATCH ? AND (? = '' OR a.megye = ?)
+            ORDER BY length(a.telepules), a.telepules LIMIT ?`
+        args = []interface{}{matchExpression("telepules", req.Query), req.Megye, req.Megye, limit}
+    case suggest.KindStreet:
+        query = `SELECT DISTINCT a.kozter_nev FROM addresses_fts JOIN addresses a ON a.id = addresses_fts.rowid
+            WHERE addresses_fts MATCH ? AND a.kozter_nev != '' AND (? = '' OR a.telepules = ?)
+            ORDER BY length(a.kozter_nev), a.kozter_nev LIMIT ?`
+        args = []interface{}{matchExpression("kozter_nev", req.Query), req.Telepules, req.Telepules, limit}
+    case suggest.KindAddress:
+        query = `SELECT DISTINCT a.teljes_cim FROM addresses_fts JOIN addresses a ON a.id = addresses_fts.rowid
+            WHERE addresses_fts MATCH ?
+            ORDER BY length(a.teljes_cim), a.teljes_cim LIMIT ?`
+        args = []interface{}{matchExpression("teljes_cim", req.Query), limit}
+    case suggest.KindZip:
+        query = `SELECT DISTINCT irsz FROM addresses WHERE irsz != '' AND irsz LIKE ? ESCAPE '\'
+            ORDER BY irsz LIMIT ?`
+        args = []interface{}{likePrefix(strings.TrimSpace(req.Query)), limit}
+    default:
+        return suggest.Set{}, "", fmt.Errorf("%w: %q", suggest.ErrUnknownKind, req.Kind)
+    }
+
+    suggestions := []string{}
+    // Szó nélküli lekérdezésre az FTS5 szintaktikai hibát adna; ilyenkor nincs javaslat.
+    if req.Kind == suggest.KindZip || args[0] != "" {
+        var err error
+        if suggestions, err = b.column(ctx, query, args...); err != nil {
+            return suggest.Set{}, "", err
+        }
+    }
+    suggestions = suggest.Rank(suggestions, req.Query, req.Sort)
+    debugInfo := fmt.Sprintf("SQLite keresés (%s): %v\nVisszaadott javaslatok: %v\n", req.Kind, args[0], suggestions)
+    set := suggest.Set{Suggestions: suggestions}
+    if (req.Kind == "" || req.Kind == suggest.KindSettlement) && len(suggestions) > 0 {
+        codes, err := b.kshCodes(ctx, suggestions)
+        if err != nil {
+            return suggest.Set{}, "", err
+        }
+        set.KSHCodes = codes
+    }
+    return set, debugInfo, nil
+}
+
+// kshCodes a településnevekhez a rekordjaikban megadott KSH kódot rendeli; nil, ha egyiknek sincs.
+func (b *Backend) kshCodes(ctx context.Context, settlements []string) (map[string]string, error) {
+    args := make([]interface{}, len(settlements))
+    for i, name := range settlements {
+        args[i] = name
+    }
+    placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(settlements)), ", ")
+    rows, err := b.db.QueryContext(ctx, `SELECT telepules, max(ksh_kod) FROM addresses
+        WHERE telepules IN (`+placeholders+`) AND ksh_kod != '' GROUP BY telepules`, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    var codes map[string]string
+    for rows.Next() {
+        var name, code string
+        if err := rows.Scan(&name, &code); err != nil {
+            return nil, err
+        }
+        if codes == nil {
+            codes = map[string]string{}
+        }
+        codes[name] = code
+    }
+    return codes, rows.Err()
+}
+
+// ZipSettlements az irányítószámhoz tartozó településneveket adja vissza betűrendben.
+func (b *Backend) ZipSettlements(ctx context.Context, zip string) ([]string, string, error) {
+    settlements, err := b.column(ctx, "SELECT DISTINCT telepules FROM addresses WHERE irsz = ? ORDER BY telepules LIMIT ?", zip, b.limit.Load())
+    if err != nil {
+        return nil, "", err
+    }
+    return settlements, fmt.Sprintf("SQLite irányítószám feloldás: %q\n", zip), nil
+}
+
+// column egyoszlopos lekérdezés eredményét gyűjti szeletbe.
+func (b *Backend) column(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+    rows, err := b.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    result := []string{}
+    for rows.Next() {
+        var value string
+        if err := rows.Scan(&value); err != nil {
+            return nil, err
+        }
+        result = append(result, value)
+    }
+    return result, rows.Err()
+}
+
+// Validate pontos egyezéssel ellenőrzi a címet, az OpenSearch motor Validate-jével azonos módon:
+// a megadott közterületnek és irányítószámnak ugyanahhoz a rekordhoz kell tartoznia.
+func (b *Backend) Validate(ctx context.Context, in suggest.AddressInput) (suggest.AddressValidation, error) {
+    telepules, kozterNev, irsz := strings.TrimSpace(in.Telepules), strings.TrimSpace(in.KozterNev), strings.TrimSpace(in.Irsz)
+    var result suggest.AddressValidation
+    var err error
+    if result.SettlementFound, err = b.exists(ctx, "telepules = ?", telepules); err != nil || !result.SettlementFound {
+        return result, err
+    }
+    if kozterNev != "" {
+        if result.StreetFound, err = b.exists(ctx, "telepules = ? AND kozter_nev = ?", telepules, kozterNev); err != nil {
+            return result, err
+        }
+    }
+    if irsz != "" {
+        if result.ZipMatches, err = b.exists(ctx, "telepules = ? AND irsz = ?", telepules, irsz); err != nil {
+            return result, err
+        }
+    }
+
+    var canonical index.AddressDocument
+    err = b.db.QueryRowContext(ctx,
+        `SELECT telepules, kozter_nev, irsz, megye,
+                (SELECT max(ksh_kod) FROM addresses WHERE telepules = a.telepules) FROM addresses a
+            WHERE telepules = ? AND (? = '' OR kozter_nev = ?) AND (? = '' OR irsz = ?) LIMIT 1`,
+        telepules, kozterNev, kozterNev, irsz, irsz).Scan(&canonical.Telepules, &canonical.KozterNev, &canonical.Irsz, &canonical.Megye, &canonical.KSHKod)
+    if errors.Is(err, sql.ErrNoRows) {
+        return result, nil
+    }
+    if err != nil {
+        return result, err
+    }
+    if kozterNev == "" && irsz == "" {
+        canonical.KozterNev, canonical.Irsz = "", ""
+    }
+    result.Valid = true
+    result.Canonical = &canonical
+    return result, nil
+}
+
+// exists jelzi, hogy van-e a where feltételnek megfelelő rekord.
+func (b *Backend) exists(ctx context.Context, where string, args ...interface{}) (bool, error) {
+    var found bool
+    err := b.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM addresses WHERE "+where+")", args...).Scan(&found)
+    return found, err
+}
+
+// Health hibát ad, ha az adatbázis nem érhető el vagy a címlista üres.
+func (b *Backend) Health(ctx context.Context) error {
+    empty, err := b.Empty(ctx)
+    if err != nil {
+        return err
+    }
+    if empty {
+        return errors.New("az SQLite címlista üres")
+    }
+    return nil
+}
@@ -0,0 +1,88 @@
+package suggest
+
+import (
+    "strings"
+
+    "autocomplete/internal/dsl"
+)
+
+// aliasField a települések korábbi és alternatív neveit tartalmazó mező; a "telepules" mezőre
+// futó javaslatkérések erre is illeszkednek.
+const aliasField = "aliases"
+
+// aliasLimit a vödrönként visszakért alternatív nevek legnagyobb száma.
+const aliasLimit = 10
+
+// textMatch a field mezőre illeszkedő match feltétel; a "telepules" mezőnél az aliases mezőre
+// illeszkedő dokumentumokat is elfogadja.
+func textMatch(field string, m dsl.MatchQuery) dsl.Query {
+    if field != "telepules" {
+        return dsl.Match(field, m)
+    }
+    return dsl.Bool(dsl.BoolQuery{Should: []dsl.Query{dsl.Match(field, m), dsl.Match(aliasField, m)}})
+}
+
+// infixMatch a tokenClauses wildcard feltétele; a "telepules" mezőnél az alternatív neveken is.
+func infixMatch(field, token string) []dsl.Query {
+    clauses := []dsl.Query{dsl.WildcardCaseInsensitive(field+".keyword", "*"+token+"*")}
+    if field == "telepules" {
+        clauses = append(clauses, dsl.WildcardCaseInsensitive(aliasField+".keyword", "*"+token+"*"))
+    }
+    return clauses
+}
+
+// withAliases a "telepules" mező terms aggregációjához al-aggregációként hozzáadja a vödör
+// dokumentumainak alternatív neveit, amelyekből a matchedAliases kiválasztja az illeszkedőt.
+func withAliases(unique dsl.Agg) dsl.Agg {
+    sub, _ := unique["aggs"].(map[string]dsl.Agg)
+    if sub == nil {
+        sub = map[string]dsl.Agg{}
+    }
+    sub[aliasField] = dsl.Terms(dsl.TermsAgg{Field: aliasField + ".keyword", Size: aliasLimit})
+    unique["aggs"] = sub
+    return unique
+}
+
+// matchedAliases azokhoz a vödrökhöz, amelyek neve nem illeszkedik a lekérdezésre, az első
+// illeszkedő alternatív nevet rendeli; nil, ha nincs ilyen vödör.
+func matchedAliases(values dsl.AggResult, query string) map[string]string {
+    var matched map[string]string
+    for _, bucket := range values.Buckets {
+        aliases := bucket.Sub[aliasField].Buckets
+        if len(aliases) == 0 || nameMatches(bucket.Key, query) {
+            continue
+        }
+        for _, alias := range aliases {
+            if nameMatches(alias.Key, query) {
+                if matched == nil {
+                    matched = map[string]string{}
+                }
+                matched[bucket.Key] = alias.Key
+                break
+            }
+        }
+    }
+    return matched
+}
+
+// nameMatches a lekérdezés illesztését közelíti az OpenSearch nélkül, ékezet- és kis-nagybetű
+// függetlenül: egy szónál a név valamely szava a lekérdezéssel kezdődik, több szónál minden
+// szó előfordul a névben (lásd tokenClauses).
+func nameMatches(name, query string) bool {
+    tokens := queryTokens(fold(query))
+    folded := fold(name)
+    if len(tokens) == 1 {
+        for _, word := range queryTokens(folded) {
+            if strings.HasPrefix(word, tokens[0]) {
+                return true
+            }
+        }
+        return false
+    }
+    for _, token := range tokens {
+        if !strings.Contains(folded, token) {
+            return false
+        }
+    }
+    return len(tokens) > 0
+}
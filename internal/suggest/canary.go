@@ -0,0 +1,115 @@
+package suggest
+
+import (
+    "context"
+    "fmt"
+    "math/rand"
+    "sync"
+    "time"
+
+    "autocomplete/internal/reqlog"
+)
+
+type routeKey struct{}
+
+// routeCanary a javaslatkérést Options.CanaryPercent százalék valószínűséggel az
+// Options.CanaryIndex indexre, egyébként a stabil indexre (aliasra) irányítja, és a választott
+// index nevét a kontextushoz rendeli, így a kérés minden lekérdezése ugyanabból az indexből
+// szolgálódik ki. A már irányított kontextust nem módosítja.
+func (e *Engine) routeCanary(ctx context.Context) context.Context {
+    if _, ok := ctx.Value(routeKey{}).(string); ok {
+        return ctx
+    }
+    opts := e.Options()
+    name := e.index
+    if opts.CanaryIndex != "" && rand.Float64()*100 < opts.CanaryPercent {
+        name = opts.CanaryIndex
+        reqlog.Add(ctx, "index", name)
+    }
+    return context.WithValue(ctx, routeKey{}, name)
+}
+
+// searchIndex a kérést kiszolgáló index neve (lásd routeCanary); irányítás nélkül a stabil index.
+func (e *Engine) searchIndex(ctx context.Context) string {
+    if name, ok := ctx.Value(routeKey{}).(string); ok {
+        return name
+    }
+    return e.index
+}
+
+// routeDebug a kanári indexre irányított kérés debug sora; a stabil indexnél üres.
+func (e *Engine) routeDebug(ctx context.Context) string {
+    if name := e.searchIndex(ctx); name != e.index {
+        return fmt.Sprintf("Kanári index: %s\n", name)
+    }
+    return ""
+}
+
+// routeCounters egy index javaslatkéréseinek számlálói.
+type routeCounters struct {
+    requests int64
+    zero     int64
+    errors   int64
+    latency  time.Duration
+}
+
+// routes az indexenkénti számlálók; konkurens használatra biztonságos.
+type routes struct {
+    mu      sync.Mutex
+    indexes map[string]*routeCounters
+}
+
+// recordRoute a kérés eredményét a kiszolgáló indexének számlálóiba rögzíti.
+func (e *Engine) recordRoute(ctx context.Context, set Set, err error, took time.Duration) {
+    name := e.searchIndex(ctx)
+    e.routes.mu.Lock()
+    defer e.routes.mu.Unlock()
+    if e.routes.indexes == nil {
+        e.routes.indexes = map[string]*routeCounters{}
+    }
+    c, ok := e.routes.indexes[name]
+    if !ok {
+        c = &routeCounters{}
+        e.routes.indexes[name] = c
+    }
+    c.requests++
+    c.latency += took
+    switch {
+    case err != nil:
+        c.errors++
+    case len(set.Suggestions) == 0:
+        c.zero++
+    }
+}
+
+// RouteStats egy index javaslatkéréseinek összesítése: a kérések, a hibás és a találat nélküli
+// kérések száma, a találat nélküli (a sikeres kérések közül) és a hibás kérések aránya, valamint
+// az átlagos késleltetés (a gyorsítótárból kiszolgált kérésekkel együtt).
+type RouteStats struct {
+    Requests       int64   `json:"requests"`
+    Errors         int64   `json:"errors"`
+    ZeroResults    int64   `json:"zeroResults"`
+    ErrorRate      float64 `json:"errorRate"`
+    ZeroResultRate float64 `json:"zeroResultRate"`
+    AvgLatencyMs   float64 `json:"avgLatencyMs"`
+}
+
+// RouteStats az indexenkénti számlálók pillanatképe (expvar "index_routing"); kanári index
+// (CANARY_INDEX) mellett ebből hasonlítható össze az új indexverzió a stabil indexszel.
+func (e *Engine) RouteStats() map[string]RouteStats {
+    e.routes.mu.Lock()
+    defer e.routes.mu.Unlock()
+    stats := make(map[string]RouteStats, len(e.routes.indexes))
+    for name, c := range e.routes.indexes {
+        s := RouteStats{Requests: c.requests, Errors: c.errors, ZeroResults: c.zero}
+        if c.requests > 0 {
+            s.ErrorRate = float64(c.errors) / float64(c.requests)
+            s.AvgLatencyMs = float64(c.latency.Microseconds()) / 1000 / float64(c.requests)
+        }
+        if ok := c.requests - c.errors; ok > 0 {
+            s.ZeroResultRate = float64(c.zero) / float64(ok)
+        }
+        stats[name] = s
+    }
+    return stats
+}
@@ -0,0 +1,127 @@
+package suggest
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strings"
+
+    "autocomplete/internal/dsl"
+    "autocomplete/internal/index"
+)
+
+// completionContextFields a terms szűrőmezőihez tartozó completion kontextusok nevei
+// (lásd index.CompletionInputs).
+var completionContextFields = map[string]string{
+    "megye":             "megye",
+    "telepules.keyword": "telepules",
+}
+
+// completionContexts a Settlements és Streets term szűrőit completion kontextusokká alakítja
+// (index.ContextValue alakban, ahogy az indexelésnél). Így a Streets településszűrője a
+// "suggest.kozter_nev" telepules kontextusa lesz, külön bool filter lekérdezés nélkül.
+func completionContexts(filters []dsl.Query) map[string][]string {
+    contexts := map[string][]string{}
+    for _, filter := range filters {
+        term, _ := filter["term"].(map[string]interface{})
+        for field, value := range term {
+            name, ok := completionContextFields[field]
+            if !ok {
+                continue
+            }
+            contexts[name] = append(contexts[name], index.ContextValue(fmt.Sprint(value)))
+        }
+    }
+    return contexts
+}
+
+// queryCompletion a QueryModeCompletion lekérdezése: completion suggestert futtat a
+// "suggest.<field>" mezőn a szűrőkből képzett kontextusokkal. A javaslat a dokumentum field
+// mezőjének értéke; ha a bemenet ettől eltér (a település egy alternatív neve illeszkedett),
+// azt a Matched-be teszi; a település KSH kódja a KSHCodes-ba, a dokumentum helyadata a
+// Locations-be kerül.
+func (e *Engine) queryCompletion(ctx context.Context, opts Options, field, query string, filters []dsl.Query, debugBuffer *bytes.Buffer) (Set, error) {
+    completion := map[string]interface{}{
+        "field":           "suggest." + field,
+        "size":            opts.SuggestionLimit,
+        "skip_duplicates": true,
+    }
+    if contexts := completionContexts(filters); len(contexts) > 0 {
+        completion["contexts"] = contexts
+    }
+    source := []string{field, "location"}
+    if field == "telepules" {
+        source = append(source, kshField)
+    }
+    payload := dsl.Search{
+        Size:    0,
+        Source:  source,
+        Suggest: map[string]interface{}{"unique_values": map[string]interface{}{"prefix": query, "completion": completion}},
+    }
+    payloadBytes, err := json.Marshal(payload)
+    if err != nil {
+        return Set{}, err
+    }
+    debugBuffer.WriteString("Completion Payload JSON: " + string(payloadBytes) + "\n")
+
+    resp, err := e.search(ctx, payloadBytes)
+    if err != nil {
+        debugBuffer.WriteString(fmt.Sprintf("Hiba az OpenSearch lekérdezés végrehajtásakor: %v\n", err))
+        return Set{}, err
+    }
+    debugBuffer.WriteString(fmt.Sprintf("OpenSearch válasz státusza: %d\n", resp.StatusCode))
+    debugBuffer.WriteString("Válasz body: " + string(resp.Body) + "\n")
+    if resp.StatusCode != http.StatusOK {
+        return Set{}, fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+    }
+
+    var result struct {
+        Suggest map[string][]struct {
+            Options []struct {
+                Text   string                 `json:"text"`
+                Source map[string]interface{} `json:"_source"`
+            } `json:"options"`
+        } `json:"suggest"`
+    }
+    if err := json.Unmarshal(resp.Body, &result); err != nil {
+        debugBuffer.WriteString(fmt.Sprintf("Hiba a válasz JSON dekódolásakor: %v\n", err))
+        return Set{}, err
+    }
+    set := Set{Suggestions: []string{}}
+    seen := map[string]bool{}
+    for _, entry := range result.Suggest["unique_values"] {
+        for _, option := range entry.Options {
+            value, _ := option.Source[field].(string)
+            if value == "" {
+                value = option.Text
+            }
+            if seen[value] {
+                continue
+            }
+            seen[value] = true
+            set.Suggestions = append(set.Suggestions, value)
+            if code, _ := option.Source[kshField].(string); code != "" {
+                if set.KSHCodes == nil {
+                    set.KSHCodes = map[string]string{}
+                }
+                set.KSHCodes[value] = code
+            }
+            if location, ok := sourceLocation(option.Source); ok {
+                if set.Locations == nil {
+                    set.Locations = map[string]index.GeoPoint{}
+                }
+                set.Locations[value] = location
+            }
+            if field == "telepules" && !strings.EqualFold(option.Text, value) {
+                if set.Matched == nil {
+                    set.Matched = map[string]string{}
+                }
+                set.Matched[value] = option.Text
+            }
+        }
+    }
+    debugBuffer.WriteString(fmt.Sprintf("Visszaadott javaslatok: %v\n", set.Suggestions))
+    return set, nil
+}
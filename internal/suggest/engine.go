@@ -0,0 +1,597 @@
+// Package suggest a javaslatmotor: település-, közterület-, teljes cím- és irányítószám-javaslatok,
+// helyesírási javaslatok és címellenőrzés az OpenSearch index fölött, gyorsítótárral és
+// elgépelés-tűrő tartalékkal. A HTTP rétegtől független, így más szolgáltatások közvetlenül
+// beágyazhatják.
+package suggest
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "time"
+    "unicode"
+
+    "autocomplete/internal/cache"
+    "autocomplete/internal/dsl"
+    "autocomplete/internal/index"
+    "autocomplete/internal/opensearch"
+    "autocomplete/internal/reqlog"
+)
+
+// A javaslatkérés lehetséges módjai (QUERY_MODE környezeti változó).
+const (
+    // QueryModeNgram az edge_ngram analyzerrel indexelt mezőn futtat match lekérdezést.
+    QueryModeNgram = "ngram"
+    // QueryModeRegex a régi, regexp-szűrt terms aggregáció; összehasonlításhoz megtartva.
+    QueryModeRegex = "regex"
+    // QueryModeCompletion az OpenSearch completion suggesterét használja a "suggest.<mező>"
+    // completion mezőkön; nagy kulcskészletnél lényegesen gyorsabb az aggregációknál, de a
+    // népszerűség és a közelség szerinti rangsorolást nem támogatja (lásd queryCompletion).
+    QueryModeCompletion = "completion"
+    // QueryModeSearchAsYouType a "telepules" és "kozter_nev" mezők search_as_you_type almezőin
+    // bool_prefix multi_match lekérdezést futtat (lásd searchAsYouTypeMatch); a szóhatárokon
+    // átnyúló prefixekre is illeszkedik. Az index.FieldStrategySearchAsYouType stratégiát igényli.
+    QueryModeSearchAsYouType = "search_as_you_type"
+)
+
+// Options a motor futás közben módosítható beállításai (lásd Engine.SetOptions).
+type Options struct {
+    QueryMode       string
+    SuggestionLimit int
+    FuzzyFallback   bool
+    // KeyboardTypoMinResults (ha > 0) esetén az ennél kevesebb javaslatot adó lekérdezést a
+    // billentyűzeten szomszédos betűkkel helyettesített utolsó karakterű változatokkal is
+    // lefuttatjuk, és a találataikat a pontos javaslatok után fűzzük (lásd keyboardVariants).
+    KeyboardTypoMinResults int
+    // FoldAccents a lekérdezést ékezetek nélkül futtatja (lásd NormalizeQuery); az index
+    // analyzere az ékezet nélküli alakot is indexeli, így a "gyor" a "Győr"-re is illeszkedik.
+    FoldAccents bool
+    // StaleWhileRevalidate bekapcsolásakor a lejárt cache bejegyzésre legfeljebb StaleTimeout
+    // ideig várunk friss eredményt, utána az elavultat adjuk vissza, és a háttérben frissítünk.
+    StaleWhileRevalidate bool
+    StaleTimeout         time.Duration
+    // MaterializedServe bekapcsolásakor a szűrő nélküli, legfeljebb MaterializedPrefixLength
+    // karakteres lekérdezések az előre kiszámolt lookup indexből jönnek (lásd StartMaterialize).
+    MaterializedServe        bool
+    MaterializedPrefixLength int
+    // PopularityRanking bekapcsolásakor a javaslatok elsősorban a kiválasztások száma
+    // (lásd RecordSelection), másodsorban a dokumentumszám szerint rendeződnek.
+    PopularityRanking bool
+    // GeoScale a közelség szerinti rangsorolás gauss lecsengésének távolsága (pl. "25km"):
+    // ennyire a ponttól a közelségi pontszám a felére csökken.
+    GeoScale string
+    // WeightBoost bekapcsolásakor (> 0) a nagyobb súlyú (lakosságú vagy címszámú) javaslatok
+    // előrébb kerülnek: a jelöltek a súlyuk szerint rendeződnek, a relevancia szerinti rendezés
+    // pedig a súly tízes alapú logaritmusának WeightBoost-szorosát levonja a hosszukból (lásd
+    // RankWeighted).
+    WeightBoost float64
+    // Ranking a relevancia szerinti rendezést felváltó rangsorolási folyamat (RANKING_PIPELINE);
+    // üresen a beépített RankWeighted rendez.
+    Ranking Pipeline
+    // CanaryIndex (ha meg van adva) egy új indexverzió, amely a javaslatkérések CanaryPercent
+    // százalékát kapja a stabil index helyett (lásd routeCanary, RouteStats).
+    CanaryIndex   string
+    CanaryPercent float64
+}
+
+// Set egy javaslatkérés eredménye, ahogyan a gyorsítótárban is tároljuk. A Fuzzy jelzi,
+// hogy a javaslatok az elgépelés-tűrő tartalék lekérdezésből (vagy pontos találat hiányában a
+// billentyűszomszéd-változatokból) származnak, a Stale pedig azt,
+// hogy az OpenSearch elérhetetlensége miatt lejárt cache bejegyzést adunk vissza. A Matched
+// azokhoz a javaslatokhoz, amelyek nem a saját nevükkel, hanem egy korábbi vagy alternatív
+// nevükkel (lásd index.AddressDocument.Aliases) illeszkedtek, ezt az illeszkedő nevet adja.
+// A KSHCodes településjavaslatoknál a településnévhez a KSH kódját rendeli, ha az indexben meg
+// van adva. A Locations a javaslatokhoz a helyadatukat rendeli (a rekordjaik koordinátáinak
+// súlypontját); a helyadat nélküli javaslatok hiányoznak belőle. A Weights a javaslatokhoz a
+// rekordjaik legnagyobb súlyát (lásd index.AddressDocument.Weight), a Popularity a kiválasztásaik
+// számát (PopularityRanking mellett) rendeli, ha van. A Sources a többmezős
+// kérésnél (lásd FieldSuggester) a javaslatokhoz annak a mezőnek a nevét rendeli, amelyből
+// származnak; egymezős kérésnél nil.
+type Set struct {
+    Suggestions []string
+    Fuzzy       bool
+    Stale       bool
+    Matched     map[string]string
+    KSHCodes    map[string]string
+    Locations   map[string]index.GeoPoint
+    Weights     map[string]int
+    Popularity  map[string]int
+    Sources     map[string]string
+}
+
+// Item egy javaslat a hozzá tartozó azonosítókkal és helyadattal (a részletes és a GeoJSON
+// válaszokhoz). A Weight a javaslat súlya (lakosság vagy címszám), a Field többmezős kérésnél
+// a javaslat forrásmezőjének neve.
+type Item struct {
+    Value    string          `json:"value"`
+    KSHKod   string          `json:"kshKod,omitempty"`
+    Location *index.GeoPoint `json:"location,omitempty"`
+    Weight   int             `json:"weight,omitempty"`
+    Field    string          `json:"field,omitempty"`
+}
+
+// Items a javaslatokat sorrendben Item-ként adja vissza.
+func (s Set) Items() []Item {
+    items := make([]Item, 0, len(s.Suggestions))
+    for _, suggestion := range s.Suggestions {
+        item := Item{Value: suggestion, KSHKod: s.KSHCodes[suggestion], Weight: s.Weights[suggestion], Field: s.Sources[suggestion]}
+        if p, ok := s.Locations[suggestion]; ok {
+            item.Location = &p
+        }
+        items = append(items, item)
+    }
+    return items
+}
+
+// Engine a javaslatmotor egy indexhez. A lekérdező metódusok a lépéseket naplózó debug
+// szöveget is visszaadják, és a reqlog-on keresztül gazdagítják a kérés naplósorát.
+type Engine struct {
+    client  *opensearch.Client
+    index   string
+    cache   *cache.LRU[Set]
+    options atomic.Pointer[Options]
+
+    // StaleRefreshTimeout a háttérbeli (stale-while-revalidate) frissítés időkorlátja.
+    StaleRefreshTimeout time.Duration
+
+    // revalidating a folyamatban lévő háttérfrissítések cache kulcsai, hogy egy kulcsra egyszerre
+    // csak egy frissítő lekérdezés fusson.
+    revalidatingMu sync.Mutex
+    revalidating   map[string]bool
+
+    materializeMu  sync.Mutex
+    materializeJob *MaterializeJob
+
+    selections selections
+    routes     routes
+}
+
+// New a client-en keresztül az index nevű indexben kereső motort adja vissza; a javaslatokat
+// a c gyorsítótárban tárolja.
+func New(client *opensearch.Client, index string, c *cache.LRU[Set], opts Options) *Engine {
+    e := &Engine{
+        client:              client,
+        index:               index,
+        cache:               c,
+        StaleRefreshTimeout: 10 * time.Second,
+        revalidating:        map[string]bool{},
+    }
+    e.SetOptions(opts)
+    return e
+}
+
+// Options az érvényes beállítások pillanatképe.
+func (e *Engine) Options() Options {
+    return *e.options.Load()
+}
+
+// SetOptions egyetlen atomi cserével lecseréli a beállításokat; a folyamatban lévő
+// lekérdezések a korábbi pillanatképpel fejeződnek be.
+func (e *Engine) SetOptions(opts Options) {
+    e.options.Store(&opts)
+}
+
+// FlushCache kiüríti a javaslat-gyorsítótárat, és az eldobott bejegyzések számát adja vissza.
+func (e *Engine) FlushCache() int {
+    return e.cache.Flush()
+}
+
+// caseInsensitiveRegex generál egy reguláris kifejezést, amely az adott string minden karakterére
+// létrehoz egy karakterosztályt, így például "sze" → "[sS][zZ][eE].*". Az ékezetes betűket is
+// kezeli ("ős" → "[őŐ][sS].*"), mivel a lekérdezés a gyorsítótárazás miatt már kisbetűsítve érkezik.
+func caseInsensitiveRegex(query string) string {
+    var sb strings.Builder
+    for _, ch := range query {
+        if unicode.IsLetter(ch) && unicode.ToLower(ch) != unicode.ToUpper(ch) {
+            lower := strings.ToLower(string(ch))
+            upper := strings.ToUpper(string(ch))
+            sb.WriteString("[" + lower + upper + "]")
+        } else {
+            sb.WriteRune(ch)
+        }
+    }
+    sb.WriteString(".*")
+    return sb.String()
+}
+
+// Settlements a "telepules" mezőn keres településneveket a QueryMode szerinti lekérdezéssel,
+// és azokat az egyedi városneveket adja vissza, amelyek a felhasználó által beírt prefix-szel
+// kezdődnek. Ha a megye nem üres, csak az adott megye településeit adja vissza; ha a near
+// meg van adva, a ponthoz közeli települések kerülnek előre.
+func (e *Engine) Settlements(ctx context.Context, query, megye string, near *index.GeoPoint) (Set, string, error) {
+    var filters []dsl.Query
+    if megye != "" {
+        filters = append(filters, dsl.Term("megye", megye))
+    }
+    return e.terms(ctx, "telepules", query, filters, near)
+}
+
+// Streets a "kozter_nev" mezőn keres közterületneveket.
+// Ha a telepules nem üres, csak az adott településhez tartozó közterületeket adja vissza;
+// QueryModeCompletion esetén a település a completion mező kontextusaként szűkít.
+func (e *Engine) Streets(ctx context.Context, query, telepules string, near *index.GeoPoint) (Set, string, error) {
+    var filters []dsl.Query
+    if telepules != "" {
+        filters = append(filters, dsl.Term("telepules.keyword", telepules))
+    }
+    return e.terms(ctx, "kozter_nev", query, filters, near)
+}
+
+// Addresses a "teljes_cim" mezőn keres "Település, Közterület" alakú teljes címeket,
+// így egyetlen beviteli mezőből a település és a közterület is kiválasztható.
+func (e *Engine) Addresses(ctx context.Context, query string, near *index.GeoPoint) (Set, string, error) {
+    return e.terms(ctx, "teljes_cim", query, nil, near)
+}
+
+// buildAutocompleteQuery összeállítja a javaslatkérés payloadját a field szöveges mezőre.
+// QueryModeNgram esetén match lekérdezést futtat az edge_ngram-mel indexelt mezőn, és a
+// "<field>.keyword" almezőn végzett terms aggregációval deduplikálja a találatokat;
+// QueryModeRegex esetén a régi, caseInsensitiveRegex-szel szűrt terms aggregációt használja.
+// Több szavas lekérdezésnél ngram módban minden szó külön feltétel (lásd tokenClauses), így a
+// szavak sorrendje nem számít. PopularityRanking esetén a vödrök a népszerűség szerint
+// rendeződnek (lásd withPopularity), near megadásakor pedig elsősorban a ponthoz való
+// közelség szerint (lásd withProximity). A településnevek (a regex mód kivételével) a korábbi
+// és alternatív nevekre is illeszkednek (lásd textMatch, withAliases), és a KSH kódjukat is
+// visszakérjük (lásd withKSHCodes); minden javaslat a helyadatai súlypontjával és a súlyával
+// együtt jön (lásd withLocations, withWeights), WeightBoost esetén a jelöltek a súly szerint
+// rendeződnek. QueryModeSearchAsYouType
+// esetén a "telepules" és "kozter_nev" mező a search_as_you_type almezőn illeszkedik.
+func buildAutocompleteQuery(opts Options, field, query string, filters []dsl.Query, near *index.GeoPoint, debugBuffer *bytes.Buffer) dsl.Search {
+    keywordField := field + ".keyword"
+    terms := dsl.TermsAgg{Field: keywordField, Size: opts.SuggestionLimit}
+    search := dsl.Search{Size: 0, Aggs: map[string]dsl.Agg{}}
+    finish := func() dsl.Search {
+        var unique dsl.Agg
+        if opts.PopularityRanking {
+            unique = withPopularity(terms, field)
+        } else {
+            unique = dsl.Terms(terms)
+        }
+        unique = withWeights(unique, opts.WeightBoost > 0)
+        if near != nil {
+            debugBuffer.WriteString(fmt.Sprintf("Közelség szerinti rangsorolás: %g, %g (scale: %s)\n", near.Lat, near.Lon, opts.GeoScale))
+            search.Query, unique = withProximity(search.Query, unique, *near, opts.GeoScale)
+        }
+        if field == "telepules" && opts.QueryMode != QueryModeRegex {
+            unique = withAliases(unique)
+        }
+        if field == "telepules" {
+            unique = withKSHCodes(unique)
+        }
+        search.Aggs["unique_values"] = withLocations(unique)
+        return search
+    }
+
+    if opts.QueryMode == QueryModeRegex {
+        regexPattern := caseInsensitiveRegex(query)
+        debugBuffer.WriteString(fmt.Sprintf("Generált regexp: %q\n", regexPattern))
+        terms.Include = regexPattern
+        if len(filters) > 0 {
+            search.Query = dsl.Filter(filters...)
+        }
+        return finish()
+    }
+
+    must := []dsl.Query{textMatch(field, dsl.MatchQuery{Query: query, Operator: "and"})}
+    if opts.QueryMode == QueryModeSearchAsYouType && field != "teljes_cim" {
+        must = []dsl.Query{searchAsYouTypeMatch(field, query)}
+    } else if tokens := queryTokens(query); len(tokens) > 1 {
+        debugBuffer.WriteString(fmt.Sprintf("Szavankénti illesztés: %q\n", tokens))
+        must = tokenClauses(field, tokens)
+    }
+    search.Query = dsl.Bool(dsl.BoolQuery{Must: must, Filter: filters})
+    search.Aggs["unique_count"] = dsl.Cardinality(keywordField)
+    return finish()
+}
+
+// searchAsYouTypeMatch a QueryModeSearchAsYouType feltétele: bool_prefix multi_match a mező
+// search_as_you_type almezőjén és annak shingle almezőin, így a "szent ist" a "Szent István
+// tér"-re is illeszkedik. A "telepules" mezőnél az alternatív nevek is illeszkedhetnek.
+func searchAsYouTypeMatch(field, query string) dsl.Query {
+    sayt := field + "." + index.SearchAsYouTypeField
+    match := dsl.MultiMatch(dsl.MultiMatchQuery{
+        Query:    query,
+        Type:     "bool_prefix",
+        Fields:   []string{sayt, sayt + "._2gram", sayt + "._3gram"},
+        Operator: "and",
+    })
+    if field != "telepules" {
+        return match
+    }
+    return dsl.Bool(dsl.BoolQuery{Should: []dsl.Query{match, dsl.Match(aliasField, dsl.MatchQuery{Query: query, Operator: "and"})}})
+}
+
+// queryTokens a lekérdezés szavai: a betűkből és számjegyekből álló szakaszok, így az írásjelek
+// ("Budapest, Fő") és a wildcard metakarakterek nem kerülnek a feltételekbe.
+func queryTokens(query string) []string {
+    return strings.FieldsFunc(query, func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+}
+
+// tokenClauses szavanként egy feltételt ad: a szó a mező egy szavának eleje (edge_ngram match),
+// vagy az érték bármely részén előfordul (kis-nagybetű független wildcard a keyword almezőn).
+// Így a "kiskun félegy" és a "félegyháza kiskun" is megtalálja a "Kiskunfélegyháza"-t.
+func tokenClauses(field string, tokens []string) []dsl.Query {
+    clauses := make([]dsl.Query, 0, len(tokens))
+    for _, token := range tokens {
+        should := append([]dsl.Query{textMatch(field, dsl.MatchQuery{Query: token})}, infixMatch(field, token)...)
+        clauses = append(clauses, dsl.Bool(dsl.BoolQuery{Should: should}))
+    }
+    return clauses
+}
+
+// terms a megadott szöveges mezőn futtat javaslatkérést, és a "<field>.keyword" almező egyedi
+// értékeit adja vissza. A filters feltételei (ha vannak) bool filter-ként szűkítik az aggregált
+// dokumentumok körét. Ha nincs találat és a FuzzyFallback be van kapcsolva, egy elgépelés-tűrő
+// lekérdezés eredményét adja vissza Fuzzy jelöléssel. A lekérdezést előbb normalizálja (lásd
+// NormalizeQuery; FoldAccents esetén ékezetek nélkül), és a normalizált alakot a debug kimenet
+// elejére írja. A normalizált lekérdezésre kapott javaslatokat a gyorsítótárban tároljuk, így a
+// gyakori rövid prefixek nem terhelik az OpenSearch-öt. Ha a near meg van adva, a hozzá közeli
+// települések kerülnek előre (lásd withProximity).
+func (e *Engine) terms(ctx context.Context, field, query string, filters []dsl.Query, near *index.GeoPoint) (Set, string, error) {
+    opts := e.requestOptions(ctx)
+    normalized := NormalizeQuery(query, opts.FoldAccents)
+    recordMeta(ctx, func(m *Meta) { m.NormalizedQuery = normalized })
+    set, debugInfo, err := e.cachedTerms(ctx, opts, field, normalized, filters, near)
+    return set, fmt.Sprintf("Normalizált lekérdezés: %q -> %q\n", query, normalized) + debugInfo, err
+}
+
+// ResultKey a beállítások azon részének kulcsa, amelytől egy lekérdezés eredménye (a
+// gyorsítótárazott Set) függ; a gyorsítótár kulcsának része. A rangsorolás (Ranking) a
+// gyorsítótárazott eredményre fut, ezért nem része.
+func (o Options) ResultKey() string {
+    return fmt.Sprintf("%s|%d|%t|%d|%t|%t|%t|%s", o.QueryMode, o.SuggestionLimit, o.FuzzyFallback, o.KeyboardTypoMinResults,
+        o.FoldAccents, o.PopularityRanking, o.WeightBoost > 0, o.GeoScale)
+}
+
+// cachedTerms a terms a már normalizált lekérdezéssel: gyorsítótár, előre kiszámolt javaslatok,
+// majd az OpenSearch lekérdezés.
+func (e *Engine) cachedTerms(ctx context.Context, opts Options, field, query string, filters []dsl.Query, near *index.GeoPoint) (Set, string, error) {
+    filterKey, _ := json.Marshal(filters)
+    nearKey := ""
+    if near != nil {
+        nearKey = fmt.Sprintf("%g,%g", near.Lat, near.Lon)
+    }
+    cacheKey := fmt.Sprintf("%s|%s|%s|%s|%s|%s", e.searchIndex(ctx), opts.ResultKey(), field, filterKey, nearKey, query)
+    if set, ok := e.cache.Get(cacheKey); ok {
+        recordCache(ctx, "hit")
+        return set, fmt.Sprintf("Cache találat: %q, mező: %s\nVisszaadott javaslatok: %v\n", query, field, set.Suggestions), nil
+    }
+    if opts.StaleWhileRevalidate {
+        if stale, ok := e.cache.GetStale(cacheKey); ok {
+            return e.revalidate(ctx, opts, cacheKey, stale, field, query, filters, near)
+        }
+    }
+    recordCache(ctx, "miss")
+    if set, ok := e.lookupMaterialized(ctx, opts, field, query, filters, near); ok {
+        e.cache.Set(cacheKey, set)
+        return set, fmt.Sprintf("Előre kiszámolt találat: %q, mező: %s\nVisszaadott javaslatok: %v\n", query, field, set.Suggestions), nil
+    }
+    set, debugInfo, err := e.fetch(ctx, opts, field, query, filters, near)
+    if err != nil {
+        // Nyitott circuit breaker vagy túlterhelés mellett inkább a korábbi (akár lejárt) cache
+        // bejegyzést adjuk vissza.
+        if errors.Is(err, opensearch.ErrCircuitOpen) || errors.Is(err, opensearch.ErrOverloaded) {
+            if set, ok := e.cache.GetStale(cacheKey); ok {
+                recordCache(ctx, "stale")
+                set.Stale = true
+                return set, debugInfo + "OpenSearch nem elérhető, elavult cache találat visszaadva\n", nil
+            }
+        }
+        return Set{}, debugInfo, err
+    }
+    e.cache.Set(cacheKey, set)
+    return set, debugInfo, nil
+}
+
+// fetch gyorsítótár nélkül kéri le a javaslatokat: kevés találatnál a billentyűszomszéd-
+// változatokkal kiegészítve (KeyboardTypoMinResults), üres eredménynél a fuzzy tartalékkal.
+func (e *Engine) fetch(ctx context.Context, opts Options, field, query string, filters []dsl.Query, near *index.GeoPoint) (Set, string, error) {
+    set, debugInfo, err := e.queryTerms(ctx, opts, field, query, filters, near)
+    if err != nil {
+        return Set{}, debugInfo, err
+    }
+    if opts.KeyboardTypoMinResults > 0 && len(set.Suggestions) < opts.KeyboardTypoMinResults {
+        if variants := keyboardVariants(query, opts.FoldAccents); len(variants) > 0 {
+            values, keyboardDebug, err := e.queryKeyboard(ctx, opts, field, variants, filters)
+            debugInfo += keyboardDebug
+            if err != nil {
+                // A kibontás hibája a fuzzy tartalékhoz hasonlóan nem teszi sikertelenné a kérést.
+                slog.Warn("Keyboard typo expansion error", "field", field, "query", query, "error", err)
+            } else {
+                exact := len(set.Suggestions)
+                set = mergeKeyboard(set, values, opts.SuggestionLimit)
+                reqlog.Add(ctx, "keyboard_typos", len(set.Suggestions)-exact)
+            }
+        }
+    }
+    if len(set.Suggestions) == 0 && opts.FuzzyFallback {
+        fuzzyValues, fuzzyDebug, err := e.queryFuzzy(ctx, opts, field, query, filters)
+        debugInfo += fuzzyDebug
+        if err != nil {
+            // A fuzzy tartalék hibája nem teszi sikertelenné a kérést: az üres pontos találatot adjuk vissza.
+            slog.Warn("Fuzzy autocomplete error", "field", field, "query", query, "error", err)
+        } else if len(fuzzyValues.Buckets) > 0 {
+            set = Set{Suggestions: fuzzyValues.Keys(), Fuzzy: true, KSHCodes: kshCodes(fuzzyValues), Locations: locations(fuzzyValues), Weights: weights(fuzzyValues)}
+        }
+    }
+    return set, debugInfo, nil
+}
+
+type fetchResult struct {
+    set       Set
+    debugInfo string
+    err       error
+}
+
+// revalidate a stale-while-revalidate mód: lejárt cache bejegyzésnél elindítja a friss
+// lekérdezést, és legfeljebb StaleTimeout ideig vár rá. Ha a friss eredmény addig nem
+// érkezik meg, vagy hibával tér vissza, az elavult javaslatokat adja vissza Stale jelöléssel;
+// a háttérben futó lekérdezés sikeres befejezéskor frissíti a gyorsítótárat.
+func (e *Engine) revalidate(ctx context.Context, opts Options, cacheKey string, stale Set, field, query string, filters []dsl.Query, near *index.GeoPoint) (Set, string, error) {
+    stale.Stale = true
+    staleDebug := fmt.Sprintf("Elavult cache találat: %q, mező: %s\nVisszaadott javaslatok: %v\n", query, field, stale.Suggestions)
+
+    e.revalidatingMu.Lock()
+    if e.revalidating[cacheKey] {
+        e.revalidatingMu.Unlock()
+        recordCache(ctx, "stale")
+        return stale, staleDebug + "Háttérfrissítés már folyamatban\n", nil
+    }
+    e.revalidating[cacheKey] = true
+    e.revalidatingMu.Unlock()
+
+    results := make(chan fetchResult, 1)
+    go func() {
+        defer func() {
+            e.revalidatingMu.Lock()
+            delete(e.revalidating, cacheKey)
+            e.revalidatingMu.Unlock()
+        }()
+        // A háttérben futó lekérdezés túléli a kérést, ezért a kérés megszakítása nem állítja le.
+        bgCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), e.StaleRefreshTimeout)
+        defer cancel()
+        set, debugInfo, err := e.fetch(bgCtx, opts, field, query, filters, near)
+        if err == nil {
+            e.cache.Set(cacheKey, set)
+        } else {
+            slog.Warn("Stale-while-revalidate refresh error", "field", field, "query", query, "error", err)
+        }
+        results <- fetchResult{set: set, debugInfo: debugInfo, err: err}
+    }()
+
+    timer := time.NewTimer(opts.StaleTimeout)
+    defer timer.Stop()
+    select {
+    case res := <-results:
+        if res.err != nil {
+            recordCache(ctx, "stale")
+            return stale, staleDebug + res.debugInfo, nil
+        }
+        recordCache(ctx, "revalidated")
+        return res.set, res.debugInfo, nil
+    case <-timer.C:
+        recordCache(ctx, "stale")
+        return stale, staleDebug + fmt.Sprintf("A friss lekérdezés nem érkezett meg %s alatt, háttérben frissül\n", opts.StaleTimeout), nil
+    case <-ctx.Done():
+        return Set{}, staleDebug, ctx.Err()
+    }
+}
+
+// queryTerms gyorsítótár nélkül futtatja a javaslatkérést az OpenSearch-ön.
+func (e *Engine) queryTerms(ctx context.Context, opts Options, field, query string, filters []dsl.Query, near *index.GeoPoint) (Set, string, error) {
+    var debugBuffer bytes.Buffer
+    debugBuffer.WriteString(fmt.Sprintf("Keresési lekérdezés (%s): %q, mező: %s\n", opts.QueryMode, query, field))
+
+    if opts.QueryMode == QueryModeCompletion {
+        set, err := e.queryCompletion(ctx, opts, field, query, filters, &debugBuffer)
+        return set, debugBuffer.String(), err
+    }
+    aggQuery := buildAutocompleteQuery(opts, field, query, filters, near, &debugBuffer)
+    values, err := e.executeAggQuery(ctx, aggQuery, &debugBuffer)
+    if err != nil {
+        return Set{}, debugBuffer.String(), err
+    }
+    set := Set{Suggestions: values.Keys(), Matched: matchedAliases(values, query), KSHCodes: kshCodes(values), Locations: locations(values), Weights: weights(values),
+        Popularity: popularityCounts(values)}
+    if len(set.Matched) > 0 {
+        debugBuffer.WriteString(fmt.Sprintf("Korábbi/alternatív névre illeszkedett: %v\n", set.Matched))
+    }
+    return set, debugBuffer.String(), nil
+}
+
+// buildFuzzyQuery elgépelés-tűrő (fuzziness: AUTO) match lekérdezést állít össze a field mezőre,
+// a találatokat a "<field>.keyword" almezőn deduplikálva. Az első karaktert pontosnak várjuk el,
+// ami jelentősen csökkenti a vizsgálandó termek számát.
+func buildFuzzyQuery(opts Options, field, query string, filters []dsl.Query) dsl.Search {
+    unique := dsl.Terms(dsl.TermsAgg{Field: field + ".keyword", Size: opts.SuggestionLimit})
+    if field == "telepules" {
+        unique = withKSHCodes(unique)
+    }
+    unique = withWeights(withLocations(unique), false)
+    return dsl.Search{
+        Size: 0,
+        Query: dsl.Bool(dsl.BoolQuery{
+            Must:   []dsl.Query{textMatch(field, dsl.MatchQuery{Query: query, Operator: "and", Fuzziness: "AUTO", PrefixLength: 1})},
+            Filter: filters,
+        }),
+        Aggs: map[string]dsl.Agg{"unique_values": unique},
+    }
+}
+
+// queryFuzzy a buildFuzzyQuery szerinti elgépelés-tűrő lekérdezést futtatja.
+func (e *Engine) queryFuzzy(ctx context.Context, opts Options, field, query string, filters []dsl.Query) (dsl.AggResult, string, error) {
+    var debugBuffer bytes.Buffer
+    debugBuffer.WriteString(fmt.Sprintf("Fuzzy lekérdezés: %q, mező: %s\n", query, field))
+    values, err := e.executeAggQuery(ctx, buildFuzzyQuery(opts, field, query, filters), &debugBuffer)
+    return values, debugBuffer.String(), err
+}
+
+// search elküldi a kérést az index (kanári irányításnál a kérés indexének, lásd routeCanary)
+// _search végpontjára, és a nyers választ adja vissza.
+// A nem 200-as válasz hibának számít; az upstream státuszt a kérés naplósorához fűzi, a sikeres
+// válasz idejét és találatszámát a kérés Meta gyűjtőjébe írja (lásd WithMeta).
+func (e *Engine) search(ctx context.Context, payload []byte) (*opensearch.Response, error) {
+    resp, err := e.client.Do(ctx, "POST", "/"+e.searchIndex(ctx)+"/_search", payload, "application/json")
+    if err != nil {
+        return nil, err
+    }
+    reqlog.Add(ctx, "upstream_status", resp.StatusCode)
+    if resp.StatusCode == http.StatusOK {
+        recordSearchMeta(ctx, resp.Body)
+    }
+    return resp, nil
+}
+
+// executeSuggestionQuery elküldi a javaslatkérést az index _search végpontjára, és a
+// "unique_values" aggregáció kulcsait adja vissza. A lépéseket a debugBuffer-be naplózza.
+func (e *Engine) executeSuggestionQuery(ctx context.Context, search dsl.Search, debugBuffer *bytes.Buffer) ([]string, error) {
+    values, err := e.executeAggQuery(ctx, search, debugBuffer)
+    if err != nil {
+        return nil, err
+    }
+    return values.Keys(), nil
+}
+
+// executeAggQuery az executeSuggestionQuery lekérdezését futtatja, és a "unique_values"
+// aggregáció teljes eredményét adja vissza az al-aggregációkkal együtt.
+func (e *Engine) executeAggQuery(ctx context.Context, search dsl.Search, debugBuffer *bytes.Buffer) (dsl.AggResult, error) {
+    payloadBytes, err := json.Marshal(search)
+    if err != nil {
+        debugBuffer.WriteString(fmt.Sprintf("Hiba a payload marshalolásakor: %v\n", err))
+        return dsl.AggResult{}, err
+    }
+    debugBuffer.WriteString("Aggregation Payload JSON: " + string(payloadBytes) + "\n")
+
+    resp, err := e.search(ctx, payloadBytes)
+    if err != nil {
+        debugBuffer.WriteString(fmt.Sprintf("Hiba az OpenSearch lekérdezés végrehajtásakor: %v\n", err))
+        return dsl.AggResult{}, err
+    }
+    body := resp.Body
+    debugBuffer.WriteString(fmt.Sprintf("OpenSearch válasz státusza: %d\n", resp.StatusCode))
+    debugBuffer.WriteString("Válasz body: " + string(body) + "\n")
+    if resp.StatusCode != http.StatusOK {
+        return dsl.AggResult{}, fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+    }
+
+    var result dsl.SearchResponse
+    if err := json.Unmarshal(body, &result); err != nil {
+        debugBuffer.WriteString(fmt.Sprintf("Hiba a válasz JSON dekódolásakor: %v\n", err))
+        return dsl.AggResult{}, err
+    }
+    if countAgg, ok := result.Aggregations["unique_count"]; ok {
+        debugBuffer.WriteString(fmt.Sprintf("Egyedi találatok becsült száma: %v\n", countAgg.Value))
+    }
+    values := result.Aggregations["unique_values"]
+    debugBuffer.WriteString(fmt.Sprintf("Visszaadott javaslatok: %v\n", values.Keys()))
+    return values, nil
+}
@@ -0,0 +1,144 @@
+package suggest
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "net/http"
+
+    "autocomplete/internal/dsl"
+)
+
+const (
+    // exportPageSize az export egy lapjának dokumentumszáma.
+    exportPageSize = 5000
+    // pitKeepAlive a point-in-time pillanatkép élettartama; minden lapkérés meghosszabbítja, így
+    // csak két lap közötti szünetre (pl. lassú kliens) kell elégnek lennie.
+    pitKeepAlive = "5m"
+)
+
+// ExportRow az exportált adatállomány egy sora: egy egyedi település, illetve utcákkal együtt
+// kért exportnál egy egyedi település–közterület pár, és a hozzá tartozó dokumentumok száma. A
+// közterület nélküli dokumentumok üres KozterNev-vel szerepelnek.
+type ExportRow struct {
+    Telepules string `json:"telepules"`
+    KozterNev string `json:"kozter_nev,omitempty"`
+    Count     int64  `json:"count"`
+}
+
+// Export az index összes egyedi településén (streets esetén település–közterület párján) halad
+// végig, és a sorokat rendezve, egyenként adja át az fn-nek. Ha az fn hibát ad, a lapozás azzal
+// a hibával leáll.
+//
+// A dokumentumokat egy point-in-time pillanatképen search_after lapozással, a kulcsmezők szerint
+// rendezve olvassa, és az egymást követő azonos kulcsokat számolja össze. Így az export a
+// futása alatti indexelésektől függetlenül konzisztens, és a fürt vödörkorlátai
+// (search.max_buckets) sem szabnak határt; a település nélküli dokumentumok kimaradnak.
+func (e *Engine) Export(ctx context.Context, streets bool, fn func(ExportRow) error) error {
+    pit, err := e.openPIT(ctx, pitKeepAlive)
+    if err != nil {
+        return err
+    }
+    defer func() { e.closePIT(ctx, pit) }()
+
+    sort := []interface{}{map[string]string{"telepules.keyword": "asc"}}
+    if streets {
+        sort = append(sort, map[string]interface{}{"kozter_nev.keyword": map[string]string{"order": "asc", "missing": "_first"}})
+    }
+    // Döntetlenfeloldó nélkül a lap határán álló azonos kulcsú dokumentumok kimaradnának. A
+    // _shard_doc (szilánk és szegmensbeli dokumentumsorszám) csak point-in-time kereséssel
+    // használható, és az _id-vel szemben nem igényel fielddatát.
+    sort = append(sort, map[string]string{"_shard_doc": "asc"})
+
+    var current *ExportRow
+    var after []interface{}
+    for {
+        search := dsl.Search{Size: exportPageSize, StoredFields: []string{"_none_"}, TrackTotalHits: false, Sort: sort,
+            PIT: &dsl.PIT{ID: pit, KeepAlive: pitKeepAlive}, SearchAfter: after}
+        payload, err := json.Marshal(search)
+        if err != nil {
+            return err
+        }
+        resp, err := e.client.Do(ctx, "POST", "/_search", payload, "application/json")
+        if err != nil {
+            return err
+        }
+        if resp.StatusCode != http.StatusOK {
+            return fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+        }
+        var result dsl.SearchResponse
+        if err := json.Unmarshal(resp.Body, &result); err != nil {
+            return err
+        }
+        if result.PITID != "" {
+            pit = result.PITID
+        }
+        for _, hit := range result.Hits.Hits {
+            if len(hit.Sort) < len(sort) {
+                return fmt.Errorf("hiányzó rendezési értékek az export találatában: %v", hit.Sort)
+            }
+            telepules, _ := hit.Sort[0].(string)
+            if telepules == "" {
+                continue
+            }
+            var kozterNev string
+            if streets {
+                kozterNev, _ = hit.Sort[1].(string)
+            }
+            if current != nil && current.Telepules == telepules && current.KozterNev == kozterNev {
+                current.Count++
+                continue
+            }
+            if current != nil {
+                if err := fn(*current); err != nil {
+                    return err
+                }
+            }
+            current = &ExportRow{Telepules: telepules, KozterNev: kozterNev, Count: 1}
+        }
+        if len(result.Hits.Hits) < exportPageSize {
+            if current != nil {
+                return fn(*current)
+            }
+            return nil
+        }
+        after = result.Hits.Hits[len(result.Hits.Hits)-1].Sort
+    }
+}
+
+// openPIT keepAlive élettartamú point-in-time pillanatképet nyit a kérés indexén (lásd
+// searchIndex), és az azonosítóját adja vissza.
+func (e *Engine) openPIT(ctx context.Context, keepAlive string) (string, error) {
+    resp, err := e.client.Do(ctx, "POST", "/"+e.searchIndex(ctx)+"/_search/point_in_time?keep_alive="+keepAlive, nil, "")
+    if err != nil {
+        return "", err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return "", fmt.Errorf("a point-in-time megnyitása sikertelen (%d): %s", resp.StatusCode, resp.Body)
+    }
+    var result struct {
+        PITID string `json:"pit_id"`
+    }
+    if err := json.Unmarshal(resp.Body, &result); err != nil {
+        return "", err
+    }
+    if result.PITID == "" {
+        return "", fmt.Errorf("a point-in-time megnyitása nem adott azonosítót: %s", resp.Body)
+    }
+    return result.PITID, nil
+}
+
+// closePIT lezárja a pillanatképet, hogy a fürt ne tartsa a lejáratáig a szegmenseit. A kérés
+// megszakítása után is lefut; a hibát csak naplózza, a pillanatkép legkésőbb pitKeepAlive után
+// magától lejár.
+func (e *Engine) closePIT(ctx context.Context, id string) {
+    body, _ := json.Marshal(map[string][]string{"pit_id": {id}})
+    resp, err := e.client.Do(context.WithoutCancel(ctx), "DELETE", "/_search/point_in_time", body, "application/json")
+    if err == nil && resp.StatusCode != http.StatusOK {
+        err = fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+    }
+    if err != nil {
+        slog.Warn("Point-in-time close failed", "error", err)
+    }
+}
@@ -0,0 +1,108 @@
+package suggest
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+
+    "autocomplete/internal/dsl"
+)
+
+// Field a többmezős javaslatkérés egy mezője: a Name a kliens által használt név (ez kerül a
+// Set.Sources-ba), az IndexField az index szöveges mezője, amelyen a keresés fut.
+type Field struct {
+    Name       string
+    IndexField string
+}
+
+// SuggestFields a req lekérdezését a fields minden mezőjén párhuzamosan futtatja (mezőnként a
+// terms gyorsítótárával, így az egymezős kérésekkel azonos cache bejegyzéseket használ), majd a
+// javaslatokat összefésüli: a több mezőben is előforduló értéket csak egyszer, a fields
+// sorrendjében első mezőjével adja vissza. A req.Megye és req.Telepules minden mezőre szűkít.
+// Az összefésült lista a req.Sort szerint (relevanciánál a rangsorolási folyamattal) rendeződik,
+// és legfeljebb SuggestionLimit elemű. Minden mező ugyanabból az indexből szolgálódik ki (lásd
+// routeCanary).
+func (e *Engine) SuggestFields(ctx context.Context, req Request, fields []Field) (Set, string, error) {
+    ctx = e.routeCanary(ctx)
+    start := time.Now()
+    set, debugInfo, err := e.suggestFields(ctx, req, fields)
+    e.recordRoute(ctx, set, err, time.Since(start))
+    return set, e.routeDebug(ctx) + debugInfo, err
+}
+
+// suggestFields a SuggestFields a már irányított kontextussal.
+func (e *Engine) suggestFields(ctx context.Context, req Request, fields []Field) (Set, string, error) {
+    var filters []dsl.Query
+    if req.Megye != "" {
+        filters = append(filters, dsl.Term("megye", req.Megye))
+    }
+    if req.Telepules != "" {
+        filters = append(filters, dsl.Term("telepules.keyword", req.Telepules))
+    }
+    near := roundGeoPoint(req.Near)
+    results := make([]fetchResult, len(fields))
+    var wg sync.WaitGroup
+    for i, field := range fields {
+        wg.Add(1)
+        go func(i int, field Field) {
+            defer wg.Done()
+            set, debugInfo, err := e.terms(ctx, field.IndexField, req.Query, filters, near)
+            results[i] = fetchResult{set: set, debugInfo: debugInfo, err: err}
+        }(i, field)
+    }
+    wg.Wait()
+
+    var debug strings.Builder
+    merged := Set{Suggestions: []string{}, Sources: map[string]string{}}
+    fuzzy := false
+    for i, result := range results {
+        fmt.Fprintf(&debug, "Mező: %s (%s)\n%s", fields[i].Name, fields[i].IndexField, result.debugInfo)
+        if result.err != nil {
+            return Set{}, debug.String(), fmt.Errorf("%s: %w", fields[i].Name, result.err)
+        }
+        merged.Stale = merged.Stale || result.set.Stale
+        if len(result.set.Suggestions) > 0 {
+            fuzzy = result.set.Fuzzy && (fuzzy || len(merged.Suggestions) == 0)
+        }
+        for _, suggestion := range result.set.Suggestions {
+            if _, seen := merged.Sources[suggestion]; seen {
+                continue
+            }
+            merged.Suggestions = append(merged.Suggestions, suggestion)
+            merged.Sources[suggestion] = fields[i].Name
+            mergeValue(&merged.Matched, result.set.Matched, suggestion)
+            mergeValue(&merged.KSHCodes, result.set.KSHCodes, suggestion)
+            mergeValue(&merged.Locations, result.set.Locations, suggestion)
+            mergeValue(&merged.Weights, result.set.Weights, suggestion)
+            mergeValue(&merged.Popularity, result.set.Popularity, suggestion)
+        }
+    }
+    // Csak akkor elgépelés-tűrő az eredmény, ha minden javaslatot adó mező a tartalékból válaszolt.
+    merged.Fuzzy = fuzzy
+    indexFields := make(map[string]string, len(fields))
+    for _, field := range fields {
+        indexFields[field.Name] = field.IndexField
+    }
+    ranked, rankDebug := e.rank(ctx, req, merged, func(value string) string { return indexFields[merged.Sources[value]] })
+    debug.WriteString(rankDebug)
+    merged.Suggestions = ranked
+    if limit := e.Options().SuggestionLimit; len(merged.Suggestions) > limit {
+        merged.Suggestions = merged.Suggestions[:limit]
+    }
+    return merged, debug.String(), nil
+}
+
+// mergeValue a src map suggestion kulcsú értékét (ha van) a *dst map-be másolja, szükség esetén
+// létrehozva azt; a gyorsítótárban tárolt Set map-jeit így nem módosítjuk.
+func mergeValue[V any](dst *map[string]V, src map[string]V, suggestion string) {
+    v, ok := src[suggestion]
+    if !ok {
+        return
+    }
+    if *dst == nil {
+        *dst = map[string]V{}
+    }
+    (*dst)[suggestion] = v
+}
@@ -0,0 +1,154 @@
+package suggest
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+
+    "autocomplete/internal/dsl"
+    "autocomplete/internal/index"
+)
+
+// A geokódolás pontossága (Geocode.Precision): a koordináta a közterület vagy csak a
+// település rekordjából származik.
+const (
+    PrecisionStreet     = "street"
+    PrecisionSettlement = "settlement"
+)
+
+// ReverseGeocodeRadius a fordított geokódolás keresési sugara méterben; ennél távolabbi
+// rekordot nem adunk vissza.
+const ReverseGeocodeRadius = 25000
+
+// geocodeSource a geokódolás eredményéhez szükséges mezők.
+var geocodeSource = []string{"telepules", "kozter_nev", "irsz", "megye", kshField, "location"}
+
+// Geocode egy cím geokódolásának eredménye: a cím ellenőrzése (lásd AddressValidation), és
+// érvényes cím esetén a Location koordináta. Ha a címhez illeszkedő rekordnak nincs
+// koordinátája, a település bármely koordinátával rendelkező rekordjáé kerül bele, és a
+// Precision PrecisionSettlement; a Location nil, ha a településnek sincs koordinátája.
+type Geocode struct {
+    AddressValidation
+    Precision string          `json:"precision,omitempty"`
+    Location  *index.GeoPoint `json:"location,omitempty"`
+}
+
+// ReverseGeocode a fordított geokódolás eredménye: a ponthoz legközelebbi, ReverseGeocodeRadius
+// sugáron belüli rekord (település, és ha van, közterület) és a távolsága méterben.
+type ReverseGeocode struct {
+    Found          bool                   `json:"found"`
+    Nearest        *index.AddressDocument `json:"nearest,omitempty"`
+    DistanceMeters float64                `json:"distanceMeters,omitempty"`
+}
+
+// LocatedDocument a rekord geokódolásban visszaadott alakja: a Lat/Lon a Location-ből kerül
+// kitöltésre, a képzett mezők (ID, TeljesCim, Location, Suggest) üresek.
+func LocatedDocument(doc index.AddressDocument) index.AddressDocument {
+    if p := doc.GeoPoint(); p == nil && doc.Location != nil {
+        lat, lon := doc.Location.Lat, doc.Location.Lon
+        doc.Lat, doc.Lon = &lat, &lon
+    }
+    doc.ID, doc.TeljesCim, doc.Location, doc.Suggest = "", "", nil, nil
+    return doc
+}
+
+// GeocodeResult a geokódolás eredményét a validation ellenőrzésből és a koordinátával
+// rendelkező rekordból (located, vagy nil) állítja össze.
+func GeocodeResult(validation AddressValidation, located *index.AddressDocument) Geocode {
+    result := Geocode{AddressValidation: validation}
+    if !validation.Valid || located == nil {
+        return result
+    }
+    result.Location = located.GeoPoint()
+    if result.Location == nil {
+        result.Location = located.Location
+    }
+    if result.Location == nil {
+        return result
+    }
+    result.Precision = PrecisionSettlement
+    if validation.Canonical != nil && validation.Canonical.KozterNev != "" && located.KozterNev == validation.Canonical.KozterNev {
+        result.Precision = PrecisionStreet
+    }
+    return result
+}
+
+// Geocode ellenőrzi a címet (lásd Validate), és ugyanabban a lekérdezésben a címhez illeszkedő,
+// illetve tartalékként a település koordinátával rendelkező rekordját is lekéri.
+func (e *Engine) Geocode(ctx context.Context, in AddressInput) (Geocode, error) {
+    search := buildValidationSearch(in, false)
+    located := dsl.FilterAgg(dsl.Exists("location")).With(map[string]dsl.Agg{"best": dsl.TopHits(1, geocodeSource...)})
+    if search.search.Aggs == nil {
+        search.search.Aggs = map[string]dsl.Agg{}
+    }
+    search.search.Aggs["located"] = located
+    if search.combined {
+        sub, _ := search.search.Aggs["all"]["aggs"].(map[string]dsl.Agg)
+        sub["located"] = dsl.FilterAgg(dsl.Exists("location")).With(map[string]dsl.Agg{"best": dsl.TopHits(1, geocodeSource...)})
+    }
+    body, err := json.Marshal(search.search)
+    if err != nil {
+        return Geocode{}, err
+    }
+    resp, err := e.search(ctx, body)
+    if err != nil {
+        return Geocode{}, err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return Geocode{}, fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+    }
+    var parsed dsl.SearchResponse
+    if err := json.Unmarshal(resp.Body, &parsed); err != nil {
+        return Geocode{}, err
+    }
+    hits := parsed.Aggregations["located"].Sub["best"].Hits.Hits
+    if exact := parsed.Aggregations["all"].Sub["located"].Sub["best"].Hits.Hits; search.combined && len(exact) > 0 {
+        hits = exact
+    }
+    var locatedDoc *index.AddressDocument
+    var doc index.AddressDocument
+    if len(hits) > 0 && hits[0].Decode(&doc) == nil {
+        locatedDoc = &doc
+    }
+    return GeocodeResult(search.result(parsed), locatedDoc), nil
+}
+
+// ReverseGeocode a ponthoz legközelebbi koordinátával rendelkező rekordot keresi meg
+// geo_distance szűrővel és távolság szerinti rendezéssel.
+func (e *Engine) ReverseGeocode(ctx context.Context, p index.GeoPoint) (ReverseGeocode, error) {
+    search := dsl.Search{
+        Size:   1,
+        Source: geocodeSource,
+        Query:  dsl.Filter(dsl.GeoDistance("location", p.Lat, p.Lon, fmt.Sprintf("%dm", ReverseGeocodeRadius))),
+        Sort:   []interface{}{dsl.GeoDistanceSort("location", p.Lat, p.Lon)},
+    }
+    body, err := json.Marshal(search)
+    if err != nil {
+        return ReverseGeocode{}, err
+    }
+    resp, err := e.search(ctx, body)
+    if err != nil {
+        return ReverseGeocode{}, err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return ReverseGeocode{}, fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+    }
+    var parsed dsl.SearchResponse
+    if err := json.Unmarshal(resp.Body, &parsed); err != nil {
+        return ReverseGeocode{}, err
+    }
+    var result ReverseGeocode
+    var doc index.AddressDocument
+    if len(parsed.Hits.Hits) > 0 && parsed.Hits.Hits[0].Decode(&doc) == nil {
+        nearest := LocatedDocument(doc)
+        result.Found = true
+        result.Nearest = &nearest
+        if sort := parsed.Hits.Hits[0].Sort; len(sort) > 0 {
+            result.DistanceMeters, _ = sort[0].(float64)
+        } else if located := nearest.GeoPoint(); located != nil {
+            result.DistanceMeters = p.DistanceMeters(*located)
+        }
+    }
+    return result, nil
+}
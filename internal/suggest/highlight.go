@@ -0,0 +1,115 @@
+package suggest
+
+import (
+    "html"
+    "sort"
+    "strings"
+    "unicode"
+
+    "golang.org/x/text/transform"
+)
+
+// Span a javaslat egy kiemelendő szakasza: [Start, End) Unicode kódpont (rune) indexekkel.
+type Span struct {
+    Start int `json:"start"`
+    End   int `json:"end"`
+}
+
+// Highlight egy javaslat kiemelése a válaszban: a Spans a lekérdezésre illeszkedő szakaszok,
+// a HTML pedig a javaslat HTML-biztos alakja, amelyben ezek <em> elemben állnak.
+type Highlight struct {
+    Spans []Span `json:"spans"`
+    HTML  string `json:"html,omitempty"`
+}
+
+// foldRunes a javaslatot fold-hoz hasonlóan kisbetűs, ékezet nélküli alakra hozza, és minden
+// kapott rune-hoz megadja, hogy az eredeti javaslat hányadik rune-jából származik.
+func foldRunes(s string) ([]rune, []int) {
+    var folded []rune
+    var origin []int
+    for i, r := range []rune(s) {
+        f, _, err := transform.String(foldTransformer, string(r))
+        if err != nil {
+            f = string(r)
+        }
+        for _, fr := range strings.ToLower(f) {
+            folded = append(folded, fr)
+            origin = append(origin, i)
+        }
+    }
+    return folded, origin
+}
+
+// indexToken a token első előfordulása a folded-ben; a szó elején kezdődő előfordulást
+// előnyben részesíti, mert a prefix illesztés azokra talál. -1, ha nem fordul elő.
+func indexToken(folded, token []rune) int {
+    first := -1
+    for p := 0; p+len(token) <= len(folded); p++ {
+        if string(folded[p:p+len(token)]) != string(token) {
+            continue
+        }
+        if p == 0 || !unicode.IsLetter(folded[p-1]) && !unicode.IsDigit(folded[p-1]) {
+            return p
+        }
+        if first < 0 {
+            first = p
+        }
+    }
+    return first
+}
+
+// HighlightSpans a javaslat azon szakaszai, amelyekre a lekérdezés szavai illeszkednek, kis-nagybetű
+// és ékezet függetlenül (a "sze" a "Szeged", a "gyor" a "Győr" elejére illeszkedik). Az átfedő és
+// szomszédos szakaszokat összevonja; ha semmi sem illeszkedik (pl. elgépelés-tűrő vagy alternatív
+// névre illeszkedő találatnál), üres szeletet ad.
+func HighlightSpans(suggestion, query string) []Span {
+    folded, origin := foldRunes(suggestion)
+    var spans []Span
+    for _, token := range queryTokens(fold(query)) {
+        t := []rune(token)
+        if p := indexToken(folded, t); p >= 0 {
+            spans = append(spans, Span{Start: origin[p], End: origin[p+len(t)-1] + 1})
+        }
+    }
+    sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+    merged := []Span{}
+    for _, span := range spans {
+        if n := len(merged); n > 0 && span.Start <= merged[n-1].End {
+            if span.End > merged[n-1].End {
+                merged[n-1].End = span.End
+            }
+            continue
+        }
+        merged = append(merged, span)
+    }
+    return merged
+}
+
+// HighlightHTML a javaslatot HTML-escape-elve adja vissza, a spans szakaszokat <em> elembe
+// foglalva, pl. "<em>Sze</em>ged".
+func HighlightHTML(suggestion string, spans []Span) string {
+    runes := []rune(suggestion)
+    var sb strings.Builder
+    pos := 0
+    for _, span := range spans {
+        sb.WriteString(html.EscapeString(string(runes[pos:span.Start])))
+        sb.WriteString("<em>")
+        sb.WriteString(html.EscapeString(string(runes[span.Start:span.End])))
+        sb.WriteString("</em>")
+        pos = span.End
+    }
+    sb.WriteString(html.EscapeString(string(runes[pos:])))
+    return sb.String()
+}
+
+// Highlights a javaslatokkal párhuzamos kiemeléseket adja; withHTML esetén a HTML alakot is.
+func Highlights(suggestions []string, query string, withHTML bool) []Highlight {
+    highlights := make([]Highlight, len(suggestions))
+    for i, s := range suggestions {
+        highlights[i].Spans = HighlightSpans(s, query)
+        if withHTML {
+            highlights[i].HTML = HighlightHTML(s, highlights[i].Spans)
+        }
+    }
+    return highlights
+}
@@ -0,0 +1,111 @@
+package suggest
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strings"
+
+    "autocomplete/internal/dsl"
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+)
+
+// houseNumberSegmentLimit egy közterület legfeljebb ennyi szakaszát vizsgáljuk (a top_hits
+// alapértelmezett felső korlátja, index.max_inner_result_window).
+const houseNumberSegmentLimit = 100
+
+// houseNumberSource a szakaszok ellenőrzéshez és a kanonikus alakhoz szükséges mezői.
+var houseNumberSource = []string{"telepules", "kozter_nev", "irsz", "megye", kshField, "hazszam_tol", "hazszam_ig", "hazszam_paritas"}
+
+// HouseNumberInput egy házszámos cím ellenőrzéséhez.
+type HouseNumberInput struct {
+    Telepules string `json:"telepules"`
+    KozterNev string `json:"kozter_nev"`
+    Hazszam   string `json:"hazszam"`
+}
+
+// HouseNumberValidation egy házszámos cím ellenőrzésének eredménye. A Valid akkor igaz, ha a
+// közterület valamely szakaszának házszámtartománya (az oldalát is figyelembe véve) tartalmazza
+// a házszámot; ilyenkor az Irsz a szakasz irányítószáma, a Canonical pedig a szakasz rekordja.
+// A RangesKnown jelzi, hogy a közterülethez egyáltalán van-e házszámtartomány az indexben;
+// ha nincs, a házszám nem ellenőrizhető.
+type HouseNumberValidation struct {
+    Valid           bool                   `json:"valid"`
+    SettlementFound bool                   `json:"settlementFound"`
+    StreetFound     bool                   `json:"streetFound"`
+    RangesKnown     bool                   `json:"rangesKnown"`
+    Hazszam         int                    `json:"hazszam"`
+    Irsz            string                 `json:"irsz,omitempty"`
+    Canonical       *index.AddressDocument `json:"canonical,omitempty"`
+}
+
+// MatchHouseNumber a közterület szakaszai (segments) közül kiválasztja az n házszámot tartalmazót.
+func MatchHouseNumber(segments []index.AddressDocument, n int) HouseNumberValidation {
+    result := HouseNumberValidation{SettlementFound: true, StreetFound: len(segments) > 0, Hazszam: n}
+    for _, doc := range segments {
+        if !doc.HasHouseNumberRange() {
+            continue
+        }
+        result.RangesKnown = true
+        if doc.CoversHouseNumber(n) {
+            canonical := doc
+            canonical.ID, canonical.TeljesCim, canonical.Location, canonical.Suggest = "", "", nil, nil
+            result.Valid = true
+            result.Irsz = doc.Irsz
+            result.Canonical = &canonical
+            return result
+        }
+    }
+    return result
+}
+
+// ValidateHouseNumber egyetlen lekérdezéssel, pontos egyezéssel megkeresi a település és a
+// közterület szakaszait, és ellenőrzi, hogy a házszám valamelyik tartományukba esik-e (lásd
+// MatchHouseNumber). Érvénytelen házszámra index.ErrInvalidHouseNumber hibát ad.
+func (e *Engine) ValidateHouseNumber(ctx context.Context, in HouseNumberInput) (HouseNumberValidation, error) {
+    n, err := index.ParseHouseNumber(in.Hazszam)
+    if err != nil {
+        return HouseNumberValidation{}, err
+    }
+    telepules, kozterNev := strings.TrimSpace(in.Telepules), strings.TrimSpace(in.KozterNev)
+    search := dsl.Search{
+        Size:           0,
+        TrackTotalHits: 1,
+        Query:          dsl.Filter(dsl.Term("telepules.keyword", telepules)),
+        Aggs: map[string]dsl.Agg{
+            "street": dsl.FilterAgg(dsl.Term("kozter_nev.keyword", kozterNev)).With(map[string]dsl.Agg{
+                "segments": dsl.TopHits(houseNumberSegmentLimit, houseNumberSource...),
+            }),
+        },
+    }
+    body, err := json.Marshal(search)
+    if err != nil {
+        return HouseNumberValidation{}, err
+    }
+    resp, err := e.search(ctx, body)
+    if err != nil {
+        return HouseNumberValidation{}, err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return HouseNumberValidation{}, fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+    }
+    var parsed dsl.SearchResponse
+    if err := json.Unmarshal(resp.Body, &parsed); err != nil {
+        return HouseNumberValidation{}, err
+    }
+    if parsed.Hits.Total.Value == 0 {
+        return HouseNumberValidation{Hazszam: n}, nil
+    }
+    var segments []index.AddressDocument
+    for _, hit := range parsed.Aggregations["street"].Sub["segments"].Hits.Hits {
+        var doc index.AddressDocument
+        if hit.Decode(&doc) == nil {
+            segments = append(segments, doc)
+        }
+    }
+    result := MatchHouseNumber(segments, n)
+    reqlog.Add(ctx, "segments", len(segments), "valid", result.Valid)
+    return result, nil
+}
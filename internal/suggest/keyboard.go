@@ -0,0 +1,148 @@
+package suggest
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "unicode"
+
+    "autocomplete/internal/dsl"
+)
+
+// keyboardRows a magyar QWERTZ billentyűzet sorai felülről lefelé, kisbetűvel.
+var keyboardRows = []string{"0123456789öüó", "qwertzuiopőú", "asdfghjkléáű", "íyxcvbnm"}
+
+// keyboardRowOffsets a sorok eltolása: a keyboardRows[r] i-edik billentyűje alatt a következő sor
+// i+offset[0]-adik és i+offset[1]-edik billentyűje van (a "0" billentyű miatt a számsor a
+// betűsorokhoz képest egy billentyűvel jobbra csúszik, az alsó sort az "í" nyitja).
+var keyboardRowOffsets = [][2]int{{-2, -1}, {-1, 0}, {0, 1}}
+
+// keyboardNeighbors a betűbillentyűkhöz a szomszédos betűbillentyűket rendeli (a sorban balra és
+// jobbra, valamint felette és alatta).
+var keyboardNeighbors = buildKeyboardNeighbors()
+
+func buildKeyboardNeighbors() map[rune][]rune {
+    rows := make([][]rune, len(keyboardRows))
+    for i, row := range keyboardRows {
+        rows[i] = []rune(row)
+    }
+    neighbors := map[rune][]rune{}
+    link := func(a, b rune) {
+        if unicode.IsLetter(a) && unicode.IsLetter(b) {
+            neighbors[a] = append(neighbors[a], b)
+            neighbors[b] = append(neighbors[b], a)
+        }
+    }
+    for r, row := range rows {
+        for i, key := range row {
+            if i > 0 {
+                link(row[i-1], key)
+            }
+            if r+1 == len(rows) {
+                continue
+            }
+            for _, offset := range keyboardRowOffsets[r] {
+                if j := i + offset; j >= 0 && j < len(rows[r+1]) {
+                    link(key, rows[r+1][j])
+                }
+            }
+        }
+    }
+    return neighbors
+}
+
+// keyboardVariants a (már normalizált) lekérdezés változatai, amelyekben az utolsó karaktert a
+// billentyűzeten szomszédos betűk egyike helyettesíti (a "budapesr" változatai a "budapese",
+// "budapest", "budapesd" és "budapesf"). A foldAccents az ékezetes szomszédokat ékezet nélkül adja (lásd
+// NormalizeQuery); az ismétlődő és a lekérdezéssel azonos változatok kimaradnak. Ha az utolsó
+// karakter nem betű, nincs változat.
+func keyboardVariants(query string, foldAccents bool) []string {
+    runes := []rune(query)
+    if len(runes) == 0 {
+        return nil
+    }
+    prefix := string(runes[:len(runes)-1])
+    seen := map[string]bool{query: true}
+    var variants []string
+    for _, neighbor := range keyboardNeighbors[runes[len(runes)-1]] {
+        variant := NormalizeQuery(prefix+string(neighbor), foldAccents)
+        if !seen[variant] {
+            seen[variant] = true
+            variants = append(variants, variant)
+        }
+    }
+    return variants
+}
+
+// buildKeyboardQuery a billentyűszomszéd-változatokat egyetlen bool lekérdezésben fogja össze:
+// bármelyik változatra illeszkedő dokumentum találat, a "<field>.keyword" almezőn deduplikálva,
+// a fuzzy tartalékkal azonos al-aggregációkkal.
+func buildKeyboardQuery(opts Options, field string, variants []string, filters []dsl.Query) dsl.Search {
+    should := make([]dsl.Query, 0, len(variants))
+    for _, variant := range variants {
+        should = append(should, textMatch(field, dsl.MatchQuery{Query: variant, Operator: "and"}))
+    }
+    unique := dsl.Terms(dsl.TermsAgg{Field: field + ".keyword", Size: opts.SuggestionLimit})
+    if field == "telepules" {
+        unique = withKSHCodes(unique)
+    }
+    unique = withWeights(withLocations(unique), false)
+    return dsl.Search{
+        Size: 0,
+        Query: dsl.Bool(dsl.BoolQuery{
+            Must:   []dsl.Query{dsl.Bool(dsl.BoolQuery{Should: should})},
+            Filter: filters,
+        }),
+        Aggs: map[string]dsl.Agg{"unique_values": unique},
+    }
+}
+
+// queryKeyboard a buildKeyboardQuery szerinti lekérdezést futtatja.
+func (e *Engine) queryKeyboard(ctx context.Context, opts Options, field string, variants []string, filters []dsl.Query) (dsl.AggResult, string, error) {
+    var debugBuffer bytes.Buffer
+    debugBuffer.WriteString(fmt.Sprintf("Billentyűszomszéd-változatok: %v, mező: %s\n", variants, field))
+    values, err := e.executeAggQuery(ctx, buildKeyboardQuery(opts, field, variants, filters), &debugBuffer)
+    return values, debugBuffer.String(), err
+}
+
+// mergeKeyboard a pontos találatok után fűzi a billentyűszomszéd-változatok még nem szereplő
+// javaslatait legfeljebb limit javaslatig, a hozzájuk tartozó KSH kódokkal, helyadatokkal és
+// súlyokkal együtt. Ha a pontos lekérdezésnek nem volt találata, az eredmény Fuzzy jelölést kap.
+func mergeKeyboard(set Set, values dsl.AggResult, limit int) Set {
+    merged := set
+    merged.Suggestions = append([]string(nil), set.Suggestions...)
+    seen := make(map[string]bool, len(set.Suggestions))
+    for _, suggestion := range set.Suggestions {
+        seen[suggestion] = true
+    }
+    for _, key := range values.Keys() {
+        if len(merged.Suggestions) >= limit {
+            break
+        }
+        if !seen[key] {
+            seen[key] = true
+            merged.Suggestions = append(merged.Suggestions, key)
+        }
+    }
+    merged.KSHCodes = mergeMaps(set.KSHCodes, kshCodes(values))
+    merged.Locations = mergeMaps(set.Locations, locations(values))
+    merged.Weights = mergeMaps(set.Weights, weights(values))
+    merged.Fuzzy = set.Fuzzy || (len(set.Suggestions) == 0 && len(merged.Suggestions) > 0)
+    return merged
+}
+
+// mergeMaps új map-ben egyesíti az a és a b bejegyzéseit (ütközésnél az a értéke marad); ha
+// mindkettő üres, nil.
+func mergeMaps[K comparable, V any](a, b map[K]V) map[K]V {
+    if len(a) == 0 && len(b) == 0 {
+        return nil
+    }
+    merged := make(map[K]V, len(a)+len(b))
+    for k, v := range b {
+        merged[k] = v
+    }
+    for k, v := range a {
+        merged[k] = v
+    }
+    return merged
+}
@@ -0,0 +1,32 @@
+package suggest
+
+import "autocomplete/internal/dsl"
+
+// kshField a település KSH azonosítóját tartalmazó keyword mező (lásd index.AddressDocument.KSHKod).
+const kshField = "ksh_kod"
+
+// withKSHCodes a "telepules" mező terms aggregációjához al-aggregációként hozzáadja a vödör
+// KSH kódját, amelyet a kshCodes olvas ki.
+func withKSHCodes(unique dsl.Agg) dsl.Agg {
+    sub, _ := unique["aggs"].(map[string]dsl.Agg)
+    if sub == nil {
+        sub = map[string]dsl.Agg{}
+    }
+    sub[kshField] = dsl.Terms(dsl.TermsAgg{Field: kshField, Size: 1})
+    unique["aggs"] = sub
+    return unique
+}
+
+// kshCodes a településnevekhez rendeli a vödrük KSH kódját; nil, ha egyik vödörnek sincs.
+func kshCodes(values dsl.AggResult) map[string]string {
+    var codes map[string]string
+    for _, bucket := range values.Buckets {
+        if buckets := bucket.Sub[kshField].Buckets; len(buckets) > 0 && buckets[0].Key != "" {
+            if codes == nil {
+                codes = map[string]string{}
+            }
+            codes[bucket.Key] = buckets[0].Key
+        }
+    }
+    return codes
+}
@@ -0,0 +1,44 @@
+package suggest
+
+import (
+    "autocomplete/internal/dsl"
+    "autocomplete/internal/index"
+)
+
+// centroidAgg a javaslat vödrének helyadatát adó geo_centroid al-aggregáció neve.
+const centroidAgg = "centroid"
+
+// withLocations a terms aggregációhoz al-aggregációként hozzáadja a vödör dokumentumainak
+// súlypontját a "location" mezőn (településnél a rekordjai, közterületnél a szakaszai
+// középpontja), amelyet a locations olvas ki.
+func withLocations(unique dsl.Agg) dsl.Agg {
+    sub, _ := unique["aggs"].(map[string]dsl.Agg)
+    if sub == nil {
+        sub = map[string]dsl.Agg{}
+    }
+    sub[centroidAgg] = dsl.GeoCentroid("location")
+    unique["aggs"] = sub
+    return unique
+}
+
+// locations a javaslatokhoz rendeli a vödrük súlypontját; nil, ha egyik vödörnek sincs helyadata.
+func locations(values dsl.AggResult) map[string]index.GeoPoint {
+    var points map[string]index.GeoPoint
+    for _, bucket := range values.Buckets {
+        if p := bucket.Sub[centroidAgg].Location; p != nil {
+            if points == nil {
+                points = map[string]index.GeoPoint{}
+            }
+            points[bucket.Key] = index.GeoPoint{Lat: p.Lat, Lon: p.Lon}
+        }
+    }
+    return points
+}
+
+// sourceLocation a dokumentum _source-ában tárolt "location" geo_point (lat/lon objektum) értéke.
+func sourceLocation(source map[string]interface{}) (index.GeoPoint, bool) {
+    location, _ := source["location"].(map[string]interface{})
+    lat, latOK := location["lat"].(float64)
+    lon, lonOK := location["lon"].(float64)
+    return index.GeoPoint{Lat: lat, Lon: lon}, latOK && lonOK
+}
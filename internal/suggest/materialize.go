@@ -0,0 +1,403 @@
+package suggest
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "net/url"
+    "sort"
+    "sync"
+    "time"
+    "unicode/utf8"
+
+    "autocomplete/internal/dsl"
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+)
+
+// Az előszámítási feladat (lásd Engine.StartMaterialize) állapotai.
+const (
+    MaterializeRunning   = "running"
+    MaterializeCompleted = "completed"
+    MaterializeFailed    = "failed"
+)
+
+// ErrMaterializeInProgress jelzi, hogy már fut egy előszámítás.
+var ErrMaterializeInProgress = errors.New("már folyamatban van a javaslatok előszámítása")
+
+// materializedFields a szűrő nélkül is kérdezhető mezők, amelyek javaslatait előre kiszámoljuk.
+var materializedFields = []string{"telepules", "kozter_nev", "teljes_cim"}
+
+const (
+    // materializeBatchSize a lookup indexbe egy _bulk kérésben írt dokumentumok száma.
+    materializeBatchSize = 500
+    // compositePageSize a mezőértékek lapozásakor egy kérésben lekért értékek száma.
+    compositePageSize = 5000
+)
+
+// MaterializeJob az előszámítás állapota.
+type MaterializeJob struct {
+    Index           string     `json:"index"`
+    Status          string     `json:"status"`
+    MaxPrefixLength int        `json:"maxPrefixLength"`
+    Prefixes        int        `json:"prefixes"`
+    Written         int        `json:"written"`
+    Failed          int        `json:"failed"`
+    StartedAt       time.Time  `json:"startedAt"`
+    FinishedAt      *time.Time `json:"finishedAt,omitempty"`
+    Error           string     `json:"error,omitempty"`
+}
+
+// materializedDoc egy mező egy előtagjának javaslatai a lookup indexben (_id: materializedID).
+// A QueryMode, a Limit, a Weighted (WeightBoost > 0), a PopularityRanking és a FoldAccents azt
+// rögzíti, milyen beállításokkal készült; a kiszolgálás csak egyező beállításoknál és legalább
+// akkora limitnél használja. A Weights és a Popularity a rangsoroláshoz és a részletes
+// válaszokhoz tárolt súlyok, illetve kiválasztásszámok (lásd Set). A Generation a feladat
+// azonosítója, a korábbi futások megmaradt dokumentumai ez alapján törlődnek.
+type materializedDoc struct {
+    Field             string                    `json:"field"`
+    Prefix            string                    `json:"prefix"`
+    QueryMode         string                    `json:"query_mode"`
+    Limit             int                       `json:"limit"`
+    Weighted          bool                      `json:"weighted,omitempty"`
+    PopularityRanking bool                      `json:"popularity_ranking,omitempty"`
+    FoldAccents       bool                      `json:"fold_accents,omitempty"`
+    Suggestions       []string                  `json:"suggestions"`
+    Fuzzy             bool                      `json:"fuzzy,omitempty"`
+    Matched           map[string]string         `json:"matched,omitempty"`
+    KSHCodes          map[string]string         `json:"ksh_codes,omitempty"`
+    Locations         map[string]index.GeoPoint `json:"locations,omitempty"`
+    Weights           map[string]int            `json:"weights,omitempty"`
+    Popularity        map[string]int            `json:"popularity,omitempty"`
+    Generation        int64                     `json:"generation"`
+}
+
+func materializedID(field, prefix string) string {
+    return field + "|" + prefix
+}
+
+// LookupIndex az előre kiszámolt javaslatok indexének neve.
+func (e *Engine) LookupIndex() string {
+    return e.index + "_prefixes"
+}
+
+// CurrentMaterialize az utolsó előszámítás állapotának másolatát adja vissza (nil, ha még nem volt).
+func (e *Engine) CurrentMaterialize() *MaterializeJob {
+    e.materializeMu.Lock()
+    defer e.materializeMu.Unlock()
+    if e.materializeJob == nil {
+        return nil
+    }
+    job := *e.materializeJob
+    return &job
+}
+
+func (e *Engine) updateMaterialize(fn func(job *MaterializeJob)) {
+    e.materializeMu.Lock()
+    defer e.materializeMu.Unlock()
+    fn(e.materializeJob)
+}
+
+// StartMaterialize a háttérben elindítja az előszámítást: a materializedFields mezők minden
+// értékének legfeljebb MaterializedPrefixLength karakteres előtagjaira kiszámolja a javaslatokat
+// (ugyanúgy, mint egy gyorsítótár-hiánynál), és a LookupIndex indexbe írja őket. Ha már fut
+// egy előszámítás, ErrMaterializeInProgress hibát ad.
+func (e *Engine) StartMaterialize() (*MaterializeJob, error) {
+    e.materializeMu.Lock()
+    defer e.materializeMu.Unlock()
+    if e.materializeJob != nil && e.materializeJob.Status == MaterializeRunning {
+        return nil, ErrMaterializeInProgress
+    }
+    opts := e.Options()
+    e.materializeJob = &MaterializeJob{
+        Index:           e.LookupIndex(),
+        Status:          MaterializeRunning,
+        MaxPrefixLength: opts.MaterializedPrefixLength,
+        StartedAt:       time.Now(),
+    }
+    job := *e.materializeJob
+    slog.Info("Materialization started", "index", job.Index, "max_prefix_length", job.MaxPrefixLength)
+    go e.runMaterialize(opts, job.StartedAt.UnixNano())
+    return &job, nil
+}
+
+// runMaterialize lefuttatja az előszámítást, és lezárja a feladatot.
+func (e *Engine) runMaterialize(opts Options, generation int64) {
+    err := e.materialize(context.Background(), opts, generation)
+    e.updateMaterialize(func(job *MaterializeJob) {
+        now := time.Now()
+        job.Status = MaterializeCompleted
+        job.FinishedAt = &now
+        if err != nil {
+            job.Status = MaterializeFailed
+            job.Error = err.Error()
+        }
+    })
+    job := e.CurrentMaterialize()
+    if err != nil {
+        slog.Error("Materialization failed", "index", job.Index, "error", err)
+        return
+    }
+    slog.Info("Materialization finished", "index", job.Index, "prefixes", job.Prefixes, "written", job.Written,
+        "failed", job.Failed, "duration", job.FinishedAt.Sub(job.StartedAt).String())
+}
+
+func (e *Engine) materialize(ctx context.Context, opts Options, generation int64) error {
+    if err := e.ensureLookupIndex(ctx); err != nil {
+        return err
+    }
+    for _, field := range materializedFields {
+        prefixes, err := e.fieldPrefixes(ctx, field, opts.MaterializedPrefixLength)
+        if err != nil {
+            return fmt.Errorf("a(z) %s mező előtagjainak lekérése sikertelen: %w", field, err)
+        }
+        e.updateMaterialize(func(job *MaterializeJob) { job.Prefixes += len(prefixes) })
+        if err := e.materializeField(ctx, opts, generation, field, prefixes); err != nil {
+            return err
+        }
+    }
+    // A korábbi futásokból megmaradt (azóta nem létező) előtagok törlése.
+    query, _ := json.Marshal(dsl.Search{Query: dsl.Bool(dsl.BoolQuery{MustNot: []dsl.Query{dsl.Term("generation", generation)}})})
+    resp, err := e.client.Do(ctx, "POST", "/"+e.LookupIndex()+"/_delete_by_query?conflicts=proceed", query, "application/json")
+    if err != nil {
+        return err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("a régi előtagok törlése sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    return nil
+}
+
+// materializeField a mező előtagjainak javaslatait warmupWorkers párhuzamos lekérdezéssel
+// számolja ki, és materializeBatchSize méretű kötegekben írja a lookup indexbe. A sikertelen
+// lekérdezéseket csak számolja; a _bulk kérés hibája megszakítja a feladatot.
+func (e *Engine) materializeField(ctx context.Context, opts Options, generation int64, field string, prefixes []string) error {
+    jobs := make(chan string)
+    docs := make(chan materializedDoc)
+    var wg sync.WaitGroup
+    for i := 0; i < warmupWorkers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for prefix := range jobs {
+                set, _, err := e.fetch(ctx, opts, field, prefix, nil, nil)
+                if err != nil {
+                    slog.Warn("Materialization query failed", "field", field, "prefix", prefix, "error", err)
+                    e.updateMaterialize(func(job *MaterializeJob) { job.Failed++ })
+                    continue
+                }
+                docs <- materializedDoc{Field: field, Prefix: prefix, QueryMode: opts.QueryMode, Limit: opts.SuggestionLimit,
+                    Weighted: opts.WeightBoost > 0, PopularityRanking: opts.PopularityRanking, FoldAccents: opts.FoldAccents,
+                    Suggestions: set.Suggestions, Fuzzy: set.Fuzzy, Matched: set.Matched, KSHCodes: set.KSHCodes, Locations: set.Locations,
+                    Weights: set.Weights, Popularity: set.Popularity, Generation: generation}
+            }
+        }()
+    }
+    writeCtx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    go func() {
+        defer close(jobs)
+        for _, prefix := range prefixes {
+            select {
+            case jobs <- prefix:
+            case <-writeCtx.Done():
+                return
+            }
+        }
+    }()
+    go func() {
+        wg.Wait()
+        close(docs)
+    }()
+
+    var batch []materializedDoc
+    var writeErr error
+    for doc := range docs {
+        if writeErr != nil {
+            continue
+        }
+        batch = append(batch, doc)
+        if len(batch) >= materializeBatchSize {
+            writeErr = e.writeMaterialized(ctx, batch)
+            batch = nil
+            if writeErr != nil {
+                cancel()
+            }
+        }
+    }
+    if writeErr != nil {
+        return writeErr
+    }
+    return e.writeMaterialized(ctx, batch)
+}
+
+// writeMaterialized egyetlen _bulk kérésben írja a dokumentumokat a lookup indexbe.
+func (e *Engine) writeMaterialized(ctx context.Context, docs []materializedDoc) error {
+    if len(docs) == 0 {
+        return nil
+    }
+    var payload bytes.Buffer
+    encoder := json.NewEncoder(&payload)
+    for _, doc := range docs {
+        action := map[string]interface{}{"index": map[string]string{"_id": materializedID(doc.Field, doc.Prefix)}}
+        if err := encoder.Encode(action); err != nil {
+            return err
+        }
+        if err := encoder.Encode(doc); err != nil {
+            return err
+        }
+    }
+    resp, err := e.client.Do(ctx, "POST", "/"+e.LookupIndex()+"/_bulk", payload.Bytes(), "application/x-ndjson")
+    if err != nil {
+        return err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("_bulk kérés sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    var result struct {
+        Errors bool `json:"errors"`
+    }
+    if err := json.Unmarshal(resp.Body, &result); err != nil {
+        return err
+    }
+    if result.Errors {
+        return fmt.Errorf("_bulk kérés részben sikertelen: %s", string(resp.Body))
+    }
+    e.updateMaterialize(func(job *MaterializeJob) { job.Written += len(docs) })
+    return nil
+}
+
+// ensureLookupIndex létrehozza a lookup indexet, ha még nem létezik. A javaslatlistát nem
+// indexeljük, csak _id szerint olvassuk.
+func (e *Engine) ensureLookupIndex(ctx context.Context) error {
+    resp, err := e.client.Do(ctx, "HEAD", "/"+e.LookupIndex(), nil, "")
+    if err != nil {
+        return err
+    }
+    if resp.StatusCode == http.StatusOK {
+        return nil
+    }
+    body, _ := json.Marshal(map[string]interface{}{
+        "settings": map[string]interface{}{"number_of_shards": 1},
+        "mappings": map[string]interface{}{
+            "dynamic": false,
+            "properties": map[string]interface{}{
+                "field":       map[string]string{"type": "keyword"},
+                "prefix":      map[string]string{"type": "keyword"},
+                "query_mode":  map[string]string{"type": "keyword"},
+                "generation":  map[string]string{"type": "long"},
+                "suggestions": map[string]interface{}{"type": "keyword", "index": false},
+            },
+        },
+    })
+    resp, err = e.client.Do(ctx, "PUT", "/"+e.LookupIndex(), body, "application/json")
+    if err != nil {
+        return err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("a lookup index létrehozása sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+    }
+    return nil
+}
+
+// fieldPrefixes a "<field>.keyword" almező összes értékét composite aggregációval lapozva
+// a normalizált, 1..maxLength karakteres egyedi előtagokat adja vissza rendezve.
+func (e *Engine) fieldPrefixes(ctx context.Context, field string, maxLength int) ([]string, error) {
+    seen := map[string]bool{}
+    var after map[string]string
+    for {
+        composite := map[string]interface{}{
+            "size":    compositePageSize,
+            "sources": []interface{}{map[string]interface{}{"value": map[string]interface{}{"terms": map[string]string{"field": field + ".keyword"}}}},
+        }
+        if after != nil {
+            composite["after"] = after
+        }
+        payload, err := json.Marshal(dsl.Search{Size: 0, Aggs: map[string]dsl.Agg{"values": {"composite": composite}}})
+        if err != nil {
+            return nil, err
+        }
+        resp, err := e.search(ctx, payload)
+        if err != nil {
+            return nil, err
+        }
+        if resp.StatusCode != http.StatusOK {
+            return nil, fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+        }
+        var result struct {
+            Aggregations struct {
+                Values struct {
+                    AfterKey map[string]string `json:"after_key"`
+                    Buckets  []struct {
+                        Key map[string]string `json:"key"`
+                    } `json:"buckets"`
+                } `json:"values"`
+            } `json:"aggregations"`
+        }
+        if err := json.Unmarshal(resp.Body, &result); err != nil {
+            return nil, err
+        }
+        for _, bucket := range result.Aggregations.Values.Buckets {
+            runes := []rune(NormalizeQuery(bucket.Key["value"], false))
+            for n := 1; n <= maxLength && n <= len(runes); n++ {
+                if prefix := NormalizeQuery(string(runes[:n]), false); prefix != "" {
+                    seen[prefix] = true
+                }
+            }
+        }
+        values := result.Aggregations.Values
+        if len(values.Buckets) < compositePageSize || values.AfterKey == nil {
+            break
+        }
+        after = values.AfterKey
+    }
+    prefixes := make([]string, 0, len(seen))
+    for prefix := range seen {
+        prefixes = append(prefixes, prefix)
+    }
+    sort.Strings(prefixes)
+    return prefixes, nil
+}
+
+// lookupMaterialized a szűrő nélküli, legfeljebb MaterializedPrefixLength karakteres lekérdezést
+// egyetlen _id szerinti olvasással szolgálja ki a lookup indexből. Ha a kiszolgálás ki van
+// kapcsolva, nincs ilyen dokumentum, vagy az más beállításokkal készült, az ok hamis, és a
+// hívó az aggregációs lekérdezést futtatja. Közelség szerinti kéréseket nem szolgál ki.
+func (e *Engine) lookupMaterialized(ctx context.Context, opts Options, field, query string, filters []dsl.Query, near *index.GeoPoint) (Set, bool) {
+    // A lookup index a stabil indexből készül, a kanári indexre irányított kérést nem szolgálhatja ki.
+    if !opts.MaterializedServe || len(filters) > 0 || near != nil || query == "" || utf8.RuneCountInString(query) > opts.MaterializedPrefixLength ||
+        e.searchIndex(ctx) != e.index {
+        return Set{}, false
+    }
+    resp, err := e.client.Do(ctx, "GET", "/"+e.LookupIndex()+"/_doc/"+url.PathEscape(materializedID(field, query)), nil, "")
+    if err != nil {
+        slog.Debug("Materialized lookup failed", "field", field, "query", query, "error", err)
+        return Set{}, false
+    }
+    if resp.StatusCode != http.StatusOK {
+        return Set{}, false
+    }
+    var result struct {
+        Found  bool            `json:"found"`
+        Source materializedDoc `json:"_source"`
+    }
+    if err := json.Unmarshal(resp.Body, &result); err != nil || !result.Found {
+        return Set{}, false
+    }
+    doc := result.Source
+    if doc.QueryMode != opts.QueryMode || doc.Limit < opts.SuggestionLimit || doc.Weighted != (opts.WeightBoost > 0) ||
+        doc.PopularityRanking != opts.PopularityRanking || doc.FoldAccents != opts.FoldAccents {
+        return Set{}, false
+    }
+    suggestions := doc.Suggestions
+    if len(suggestions) > opts.SuggestionLimit {
+        suggestions = suggestions[:opts.SuggestionLimit]
+    }
+    reqlog.Add(ctx, "materialized", "hit")
+    return Set{Suggestions: suggestions, Fuzzy: doc.Fuzzy, Matched: doc.Matched, KSHCodes: doc.KSHCodes, Locations: doc.Locations,
+        Weights: doc.Weights, Popularity: doc.Popularity}, true
+}
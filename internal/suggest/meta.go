@@ -0,0 +1,88 @@
+package suggest
+
+import (
+    "context"
+    "encoding/json"
+    "sync"
+
+    "autocomplete/internal/reqlog"
+)
+
+// Meta a javaslatkérés futás közben gyűjtött adatai a válasz "meta" blokkjához: a normalizált
+// lekérdezés, a gyorsítótár állapota (hit, miss, stale vagy revalidated), az OpenSearch által
+// mért idő a kérés összes _search hívására összegezve, és az utolsó lekérdezésre illeszkedő
+// dokumentumok száma. A nem mért értékek nil-ek (pl. cache találatnál az upstream adatok).
+type Meta struct {
+    NormalizedQuery string
+    Cache           string
+    UpstreamTookMs  *int64
+    TotalCandidates *int64
+}
+
+type metaKey struct{}
+
+// MetaRecorder a kérés kontextusában gyűjti a Meta adatait; konkurens használatra biztonságos.
+type MetaRecorder struct {
+    mu   sync.Mutex
+    meta Meta
+}
+
+// WithMeta új gyűjtőt rendel a kontextushoz. A gyűjtő nélküli kontextusban a motor nem
+// gyűjt, így a válasz meta blokkja kikapcsolt állapotban nem jár többletmunkával.
+func WithMeta(ctx context.Context) (context.Context, *MetaRecorder) {
+    r := &MetaRecorder{}
+    return context.WithValue(ctx, metaKey{}, r), r
+}
+
+// Meta az eddig gyűjtött adatok másolata.
+func (r *MetaRecorder) Meta() Meta {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    return r.meta
+}
+
+// recordMeta a kontextus gyűjtőjében módosítja az adatokat; gyűjtő nélkül nem csinál semmit.
+func recordMeta(ctx context.Context, update func(*Meta)) {
+    if r, ok := ctx.Value(metaKey{}).(*MetaRecorder); ok {
+        r.mu.Lock()
+        update(&r.meta)
+        r.mu.Unlock()
+    }
+}
+
+// recordCache a gyorsítótár állapotát a kérés naplósorához és a Meta gyűjtőhöz is hozzáadja.
+func recordCache(ctx context.Context, status string) {
+    reqlog.Add(ctx, "cache", status)
+    recordMeta(ctx, func(m *Meta) { m.Cache = status })
+}
+
+// recordSearchMeta a _search válaszából a "took" időt és a találatok számát rögzíti.
+func recordSearchMeta(ctx context.Context, body []byte) {
+    if _, ok := ctx.Value(metaKey{}).(*MetaRecorder); !ok {
+        return
+    }
+    var result struct {
+        Took *int64 `json:"took"`
+        Hits struct {
+            Total *struct {
+                Value int64 `json:"value"`
+            } `json:"total"`
+        } `json:"hits"`
+    }
+    if json.Unmarshal(body, &result) != nil {
+        return
+    }
+    recordMeta(ctx, func(m *Meta) {
+        if result.Took != nil {
+            took := *result.Took
+            if m.UpstreamTookMs != nil {
+                took += *m.UpstreamTookMs
+            }
+            m.UpstreamTookMs = &took
+        }
+        if result.Hits.Total != nil {
+            total := result.Hits.Total.Value
+            m.TotalCandidates = &total
+        }
+    })
+}
@@ -0,0 +1,42 @@
+package suggest
+
+import (
+    "strings"
+    "unicode"
+
+    "golang.org/x/text/transform"
+)
+
+// dashReplacer a kötőjel, nagykötőjel és mínuszjel változatait egyszerű kötőjelre cseréli,
+// így a "Nagy–Kálló" és a "Nagy-Kálló" lekérdezés ugyanaz.
+var dashReplacer = strings.NewReplacer(
+    "‐", "-", "‑", "-", "‒", "-", "–", "-", "—", "-",
+    "―", "-", "−", "-", "﹘", "-", "﹣", "-", "－", "-",
+)
+
+// stripControl elhagyja a vezérlő- és formázókaraktereket (pl. nulla szélességű szóköz); a
+// tabulátor és a sortörés szóközzé válik, amit a szóközök összevonása kezel.
+func stripControl(r rune) rune {
+    switch {
+    case unicode.IsSpace(r):
+        return ' '
+    case unicode.IsControl(r), unicode.Is(unicode.Cf, r):
+        return -1
+    }
+    return r
+}
+
+// NormalizeQuery a lekérdezés egységes alakja, amelyet a gyorsítótár kulcsa és a lekérdezés is
+// használ: vezérlőkarakterek nélkül, egységes kötőjelekkel, a szélső szóközök nélkül, összevont
+// szóközökkel, kisbetűsen. A foldAccents az ékezeteket is elhagyja ("Győr" -> "gyor").
+func NormalizeQuery(query string, foldAccents bool) string {
+    query = dashReplacer.Replace(strings.Map(stripControl, query))
+    query = strings.ToLower(strings.Join(strings.Fields(query), " "))
+    if !foldAccents {
+        return query
+    }
+    if folded, _, err := transform.String(foldTransformer, query); err == nil {
+        query = folded
+    }
+    return query
+}
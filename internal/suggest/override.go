@@ -0,0 +1,33 @@
+package suggest
+
+import "context"
+
+// Override a motor beállításainak kérésszintű felülírása, pl. egy A/B kísérlet változatához
+// (lásd experiment.Variant). Az üres mezők a beállított értéket hagyják érvényben.
+type Override struct {
+    QueryMode string
+    Ranking   Pipeline
+}
+
+type overrideKey struct{}
+
+// WithOverride az o felülírást a kontextushoz rendeli; a motor az ezzel a kontextussal érkező
+// kérésekre alkalmazza. A QueryMode része a gyorsítótár kulcsának, így a változatok egymás
+// javaslatait nem kapják meg.
+func WithOverride(ctx context.Context, o Override) context.Context {
+    return context.WithValue(ctx, overrideKey{}, o)
+}
+
+// requestOptions a kérés beállításai: az Options pillanatkép a kontextus felülírásával (ha van).
+func (e *Engine) requestOptions(ctx context.Context) Options {
+    opts := e.Options()
+    if o, ok := ctx.Value(overrideKey{}).(Override); ok {
+        if o.QueryMode != "" {
+            opts.QueryMode = o.QueryMode
+        }
+        if len(o.Ranking) > 0 {
+            opts.Ranking = o.Ranking
+        }
+    }
+    return opts
+}
@@ -0,0 +1,290 @@
+package suggest
+
+import (
+    "context"
+    "errors"
+    "regexp"
+    "strings"
+
+    "autocomplete/internal/index"
+)
+
+// A felbontott címrészek megbízhatósági szintjei (ParsedComponent.Confidence).
+const (
+    // confidenceExact: az index pontosan ilyen értéket tartalmaz.
+    confidenceExact = 1.0
+    // confidenceNormalized: az index értéke csak kis-nagybetűben, ékezetben vagy rövidítésben tér el.
+    confidenceNormalized = 0.9
+    // confidenceDerived: a bemenetben nem szerepelt, az indexből következik (pl. irányítószám a címből).
+    confidenceDerived = 0.8
+    // confidenceSuggested: az index legjobb javaslata a bemenet alapján.
+    confidenceSuggested = 0.6
+    // confidenceSyntactic: csak a bemenet formája alapján, az index nem erősítette meg.
+    confidenceSyntactic = 0.3
+)
+
+// streetTypes a közterület-jellegek és rövidítéseik (kisbetűvel, a záró pont nélkül).
+var streetTypes = map[string]string{
+    "utca": "utca", "u": "utca", "út": "út", "útja": "útja", "tér": "tér", "tere": "tere",
+    "körút": "körút", "krt": "körút", "sugárút": "sugárút", "sgt": "sugárút", "köz": "köz",
+    "sétány": "sétány", "stny": "sétány", "fasor": "fasor", "rakpart": "rakpart", "rkp": "rakpart",
+    "park": "park", "dűlő": "dűlő", "lépcső": "lépcső", "liget": "liget", "sor": "sor",
+    "lakótelep": "lakótelep", "ltp": "lakótelep", "telep": "telep", "tanya": "tanya",
+    "major": "major", "kert": "kert", "part": "part", "udvar": "udvar", "hrsz": "hrsz",
+}
+
+var (
+    // leadingZipPattern a cím elején álló négyjegyű irányítószám.
+    leadingZipPattern = regexp.MustCompile(`^([1-9][0-9]{3})\b[\s,]*`)
+    // trailingZipPattern a cím végén álló irányítószám (pl. "Budapest, Fő utca 1., 1011").
+    trailingZipPattern = regexp.MustCompile(`[\s,]+([1-9][0-9]{3})$`)
+    // houseNumberSuffixPattern a közterület utáni házszám ("42.", "12/A", "3-5.", "7 b").
+    houseNumberSuffixPattern = regexp.MustCompile(`\s+([0-9]{1,5}(?:\s*-\s*[0-9]{1,5})?\.?(?:\s*[/-]?\s*\pL{1,2}\.?)?)$`)
+)
+
+// ParsedComponent egy címrész értéke és megbízhatósága (0–1, lásd a confidence* szinteket).
+type ParsedComponent struct {
+    Value      string  `json:"value"`
+    Confidence float64 `json:"confidence"`
+}
+
+// ParsedAddress egy szabad szöveges címsor felbontása. A hiányzó részek nil-ek; a KozterNev a
+// közterület teljes neve a jelleggel együtt, ahogyan az indexben szerepel, a KozterJelleg csak
+// a jelleg (pl. "utca"). A Confidence a megtalált részek megbízhatóságának átlaga.
+type ParsedAddress struct {
+    Input        string           `json:"input"`
+    Irsz         *ParsedComponent `json:"irsz,omitempty"`
+    Telepules    *ParsedComponent `json:"telepules,omitempty"`
+    KozterNev    *ParsedComponent `json:"kozter_nev,omitempty"`
+    KozterJelleg *ParsedComponent `json:"kozter_jelleg,omitempty"`
+    Hazszam      *ParsedComponent `json:"hazszam,omitempty"`
+    Confidence   float64          `json:"confidence"`
+}
+
+// addressTokens a címsor formája alapján kinyert részek, az index megkérdezése előtt. A
+// guessedSettlement jelzi, hogy a település vessző nélkül, csak az első szóként lett leválasztva.
+type addressTokens struct {
+    zip, settlement, street, streetType, houseNumber string
+    guessedSettlement                                bool
+}
+
+// tokenizeAddress a címsort a szokásos magyar írásmód szerint bontja fel: elöl (vagy a végén)
+// az irányítószám, majd a település, vesszővel elválasztva a közterület a jellegével, végül a
+// házszám. Vessző nélkül a közterület-jelleg előtti szavakból az első a település. A jelleg
+// rövidítéseit ("u.", "krt.") feloldja.
+func tokenizeAddress(line string) addressTokens {
+    var t addressTokens
+    line = strings.Join(strings.Fields(dashReplacer.Replace(strings.Map(stripControl, line))), " ")
+    if m := leadingZipPattern.FindStringSubmatch(line); m != nil {
+        t.zip = m[1]
+        line = line[len(m[0]):]
+    } else if m := trailingZipPattern.FindStringSubmatch(line); m != nil {
+        t.zip = m[1]
+        line = line[:len(line)-len(m[0])]
+    }
+    line = strings.Trim(line, " ,")
+
+    settlement, rest, hasComma := strings.Cut(line, ",")
+    settlement, rest = strings.TrimSpace(settlement), strings.TrimSpace(rest)
+    if !hasComma {
+        words := strings.Fields(line)
+        typeAt := -1
+        for i, word := range words {
+            if _, ok := streetTypes[strings.ToLower(strings.TrimSuffix(word, "."))]; ok && i > 0 {
+                typeAt = i
+                break
+            }
+        }
+        switch {
+        case typeAt > 1:
+            settlement, rest = words[0], strings.Join(words[1:], " ")
+            t.guessedSettlement = true
+        case typeAt == 1:
+            // Csak közterület és jelleg, település nélkül ("Fő utca 1.").
+            settlement, rest = "", line
+        default:
+            settlement, rest = line, ""
+        }
+    }
+
+    if m := houseNumberSuffixPattern.FindStringSubmatch(rest); m != nil {
+        t.houseNumber = strings.TrimSuffix(strings.TrimSpace(m[1]), ".")
+        rest = strings.TrimSpace(rest[:len(rest)-len(m[0])])
+    }
+    words := strings.Fields(rest)
+    if n := len(words); n > 1 {
+        if streetType, ok := streetTypes[strings.ToLower(strings.TrimSuffix(words[n-1], "."))]; ok {
+            t.streetType = streetType
+            words[n-1] = streetType
+        }
+    }
+    t.settlement = settlement
+    t.street = strings.Join(words, " ")
+    return t
+}
+
+// ParseAddress a szabad szöveges címsort részeire bontja (lásd tokenizeAddress), és a részeket
+// a háttérrendszer adataival egyezteti: a település és a közterület az index pontos, vagy ha
+// ilyen nincs, a legjobban illeszkedő javasolt alakját kapja; hiányzó irányítószámot a
+// címből (házszámmal a közterület-szakaszból) egészít ki. Minden részhez megbízhatóságot ad.
+func ParseAddress(ctx context.Context, s Suggester, line string) (ParsedAddress, error) {
+    t := tokenizeAddress(line)
+    result := ParsedAddress{Input: line}
+
+    if t.settlement != "" {
+        component, err := resolveName(ctx, s, Request{Kind: KindSettlement, Query: t.settlement}, t.settlement)
+        if err != nil {
+            return result, err
+        }
+        result.Telepules = component
+        if t.guessedSettlement && component.Confidence == confidenceSyntactic {
+            // Az első szó nem település: a címsor település nélküli közterület ("Irinyi József u. 42").
+            result.Telepules = nil
+            t.street = t.settlement + " " + t.street
+        }
+    }
+    if result.Telepules == nil && t.zip != "" {
+        if resolver, ok := s.(ZipResolver); ok {
+            settlements, _, err := resolver.ZipSettlements(ctx, t.zip)
+            if err != nil {
+                return result, err
+            }
+            if len(settlements) == 1 {
+                result.Telepules = &ParsedComponent{Value: settlements[0], Confidence: confidenceDerived}
+            }
+        }
+    }
+    telepules := ""
+    if result.Telepules != nil {
+        telepules = result.Telepules.Value
+    }
+
+    if t.street != "" {
+        component, err := resolveName(ctx, s, Request{Kind: KindStreet, Query: t.street, Telepules: telepules}, t.street)
+        if err != nil {
+            return result, err
+        }
+        result.KozterNev = component
+        words := strings.Fields(component.Value)
+        if streetType, ok := streetTypes[strings.ToLower(words[len(words)-1])]; ok && len(words) > 1 {
+            result.KozterJelleg = &ParsedComponent{Value: streetType, Confidence: component.Confidence}
+        } else if t.streetType != "" {
+            result.KozterJelleg = &ParsedComponent{Value: t.streetType, Confidence: confidenceSyntactic}
+        }
+    }
+
+    if t.houseNumber != "" {
+        result.Hazszam = &ParsedComponent{Value: t.houseNumber, Confidence: confidenceSyntactic}
+        if _, err := index.ParseHouseNumber(t.houseNumber); err == nil {
+            result.Hazszam.Confidence = confidenceSuggested
+        }
+    }
+
+    if err := resolveZip(ctx, s, t.zip, &result); err != nil {
+        return result, err
+    }
+
+    var sum float64
+    var n int
+    for _, c := range []*ParsedComponent{result.Irsz, result.Telepules, result.KozterNev, result.Hazszam} {
+        if c != nil {
+            sum += c.Confidence
+            n++
+        }
+    }
+    if n > 0 {
+        result.Confidence = float64(int(sum/float64(n)*100+0.5)) / 100
+    }
+    return result, nil
+}
+
+// resolveName a név (település vagy közterület) indexbeli alakját keresi a javaslatok között:
+// a kis-nagybetűre és ékezetre is egyező javaslat pontos, a csak normalizálva egyező
+// normalizált, egyébként az első javaslat javasolt megbízhatóságú; javaslat nélkül a bemenetet
+// adja vissza szintaktikai megbízhatósággal. Ha a teljes névre nincs javaslat (pl. ékezet
+// nélküli többszavas bemenetnél), az első szavára kapott javaslatokat is megnézi.
+func resolveName(ctx context.Context, s Suggester, req Request, value string) (*ParsedComponent, error) {
+    set, _, err := s.Suggest(ctx, req)
+    if err != nil {
+        return nil, err
+    }
+    if words := strings.Fields(value); len(set.Suggestions) == 0 && len(words) > 1 {
+        req.Query = words[0]
+        if set, _, err = s.Suggest(ctx, req); err != nil {
+            return nil, err
+        }
+        if !containsFolded(set.Suggestions, value) {
+            set.Suggestions = nil
+        }
+    }
+    for _, suggestion := range set.Suggestions {
+        if suggestion == value {
+            return &ParsedComponent{Value: suggestion, Confidence: confidenceExact}, nil
+        }
+    }
+    for _, suggestion := range set.Suggestions {
+        if fold(suggestion) == fold(value) {
+            return &ParsedComponent{Value: suggestion, Confidence: confidenceNormalized}, nil
+        }
+    }
+    if len(set.Suggestions) > 0 && !set.Fuzzy {
+        return &ParsedComponent{Value: set.Suggestions[0], Confidence: confidenceSuggested}, nil
+    }
+    return &ParsedComponent{Value: value, Confidence: confidenceSyntactic}, nil
+}
+
+// containsFolded jelzi, hogy a value normalizált alakja szerepel-e a javaslatok között.
+func containsFolded(suggestions []string, value string) bool {
+    for _, suggestion := range suggestions {
+        if fold(suggestion) == fold(value) {
+            return true
+        }
+    }
+    return false
+}
+
+// resolveZip az irányítószámot a feloldott címmel veti össze: a megadott irányítószám a
+// címhez tartozik-e, illetve hiányzó irányítószámot a közterület-szakaszból (házszámmal) vagy
+// a cím kanonikus alakjából egészít ki. A házszám megbízhatóságát is frissíti.
+func resolveZip(ctx context.Context, s Suggester, zip string, result *ParsedAddress) error {
+    if zip != "" {
+        result.Irsz = &ParsedComponent{Value: zip, Confidence: confidenceSyntactic}
+    }
+    if result.Telepules == nil {
+        return nil
+    }
+    in := AddressInput{Telepules: result.Telepules.Value, Irsz: zip}
+    if result.KozterNev != nil {
+        in.KozterNev = result.KozterNev.Value
+    }
+
+    if validator, ok := s.(HouseNumberValidator); ok && result.Hazszam != nil && in.KozterNev != "" {
+        v, err := validator.ValidateHouseNumber(ctx, HouseNumberInput{Telepules: in.Telepules, KozterNev: in.KozterNev, Hazszam: result.Hazszam.Value})
+        if err != nil && !errors.Is(err, index.ErrInvalidHouseNumber) {
+            return err
+        }
+        if v.Valid {
+            result.Hazszam.Confidence = confidenceExact
+            switch {
+            case zip == "":
+                result.Irsz = &ParsedComponent{Value: v.Irsz, Confidence: confidenceDerived}
+                return nil
+            case zip == v.Irsz:
+                result.Irsz.Confidence = confidenceExact
+                return nil
+            }
+        }
+    }
+
+    v, err := s.Validate(ctx, in)
+    if err != nil {
+        return err
+    }
+    switch {
+    case zip != "" && v.ZipMatches:
+        result.Irsz.Confidence = confidenceExact
+    case zip == "" && v.Valid && v.Canonical != nil && v.Canonical.Irsz != "":
+        result.Irsz = &ParsedComponent{Value: v.Canonical.Irsz, Confidence: confidenceDerived}
+    }
+    return nil
+}
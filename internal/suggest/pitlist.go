@@ -0,0 +1,158 @@
+package suggest
+
+import (
+    "bytes"
+    "context"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "math"
+    "net/http"
+
+    "autocomplete/internal/dsl"
+)
+
+const (
+    // listBatchSize a listavégpontok egy point-in-time lapkérésének dokumentumszáma.
+    listBatchSize = 5000
+    // listPITKeepAlive a listavégpontok pillanatképének élettartama két lapkérés között; a
+    // lejárt pillanatkép helyett a következő lap újat nyit (lásd keyRuns).
+    listPITKeepAlive = "2m"
+)
+
+// ErrInvalidCursor jelzi, hogy a lapozási kurzor (after) nem egy korábbi válasz next értéke.
+var ErrInvalidCursor = errors.New("érvénytelen lapozási kurzor")
+
+// listCursor a listavégpontok átlátszatlan lapozási kurzora: a pillanatkép azonosítója és az
+// előző lap utolsó kulcsa. A lapok mindig kulcshatáron érnek véget, így a folytatáshoz elég a
+// kulcs, és a lejárt pillanatkép helyett nyitott újban is ugyanott folytatódik a lista.
+type listCursor struct {
+    PIT string `json:"pit"`
+    Key string `json:"key"`
+}
+
+func (c listCursor) encode() string {
+    data, _ := json.Marshal(c)
+    return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeListCursor az after paramétert olvassa vissza; üresen az első lap kurzorát adja.
+func decodeListCursor(after string) (listCursor, error) {
+    var c listCursor
+    if after == "" {
+        return c, nil
+    }
+    data, err := base64.RawURLEncoding.DecodeString(after)
+    if err != nil || json.Unmarshal(data, &c) != nil || c.Key == "" {
+        return listCursor{}, ErrInvalidCursor
+    }
+    return c, nil
+}
+
+// keyRun egy kulcs egymást követő dokumentumainak összesítése: a kulcs, a dokumentumok száma és
+// az első nem üres érték (lásd keyRuns).
+type keyRun struct {
+    Key   string
+    Count int
+    Value string
+}
+
+// keyRuns a filterekre illeszkedő dokumentumokat point-in-time pillanatképen, a keyField szerint
+// rendezve, search_after lapozással olvassa, és az egymást követő azonos kulcsokat összesíti:
+// az after kurzor utáni legfeljebb size kulcsot adja vissza, és ha van további, a folytatás
+// kurzorát. A value (ha nem nil) a dokumentumból olvas ki egy kísérő értéket a source mezőkből;
+// a kulcs első nem üres értéke kerül a keyRun.Value-ba. Az aggs az első lapkéréshez fűzött
+// aggregációk, az eredményüket a harmadik visszatérési érték adja.
+//
+// Az utolsó lap lezárja a pillanatképet; a félbehagyott lapozás pillanatképe listPITKeepAlive
+// után magától lejár, a lejárt pillanatkép helyett a következő lap újat nyit.
+func (e *Engine) keyRuns(ctx context.Context, after, keyField string, filters []dsl.Query, source []string, value func(dsl.Hit) string,
+    aggs map[string]dsl.Agg, size int, debugBuffer *bytes.Buffer) ([]keyRun, string, map[string]dsl.AggResult, error) {
+    cursor, err := decodeListCursor(after)
+    if err != nil {
+        return nil, "", nil, err
+    }
+    reopened := cursor.PIT == ""
+    if reopened {
+        if cursor.PIT, err = e.openPIT(ctx, listPITKeepAlive); err != nil {
+            return nil, "", nil, err
+        }
+    }
+    var searchAfter []interface{}
+    if cursor.Key != "" {
+        // A kulcs összes dokumentuma az előző lapon volt, így a _shard_doc legnagyobb értékével
+        // a következő kulcs első dokumentumánál folytatódik; ez új pillanatképben is érvényes.
+        searchAfter = []interface{}{cursor.Key, int64(math.MaxInt64)}
+    }
+    search := dsl.Search{
+        Size:   listBatchSize,
+        Source: source,
+        Query:  dsl.Filter(append([]dsl.Query{dsl.Exists(keyField)}, filters...)...),
+        Sort:   []interface{}{map[string]string{keyField: "asc"}, map[string]string{"_shard_doc": "asc"}},
+        Aggs:   aggs,
+    }
+    if source == nil {
+        search.StoredFields = []string{"_none_"}
+    }
+    var runs []keyRun
+    var aggResults map[string]dsl.AggResult
+    for batch := 0; ; batch++ {
+        search.PIT, search.SearchAfter = &dsl.PIT{ID: cursor.PIT, KeepAlive: listPITKeepAlive}, searchAfter
+        payload, err := json.Marshal(search)
+        if err != nil {
+            return nil, "", nil, err
+        }
+        if batch == 0 {
+            debugBuffer.WriteString("Search Payload JSON: " + string(payload) + "\n")
+        }
+        resp, err := e.client.Do(ctx, "POST", "/_search", payload, "application/json")
+        if err != nil {
+            return nil, "", nil, err
+        }
+        if resp.StatusCode == http.StatusNotFound && !reopened {
+            // A kurzor pillanatképe lejárt: új pillanatképben ugyanattól a kulcstól folytatjuk.
+            debugBuffer.WriteString("A kurzor pillanatképe lejárt, új pillanatkép\n")
+            reopened = true
+            if cursor.PIT, err = e.openPIT(ctx, listPITKeepAlive); err != nil {
+                return nil, "", nil, err
+            }
+            continue
+        }
+        if resp.StatusCode != http.StatusOK {
+            return nil, "", nil, fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+        }
+        var result dsl.SearchResponse
+        if err := json.Unmarshal(resp.Body, &result); err != nil {
+            return nil, "", nil, err
+        }
+        if result.PITID != "" {
+            cursor.PIT = result.PITID
+        }
+        if search.Aggs != nil {
+            aggResults, search.Aggs = result.Aggregations, nil
+        }
+        for _, hit := range result.Hits.Hits {
+            if len(hit.Sort) < 2 {
+                return nil, "", nil, fmt.Errorf("hiányzó rendezési értékek a lista találatában: %v", hit.Sort)
+            }
+            key, _ := hit.Sort[0].(string)
+            if len(runs) == 0 || runs[len(runs)-1].Key != key {
+                if len(runs) == size {
+                    return runs, listCursor{PIT: cursor.PIT, Key: runs[size-1].Key}.encode(), aggResults, nil
+                }
+                runs = append(runs, keyRun{Key: key})
+            }
+            run := &runs[len(runs)-1]
+            run.Count++
+            if run.Value == "" && value != nil {
+                run.Value = value(hit)
+            }
+        }
+        if len(result.Hits.Hits) < listBatchSize {
+            e.closePIT(ctx, cursor.PIT)
+            return runs, "", aggResults, nil
+        }
+        searchAfter = result.Hits.Hits[len(result.Hits.Hits)-1].Sort
+    }
+}
@@ -0,0 +1,182 @@
+package suggest
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "sync"
+    "time"
+
+    "autocomplete/internal/dsl"
+)
+
+// maxPendingSelections a két ürítés között gyűjtött különböző kiválasztások legnagyobb száma;
+// ezen felül a kiválasztásokat eldobjuk, hogy egy elárasztó kliens ne fogyassza el a memóriát.
+const maxPendingSelections = 10000
+
+// ErrSelectionBufferFull jelzi, hogy a kiválasztás nem került rögzítésre, mert a puffer megtelt.
+var ErrSelectionBufferFull = errors.New("a kiválasztási puffer megtelt")
+
+// Selection egy felhasználó által kiválasztott javaslat. A Telepules közterület kiválasztásánál
+// a települést adja meg, így csak annak a településnek az azonos nevű közterülete népszerűbb.
+type Selection struct {
+    Kind      string `json:"kind"`
+    Value     string `json:"value"`
+    Telepules string `json:"telepules,omitempty"`
+}
+
+// popularityField a kiválasztás fajtájához tartozó szöveges mező; a kiválasztások száma a
+// dokumentumok "popularity.<mező>" mezőjébe kerül.
+func popularityField(kind string) (string, error) {
+    switch kind {
+    case "", KindSettlement:
+        return "telepules", nil
+    case KindStreet:
+        return "kozter_nev", nil
+    case KindAddress:
+        return "teljes_cim", nil
+    }
+    return "", fmt.Errorf("%w: %q", ErrUnknownKind, kind)
+}
+
+// selectionKey a pufferben összevont kiválasztások kulcsa.
+type selectionKey struct {
+    field     string
+    value     string
+    telepules string
+}
+
+// selections a még ki nem írt kiválasztások száma kulcsonként. A last a mezőnkénti értékek
+// legutóbbi kiválasztásának ideje a RankRecency lépéshez; legfeljebb maxPendingSelections elemű.
+type selections struct {
+    mu      sync.Mutex
+    pending map[selectionKey]int
+    dropped int
+    last    map[selectionKey]time.Time
+}
+
+// RecordSelection a kiválasztást a memóriában összesíti; az indexbe a FlushSelections írja ki,
+// így egy népszerű település sok kiválasztása is egyetlen _update_by_query kérés.
+func (e *Engine) RecordSelection(ctx context.Context, sel Selection) error {
+    field, err := popularityField(sel.Kind)
+    if err != nil {
+        return err
+    }
+    key := selectionKey{field: field, value: sel.Value}
+    if field == "kozter_nev" {
+        key.telepules = sel.Telepules
+    }
+    e.selections.mu.Lock()
+    defer e.selections.mu.Unlock()
+    e.selections.touch(selectionKey{field: field, value: sel.Value}, time.Now())
+    if e.selections.pending == nil {
+        e.selections.pending = map[selectionKey]int{}
+    }
+    if _, ok := e.selections.pending[key]; !ok && len(e.selections.pending) >= maxPendingSelections {
+        e.selections.dropped++
+        return ErrSelectionBufferFull
+    }
+    e.selections.pending[key]++
+    return nil
+}
+
+// popularityScript a dokumentum "popularity.<field>" számlálóját növeli params.n-nel.
+const popularityScript = `if (ctx._source.popularity == null) { ctx._source.popularity = [:]; }
+ctx._source.popularity[params.field] = (ctx._source.popularity[params.field] ?: 0) + params.n;`
+
+// FlushSelections az összegyűjtött kiválasztásokat kulcsonként egy-egy _update_by_query kéréssel
+// hozzáadja az érintett dokumentumok popularity számlálóihoz. A sikertelenül kiírt kiválasztások
+// elvesznek; az első hibát adja vissza.
+func (e *Engine) FlushSelections(ctx context.Context) error {
+    e.selections.mu.Lock()
+    pending, dropped := e.selections.pending, e.selections.dropped
+    e.selections.pending, e.selections.dropped = nil, 0
+    e.selections.mu.Unlock()
+    if dropped > 0 {
+        slog.Warn("Selections dropped, buffer full", "dropped", dropped)
+    }
+
+    var firstErr error
+    for key, n := range pending {
+        filters := []dsl.Query{dsl.Term(key.field+".keyword", key.value)}
+        if key.telepules != "" {
+            filters = append(filters, dsl.Term("telepules.keyword", key.telepules))
+        }
+        body, _ := json.Marshal(map[string]interface{}{
+            "query": dsl.Filter(filters...),
+            "script": map[string]interface{}{
+                "lang":   "painless",
+                "source": popularityScript,
+                "params": map[string]interface{}{"field": key.field, "n": n},
+            },
+        })
+        resp, err := e.client.Do(ctx, "POST", "/"+e.index+"/_update_by_query?conflicts=proceed", body, "application/json")
+        if err == nil && resp.StatusCode != http.StatusOK {
+            err = fmt.Errorf("_update_by_query sikertelen (%d): %s", resp.StatusCode, string(resp.Body))
+        }
+        if err != nil {
+            slog.Warn("Selection flush failed", "field", key.field, "value", key.value, "count", n, "error", err)
+            if firstErr == nil {
+                firstErr = err
+            }
+        }
+    }
+    if len(pending) > 0 {
+        slog.Debug("Selections flushed", "keys", len(pending))
+    }
+    return firstErr
+}
+
+// touch a kulcs legutóbbi kiválasztását rögzíti. Megtelt táblánál előbb a recencyHalfLife
+// tízszeresénél (ekkor a RankRecency pontszáma már elhanyagolható) régebbieket törli; ha így
+// sincs hely, az új kulcsot nem veszi fel. A hívó tartja a zárat.
+func (s *selections) touch(key selectionKey, now time.Time) {
+    if s.last == nil {
+        s.last = map[selectionKey]time.Time{}
+    }
+    if _, ok := s.last[key]; !ok && len(s.last) >= maxPendingSelections {
+        for k, at := range s.last {
+            if now.Sub(at) > 10*recencyHalfLife {
+                delete(s.last, k)
+            }
+        }
+        if len(s.last) >= maxPendingSelections {
+            return
+        }
+    }
+    s.last[key] = now
+}
+
+// lastSelected a field mező value értékének legutóbbi kiválasztási ideje (nulla, ha nem volt).
+func (e *Engine) lastSelected(field, value string) time.Time {
+    e.selections.mu.Lock()
+    defer e.selections.mu.Unlock()
+    return e.selections.last[selectionKey{field: field, value: value}]
+}
+
+// popularityCounts a withPopularity vödreinek kiválasztásszámát a javaslatokhoz rendeli; nil,
+// ha egyik vödörnek sincs kiválasztása.
+func popularityCounts(values dsl.AggResult) map[string]int {
+    var counts map[string]int
+    for _, bucket := range values.Buckets {
+        if n := bucket.Sub["popularity"].Value; n > 0 {
+            if counts == nil {
+                counts = map[string]int{}
+            }
+            counts[bucket.Key] = int(n)
+        }
+    }
+    return counts
+}
+
+// withPopularity a terms aggregációt a vödrök legnagyobb "popularity.<field>" értéke, azonos
+// népszerűségnél a dokumentumszám szerint rendezi. A számláló nélküli dokumentumok 0-nak számítanak.
+func withPopularity(terms dsl.TermsAgg, field string) dsl.Agg {
+    terms.Order = []map[string]string{{"popularity": "desc"}, {"_count": "desc"}}
+    return dsl.Terms(terms).With(map[string]dsl.Agg{
+        "popularity": {"max": map[string]interface{}{"field": "popularity." + field, "missing": 0}},
+    })
+}
@@ -0,0 +1,66 @@
+package suggest
+
+import (
+    "math"
+
+    "autocomplete/internal/dsl"
+    "autocomplete/internal/index"
+)
+
+// proximityFloor a helyadat nélküli dokumentumok közelségi pontszáma; a gauss függvény
+// távoli településeknél is e fölött marad, így azok a helyadat nélküliek elé kerülnek.
+const proximityFloor = 0.01
+
+// roundGeoPoint a pontot két tizedesjegyre (kb. 1 km) kerekíti, így a közeli helyzetből érkező
+// kérések ugyanazt a gyorsítótár-bejegyzést használják.
+func roundGeoPoint(p *index.GeoPoint) *index.GeoPoint {
+    if p == nil {
+        return nil
+    }
+    round := func(v float64) float64 { return math.Round(v*100) / 100 }
+    return &index.GeoPoint{Lat: round(p.Lat), Lon: round(p.Lon)}
+}
+
+// withProximity a lekérdezést function_score-ba foglalja, amely a dokumentum pontszámát a
+// "location" mező és a near távolsága szerinti gauss lecsengésre cseréli, és a terms
+// aggregációt elsősorban a vödör legnagyobb pontszáma szerint rendezi. A meglévő rendezés
+// (népszerűség, dokumentumszám) azonos közelségnél érvényes.
+func withProximity(query dsl.Query, unique dsl.Agg, near index.GeoPoint, scale string) (dsl.Query, dsl.Agg) {
+    if query == nil {
+        query = dsl.MatchAll()
+    }
+    scored := dsl.Query{"function_score": map[string]interface{}{
+        "query": query,
+        "functions": []map[string]interface{}{
+            {
+                "filter": dsl.Exists("location"),
+                "gauss": map[string]interface{}{
+                    "location": map[string]interface{}{
+                        "origin": map[string]float64{"lat": near.Lat, "lon": near.Lon},
+                        "scale":  scale,
+                    },
+                },
+            },
+            {"weight": proximityFloor},
+        },
+        "score_mode": "max",
+        "boost_mode": "replace",
+    }}
+
+    terms, _ := unique["terms"].(dsl.TermsAgg)
+    order := []map[string]string{{"proximity": "desc"}}
+    if previous, ok := terms.Order.([]map[string]string); ok {
+        order = append(order, previous...)
+    } else {
+        order = append(order, map[string]string{"_count": "desc"})
+    }
+    terms.Order = order
+    unique["terms"] = terms
+    sub, _ := unique["aggs"].(map[string]dsl.Agg)
+    if sub == nil {
+        sub = map[string]dsl.Agg{}
+    }
+    sub["proximity"] = dsl.Agg{"max": map[string]interface{}{"script": map[string]string{"source": "_score"}}}
+    unique["aggs"] = sub
+    return scored, unique
+}
@@ -0,0 +1,127 @@
+package suggest
+
+import (
+    "math"
+    "sort"
+    "strings"
+    "unicode"
+
+    "golang.org/x/text/collate"
+    "golang.org/x/text/language"
+    "golang.org/x/text/runes"
+    "golang.org/x/text/transform"
+    "golang.org/x/text/unicode/norm"
+)
+
+// A javaslatok rendezési módjai (Request.Sort).
+const (
+    // SortRelevance: elöl a lekérdezéssel pontosan egyező, majd az azzal kezdődő javaslatok,
+    // ezeken belül a rövidebbek, azonos hossznál betűrendben. Ha a háttérrendszer rangsora
+    // kiválasztásokat vagy közelséget is tükröz (PopularityRanking, ?lat=&lon=), az egyezési
+    // osztályokon belül az a rangsor marad meg (lásd RankMatchClass), különben a népszerű, illetve a
+    // közeli javaslatokat a rövidebbek kiszorítanák.
+    SortRelevance = "relevance"
+    // SortAlphabetical: magyar betűrend.
+    SortAlphabetical = "alphabetical"
+    // SortPopularity: a háttérrendszer rangsora (kiválasztások, közelség, dokumentumszám).
+    SortPopularity = "popularity"
+)
+
+// IsSortMode jelzi, hogy a mode ismert rendezési mód-e.
+func IsSortMode(mode string) bool {
+    return mode == SortRelevance || mode == SortAlphabetical || mode == SortPopularity
+}
+
+// foldTransformer kisbetűsítés előtt az ékezeteket leválasztja és elhagyja.
+var foldTransformer = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// fold a javaslat és a lekérdezés összevetéséhez használt alak: kisbetűs, ékezet nélküli,
+// összevont szóközökkel; így a "gyor" lekérdezés pontosan egyezik a "Győr" javaslattal.
+func fold(s string) string {
+    return NormalizeQuery(s, true)
+}
+
+// Rank a háttérrendszer által visszaadott javaslatokat a mode szerint rendezi; az üres mode
+// SortRelevance-nek számít. A bemenetet nem módosítja. A rendezés determinisztikus: a
+// betűrendben is egyező javaslatok között a bájtsorrend dönt.
+func Rank(suggestions []string, query, mode string) []string {
+    return RankWeighted(suggestions, query, mode, nil, 0)
+}
+
+// RankWeighted a Rank súlyozott változata: SortRelevance esetén az azonos egyezési osztályú
+// javaslatok között a hossz helyett a hossz és a súly tízes alapú logaritmusa boost-szorosának
+// különbsége dönt, így pl. boost=1 mellett a milliós lakosságú település a nála hat karakterrel
+// rövidebb, súly nélküli javaslatokkal egyenrangú. Nulla boost vagy súlyok nélkül a Rank-kal egyezik.
+func RankWeighted(suggestions []string, query, mode string, weights map[string]int, boost float64) []string {
+    if mode == SortPopularity || len(suggestions) < 2 {
+        return suggestions
+    }
+    ranked := append([]string(nil), suggestions...)
+    collator := collate.New(language.Hungarian, collate.IgnoreCase)
+    alphabetical := func(a, b string) bool {
+        if c := collator.CompareString(a, b); c != 0 {
+            return c < 0
+        }
+        return a < b
+    }
+    if mode == SortAlphabetical {
+        sort.SliceStable(ranked, func(i, j int) bool { return alphabetical(ranked[i], ranked[j]) })
+        return ranked
+    }
+
+    q := fold(query)
+    type key struct {
+        class  int // 0: pontos egyezés, 1: a lekérdezéssel kezdődik, 2: egyéb
+        length float64
+    }
+    keys := make(map[string]key, len(ranked))
+    for _, s := range ranked {
+        f := fold(s)
+        k := key{length: float64(len([]rune(f)))}
+        if w := weights[s]; w > 0 && boost > 0 {
+            k.length -= boost * math.Log10(1+float64(w))
+        }
+        k.class = prefixClass(f, q)
+        keys[s] = k
+    }
+    sort.SliceStable(ranked, func(i, j int) bool {
+        a, b := keys[ranked[i]], keys[ranked[j]]
+        if a.class != b.class {
+            return a.class < b.class
+        }
+        if a.length != b.length {
+            return a.length < b.length
+        }
+        return alphabetical(ranked[i], ranked[j])
+    })
+    return ranked
+}
+
+// RankMatchClass a SortRelevance változata a háttérrendszer rangsorának megtartásával: csak az
+// egyezési osztályok (pontos egyezés, a lekérdezéssel kezdődő, egyéb) szerint rendez, az
+// osztályokon belül a bemeneti sorrend marad. A bemenetet nem módosítja.
+func RankMatchClass(suggestions []string, query string) []string {
+    if len(suggestions) < 2 {
+        return suggestions
+    }
+    q := fold(query)
+    classes := make(map[string]int, len(suggestions))
+    for _, s := range suggestions {
+        classes[s] = prefixClass(fold(s), q)
+    }
+    ranked := append([]string(nil), suggestions...)
+    sort.SliceStable(ranked, func(i, j int) bool { return classes[ranked[i]] < classes[ranked[j]] })
+    return ranked
+}
+
+// prefixClass a javaslat egyezési osztálya a lekérdezéshez (mindkettő fold alakban): 0 pontos
+// egyezésnél, 1 ha a lekérdezéssel kezdődik, egyébként 2.
+func prefixClass(folded, query string) int {
+    switch {
+    case folded == query:
+        return 0
+    case strings.HasPrefix(folded, query):
+        return 1
+    }
+    return 2
+}
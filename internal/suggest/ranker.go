@@ -0,0 +1,282 @@
+package suggest
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+    "unicode/utf8"
+
+    "autocomplete/internal/index"
+)
+
+// A beépített rangsorolási lépések nevei (RANKING_PIPELINE).
+const (
+    // RankPrefix: 1 a lekérdezéssel pontosan egyező, 0,5 az azzal kezdődő javaslatra.
+    RankPrefix = "prefix"
+    // RankLength: a lekérdezés és a javaslat hosszának aránya; a rövidebb javaslat előrébb kerül.
+    RankLength = "length"
+    // RankPosition: a háttérrendszer sorrendje (az első 1, az utolsó közel 0).
+    RankPosition = "position"
+    // RankPopularity: a kiválasztások száma (lásd RecordSelection) logaritmikusan, a jelöltek
+    // legnagyobbjához mérve; csak bekapcsolt POPULARITY_RANKING mellett van adata.
+    RankPopularity = "popularity"
+    // RankWeight: a dokumentumsúly (lakosság vagy címszám) logaritmikusan, a legnagyobbhoz mérve.
+    RankWeight = "weight"
+    // RankGeo: a ?lat=&lon= ponttól való távolság; GEO_SCALE távolságban 0,5.
+    RankGeo = "geo"
+    // RankRecency: a javaslat legutóbbi kiválasztása óta eltelt idő; recencyHalfLife után 0,5.
+    RankRecency = "recency"
+)
+
+// recencyHalfLife a RankRecency felezési ideje.
+const recencyHalfLife = 24 * time.Hour
+
+// Candidate egy rangsorolandó javaslat a lépések által használt adataival.
+type Candidate struct {
+    Value string
+    // Position a javaslat helye a háttérrendszer sorrendjében (0-tól).
+    Position     int
+    Weight       int
+    Popularity   int
+    Location     *index.GeoPoint
+    LastSelected time.Time
+}
+
+// RankContext a rangsorolás kérésszintű adatai: a lekérdezés, a felhasználó helyzete (ha meg
+// van adva), a jelöltek száma és a súlyok, kiválasztásszámok legnagyobb értéke a normáláshoz.
+type RankContext struct {
+    Query         string
+    Near          *index.GeoPoint
+    GeoScaleKm    float64
+    Now           time.Time
+    Candidates    int
+    MaxWeight     int
+    MaxPopularity int
+}
+
+// Ranker egy rangsorolási lépés: a jelölthöz 0 és 1 közötti pontszámot ad, amelyet a Pipeline
+// a lépés szorzójával súlyoz.
+type Ranker interface {
+    Score(rc RankContext, c Candidate) float64
+}
+
+// RankerFunc függvényt Ranker-ként használhatóvá tesz.
+type RankerFunc func(rc RankContext, c Candidate) float64
+
+// Score a függvényt hívja.
+func (f RankerFunc) Score(rc RankContext, c Candidate) float64 {
+    return f(rc, c)
+}
+
+var (
+    rankersMu sync.RWMutex
+    rankers   = map[string]Ranker{
+        RankPrefix:     RankerFunc(prefixScore),
+        RankLength:     RankerFunc(lengthScore),
+        RankPosition:   RankerFunc(positionScore),
+        RankPopularity: RankerFunc(popularityScore),
+        RankWeight:     RankerFunc(weightScore),
+        RankGeo:        RankerFunc(geoScore),
+        RankRecency:    RankerFunc(recencyScore),
+    }
+)
+
+// RegisterRanker új (vagy felülírt) rangsorolási lépést vesz fel name néven, amelyre a
+// RANKING_PIPELINE hivatkozhat. A beágyazó szolgáltatások a konfiguráció betöltése előtt hívják.
+func RegisterRanker(name string, r Ranker) {
+    rankersMu.Lock()
+    defer rankersMu.Unlock()
+    rankers[name] = r
+}
+
+// Stage a rangsorolási folyamat egy lépése a szorzójával.
+type Stage struct {
+    Name   string
+    Factor float64
+    Ranker Ranker
+}
+
+// Pipeline a relevancia szerinti rendezést felváltó rangsorolási folyamat: a jelöltek
+// pontszáma a lépések pontszámának szorzókkal súlyozott összege. Az üres Pipeline helyett a
+// beépített Rank rendez.
+type Pipeline []Stage
+
+// ParsePipeline feldolgozza a vesszővel elválasztott lépés:szorzó listát (RANKING_PIPELINE,
+// pl. "prefix:3,length:1,weight:0.5,geo:2"); szorzó nélkül 1. Ismeretlen lépés vagy negatív
+// szorzó esetén hibát ad.
+func ParsePipeline(spec string) (Pipeline, error) {
+    rankersMu.RLock()
+    defer rankersMu.RUnlock()
+    var pipeline Pipeline
+    for _, item := range strings.Split(spec, ",") {
+        item = strings.TrimSpace(item)
+        if item == "" {
+            continue
+        }
+        name, factorText, hasFactor := strings.Cut(item, ":")
+        name = strings.TrimSpace(name)
+        ranker, ok := rankers[name]
+        if !ok {
+            return nil, fmt.Errorf("ismeretlen rangsorolási lépés: %q", name)
+        }
+        factor := 1.0
+        if hasFactor {
+            f, err := strconv.ParseFloat(strings.TrimSpace(factorText), 64)
+            if err != nil || f < 0 {
+                return nil, fmt.Errorf("érvénytelen szorzó a(z) %q lépésnél: %q", name, factorText)
+            }
+            factor = f
+        }
+        pipeline = append(pipeline, Stage{Name: name, Factor: factor, Ranker: ranker})
+    }
+    return pipeline, nil
+}
+
+// String a folyamat RANKING_PIPELINE alakja.
+func (p Pipeline) String() string {
+    stages := make([]string, len(p))
+    for i, stage := range p {
+        stages[i] = stage.Name + ":" + strconv.FormatFloat(stage.Factor, 'g', -1, 64)
+    }
+    return strings.Join(stages, ",")
+}
+
+// Rank a jelölteket csökkenő pontszám szerint rendezi; azonos pontszámnál a háttérrendszer
+// sorrendje dönt. A javaslatok mellett a pontszámukat is visszaadja.
+func (p Pipeline) Rank(rc RankContext, candidates []Candidate) ([]string, map[string]float64) {
+    rc.Candidates = len(candidates)
+    for _, c := range candidates {
+        rc.MaxWeight = max(rc.MaxWeight, c.Weight)
+        rc.MaxPopularity = max(rc.MaxPopularity, c.Popularity)
+    }
+    scores := make(map[string]float64, len(candidates))
+    for _, c := range candidates {
+        var score float64
+        for _, stage := range p {
+            score += stage.Factor * stage.Ranker.Score(rc, c)
+        }
+        scores[c.Value] = score
+    }
+    ranked := append([]Candidate(nil), candidates...)
+    sort.SliceStable(ranked, func(i, j int) bool {
+        if a, b := scores[ranked[i].Value], scores[ranked[j].Value]; a != b {
+            return a > b
+        }
+        return ranked[i].Position < ranked[j].Position
+    })
+    values := make([]string, len(ranked))
+    for i, c := range ranked {
+        values[i] = c.Value
+    }
+    return values, scores
+}
+
+func prefixScore(rc RankContext, c Candidate) float64 {
+    q, v := fold(rc.Query), fold(c.Value)
+    switch {
+    case v == q:
+        return 1
+    case strings.HasPrefix(v, q):
+        return 0.5
+    }
+    return 0
+}
+
+func lengthScore(rc RankContext, c Candidate) float64 {
+    n := utf8.RuneCountInString(c.Value)
+    if n == 0 {
+        return 0
+    }
+    return math.Min(1, float64(utf8.RuneCountInString(rc.Query))/float64(n))
+}
+
+func positionScore(rc RankContext, c Candidate) float64 {
+    if rc.Candidates == 0 {
+        return 0
+    }
+    return 1 - float64(c.Position)/float64(rc.Candidates)
+}
+
+func popularityScore(rc RankContext, c Candidate) float64 {
+    if rc.MaxPopularity <= 0 {
+        return 0
+    }
+    return math.Log1p(float64(c.Popularity)) / math.Log1p(float64(rc.MaxPopularity))
+}
+
+func weightScore(rc RankContext, c Candidate) float64 {
+    if rc.MaxWeight <= 0 {
+        return 0
+    }
+    return math.Log1p(float64(c.Weight)) / math.Log1p(float64(rc.MaxWeight))
+}
+
+func geoScore(rc RankContext, c Candidate) float64 {
+    if rc.Near == nil || c.Location == nil || rc.GeoScaleKm <= 0 {
+        return 0
+    }
+    km := rc.Near.DistanceMeters(*c.Location) / 1000
+    return math.Exp2(-km / rc.GeoScaleKm)
+}
+
+func recencyScore(rc RankContext, c Candidate) float64 {
+    if c.LastSelected.IsZero() {
+        return 0
+    }
+    return math.Exp2(-float64(rc.Now.Sub(c.LastSelected)) / float64(recencyHalfLife))
+}
+
+// geoScaleKm a GEO_SCALE távolság (pl. "25km", "500m") kilométerben; értelmezhetetlen értéknél 0.
+func geoScaleKm(scale string) float64 {
+    unit := 1.0
+    number := strings.TrimSuffix(scale, "km")
+    if number == scale {
+        number, unit = strings.TrimSuffix(scale, "m"), 0.001
+    }
+    v, err := strconv.ParseFloat(number, 64)
+    if err != nil {
+        return 0
+    }
+    return v * unit
+}
+
+// rank a req.Sort szerint rendezi a set javaslatait: relevancia szerinti rendezésnél a
+// beállított rangsorolási folyamattal (Options.Ranking), ha van, egyébként a RankWeighted-del,
+// illetve ha a háttérrendszer már kiválasztások vagy közelség szerint rangsorolt (req.Near vagy
+// a set népszerűségi adatai), a rangsort megtartó RankMatchClass-szal.
+// A fieldOf a javaslat index mezőjét adja a RankRecency kiválasztásaihoz ("" ha nincs). A
+// folyamat pontszámait debug sorként adja vissza. A kontextus felülírása (lásd WithOverride) a
+// folyamatot is lecserélheti.
+func (e *Engine) rank(ctx context.Context, req Request, set Set, fieldOf func(value string) string) ([]string, string) {
+    opts := e.requestOptions(ctx)
+    relevance := req.Sort == "" || req.Sort == SortRelevance
+    if len(opts.Ranking) == 0 && relevance && (req.Near != nil || len(set.Popularity) > 0) {
+        return RankMatchClass(set.Suggestions, req.Query), ""
+    }
+    if len(opts.Ranking) == 0 || !relevance {
+        return RankWeighted(set.Suggestions, req.Query, req.Sort, set.Weights, opts.WeightBoost), ""
+    }
+    candidates := make([]Candidate, len(set.Suggestions))
+    for i, value := range set.Suggestions {
+        c := Candidate{Value: value, Position: i, Weight: set.Weights[value], Popularity: set.Popularity[value]}
+        if p, ok := set.Locations[value]; ok {
+            c.Location = &p
+        }
+        if field := fieldOf(value); field != "" {
+            c.LastSelected = e.lastSelected(field, value)
+        }
+        candidates[i] = c
+    }
+    rc := RankContext{Query: req.Query, Near: req.Near, GeoScaleKm: geoScaleKm(opts.GeoScale), Now: time.Now()}
+    ranked, scores := opts.Ranking.Rank(rc, candidates)
+    parts := make([]string, len(ranked))
+    for i, value := range ranked {
+        parts[i] = fmt.Sprintf("%q=%.3f", value, scores[value])
+    }
+    return ranked, fmt.Sprintf("Rangsorolás (%s): %s\n", opts.Ranking, strings.Join(parts, ", "))
+}
@@ -0,0 +1,80 @@
+package suggest
+
+import (
+    "sort"
+    "strings"
+
+    "autocomplete/internal/index"
+)
+
+// Section egy fajta (KindSettlement, KindStreet, KindZip) javaslatai a szekcionált keresésben.
+type Section struct {
+    Kind string
+    Set  Set
+}
+
+// SectionHit a szekcionált keresés egy találata a forrásával (a javaslat fajtájával).
+type SectionHit struct {
+    Value    string          `json:"value"`
+    Source   string          `json:"source"`
+    KSHKod   string          `json:"kshKod,omitempty"`
+    Location *index.GeoPoint `json:"location,omitempty"`
+}
+
+// MergeSections a fajtánkénti javaslatokat egy rangsorolt listába fésüli, legfeljebb limit
+// találattal. Elöl a lekérdezéssel pontosan egyező, majd az azzal kezdődő, aztán a valamelyik
+// szavukban vele kezdődő javaslatok állnak; azonos osztályon belül a saját listájukban elfoglalt
+// hely, azonos helyen a sections sorrendje dönt, így a fajták váltakozva, a háttérrendszer
+// rangsorát megtartva kerülnek a listába.
+func MergeSections(query string, sections []Section, limit int) []SectionHit {
+    type ranked struct {
+        hit      SectionHit
+        class    int
+        position int
+        section  int
+    }
+    q := fold(query)
+    var all []ranked
+    for si, section := range sections {
+        for pi, item := range section.Set.Items() {
+            all = append(all, ranked{
+                hit:      SectionHit{Value: item.Value, Source: section.Kind, KSHKod: item.KSHKod, Location: item.Location},
+                class:    matchClass(fold(item.Value), q),
+                position: pi,
+                section:  si,
+            })
+        }
+    }
+    sort.SliceStable(all, func(i, j int) bool {
+        a, b := all[i], all[j]
+        if a.class != b.class {
+            return a.class < b.class
+        }
+        if a.position != b.position {
+            return a.position < b.position
+        }
+        return a.section < b.section
+    })
+    if limit > 0 && len(all) > limit {
+        all = all[:limit]
+    }
+    hits := make([]SectionHit, len(all))
+    for i, r := range all {
+        hits[i] = r.hit
+    }
+    return hits
+}
+
+// matchClass a (fold-olt) javaslat és lekérdezés egyezésének osztálya: 0 pontos egyezés,
+// 1 a lekérdezéssel kezdődik, 2 valamelyik szava kezdődik vele, 3 egyéb (pl. fuzzy találat).
+func matchClass(value, query string) int {
+    switch {
+    case value == query:
+        return 0
+    case strings.HasPrefix(value, query):
+        return 1
+    case strings.Contains(value, " "+query) || strings.Contains(value, "-"+query):
+        return 2
+    }
+    return 3
+}
@@ -0,0 +1,62 @@
+package suggest
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "strings"
+
+    "autocomplete/internal/dsl"
+    "autocomplete/internal/index"
+)
+
+// MaxSettlementPage a településlista egy oldalának legnagyobb mérete.
+const MaxSettlementPage = 1000
+
+// SettlementListItem a településlista egy eleme: a település neve, KSH kódja (ha van) és a
+// rekordjainak száma.
+type SettlementListItem struct {
+    Name    string `json:"name"`
+    KSHKod  string `json:"kshKod,omitempty"`
+    Records int    `json:"records"`
+}
+
+// SettlementPage a településlista egy oldala; a Next a következő oldal átlátszatlan kurzora (az
+// after paraméterként visszaküldendő), az utolsó oldalnál üres.
+type SettlementPage struct {
+    Settlements []SettlementListItem `json:"settlements"`
+    Next        string               `json:"next,omitempty"`
+}
+
+// ListSettlements a településeket a telepules.keyword mező sorrendjében (bájtsorrend, így az
+// ékezetes kezdőbetűk a "Z" után következnek) lapozva adja vissza: az after kurzor utáni
+// legfeljebb size települést, megye megadásakor csak a megye településeit (a megye kis-nagybetű
+// független). A dokumentumokat point-in-time pillanatképen search_after lapozással olvassa (lásd
+// keyRuns), így a lapozás az Exporthoz hasonlóan (amíg a pillanatkép él) a közben indexelt
+// változásoktól független; érvénytelen kurzorra ErrInvalidCursor hibát ad.
+func (e *Engine) ListSettlements(ctx context.Context, megye, after string, size int) (SettlementPage, string, error) {
+    var debugBuffer bytes.Buffer
+    megye = strings.TrimSpace(megye)
+    debugBuffer.WriteString(fmt.Sprintf("Településlista: megye=%q, after=%q, size=%d\n", megye, after, size))
+    var filters []dsl.Query
+    if megye != "" {
+        filters = append(filters, dsl.Term("megye", megye))
+    }
+    kshCode := func(hit dsl.Hit) string {
+        var doc index.AddressDocument
+        if hit.Decode(&doc) != nil {
+            return ""
+        }
+        return doc.KSHKod
+    }
+    runs, next, _, err := e.keyRuns(ctx, after, "telepules.keyword", filters, []string{kshField}, kshCode, nil, size, &debugBuffer)
+    if err != nil {
+        return SettlementPage{}, debugBuffer.String(), err
+    }
+    page := SettlementPage{Settlements: make([]SettlementListItem, 0, len(runs)), Next: next}
+    for _, run := range runs {
+        page.Settlements = append(page.Settlements, SettlementListItem{Name: run.Key, KSHKod: run.Value, Records: run.Count})
+    }
+    debugBuffer.WriteString(fmt.Sprintf("Települések: %d, következő oldal: %q\n", len(page.Settlements), page.Next))
+    return page, debugBuffer.String(), nil
+}
@@ -0,0 +1,90 @@
+package suggest
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/http"
+    "strings"
+
+    "autocomplete/internal/dsl"
+    "autocomplete/internal/index"
+)
+
+// maxSettlementZips a település összesítésében visszaadott irányítószámok legnagyobb száma
+// (Budapestnek is kevesebb van).
+const maxSettlementZips = 1000
+
+// streetCountPrecision a közterületek számlálásának precision_threshold értéke: ennyi
+// közterületig a szám közel pontos, ami minden magyar településre teljesül.
+const streetCountPrecision = 40000
+
+// ErrUnknownSettlement jelzi, hogy a kért település nem szerepel a címlistában.
+var ErrUnknownSettlement = errors.New("ismeretlen település")
+
+// SettlementStats egy település összesítése: a kanonikus neve és megyéje, a különböző
+// közterületek és irányítószámok száma, az irányítószámok növekvő sorrendben, valamint a
+// település rekordjainak száma.
+type SettlementStats struct {
+    Settlement string   `json:"settlement"`
+    Megye      string   `json:"megye,omitempty"`
+    Streets    int      `json:"streets"`
+    ZipCodes   int      `json:"zipCodes"`
+    Zips       []string `json:"zips"`
+    Records    int      `json:"records"`
+}
+
+// SettlementStats a name településre (kis-nagybetű függetlenül, egyébként pontos egyezéssel)
+// egyetlen lekérdezés al-aggregációival számolja a közterületeket és az irányítószámokat. Ha a
+// település nincs az indexben, ErrUnknownSettlement hibát ad.
+func (e *Engine) SettlementStats(ctx context.Context, name string) (SettlementStats, string, error) {
+    var debugBuffer bytes.Buffer
+    name = strings.TrimSpace(name)
+    debugBuffer.WriteString(fmt.Sprintf("Település összesítés: %q\n", name))
+    search := dsl.Search{
+        Size:           1,
+        Source:         []string{"telepules", "megye"},
+        TrackTotalHits: true,
+        Query:          dsl.Filter(exactFilter("telepules.keyword", name, true)),
+        Aggs: map[string]dsl.Agg{
+            "streets": dsl.CardinalityOf(dsl.CardinalityAgg{Field: "kozter_nev.keyword", PrecisionThreshold: streetCountPrecision}),
+            "zips":    dsl.Terms(dsl.TermsAgg{Field: "irsz", Size: maxSettlementZips, Order: map[string]string{"_key": "asc"}}),
+        },
+    }
+    body, err := json.Marshal(search)
+    if err != nil {
+        return SettlementStats{}, debugBuffer.String(), err
+    }
+    debugBuffer.WriteString("Aggregation Payload JSON: " + string(body) + "\n")
+    resp, err := e.search(ctx, body)
+    if err != nil {
+        return SettlementStats{}, debugBuffer.String(), err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return SettlementStats{}, debugBuffer.String(), fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+    }
+    var parsed dsl.SearchResponse
+    if err := json.Unmarshal(resp.Body, &parsed); err != nil {
+        return SettlementStats{}, debugBuffer.String(), err
+    }
+    if parsed.Hits.Total.Value == 0 || len(parsed.Hits.Hits) == 0 {
+        return SettlementStats{}, debugBuffer.String(), ErrUnknownSettlement
+    }
+    var doc index.AddressDocument
+    if err := parsed.Hits.Hits[0].Decode(&doc); err != nil {
+        return SettlementStats{}, debugBuffer.String(), err
+    }
+    zips := parsed.Aggregations["zips"].Keys()
+    stats := SettlementStats{
+        Settlement: doc.Telepules,
+        Megye:      doc.Megye,
+        Streets:    int(parsed.Aggregations["streets"].Value),
+        ZipCodes:   len(zips),
+        Zips:       zips,
+        Records:    parsed.Hits.Total.Value,
+    }
+    debugBuffer.WriteString(fmt.Sprintf("Közterületek: %d, irányítószámok: %d, rekordok: %d\n", stats.Streets, stats.ZipCodes, stats.Records))
+    return stats, debugBuffer.String(), nil
+}
@@ -0,0 +1,77 @@
+package suggest
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+
+    "autocomplete/internal/dsl"
+)
+
+// SpellingSuggestion egy javított településnév-alternatíva a suggester pontszámával
+// és az indexbeli előfordulások számával.
+type SpellingSuggestion struct {
+    Text  string  `json:"text"`
+    Score float64 `json:"score"`
+    Freq  int     `json:"freq"`
+}
+
+// Spelling term suggestert futtat a "telepules.keyword" mezőn, így egy teljes, de elgépelt
+// településnévre a legfeljebb két szerkesztési lépésre lévő indexbeli neveket adja vissza.
+// Ha a név pontosan szerepel az indexben, nem ad javaslatot.
+func (e *Engine) Spelling(ctx context.Context, query string) ([]SpellingSuggestion, string, error) {
+    var debugBuffer bytes.Buffer
+    debugBuffer.WriteString(fmt.Sprintf("Helyesírási javaslatkérés: %q\n", query))
+
+    payload := dsl.Search{
+        Size: 0,
+        Suggest: map[string]interface{}{
+            "spelling": map[string]interface{}{
+                "text": query,
+                "term": map[string]interface{}{
+                    "field":           "telepules.keyword",
+                    "suggest_mode":    "missing",
+                    "max_edits":       2,
+                    "prefix_length":   0,
+                    "min_word_length": 2,
+                    "size":            e.Options().SuggestionLimit,
+                    "sort":            "score",
+                },
+            },
+        },
+    }
+    payloadBytes, err := json.Marshal(payload)
+    if err != nil {
+        return nil, debugBuffer.String(), err
+    }
+    debugBuffer.WriteString("Suggest Payload JSON: " + string(payloadBytes) + "\n")
+
+    resp, err := e.search(ctx, payloadBytes)
+    if err != nil {
+        debugBuffer.WriteString(fmt.Sprintf("Hiba az OpenSearch lekérdezés végrehajtásakor: %v\n", err))
+        return nil, debugBuffer.String(), err
+    }
+    debugBuffer.WriteString(fmt.Sprintf("OpenSearch válasz státusza: %d\n", resp.StatusCode))
+    debugBuffer.WriteString("Válasz body: " + string(resp.Body) + "\n")
+    if resp.StatusCode != http.StatusOK {
+        return nil, debugBuffer.String(), fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+    }
+
+    var result struct {
+        Suggest map[string][]struct {
+            Options []SpellingSuggestion `json:"options"`
+        } `json:"suggest"`
+    }
+    if err := json.Unmarshal(resp.Body, &result); err != nil {
+        debugBuffer.WriteString(fmt.Sprintf("Hiba a válasz JSON dekódolásakor: %v\n", err))
+        return nil, debugBuffer.String(), err
+    }
+    suggestions := []SpellingSuggestion{}
+    for _, entry := range result.Suggest["spelling"] {
+        suggestions = append(suggestions, entry.Options...)
+    }
+    debugBuffer.WriteString(fmt.Sprintf("Visszaadott javaslatok: %v\n", suggestions))
+    return suggestions, debugBuffer.String(), nil
+}
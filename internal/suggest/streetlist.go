@@ -0,0 +1,68 @@
+package suggest
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "strings"
+
+    "autocomplete/internal/dsl"
+    "autocomplete/internal/index"
+)
+
+// MaxStreetPage a közterületlista egy oldalának legnagyobb mérete.
+const MaxStreetPage = 1000
+
+// StreetListItem a közterületlista egy eleme: a közterület neve és a rekordjainak száma.
+type StreetListItem struct {
+    Name    string `json:"name"`
+    Records int    `json:"records"`
+}
+
+// StreetPage egy település közterületlistájának egy oldala: a település kanonikus neve, a lap
+// közterületei és a következő oldal átlátszatlan kurzora (az utolsó oldalnál üres).
+type StreetPage struct {
+    Settlement string           `json:"settlement"`
+    Streets    []StreetListItem `json:"streets"`
+    Next       string           `json:"next,omitempty"`
+}
+
+// ListStreets a name település (kis-nagybetű függetlenül illesztve) közterületeit a
+// kozter_nev.keyword mező sorrendjében, point-in-time pillanatképen search_after lapozással
+// (lásd keyRuns) adja vissza: az after kurzor utáni legfeljebb size közterületet, prefix
+// megadásakor csak az azzal (kis-nagybetű függetlenül) kezdődőket. A település létezését a
+// szűrőktől független global aggregáció ellenőrzi a lap első kérésében; ismeretlen településnél
+// ErrUnknownSettlement, érvénytelen kurzornál ErrInvalidCursor hibát ad.
+func (e *Engine) ListStreets(ctx context.Context, name, prefix, after string, size int) (StreetPage, string, error) {
+    var debugBuffer bytes.Buffer
+    name, prefix = strings.TrimSpace(name), strings.TrimSpace(prefix)
+    debugBuffer.WriteString(fmt.Sprintf("Közterületlista: %q, prefix=%q, after=%q, size=%d\n", name, prefix, after, size))
+    settlement := exactFilter("telepules.keyword", name, true)
+    filters := []dsl.Query{settlement}
+    if prefix != "" {
+        filters = append(filters, dsl.PrefixCaseInsensitive("kozter_nev.keyword", prefix))
+    }
+    aggs := map[string]dsl.Agg{
+        "settlement": dsl.Global().With(map[string]dsl.Agg{
+            "docs": dsl.FilterAgg(settlement).With(map[string]dsl.Agg{"best": dsl.TopHits(1, "telepules")}),
+        }),
+    }
+    runs, next, aggResults, err := e.keyRuns(ctx, after, "kozter_nev.keyword", filters, nil, nil, aggs, size, &debugBuffer)
+    if err != nil {
+        return StreetPage{}, debugBuffer.String(), err
+    }
+    hits := aggResults["settlement"].Sub["docs"].Sub["best"].Hits.Hits
+    if len(hits) == 0 {
+        return StreetPage{}, debugBuffer.String(), ErrUnknownSettlement
+    }
+    var doc index.AddressDocument
+    if err := hits[0].Decode(&doc); err != nil {
+        return StreetPage{}, debugBuffer.String(), err
+    }
+    page := StreetPage{Settlement: doc.Telepules, Streets: make([]StreetListItem, 0, len(runs)), Next: next}
+    for _, run := range runs {
+        page.Streets = append(page.Streets, StreetListItem{Name: run.Key, Records: run.Count})
+    }
+    debugBuffer.WriteString(fmt.Sprintf("Közterületek: %d, következő oldal: %q\n", len(page.Streets), page.Next))
+    return page, debugBuffer.String(), nil
+}
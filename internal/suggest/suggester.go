@@ -0,0 +1,188 @@
+package suggest
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/http"
+    "time"
+
+    "autocomplete/internal/index"
+)
+
+// A javaslat fajtái (Request.Kind).
+const (
+    KindSettlement = "settlement"
+    KindStreet     = "street"
+    KindAddress    = "address"
+    KindZip        = "zip"
+)
+
+// ErrUnknownKind jelzi, hogy a háttérrendszer nem ismeri a kért javaslatfajtát.
+var ErrUnknownKind = errors.New("ismeretlen javaslatfajta")
+
+// Request egy javaslatkérés. A Megye csak településjavaslatnál, a Telepules csak
+// közterület-javaslatnál szűkít; üres Kind esetén településjavaslatot kérünk. A Near
+// (ha nem nil) a felhasználó helyzete: a hozzá közeli települések előrébb kerülnek (ezt a
+// helyadatokat indexelő OpenSearch háttérrendszer veszi figyelembe). A Sort a javaslatok
+// rendezési módja (lásd Rank); üresen SortRelevance.
+type Request struct {
+    Kind      string
+    Query     string
+    Megye     string
+    Telepules string
+    Near      *index.GeoPoint
+    Sort      string
+}
+
+// Suggester a javaslatokat kiszolgáló háttérrendszer. A HTTP kezelők csak ezen keresztül
+// kérdeznek, így az OpenSearch Engine mellé más megvalósítás (memóriabeli, SQLite,
+// Meilisearch) is beköthető. A Suggest a javaslatok mellett a lépéseket leíró debug
+// szöveget is visszaadja; a Health nil-t ad, ha a háttérrendszer kérések fogadására kész.
+type Suggester interface {
+    Suggest(ctx context.Context, req Request) (Set, string, error)
+    Validate(ctx context.Context, in AddressInput) (AddressValidation, error)
+    Health(ctx context.Context) error
+}
+
+// A Suggester opcionális képességei: a kezelők típusellenőrzéssel kérdezik le, és ha a
+// háttérrendszer nem valósítja meg, a végpont 501-es hibát ad.
+type (
+    // ZipResolver az irányítószámhoz tartozó településeket adja vissza.
+    ZipResolver interface {
+        ZipSettlements(ctx context.Context, zip string) ([]string, string, error)
+    }
+    // SpellChecker elgépelt településnevekre ad javított alternatívákat.
+    SpellChecker interface {
+        Spelling(ctx context.Context, query string) ([]SpellingSuggestion, string, error)
+    }
+    // BatchValidator egyetlen háttérkérésben ellenőriz több címet.
+    BatchValidator interface {
+        ValidateBatch(ctx context.Context, inputs []AddressInput) ([]BatchValidation, error)
+    }
+    // CacheFlusher kiüríti a háttérrendszer javaslat-gyorsítótárát.
+    CacheFlusher interface {
+        FlushCache() int
+    }
+    // CacheWarmer induláskor előre kiszámolja a gyakori rövid prefixek javaslatait.
+    CacheWarmer interface {
+        WarmCache(ctx context.Context, prefixes []string) (WarmupSummary, error)
+    }
+    // SelectionRecorder rögzíti a felhasználók által kiválasztott javaslatokat a népszerűség
+    // szerinti rangsoroláshoz.
+    SelectionRecorder interface {
+        RecordSelection(ctx context.Context, sel Selection) error
+    }
+    // Materializer a rövid prefixek javaslatait előre kiszámolja egy lookup indexbe.
+    Materializer interface {
+        StartMaterialize() (*MaterializeJob, error)
+        CurrentMaterialize() *MaterializeJob
+    }
+    // HouseNumberValidator a házszámot is ellenőrzi a közterület-szakaszok házszámtartományai alapján.
+    HouseNumberValidator interface {
+        ValidateHouseNumber(ctx context.Context, in HouseNumberInput) (HouseNumberValidation, error)
+    }
+    // Geocoder a cím koordinátáit, illetve egy ponthoz a legközelebbi címet adja vissza.
+    Geocoder interface {
+        Geocode(ctx context.Context, in AddressInput) (Geocode, error)
+        ReverseGeocode(ctx context.Context, p index.GeoPoint) (ReverseGeocode, error)
+    }
+    // FieldSuggester egyetlen kérésben több mezőn keres (lásd Field), és a javaslatok
+    // forrásmezőjét a Set.Sources-ban adja vissza.
+    FieldSuggester interface {
+        SuggestFields(ctx context.Context, req Request, fields []Field) (Set, string, error)
+    }
+    // SettlementStatsProvider egy település közterületeinek és irányítószámainak számát adja vissza;
+    // ismeretlen településnél ErrUnknownSettlement hibát.
+    SettlementStatsProvider interface {
+        SettlementStats(ctx context.Context, name string) (SettlementStats, string, error)
+    }
+    // SettlementLister a települések teljes listáját adja vissza lapozva, opcionálisan egy megyére
+    // szűkítve (lásd SettlementPage).
+    SettlementLister interface {
+        ListSettlements(ctx context.Context, megye, after string, size int) (SettlementPage, string, error)
+    }
+    // StreetLister egy település közterületeit adja vissza lapozva, opcionálisan előtagra szűrve
+    // (lásd StreetPage); ismeretlen településnél ErrUnknownSettlement hibát.
+    StreetLister interface {
+        ListStreets(ctx context.Context, name, prefix, after string, size int) (StreetPage, string, error)
+    }
+    // Exporter a teljes egyedi település- (és közterület-) listát adja vissza ellenőrzéshez.
+    Exporter interface {
+        Export(ctx context.Context, streets bool, fn func(ExportRow) error) error
+    }
+)
+
+var (
+    _ Suggester               = (*Engine)(nil)
+    _ ZipResolver             = (*Engine)(nil)
+    _ SpellChecker            = (*Engine)(nil)
+    _ BatchValidator          = (*Engine)(nil)
+    _ CacheFlusher            = (*Engine)(nil)
+    _ CacheWarmer             = (*Engine)(nil)
+    _ Materializer            = (*Engine)(nil)
+    _ Exporter                = (*Engine)(nil)
+    _ HouseNumberValidator    = (*Engine)(nil)
+    _ Geocoder                = (*Engine)(nil)
+    _ SelectionRecorder       = (*Engine)(nil)
+    _ FieldSuggester          = (*Engine)(nil)
+    _ SettlementStatsProvider = (*Engine)(nil)
+    _ SettlementLister        = (*Engine)(nil)
+    _ StreetLister            = (*Engine)(nil)
+)
+
+// Suggest a kérés fajtája szerinti lekérdezést futtatja, és a javaslatokat a req.Sort szerint
+// rendezi (relevanciánál a beállított rangsorolási folyamattal, lásd Options.Ranking). A
+// gyorsítótárban a rendezés előtti javaslatok vannak, így minden mód ugyanazt használja. A kérést
+// kanári index (Options.CanaryIndex) mellett a forgalom beállított része kapja (lásd routeCanary).
+func (e *Engine) Suggest(ctx context.Context, req Request) (Set, string, error) {
+    ctx = e.routeCanary(ctx)
+    start := time.Now()
+    set, debugInfo, err := e.suggest(ctx, req)
+    e.recordRoute(ctx, set, err, time.Since(start))
+    return set, e.routeDebug(ctx) + debugInfo, err
+}
+
+// suggest a Suggest a már irányított kontextussal.
+func (e *Engine) suggest(ctx context.Context, req Request) (Set, string, error) {
+    var set Set
+    var debugInfo string
+    var err error
+    switch req.Kind {
+    case "", KindSettlement:
+        set, debugInfo, err = e.Settlements(ctx, req.Query, req.Megye, roundGeoPoint(req.Near))
+    case KindStreet:
+        set, debugInfo, err = e.Streets(ctx, req.Query, req.Telepules, roundGeoPoint(req.Near))
+    case KindAddress:
+        set, debugInfo, err = e.Addresses(ctx, req.Query, roundGeoPoint(req.Near))
+    case KindZip:
+        set.Suggestions, debugInfo, err = e.Zips(ctx, req.Query)
+    default:
+        return Set{}, "", fmt.Errorf("%w: %q", ErrUnknownKind, req.Kind)
+    }
+    if err != nil {
+        return set, debugInfo, err
+    }
+    field, _ := popularityField(req.Kind)
+    if req.Kind == KindZip {
+        field = ""
+    }
+    var rankDebug string
+    set.Suggestions, rankDebug = e.rank(ctx, req, set, func(string) string { return field })
+    return set, debugInfo + rankDebug, nil
+}
+
+// Health ellenőrzi, hogy az index (vagy alias) elérhető-e az OpenSearch-ben.
+func (e *Engine) Health(ctx context.Context) error {
+    resp, err := e.client.Do(ctx, "HEAD", "/"+e.index, nil, "")
+    if err != nil {
+        return err
+    }
+    switch resp.StatusCode {
+    case http.StatusOK:
+        return nil
+    case http.StatusNotFound:
+        return fmt.Errorf("az index nem létezik (%s)", e.index)
+    }
+    return fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+}
@@ -0,0 +1,192 @@
+package suggest
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strings"
+
+    "autocomplete/internal/dsl"
+    "autocomplete/internal/index"
+    "autocomplete/internal/reqlog"
+)
+
+// AddressInput egy ellenőrizendő cím.
+type AddressInput struct {
+    Telepules string `json:"telepules"`
+    KozterNev string `json:"kozter_nev,omitempty"`
+    Irsz      string `json:"irsz,omitempty"`
+}
+
+// AddressValidation egy cím ellenőrzésének eredménye. A Valid akkor igaz, ha a település
+// létezik, és a megadott közterület és irányítószám is ugyanahhoz a településhez tartozik
+// (a meg nem adott részeket nem ellenőrizzük). A Canonical az egyező rekord indexbeli alakja,
+// a település KSH kódjával (ksh_kod), ha az indexben meg van adva.
+type AddressValidation struct {
+    Valid           bool                   `json:"valid"`
+    SettlementFound bool                   `json:"settlementFound"`
+    StreetFound     bool                   `json:"streetFound,omitempty"`
+    ZipMatches      bool                   `json:"zipMatches,omitempty"`
+    Canonical       *index.AddressDocument `json:"canonical,omitempty"`
+}
+
+// BatchValidation a kötegelt ellenőrzés egy sora; ha az Err nem nil, a Result üres.
+type BatchValidation struct {
+    Input  AddressInput
+    Result AddressValidation
+    Err    error
+}
+
+// validationSearch egy cím ellenőrző lekérdezése és a kiértékeléséhez szükséges adatok.
+type validationSearch struct {
+    search   dsl.Search
+    combined bool
+}
+
+// exactFilter pontos egyezést követelő term szűrő; ignoreCase esetén kis-nagybetű függetlenül.
+func exactFilter(field, value string, ignoreCase bool) dsl.Query {
+    if ignoreCase {
+        return dsl.TermCaseInsensitive(field, value)
+    }
+    return dsl.Term(field, value)
+}
+
+// buildValidationSearch összeállítja a cím ellenőrző lekérdezését: a település szerepel-e az
+// indexben, és a szűrő aggregációkkal azt is, hogy a közterület, az irányítószám, illetve a
+// kettő együtt előfordul-e nála. Az összevetés a keyword mezőkön pontos egyezéssel történik.
+// A "best" top_hits adja a kanonikus alakot az összes feltételnek megfelelő rekordból.
+func buildValidationSearch(in AddressInput, ignoreCase bool) validationSearch {
+    telepules, kozterNev, irsz := strings.TrimSpace(in.Telepules), strings.TrimSpace(in.KozterNev), strings.TrimSpace(in.Irsz)
+    source := []string{"telepules", "kozter_nev", "irsz", "megye", kshField}
+
+    var combined []dsl.Query
+    aggs := map[string]dsl.Agg{}
+    if kozterNev != "" {
+        combined = append(combined, exactFilter("kozter_nev.keyword", kozterNev, ignoreCase))
+        aggs["street"] = dsl.FilterAgg(exactFilter("kozter_nev.keyword", kozterNev, ignoreCase))
+    }
+    if irsz != "" {
+        combined = append(combined, dsl.Term("irsz", irsz))
+        aggs["zip"] = dsl.FilterAgg(dsl.Term("irsz", irsz))
+    }
+    if len(combined) > 0 {
+        aggs["all"] = dsl.FilterAgg(dsl.Filter(combined...)).With(map[string]dsl.Agg{"best": dsl.TopHits(1, source...)})
+    }
+    search := dsl.Search{
+        Size:           1,
+        Source:         source,
+        TrackTotalHits: 1,
+        Query:          dsl.Filter(exactFilter("telepules.keyword", telepules, ignoreCase)),
+    }
+    if len(aggs) > 0 {
+        search.Aggs = aggs
+    }
+    return validationSearch{search: search, combined: len(combined) > 0}
+}
+
+// result kiértékeli a választ; a kanonikus alak csak érvényes címnél, vagy feltételek nélküli
+// lekérdezésnél a település első rekordjából kerül kitöltésre.
+func (s validationSearch) result(parsed dsl.SearchResponse) AddressValidation {
+    var result AddressValidation
+    result.SettlementFound = parsed.Hits.Total.Value > 0
+    result.StreetFound = parsed.Aggregations["street"].DocCount > 0
+    result.ZipMatches = parsed.Aggregations["zip"].DocCount > 0
+    result.Valid = result.SettlementFound && (!s.combined || parsed.Aggregations["all"].DocCount > 0)
+    hits := parsed.Hits.Hits
+    if s.combined {
+        hits = parsed.Aggregations["all"].Sub["best"].Hits.Hits
+    }
+    var canonical index.AddressDocument
+    if result.Valid && len(hits) > 0 && hits[0].Decode(&canonical) == nil {
+        if !s.combined {
+            // Csak a település volt megadva: a rekord többi része nem a bemenet kanonikus alakja.
+            canonical = index.AddressDocument{Telepules: canonical.Telepules, Megye: canonical.Megye, KSHKod: canonical.KSHKod}
+        }
+        result.Canonical = &canonical
+    }
+    return result
+}
+
+// Validate egyetlen lekérdezéssel, pontos egyezéssel ellenőrzi a címet (lásd buildValidationSearch).
+func (e *Engine) Validate(ctx context.Context, in AddressInput) (AddressValidation, error) {
+    search := buildValidationSearch(in, false)
+    body, err := json.Marshal(search.search)
+    if err != nil {
+        return AddressValidation{}, err
+    }
+    resp, err := e.search(ctx, body)
+    if err != nil {
+        return AddressValidation{}, err
+    }
+    if resp.StatusCode != http.StatusOK {
+        return AddressValidation{}, fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+    }
+    var parsed dsl.SearchResponse
+    if err := json.Unmarshal(resp.Body, &parsed); err != nil {
+        return AddressValidation{}, err
+    }
+    return search.result(parsed), nil
+}
+
+// ValidateBatch a címeket egyetlen _msearch kéréssel ellenőrzi, kis-nagybetű függetlenül, hogy
+// a régi exportok eltérő írásmódja is a kanonikus alakra feloldható legyen. Az egyes sorok
+// hibái (pl. egy shard hiba) csak az adott sort érintik; a sorok a bemenet sorrendjében jönnek.
+func (e *Engine) ValidateBatch(ctx context.Context, inputs []AddressInput) ([]BatchValidation, error) {
+    searches := make([]validationSearch, len(inputs))
+    var body bytes.Buffer
+    header, _ := json.Marshal(map[string]string{"index": e.index})
+    for i, in := range inputs {
+        searches[i] = buildValidationSearch(in, true)
+        line, err := json.Marshal(searches[i].search)
+        if err != nil {
+            return nil, err
+        }
+        body.Write(header)
+        body.WriteByte('\n')
+        body.Write(line)
+        body.WriteByte('\n')
+    }
+    resp, err := e.client.Do(ctx, "POST", "/_msearch", body.Bytes(), "application/x-ndjson")
+    if err != nil {
+        return nil, err
+    }
+    reqlog.Add(ctx, "upstream_status", resp.StatusCode)
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("OpenSearch hiba (%d)", resp.StatusCode)
+    }
+    var parsed struct {
+        Responses []json.RawMessage `json:"responses"`
+    }
+    if err := json.Unmarshal(resp.Body, &parsed); err != nil {
+        return nil, err
+    }
+    if len(parsed.Responses) != len(inputs) {
+        return nil, fmt.Errorf("az _msearch %d választ adott %d kérésre", len(parsed.Responses), len(inputs))
+    }
+    results := make([]BatchValidation, len(inputs))
+    for i, raw := range parsed.Responses {
+        results[i].Input = inputs[i]
+        var item struct {
+            Status int             `json:"status"`
+            Error  json.RawMessage `json:"error"`
+        }
+        var search dsl.SearchResponse
+        err := json.Unmarshal(raw, &item)
+        if err == nil && len(item.Error) > 0 {
+            err = fmt.Errorf("OpenSearch hiba (%d): %s", item.Status, item.Error)
+        }
+        if err == nil {
+            err = json.Unmarshal(raw, &search)
+        }
+        if err != nil {
+            slog.Warn("Batch validation row failed", "request_id", reqlog.RequestID(ctx), "row", i, "error", err)
+            results[i].Err = err
+            continue
+        }
+        results[i].Result = searches[i].result(search)
+    }
+    return results, nil
+}
@@ -0,0 +1,106 @@
+package suggest
+
+import (
+    "bytes"
+    "context"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "autocomplete/internal/dsl"
+)
+
+// warmupWorkers a cache előmelegítéskor párhuzamosan futó lekérdezések száma; kicsi, hogy
+// induláskor ne terhelje túl a fürtöt.
+const warmupWorkers = 4
+
+// warmupSettlementLimit a prefixek kinyeréséhez lekért településnevek legnagyobb száma.
+const warmupSettlementLimit = 10000
+
+// WarmupSummary a cache előmelegítés eredménye.
+type WarmupSummary struct {
+    Prefixes int
+    Queries  int
+    Failed   int
+    Duration time.Duration
+}
+
+// WarmCache a prefixek település- és teljes cím javaslatait (szűrő nélkül) a gyorsítótárba tölti,
+// ugyanazokkal a kulcsokkal, mint a Suggest. Ha a prefixes üres, az index településneveinek
+// 1–2 karakteres előtagjait használja (lásd WarmupPrefixes). A teljes cím a településnévvel
+// kezdődik, így ugyanazok a prefixek mindkét mezőre érvényesek. Az egyes lekérdezések hibáit
+// csak számolja; hibát csak akkor ad, ha a prefixek nem kérdezhetők le, vagy a ctx lezárul.
+func (e *Engine) WarmCache(ctx context.Context, prefixes []string) (WarmupSummary, error) {
+    start := time.Now()
+    if len(prefixes) == 0 {
+        var err error
+        if prefixes, err = e.WarmupPrefixes(ctx); err != nil {
+            return WarmupSummary{}, err
+        }
+    }
+
+    kinds := []string{KindSettlement, KindAddress}
+    jobs := make(chan Request)
+    var mu sync.Mutex
+    summary := WarmupSummary{Prefixes: len(prefixes)}
+    var wg sync.WaitGroup
+    for i := 0; i < warmupWorkers; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            for req := range jobs {
+                _, _, err := e.Suggest(ctx, req)
+                mu.Lock()
+                summary.Queries++
+                if err != nil {
+                    summary.Failed++
+                }
+                mu.Unlock()
+            }
+        }()
+    }
+    for _, prefix := range prefixes {
+        if strings.TrimSpace(prefix) == "" {
+            continue
+        }
+        for _, kind := range kinds {
+            select {
+            case jobs <- Request{Kind: kind, Query: prefix}:
+            case <-ctx.Done():
+            }
+        }
+    }
+    close(jobs)
+    wg.Wait()
+    summary.Duration = time.Since(start)
+    return summary, ctx.Err()
+}
+
+// WarmupPrefixes az index településneveinek egyedi, kisbetűs 1 és 2 karakteres előtagjait adja
+// vissza rendezve.
+func (e *Engine) WarmupPrefixes(ctx context.Context) ([]string, error) {
+    search := dsl.Search{Size: 0, Aggs: map[string]dsl.Agg{
+        "unique_values": dsl.Terms(dsl.TermsAgg{Field: "telepules.keyword", Size: warmupSettlementLimit}),
+    }}
+    var debugBuffer bytes.Buffer
+    settlements, err := e.executeSuggestionQuery(ctx, search, &debugBuffer)
+    if err != nil {
+        return nil, err
+    }
+    seen := map[string]bool{}
+    for _, settlement := range settlements {
+        runes := []rune(NormalizeQuery(settlement, false))
+        for n := 1; n <= 2 && n <= len(runes); n++ {
+            if prefix := strings.TrimSpace(string(runes[:n])); prefix != "" {
+                seen[prefix] = true
+            }
+        }
+    }
+    prefixes := make([]string, 0, len(seen))
+    for prefix := range seen {
+        prefixes = append(prefixes, prefix)
+    }
+    sort.Strings(prefixes)
+    return prefixes, nil
+}
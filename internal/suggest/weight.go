@@ -0,0 +1,52 @@
+package suggest
+
+import (
+    "math"
+
+    "autocomplete/internal/dsl"
+)
+
+// weightAgg a javaslat vödrének legnagyobb súlyát adó al-aggregáció neve.
+const weightAgg = "weight"
+
+// withWeights a terms aggregációhoz al-aggregációként hozzáadja a vödör dokumentumainak
+// legnagyobb "weight" értékét (a súly nélküliek 0-nak számítanak), amelyet a weights olvas ki.
+// Ha order igaz, a vödrök a dokumentumszám előtt a súly szerint rendeződnek; a népszerűség
+// szerinti rendezés (lásd withPopularity) ennél erősebb marad.
+func withWeights(unique dsl.Agg, order bool) dsl.Agg {
+    sub, _ := unique["aggs"].(map[string]dsl.Agg)
+    if sub == nil {
+        sub = map[string]dsl.Agg{}
+    }
+    sub[weightAgg] = dsl.Agg{"max": map[string]interface{}{"field": "weight", "missing": 0}}
+    unique["aggs"] = sub
+    if !order {
+        return unique
+    }
+    terms, _ := unique["terms"].(dsl.TermsAgg)
+    previous, _ := terms.Order.([]map[string]string)
+    var ordered []map[string]string
+    for _, o := range previous {
+        if _, count := o["_count"]; count {
+            continue
+        }
+        ordered = append(ordered, o)
+    }
+    terms.Order = append(ordered, map[string]string{weightAgg: "desc"}, map[string]string{"_count": "desc"})
+    unique["terms"] = terms
+    return unique
+}
+
+// weights a javaslatokhoz rendeli a vödrük súlyát; nil, ha egyik vödörnek sincs pozitív súlya.
+func weights(values dsl.AggResult) map[string]int {
+    var result map[string]int
+    for _, bucket := range values.Buckets {
+        if w := bucket.Sub[weightAgg].Value; w > 0 {
+            if result == nil {
+                result = map[string]int{}
+            }
+            result[bucket.Key] = int(math.Round(w))
+        }
+    }
+    return result
+}
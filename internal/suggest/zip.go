@@ -0,0 +1,52 @@
+package suggest
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+
+    "autocomplete/internal/dsl"
+)
+
+// IsZipPrefix jelzi, hogy a lekérdezés lehet-e irányítószám (eleje): legfeljebb 4 számjegy.
+func IsZipPrefix(query string) bool {
+    if query == "" || len(query) > 4 {
+        return false
+    }
+    for _, ch := range query {
+        if ch < '0' || ch > '9' {
+            return false
+        }
+    }
+    return true
+}
+
+// Zips a megadott számjegyekkel kezdődő irányítószámokat adja vissza növekvő sorrendben.
+func (e *Engine) Zips(ctx context.Context, query string) ([]string, string, error) {
+    var debugBuffer bytes.Buffer
+    debugBuffer.WriteString(fmt.Sprintf("Irányítószám keresés: %q\n", query))
+    aggQuery := dsl.Search{
+        Size:  0,
+        Query: dsl.Prefix("irsz", query),
+        Aggs: map[string]dsl.Agg{
+            "unique_values": dsl.Terms(dsl.TermsAgg{Field: "irsz", Size: e.Options().SuggestionLimit, Order: map[string]string{"_key": "asc"}}),
+        },
+    }
+    suggestions, err := e.executeSuggestionQuery(ctx, aggQuery, &debugBuffer)
+    return suggestions, debugBuffer.String(), err
+}
+
+// ZipSettlements az irányítószámhoz tartozó településneveket adja vissza.
+func (e *Engine) ZipSettlements(ctx context.Context, zip string) ([]string, string, error) {
+    var debugBuffer bytes.Buffer
+    debugBuffer.WriteString(fmt.Sprintf("Irányítószám feloldás: %q\n", zip))
+    aggQuery := dsl.Search{
+        Size:  0,
+        Query: dsl.Filter(dsl.Term("irsz", zip)),
+        Aggs: map[string]dsl.Agg{
+            "unique_values": dsl.Terms(dsl.TermsAgg{Field: "telepules.keyword", Size: e.Options().SuggestionLimit}),
+        },
+    }
+    settlements, err := e.executeSuggestionQuery(ctx, aggQuery, &debugBuffer)
+    return settlements, debugBuffer.String(), err
+}